@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/euforicio/wikimd/internal/config"
+	"github.com/euforicio/wikimd/internal/exporter"
+)
+
+// runDiff implements `wiki-export diff <old-manifest>`: it lists the pages
+// an export of the root would add, change, and remove compared with a
+// previous build, for release notes. The argument is that build's
+// manifest.json or its output directory.
+func runDiff(args []string) int {
+	cfg := config.Default()
+	config.ApplyEnvOverrides(&cfg)
+
+	flags := pflag.NewFlagSet("wiki-export diff", pflag.ExitOnError)
+	flags.StringVarP(&cfg.RootDir, "root", "r", cfg.RootDir, "root directory containing markdown files to export")
+	flags.StringSliceVar(&cfg.ExcludeDirs, "exclude-dir", cfg.ExcludeDirs, "directory name to leave out at any depth; repeat or comma-separate for several")
+	flags.StringVar(&cfg.IgnoreFile, "ignore-file", cfg.IgnoreFile, "file (relative to root) listing patterns to leave out of the export; empty disables it")
+	includeHidden := flags.Bool("hidden", false, "include hidden files when scanning the content tree")
+	format := flags.String("format", "text", "output format: text, json, or markdown")
+	baseURL := flags.String("base-url", "", "absolute base URL of the published site, for links in markdown output")
+	if err := flags.Parse(args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: wiki-export diff [flags] <old-manifest.json or output directory>")
+		return 2
+	}
+	if err := config.Finalize(&cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid configuration:", err)
+		return 2
+	}
+
+	manifestPath := flags.Arg(0)
+	if info, err := os.Stat(manifestPath); err == nil && info.IsDir() {
+		manifestPath = filepath.Join(manifestPath, exporter.ManifestFile)
+	}
+	old, err := exporter.ReadManifest(manifestPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "read manifest:", err)
+		return 2
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	exp, err := exporter.New(logger)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "init exporter:", err)
+		return 2
+	}
+	diff, err := exp.Diff(context.Background(), old, exporter.Options{
+		Root:          cfg.RootDir,
+		IncludeHidden: *includeHidden,
+		ExcludeDirs:   cfg.ExcludeDirs,
+		IgnoreFile:    cfg.IgnoreFile,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(diff)
+	case "markdown":
+		err = writeDiffMarkdown(os.Stdout, diff, strings.TrimRight(*baseURL, "/"))
+	case "text":
+		err = writeDiffText(os.Stdout, diff)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %q (allowed: text, json, markdown)\n", *format)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	return 0
+}
+
+func writeDiffText(w io.Writer, diff exporter.Diff) error {
+	if diff.Empty() {
+		_, err := fmt.Fprintln(w, "no pages changed")
+		return err
+	}
+	for _, section := range diffSections(diff) {
+		for _, page := range section.pages {
+			line := fmt.Sprintf("%-8s %s", section.verb, page.Path)
+			if page.Title != "" {
+				line += "  " + page.Title
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeDiffMarkdown writes a release-notes section per kind of change.
+// Added and changed pages link to the site; removed ones have no page left
+// to link to.
+func writeDiffMarkdown(w io.Writer, diff exporter.Diff, baseURL string) error {
+	if diff.Empty() {
+		_, err := fmt.Fprintln(w, "No pages changed.")
+		return err
+	}
+	first := true
+	for _, section := range diffSections(diff) {
+		if len(section.pages) == 0 {
+			continue
+		}
+		if !first {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		first = false
+		if _, err := fmt.Fprintf(w, "## %s\n\n", section.heading); err != nil {
+			return err
+		}
+		for _, page := range section.pages {
+			title := page.Title
+			if title == "" {
+				title = page.Path
+			}
+			line := fmt.Sprintf("- %s (`%s`)", title, page.Path)
+			if section.verb != "removed" {
+				href := page.URL
+				if baseURL != "" {
+					href = baseURL + "/" + href
+				}
+				line = fmt.Sprintf("- [%s](%s)", title, href)
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type diffSection struct {
+	verb    string
+	heading string
+	pages   []exporter.PageChange
+}
+
+func diffSections(diff exporter.Diff) []diffSection {
+	return []diffSection{
+		{verb: "added", heading: "Added", pages: diff.Added},
+		{verb: "changed", heading: "Changed", pages: diff.Changed},
+		{verb: "removed", heading: "Removed", pages: diff.Removed},
+	}
+}
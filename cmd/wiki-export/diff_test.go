@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/euforicio/wikimd/internal/exporter"
+)
+
+func TestWriteDiffMarkdown(t *testing.T) {
+	t.Parallel()
+	diff := exporter.Diff{
+		Added:   []exporter.PageChange{{Path: "guides/new.md", Title: "New Guide", URL: "guides/new.html"}},
+		Removed: []exporter.PageChange{{Path: "old.md", URL: "old.html"}},
+	}
+	var buf bytes.Buffer
+	if err := writeDiffMarkdown(&buf, diff, "https://docs.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	want := "## Added\n\n- [New Guide](https://docs.example.com/guides/new.html)\n\n## Removed\n\n- old.md (`old.md`)\n"
+	if buf.String() != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, buf.String())
+	}
+
+	buf.Reset()
+	if err := writeDiffText(&buf, exporter.Diff{}); err != nil || buf.String() != "no pages changed\n" {
+		t.Fatalf("expected the empty message, got %q, %v", buf.String(), err)
+	}
+}
@@ -3,17 +3,31 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
 
 	"github.com/spf13/pflag"
 
+	"github.com/euforicio/wikimd/internal/banner"
 	"github.com/euforicio/wikimd/internal/buildinfo"
 	"github.com/euforicio/wikimd/internal/config"
+	"github.com/euforicio/wikimd/internal/content"
 	"github.com/euforicio/wikimd/internal/exporter"
+	"github.com/euforicio/wikimd/internal/renderer"
+	d2renderer "github.com/euforicio/wikimd/internal/renderer/d2"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		os.Exit(runDiff(os.Args[2:]))
+	}
+
 	cfg := config.Default()
 	config.ApplyEnvOverrides(&cfg)
 
@@ -21,7 +35,10 @@ func main() {
 	flags.StringVarP(&cfg.RootDir, "root", "r", cfg.RootDir, "root directory containing markdown files to export")
 	flags.StringVar(&cfg.StaticOutput, "out", cfg.StaticOutput, "output directory for generated static site")
 	flags.StringVar(&cfg.AssetsDir, "assets", cfg.AssetsDir, "directory containing prepared static assets to copy")
+	assetsRequired := flags.Bool("assets-required", false, "fail instead of falling back to embedded assets when --assets is missing or incomplete")
 
+	flags.StringSliceVar(&cfg.ExcludeDirs, "exclude-dir", cfg.ExcludeDirs, "directory name to leave out at any depth; repeat or comma-separate for several")
+	flags.StringVar(&cfg.IgnoreFile, "ignore-file", cfg.IgnoreFile, "file (relative to root) listing patterns to leave out of the export; empty disables it")
 	includeHidden := flags.Bool("hidden", false, "include hidden files when scanning the content tree")
 	title := flags.String("title", "wikimd", "site title to use for exported pages")
 	darkMode := flags.Bool("dark", cfg.DarkModeFirst, "enable dark mode by default in the exported site")
@@ -30,6 +47,15 @@ func main() {
 	flags.BoolVar(&clean, "clean", true, "wipe the output directory before exporting")
 	assetPrefix := flags.String("asset-prefix", "assets", "relative directory name for copied assets within the export output")
 	baseURL := flags.String("base-url", "", "optional absolute base URL for canonical link tags")
+	singleFile := flags.Bool("single-file", false, "write one self-contained index.html with all pages, styles, scripts, and images inlined")
+	watch := flags.Bool("watch", false, "keep running and regenerate changed pages when the root changes")
+	optimize := flags.Bool("optimize", false, "inline critical CSS and defer non-critical stylesheets and scripts")
+	llmsText := flags.Bool("llms-txt", false, "write a markdown copy of each page and an llms.txt index of them for AI tools")
+	formats := flags.StringSlice("formats", []string{"html"}, "artifacts to write: html, plus pdf and epub for a book of the whole wiki from the same walk")
+	config.RegisterBannerFlags(flags, &cfg)
+	config.RegisterD2Flags(flags, &cfg)
+	config.RegisterShortcodeFlags(flags, &cfg)
+	config.RegisterExternalLinkFlags(flags, &cfg)
 
 	if err := flags.Parse(os.Args[1:]); err != nil {
 		slog.Error("flag parsing failed", slog.Any("err", err))
@@ -41,6 +67,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	books, err := bookFormats(*formats)
+	if err != nil {
+		slog.Error("invalid configuration", slog.Any("err", err))
+		os.Exit(1)
+	}
+
+	announcement, err := banner.New(cfg.Banner, cfg.BannerSeverity, cfg.BannerDismissible)
+	if err != nil {
+		slog.Error("invalid configuration", slog.Any("err", err))
+		os.Exit(1)
+	}
+
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
 	slog.SetDefault(logger)
 	logger.Info("starting wikimd-export", slog.String("version", buildinfo.Summary()))
@@ -50,28 +88,105 @@ func main() {
 		assetsOverride = cfg.AssetsDir
 	}
 
-	exp, err := exporter.New(logger)
+	d2renderer.SetConcurrency(cfg.DiagramConcurrency)
+	renderSvc, err := renderer.NewServiceWithOptions(logger, renderer.Options{
+		D2:            cfg.D2(),
+		Shortcodes:    cfg.ShortcodeOptions(),
+		ExternalLinks: cfg.ExternalLinks(),
+	})
+	if err != nil {
+		logger.Error("init renderer failed", slog.Any("err", err))
+		os.Exit(1)
+	}
+	exp, err := exporter.NewWithRenderer(logger, renderSvc)
 	if err != nil {
 		logger.Error("init exporter failed", slog.Any("err", err))
 		os.Exit(1)
 	}
 
-	ctx := context.Background()
-	if err := exp.Export(ctx, exporter.Options{
+	opts := exporter.Options{
 		Root:                cfg.RootDir,
 		OutputDir:           cfg.StaticOutput,
 		AssetsDir:           assetsOverride,
+		AssetsRequired:      *assetsRequired,
 		IncludeHidden:       *includeHidden,
+		ExcludeDirs:         cfg.ExcludeDirs,
+		IgnoreFile:          cfg.IgnoreFile,
 		SiteTitle:           *title,
 		DarkModeFirst:       *darkMode,
 		GenerateSearchIndex: *searchIndex,
 		CleanOutput:         clean,
 		AssetPrefix:         *assetPrefix,
 		BaseURL:             *baseURL,
-	}); err != nil {
+		Optimize:            *optimize,
+		SingleFile:          *singleFile,
+		Banner:              announcement,
+		LLMsText:            *llmsText,
+		Books:               books,
+	}
+
+	if *watch {
+		os.Exit(runWatch(logger, exp, renderSvc, opts))
+	}
+
+	if err := exp.Export(context.Background(), opts); err != nil {
 		logger.Error("export failed", slog.Any("err", err))
 		os.Exit(1)
 	}
 
 	logger.Info("export succeeded", slog.String("output", cfg.StaticOutput))
 }
+
+// bookFormats reads --formats. The HTML site is always written, so html
+// must be listed; the other formats become books.
+func bookFormats(formats []string) ([]exporter.Format, error) {
+	var books []exporter.Format
+	site := false
+	for _, name := range formats {
+		format := exporter.Format(strings.ToLower(strings.TrimSpace(name)))
+		switch {
+		case format == exporter.FormatHTML:
+			site = true
+		case slices.Contains(exporter.BookFormats(), format):
+			if !slices.Contains(books, format) {
+				books = append(books, format)
+			}
+		default:
+			return nil, fmt.Errorf("unknown format %q (allowed: html, pdf, epub)", name)
+		}
+	}
+	if !site {
+		return nil, errors.New("--formats must include html")
+	}
+	return books, nil
+}
+
+// runWatch exports once and then regenerates the output whenever the content
+// service reports a change, until interrupted.
+func runWatch(logger *slog.Logger, exp *exporter.Exporter, renderSvc *renderer.Service, opts exporter.Options) int {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	contentSvc, err := content.NewService(ctx, opts.Root, renderSvc, logger, content.Options{
+		IncludeHidden: opts.IncludeHidden,
+		ExcludeDirs:   opts.ExcludeDirs,
+		IgnoreFile:    opts.IgnoreFile,
+	})
+	if err != nil {
+		logger.Error("content service init failed", slog.Any("err", err))
+		return 1
+	}
+	defer func() {
+		if err := contentSvc.Close(); err != nil {
+			logger.Error("close content service", slog.Any("err", err))
+		}
+	}()
+
+	logger.Info("watching for changes", slog.String("root", opts.Root), slog.String("output", opts.OutputDir))
+	if err := exp.Watch(ctx, opts, contentSvc.Subscribe(ctx)); err != nil {
+		logger.Error("export failed", slog.Any("err", err))
+		return 1
+	}
+	logger.Info("watch stopped")
+	return 0
+}
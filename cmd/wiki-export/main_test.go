@@ -0,0 +1,24 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/euforicio/wikimd/internal/exporter"
+)
+
+func TestBookFormats(t *testing.T) {
+	t.Parallel()
+	books, err := bookFormats([]string{"html", "EPUB", " pdf", "epub"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []exporter.Format{exporter.FormatEPUB, exporter.FormatPDF}; !slices.Equal(books, want) {
+		t.Fatalf("expected %v, got %v", want, books)
+	}
+	for _, formats := range [][]string{{"pdf"}, {"html", "docx"}} {
+		if _, err := bookFormats(formats); err == nil {
+			t.Errorf("expected %v to be rejected", formats)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/euforicio/wikimd/internal/config"
+	"github.com/euforicio/wikimd/internal/exporter"
+)
+
+// exportPageArgs is the parsed command line of `wikimd export-page`.
+type exportPageArgs struct {
+	root      string
+	path      string
+	dest      string
+	format    exporter.Format
+	clipboard bool
+}
+
+// errExportPageUsage marks a malformed command line whose usage has already
+// been printed.
+var errExportPageUsage = errors.New("invalid export-page arguments")
+
+// runExportPage implements `wikimd export-page <path> [dest]`. The converted
+// page goes to dest, to stdout when dest is "-" or omitted, or to the system
+// clipboard with --clipboard.
+func runExportPage(args []string) int {
+	parsed, err := parseExportPageArgs(args)
+	if err != nil {
+		if !errors.Is(err, errExportPageUsage) && !errors.Is(err, pflag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return 2
+	}
+
+	// Keep stdout clean for the exported document; only errors are logged.
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	exp, err := exporter.New(logger)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "init exporter:", err)
+		return 1
+	}
+
+	var buf bytes.Buffer
+	ctx := context.Background()
+	if err := exp.ExportPage(ctx, exporter.ExportPageOptions{
+		RootDir: parsed.root,
+		Path:    parsed.path,
+		Format:  parsed.format,
+		Writer:  &buf,
+	}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if err := deliverPage(ctx, parsed, &buf, os.Stdout, copyToClipboard); err != nil {
+		fmt.Fprintln(os.Stderr, "write output:", err)
+		return 1
+	}
+	return 0
+}
+
+// parseExportPageArgs parses the export-page command line, applying the usual
+// environment overrides to the root directory.
+func parseExportPageArgs(args []string) (exportPageArgs, error) {
+	cfg := config.Default()
+	config.ApplyEnvOverrides(&cfg)
+
+	flags := pflag.NewFlagSet("wikimd export-page", pflag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: wikimd export-page <path> [dest|-] [flags]")
+		flags.PrintDefaults()
+	}
+	flags.StringVarP(&cfg.RootDir, "root", "r", cfg.RootDir, "root directory containing markdown files")
+	format := flags.StringP("format", "f", string(exporter.FormatHTML), "output format: html, pdf, markdown, or txt")
+	clipboard := flags.BoolP("clipboard", "c", false, "copy the output to the system clipboard instead of writing it")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return exportPageArgs{}, err
+		}
+		return exportPageArgs{}, fmt.Errorf("%w: %w", errExportPageUsage, err)
+	}
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		flags.Usage()
+		return exportPageArgs{}, errExportPageUsage
+	}
+	if err := config.Finalize(&cfg); err != nil {
+		return exportPageArgs{}, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	parsed := exportPageArgs{
+		root:      cfg.RootDir,
+		path:      flags.Arg(0),
+		dest:      "-",
+		format:    exporter.Format(strings.ToLower(strings.TrimSpace(*format))),
+		clipboard: *clipboard,
+	}
+	if parsed.clipboard && parsed.format == exporter.FormatPDF {
+		return exportPageArgs{}, errors.New("pdf output cannot be copied to the clipboard; write it to a file instead")
+	}
+	if flags.NArg() == 2 {
+		parsed.dest = flags.Arg(1)
+	}
+	return parsed, nil
+}
+
+// deliverPage sends the exported page to the clipboard, stdout, or the
+// destination file named in args.
+func deliverPage(ctx context.Context, args exportPageArgs, page io.Reader, stdout io.Writer, clip func(context.Context, io.Reader) error) error {
+	switch {
+	case args.clipboard:
+		return clip(ctx, page)
+	case args.dest == "-":
+		_, err := io.Copy(stdout, page)
+		return err
+	default:
+		data, err := io.ReadAll(page)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(args.dest, data, 0o644) //nolint:gosec // user-chosen output file
+	}
+}
+
+// copyToClipboard pipes r into the platform clipboard tool.
+func copyToClipboard(ctx context.Context, r io.Reader) error {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pbcopy"}}
+	case "windows":
+		candidates = [][]string{{"clip"}}
+	default:
+		candidates = [][]string{{"wl-copy"}, {"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}}
+	}
+	for _, argv := range candidates {
+		if _, err := exec.LookPath(argv[0]); err != nil {
+			continue
+		}
+		cmd := exec.CommandContext(ctx, argv[0], argv[1:]...) //nolint:gosec // fixed clipboard commands
+		cmd.Stdin = r
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+	return errors.New("no clipboard tool found (install wl-copy, xclip, or xsel)")
+}
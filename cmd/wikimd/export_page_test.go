@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/euforicio/wikimd/internal/exporter"
+)
+
+func TestParseExportPageArgs(t *testing.T) {
+	root := t.TempDir()
+
+	got, err := parseExportPageArgs([]string{"--root", root, "guides/intro.md"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got.path != "guides/intro.md" || got.dest != "-" || got.format != exporter.FormatHTML || got.clipboard {
+		t.Fatalf("unexpected defaults %+v", got)
+	}
+	if got.root != root {
+		t.Fatalf("expected root %q, got %q", root, got.root)
+	}
+
+	got, err = parseExportPageArgs([]string{"-r", root, "-f", " PDF ", "intro.md", "out.pdf"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got.format != exporter.FormatPDF || got.dest != "out.pdf" {
+		t.Fatalf("expected pdf to out.pdf, got %+v", got)
+	}
+
+	got, err = parseExportPageArgs([]string{"-r", root, "--clipboard", "-f", "markdown", "intro.md"})
+	if err != nil || !got.clipboard || got.format != exporter.FormatMarkdown {
+		t.Fatalf("expected markdown to the clipboard, got %+v, %v", got, err)
+	}
+
+	for _, args := range [][]string{
+		{"-r", root},
+		{"-r", root, "a.md", "b.html", "c.html"},
+		{"-r", root, "--no-such-flag", "a.md"},
+	} {
+		if _, err := parseExportPageArgs(args); !errors.Is(err, errExportPageUsage) {
+			t.Errorf("%q: expected usage error, got %v", args, err)
+		}
+	}
+
+	_, err = parseExportPageArgs([]string{"-r", root, "-c", "-f", "pdf", "intro.md"})
+	if err == nil || !strings.Contains(err.Error(), "clipboard") {
+		t.Fatalf("expected pdf to the clipboard to be rejected, got %v", err)
+	}
+}
+
+func TestDeliverPage(t *testing.T) {
+	ctx := context.Background()
+	noClipboard := func(context.Context, io.Reader) error {
+		t.Fatal("clipboard should not be used")
+		return nil
+	}
+
+	var stdout bytes.Buffer
+	if err := deliverPage(ctx, exportPageArgs{dest: "-"}, strings.NewReader("<p>hi</p>"), &stdout, noClipboard); err != nil {
+		t.Fatalf("stdout: %v", err)
+	}
+	if stdout.String() != "<p>hi</p>" {
+		t.Fatalf("expected page on stdout, got %q", stdout.String())
+	}
+
+	dest := filepath.Join(t.TempDir(), "page.html")
+	stdout.Reset()
+	if err := deliverPage(ctx, exportPageArgs{dest: dest}, strings.NewReader("<p>file</p>"), &stdout, noClipboard); err != nil {
+		t.Fatalf("file: %v", err)
+	}
+	data, err := os.ReadFile(dest)
+	if err != nil || string(data) != "<p>file</p>" {
+		t.Fatalf("expected page in %s, got %q, %v", dest, data, err)
+	}
+	if stdout.Len() != 0 {
+		t.Fatalf("expected nothing on stdout, got %q", stdout.String())
+	}
+
+	var clipped string
+	clip := func(_ context.Context, r io.Reader) error {
+		data, err := io.ReadAll(r)
+		clipped = string(data)
+		return err
+	}
+	if err := deliverPage(ctx, exportPageArgs{dest: dest, clipboard: true}, strings.NewReader("# Title"), &stdout, clip); err != nil {
+		t.Fatalf("clipboard: %v", err)
+	}
+	if clipped != "# Title" || stdout.Len() != 0 {
+		t.Fatalf("expected page on the clipboard only, got %q and stdout %q", clipped, stdout.String())
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// fuzzTarget names a Go fuzz test and the package holding it.
+type fuzzTarget struct {
+	Name string
+	Func string
+	Pkg  string
+	Desc string
+}
+
+// fuzzTargets are the content pipeline's fuzz tests, in the order
+// `wikimd fuzz` runs them.
+var fuzzTargets = []fuzzTarget{
+	{Name: "render", Func: "FuzzRender", Pkg: "./internal/renderer", Desc: "markdown rendering, frontmatter metadata, and diagrams"},
+	{Name: "links", Func: "FuzzRenderLinks", Pkg: "./internal/renderer", Desc: "link and image rewriting"},
+	{Name: "frontmatter", Func: "FuzzFrontmatter", Pkg: "./internal/frontmatter", Desc: "frontmatter editing"},
+	{Name: "shortcodes", Func: "FuzzRewrite", Pkg: "./internal/renderer/shortcode", Desc: "Hugo shortcode and Liquid tag rewriting"},
+	{Name: "paths", Func: "FuzzResolvePath", Pkg: "./internal/content", Desc: "wiki-relative path resolution"},
+}
+
+// runFuzz implements `wikimd fuzz [targets...]`, a development command that
+// runs the fuzz tests one after another with `go test -fuzz`. It needs the Go
+// toolchain and is run from the repository root.
+func runFuzz(args []string) int {
+	flags := pflag.NewFlagSet("wikimd fuzz", pflag.ExitOnError)
+	duration := flags.Duration("time", 30*time.Second, "how long to fuzz each target")
+	list := flags.Bool("list", false, "list the fuzz targets and exit")
+	if err := flags.Parse(args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if *list {
+		for _, target := range fuzzTargets {
+			fmt.Printf("%-12s %s\n", target.Name, target.Desc)
+		}
+		return 0
+	}
+	if *duration <= 0 {
+		fmt.Fprintln(os.Stderr, "--time must be positive")
+		return 2
+	}
+	targets, err := selectFuzzTargets(flags.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if _, err := os.Stat("go.mod"); err != nil {
+		fmt.Fprintln(os.Stderr, "wikimd fuzz runs from the repository root")
+		return 2
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	for _, target := range targets {
+		fmt.Printf("fuzzing %s (%s) for %s\n", target.Name, target.Func, *duration)
+		cmd := exec.CommandContext(ctx, "go", "test", "-run", "^$", //nolint:gosec // fixed arguments from fuzzTargets
+			"-fuzz", "^"+target.Func+"$", "-fuzztime", duration.String(), target.Pkg)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "fuzz %s failed: %v\n", target.Name, err)
+			fmt.Fprintf(os.Stderr, "failing inputs are saved under %s/testdata/fuzz/%s\n", target.Pkg, target.Func)
+			return 1
+		}
+	}
+	return 0
+}
+
+// selectFuzzTargets returns the targets named in names, or all of them when
+// names is empty.
+func selectFuzzTargets(names []string) ([]fuzzTarget, error) {
+	if len(names) == 0 {
+		return fuzzTargets, nil
+	}
+	selected := make([]fuzzTarget, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, target := range fuzzTargets {
+			if target.Name == name {
+				selected = append(selected, target)
+				found = true
+				break
+			}
+		}
+		if !found {
+			known := make([]string, len(fuzzTargets))
+			for i, target := range fuzzTargets {
+				known[i] = target.Name
+			}
+			return nil, fmt.Errorf("unknown fuzz target %q (allowed: %s)", name, strings.Join(known, ", "))
+		}
+	}
+	return selected, nil
+}
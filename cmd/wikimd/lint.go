@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"github.com/euforicio/wikimd/internal/config"
+	"github.com/euforicio/wikimd/internal/content/tree"
+	"github.com/euforicio/wikimd/internal/lint"
+)
+
+// runLint implements `wikimd lint [paths...]`. It exits 1 when any finding
+// has error severity so it can gate CI pipelines.
+func runLint(args []string) int {
+	cfg := config.Default()
+	config.ApplyEnvOverrides(&cfg)
+
+	flags := pflag.NewFlagSet("wikimd lint", pflag.ExitOnError)
+	flags.StringVarP(&cfg.RootDir, "root", "r", cfg.RootDir, "root directory containing markdown files")
+	format := flags.String("format", "text", "output format: text, json, or sarif")
+	pass := flags.String("pass", "all", "rule set to run: all, accessibility, or links")
+	includeHidden := flags.Bool("hidden", false, "include hidden files when scanning the content tree")
+	if err := flags.Parse(args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if err := config.Finalize(&cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid configuration:", err)
+		return 2
+	}
+
+	rules, ok := lint.Passes[*pass]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown pass %q (allowed: all, accessibility, links)\n", *pass)
+		return 2
+	}
+
+	ctx := context.Background()
+	lintCfg, err := lint.LoadConfig(cfg.RootDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	root, err := tree.Build(ctx, cfg.RootDir, tree.Options{
+		IncludeHidden: *includeHidden,
+		ExcludeDirs:   cfg.ExcludeDirs,
+		IgnoreFile:    cfg.IgnoreFile,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "build content tree:", err)
+		return 2
+	}
+	site, err := lint.LoadSite(ctx, cfg.RootDir, root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	engine := lint.NewEngine(lintCfg, rules()...)
+	report, err := engine.Lint(ctx, site, flags.Args()...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	case "sarif":
+		err = lint.WriteSARIF(os.Stdout, report, engine.Rules())
+	case "text":
+		err = writeLintText(os.Stdout, report)
+	default:
+		fmt.Fprintf(os.Stderr, "unsupported format %q (allowed: text, json, sarif)\n", *format)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "write report:", err)
+		return 2
+	}
+
+	if report.Errors > 0 {
+		return 1
+	}
+	return 0
+}
+
+func writeLintText(w io.Writer, report lint.Report) error {
+	for _, f := range report.Findings {
+		if _, err := fmt.Fprintf(w, "%s:%d: %s [%s] %s\n", f.Path, f.Line, f.Severity, f.Rule, f.Message); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d documents checked: %d errors, %d warnings\n", report.Documents, report.Errors, report.Warnings)
+	return err
+}
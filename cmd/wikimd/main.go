@@ -15,12 +15,30 @@ import (
 	"github.com/euforicio/wikimd/internal/buildinfo"
 	"github.com/euforicio/wikimd/internal/config"
 	"github.com/euforicio/wikimd/internal/content"
+	"github.com/euforicio/wikimd/internal/content/tree"
+	"github.com/euforicio/wikimd/internal/migrate"
 	"github.com/euforicio/wikimd/internal/renderer"
+	d2renderer "github.com/euforicio/wikimd/internal/renderer/d2"
 	"github.com/euforicio/wikimd/internal/search"
 	"github.com/euforicio/wikimd/internal/server"
 )
 
+// subcommands are dispatched on the first argument; anything else starts the server.
+var subcommands = map[string]func(args []string) int{
+	"lint":           runLint,
+	"export-page":    runExportPage,
+	"preview-export": runPreviewExport,
+	"fuzz":           runFuzz,
+	"migrate":        runMigrate,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(run(os.Args[2:]))
+		}
+	}
+
 	cfg := config.Default()
 	config.ApplyEnvOverrides(&cfg)
 
@@ -55,8 +73,34 @@ func main() {
 	ctx, cancel := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	rendererSvc := renderer.NewService(logger)
-	contentSvc, err := content.NewService(ctx, cfg.RootDir, rendererSvc, logger, content.Options{})
+	// Upgrade state left by an older release before anything reads it.
+	if _, err := migrate.Upgrade(cfg.RootDir, logger); err != nil {
+		cancel()
+		logger.Error("migrate wiki state failed; see `wikimd migrate --check`", slog.Any("err", err))
+		os.Exit(1)
+	}
+
+	d2renderer.SetConcurrency(cfg.DiagramConcurrency)
+	rendererSvc, err := renderer.NewServiceWithOptions(logger, renderer.Options{
+		D2:            cfg.D2(),
+		Shortcodes:    cfg.ShortcodeOptions(),
+		ExternalLinks: cfg.ExternalLinks(),
+	})
+	if err != nil {
+		cancel()
+		logger.Error("renderer init failed", slog.Any("err", err))
+		os.Exit(1)
+	}
+	contentSvc, err := content.NewService(ctx, cfg.RootDir, rendererSvc, logger, content.Options{
+		FrozenDirs:   cfg.FrozenDirs,
+		ExcludeDirs:  cfg.ExcludeDirs,
+		IgnoreFile:   cfg.IgnoreFile,
+		TreeSort:     &tree.Sort{By: cfg.TreeSort, DirsFirst: cfg.TreeDirsFirst},
+		TreeCache:    cfg.TreeCache,
+		PageVersions: cfg.PageVersions,
+		// Serve the UI while a large wiki is still being scanned.
+		BuildInBackground: true,
+	})
 	if err != nil {
 		cancel()
 		logger.Error("content service init failed", slog.Any("err", err))
@@ -69,7 +113,7 @@ func main() {
 		}
 	}()
 
-	searchSvc, err := search.NewService(cfg.RootDir, logger)
+	searchSvc, err := search.New(cfg.SearchBackend, cfg.RootDir, logger, cfg.Search())
 	if err != nil {
 		cancel()
 		logger.Error("search service init failed", slog.Any("err", err))
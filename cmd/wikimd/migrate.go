@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"github.com/euforicio/wikimd/internal/config"
+	"github.com/euforicio/wikimd/internal/migrate"
+)
+
+// runMigrate implements `wikimd migrate`, which upgrades the wiki's .wikimd
+// state to this release. The server does the same at startup; the command
+// lets the upgrade run ahead of time, or with --check, report what it would
+// do and exit 1 when anything is pending.
+func runMigrate(args []string) int {
+	cfg := config.Default()
+	config.ApplyEnvOverrides(&cfg)
+
+	flags := pflag.NewFlagSet("wikimd migrate", pflag.ExitOnError)
+	flags.StringVarP(&cfg.RootDir, "root", "r", cfg.RootDir, "root directory containing markdown files")
+	check := flags.Bool("check", false, "list pending migrations without applying them; exit 1 if any")
+	if err := flags.Parse(args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if err := config.Finalize(&cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid configuration:", err)
+		return 2
+	}
+	return migrateState(os.Stdout, os.Stderr, cfg.RootDir, *check)
+}
+
+func migrateState(stdout, stderr io.Writer, root string, check bool) int {
+	st, err := migrate.Check(root)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if len(st.Pending) == 0 {
+		fmt.Fprintf(stdout, "wiki state is up to date (version %d)\n", migrate.Latest())
+		return 0
+	}
+	if check {
+		fmt.Fprintf(stdout, "wiki state is at version %d; %d migration(s) pending:\n", st.Version, len(st.Pending))
+		for _, m := range st.Pending {
+			fmt.Fprintf(stdout, "  %d: %s\n", m.Version, m.Description)
+		}
+		return 1
+	}
+
+	applied, err := migrate.Upgrade(root, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	for _, m := range applied {
+		fmt.Fprintf(stdout, "applied %d: %s\n", m.Version, m.Description)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "wiki state upgraded to version %d\n", migrate.Latest())
+	return 0
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/euforicio/wikimd/internal/migrate"
+)
+
+func TestMigrateState(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, migrate.StateDir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := migrateState(&stdout, &stderr, root, true); code != 1 {
+		t.Fatalf("expected --check to exit 1 with migrations pending, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "pending") {
+		t.Errorf("expected the pending migrations listed, got %q", stdout.String())
+	}
+	if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(migrate.StateFile))); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected --check to leave the state alone, got %v", err)
+	}
+
+	stdout.Reset()
+	if code := migrateState(&stdout, &stderr, root, false); code != 0 {
+		t.Fatalf("expected the upgrade to succeed, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "applied 1:") {
+		t.Errorf("expected the applied migrations listed, got %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := migrateState(&stdout, &stderr, root, true); code != 0 || !strings.Contains(stdout.String(), "up to date") {
+		t.Fatalf("expected up-to-date state, got %d: %q", code, stdout.String())
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/euforicio/wikimd/internal/banner"
+	"github.com/euforicio/wikimd/internal/config"
+	"github.com/euforicio/wikimd/internal/exporter"
+	"github.com/euforicio/wikimd/internal/renderer"
+	d2renderer "github.com/euforicio/wikimd/internal/renderer/d2"
+)
+
+// previewMIMETypes are registered up front so the preview serves the same
+// Content-Types a static host would, regardless of the local mime database.
+var previewMIMETypes = map[string]string{
+	".css":   "text/css; charset=utf-8",
+	".html":  "text/html; charset=utf-8",
+	".js":    "text/javascript; charset=utf-8",
+	".json":  "application/json",
+	".map":   "application/json",
+	".svg":   "image/svg+xml",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+}
+
+// runPreviewExport implements `wikimd preview-export`: it exports the wiki to
+// a temporary directory and serves it exactly as written, with no SPA
+// fallback, so broken links show up as 404s before publishing.
+func runPreviewExport(args []string) int {
+	cfg := config.Default()
+	config.ApplyEnvOverrides(&cfg)
+
+	flags := pflag.NewFlagSet("wikimd preview-export", pflag.ExitOnError)
+	flags.StringVarP(&cfg.RootDir, "root", "r", cfg.RootDir, "root directory containing markdown files")
+	flags.IntVarP(&cfg.Port, "port", "p", cfg.Port, "port to serve the preview on (0 = auto-assign)")
+	flags.StringSliceVar(&cfg.ExcludeDirs, "exclude-dir", cfg.ExcludeDirs, "directory name to leave out at any depth; repeat or comma-separate for several")
+	flags.StringVar(&cfg.IgnoreFile, "ignore-file", cfg.IgnoreFile, "file (relative to root) listing patterns to leave out of the export; empty disables it")
+	title := flags.String("title", "wikimd", "site title to use for exported pages")
+	includeHidden := flags.Bool("hidden", false, "include hidden files when scanning the content tree")
+	searchIndex := flags.Bool("search-index", false, "generate the JSON search index alongside the export")
+	optimize := flags.Bool("optimize", false, "inline critical CSS and defer non-critical stylesheets and scripts")
+	singleFile := flags.Bool("single-file", false, "preview the single self-contained HTML export")
+	llmsText := flags.Bool("llms-txt", false, "also write markdown copies of the pages and an llms.txt index")
+	keep := flags.Bool("keep", false, "keep the temporary export directory after exiting")
+	config.RegisterBannerFlags(flags, &cfg)
+	config.RegisterD2Flags(flags, &cfg)
+	config.RegisterShortcodeFlags(flags, &cfg)
+	config.RegisterExternalLinkFlags(flags, &cfg)
+	if err := flags.Parse(args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if err := config.Finalize(&cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid configuration:", err)
+		return 2
+	}
+
+	announcement, err := banner.New(cfg.Banner, cfg.BannerSeverity, cfg.BannerDismissible)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid configuration:", err)
+		return 2
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	outDir, err := os.MkdirTemp("", "wikimd-preview-*")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "create temp dir:", err)
+		return 1
+	}
+	if *keep {
+		fmt.Fprintln(os.Stderr, "export kept at", outDir)
+	} else {
+		defer func() { _ = os.RemoveAll(outDir) }()
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	d2renderer.SetConcurrency(cfg.DiagramConcurrency)
+	renderSvc, err := renderer.NewServiceWithOptions(logger, renderer.Options{
+		D2:            cfg.D2(),
+		Shortcodes:    cfg.ShortcodeOptions(),
+		ExternalLinks: cfg.ExternalLinks(),
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "init renderer:", err)
+		return 1
+	}
+	exp, err := exporter.NewWithRenderer(logger, renderSvc)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "init exporter:", err)
+		return 1
+	}
+	if err := exp.Export(ctx, exporter.Options{
+		Root:                cfg.RootDir,
+		OutputDir:           outDir,
+		IncludeHidden:       *includeHidden,
+		ExcludeDirs:         cfg.ExcludeDirs,
+		IgnoreFile:          cfg.IgnoreFile,
+		SiteTitle:           *title,
+		DarkModeFirst:       cfg.DarkModeFirst,
+		GenerateSearchIndex: *searchIndex,
+		CleanOutput:         true,
+		Optimize:            *optimize,
+		SingleFile:          *singleFile,
+		Banner:              announcement,
+		LLMsText:            *llmsText,
+	}); err != nil {
+		fmt.Fprintln(os.Stderr, "export failed:", err)
+		return 1
+	}
+
+	for ext, typ := range previewMIMETypes {
+		_ = mime.AddExtensionType(ext, typ)
+	}
+
+	addr := "127.0.0.1:0"
+	if cfg.Port != 0 {
+		addr = fmt.Sprintf("127.0.0.1:%d", cfg.Port)
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "listen:", err)
+		return 1
+	}
+
+	srv := &http.Server{
+		Handler:           staticPreviewHandler(outDir),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, stop := context.WithTimeout(context.Background(), 5*time.Second)
+		defer stop()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(os.Stdout, "Previewing static export at http://%s (Ctrl+C to stop)\n", listener.Addr())
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintln(os.Stderr, "serve:", err)
+		return 1
+	}
+	return 0
+}
+
+// staticPreviewHandler serves dir like a plain static host: directories
+// resolve to their index.html, and there is no directory listing or
+// catch-all fallback.
+func staticPreviewHandler(dir string) http.Handler {
+	files := http.FileServer(http.Dir(dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := filepath.Join(dir, filepath.FromSlash(path.Clean("/"+r.URL.Path)))
+		info, err := os.Stat(target)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if info.IsDir() {
+			if _, err := os.Stat(filepath.Join(target, "index.html")); err != nil {
+				http.NotFound(w, r)
+				return
+			}
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStaticPreviewHandler(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, "site")
+	for rel, body := range map[string]string{
+		"index.html":        "home",
+		"intro.html":        "intro",
+		"guides/index.html": "guides",
+		"assets/app.css":    "body{}",
+	} {
+		abs := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(base, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	handler := staticPreviewHandler(dir)
+
+	cases := []struct {
+		path     string
+		status   int
+		body     string
+		location string
+	}{
+		{path: "/", status: http.StatusOK, body: "home"},
+		{path: "/intro.html", status: http.StatusOK, body: "intro"},
+		{path: "/guides/", status: http.StatusOK, body: "guides"},
+		{path: "/guides", status: http.StatusMovedPermanently, location: "guides/"},
+		{path: "/assets/app.css", status: http.StatusOK, body: "body{}"},
+		// No directory listings and no catch-all fallback to index.html.
+		{path: "/assets/", status: http.StatusNotFound},
+		{path: "/missing.html", status: http.StatusNotFound},
+		{path: "/intro", status: http.StatusNotFound},
+		// Requests cannot climb out of the export directory.
+		{path: "/../secret.txt", status: http.StatusNotFound},
+		{path: "/guides/../../secret.txt", status: http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "http://preview.test/", nil)
+		req.URL.Path = tc.path
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.path, tc.status, rec.Code)
+			continue
+		}
+		if tc.body != "" && rec.Body.String() != tc.body {
+			t.Errorf("%s: expected body %q, got %q", tc.path, tc.body, rec.Body.String())
+		}
+		if tc.location != "" && rec.Header().Get("Location") != tc.location {
+			t.Errorf("%s: expected redirect to %q, got %q", tc.path, tc.location, rec.Header().Get("Location"))
+		}
+		if strings.Contains(rec.Body.String(), "secret") {
+			t.Errorf("%s: served a file outside the export directory", tc.path)
+		}
+		if tc.status == http.StatusOK && rec.Header().Get("Cache-Control") != "no-store" {
+			t.Errorf("%s: expected Cache-Control no-store, got %q", tc.path, rec.Header().Get("Cache-Control"))
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Package banner renders the site-wide announcement shown above every page,
+// such as a maintenance notice or a migration warning.
+package banner
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Severities a banner can carry; they pick its colours and ARIA role.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Banner is a rendered announcement.
+type Banner struct {
+	HTML        template.HTML
+	Severity    string
+	Dismissible bool
+	// ID changes with the message, so a dismissed banner comes back when the
+	// announcement is edited.
+	ID string
+}
+
+// md renders the snippet without raw HTML: the banner text comes from
+// configuration and appears on every page, so it stays plain markdown.
+var md = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// New renders a markdown snippet into a banner. It returns nil when the
+// snippet is blank.
+func New(markdown, severity string, dismissible bool) (*Banner, error) {
+	markdown = strings.TrimSpace(markdown)
+	if markdown == "" {
+		return nil, nil
+	}
+	switch severity {
+	case "":
+		severity = SeverityInfo
+	case SeverityInfo, SeverityWarning, SeverityCritical:
+	default:
+		return nil, fmt.Errorf("invalid banner severity %q (want info, warning, or critical)", severity)
+	}
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(markdown), &buf); err != nil {
+		return nil, fmt.Errorf("render banner: %w", err)
+	}
+	sum := sha256.Sum256([]byte(severity + "\x00" + markdown))
+	return &Banner{
+		HTML:        template.HTML(buf.String()), //nolint:gosec // goldmark escapes raw HTML by default
+		Severity:    severity,
+		Dismissible: dismissible,
+		ID:          hex.EncodeToString(sum[:8]),
+	}, nil
+}
+
+// markup is shared by the live server and exported sites. Styles are inline
+// so the banner needs nothing from the theme's built stylesheet.
+var markup = template.Must(template.New("banner").Parse(`<style>
+.wikimd-banner{display:flex;align-items:flex-start;gap:.75rem;padding:.6rem 1rem;font-size:.875rem;line-height:1.4;border-bottom:1px solid}
+.wikimd-banner p{margin:0}
+.wikimd-banner a{color:inherit;text-decoration:underline}
+.wikimd-banner-body{flex:1;min-width:0}
+.wikimd-banner-close{background:none;border:0;color:inherit;cursor:pointer;font-size:1.1rem;line-height:1;padding:0 .25rem}
+.wikimd-banner-info{background:#0c4a6e;border-color:#0369a1;color:#e0f2fe}
+.wikimd-banner-warning{background:#78350f;border-color:#b45309;color:#fef3c7}
+.wikimd-banner-critical{background:#7f1d1d;border-color:#b91c1c;color:#fee2e2}
+</style>
+<div id="wikimd-banner" class="wikimd-banner wikimd-banner-{{ .Severity }}" role="{{ if eq .Severity "critical" }}alert{{ else }}status{{ end }}" data-banner-id="{{ .ID }}">
+  <div class="wikimd-banner-body">{{ .HTML }}</div>
+  {{- if .Dismissible }}
+  <button type="button" class="wikimd-banner-close" aria-label="Dismiss announcement">&times;</button>
+  {{- end }}
+</div>
+{{- if .Dismissible }}
+<script>
+(function () {
+  var el = document.getElementById("wikimd-banner");
+  var key = "wikimd-banner-dismissed";
+  try { if (localStorage.getItem(key) === el.dataset.bannerId) { el.hidden = true; } } catch (e) {}
+  el.querySelector("button").addEventListener("click", function () {
+    el.hidden = true;
+    try { localStorage.setItem(key, el.dataset.bannerId); } catch (e) {}
+  });
+})();
+</script>
+{{- end }}`))
+
+// Markup returns the banner's HTML, stylesheet, and dismissal script, ready
+// to place at the top of a page body. A nil banner renders nothing.
+func (b *Banner) Markup() (template.HTML, error) {
+	if b == nil {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	if err := markup.Execute(&buf, b); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil //nolint:gosec // produced by html/template
+}
@@ -0,0 +1,70 @@
+package banner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	if b, err := New("  \n", SeverityInfo, true); err != nil || b != nil {
+		t.Fatalf("blank snippet: got %v, %v; want nil, nil", b, err)
+	}
+	if _, err := New("Down tonight", "urgent", true); err == nil {
+		t.Fatalf("expected error for unknown severity")
+	}
+
+	b, err := New("Read-only until *Friday*. <script>alert(1)</script>", "", false)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if b.Severity != SeverityInfo {
+		t.Fatalf("severity = %q, want info by default", b.Severity)
+	}
+	if !strings.Contains(string(b.HTML), "<em>Friday</em>") {
+		t.Fatalf("expected rendered markdown, got %q", b.HTML)
+	}
+	if strings.Contains(string(b.HTML), "<script>") {
+		t.Fatalf("expected raw HTML to be dropped, got %q", b.HTML)
+	}
+
+	other, err := New("Read-only until *Monday*.", "", false)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if other.ID == b.ID {
+		t.Fatalf("expected a different ID for different text")
+	}
+}
+
+func TestMarkup(t *testing.T) {
+	t.Parallel()
+
+	var none *Banner
+	if html, err := none.Markup(); err != nil || html != "" {
+		t.Fatalf("nil banner: got %q, %v", html, err)
+	}
+
+	b, err := New("Maintenance at 18:00 UTC", SeverityCritical, false)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	html, err := b.Markup()
+	if err != nil {
+		t.Fatalf("Markup: %v", err)
+	}
+	for _, want := range []string{`class="wikimd-banner wikimd-banner-critical"`, `role="alert"`, `data-banner-id="` + b.ID + `"`} {
+		if !strings.Contains(string(html), want) {
+			t.Fatalf("expected %q in %q", want, html)
+		}
+	}
+	if strings.Contains(string(html), "<button") {
+		t.Fatalf("expected no dismiss button on a non-dismissible banner")
+	}
+
+	b.Dismissible = true
+	if html, _ = b.Markup(); !strings.Contains(string(html), "localStorage") {
+		t.Fatalf("expected dismissal script, got %q", html)
+	}
+}
@@ -0,0 +1,111 @@
+// Package board groups pages into kanban columns by their status
+// frontmatter.
+package board
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+)
+
+// Field is the frontmatter key that places a page in a column.
+const Field = "status"
+
+// Card is a page on the board.
+type Card struct {
+	Modified time.Time `json:"modified"`
+	Path     string    `json:"path"`
+	Title    string    `json:"title"`
+	Tags     []string  `json:"tags,omitempty"`
+}
+
+// Column holds the pages sharing one status.
+type Column struct {
+	Name  string `json:"name"`
+	Cards []Card `json:"cards"`
+}
+
+// Board is the full set of columns.
+type Board struct {
+	Field   string   `json:"field"`
+	Columns []Column `json:"columns"`
+	Count   int      `json:"count"`
+}
+
+// Build walks the pages under dir ("" for the whole wiki) and groups those
+// with a status. Columns named in order come first, in that order and even
+// when empty; other statuses follow alphabetically. Cards keep tree order.
+func Build(root *tree.Node, dir string, order []string) Board {
+	dir = strings.Trim(dir, "/")
+	byStatus := make(map[string][]Card)
+	count := 0
+	var walk func(*tree.Node)
+	walk = func(n *tree.Node) {
+		if n == nil {
+			return
+		}
+		if n.Type == tree.NodeTypeFile && n.Metadata != nil && inDir(n.RelativePath, dir) {
+			if status := Status(n.Metadata.Raw); status != "" {
+				byStatus[status] = append(byStatus[status], Card{
+					Modified: n.Modified,
+					Path:     n.RelativePath,
+					Title:    n.Title,
+					Tags:     n.Metadata.Tags,
+				})
+				count++
+			}
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	b := Board{Field: Field, Columns: []Column{}, Count: count}
+	listed := make(map[string]bool)
+	for _, name := range order {
+		name = strings.TrimSpace(name)
+		if name == "" || listed[name] {
+			continue
+		}
+		listed[name] = true
+		b.Columns = append(b.Columns, column(name, byStatus[name]))
+	}
+	var rest []string
+	for name := range byStatus {
+		if !listed[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	for _, name := range rest {
+		b.Columns = append(b.Columns, column(name, byStatus[name]))
+	}
+	return b
+}
+
+// Status returns the page's status from raw frontmatter, or "".
+func Status(raw map[string]any) string {
+	switch v := raw[Field].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case bool, int, float64:
+		// YAML reads bare yes/no and numbers as non-strings.
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+func column(name string, cards []Card) Column {
+	if cards == nil {
+		cards = []Card{}
+	}
+	return Column{Name: name, Cards: cards}
+}
+
+func inDir(path, dir string) bool {
+	return dir == "" || strings.HasPrefix(path, dir+"/")
+}
@@ -0,0 +1,70 @@
+package board
+
+import (
+	"testing"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+	"github.com/euforicio/wikimd/internal/renderer"
+)
+
+func page(path string, status any) *tree.Node {
+	return &tree.Node{
+		Type:         tree.NodeTypeFile,
+		RelativePath: path,
+		Title:        path,
+		Metadata:     &renderer.Metadata{Raw: map[string]any{"status": status}},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	t.Parallel()
+
+	root := &tree.Node{
+		Type: tree.NodeTypeDirectory,
+		Children: []*tree.Node{
+			page("ideas.md", "backlog"),
+			{Type: tree.NodeTypeFile, RelativePath: "plain.md"},
+			{
+				Type: tree.NodeTypeDirectory,
+				Children: []*tree.Node{
+					page("project/api.md", "doing"),
+					page("project/docs.md", " done "),
+					page("project/ui.md", "doing"),
+				},
+			},
+		},
+	}
+
+	b := Build(root, "", []string{"todo", "doing", "done"})
+	if b.Field != "status" || b.Count != 4 {
+		t.Fatalf("unexpected board %+v", b)
+	}
+	var names []string
+	for _, c := range b.Columns {
+		names = append(names, c.Name)
+	}
+	if want := []string{"todo", "doing", "done", "backlog"}; len(names) != len(want) || names[0] != want[0] || names[1] != want[1] || names[2] != want[2] || names[3] != want[3] {
+		t.Fatalf("expected columns %v, got %v", want, names)
+	}
+	if len(b.Columns[0].Cards) != 0 {
+		t.Fatalf("expected an empty todo column, got %+v", b.Columns[0])
+	}
+	if doing := b.Columns[1].Cards; len(doing) != 2 || doing[0].Path != "project/api.md" || doing[1].Path != "project/ui.md" {
+		t.Fatalf("expected doing cards in tree order, got %+v", doing)
+	}
+
+	scoped := Build(root, "project/", nil)
+	if scoped.Count != 3 || len(scoped.Columns) != 2 || scoped.Columns[0].Name != "doing" {
+		t.Fatalf("expected only the project folder, got %+v", scoped)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+	cases := map[string]any{"done": "done", "true": true, "3": 3, "": nil}
+	for want, raw := range cases {
+		if got := Status(map[string]any{"status": raw}); got != want {
+			t.Errorf("Status(%v) = %q, want %q", raw, got, want)
+		}
+	}
+}
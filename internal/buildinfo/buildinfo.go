@@ -8,7 +8,8 @@ var (
 	Date    = ""
 )
 
-// Summary returns a human-readable version summary string.
+// Summary returns a human-readable version summary string, including the
+// bundled Mermaid release.
 func Summary() string {
 	version := Version
 	if version == "" {
@@ -24,5 +25,8 @@ func Summary() string {
 	} else if Date != "" {
 		parts += " (" + Date + ")"
 	}
+	if MermaidVersion != "" {
+		parts += "; mermaid " + MermaidVersion
+	}
 	return parts
 }
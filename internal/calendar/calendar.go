@@ -0,0 +1,266 @@
+// Package calendar turns dated frontmatter into an iCalendar feed.
+//
+// A page becomes an event when its frontmatter has an event or date key.
+// Either may be a date or date-time; event may also be a mapping with start,
+// end, title, and location:
+//
+//	event:
+//	  start: 2025-07-01T15:00:00Z
+//	  end: 2025-07-01T16:00:00Z
+//	  location: Room 4
+//
+// Date-only values are all-day events. Date-times without a zone are floating
+// times, shown at that clock time in every calendar's zone.
+package calendar
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+)
+
+// DefaultDuration is the length of a timed event that gives no end.
+const DefaultDuration = time.Hour
+
+// Event is one calendar entry derived from a page.
+type Event struct {
+	Start       time.Time
+	End         time.Time
+	Modified    time.Time
+	Path        string
+	Title       string
+	Location    string
+	Description string
+	AllDay      bool
+	Floating    bool
+}
+
+// dateLayouts are the accepted spellings of frontmatter dates, matching the
+// reviewBy key. The zone-less layouts yield floating times.
+var dateLayouts = []struct {
+	layout   string
+	allDay   bool
+	floating bool
+}{
+	{layout: "2006-01-02", allDay: true},
+	{layout: time.RFC3339},
+	{layout: "2006-01-02 15:04", floating: true},
+	{layout: "2006-01-02 15:04:05", floating: true},
+	{layout: "2006-01-02T15:04", floating: true},
+	{layout: "2006-01-02T15:04:05", floating: true},
+}
+
+// moment is a parsed frontmatter date.
+type moment struct {
+	t        time.Time
+	allDay   bool
+	floating bool
+}
+
+func parseMoment(v any) (moment, bool) {
+	switch val := v.(type) {
+	case time.Time:
+		allDay := val.Hour() == 0 && val.Minute() == 0 && val.Second() == 0 && val.Nanosecond() == 0
+		return moment{t: val, allDay: allDay}, true
+	case string:
+		s := strings.TrimSpace(val)
+		for _, l := range dateLayouts {
+			if t, err := time.Parse(l.layout, s); err == nil {
+				return moment{t: t, allDay: l.allDay, floating: l.floating}, true
+			}
+		}
+	}
+	return moment{}, false
+}
+
+// FromMetadata reads the event in raw frontmatter. title is used when the
+// event names none.
+func FromMetadata(raw map[string]any, title string) (Event, bool) {
+	ev := Event{Title: title}
+	var start, end moment
+	var ok bool
+	switch val := raw["event"].(type) {
+	case nil:
+		start, ok = parseMoment(raw["date"])
+	case map[any]any, map[string]any:
+		fields := stringKeys(val)
+		start, ok = parseMoment(fields["start"])
+		if !ok {
+			start, ok = parseMoment(fields["date"])
+		}
+		end, _ = parseMoment(fields["end"])
+		for _, key := range []string{"title", "summary"} {
+			if s, isString := fields[key].(string); isString && strings.TrimSpace(s) != "" {
+				ev.Title = strings.TrimSpace(s)
+				break
+			}
+		}
+		if s, isString := fields["location"].(string); isString {
+			ev.Location = strings.TrimSpace(s)
+		}
+	default:
+		start, ok = parseMoment(val)
+	}
+	if !ok {
+		return Event{}, false
+	}
+
+	ev.Start, ev.AllDay, ev.Floating = start.t, start.allDay, start.floating
+	switch {
+	case !end.t.IsZero() && end.allDay == start.allDay && !end.t.Before(start.t):
+		ev.End = end.t
+		if ev.AllDay {
+			// iCalendar all-day ends are exclusive; frontmatter ends are the
+			// last day of the event.
+			ev.End = ev.End.AddDate(0, 0, 1)
+		}
+	case ev.AllDay:
+		ev.End = ev.Start.AddDate(0, 0, 1)
+	default:
+		ev.End = ev.Start.Add(DefaultDuration)
+	}
+	return ev, true
+}
+
+// stringKeys normalizes a YAML mapping, which decodes with interface keys.
+func stringKeys(v any) map[string]any {
+	if m, ok := v.(map[string]any); ok {
+		return m
+	}
+	fields := make(map[string]any)
+	for k, val := range v.(map[any]any) {
+		if key, ok := k.(string); ok {
+			fields[key] = val
+		}
+	}
+	return fields
+}
+
+// Collect walks root and returns the events of every dated page, earliest
+// first.
+func Collect(root *tree.Node) []Event {
+	var events []Event
+	var walk func(*tree.Node)
+	walk = func(n *tree.Node) {
+		if n == nil {
+			return
+		}
+		if n.Type == tree.NodeTypeFile && n.Metadata != nil {
+			if ev, ok := FromMetadata(n.Metadata.Raw, n.Title); ok {
+				ev.Path = n.RelativePath
+				ev.Modified = n.Modified
+				ev.Description = n.Metadata.Description
+				events = append(events, ev)
+			}
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].Start.Equal(events[j].Start) {
+			return events[i].Start.Before(events[j].Start)
+		}
+		return events[i].Path < events[j].Path
+	})
+	return events
+}
+
+// Feed describes the calendar as a whole.
+type Feed struct {
+	// Name is shown by calendar apps as the subscription's title.
+	Name string
+	// PageURL returns the link for an event's page, or "" for none.
+	PageURL func(path string) string
+}
+
+// Write renders events as an iCalendar (RFC 5545) document.
+func Write(w io.Writer, feed Feed, events []Event) error {
+	cw := &contentWriter{w: w}
+	cw.line("BEGIN:VCALENDAR")
+	cw.line("VERSION:2.0")
+	cw.line("PRODID:-//wikimd//calendar//EN")
+	cw.line("CALSCALE:GREGORIAN")
+	if feed.Name != "" {
+		cw.line("X-WR-CALNAME:" + escape(feed.Name))
+	}
+	for _, ev := range events {
+		cw.line("BEGIN:VEVENT")
+		cw.line("UID:" + escape(ev.Path) + "@wikimd")
+		stamp := ev.Modified
+		if stamp.IsZero() {
+			stamp = time.Unix(0, 0)
+		}
+		cw.line("DTSTAMP:" + stamp.UTC().Format("20060102T150405Z"))
+		cw.line("DTSTART" + formatTime(ev.Start, ev.AllDay, ev.Floating))
+		cw.line("DTEND" + formatTime(ev.End, ev.AllDay, ev.Floating))
+		cw.line("SUMMARY:" + escape(ev.Title))
+		if ev.Location != "" {
+			cw.line("LOCATION:" + escape(ev.Location))
+		}
+		if ev.Description != "" {
+			cw.line("DESCRIPTION:" + escape(ev.Description))
+		}
+		if feed.PageURL != nil {
+			if u := feed.PageURL(ev.Path); u != "" {
+				cw.line("URL:" + u)
+			}
+		}
+		cw.line("END:VEVENT")
+	}
+	cw.line("END:VCALENDAR")
+	return cw.err
+}
+
+// formatTime renders a DTSTART or DTEND value, including its parameters.
+func formatTime(t time.Time, allDay, floating bool) string {
+	switch {
+	case allDay:
+		return ";VALUE=DATE:" + t.Format("20060102")
+	case floating:
+		return ":" + t.Format("20060102T150405")
+	default:
+		return ":" + t.UTC().Format("20060102T150405Z")
+	}
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escape(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// contentWriter writes CRLF-terminated content lines folded at 75 octets, as
+// RFC 5545 requires. The first error sticks.
+type contentWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (cw *contentWriter) line(s string) {
+	if cw.err != nil {
+		return
+	}
+	var b strings.Builder
+	width := 75
+	for len(s) > width {
+		cut := width
+		// Never split a UTF-8 sequence across lines.
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		width = 74 // continuation lines start with a space
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+	_, cw.err = fmt.Fprint(cw.w, b.String())
+}
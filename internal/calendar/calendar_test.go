@@ -0,0 +1,123 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+	"github.com/euforicio/wikimd/internal/renderer"
+)
+
+func page(path string, raw map[string]any) *tree.Node {
+	return &tree.Node{
+		Type:         tree.NodeTypeFile,
+		RelativePath: path,
+		Title:        strings.TrimSuffix(path, ".md"),
+		Metadata:     &renderer.Metadata{Raw: raw},
+	}
+}
+
+func TestFromMetadata(t *testing.T) {
+	t.Parallel()
+
+	ev, ok := FromMetadata(map[string]any{"date": "2025-07-01"}, "Release")
+	if !ok || !ev.AllDay || ev.Title != "Release" {
+		t.Fatalf("expected an all-day event, got %+v", ev)
+	}
+	if want := time.Date(2025, 7, 2, 0, 0, 0, 0, time.UTC); !ev.End.Equal(want) {
+		t.Fatalf("expected the exclusive end %v, got %v", want, ev.End)
+	}
+
+	ev, ok = FromMetadata(map[string]any{"event": "2025-07-01T15:00:00+02:00"}, "Sync")
+	if !ok || ev.AllDay || ev.Floating || ev.End.Sub(ev.Start) != DefaultDuration {
+		t.Fatalf("expected a one-hour timed event, got %+v", ev)
+	}
+
+	ev, ok = FromMetadata(map[string]any{
+		"date": "2020-01-01",
+		"event": map[any]any{
+			"start":    "2025-07-01 09:30",
+			"end":      "2025-07-01 11:00",
+			"title":    "Planning",
+			"location": "Room 4",
+		},
+	}, "Notes")
+	if !ok || !ev.Floating || ev.Title != "Planning" || ev.Location != "Room 4" {
+		t.Fatalf("expected the event mapping to win, got %+v", ev)
+	}
+	if ev.End.Sub(ev.Start) != 90*time.Minute {
+		t.Fatalf("expected a 90 minute event, got %v", ev.End.Sub(ev.Start))
+	}
+
+	if _, ok := FromMetadata(map[string]any{"date": "someday"}, "x"); ok {
+		t.Fatal("expected an unparseable date to be ignored")
+	}
+	if _, ok := FromMetadata(map[string]any{"title": "Plain"}, "x"); ok {
+		t.Fatal("expected an undated page to be ignored")
+	}
+}
+
+func TestCollectAndWrite(t *testing.T) {
+	t.Parallel()
+
+	root := &tree.Node{
+		Type: tree.NodeTypeDirectory,
+		Children: []*tree.Node{
+			page("launch.md", map[string]any{"date": "2025-09-01"}),
+			{Type: tree.NodeTypeFile, RelativePath: "plain.md"},
+			{
+				Type: tree.NodeTypeDirectory,
+				Children: []*tree.Node{
+					page("meetings/kickoff.md", map[string]any{"event": map[any]any{
+						"start":    "2025-08-01T09:00:00Z",
+						"location": "Lab, 2nd floor; east wing",
+					}}),
+				},
+			},
+		},
+	}
+
+	events := Collect(root)
+	if len(events) != 2 || events[0].Path != "meetings/kickoff.md" || events[1].Path != "launch.md" {
+		t.Fatalf("expected two events, earliest first, got %+v", events)
+	}
+
+	var b strings.Builder
+	feed := Feed{Name: "Team wiki", PageURL: func(path string) string { return "https://wiki.example/page/" + path }}
+	if err := Write(&b, feed, events); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"X-WR-CALNAME:Team wiki\r\n",
+		"UID:meetings/kickoff.md@wikimd\r\n",
+		"DTSTART:20250801T090000Z\r\nDTEND:20250801T100000Z\r\n",
+		`LOCATION:Lab\, 2nd floor\; east wing` + "\r\n",
+		"DTSTART;VALUE=DATE:20250901\r\nDTEND;VALUE=DATE:20250902\r\n",
+		"URL:https://wiki.example/page/launch.md\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected feed to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestLongLinesAreFolded(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+	cw := &contentWriter{w: &b}
+	cw.line("SUMMARY:" + strings.Repeat("é", 60))
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Fatalf("line exceeds 75 octets: %q", line)
+		}
+	}
+	unfolded := strings.ReplaceAll(b.String(), "\r\n ", "")
+	if unfolded != "SUMMARY:"+strings.Repeat("é", 60)+"\r\n" {
+		t.Fatalf("folding corrupted the text: %q", unfolded)
+	}
+}
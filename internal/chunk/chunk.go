@@ -0,0 +1,287 @@
+// Package chunk splits markdown pages into pieces small enough to embed or
+// index on their own. Chunks never cross a heading, carry the trail of
+// headings above them, and keep the source line range they came from, so
+// external search pipelines can cite and link back to the exact section.
+package chunk
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	goldmarkmeta "github.com/yuin/goldmark-meta"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+
+	"github.com/euforicio/wikimd/internal/frontmatter"
+	"github.com/euforicio/wikimd/internal/renderer/headingid"
+)
+
+// DefaultMaxTokens is the chunk budget used when callers pass none.
+const DefaultMaxTokens = 500
+
+// Chunk is a run of whole source lines under one heading.
+//
+//nolint:govet // field order matches the JSON documented in the README
+type Chunk struct {
+	// ID is the page path, then "#" and the section anchor, then "~2",
+	// "~3", … for later parts of a long section. Text before the first
+	// heading has no anchor. IDs depend only on the page path and its
+	// headings, so edits elsewhere in a page leave them unchanged.
+	ID string `json:"id"`
+	// Anchor is the section's heading ID, as rendered on the page.
+	Anchor string `json:"anchor,omitempty"`
+	// Headings is the trail of headings above the chunk, outermost first.
+	Headings []string `json:"headings,omitempty"`
+	// Text is the markdown source of StartLine through EndLine.
+	Text      string `json:"text"`
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+	// Tokens estimates the size of Text at four characters per token.
+	Tokens int `json:"tokens"`
+}
+
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM, goldmarkmeta.Meta),
+	goldmark.WithParserOptions(
+		parser.WithAutoHeadingID(),
+		parser.WithAttribute(),
+	),
+)
+
+// heading is a top-level heading. line and end are the 0-based first and
+// last lines it spans; a setext heading ends on its underline.
+type heading struct {
+	text  string
+	id    string
+	level int
+	line  int
+	end   int
+}
+
+// Split chunks the markdown source of the page at rel. Paragraphs, lists,
+// and code blocks are kept whole when they fit in maxTokens; larger ones
+// are split between lines. A chunk exceeds maxTokens only when a single
+// line does. Frontmatter is left out, and line numbers count from 1 in
+// source.
+func Split(rel string, source []byte, maxTokens int) []Chunk {
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxTokens
+	}
+	lines := strings.Split(string(source), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	var out []Chunk
+	var trail []heading
+	section := heading{line: bodyStart(source)}
+	for _, next := range append(headings(source), heading{line: len(lines)}) {
+		out = append(out, pack(rel, section, trail, lines[section.line:next.line], maxTokens)...)
+		for len(trail) > 0 && trail[len(trail)-1].level >= next.level {
+			trail = trail[:len(trail)-1]
+		}
+		trail = append(trail, next)
+		section = next
+	}
+	return out
+}
+
+// pack groups the blocks of one section into chunks of at most maxTokens.
+// section.line is the 0-based line where lines begin. A heading with nothing
+// under it before the next heading yields no chunk.
+func pack(rel string, section heading, trail []heading, lines []string, maxTokens int) []Chunk {
+	bs := blocks(lines)
+	if len(bs) == 0 || (section.text != "" && len(bs) == 1 && bs[0][1] <= section.end-section.line) {
+		return nil
+	}
+	var titles []string
+	for _, h := range trail {
+		titles = append(titles, h.text)
+	}
+	base := rel
+	if section.id != "" {
+		base += "#" + section.id
+	}
+
+	var out []Chunk
+	start, end := -1, -1 // current chunk, as indexes into lines
+	flush := func() {
+		if start < 0 {
+			return
+		}
+		body := strings.Join(lines[start:end+1], "\n")
+		id := base
+		if len(out) > 0 {
+			id += "~" + strconv.Itoa(len(out)+1)
+		}
+		out = append(out, Chunk{
+			ID:        id,
+			Anchor:    section.id,
+			Headings:  titles,
+			Text:      body,
+			StartLine: section.line + start + 1,
+			EndLine:   section.line + end + 1,
+			Tokens:    Tokens(body),
+		})
+		start, end = -1, -1
+	}
+	add := func(from, to int) {
+		if start >= 0 && Tokens(strings.Join(lines[start:to+1], "\n")) > maxTokens {
+			flush()
+		}
+		if start < 0 {
+			start = from
+		}
+		end = to
+	}
+
+	for _, b := range bs {
+		if Tokens(strings.Join(lines[b[0]:b[1]+1], "\n")) <= maxTokens {
+			add(b[0], b[1])
+			continue
+		}
+		for i := b[0]; i <= b[1]; i++ {
+			add(i, i)
+		}
+	}
+	flush()
+	return out
+}
+
+// blocks returns the [first, last] line indexes of each run of non-blank
+// lines. Fenced code is one block even when it holds blank lines.
+func blocks(lines []string) [][2]int {
+	var out [][2]int
+	first, fence := -1, ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			fence = "```"
+		case strings.HasPrefix(trimmed, "~~~"):
+			fence = "~~~"
+		}
+		if trimmed == "" {
+			if first >= 0 {
+				out = append(out, [2]int{first, i - 1})
+				first = -1
+			}
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+	}
+	if first >= 0 {
+		last := len(lines) - 1
+		for strings.TrimSpace(lines[last]) == "" {
+			last--
+		}
+		out = append(out, [2]int{first, last})
+	}
+	return out
+}
+
+// headings returns the top-level headings of source with the IDs the
+// renderer gives them. Headings inside lists and quotes do not start a
+// section.
+func headings(source []byte) []heading {
+	body := source
+	if _, blanked, ok, err := frontmatter.Decode(source); ok && err == nil {
+		body = blanked
+	}
+	lineStarts := []int{0}
+	for i, b := range body {
+		if b == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	lineOf := func(offset int) int {
+		return sort.Search(len(lineStarts), func(i int) bool { return lineStarts[i] > offset }) - 1
+	}
+
+	pc := parser.NewContext(parser.WithIDs(headingid.New(body)))
+	root := markdown.Parser().Parse(text.NewReader(body), parser.WithContext(pc))
+	var out []heading
+	for n := root.FirstChild(); n != nil; n = n.NextSibling() {
+		h, ok := n.(*ast.Heading)
+		if !ok || h.Lines().Len() == 0 {
+			continue
+		}
+		line := lineOf(h.Lines().At(0).Start)
+		end := lineOf(h.Lines().At(h.Lines().Len() - 1).Start)
+		if !bytes.HasPrefix(bytes.TrimLeft(body[lineStarts[line]:], " "), []byte("#")) {
+			end++ // setext underline
+		}
+		var id string
+		if v, ok := h.AttributeString("id"); ok {
+			if b, ok := v.([]byte); ok {
+				id = string(b)
+			}
+		}
+		out = append(out, heading{
+			text:  strings.TrimSpace(string(headingText(h, body))),
+			id:    id,
+			level: h.Level,
+			line:  line,
+			end:   end,
+		})
+	}
+	return out
+}
+
+func headingText(n ast.Node, source []byte) []byte {
+	var buf bytes.Buffer
+	_ = ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := child.(type) {
+		case *ast.Text:
+			buf.Write(t.Segment.Value(source))
+			if t.SoftLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(t.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return buf.Bytes()
+}
+
+// bodyStart returns the 0-based line after source's frontmatter.
+func bodyStart(source []byte) int {
+	if body, ok := frontmatter.Strip(source); ok {
+		return bytes.Count(source[:len(source)-len(body)], []byte("\n"))
+	}
+	if frontmatter.Format(source) != frontmatter.FormatYAML {
+		return 0
+	}
+	lines := bytes.Split(source, []byte("\n"))
+	for i := 1; i < len(lines); i++ {
+		if delim := string(bytes.TrimSpace(lines[i])); delim == "---" || delim == "..." {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Tokens estimates how many model tokens s takes, at four characters per
+// token. Real tokenizers vary by model; this errs towards smaller chunks
+// for prose.
+func Tokens(s string) int {
+	return (utf8.RuneCountInString(s) + 3) / 4
+}
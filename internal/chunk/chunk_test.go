@@ -0,0 +1,64 @@
+package chunk
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	t.Parallel()
+	src := "---\ntitle: Guide\n---\nIntro text.\n\n" +
+		"# Setup\n\nInstall the tools.\n\n" +
+		"## Linux\n\n```sh\napt install wikimd\n\napt upgrade\n```\n\n" +
+		"## Setup\n\nAgain.\n\n" +
+		"API {#api}\n===\n\n" +
+		"## Endpoints\n\n" +
+		"### List\n\n- one\n- two\n"
+
+	got := Split("guides/setup.md", []byte(src), DefaultMaxTokens)
+	want := []Chunk{
+		{ID: "guides/setup.md", Text: "Intro text.", StartLine: 4, EndLine: 4},
+		{ID: "guides/setup.md#setup", Anchor: "setup", Headings: []string{"Setup"}, Text: "# Setup\n\nInstall the tools.", StartLine: 6, EndLine: 8},
+		{ID: "guides/setup.md#linux", Anchor: "linux", Headings: []string{"Setup", "Linux"}, Text: "## Linux\n\n```sh\napt install wikimd\n\napt upgrade\n```", StartLine: 10, EndLine: 16},
+		{ID: "guides/setup.md#setup-1", Anchor: "setup-1", Headings: []string{"Setup", "Setup"}, Text: "## Setup\n\nAgain.", StartLine: 18, EndLine: 20},
+		{ID: "guides/setup.md#list", Anchor: "list", Headings: []string{"API", "Endpoints", "List"}, Text: "### List\n\n- one\n- two", StartLine: 27, EndLine: 30},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d chunks, got %d: %+v", len(want), len(got), got)
+	}
+	for i := range want {
+		want[i].Tokens = Tokens(want[i].Text)
+		g, w := got[i], want[i]
+		if g.ID != w.ID || g.Anchor != w.Anchor || !slices.Equal(g.Headings, w.Headings) || g.Text != w.Text ||
+			g.StartLine != w.StartLine || g.EndLine != w.EndLine || g.Tokens != w.Tokens {
+			t.Errorf("chunk %d:\n got %+v\nwant %+v", i, g, w)
+		}
+	}
+}
+
+func TestSplitLongSections(t *testing.T) {
+	t.Parallel()
+	para := strings.Repeat("word ", 30) // 38 tokens
+	src := "# Notes\n\n" + para + "\n\n" + para + "\n\n" + para + "\n" + para + "\n"
+
+	got := Split("notes.md", []byte(src), 60)
+	var ids []string
+	for _, c := range got {
+		ids = append(ids, c.ID)
+		if c.Tokens > 60 {
+			t.Errorf("chunk %s has %d tokens, over the budget", c.ID, c.Tokens)
+		}
+	}
+	if want := []string{"notes.md#notes", "notes.md#notes~2", "notes.md#notes~3", "notes.md#notes~4"}; !slices.Equal(ids, want) {
+		t.Fatalf("expected ids %v, got %v", want, ids)
+	}
+	if got[0].StartLine != 1 || got[0].EndLine != 3 || got[3].StartLine != 8 || got[3].EndLine != 8 {
+		t.Errorf("unexpected line ranges: %+v", got)
+	}
+	for _, c := range got[1:] {
+		if !slices.Equal(c.Headings, []string{"Notes"}) {
+			t.Errorf("expected continuation %s to keep its heading trail, got %v", c.ID, c.Headings)
+		}
+	}
+}
@@ -0,0 +1,387 @@
+// Package clipboard turns rendered pages into self-contained payloads for
+// pasting into word processors, email clients, and other editors.
+package clipboard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/euforicio/wikimd/internal/frontmatter"
+)
+
+// Options control how a rendered page is prepared.
+type Options struct {
+	// Inline returns a data URI for a root-relative image source such as
+	// "/media/diagram.png". Images it rejects are replaced by their alt text.
+	Inline func(src string) (string, bool)
+	// BaseURL makes root-relative links absolute so they still work once
+	// pasted elsewhere.
+	BaseURL string
+	// Styled adds inline styles, since pasted HTML loses stylesheets and
+	// classes.
+	Styled bool
+}
+
+// allowedTags lists the elements kept, with the attributes each may carry.
+var allowedTags = map[atom.Atom][]string{
+	atom.A: {"href", "title"}, atom.Abbr: {"title"}, atom.B: nil, atom.Blockquote: nil,
+	atom.Br: nil, atom.Code: nil, atom.Dd: nil, atom.Del: nil, atom.Div: nil, atom.Dl: nil,
+	atom.Dt: nil, atom.Em: nil, atom.Figcaption: nil, atom.Figure: nil,
+	atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil, atom.H5: nil, atom.H6: nil,
+	atom.Hr: nil, atom.I: nil, atom.Img: {"src", "alt", "title", "width", "height"},
+	atom.Ins: nil, atom.Kbd: nil, atom.Li: nil, atom.Mark: nil, atom.Ol: {"start"},
+	atom.P: nil, atom.Pre: nil, atom.S: nil, atom.Span: nil, atom.Strong: nil,
+	atom.Sub: nil, atom.Sup: nil, atom.Table: nil, atom.Tbody: nil,
+	atom.Td: {"colspan", "rowspan", "align"}, atom.Tfoot: nil,
+	atom.Th: {"colspan", "rowspan", "align"}, atom.Thead: nil, atom.Tr: nil,
+	atom.U: nil, atom.Ul: nil,
+}
+
+// droppedTags are removed together with their content.
+var droppedTags = map[atom.Atom]bool{
+	atom.Button: true, atom.Embed: true, atom.Form: true, atom.Iframe: true,
+	atom.Noscript: true, atom.Object: true, atom.Script: true, atom.Style: true,
+	atom.Template: true, atom.Textarea: true,
+}
+
+// styles are the inline styles added in Styled mode.
+var styles = map[atom.Atom]string{
+	atom.H1:         "font-size:2em;font-weight:bold;margin:0.67em 0",
+	atom.H2:         "font-size:1.5em;font-weight:bold;margin:0.83em 0",
+	atom.H3:         "font-size:1.17em;font-weight:bold;margin:1em 0",
+	atom.H4:         "font-size:1em;font-weight:bold;margin:1.33em 0",
+	atom.A:          "color:#0366d6",
+	atom.Code:       "font-family:Consolas,Menlo,monospace;background:#f3f4f6;padding:0.1em 0.3em;border-radius:3px",
+	atom.Pre:        "font-family:Consolas,Menlo,monospace;background:#f3f4f6;padding:0.75em;white-space:pre-wrap;border-radius:4px",
+	atom.Blockquote: "border-left:4px solid #d1d5db;margin:0;padding-left:1em;color:#4b5563",
+	atom.Table:      "border-collapse:collapse",
+	atom.Th:         "border:1px solid #d1d5db;padding:4px 8px;background:#f3f4f6;text-align:left",
+	atom.Td:         "border:1px solid #d1d5db;padding:4px 8px",
+	atom.Img:        "max-width:100%",
+}
+
+// HTML sanitizes a rendered page fragment: scripts, forms, and event
+// handlers are removed, images are inlined, links made absolute, and
+// diagrams embedded as images.
+func HTML(fragment string, opts Options) string {
+	var (
+		out   strings.Builder
+		skip  skipper
+		inPre bool
+	)
+	z := html.NewTokenizer(strings.NewReader(fragment))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return out.String()
+		}
+		tok := z.Token()
+		if skip.skipping(tt, tok) {
+			continue
+		}
+		switch tt {
+		case html.TextToken:
+			out.WriteString(html.EscapeString(tok.Data))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch tok.DataAtom {
+			case atom.Svg:
+				out.WriteString(svgImage(z, tok, tt))
+			case atom.Input:
+				out.WriteString(checkbox(tok))
+			case atom.Pre:
+				inPre = true
+				writeStart(&out, tok, false, false, opts)
+			default:
+				writeStart(&out, tok, tt == html.SelfClosingTagToken, inPre, opts)
+			}
+		case html.EndTagToken:
+			if tok.DataAtom == atom.Pre {
+				inPre = false
+			}
+			if _, ok := allowedTags[tok.DataAtom]; ok {
+				out.WriteString("</" + tok.Data + ">")
+			}
+		}
+	}
+}
+
+// skipper drops elements that have no place in a pasted document, together
+// with their content: scripts, forms, and heading permalinks.
+type skipper struct {
+	tag   atom.Atom
+	depth int
+}
+
+// skipping reports whether the token belongs to a dropped element.
+func (s *skipper) skipping(tt html.TokenType, tok html.Token) bool {
+	if s.depth > 0 {
+		switch {
+		case tok.DataAtom != s.tag:
+		case tt == html.StartTagToken:
+			s.depth++
+		case tt == html.EndTagToken:
+			s.depth--
+		}
+		return true
+	}
+	if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+		return false
+	}
+	drop := droppedTags[tok.DataAtom] || (tok.DataAtom == atom.A && hasClass(tok, "anchor"))
+	if drop && tt == html.StartTagToken {
+		s.tag, s.depth = tok.DataAtom, 1
+	}
+	return drop
+}
+
+func writeStart(out *strings.Builder, tok html.Token, selfClosing, inPre bool, opts Options) {
+	allowed, ok := allowedTags[tok.DataAtom]
+	if !ok {
+		return
+	}
+	var attrs []html.Attribute
+	for _, a := range tok.Attr {
+		if !contains(allowed, a.Key) {
+			continue
+		}
+		switch a.Key {
+		case "href":
+			href, ok := safeURL(a.Val, opts.BaseURL)
+			if !ok {
+				continue
+			}
+			a.Val = href
+		case "src":
+			src, ok := imageSource(a.Val, opts)
+			if !ok {
+				out.WriteString(html.EscapeString(attr(tok, "alt")))
+				return
+			}
+			a.Val = src
+		}
+		attrs = append(attrs, a)
+	}
+	if tok.DataAtom == atom.Img && attr(tok, "src") == "" {
+		return
+	}
+	if opts.Styled {
+		style := styles[tok.DataAtom]
+		if inPre && tok.DataAtom == atom.Code {
+			style = "font-family:Consolas,Menlo,monospace"
+		}
+		if style != "" {
+			attrs = append(attrs, html.Attribute{Key: "style", Val: style})
+		}
+	}
+
+	out.WriteString("<" + tok.Data)
+	for _, a := range attrs {
+		out.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+	}
+	if selfClosing || tok.DataAtom == atom.Img || tok.DataAtom == atom.Br || tok.DataAtom == atom.Hr {
+		out.WriteString(" />")
+		return
+	}
+	out.WriteString(">")
+}
+
+// safeURL keeps http(s), mailto, and fragment links, resolving root-relative
+// ones against base.
+func safeURL(raw, base string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto":
+		return u.String(), true
+	case "":
+	default:
+		return "", false
+	}
+	if base == "" || strings.HasPrefix(raw, "#") {
+		return u.String(), true
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return u.String(), true
+	}
+	return b.ResolveReference(u).String(), true
+}
+
+func imageSource(src string, opts Options) (string, bool) {
+	switch {
+	case strings.HasPrefix(src, "data:image/"):
+		return src, true
+	case strings.HasPrefix(src, "http://"), strings.HasPrefix(src, "https://"):
+		return src, true
+	case strings.HasPrefix(src, "/") && opts.Inline != nil:
+		return opts.Inline(src)
+	default:
+		return "", false
+	}
+}
+
+// svgImage consumes an inline <svg> element and returns it as an <img> with
+// a data URI, which editors paste as a picture instead of dropping it.
+func svgImage(z *html.Tokenizer, start html.Token, tt html.TokenType) string {
+	var raw bytes.Buffer
+	raw.WriteString(start.String())
+	if tt == html.StartTagToken {
+		for depth := 1; depth > 0; {
+			next := z.Next()
+			if next == html.ErrorToken {
+				break
+			}
+			raw.Write(z.Raw())
+			switch tok := z.Token(); {
+			case next == html.StartTagToken && tok.DataAtom == atom.Svg:
+				depth++
+			case next == html.EndTagToken && tok.DataAtom == atom.Svg:
+				depth--
+			}
+		}
+	}
+	uri := "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString(raw.Bytes())
+	return `<img src="` + uri + `" alt="diagram" />`
+}
+
+// checkbox renders a task-list checkbox as a character.
+func checkbox(tok html.Token) string {
+	if attr(tok, "type") != "checkbox" {
+		return ""
+	}
+	for _, a := range tok.Attr {
+		if a.Key == "checked" {
+			return "☑"
+		}
+	}
+	return "☐"
+}
+
+// Text returns the readable text of a rendered fragment, for the plain-text
+// clipboard flavour. Paragraph-level blocks are separated by a blank line,
+// list items and table rows by a line break.
+func Text(fragment string) string {
+	var (
+		out   strings.Builder
+		skip  skipper
+		inPre bool
+	)
+	z := html.NewTokenizer(strings.NewReader(fragment))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		tok := z.Token()
+		if tok.DataAtom == atom.Svg && tt == html.StartTagToken {
+			skip.tag, skip.depth = atom.Svg, 1
+			continue
+		}
+		if skip.skipping(tt, tok) {
+			continue
+		}
+		switch tt {
+		case html.TextToken:
+			// Source formatting between tags is not content.
+			if inPre || strings.TrimSpace(tok.Data) != "" || !strings.Contains(tok.Data, "\n") {
+				out.WriteString(tok.Data)
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch tok.DataAtom {
+			case atom.Pre:
+				inPre = true
+			case atom.Li:
+				out.WriteString("- ")
+			case atom.Br:
+				out.WriteString("\n")
+			case atom.Input:
+				out.WriteString(checkbox(tok))
+			}
+		case html.EndTagToken:
+			if tok.DataAtom == atom.Pre {
+				inPre = false
+			}
+			out.WriteString(blockBreak(tok.DataAtom))
+		}
+	}
+
+	lines := strings.Split(out.String(), "\n")
+	kept := lines[:0]
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" && blank {
+			continue
+		}
+		blank = line == ""
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n")) + "\n"
+}
+
+// blockBreak is the text emitted after a closing tag.
+func blockBreak(a atom.Atom) string {
+	switch a {
+	case atom.P, atom.Pre, atom.Blockquote, atom.Table, atom.Ul, atom.Ol, atom.Dl, atom.Figure,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return "\n\n"
+	case atom.Li, atom.Tr, atom.Dt, atom.Dd, atom.Div, atom.Figcaption:
+		return "\n"
+	case atom.Td, atom.Th:
+		return "\t"
+	}
+	return ""
+}
+
+// Markdown returns the page source without its frontmatter block.
+func Markdown(raw string) string {
+	if body, ok := frontmatter.Strip([]byte(raw)); ok {
+		return strings.TrimLeft(string(body), "\r\n")
+	}
+	for _, fence := range []string{"---\n", "---\r\n"} {
+		if !strings.HasPrefix(raw, fence) {
+			continue
+		}
+		rest := raw[len(fence):]
+		for _, end := range []string{"\n---\n", "\n...\n", "\n---\r\n", "\n...\r\n"} {
+			if i := strings.Index(rest, end); i >= 0 {
+				return strings.TrimLeft(rest[i+len(end):], "\r\n")
+			}
+		}
+	}
+	return raw
+}
+
+func attr(tok html.Token, key string) string {
+	for _, a := range tok.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasClass(tok html.Token, class string) bool {
+	for _, c := range strings.Fields(attr(tok, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package clipboard
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTMLSanitizesAndInlines(t *testing.T) {
+	t.Parallel()
+	fragment := `<h1 id="intro" class="title">Intro</h1>
+<p onclick="steal()">See <a href="/page/guides/setup.md" class="x">setup</a> and <a href="javascript:alert(1)">this</a>.</p>
+<script>alert("x")</script>
+<img src="/media/logo.png" alt="Logo">
+<img src="/media/missing.png" alt="Missing">
+<div class="d2-diagram"><svg xmlns="http://www.w3.org/2000/svg"><rect width="1" height="1"/></svg></div>
+<pre><code class="language-go">fmt.Println("&lt;hi&gt;")</code></pre>`
+
+	out := HTML(fragment, Options{
+		BaseURL: "https://wiki.example/",
+		Inline: func(src string) (string, bool) {
+			if src == "/media/logo.png" {
+				return "data:image/png;base64,AAAA", true
+			}
+			return "", false
+		},
+		Styled: true,
+	})
+
+	for _, want := range []string{
+		`<h1 style="font-size:2em`,
+		`<a href="https://wiki.example/page/guides/setup.md" style=`,
+		`<img src="data:image/png;base64,AAAA" alt="Logo"`,
+		`Missing`,
+		`<img src="data:image/svg+xml;base64,`,
+		`&lt;hi&gt;`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	for _, banned := range []string{"<script", "alert", "onclick", "class=", "javascript:", "/media/missing.png", "<svg"} {
+		if strings.Contains(out, banned) {
+			t.Errorf("expected %q to be removed:\n%s", banned, out)
+		}
+	}
+}
+
+func TestHTMLUnstyledKeepsMarkupPlain(t *testing.T) {
+	t.Parallel()
+	out := HTML(`<p>Hi <code>x</code> <a href="#top">top</a></p>`, Options{BaseURL: "https://wiki.example/"})
+	if out != `<p>Hi <code>x</code> <a href="#top">top</a></p>` {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
+
+func TestText(t *testing.T) {
+	t.Parallel()
+	got := Text("<h1>Title</h1>\n<ul>\n<li><input type=\"checkbox\" checked disabled/> done</li>\n<li>next</li>\n</ul><script>x()</script><p>A &amp; B</p>")
+	want := "Title\n\n- ☑ done\n- next\n\nA & B\n"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestMarkdownStripsFrontmatter(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"---\ntitle: X\n---\n\n# Body\n": "# Body\n",
+		"# No frontmatter\n":             "# No frontmatter\n",
+		"---\nunterminated\n":            "---\nunterminated\n",
+	}
+	for in, want := range cases {
+		if got := Markdown(in); got != want {
+			t.Errorf("Markdown(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
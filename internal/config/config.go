@@ -3,12 +3,20 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
+
+	"github.com/euforicio/wikimd/internal/content"
+	"github.com/euforicio/wikimd/internal/renderer/d2"
+	"github.com/euforicio/wikimd/internal/renderer/extlink"
+	"github.com/euforicio/wikimd/internal/renderer/shortcode"
+	"github.com/euforicio/wikimd/internal/search"
 )
 
 const envPrefix = "WIKIMD_"
@@ -22,6 +30,85 @@ type Config struct {
 	AutoOpen      bool
 	DarkModeFirst bool
 	Verbose       bool
+	// LinkCheckInterval enables the background external link checker when
+	// positive; zero leaves it off.
+	LinkCheckInterval time.Duration
+	// FrozenDirs lists directories, relative to RootDir, whose documents
+	// cannot be changed through the API.
+	FrozenDirs []string
+	// Webhooks are endpoints that receive JSON event notifications, signed
+	// with WebhookSecret when it is set.
+	Webhooks      []string
+	WebhookSecret string
+	// PublicURL is the externally reachable base URL used for links in
+	// notifications; the local listen address is used when empty.
+	PublicURL string
+	// Digest schedules the change digest email: "daily", "weekly", or a
+	// duration. Finalize parses it into DigestInterval; empty disables it.
+	Digest         string
+	DigestInterval time.Duration
+	DigestFrom     string
+	DigestTo       []string
+	SMTPAddr       string
+	SMTPUsername   string
+	SMTPPassword   string
+	// SearchBackend names the full-text search implementation; the search
+	// package rejects unknown names at startup.
+	SearchBackend string
+	// SearchTimeout bounds each full-text search; a query still running
+	// then is stopped and answered with a timeout error.
+	SearchTimeout time.Duration
+	// RipgrepPath is the rg executable, found on PATH when empty, and
+	// RipgrepArgs are extra flags passed to every search, such as
+	// --threads=2 to throttle it on huge wikis.
+	RipgrepPath string
+	RipgrepArgs []string
+	// TreeSort is the default navigation order: "title", "modified" (newest
+	// first), or "size" (largest first). Clients can override it per request.
+	TreeSort      string
+	TreeDirsFirst bool
+	// ExcludeDirs names directories left out of the wiki at any depth, and
+	// IgnoreFile is a root-relative file of further patterns to leave out.
+	// The server and static exports both honour them.
+	ExcludeDirs []string
+	IgnoreFile  string
+	// TreeCache saves the navigation tree under .wikimd on shutdown and serves
+	// it at the next start while the wiki is checked for changes. It is off
+	// by default because it writes a file into the wiki itself.
+	TreeCache bool
+	// PageVersions is how many earlier versions of each page are kept
+	// under .wikimd/history when the wiki is not a git repository; 0
+	// keeps none.
+	PageVersions int
+	// Banner is a markdown announcement shown above every page and in
+	// exports; BannerSeverity is info, warning, or critical.
+	Banner            string
+	BannerSeverity    string
+	BannerDismissible bool
+	// D2Theme, D2DarkTheme, D2Layout, D2Sketch, and D2Pad are the defaults for
+	// D2 diagrams; a diagram's own d2-config and fence attributes win.
+	D2Theme     string
+	D2DarkTheme string
+	D2Layout    string
+	D2Sketch    bool
+	D2Pad       int
+	// DiagramTimeout bounds each server-rendered diagram, queueing included,
+	// and DiagramConcurrency caps how many render at once (0 means half the
+	// CPUs). Mermaid renders in the browser and is not affected.
+	DiagramTimeout     time.Duration
+	DiagramConcurrency int
+	// Shortcodes is off, strip, warn, or map: what happens to Hugo shortcodes
+	// and Liquid tags in migrated content. ShortcodeMap lists the shortcodes
+	// map mode converts, as "name" or "name=kind".
+	Shortcodes   string
+	ShortcodeMap []string
+	// ExternalLinksNewTab and ExternalLinksIcon decorate links to other
+	// sites. ExternalLinksAllow, when set, keeps only links to those domains,
+	// and ExternalLinksDeny unlinks its domains; both include subdomains.
+	ExternalLinksNewTab bool
+	ExternalLinksIcon   bool
+	ExternalLinksAllow  []string
+	ExternalLinksDeny   []string
 }
 
 // Default returns ready-to-use defaults prior to env/flag overrides.
@@ -33,6 +120,19 @@ func Default() Config {
 		DarkModeFirst: true,
 		StaticOutput:  "dist",
 		AssetsDir:     "static",
+		SearchBackend: "ripgrep",
+		SearchTimeout: search.DefaultTimeout,
+		TreeSort:      "title",
+		TreeDirsFirst: true,
+		IgnoreFile:    ".wikimdignore",
+		PageVersions:  content.DefaultPageVersions,
+		// Severity and dismissibility only matter once a banner is set.
+		BannerSeverity:    "info",
+		BannerDismissible: true,
+		D2Pad:             d2.DefaultPad,
+		DiagramTimeout:    d2.DefaultTimeout,
+		Shortcodes:        string(shortcode.ModeOff),
+		ShortcodeMap:      shortcode.DefaultMap,
 	}
 }
 
@@ -45,6 +145,104 @@ func RegisterFlags(fs *pflag.FlagSet, cfg *Config) {
 	fs.StringVar(&cfg.StaticOutput, "out", cfg.StaticOutput, "default output directory for static export")
 	fs.StringVar(&cfg.AssetsDir, "assets", cfg.AssetsDir, "directory containing built frontend assets")
 	fs.BoolVarP(&cfg.Verbose, "verbose", "v", cfg.Verbose, "enable verbose logging (HTTP requests)")
+	fs.DurationVar(&cfg.LinkCheckInterval, "check-links", cfg.LinkCheckInterval, "periodically check external links at this interval (e.g. 24h; 0 = disabled)")
+	fs.StringSliceVar(&cfg.FrozenDirs, "frozen", cfg.FrozenDirs, "directory (relative to root) to serve read-only; repeat or comma-separate for several")
+	fs.StringSliceVar(&cfg.Webhooks, "webhook", cfg.Webhooks, "URL to POST event notifications to (e.g. overdue reviews); repeat for several")
+	fs.StringVar(&cfg.WebhookSecret, "webhook-secret", cfg.WebhookSecret, "secret used to sign webhook bodies (X-Wikimd-Signature)")
+	fs.StringVar(&cfg.PublicURL, "public-url", cfg.PublicURL, "externally reachable base URL used for links in notifications (e.g. https://wiki.example.com)")
+	fs.StringVar(&cfg.Digest, "digest", cfg.Digest, "email a digest of changed pages: daily, weekly, or an interval such as 12h (requires --smtp-addr, --digest-from, --digest-to)")
+	fs.StringVar(&cfg.DigestFrom, "digest-from", cfg.DigestFrom, "sender address for digest emails")
+	fs.StringSliceVar(&cfg.DigestTo, "digest-to", cfg.DigestTo, "recipient address for digest emails; repeat or comma-separate for several")
+	fs.StringVar(&cfg.SMTPAddr, "smtp-addr", cfg.SMTPAddr, "SMTP server as host:port for digest emails")
+	fs.StringVar(&cfg.SMTPUsername, "smtp-username", cfg.SMTPUsername, "SMTP username (PLAIN auth)")
+	fs.StringVar(&cfg.SMTPPassword, "smtp-password", cfg.SMTPPassword, "SMTP password; prefer WIKIMD_SMTP_PASSWORD")
+	fs.StringVar(&cfg.SearchBackend, "search-backend", cfg.SearchBackend, "full-text search backend (ripgrep)")
+	fs.DurationVar(&cfg.SearchTimeout, "search-timeout", cfg.SearchTimeout, "stop a full-text search that runs longer than this")
+	fs.StringVar(&cfg.RipgrepPath, "rg-path", cfg.RipgrepPath, "ripgrep executable to search with (default: rg on PATH)")
+	fs.StringArrayVar(&cfg.RipgrepArgs, "rg-arg", cfg.RipgrepArgs, "extra ripgrep flag for every search, with its value after = (e.g. --rg-arg=--threads=2); repeat for several")
+	fs.StringVar(&cfg.TreeSort, "tree-sort", cfg.TreeSort, "default navigation order: title, modified (newest first), or size")
+	fs.BoolVar(&cfg.TreeDirsFirst, "tree-dirs-first", cfg.TreeDirsFirst, "list directories before documents in the navigation tree")
+	fs.StringSliceVar(&cfg.ExcludeDirs, "exclude-dir", cfg.ExcludeDirs, "directory name to leave out at any depth; repeat or comma-separate for several")
+	fs.StringVar(&cfg.IgnoreFile, "ignore-file", cfg.IgnoreFile, "file (relative to root) listing patterns to leave out of the wiki; empty disables it")
+	fs.BoolVar(&cfg.TreeCache, "tree-cache", cfg.TreeCache, "persist the navigation tree to .wikimd/tree.cache for fast startup (add it to .gitignore)")
+	fs.IntVar(&cfg.PageVersions, "page-versions", cfg.PageVersions, "earlier versions of each page to keep under .wikimd/history when the wiki is not a git repository (0 = none)")
+	RegisterBannerFlags(fs, cfg)
+	RegisterD2Flags(fs, cfg)
+	RegisterShortcodeFlags(fs, cfg)
+	RegisterExternalLinkFlags(fs, cfg)
+}
+
+// RegisterBannerFlags attaches the announcement banner flags, which the
+// server and the static exporter share.
+func RegisterBannerFlags(fs *pflag.FlagSet, cfg *Config) {
+	fs.StringVar(&cfg.Banner, "banner", cfg.Banner, "markdown announcement shown above every page (e.g. a maintenance notice)")
+	fs.StringVar(&cfg.BannerSeverity, "banner-severity", cfg.BannerSeverity, "banner style: info, warning, or critical")
+	fs.BoolVar(&cfg.BannerDismissible, "banner-dismissible", cfg.BannerDismissible, "let readers close the banner until its text changes")
+}
+
+// RegisterD2Flags attaches the D2 diagram defaults, which the server and the
+// static exporter share.
+func RegisterD2Flags(fs *pflag.FlagSet, cfg *Config) {
+	fs.StringVar(&cfg.D2Theme, "d2-theme", cfg.D2Theme, "default D2 theme by catalog name or ID (default: Dark Flagship Terrastruct)")
+	fs.StringVar(&cfg.D2DarkTheme, "d2-dark-theme", cfg.D2DarkTheme, "D2 theme used when the reader's system prefers dark mode")
+	fs.StringVar(&cfg.D2Layout, "d2-layout", cfg.D2Layout, "default D2 layout engine: dagre or elk")
+	fs.BoolVar(&cfg.D2Sketch, "d2-sketch", cfg.D2Sketch, "draw D2 diagrams in hand-drawn sketch style")
+	fs.IntVar(&cfg.D2Pad, "d2-pad", cfg.D2Pad, "padding around D2 diagrams in pixels")
+	fs.DurationVar(&cfg.DiagramTimeout, "diagram-timeout", cfg.DiagramTimeout, "give up on a D2 diagram that queues and renders for longer than this")
+	fs.IntVar(&cfg.DiagramConcurrency, "diagram-concurrency", cfg.DiagramConcurrency, "D2 diagrams rendered at once (0 = half the CPUs)")
+}
+
+// RegisterShortcodeFlags attaches the shortcode compatibility flags, which
+// the server and the static exporter share.
+func RegisterShortcodeFlags(fs *pflag.FlagSet, cfg *Config) {
+	fs.StringVar(&cfg.Shortcodes, "shortcodes", cfg.Shortcodes, "Hugo shortcodes and Liquid tags in pages: off (show as text), strip, warn (strip and log), or map")
+	fs.StringSliceVar(&cfg.ShortcodeMap, "shortcode-map", cfg.ShortcodeMap, "shortcodes --shortcodes=map converts, as name or name=kind (kinds: figure, youtube, admonition); repeat or comma-separate")
+}
+
+// RegisterExternalLinkFlags attaches the external link options, which the
+// server and the static exporter share.
+func RegisterExternalLinkFlags(fs *pflag.FlagSet, cfg *Config) {
+	fs.BoolVar(&cfg.ExternalLinksNewTab, "external-links-new-tab", cfg.ExternalLinksNewTab, "open links to other sites in a new tab (target=_blank rel=noopener)")
+	fs.BoolVar(&cfg.ExternalLinksIcon, "external-links-icon", cfg.ExternalLinksIcon, "mark links to other sites with an external-link icon")
+	fs.StringSliceVar(&cfg.ExternalLinksAllow, "external-links-allow", cfg.ExternalLinksAllow, "only link to these domains and their subdomains, showing other links as text; repeat or comma-separate")
+	fs.StringSliceVar(&cfg.ExternalLinksDeny, "external-links-deny", cfg.ExternalLinksDeny, "show links to these domains and their subdomains as text; repeat or comma-separate")
+}
+
+// ExternalLinks returns the external link settings as renderer options.
+// Finalize has already normalized the domain lists.
+func (c Config) ExternalLinks() extlink.Options {
+	return extlink.Options{
+		NewTab: c.ExternalLinksNewTab,
+		Icon:   c.ExternalLinksIcon,
+		Allow:  c.ExternalLinksAllow,
+		Deny:   c.ExternalLinksDeny,
+	}
+}
+
+// ShortcodeOptions returns the shortcode settings as renderer options. Call
+// it after Finalize, which rejects an invalid mode or map.
+func (c Config) ShortcodeOptions() shortcode.Options {
+	mode, _ := shortcode.ParseMode(c.Shortcodes)
+	mapped, _ := shortcode.ParseMap(c.ShortcodeMap)
+	return shortcode.Options{Mode: mode, Map: mapped}
+}
+
+// Search returns the settings the search backends are built with.
+func (c Config) Search() search.BackendOptions {
+	return search.BackendOptions{RipgrepPath: c.RipgrepPath, RipgrepArgs: c.RipgrepArgs}
+}
+
+// D2 returns the D2 diagram defaults as renderer options.
+func (c Config) D2() d2.Options {
+	pad := int64(c.D2Pad)
+	return d2.Options{
+		Theme:     c.D2Theme,
+		DarkTheme: c.D2DarkTheme,
+		Layout:    c.D2Layout,
+		Sketch:    c.D2Sketch,
+		Pad:       &pad,
+		Timeout:   c.DiagramTimeout,
+	}
 }
 
 // ApplyEnvOverrides reads supported environment variables and overrides cfg in place.
@@ -56,6 +254,43 @@ func ApplyEnvOverrides(cfg *Config) {
 	applyStringEnv("OUT", func(v string) { cfg.StaticOutput = v })
 	applyStringEnv("ASSETS", func(v string) { cfg.AssetsDir = v })
 	applyBoolEnv("VERBOSE", func(v bool) { cfg.Verbose = v })
+	applyDurationEnv("CHECK_LINKS", func(v time.Duration) { cfg.LinkCheckInterval = v })
+	applyListEnv("FROZEN", func(v []string) { cfg.FrozenDirs = v })
+	applyListEnv("WEBHOOKS", func(v []string) { cfg.Webhooks = v })
+	applyStringEnv("WEBHOOK_SECRET", func(v string) { cfg.WebhookSecret = v })
+	applyStringEnv("PUBLIC_URL", func(v string) { cfg.PublicURL = v })
+	applyStringEnv("DIGEST", func(v string) { cfg.Digest = v })
+	applyStringEnv("DIGEST_FROM", func(v string) { cfg.DigestFrom = v })
+	applyListEnv("DIGEST_TO", func(v []string) { cfg.DigestTo = v })
+	applyStringEnv("SMTP_ADDR", func(v string) { cfg.SMTPAddr = v })
+	applyStringEnv("SMTP_USERNAME", func(v string) { cfg.SMTPUsername = v })
+	applyStringEnv("SMTP_PASSWORD", func(v string) { cfg.SMTPPassword = v })
+	applyStringEnv("SEARCH_BACKEND", func(v string) { cfg.SearchBackend = v })
+	applyDurationEnv("SEARCH_TIMEOUT", func(v time.Duration) { cfg.SearchTimeout = v })
+	applyStringEnv("RG_PATH", func(v string) { cfg.RipgrepPath = v })
+	applyStringEnv("RG_ARGS", func(v string) { cfg.RipgrepArgs = strings.Fields(v) })
+	applyStringEnv("TREE_SORT", func(v string) { cfg.TreeSort = v })
+	applyBoolEnv("TREE_DIRS_FIRST", func(v bool) { cfg.TreeDirsFirst = v })
+	applyBoolEnv("TREE_CACHE", func(v bool) { cfg.TreeCache = v })
+	applyIntEnv("PAGE_VERSIONS", func(v int) { cfg.PageVersions = v })
+	applyListEnv("EXCLUDE_DIRS", func(v []string) { cfg.ExcludeDirs = v })
+	applyStringEnv("IGNORE_FILE", func(v string) { cfg.IgnoreFile = v })
+	applyStringEnv("BANNER", func(v string) { cfg.Banner = v })
+	applyStringEnv("BANNER_SEVERITY", func(v string) { cfg.BannerSeverity = v })
+	applyBoolEnv("BANNER_DISMISSIBLE", func(v bool) { cfg.BannerDismissible = v })
+	applyStringEnv("D2_THEME", func(v string) { cfg.D2Theme = v })
+	applyStringEnv("D2_DARK_THEME", func(v string) { cfg.D2DarkTheme = v })
+	applyStringEnv("D2_LAYOUT", func(v string) { cfg.D2Layout = v })
+	applyBoolEnv("D2_SKETCH", func(v bool) { cfg.D2Sketch = v })
+	applyIntEnv("D2_PAD", func(v int) { cfg.D2Pad = v })
+	applyDurationEnv("DIAGRAM_TIMEOUT", func(v time.Duration) { cfg.DiagramTimeout = v })
+	applyIntEnv("DIAGRAM_CONCURRENCY", func(v int) { cfg.DiagramConcurrency = v })
+	applyStringEnv("SHORTCODES", func(v string) { cfg.Shortcodes = v })
+	applyListEnv("SHORTCODE_MAP", func(v []string) { cfg.ShortcodeMap = v })
+	applyBoolEnv("EXTERNAL_LINKS_NEW_TAB", func(v bool) { cfg.ExternalLinksNewTab = v })
+	applyBoolEnv("EXTERNAL_LINKS_ICON", func(v bool) { cfg.ExternalLinksIcon = v })
+	applyListEnv("EXTERNAL_LINKS_ALLOW", func(v []string) { cfg.ExternalLinksAllow = v })
+	applyListEnv("EXTERNAL_LINKS_DENY", func(v []string) { cfg.ExternalLinksDeny = v })
 }
 
 func applyStringEnv(key string, apply func(string)) {
@@ -80,6 +315,20 @@ func applyBoolEnv(key string, apply func(bool)) {
 	}
 }
 
+func applyDurationEnv(key string, apply func(time.Duration)) {
+	if raw, ok := lookupNonEmpty(key); ok {
+		if value, err := time.ParseDuration(raw); err == nil {
+			apply(value)
+		}
+	}
+}
+
+func applyListEnv(key string, apply func([]string)) {
+	if raw, ok := lookupNonEmpty(key); ok {
+		apply(strings.Split(raw, ","))
+	}
+}
+
 func lookupNonEmpty(key string) (string, bool) {
 	raw, ok := os.LookupEnv(envPrefix + key)
 	if !ok {
@@ -100,11 +349,126 @@ func Finalize(cfg *Config) error {
 	}
 	cfg.RootDir = root
 
+	cfg.SearchBackend = strings.ToLower(strings.TrimSpace(cfg.SearchBackend))
+	if cfg.SearchTimeout <= 0 {
+		return fmt.Errorf("invalid search timeout: %s", cfg.SearchTimeout)
+	}
+
+	cfg.TreeSort = strings.ToLower(strings.TrimSpace(cfg.TreeSort))
+	switch cfg.TreeSort {
+	case "":
+		cfg.TreeSort = "title"
+	case "title", "modified", "size":
+	default:
+		return fmt.Errorf("invalid tree sort %q (want title, modified, or size)", cfg.TreeSort)
+	}
+
 	// Allow port 0 for dynamic allocation, otherwise validate range
 	if cfg.Port < 0 || cfg.Port > 65535 {
 		return fmt.Errorf("invalid port: %d", cfg.Port)
 	}
 
+	if cfg.LinkCheckInterval < 0 {
+		return fmt.Errorf("invalid link check interval: %s", cfg.LinkCheckInterval)
+	}
+
+	frozen := make([]string, 0, len(cfg.FrozenDirs))
+	for _, dir := range cfg.FrozenDirs {
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			continue
+		}
+		clean := filepath.ToSlash(filepath.Clean(dir))
+		if filepath.IsAbs(dir) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("invalid frozen directory: %s", dir)
+		}
+		frozen = append(frozen, clean)
+	}
+	cfg.FrozenDirs = frozen
+
+	if cfg.IgnoreFile = strings.TrimSpace(cfg.IgnoreFile); cfg.IgnoreFile != "" {
+		clean := filepath.ToSlash(filepath.Clean(cfg.IgnoreFile))
+		if filepath.IsAbs(cfg.IgnoreFile) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("invalid ignore file: %s", cfg.IgnoreFile)
+		}
+		cfg.IgnoreFile = clean
+	}
+
+	hooks := make([]string, 0, len(cfg.Webhooks))
+	for _, raw := range cfg.Webhooks {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook URL: %s", raw)
+		}
+		hooks = append(hooks, raw)
+	}
+	cfg.Webhooks = hooks
+
+	if cfg.PublicURL != "" {
+		u, err := url.Parse(cfg.PublicURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid public URL: %s", cfg.PublicURL)
+		}
+		cfg.PublicURL = strings.TrimSuffix(cfg.PublicURL, "/")
+	}
+
+	cfg.BannerSeverity = strings.ToLower(strings.TrimSpace(cfg.BannerSeverity))
+	switch cfg.BannerSeverity {
+	case "":
+		cfg.BannerSeverity = "info"
+	case "info", "warning", "critical":
+	default:
+		return fmt.Errorf("invalid banner severity %q (want info, warning, or critical)", cfg.BannerSeverity)
+	}
+
+	for _, theme := range []string{cfg.D2Theme, cfg.D2DarkTheme} {
+		if theme = strings.TrimSpace(theme); theme != "" {
+			if _, err := d2.ThemeID(theme); err != nil {
+				return err
+			}
+		}
+	}
+	cfg.D2Layout = strings.ToLower(strings.TrimSpace(cfg.D2Layout))
+	switch cfg.D2Layout {
+	case "", "dagre", "elk":
+	default:
+		return fmt.Errorf("invalid D2 layout %q (want dagre or elk)", cfg.D2Layout)
+	}
+	if cfg.D2Pad < 0 {
+		return fmt.Errorf("invalid D2 padding: %d", cfg.D2Pad)
+	}
+	if cfg.DiagramTimeout <= 0 {
+		return fmt.Errorf("invalid diagram timeout: %s", cfg.DiagramTimeout)
+	}
+	if cfg.DiagramConcurrency < 0 {
+		return fmt.Errorf("invalid diagram concurrency: %d", cfg.DiagramConcurrency)
+	}
+	if cfg.PageVersions < 0 {
+		return fmt.Errorf("invalid page versions: %d", cfg.PageVersions)
+	}
+	mode, err := shortcode.ParseMode(cfg.Shortcodes)
+	if err != nil {
+		return err
+	}
+	cfg.Shortcodes = string(mode)
+	if _, err := shortcode.ParseMap(cfg.ShortcodeMap); err != nil {
+		return err
+	}
+	if cfg.ExternalLinksAllow, err = extlink.ParseDomains(cfg.ExternalLinksAllow); err != nil {
+		return err
+	}
+	if cfg.ExternalLinksDeny, err = extlink.ParseDomains(cfg.ExternalLinksDeny); err != nil {
+		return err
+	}
+
+	if err := finalizeDigest(cfg); err != nil {
+		return err
+	}
+
 	if cfg.StaticOutput == "" {
 		cfg.StaticOutput = "dist"
 	}
@@ -120,3 +484,33 @@ func Finalize(cfg *Config) error {
 
 	return nil
 }
+
+func finalizeDigest(cfg *Config) error {
+	switch schedule := strings.ToLower(strings.TrimSpace(cfg.Digest)); schedule {
+	case "", "off":
+		cfg.DigestInterval = 0
+		return nil
+	case "daily":
+		cfg.DigestInterval = 24 * time.Hour
+	case "weekly":
+		cfg.DigestInterval = 7 * 24 * time.Hour
+	default:
+		interval, err := time.ParseDuration(schedule)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("invalid digest schedule: %s", cfg.Digest)
+		}
+		cfg.DigestInterval = interval
+	}
+
+	to := make([]string, 0, len(cfg.DigestTo))
+	for _, addr := range cfg.DigestTo {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	cfg.DigestTo = to
+	if cfg.SMTPAddr == "" || cfg.DigestFrom == "" || len(cfg.DigestTo) == 0 {
+		return fmt.Errorf("digest requires --smtp-addr, --digest-from, and --digest-to")
+	}
+	return nil
+}
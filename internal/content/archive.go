@@ -0,0 +1,109 @@
+package content
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ArchiveDir is the top-level directory that retired pages are moved into,
+// mirroring their original location.
+const ArchiveDir = "archive"
+
+// RedirectsFile records where moved pages now live, relative to the root.
+const RedirectsFile = ".wikimd/redirects.json"
+
+// IsArchived reports whether the wiki-relative path lies under ArchiveDir.
+func IsArchived(rel string) bool {
+	rel = strings.TrimPrefix(filepath.ToSlash(rel), "/")
+	return rel == ArchiveDir || strings.HasPrefix(rel, ArchiveDir+"/")
+}
+
+// ArchiveDocument moves a document to the same path under ArchiveDir and
+// records a redirect from the old location. It returns the new path.
+func (s *Service) ArchiveDocument(ctx context.Context, relPath string) (string, error) {
+	rel, _, err := s.resolveDocumentPath(relPath)
+	if err != nil {
+		return "", err
+	}
+	if IsArchived(rel) {
+		return "", fmt.Errorf("document already archived: %s: %w", rel, os.ErrExist)
+	}
+	dest := path.Join(ArchiveDir, rel)
+	if err := s.RenameDocument(ctx, rel, dest); err != nil {
+		return "", err
+	}
+	if err := s.addRedirect(rel, dest); err != nil {
+		return dest, fmt.Errorf("record redirect: %w", err)
+	}
+	return dest, nil
+}
+
+// Redirect returns the current location of a page that was moved away from
+// relPath, if one was recorded.
+func (s *Service) Redirect(relPath string) (string, bool) {
+	rel, _, err := s.resolveDocumentPath(relPath)
+	if err != nil {
+		return "", false
+	}
+	s.redirectsMu.Lock()
+	defer s.redirectsMu.Unlock()
+	redirects, err := s.loadRedirectsLocked()
+	if err != nil {
+		s.logger.Warn("load redirects failed", slog.Any("err", err))
+		return "", false
+	}
+	dest, ok := redirects[rel]
+	return dest, ok
+}
+
+func (s *Service) addRedirect(from, to string) error {
+	s.redirectsMu.Lock()
+	defer s.redirectsMu.Unlock()
+	redirects, err := s.loadRedirectsLocked()
+	if err != nil {
+		return err
+	}
+	// Keep earlier redirects pointing at the page's final location, and drop
+	// any redirect away from the path the page now occupies.
+	for src, dst := range redirects {
+		if dst == from {
+			redirects[src] = to
+		}
+	}
+	delete(redirects, to)
+	redirects[from] = to
+
+	data, err := json.MarshalIndent(redirects, "", "  ")
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(s.root, filepath.FromSlash(RedirectsFile))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil { //nolint:gosec // standard directory permissions
+		return fmt.Errorf("ensure directory: %w", err)
+	}
+	return writeFileAtomic(target, append(data, '\n'))
+}
+
+// loadRedirectsLocked reads RedirectsFile; callers hold redirectsMu. The file
+// is small and rarely read, so it is not cached.
+func (s *Service) loadRedirectsLocked() (map[string]string, error) {
+	redirects := make(map[string]string)
+	raw, err := os.ReadFile(filepath.Join(s.root, filepath.FromSlash(RedirectsFile)))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return redirects, nil
+		}
+		return nil, fmt.Errorf("read redirects: %w", err)
+	}
+	if err := json.Unmarshal(raw, &redirects); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", RedirectsFile, err)
+	}
+	return redirects, nil
+}
@@ -0,0 +1,146 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// File kinds reported by FileInfo.Type.
+const (
+	FileMarkdown = "markdown"
+	FileImage    = "image"
+	FileVideo    = "video"
+	FileAudio    = "audio"
+	FilePDF      = "pdf"
+	FileText     = "text"
+	FileBinary   = "binary"
+)
+
+// FileInfo describes a file under the wiki root.
+type FileInfo struct {
+	Modified time.Time `json:"modified"`
+	Path     string    `json:"path"`
+	Type     string    `json:"type"`
+	MIME     string    `json:"mime"`
+	Size     int64     `json:"size"`
+}
+
+// File describes any regular file under the root, markdown or not. The MIME
+// type is sniffed from the file's first bytes; the extension only refines a
+// generic result such as text/plain. Files the visibility policy leaves
+// out, such as hidden or ignored ones, are reported missing, and so are
+// symlinks leading outside the root.
+func (s *Service) File(ctx context.Context, relPath string) (FileInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return FileInfo{}, err
+	}
+	clean, err := cleanRelPath(relPath)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	rel, abs, err := s.resolveUnderRoot(relPath, clean)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	notFound := fmt.Errorf("file not found: %s: %w", rel, os.ErrNotExist)
+	if !s.Visibility().Visible(rel) {
+		return FileInfo{}, notFound
+	}
+
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return FileInfo{}, notFound
+		}
+		return FileInfo{}, fmt.Errorf("resolve file: %w", err)
+	}
+	if !s.withinRoot(resolved) {
+		return FileInfo{}, notFound
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return FileInfo{}, fmt.Errorf("stat file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return FileInfo{}, fmt.Errorf("%w: not a file: %s", ErrInvalidPath, rel)
+	}
+
+	mimeType, err := sniffMIME(resolved, rel)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	return FileInfo{
+		Modified: info.ModTime(),
+		Path:     rel,
+		Type:     fileKind(rel, mimeType),
+		MIME:     mimeType,
+		Size:     info.Size(),
+	}, nil
+}
+
+// withinRoot reports whether abs, with symlinks resolved, lies inside the
+// root.
+func (s *Service) withinRoot(abs string) bool {
+	root, err := filepath.EvalSymlinks(s.root)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(root, abs)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
+
+func sniffMIME(abs, rel string) (string, error) {
+	f, err := os.Open(abs) //nolint:gosec // abs is resolved inside the root
+	if err != nil {
+		return "", fmt.Errorf("open file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("read file: %w", err)
+	}
+
+	// Sniffing cannot tell markdown, CSS, or SVG from other text, so a
+	// generic result defers to the extension when it names a type.
+	sniffed := http.DetectContentType(head[:n])
+	generic := sniffed == "application/octet-stream" || strings.HasPrefix(sniffed, "text/plain") || strings.HasPrefix(sniffed, "text/xml")
+	if !generic {
+		return sniffed, nil
+	}
+	if isMarkdownPath(rel) {
+		return "text/markdown; charset=utf-8", nil
+	}
+	if byExt := mime.TypeByExtension(strings.ToLower(path.Ext(rel))); byExt != "" {
+		return byExt, nil
+	}
+	return sniffed, nil
+}
+
+func fileKind(rel, mimeType string) string {
+	base, _, _ := strings.Cut(mimeType, ";")
+	switch {
+	case isMarkdownPath(rel):
+		return FileMarkdown
+	case strings.HasPrefix(base, "image/"):
+		return FileImage
+	case strings.HasPrefix(base, "video/"):
+		return FileVideo
+	case strings.HasPrefix(base, "audio/"):
+		return FileAudio
+	case base == "application/pdf":
+		return FilePDF
+	case strings.HasPrefix(base, "text/"), base == "application/json", strings.HasSuffix(base, "+xml"), base == "application/xml":
+		return FileText
+	default:
+		return FileBinary
+	}
+}
@@ -0,0 +1,43 @@
+package content
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/euforicio/wikimd/internal/fuzztest"
+)
+
+func FuzzResolvePath(f *testing.F) {
+	for _, rel := range fuzztest.Paths(f, "../../testdata/wiki") {
+		f.Add(rel)
+		f.Add("guides/../" + rel)
+	}
+	for _, seed := range []string{"..", "../etc/passwd", "a/../../b", "/abs.md", `..\x.md`, "a//b/./c.md", " \t", "C:/x.md"} {
+		f.Add(seed)
+	}
+
+	root := f.TempDir()
+	svc := &Service{root: root}
+	f.Fuzz(func(t *testing.T, relPath string) {
+		clean, err := cleanRelPath(relPath)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidPath) {
+				t.Fatalf("cleanRelPath(%q) = %v, want ErrInvalidPath", relPath, err)
+			}
+			return
+		}
+		if clean == ".." || strings.HasPrefix(clean, "../") || filepath.IsAbs(clean) {
+			t.Fatalf("cleanRelPath(%q) accepted %q", relPath, clean)
+		}
+		_, abs, err := svc.resolveUnderRoot(relPath, clean)
+		if err != nil {
+			return
+		}
+		rel, err := filepath.Rel(root, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			t.Fatalf("%q resolved outside the root: %s", relPath, abs)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+)
+
+// treeHistoryLimit is how many past tree generations TreeDelta can diff
+// against before falling back to the full tree.
+const treeHistoryLimit = 64
+
+// treeSnapshot records the node hashes of one tree generation.
+type treeSnapshot struct {
+	hashes     map[string]string
+	generation uint64
+}
+
+// TreeDelta describes how the tree changed since a client's generation.
+type TreeDelta struct {
+	// Tree is the full tree, sent instead of Changed and Removed when Full.
+	Tree *tree.Node `json:"tree,omitempty"`
+	// Changed holds added and modified nodes without their children. A
+	// directory is included whenever anything below it changed, so clients
+	// can refresh its hash.
+	Changed    []*tree.Node `json:"changed,omitempty"`
+	Removed    []string     `json:"removed,omitempty"`
+	Generation uint64       `json:"generation"`
+	Since      uint64       `json:"since"`
+	// Full is set when since is unknown or older than the retained history.
+	Full bool `json:"full"`
+}
+
+// storeTree publishes node as the current tree. The generation advances only
+// when the tree actually changed, so rebuilds triggered by unrelated files do
+// not send clients fetching deltas.
+func (s *Service) storeTree(node *tree.Node) {
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+
+	if prev := s.tree.Load(); prev != nil && prev.Hash == node.Hash {
+		s.tree.Store(node)
+		return
+	}
+	s.tree.Store(node)
+	s.generation++
+	s.history = append(s.history, treeSnapshot{generation: s.generation, hashes: tree.Hashes(node)})
+	if len(s.history) > treeHistoryLimit {
+		s.history = s.history[len(s.history)-treeHistoryLimit:]
+	}
+}
+
+// Generation returns the current tree generation. It starts at 1 and grows
+// by one for every change to the tree.
+func (s *Service) Generation() uint64 {
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	return s.generation
+}
+
+// TreeWithGeneration returns the current tree together with its generation.
+// Clients that later ask for a TreeDelta must use this rather than pairing
+// CurrentTree with Generation, which a rebuild could land between.
+func (s *Service) TreeWithGeneration(ctx context.Context) (*tree.Node, uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+
+	root := s.tree.Load()
+	if root == nil {
+		return nil, 0, errors.New("tree not initialized")
+	}
+	return root, s.generation, nil
+}
+
+// TreeDelta returns the nodes that changed between generation since and the
+// current tree.
+func (s *Service) TreeDelta(ctx context.Context, since uint64) (TreeDelta, error) {
+	if err := ctx.Err(); err != nil {
+		return TreeDelta{}, err
+	}
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+
+	root := s.tree.Load()
+	if root == nil {
+		return TreeDelta{}, errors.New("tree not initialized")
+	}
+	delta := TreeDelta{Generation: s.generation, Since: since}
+	if since == s.generation {
+		return delta, nil
+	}
+
+	var base map[string]string
+	for _, snap := range s.history {
+		if snap.generation == since {
+			base = snap.hashes
+			break
+		}
+	}
+	if base == nil {
+		delta.Full = true
+		delta.Tree = root
+		return delta, nil
+	}
+
+	current := make(map[string]bool)
+	var walk func(*tree.Node)
+	walk = func(n *tree.Node) {
+		current[n.RelativePath] = true
+		if base[n.RelativePath] != n.Hash {
+			flat := *n
+			flat.Children = nil
+			delta.Changed = append(delta.Changed, &flat)
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+	for p := range base {
+		if !current[p] {
+			delta.Removed = append(delta.Removed, p)
+		}
+	}
+	sort.Strings(delta.Removed)
+	return delta, nil
+}
@@ -0,0 +1,96 @@
+package content
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+)
+
+// indexProgressInterval throttles EventIndexing broadcasts.
+const indexProgressInterval = 250 * time.Millisecond
+
+// Indexing reports whether the initial tree build started by
+// Options.BuildInBackground is still running, and how many documents it has
+// scanned so far.
+func (s *Service) Indexing() (scanned int, indexing bool) {
+	return int(s.scanned.Load()), s.indexing.Load()
+}
+
+// startIndexing serves an empty tree right away and builds the real one in
+// the background, reporting progress to subscribers as EventIndexing.
+func (s *Service) startIndexing() {
+	name := filepath.Base(s.root)
+	placeholder := &tree.Node{
+		Name:     name,
+		RawName:  name,
+		Title:    name,
+		Type:     tree.NodeTypeDirectory,
+		Modified: time.Now(),
+	}
+	tree.Checksum(placeholder)
+	s.indexing.Store(true)
+	s.storeTree(placeholder)
+	go s.indexInBackground()
+}
+
+func (s *Service) indexInBackground() {
+	started := time.Now()
+	opts := s.treeOptions()
+	var last time.Time
+	opts.Progress = func(files int) {
+		s.scanned.Store(int64(files))
+		if now := time.Now(); now.Sub(last) >= indexProgressInterval {
+			last = now
+			s.broadcast(Event{Type: EventIndexing, Scanned: files, Timestamp: now})
+		}
+	}
+
+	s.rebuildMu.Lock()
+	node, err := tree.Build(s.ctx, s.root, opts)
+	if err == nil {
+		s.storeTree(node)
+	}
+	s.rebuildMu.Unlock()
+	s.indexing.Store(false)
+	if err != nil {
+		if s.ctx.Err() == nil {
+			s.logger.Error("initial tree build failed", slog.Any("err", err))
+		}
+		return
+	}
+
+	scanned, _ := s.Indexing()
+	s.logger.Info("initial index complete", slog.Int("documents", scanned), slog.Duration("took", time.Since(started)))
+	s.broadcast(Event{Type: EventTreeUpdated, Timestamp: time.Now(), Generation: s.Generation()})
+}
+
+// Reindex rebuilds the tree from disk, calling progress with the documents
+// scanned so far and the count from the previous tree as an estimate of the
+// total.
+func (s *Service) Reindex(ctx context.Context, progress func(scanned, total int)) error {
+	total := 0
+	if root := s.tree.Load(); root != nil {
+		total = root.Documents
+	}
+	opts := s.treeOptions()
+	if progress != nil {
+		opts.Progress = func(files int) {
+			progress(files, max(total, files))
+		}
+	}
+
+	s.rebuildMu.Lock()
+	node, err := tree.Build(ctx, s.root, opts)
+	if err == nil {
+		s.storeTree(node)
+	}
+	s.rebuildMu.Unlock()
+	if err != nil {
+		return err
+	}
+	s.broadcast(Event{Type: EventTreeUpdated, Timestamp: time.Now(), Generation: s.Generation()})
+	return nil
+}
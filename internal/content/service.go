@@ -19,37 +19,65 @@ import (
 
 	"github.com/euforicio/wikimd/internal/content/tree"
 	"github.com/euforicio/wikimd/internal/renderer"
+	"github.com/euforicio/wikimd/internal/visibility"
 )
 
+// Event types broadcast to subscribers.
 const (
-	eventTypeTreeUpdated = "treeUpdated"
-	eventTypeDeleted     = "deleted"
-	eventTypePageUpdated = "pageUpdated"
-	eventTypeUnknown     = "unknown"
+	EventTreeUpdated = "treeUpdated"
+	EventDeleted     = "deleted"
+	EventPageUpdated = "pageUpdated"
+	// EventIndexing reports progress of a background initial build.
+	EventIndexing = "indexing"
+	EventUnknown  = "unknown"
 )
 
+// ErrFrozen is returned by the write methods when the target document lies in
+// a frozen directory.
+var ErrFrozen = errors.New("directory is frozen")
+
+// ErrInvalidPath is returned when a document path is empty, absolute, or
+// would resolve outside the content root.
+var ErrInvalidPath = errors.New("invalid path")
+
 // Event describes change notifications emitted to subscribers.
 type Event struct {
 	Timestamp time.Time `json:"timestamp"`
 	Type      string    `json:"type"`
 	Path      string    `json:"path,omitempty"`
+	// Generation is the tree generation after the change (see TreeDelta).
+	Generation uint64 `json:"generation,omitempty"`
+	// Scanned is the number of documents built so far, for EventIndexing.
+	Scanned int `json:"scanned,omitempty"`
 }
 
 // Service coordinates content rendering, indexing, and change notifications.
 type Service struct {
-	ctx           context.Context
-	logger        *slog.Logger
-	watcher       *fsnotify.Watcher
-	renderer      *renderer.Service
-	cancel        context.CancelFunc
-	tree          atomic.Pointer[tree.Node]
-	subscribers   map[uint64]*subscriber
-	root          string
-	subCounter    atomic.Uint64
-	subsMu        sync.RWMutex
-	writeMu       sync.Mutex
-	rebuildMu     sync.Mutex
-	includeHidden bool
+	ctx         context.Context
+	logger      *slog.Logger
+	watcher     *fsnotify.Watcher
+	renderer    *renderer.Service
+	cancel      context.CancelFunc
+	tree        atomic.Pointer[tree.Node]
+	subscribers map[uint64]*subscriber
+	root        string
+	visibility  visibility.Options
+	policy      atomic.Pointer[visibility.Policy]
+	frozenDirs  []string
+	treeSort    *tree.Sort
+	subCounter  atomic.Uint64
+	subsMu      sync.RWMutex
+	writeMu     sync.Mutex
+	rebuildMu   sync.Mutex
+	redirectsMu sync.Mutex
+	historyMu   sync.Mutex // guards generation, history, and tree stores
+	history     []treeSnapshot
+	generation  uint64
+	scanned     atomic.Int64
+	indexing    atomic.Bool
+	treeCache   bool
+	background  bool
+	versions    int // page versions kept per page; 0 when none are kept
 }
 
 type subscriber struct {
@@ -60,7 +88,25 @@ type subscriber struct {
 
 // Options configures the content service.
 type Options struct {
-	IncludeHidden bool
+	// FrozenDirs lists wiki-relative directories that reject writes.
+	FrozenDirs []string
+	// ExcludeDirs and IgnoreFile leave content out of the tree; see
+	// tree.Options.
+	ExcludeDirs []string
+	IgnoreFile  string
+	// TreeSort orders the navigation tree; nil means tree.DefaultSort.
+	TreeSort *tree.Sort
+	// TreeCache loads the tree from TreeCacheFile at startup, checking it
+	// against the filesystem in the background, and saves it on Close.
+	TreeCache bool
+	// BuildInBackground returns from NewService before the tree is built.
+	// An empty tree is served until the build finishes; see Indexing.
+	BuildInBackground bool
+	IncludeHidden     bool
+	// PageVersions keeps that many earlier versions of each page under
+	// VersionsDir as pages are saved, unless the root is in a git
+	// repository, whose history already covers it. 0 keeps none.
+	PageVersions int
 }
 
 // NewService initializes content monitoring rooted at path.
@@ -83,13 +129,30 @@ func NewService(parentCtx context.Context, root string, rendererSvc *renderer.Se
 	ctx, cancel := context.WithCancel(parentCtx)
 
 	svc := &Service{
-		root:          absRoot,
-		renderer:      rendererSvc,
-		includeHidden: opts.IncludeHidden,
-		logger:        logger.With("component", "content_service"),
-		ctx:           ctx,
-		cancel:        cancel,
-		subscribers:   make(map[uint64]*subscriber),
+		root:     absRoot,
+		renderer: rendererSvc,
+		visibility: visibility.Options{
+			ExcludeDirs:   opts.ExcludeDirs,
+			IgnoreFile:    opts.IgnoreFile,
+			IncludeHidden: opts.IncludeHidden,
+		},
+		frozenDirs:  opts.FrozenDirs,
+		treeSort:    opts.TreeSort,
+		treeCache:   opts.TreeCache,
+		background:  opts.BuildInBackground,
+		logger:      logger.With("component", "content_service"),
+		ctx:         ctx,
+		cancel:      cancel,
+		subscribers: make(map[uint64]*subscriber),
+	}
+	policy, err := visibility.Load(absRoot, svc.visibility)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	svc.policy.Store(policy)
+	if opts.PageVersions > 0 && !insideGitRepo(absRoot) {
+		svc.versions = opts.PageVersions
 	}
 
 	if err := svc.initTree(ctx); err != nil {
@@ -107,11 +170,17 @@ func NewService(parentCtx context.Context, root string, rendererSvc *renderer.Se
 
 // Close releases resources associated with the service.
 func (s *Service) Close() error {
+	var errs []error
+	if _, indexing := s.Indexing(); s.treeCache && !indexing {
+		if err := s.saveTreeCache(); err != nil {
+			errs = append(errs, fmt.Errorf("save tree cache: %w", err))
+		}
+	}
 	s.cancel()
 	if s.watcher != nil {
-		return s.watcher.Close()
+		errs = append(errs, s.watcher.Close())
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 // CurrentTree returns the cached tree snapshot.
@@ -158,32 +227,65 @@ func (s *Service) Document(ctx context.Context, relPath string) (renderer.Docume
 	return doc, nil
 }
 
+// Renderer returns the markdown renderer the service renders documents with.
+func (s *Service) Renderer() *renderer.Service {
+	return s.renderer
+}
+
+// RenderSource renders raw as if it were the document at relPath, for
+// content that is not on disk, such as an earlier revision.
+func (s *Service) RenderSource(ctx context.Context, relPath string, modTime time.Time, raw []byte) (renderer.Document, error) {
+	if err := ctx.Err(); err != nil {
+		return renderer.Document{}, err
+	}
+	rel, _, err := s.resolveDocumentPath(relPath)
+	if err != nil {
+		return renderer.Document{}, err
+	}
+	return s.renderer.Render(ctx, rel, modTime, raw)
+}
+
 func (s *Service) resolveDocumentPath(relPath string) (string, string, error) {
+	clean, err := cleanRelPath(relPath)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Add .md extension if not present
+	if !strings.HasSuffix(clean, ".md") && !strings.HasSuffix(clean, ".markdown") {
+		clean += ".md"
+	}
+	return s.resolveUnderRoot(relPath, clean)
+}
+
+// cleanRelPath normalizes a wiki-relative path, rejecting absolute paths and
+// parent references.
+func cleanRelPath(relPath string) (string, error) {
 	trimmed := strings.TrimSpace(relPath)
 	if trimmed == "" {
-		return "", "", fmt.Errorf("invalid path: %s", relPath)
+		return "", fmt.Errorf("%w: %s", ErrInvalidPath, relPath)
 	}
 	clean := filepath.Clean(trimmed)
 	if clean == "." || clean == "" {
-		return "", "", fmt.Errorf("invalid path: %s", relPath)
+		return "", fmt.Errorf("%w: %s", ErrInvalidPath, relPath)
 	}
 	if filepath.IsAbs(clean) {
-		return "", "", fmt.Errorf("invalid path: %s", relPath)
+		return "", fmt.Errorf("%w: %s", ErrInvalidPath, relPath)
 	}
 	if vol := filepath.VolumeName(clean); vol != "" {
-		return "", "", fmt.Errorf("invalid path: %s", relPath)
+		return "", fmt.Errorf("%w: %s", ErrInvalidPath, relPath)
 	}
 
 	clean = filepath.ToSlash(clean)
-	if strings.HasPrefix(clean, "../") || strings.Contains(clean, "/../") {
-		return "", "", fmt.Errorf("invalid path: %s", relPath)
-	}
-
-	// Add .md extension if not present
-	if !strings.HasSuffix(clean, ".md") && !strings.HasSuffix(clean, ".markdown") {
-		clean += ".md"
+	if clean == ".." || strings.HasPrefix(clean, "../") || strings.Contains(clean, "/../") {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPath, relPath)
 	}
+	return clean, nil
+}
 
+// resolveUnderRoot returns the absolute path of clean, making sure it stays
+// inside the wiki root.
+func (s *Service) resolveUnderRoot(relPath, clean string) (string, string, error) {
 	abs := filepath.Join(s.root, filepath.FromSlash(clean))
 	abs, err := filepath.Abs(abs)
 	if err != nil {
@@ -194,7 +296,7 @@ func (s *Service) resolveDocumentPath(relPath string) (string, string, error) {
 		return "", "", fmt.Errorf("resolve document path: %w", err)
 	}
 	if relToRoot == ".." || strings.HasPrefix(relToRoot, ".."+string(os.PathSeparator)) {
-		return "", "", fmt.Errorf("resolved path escapes root: %s", relPath)
+		return "", "", fmt.Errorf("%w: resolved path escapes root: %s", ErrInvalidPath, relPath)
 	}
 	return clean, abs, nil
 }
@@ -220,11 +322,55 @@ func (s *Service) Subscribe(ctx context.Context) <-chan Event {
 }
 
 func (s *Service) initTree(ctx context.Context) error {
-	node, err := tree.Build(ctx, s.root, tree.Options{Renderer: s.renderer, IncludeHidden: s.includeHidden})
+	if s.treeCache {
+		if cache, ok := s.loadTreeCache(); ok {
+			s.storeTree(cache.Root)
+			go s.validateTreeCache(cache)
+			return nil
+		}
+	}
+	if s.background {
+		s.startIndexing()
+		return nil
+	}
+	node, err := tree.Build(ctx, s.root, s.treeOptions())
 	if err != nil {
 		return err
 	}
-	s.tree.Store(node)
+	s.storeTree(node)
+	return nil
+}
+
+// treeOptions returns the options for a tree build. The visibility policy
+// is reloaded so edits to the ignore file apply; when it no longer loads,
+// tree.Build reports why.
+func (s *Service) treeOptions() tree.Options {
+	opts := tree.Options{
+		Renderer:      s.renderer,
+		IncludeHidden: s.visibility.IncludeHidden,
+		FrozenDirs:    s.frozenDirs,
+		ExcludeDirs:   s.visibility.ExcludeDirs,
+		IgnoreFile:    s.visibility.IgnoreFile,
+		Sort:          s.treeSort,
+	}
+	if policy, err := visibility.Load(s.root, s.visibility); err == nil {
+		s.policy.Store(policy)
+		opts.Policy = policy
+	}
+	return opts
+}
+
+// Visibility returns the policy deciding which files under the root are
+// part of the wiki, as of the latest tree build.
+func (s *Service) Visibility() *visibility.Policy {
+	return s.policy.Load()
+}
+
+// checkWritable rejects writes to documents inside frozen directories.
+func (s *Service) checkWritable(rel string) error {
+	if tree.IsFrozen(rel, s.frozenDirs) {
+		return fmt.Errorf("%s is read-only: %w", rel, ErrFrozen)
+	}
 	return nil
 }
 
@@ -287,12 +433,16 @@ func (s *Service) handleEvent(event fsnotify.Event) {
 	eventType := classifyEvent(event.Name, op, isMarkdown)
 
 	rebuildOK := s.rebuildTree()
-	if !rebuildOK && (eventType == eventTypeTreeUpdated || eventType == eventTypeDeleted) {
+	if rebuildOK && s.visibility.IgnoreFile != "" && rel == s.visibility.IgnoreFile {
+		// Directories the old patterns ignored are not watched yet.
+		_ = s.watchRecursive(s.root)
+	}
+	if !rebuildOK && (eventType == EventTreeUpdated || eventType == EventDeleted) {
 		s.logger.Warn("skipping tree broadcast due to rebuild failure", slog.String("path", rel))
 		return
 	}
 
-	s.broadcast(Event{Type: eventType, Path: rel, Timestamp: time.Now()})
+	s.broadcast(Event{Type: eventType, Path: rel, Timestamp: time.Now(), Generation: s.Generation()})
 }
 
 func (s *Service) rebuildTree() bool {
@@ -302,12 +452,12 @@ func (s *Service) rebuildTree() bool {
 	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
 	defer cancel()
 
-	node, err := tree.Build(ctx, s.root, tree.Options{Renderer: s.renderer, IncludeHidden: s.includeHidden})
+	node, err := tree.Build(ctx, s.root, s.treeOptions())
 	if err != nil {
 		s.logger.Error("rebuild tree failed", slog.Any("err", err))
 		return false
 	}
-	s.tree.Store(node)
+	s.storeTree(node)
 	return true
 }
 
@@ -349,7 +499,7 @@ func (s *Service) watchRecursive(dir string) error {
 			return err
 		}
 		if d.IsDir() {
-			if !s.includeHidden && strings.HasPrefix(d.Name(), ".") && path != s.root {
+			if path != s.root && s.Visibility().SkipDir(s.relativePath(path)) {
 				return filepath.SkipDir
 			}
 			if err := s.watcher.Add(path); err != nil {
@@ -380,6 +530,9 @@ func (s *Service) SaveDocument(ctx context.Context, relPath string, data []byte)
 	if !isMarkdownPath(rel) {
 		return fmt.Errorf("updates allowed for markdown documents only: %s", rel)
 	}
+	if err := s.checkWritable(rel); err != nil {
+		return err
+	}
 
 	s.writeMu.Lock()
 	defer s.writeMu.Unlock()
@@ -395,6 +548,7 @@ func (s *Service) SaveDocument(ctx context.Context, relPath string, data []byte)
 		return fmt.Errorf("ensure directory: %w", err)
 	}
 
+	s.recordVersion(rel, abs)
 	if err := writeFileAtomic(abs, data); err != nil {
 		return err
 	}
@@ -415,6 +569,9 @@ func (s *Service) CreateDocument(ctx context.Context, relPath string, data []byt
 	if !isMarkdownPath(rel) {
 		return fmt.Errorf("only markdown documents are supported: %s", rel)
 	}
+	if err := s.checkWritable(rel); err != nil {
+		return err
+	}
 
 	s.writeMu.Lock()
 	defer s.writeMu.Unlock()
@@ -437,6 +594,49 @@ func (s *Service) CreateDocument(ctx context.Context, relPath string, data []byt
 	return nil
 }
 
+// CreateAsset writes a new non-markdown file, such as an image, under the
+// wiki root. It never replaces an existing file.
+func (s *Service) CreateAsset(ctx context.Context, relPath string, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	clean, err := cleanRelPath(relPath)
+	if err != nil {
+		return err
+	}
+	if isMarkdownPath(clean) {
+		return fmt.Errorf("%w: assets cannot be markdown: %s", ErrInvalidPath, relPath)
+	}
+	rel, abs, err := s.resolveUnderRoot(relPath, clean)
+	if err != nil {
+		return err
+	}
+	if err := s.checkWritable(rel); err != nil {
+		return err
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil { //nolint:gosec // standard directory permissions
+		return fmt.Errorf("ensure directory: %w", err)
+	}
+	f, err := os.OpenFile(abs, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644) //nolint:gosec // path is resolved under the root
+	if err != nil {
+		return fmt.Errorf("create asset: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(abs)
+		return fmt.Errorf("write asset: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(abs)
+		return fmt.Errorf("close asset: %w", err)
+	}
+	return nil
+}
+
 // RenameDocument renames an existing markdown document to a new path.
 func (s *Service) RenameDocument(ctx context.Context, fromPath, toPath string) error {
 	if err := ctx.Err(); err != nil {
@@ -453,6 +653,12 @@ func (s *Service) RenameDocument(ctx context.Context, fromPath, toPath string) e
 	if !isMarkdownPath(fromRel) || !isMarkdownPath(toRel) {
 		return fmt.Errorf("rename supported for markdown documents only")
 	}
+	if err := s.checkWritable(fromRel); err != nil {
+		return err
+	}
+	if err := s.checkWritable(toRel); err != nil {
+		return err
+	}
 
 	s.writeMu.Lock()
 	defer s.writeMu.Unlock()
@@ -477,6 +683,7 @@ func (s *Service) RenameDocument(ctx context.Context, fromPath, toPath string) e
 	if err := os.Rename(fromAbs, toAbs); err != nil {
 		return fmt.Errorf("rename document: %w", err)
 	}
+	s.moveVersions(fromRel, toRel)
 
 	s.renderer.Invalidate(fromAbs)
 	s.renderer.Invalidate(toAbs)
@@ -495,6 +702,9 @@ func (s *Service) DeleteDocument(ctx context.Context, relPath string) error {
 	if !isMarkdownPath(rel) {
 		return fmt.Errorf("delete supported for markdown documents only: %s", rel)
 	}
+	if err := s.checkWritable(rel); err != nil {
+		return err
+	}
 
 	s.writeMu.Lock()
 	defer s.writeMu.Unlock()
@@ -506,6 +716,7 @@ func (s *Service) DeleteDocument(ctx context.Context, relPath string) error {
 		return fmt.Errorf("stat document: %w", err)
 	}
 
+	s.recordVersion(rel, abs)
 	if err := os.Remove(abs); err != nil {
 		return fmt.Errorf("delete document: %w", err)
 	}
@@ -555,20 +766,20 @@ func classifyEvent(path string, op fsnotify.Op, isMarkdown bool) string {
 	case op&fsnotify.Remove != 0:
 		if isMarkdown {
 			if _, err := os.Stat(path); err == nil {
-				return eventTypePageUpdated
+				return EventPageUpdated
 			}
-			return eventTypeDeleted
+			return EventDeleted
 		}
-		return eventTypeTreeUpdated
+		return EventTreeUpdated
 	case op&fsnotify.Rename != 0:
-		return eventTypeTreeUpdated
+		return EventTreeUpdated
 	case op&(fsnotify.Write|fsnotify.Create) != 0:
 		if isMarkdown {
-			return eventTypePageUpdated
+			return EventPageUpdated
 		}
-		return eventTypeTreeUpdated
+		return EventTreeUpdated
 	default:
-		return eventTypeUnknown
+		return EventUnknown
 	}
 }
 
@@ -576,7 +787,7 @@ func classifyEvent(path string, op fsnotify.Op, isMarkdown bool) string {
 func (s *Service) DebugStatus() map[string]any {
 	res := map[string]any{
 		"root":          s.root,
-		"includeHidden": s.includeHidden,
+		"includeHidden": s.visibility.IncludeHidden,
 	}
 	if w := s.watcher; w != nil {
 		res["watcher"] = map[string]any{
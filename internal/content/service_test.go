@@ -1,11 +1,14 @@
 package content_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -359,3 +362,500 @@ func copyDir(t *testing.T, src, dst string) {
 		t.Fatalf("copyDir failed: %v", err)
 	}
 }
+
+func TestFrozenDirectoriesRejectWrites(t *testing.T) {
+	t.Parallel()
+
+	src := filepath.Join("..", "..", "testdata", "wiki")
+	dst := t.TempDir()
+	copyDir(t, src, dst)
+
+	renderSvc := renderer.NewService(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+	svc, err := content.NewService(ctx, dst, renderSvc, slog.New(slog.NewTextHandler(io.Discard, nil)), content.Options{FrozenDirs: []string{"guides"}})
+	if err != nil {
+		t.Fatalf("NewService failed: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+
+	if err := svc.SaveDocument(ctx, "guides/getting_started.md", []byte("# Changed\n")); !errors.Is(err, content.ErrFrozen) {
+		t.Fatalf("expected ErrFrozen on save, got %v", err)
+	}
+	if err := svc.RenameDocument(ctx, "index.md", "guides/index.md"); !errors.Is(err, content.ErrFrozen) {
+		t.Fatalf("expected ErrFrozen when renaming into a frozen directory, got %v", err)
+	}
+	if err := svc.DeleteDocument(ctx, "guides/advanced_topics.md"); !errors.Is(err, content.ErrFrozen) {
+		t.Fatalf("expected ErrFrozen on delete, got %v", err)
+	}
+	if err := svc.SaveDocument(ctx, "index.md", []byte("# Still writable\n")); err != nil {
+		t.Fatalf("expected writes outside frozen directories to succeed, got %v", err)
+	}
+
+	root, err := svc.CurrentTree(ctx)
+	if err != nil {
+		t.Fatalf("CurrentTree error: %v", err)
+	}
+	for _, child := range root.Children {
+		if want := child.RelativePath == "guides"; child.ReadOnly != want {
+			t.Errorf("node %s: ReadOnly = %v, want %v", child.RelativePath, child.ReadOnly, want)
+		}
+	}
+}
+
+func TestArchiveDocumentMovesPageAndRecordsRedirect(t *testing.T) {
+	t.Parallel()
+
+	src := filepath.Join("..", "..", "testdata", "wiki")
+	dst := t.TempDir()
+	copyDir(t, src, dst)
+
+	renderSvc := renderer.NewService(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+	svc, err := content.NewService(ctx, dst, renderSvc, slog.New(slog.NewTextHandler(io.Discard, nil)), content.Options{})
+	if err != nil {
+		t.Fatalf("NewService failed: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+
+	dest, err := svc.ArchiveDocument(ctx, "guides/getting_started.md")
+	if err != nil {
+		t.Fatalf("ArchiveDocument failed: %v", err)
+	}
+	if dest != "archive/guides/getting_started.md" {
+		t.Fatalf("unexpected archive path %q", dest)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "archive", "guides", "getting_started.md")); err != nil {
+		t.Fatalf("expected archived file on disk: %v", err)
+	}
+	if got, ok := svc.Redirect("guides/getting_started.md"); !ok || got != dest {
+		t.Fatalf("expected redirect to %s, got %q (%v)", dest, got, ok)
+	}
+	if _, err := svc.ArchiveDocument(ctx, dest); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("expected archiving an archived page to fail with ErrExist, got %v", err)
+	}
+}
+
+func TestTreeDeltaReportsChangedAndRemovedNodes(t *testing.T) {
+	t.Parallel()
+
+	src := filepath.Join("..", "..", "testdata", "wiki")
+	dst := t.TempDir()
+	copyDir(t, src, dst)
+
+	renderSvc := renderer.NewService(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	svc, err := content.NewService(ctx, dst, renderSvc, slog.New(slog.NewTextHandler(io.Discard, nil)), content.Options{})
+	if err != nil {
+		t.Fatalf("NewService failed: %v", err)
+	}
+	t.Cleanup(func() { svc.Close() })
+
+	waitForGeneration := func(after uint64) uint64 {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if gen := svc.Generation(); gen > after {
+				return gen
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("tree generation did not advance past %d", after)
+		return 0
+	}
+
+	start := svc.Generation()
+	if start == 0 {
+		t.Fatal("expected initial tree generation")
+	}
+	if root, gen, err := svc.TreeWithGeneration(ctx); err != nil || root == nil || gen != start {
+		t.Fatalf("expected tree at generation %d, got %v, %d, %v", start, root != nil, gen, err)
+	}
+	unchanged, err := svc.TreeDelta(ctx, start)
+	if err != nil {
+		t.Fatalf("TreeDelta error: %v", err)
+	}
+	if unchanged.Full || len(unchanged.Changed) != 0 || len(unchanged.Removed) != 0 {
+		t.Fatalf("expected empty delta at current generation, got %+v", unchanged)
+	}
+
+	// Give the watcher time to attach.
+	time.Sleep(200 * time.Millisecond)
+	if err := svc.CreateDocument(ctx, "delta.md", []byte("# Delta\n")); err != nil {
+		t.Fatalf("CreateDocument error: %v", err)
+	}
+	created := waitForGeneration(start)
+
+	delta, err := svc.TreeDelta(ctx, start)
+	if err != nil {
+		t.Fatalf("TreeDelta error: %v", err)
+	}
+	if delta.Full || delta.Generation != created {
+		t.Fatalf("expected partial delta at generation %d, got %+v", created, delta)
+	}
+	changed := make(map[string]bool)
+	for _, n := range delta.Changed {
+		changed[n.RelativePath] = true
+		if len(n.Children) != 0 {
+			t.Errorf("expected %q without children", n.RelativePath)
+		}
+	}
+	if !changed["delta.md"] || !changed[""] {
+		t.Fatalf("expected new page and root in changed nodes, got %v", changed)
+	}
+	if changed["index.md"] {
+		t.Fatal("expected untouched page to be left out of the delta")
+	}
+
+	if err := svc.DeleteDocument(ctx, "delta.md"); err != nil {
+		t.Fatalf("DeleteDocument error: %v", err)
+	}
+	waitForGeneration(created)
+	delta, err = svc.TreeDelta(ctx, created)
+	if err != nil {
+		t.Fatalf("TreeDelta error: %v", err)
+	}
+	if len(delta.Removed) != 1 || delta.Removed[0] != "delta.md" {
+		t.Fatalf("expected delta.md removed, got %v", delta.Removed)
+	}
+
+	full, err := svc.TreeDelta(ctx, 9999)
+	if err != nil {
+		t.Fatalf("TreeDelta error: %v", err)
+	}
+	if !full.Full || full.Tree == nil {
+		t.Fatalf("expected full tree for unknown generation, got %+v", full)
+	}
+}
+
+func TestTreeCacheServesSnapshotAndRevalidates(t *testing.T) {
+	t.Parallel()
+
+	src := filepath.Join("..", "..", "testdata", "wiki")
+	dst := t.TempDir()
+	copyDir(t, src, dst)
+
+	renderSvc := renderer.NewService(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	opts := content.Options{TreeCache: true}
+
+	titleOf := func(svc *content.Service, rel string) string {
+		t.Helper()
+		root, err := svc.CurrentTree(ctx)
+		if err != nil {
+			t.Fatalf("CurrentTree error: %v", err)
+		}
+		for _, child := range root.Children {
+			if child.RelativePath == rel {
+				return child.Title
+			}
+		}
+		return ""
+	}
+
+	first, err := content.NewService(ctx, dst, renderSvc, logger, opts)
+	if err != nil {
+		t.Fatalf("NewService failed: %v", err)
+	}
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	cachePath := filepath.Join(dst, filepath.FromSlash(content.TreeCacheFile))
+	raw, err := os.ReadFile(cachePath)
+	if err != nil {
+		t.Fatalf("expected tree cache to be written: %v", err)
+	}
+
+	// A title only the snapshot knows proves the cached tree is served when
+	// nothing on disk changed.
+	marked := bytes.ReplaceAll(raw, []byte(`"title":"Welcome Home"`), []byte(`"title":"From Cache"`))
+	if bytes.Equal(marked, raw) {
+		t.Fatal("expected cached tree to contain the index title")
+	}
+	if err := os.WriteFile(cachePath, marked, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cached, err := content.NewService(ctx, dst, renderSvc, logger, opts)
+	if err != nil {
+		t.Fatalf("NewService failed: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if got := titleOf(cached, "index.md"); got != "From Cache" {
+		t.Fatalf("expected snapshot title, got %q", got)
+	}
+	_ = cached.Close()
+
+	// Editing a page while the server is down invalidates the snapshot.
+	if err := os.WriteFile(cachePath, marked, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dst, "index.md"), []byte("---\ntitle: Edited Offline\n---\n# Home\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stale, err := content.NewService(ctx, dst, renderSvc, logger, opts)
+	if err != nil {
+		t.Fatalf("NewService failed: %v", err)
+	}
+	t.Cleanup(func() { stale.Close() })
+	deadline := time.Now().Add(2 * time.Second)
+	for titleOf(stale, "index.md") != "Edited Offline" {
+		if time.Now().After(deadline) {
+			t.Fatalf("stale snapshot was not rebuilt, title %q", titleOf(stale, "index.md"))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestBuildInBackgroundServesPlaceholderUntilIndexed(t *testing.T) {
+	t.Parallel()
+
+	src := filepath.Join("..", "..", "testdata", "wiki")
+	dst := t.TempDir()
+	copyDir(t, src, dst)
+
+	renderSvc := renderer.NewService(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	svc, err := content.NewService(ctx, dst, renderSvc, slog.New(slog.NewTextHandler(io.Discard, nil)), content.Options{BuildInBackground: true})
+	if err != nil {
+		t.Fatalf("NewService failed: %v", err)
+	}
+	t.Cleanup(func() { svc.Close() })
+
+	root, err := svc.CurrentTree(ctx)
+	if err != nil {
+		t.Fatalf("expected a tree while indexing, got %v", err)
+	}
+	if _, indexing := svc.Indexing(); indexing && len(root.Children) != 0 {
+		t.Fatalf("expected empty placeholder while indexing, got %d children", len(root.Children))
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		scanned, indexing := svc.Indexing()
+		if !indexing {
+			if scanned == 0 {
+				t.Fatal("expected scanned documents to be counted")
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("background index did not finish")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	root, err = svc.CurrentTree(ctx)
+	if err != nil {
+		t.Fatalf("CurrentTree error: %v", err)
+	}
+	if len(root.Children) == 0 {
+		t.Fatal("expected the built tree after indexing")
+	}
+}
+
+func TestFileDescribesAttachments(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	outside := t.TempDir()
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	files := map[string][]byte{
+		"index.md":         []byte("# Home\n"),
+		"assets/chart.png": png,
+		"assets/notes.txt": []byte("plain notes\n"),
+		"assets/logo.svg":  []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`),
+		".env":             []byte("SECRET=1\n"),
+		"scratch/todo.txt": []byte("excluded\n"),
+	}
+	for rel, data := range files {
+		abs := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret.png"), png, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.png"), filepath.Join(root, "escape.png")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	svc, err := content.NewService(ctx, root, renderer.NewService(logger), logger, content.Options{ExcludeDirs: []string{"scratch"}})
+	if err != nil {
+		t.Fatalf("NewService failed: %v", err)
+	}
+	t.Cleanup(func() { svc.Close() })
+
+	for path, want := range map[string]string{
+		"index.md":         content.FileMarkdown,
+		"assets/chart.png": content.FileImage,
+		"assets/notes.txt": content.FileText,
+		"assets/logo.svg":  content.FileImage,
+	} {
+		info, err := svc.File(ctx, path)
+		if err != nil {
+			t.Fatalf("File(%s): %v", path, err)
+		}
+		if info.Type != want || info.Path != path || info.Size != int64(len(files[path])) || info.MIME == "" || info.Modified.IsZero() {
+			t.Errorf("File(%s) = %+v, want type %s", path, info, want)
+		}
+	}
+
+	for _, path := range []string{".env", "scratch/todo.txt", "escape.png", "missing.pdf"} {
+		if _, err := svc.File(ctx, path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("File(%s) error = %v, want not found", path, err)
+		}
+	}
+	for _, path := range []string{"../outside.png", "assets"} {
+		if _, err := svc.File(ctx, path); !errors.Is(err, content.ErrInvalidPath) {
+			t.Errorf("File(%s) error = %v, want invalid path", path, err)
+		}
+	}
+}
+
+func TestPageVersionsKeepEarlierSaves(t *testing.T) {
+	t.Parallel()
+
+	src := filepath.Join("..", "..", "testdata", "wiki")
+	dst := t.TempDir()
+	copyDir(t, src, dst)
+
+	renderSvc := renderer.NewService(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+	svc, err := content.NewService(ctx, dst, renderSvc, slog.New(slog.NewTextHandler(io.Discard, nil)), content.Options{PageVersions: 3})
+	if err != nil {
+		t.Fatalf("NewService failed: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+	if !svc.VersionsEnabled() {
+		t.Fatal("expected versions outside a git repository")
+	}
+
+	const page = "guides/getting_started.md"
+	original, err := os.ReadFile(filepath.Join(dst, "guides", "getting_started.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, body := range []string{"# One\n", "# Two\n", "# Two\n", "# Three\n", "# Four\n"} {
+		if err := svc.SaveDocument(ctx, page, []byte(body)); err != nil {
+			t.Fatalf("SaveDocument failed: %v", err)
+		}
+	}
+
+	versions, err := svc.PageVersions(page)
+	if err != nil {
+		t.Fatalf("PageVersions failed: %v", err)
+	}
+	if len(versions) != 3 {
+		t.Fatalf("expected the limit of 3 versions, got %+v", versions)
+	}
+	want := []string{"# Three\n", "# Two\n", "# One\n"}
+	for i, v := range versions {
+		_, data, err := svc.PageVersionContent(page, v.ID)
+		if err != nil {
+			t.Fatalf("PageVersionContent(%s) failed: %v", v.ID, err)
+		}
+		if string(data) != want[i] {
+			t.Errorf("version %d: got %q, want %q", i, data, want[i])
+		}
+	}
+	if _, _, err := svc.PageVersionContent(page, "../../index.md"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a malformed id to be not found, got %v", err)
+	}
+	if bytes.Equal(original, []byte(want[2])) {
+		t.Fatal("test page should differ from the saved versions")
+	}
+
+	if err := svc.RestorePageVersion(ctx, page, versions[2].ID); err != nil {
+		t.Fatalf("RestorePageVersion failed: %v", err)
+	}
+	restored, err := os.ReadFile(filepath.Join(dst, "guides", "getting_started.md"))
+	if err != nil || string(restored) != "# One\n" {
+		t.Fatalf("expected the restored content, got %q (%v)", restored, err)
+	}
+	versions, err = svc.PageVersions(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, data, _ := svc.PageVersionContent(page, versions[0].ID); string(data) != "# Four\n" {
+		t.Errorf("expected the replaced content kept as the newest version, got %q", data)
+	}
+
+	if err := svc.DeleteDocument(ctx, page); err != nil {
+		t.Fatalf("DeleteDocument failed: %v", err)
+	}
+	if err := svc.RestorePageVersion(ctx, page, versions[0].ID); err != nil {
+		t.Fatalf("expected a deleted page to be restorable, got %v", err)
+	}
+
+	root, err := svc.CurrentTree(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, child := range root.Children {
+		if strings.HasPrefix(child.RelativePath, ".wikimd") {
+			t.Errorf("expected the history directory hidden from the tree, got %s", child.RelativePath)
+		}
+	}
+}
+
+func TestRenameMergesVersionsOfDeletedPage(t *testing.T) {
+	t.Parallel()
+
+	dst := t.TempDir()
+	copyDir(t, filepath.Join("..", "..", "testdata", "wiki"), dst)
+
+	renderSvc := renderer.NewService(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+	svc, err := content.NewService(ctx, dst, renderSvc, slog.New(slog.NewTextHandler(io.Discard, nil)), content.Options{PageVersions: 10})
+	if err != nil {
+		t.Fatalf("NewService failed: %v", err)
+	}
+	t.Cleanup(func() { _ = svc.Close() })
+
+	// The deleted page leaves its content as a version.
+	const deleted, renamed = "guides/advanced_topics.md", "guides/getting_started.md"
+	if err := svc.SaveDocument(ctx, deleted, []byte("# Deleted\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.DeleteDocument(ctx, deleted); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SaveDocument(ctx, renamed, []byte("# Renamed\n")); err != nil {
+		t.Fatal(err)
+	}
+	before, err := svc.PageVersions(deleted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moving, err := svc.PageVersions(renamed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.RenameDocument(ctx, renamed, deleted); err != nil {
+		t.Fatalf("RenameDocument failed: %v", err)
+	}
+	after, err := svc.PageVersions(deleted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(before)+len(moving) {
+		t.Fatalf("expected %d + %d merged versions, got %+v", len(before), len(moving), after)
+	}
+	found := false
+	for _, v := range after {
+		if _, data, _ := svc.PageVersionContent(deleted, v.ID); string(data) == "# Deleted\n" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected the deleted page's history to survive the rename")
+	}
+}
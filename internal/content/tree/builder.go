@@ -8,11 +8,11 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 	"time"
 
 	"github.com/euforicio/wikimd/internal/renderer"
+	"github.com/euforicio/wikimd/internal/visibility"
 )
 
 // NodeType identifies what a tree node represents.
@@ -36,15 +36,61 @@ type Node struct {
 	Title        string             `json:"title"`
 	Children     []*Node            `json:"children,omitempty"`
 	Size         int64              `json:"size"`
+	ReadOnly     bool               `json:"readOnly,omitempty"`
+	// Documents counts the markdown files anywhere below a directory, and a
+	// directory's Size is their combined size in bytes.
+	Documents int `json:"documents,omitempty"`
+	// HasDashboard is set on directories that contain a DashboardFile.
+	HasDashboard bool `json:"hasDashboard,omitempty"`
+	// Icon is an emoji (or other short text) and IconImage a wiki-relative
+	// image path from the icon frontmatter key; at most one is set. Color is
+	// a CSS color from the color key.
+	Icon      string `json:"icon,omitempty"`
+	IconImage string `json:"iconImage,omitempty"`
+	Color     string `json:"color,omitempty"`
+	// Hash fingerprints the node and its subtree (see Checksum).
+	Hash string `json:"hash,omitempty"`
 }
 
+// DashboardFile is the per-directory file describing a generated landing page.
+const DashboardFile = "_dashboard.yaml"
+
 // Options control how the tree is constructed.
 type Options struct {
-	Renderer      *renderer.Service
-	ExcludeDirs   []string
+	Renderer *renderer.Service
+	// Policy decides which files and directories are part of the tree.
+	// When nil, one is loaded from ExcludeDirs, IgnoreFile, and
+	// IncludeHidden; see visibility.Options.
+	Policy      *visibility.Policy
+	ExcludeDirs []string
+	IgnoreFile  string
+	// FrozenDirs lists wiki-relative directories whose nodes are marked
+	// ReadOnly.
+	FrozenDirs []string
+	// Sort orders siblings; nil means DefaultSort.
+	Sort *Sort
+	// Progress, when set, is called after each document with the number of
+	// documents built so far.
+	Progress      func(files int)
 	IncludeHidden bool
 }
 
+// IsFrozen reports whether the wiki-relative path rel is one of dirs or lies
+// beneath one of them.
+func IsFrozen(rel string, dirs []string) bool {
+	rel = strings.Trim(normalizeRelative(rel), "/")
+	for _, dir := range dirs {
+		dir = strings.Trim(normalizeRelative(dir), "/")
+		if dir == "" {
+			continue
+		}
+		if rel == dir || strings.HasPrefix(rel, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 // Build walks the root directory and returns a tree of markdown content.
 func Build(ctx context.Context, root string, opts Options) (*Node, error) {
 	if root == "" {
@@ -64,55 +110,33 @@ func Build(ctx context.Context, root string, opts Options) (*Node, error) {
 		return nil, fmt.Errorf("root %s is not a directory", absRoot)
 	}
 
-	b := newBuilder(absRoot, opts)
-
-	return b.buildDir(ctx, absRoot, "")
-}
-
-// builder carries state during tree construction.
-type builder struct {
-	exclude map[string]struct{}
-	root    string
-	opts    Options
-}
-
-var defaultExcludedDirs = []string{
-	"node_modules",
-	"vendor",
-	"venv",
-	".venv",
-	"deps",
-	"third_party",
-	".git",
-	".hg",
-	".svn",
-	".idea",
-	".vscode",
-	"__pycache__",
-}
-
-func newBuilder(absRoot string, opts Options) *builder {
-	exclude := make(map[string]struct{})
-	for _, name := range defaultExcludedDirs {
-		if name = strings.TrimSpace(name); name != "" {
-			exclude[strings.ToLower(name)] = struct{}{}
-		}
-	}
-	for _, name := range opts.ExcludeDirs {
-		if name = strings.TrimSpace(name); name != "" {
-			exclude[strings.ToLower(name)] = struct{}{}
+	policy := opts.Policy
+	if policy == nil {
+		if policy, err = visibility.Load(absRoot, opts.Visibility()); err != nil {
+			return nil, err
 		}
 	}
-	return &builder{
-		root:    absRoot,
-		opts:    opts,
-		exclude: exclude,
+	b := &builder{root: absRoot, opts: opts, policy: policy}
+
+	node, err := b.buildDir(ctx, absRoot, "")
+	if err != nil {
+		return nil, err
 	}
+	Checksum(node)
+	return node, nil
 }
 
-func (b *builder) isExcluded(name string) bool {
-	_, ok := b.exclude[strings.ToLower(name)]
-	return ok
+// Visibility returns the visibility settings in o.
+func (o Options) Visibility() visibility.Options {
+	return visibility.Options{ExcludeDirs: o.ExcludeDirs, IgnoreFile: o.IgnoreFile, IncludeHidden: o.IncludeHidden}
+}
+
+// builder carries state during tree construction.
+type builder struct {
+	policy *visibility.Policy
+	root   string
+	opts   Options
+	files  int
 }
 
 //nolint:gocognit,gocyclo // directory traversal naturally requires multiple decision points
@@ -127,16 +151,17 @@ func (b *builder) buildDir(ctx context.Context, absPath, relPath string) (*Node,
 	}
 
 	children := make([]*Node, 0, len(entries))
+	hasDashboard := false
 	for _, entry := range entries {
-		if !b.opts.IncludeHidden && strings.HasPrefix(entry.Name(), ".") {
+		if entry.Name() == DashboardFile && !entry.IsDir() {
+			hasDashboard = true
 			continue
 		}
-
 		childRel := filepath.Join(relPath, entry.Name())
 		childAbs := filepath.Join(absPath, entry.Name())
 
 		if entry.IsDir() {
-			if b.isExcluded(entry.Name()) {
+			if b.policy.SkipDir(childRel) {
 				continue
 			}
 			childNode, err := b.buildDir(ctx, childAbs, childRel)
@@ -150,7 +175,7 @@ func (b *builder) buildDir(ctx context.Context, absPath, relPath string) (*Node,
 			continue
 		}
 
-		if !isMarkdown(entry) {
+		if !isMarkdown(entry) || b.policy.SkipFile(childRel) {
 			continue
 		}
 
@@ -164,18 +189,21 @@ func (b *builder) buildDir(ctx context.Context, absPath, relPath string) (*Node,
 			return nil, err
 		}
 		children = append(children, node)
+		b.files++
+		if b.opts.Progress != nil {
+			b.opts.Progress(b.files)
+		}
 	}
 
 	if len(children) == 0 && relPath != "" {
 		return nil, nil
 	}
 
-	sort.SliceStable(children, func(i, j int) bool {
-		if children[i].Type == children[j].Type {
-			return strings.Compare(children[i].Title, children[j].Title) < 0
-		}
-		return children[i].Type == NodeTypeDirectory
-	})
+	order := DefaultSort
+	if b.opts.Sort != nil {
+		order = *b.opts.Sort
+	}
+	sortChildren(children, order)
 
 	dispName := directoryDisplayName(b.root, relPath)
 	rel := normalizeRelative(relPath)
@@ -186,6 +214,17 @@ func (b *builder) buildDir(ctx context.Context, absPath, relPath string) (*Node,
 		return nil, fmt.Errorf("stat directory %s: %w", absPath, err)
 	}
 
+	var documents int
+	var size int64
+	for _, child := range children {
+		if child.Type == NodeTypeFile {
+			documents++
+		} else {
+			documents += child.Documents
+		}
+		size += child.Size
+	}
+
 	return &Node{
 		Name:         dispName,
 		RawName:      filepath.Base(absPath),
@@ -195,6 +234,10 @@ func (b *builder) buildDir(ctx context.Context, absPath, relPath string) (*Node,
 		Title:        dispName,
 		Modified:     dirInfo.ModTime(),
 		Children:     children,
+		Documents:    documents,
+		Size:         size,
+		ReadOnly:     IsFrozen(rel, b.opts.FrozenDirs),
+		HasDashboard: hasDashboard,
 	}, nil
 }
 
@@ -230,7 +273,7 @@ func (b *builder) buildFileNode(ctx context.Context, absPath, relPath string, in
 		}
 	}
 
-	return &Node{
+	node := &Node{
 		Name:         display,
 		RawName:      filepath.Base(relPath),
 		RelativePath: rel,
@@ -240,7 +283,10 @@ func (b *builder) buildFileNode(ctx context.Context, absPath, relPath string, in
 		Metadata:     meta,
 		Modified:     info.ModTime(),
 		Size:         info.Size(),
-	}, nil
+		ReadOnly:     IsFrozen(rel, b.opts.FrozenDirs),
+	}
+	decorate(node, rel, meta)
+	return node, nil
 }
 
 func isMarkdown(entry fs.DirEntry) bool {
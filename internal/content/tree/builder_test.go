@@ -4,8 +4,10 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/euforicio/wikimd/internal/content/tree"
 	"github.com/euforicio/wikimd/internal/renderer"
@@ -167,3 +169,257 @@ func TestDependencyDirectoriesExcluded(t *testing.T) {
 		}
 	}
 }
+
+func TestExcludeDirsAndIgnoreFile(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	files := map[string]string{
+		"index.md":               "# Home",
+		"drafts/idea.md":         "# Idea",
+		"guides/drafts/wip.md":   "# WIP",
+		"guides/setup.md":        "# Setup",
+		"guides/setup.draft.md":  "# Setup draft",
+		"build/output.md":        "# Generated",
+		"archive/2020/old.md":    "# Old",
+		tree.DefaultIgnoreFile:   "# comments and blank lines are skipped\n\n/drafts/\n*.draft.md\narchive/2020\n",
+		"notes/build/gen.md":     "# Generated",
+		"notes/drafts/nested.md": "# Nested",
+	}
+	for rel, body := range files {
+		abs := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	node, err := tree.Build(context.Background(), root, tree.Options{
+		ExcludeDirs: []string{"Build"},
+		IgnoreFile:  tree.DefaultIgnoreFile,
+	})
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+
+	var got []string
+	var walk func(*tree.Node)
+	walk = func(n *tree.Node) {
+		if n.Type == tree.NodeTypeFile {
+			got = append(got, n.RelativePath)
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(node)
+	sort.Strings(got)
+	want := []string{"guides/drafts/wip.md", "guides/setup.md", "index.md", "notes/drafts/nested.md"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, tree.DefaultIgnoreFile), []byte("[\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.Build(context.Background(), root, tree.Options{IgnoreFile: tree.DefaultIgnoreFile}); err == nil {
+		t.Fatal("expected an invalid pattern to be reported")
+	}
+}
+
+func TestListingSkipsCurrentPageAndHonoursDepth(t *testing.T) {
+	t.Parallel()
+	root := filepath.Join("..", "..", "..", "testdata", "wiki")
+
+	node, err := tree.Build(context.Background(), root, tree.Options{Renderer: renderer.NewService(nil)})
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	href := func(rel string) string { return "/page/" + rel }
+
+	shallow := tree.Listing(node, "index.md", 1, href)
+	for _, item := range shallow {
+		if item.Href == "/page/index.md" {
+			t.Fatalf("listing should not include the current page")
+		}
+		if item.Title == "guides" && len(item.Children) != 0 {
+			t.Fatalf("depth 1 should not descend into directories: %+v", item)
+		}
+	}
+
+	deep := tree.Listing(node, "index.md", 2, href)
+	var guides *renderer.ListItem
+	for i := range deep {
+		if deep[i].Title == "guides" {
+			guides = &deep[i]
+		}
+	}
+	if guides == nil || len(guides.Children) != 2 {
+		t.Fatalf("expected guides with two pages at depth 2, got %+v", deep)
+	}
+
+	if siblings := tree.Listing(node, "guides/getting_started.md", 1, href); len(siblings) != 1 {
+		t.Fatalf("expected one sibling page in guides, got %+v", siblings)
+	}
+}
+
+func TestBuildCarriesIconAndColorFrontmatter(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	files := map[string]string{
+		"rocket.md":       "---\nicon: 🚀\ncolor: \"#f97316\"\n---\n# Rocket\n",
+		"docs/logo.md":    "---\nicon: ../assets/logo.svg\ncolor: teal\n---\n# Logo\n",
+		"docs/unsafe.md":  "---\nicon: ../../etc/logo.png\ncolor: \"red;background:url(x)\"\n---\n# Unsafe\n",
+		"docs/rooted.md":  "---\nicon: /assets/root.png\n---\n# Rooted\n",
+		"docs/wordy.md":   "---\nicon: not an icon\n---\n# Wordy\n",
+		"assets/logo.svg": "<svg/>",
+	}
+	for rel, body := range files {
+		abs := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	node, err := tree.Build(context.Background(), root, tree.Options{Renderer: renderer.NewService(nil)})
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	find := func(rel string) *tree.Node {
+		var found *tree.Node
+		var walk func(*tree.Node)
+		walk = func(n *tree.Node) {
+			if n.RelativePath == rel {
+				found = n
+			}
+			for _, child := range n.Children {
+				walk(child)
+			}
+		}
+		walk(node)
+		if found == nil {
+			t.Fatalf("node %s not found", rel)
+		}
+		return found
+	}
+
+	cases := []struct {
+		rel, icon, image, color string
+	}{
+		{rel: "rocket.md", icon: "🚀", color: "#f97316"},
+		{rel: "docs/logo.md", image: "assets/logo.svg", color: "teal"},
+		{rel: "docs/unsafe.md"},
+		{rel: "docs/rooted.md", image: "assets/root.png"},
+		{rel: "docs/wordy.md"},
+	}
+	for _, tc := range cases {
+		n := find(tc.rel)
+		if n.Icon != tc.icon || n.IconImage != tc.image || n.Color != tc.color {
+			t.Errorf("%s: got icon=%q image=%q color=%q, want icon=%q image=%q color=%q",
+				tc.rel, n.Icon, n.IconImage, n.Color, tc.icon, tc.image, tc.color)
+		}
+	}
+}
+
+func TestSortOrdersByModifiedAndSize(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	files := []struct {
+		rel, body string
+		age       time.Duration
+	}{
+		{rel: "alpha.md", body: "# Alpha\n", age: 72 * time.Hour},
+		{rel: "beta.md", body: "# Beta\n\n" + strings.Repeat("long ", 200), age: 48 * time.Hour},
+		{rel: "journal/today.md", body: "# Today\n", age: 0},
+	}
+	for _, f := range files {
+		abs := filepath.Join(root, filepath.FromSlash(f.rel))
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, []byte(f.body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := base.Add(-f.age)
+		if err := os.Chtimes(abs, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	order := func(n *tree.Node) string {
+		var names []string
+		for _, child := range n.Children {
+			names = append(names, child.RelativePath)
+		}
+		return strings.Join(names, ",")
+	}
+
+	node, err := tree.Build(context.Background(), root, tree.Options{
+		Renderer: renderer.NewService(nil),
+		Sort:     &tree.Sort{By: tree.SortModified},
+	})
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	if got, want := order(node), "journal,beta.md,alpha.md"; got != want {
+		t.Fatalf("modified order = %s, want %s", got, want)
+	}
+
+	bySize := tree.Sorted(node, tree.Sort{By: tree.SortSize, DirsFirst: true})
+	if got, want := order(bySize), "journal,beta.md,alpha.md"; got != want {
+		t.Fatalf("size order = %s, want %s", got, want)
+	}
+	byTitle := tree.Sorted(node, tree.Sort{By: tree.SortTitle})
+	if got, want := order(byTitle), "alpha.md,beta.md,journal"; got != want {
+		t.Fatalf("title order = %s, want %s", got, want)
+	}
+	if got := order(node); got != "journal,beta.md,alpha.md" {
+		t.Fatalf("Sorted modified the original tree: %s", got)
+	}
+
+	if _, err := tree.ParseSortBy("random"); err == nil {
+		t.Fatal("expected unknown sort key to be rejected")
+	}
+}
+
+func TestDirectoriesAggregateDocumentsAndSize(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	files := map[string]string{
+		"index.md":             "# Home\n",
+		"guides/one.md":        "# One\n",
+		"guides/deep/two.md":   "# Two\n\nMore text.\n",
+		"guides/deep/three.md": "# Three\n",
+	}
+	var total int64
+	for rel, body := range files {
+		abs := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		total += int64(len(body))
+	}
+
+	node, err := tree.Build(context.Background(), root, tree.Options{Renderer: renderer.NewService(nil)})
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	if node.Documents != 4 || node.Size != total {
+		t.Fatalf("root = %d documents, %d bytes; want 4, %d", node.Documents, node.Size, total)
+	}
+	guides := node.Children[0]
+	if guides.RelativePath != "guides" || guides.Documents != 3 {
+		t.Fatalf("expected guides with 3 documents, got %s with %d", guides.RelativePath, guides.Documents)
+	}
+	if deep := guides.Children[0]; deep.Documents != 2 || deep.Size != int64(len(files["guides/deep/two.md"])+len(files["guides/deep/three.md"])) {
+		t.Fatalf("deep = %d documents, %d bytes", deep.Documents, deep.Size)
+	}
+}
@@ -0,0 +1,49 @@
+package tree
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// hashLen is the number of hex characters kept from each node digest.
+const hashLen = 16
+
+// Checksum sets Hash on n and every node below it. A node's hash covers its
+// own fields and its children's hashes, so an unchanged hash means the whole
+// subtree is unchanged and clients can skip it.
+func Checksum(n *Node) string {
+	if n == nil {
+		return ""
+	}
+	h := sha256.New()
+	own := *n
+	own.Children = nil
+	own.Hash = ""
+	// Marshal cannot fail: Node holds only plain data.
+	raw, _ := json.Marshal(own) //nolint:errchkjson // see above
+	h.Write(raw)
+	for _, child := range n.Children {
+		h.Write([]byte(Checksum(child)))
+	}
+	n.Hash = hex.EncodeToString(h.Sum(nil))[:hashLen]
+	return n.Hash
+}
+
+// Hashes maps the relative path of every node below root, root included, to
+// its hash.
+func Hashes(root *Node) map[string]string {
+	out := make(map[string]string)
+	var walk func(*Node)
+	walk = func(n *Node) {
+		if n == nil {
+			return
+		}
+		out[n.RelativePath] = n.Hash
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+	return out
+}
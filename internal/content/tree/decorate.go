@@ -0,0 +1,57 @@
+package tree
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/euforicio/wikimd/internal/renderer"
+)
+
+// maxIconRunes bounds text icons; emoji with modifiers and ZWJ sequences
+// span several runes.
+const maxIconRunes = 8
+
+// iconImageExts are the image formats accepted as icon paths.
+var iconImageExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".webp": true, ".ico": true,
+}
+
+// cssColor accepts hex colors and named colors; anything else could break
+// out of a style attribute or is not worth guessing at.
+var cssColor = regexp.MustCompile(`^(#[0-9a-fA-F]{3,4}|#[0-9a-fA-F]{6}|#[0-9a-fA-F]{8}|[a-zA-Z]{3,24})$`)
+
+// decorate copies the icon and color frontmatter of the document at rel onto
+// n. Values that would not render safely are dropped.
+func decorate(n *Node, rel string, meta *renderer.Metadata) {
+	if meta == nil {
+		return
+	}
+	if cssColor.MatchString(meta.Color) {
+		n.Color = meta.Color
+	}
+
+	icon := meta.Icon
+	if icon == "" {
+		return
+	}
+	if iconImageExts[strings.ToLower(path.Ext(icon))] {
+		// Image paths resolve like markdown images: relative to the page,
+		// or to the wiki root with a leading slash.
+		var resolved string
+		if strings.HasPrefix(icon, "/") {
+			resolved = path.Clean(strings.TrimPrefix(icon, "/"))
+		} else {
+			resolved = path.Join(path.Dir(rel), icon)
+		}
+		if resolved != ".." && !strings.HasPrefix(resolved, "../") && !strings.Contains(icon, "://") {
+			n.IconImage = resolved
+		}
+		return
+	}
+	if utf8.RuneCountInString(icon) <= maxIconRunes && !strings.ContainsFunc(icon, unicode.IsSpace) {
+		n.Icon = icon
+	}
+}
@@ -0,0 +1,6 @@
+package tree
+
+import "github.com/euforicio/wikimd/internal/visibility"
+
+// DefaultIgnoreFile is the conventional ignore file at the wiki root.
+const DefaultIgnoreFile = visibility.DefaultIgnoreFile
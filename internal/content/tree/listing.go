@@ -0,0 +1,64 @@
+package tree
+
+import (
+	"path"
+	"strings"
+
+	"github.com/euforicio/wikimd/internal/renderer"
+)
+
+// Listing returns the pages alongside docPath (excluding docPath itself) and
+// in its subdirectories, depth levels deep, for expanding {{children}}
+// directives. href maps a document's relative path to its link target.
+func Listing(root *Node, docPath string, depth int, href func(rel string) string) []renderer.ListItem {
+	dir := path.Dir(normalizeRelative(docPath))
+	if dir == "." {
+		dir = ""
+	}
+	parent := findDir(root, dir)
+	if parent == nil {
+		return nil
+	}
+	return listChildren(parent, docPath, depth, href)
+}
+
+func listChildren(dir *Node, skip string, depth int, href func(string) string) []renderer.ListItem {
+	if depth <= 0 {
+		return nil
+	}
+	items := make([]renderer.ListItem, 0, len(dir.Children))
+	for _, child := range dir.Children {
+		if strings.EqualFold(child.RelativePath, skip) {
+			continue
+		}
+		item := renderer.ListItem{Title: child.Title}
+		if child.Type == NodeTypeDirectory {
+			item.Children = listChildren(child, skip, depth-1, href)
+		} else {
+			item.Href = href(child.RelativePath)
+			if child.Metadata != nil {
+				item.Description = child.Metadata.Description
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func findDir(n *Node, rel string) *Node {
+	if n == nil || n.Type != NodeTypeDirectory {
+		return nil
+	}
+	if strings.EqualFold(n.RelativePath, rel) {
+		return n
+	}
+	for _, child := range n.Children {
+		if child.Type != NodeTypeDirectory {
+			continue
+		}
+		if rel == child.RelativePath || strings.HasPrefix(rel, child.RelativePath+"/") {
+			return findDir(child, rel)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package tree
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sort keys for sibling nodes.
+const (
+	SortTitle    = "title"
+	SortModified = "modified" // newest first
+	SortSize     = "size"     // largest first
+)
+
+// Sort orders the children of every directory.
+type Sort struct {
+	// By is SortTitle, SortModified, or SortSize. Directories are compared by
+	// their newest document and aggregate Size; ties fall back to title.
+	By string
+	// DirsFirst lists directories ahead of documents.
+	DirsFirst bool
+}
+
+// DefaultSort is alphabetical with directories first.
+var DefaultSort = Sort{By: SortTitle, DirsFirst: true}
+
+// ParseSortBy normalizes a sort key, rejecting unknown ones. An empty key
+// means SortTitle.
+func ParseSortBy(by string) (string, error) {
+	switch by = strings.ToLower(strings.TrimSpace(by)); by {
+	case "":
+		return SortTitle, nil
+	case SortTitle, SortModified, SortSize:
+		return by, nil
+	default:
+		return "", fmt.Errorf("unknown tree sort %q (want title, modified, or size)", by)
+	}
+}
+
+// Sorted returns a copy of n with every level ordered by s. The nodes are
+// copied because the current tree is shared between requests; hashes keep
+// describing the content, not the order.
+func Sorted(n *Node, s Sort) *Node {
+	if n == nil {
+		return nil
+	}
+	out := *n
+	if len(n.Children) > 0 {
+		out.Children = make([]*Node, len(n.Children))
+		for i, child := range n.Children {
+			out.Children[i] = Sorted(child, s)
+		}
+		sortChildren(out.Children, s)
+	}
+	return &out
+}
+
+type sortKey struct {
+	modified time.Time
+	size     int64
+}
+
+func sortChildren(children []*Node, s Sort) {
+	keys := make(map[*Node]sortKey, len(children))
+	if s.By == SortModified || s.By == SortSize {
+		for _, child := range children {
+			keys[child] = subtreeKey(child)
+		}
+	}
+	sort.SliceStable(children, func(i, j int) bool {
+		a, b := children[i], children[j]
+		if s.DirsFirst && a.Type != b.Type {
+			return a.Type == NodeTypeDirectory
+		}
+		ka, kb := keys[a], keys[b]
+		switch s.By {
+		case SortModified:
+			if !ka.modified.Equal(kb.modified) {
+				return ka.modified.After(kb.modified)
+			}
+		case SortSize:
+			if ka.size != kb.size {
+				return ka.size > kb.size
+			}
+		}
+		return strings.Compare(a.Title, b.Title) < 0
+	})
+}
+
+func subtreeKey(n *Node) sortKey {
+	key := sortKey{size: n.Size}
+	if n.Type == NodeTypeFile {
+		key.modified = n.Modified
+		return key
+	}
+	for _, child := range n.Children {
+		if modified := subtreeKey(child).modified; modified.After(key.modified) {
+			key.modified = modified
+		}
+	}
+	return key
+}
@@ -0,0 +1,146 @@
+package content
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+)
+
+// TreeCacheFile holds the tree snapshot written on Close when
+// Options.TreeCache is set, so the next start can serve navigation before
+// the wiki has been scanned. It is machine-specific and belongs in the wiki's
+// .gitignore.
+const TreeCacheFile = ".wikimd/tree.cache"
+
+// treeCacheVersion is bumped whenever tree.Node changes shape.
+const treeCacheVersion = 1
+
+type treeCacheFile struct {
+	// Saved is when the snapshot was written; a directory modified after it
+	// gained or lost entries while the server was down.
+	Saved   time.Time  `json:"saved"`
+	Root    *tree.Node `json:"root"`
+	Options string     `json:"options"`
+	Version int        `json:"version"`
+}
+
+// treeCacheKey fingerprints the options that shape the tree, so a snapshot
+// built with different settings is ignored.
+func (s *Service) treeCacheKey() string {
+	order := tree.DefaultSort
+	if s.treeSort != nil {
+		order = *s.treeSort
+	}
+	// The ignore file's contents are part of the key so editing it discards
+	// a snapshot built under the old patterns.
+	var ignore []byte
+	vis := s.visibility
+	if vis.IgnoreFile != "" {
+		ignore, _ = os.ReadFile(filepath.Join(s.root, filepath.FromSlash(vis.IgnoreFile)))
+	}
+	return fmt.Sprintf("hidden=%t frozen=%q sort=%s dirsFirst=%t exclude=%q ignore=%s:%x",
+		vis.IncludeHidden, s.frozenDirs, order.By, order.DirsFirst, vis.ExcludeDirs, vis.IgnoreFile, sha256.Sum256(ignore))
+}
+
+// loadTreeCache returns the saved snapshot, or false when there is none or
+// it does not match the current options.
+func (s *Service) loadTreeCache() (treeCacheFile, bool) {
+	var cache treeCacheFile
+	raw, err := os.ReadFile(filepath.Join(s.root, filepath.FromSlash(TreeCacheFile)))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			s.logger.Warn("read tree cache failed", slog.Any("err", err))
+		}
+		return cache, false
+	}
+	if err := json.Unmarshal(raw, &cache); err != nil {
+		s.logger.Warn("parse tree cache failed", slog.Any("err", err))
+		return cache, false
+	}
+	if cache.Version != treeCacheVersion || cache.Options != s.treeCacheKey() || cache.Root == nil {
+		return cache, false
+	}
+	return cache, true
+}
+
+// saveTreeCache writes the current tree to TreeCacheFile.
+func (s *Service) saveTreeCache() error {
+	root := s.tree.Load()
+	if root == nil {
+		return nil
+	}
+	target := filepath.Join(s.root, filepath.FromSlash(TreeCacheFile))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil { //nolint:gosec // standard directory permissions
+		return fmt.Errorf("ensure directory: %w", err)
+	}
+	// Saved is taken after the directory exists, so creating it does not
+	// make the root look changed on the next start.
+	data, err := json.Marshal(treeCacheFile{
+		Version: treeCacheVersion,
+		Options: s.treeCacheKey(),
+		Saved:   time.Now(),
+		Root:    root,
+	})
+	if err != nil {
+		return fmt.Errorf("encode tree cache: %w", err)
+	}
+	return writeFileAtomic(target, data)
+}
+
+// validateTreeCache checks a snapshot served at startup against the
+// filesystem and rebuilds the tree when anything changed while the server
+// was down. It only stats files, so it finishes long before a full build.
+func (s *Service) validateTreeCache(cache treeCacheFile) {
+	stale := s.treeCacheStale(cache.Root, cache.Saved)
+	if s.ctx.Err() != nil {
+		return
+	}
+	if !stale {
+		s.logger.Debug("tree cache is current")
+		return
+	}
+
+	s.rebuildMu.Lock()
+	node, err := tree.Build(s.ctx, s.root, s.treeOptions())
+	if err != nil {
+		s.rebuildMu.Unlock()
+		s.logger.Error("rebuild stale tree cache failed", slog.Any("err", err))
+		return
+	}
+	s.storeTree(node)
+	s.rebuildMu.Unlock()
+	s.broadcast(Event{Type: EventTreeUpdated, Timestamp: time.Now(), Generation: s.Generation()})
+}
+
+// treeCacheStale reports whether any node in the snapshot no longer matches
+// the file it describes. Directories are compared against the save time
+// rather than their recorded mtime, which predates later watcher rebuilds.
+func (s *Service) treeCacheStale(n *tree.Node, saved time.Time) bool {
+	if s.ctx.Err() != nil {
+		return true
+	}
+	info, err := os.Stat(filepath.Join(s.root, filepath.FromSlash(n.RelativePath)))
+	if err != nil {
+		return true
+	}
+	if n.Type == tree.NodeTypeFile {
+		return !info.Mode().Type().IsRegular() || !info.ModTime().Equal(n.Modified) || info.Size() != n.Size
+	}
+	if info.Mode().Type() != fs.ModeDir || info.ModTime().After(saved) {
+		return true
+	}
+	for _, child := range n.Children {
+		if s.treeCacheStale(child, saved) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,285 @@
+package content
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// VersionsDir holds the saved versions of each page, at the page's
+// wiki-relative path, when the wiki is not a git repository.
+const VersionsDir = ".wikimd/history"
+
+// DefaultPageVersions is how many versions of each page are kept by default.
+const DefaultPageVersions = 20
+
+// versionExt marks version files. It is not a markdown extension, so the
+// tree, search, and exports never mistake a version for a page.
+const versionExt = ".version"
+
+// versionLayout names a version after the time its content was saved.
+const versionLayout = "20060102T150405.000000000Z"
+
+var versionID = regexp.MustCompile(`^\d{8}T\d{6}\.\d{9}Z$`)
+
+// ErrVersionsDisabled is returned for page versions when none are kept,
+// because the wiki is a git repository or PageVersions is 0.
+var ErrVersionsDisabled = errors.New("page versions are not kept for this wiki")
+
+// PageVersion is an earlier saved state of a page.
+type PageVersion struct {
+	ID    string    `json:"id"`
+	Saved time.Time `json:"saved"`
+	Size  int64     `json:"size"`
+}
+
+// VersionsEnabled reports whether saves keep earlier versions of pages.
+func (s *Service) VersionsEnabled() bool {
+	return s.versions > 0
+}
+
+// insideGitRepo reports whether root is in a git work tree, whose history
+// makes page versions redundant.
+func insideGitRepo(root string) bool {
+	if _, err := exec.LookPath("git"); err != nil {
+		return false
+	}
+	out, err := exec.Command("git", "-C", root, "rev-parse", "--is-inside-work-tree").Output() //nolint:gosec,noctx // fixed git arguments, run once at startup
+	return err == nil && bytes.Equal(bytes.TrimSpace(out), []byte("true"))
+}
+
+// PageVersions lists the kept versions of a page, newest first. A page that
+// was never saved through wikimd has none.
+func (s *Service) PageVersions(relPath string) ([]PageVersion, error) {
+	rel, _, err := s.resolveDocumentPath(relPath)
+	if err != nil {
+		return nil, err
+	}
+	if !s.VersionsEnabled() {
+		return nil, ErrVersionsDisabled
+	}
+	dir := s.versionsDir(rel)
+	names, err := versionFiles(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read versions: %w", err)
+	}
+	versions := make([]PageVersion, 0, len(names))
+	for _, name := range slices.Backward(names) {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		id := strings.TrimSuffix(name, versionExt)
+		saved, _ := time.Parse(versionLayout, id)
+		versions = append(versions, PageVersion{ID: id, Saved: saved, Size: info.Size()})
+	}
+	return versions, nil
+}
+
+// PageVersionContent returns one kept version of a page.
+func (s *Service) PageVersionContent(relPath, id string) (PageVersion, []byte, error) {
+	rel, _, err := s.resolveDocumentPath(relPath)
+	if err != nil {
+		return PageVersion{}, nil, err
+	}
+	return s.readVersion(rel, id)
+}
+
+// RestorePageVersion writes a kept version back as the page's content,
+// recreating the page if it was deleted. The content it replaces becomes a
+// version itself, so a restore can be undone the same way.
+func (s *Service) RestorePageVersion(ctx context.Context, relPath, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	rel, abs, err := s.resolveDocumentPath(relPath)
+	if err != nil {
+		return err
+	}
+	if err := s.checkWritable(rel); err != nil {
+		return err
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	_, data, err := s.readVersion(rel, id)
+	if err != nil {
+		return err
+	}
+	s.recordVersion(rel, abs)
+	if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil { //nolint:gosec // standard directory permissions
+		return fmt.Errorf("ensure directory: %w", err)
+	}
+	if err := writeFileAtomic(abs, data); err != nil {
+		return err
+	}
+	s.renderer.Invalidate(abs)
+	return nil
+}
+
+func (s *Service) readVersion(rel, id string) (PageVersion, []byte, error) {
+	if !s.VersionsEnabled() {
+		return PageVersion{}, nil, ErrVersionsDisabled
+	}
+	saved, err := time.Parse(versionLayout, id)
+	if err != nil || !versionID.MatchString(id) {
+		return PageVersion{}, nil, fmt.Errorf("version not found: %s: %w", id, os.ErrNotExist)
+	}
+	data, err := os.ReadFile(filepath.Join(s.versionsDir(rel), id+versionExt))
+	if errors.Is(err, fs.ErrNotExist) {
+		return PageVersion{}, nil, fmt.Errorf("version not found: %s: %w", id, os.ErrNotExist)
+	}
+	if err != nil {
+		return PageVersion{}, nil, fmt.Errorf("read version: %w", err)
+	}
+	return PageVersion{ID: id, Saved: saved, Size: int64(len(data))}, data, nil
+}
+
+// recordVersion keeps the current content of the page at abs before it is
+// overwritten or deleted, stamped with the time it was saved, and drops the
+// oldest versions beyond the limit. Callers hold writeMu. Failures are
+// logged rather than returned, since they must not block the edit itself.
+func (s *Service) recordVersion(rel, abs string) {
+	if !s.VersionsEnabled() {
+		return
+	}
+	if err := s.writeVersion(rel, abs); err != nil {
+		s.logger.Warn("record page version failed", slog.String("path", rel), slog.Any("err", err))
+	}
+}
+
+func (s *Service) writeVersion(rel, abs string) error {
+	info, err := os.Stat(abs)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(abs) //nolint:gosec // abs resolved under the root
+	if err != nil {
+		return err
+	}
+
+	dir := s.versionsDir(rel)
+	names, err := versionFiles(dir)
+	if err != nil {
+		return err
+	}
+	if n := len(names); n > 0 {
+		// Saving the same content twice keeps one version.
+		newest, err := os.ReadFile(filepath.Join(dir, names[n-1])) //nolint:gosec // file in the versions directory
+		if err == nil && bytes.Equal(newest, data) {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gosec // standard directory permissions
+		return err
+	}
+	// Coarse file clocks can give quick successive saves the same time.
+	saved := info.ModTime().UTC()
+	name := saved.Format(versionLayout) + versionExt
+	for slices.Contains(names, name) {
+		saved = saved.Add(time.Nanosecond)
+		name = saved.Format(versionLayout) + versionExt
+	}
+	if err := writeFileAtomic(filepath.Join(dir, name), data); err != nil {
+		return err
+	}
+	names = append(names, name)
+	slices.Sort(names)
+	for len(names) > s.versions {
+		if err := os.Remove(filepath.Join(dir, names[0])); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+// moveVersions carries a page's versions along when it is renamed. Versions
+// already kept at the new path, left by a page deleted from there, are kept
+// too: the two sets are merged in time order and pruned to the limit like
+// any save. Callers hold writeMu.
+func (s *Service) moveVersions(fromRel, toRel string) {
+	if !s.VersionsEnabled() {
+		return
+	}
+	if err := s.mergeVersions(s.versionsDir(fromRel), s.versionsDir(toRel)); err != nil {
+		s.logger.Warn("move page versions failed", slog.String("from", fromRel), slog.String("to", toRel), slog.Any("err", err))
+	}
+}
+
+func (s *Service) mergeVersions(from, to string) error {
+	moving, err := versionFiles(from)
+	if err != nil || len(moving) == 0 {
+		return err
+	}
+	if err := os.MkdirAll(to, 0o755); err != nil { //nolint:gosec // standard directory permissions
+		return err
+	}
+	names, err := versionFiles(to)
+	if err != nil {
+		return err
+	}
+	for _, name := range moving {
+		target := name
+		if slices.Contains(names, target) {
+			saved, _ := time.Parse(versionLayout, strings.TrimSuffix(name, versionExt))
+			for slices.Contains(names, target) {
+				saved = saved.Add(time.Nanosecond)
+				target = saved.Format(versionLayout) + versionExt
+			}
+		}
+		if err := os.Rename(filepath.Join(from, name), filepath.Join(to, target)); err != nil {
+			return err
+		}
+		names = append(names, target)
+	}
+	slices.Sort(names)
+	for len(names) > s.versions {
+		if err := os.Remove(filepath.Join(to, names[0])); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		names = names[1:]
+	}
+	// Only the emptied directory goes; anything else in it is left alone.
+	_ = os.Remove(from)
+	return nil
+}
+
+// versionsDir is where the versions of the page at rel are kept.
+func (s *Service) versionsDir(rel string) string {
+	return filepath.Join(s.root, filepath.FromSlash(VersionsDir), filepath.FromSlash(rel))
+}
+
+// versionFiles lists the version files in dir, oldest first.
+func versionFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), versionExt)
+		if ok && versionID.MatchString(id) && entry.Type().IsRegular() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
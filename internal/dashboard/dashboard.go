@@ -0,0 +1,183 @@
+// Package dashboard builds generated landing pages for directories that carry
+// a _dashboard.yaml file.
+package dashboard
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+)
+
+// defaultRecent is how many recently changed pages are listed when the
+// dashboard file does not say.
+const defaultRecent = 5
+
+// Config is the contents of a _dashboard.yaml file.
+type Config struct {
+	// Recent is the number of recently changed pages to list; nil means the
+	// default and zero hides the section.
+	Recent      *int   `yaml:"recent"`
+	Title       string `yaml:"title"`
+	Description string `yaml:"description"`
+	Pinned      []Link `yaml:"pinned"`
+}
+
+// Link is a pinned entry. URL is either an external http(s) address or a
+// markdown path relative to the dashboard's directory.
+type Link struct {
+	Title       string `yaml:"title"`
+	URL         string `yaml:"url"`
+	Description string `yaml:"description"`
+}
+
+// Card summarizes a page or subdirectory.
+type Card struct {
+	Modified    time.Time
+	Title       string
+	Description string
+	Path        string
+	Pages       int // documents beneath a directory card
+	IsDir       bool
+}
+
+// PinnedLink is a Link resolved for display.
+type PinnedLink struct {
+	Title       string
+	Href        string
+	Description string
+	External    bool
+}
+
+// View is everything a template needs to render a dashboard.
+type View struct {
+	Modified    time.Time
+	Title       string
+	Description string
+	Path        string
+	Pinned      []PinnedLink
+	Children    []Card
+	Recent      []Card
+}
+
+// Load reads the dashboard file for the wiki-relative directory dir. It
+// returns an error wrapping os.ErrNotExist when the directory has none.
+func Load(root, dir string) (Config, error) {
+	var cfg Config
+	raw, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(dir), tree.DashboardFile)) //nolint:gosec // dir comes from the content tree
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, fmt.Errorf("no dashboard in %q: %w", dir, os.ErrNotExist)
+		}
+		return cfg, fmt.Errorf("read dashboard: %w", err)
+	}
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s in %q: %w", tree.DashboardFile, dir, err)
+	}
+	return cfg, nil
+}
+
+// Build assembles the dashboard for the directory node dir.
+func Build(cfg Config, dir *tree.Node) View {
+	view := View{
+		Title:       cfg.Title,
+		Description: cfg.Description,
+		Path:        dir.RelativePath,
+	}
+	if view.Title == "" {
+		view.Title = dir.Title
+	}
+
+	for _, child := range dir.Children {
+		card := Card{
+			Title:    child.Title,
+			Path:     child.RelativePath,
+			Modified: child.Modified,
+			IsDir:    child.Type == tree.NodeTypeDirectory,
+		}
+		if card.IsDir {
+			card.Pages = len(documents(child))
+		} else if child.Metadata != nil {
+			card.Description = child.Metadata.Description
+		}
+		view.Children = append(view.Children, card)
+	}
+
+	docs := documents(dir)
+	sort.SliceStable(docs, func(i, j int) bool {
+		return docs[i].Modified.After(docs[j].Modified)
+	})
+	if len(docs) > 0 {
+		view.Modified = docs[0].Modified
+	}
+	limit := defaultRecent
+	if cfg.Recent != nil {
+		limit = max(*cfg.Recent, 0)
+	}
+	for _, doc := range docs[:min(limit, len(docs))] {
+		card := Card{Title: doc.Title, Path: doc.RelativePath, Modified: doc.Modified}
+		if doc.Metadata != nil {
+			card.Description = doc.Metadata.Description
+		}
+		view.Recent = append(view.Recent, card)
+	}
+
+	for _, link := range cfg.Pinned {
+		if pinned, ok := resolveLink(link, dir.RelativePath); ok {
+			view.Pinned = append(view.Pinned, pinned)
+		}
+	}
+	return view
+}
+
+// resolveLink turns a pinned link into an href. Wiki paths are resolved
+// against dir; anything that would leave the wiki root is dropped.
+func resolveLink(link Link, dir string) (PinnedLink, bool) {
+	target := strings.TrimSpace(link.URL)
+	if target == "" {
+		return PinnedLink{}, false
+	}
+	out := PinnedLink{Title: link.Title, Description: link.Description}
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		out.Href = target
+		out.External = true
+		if out.Title == "" {
+			out.Title = target
+		}
+		return out, true
+	}
+
+	var rel string
+	if strings.HasPrefix(target, "/") {
+		rel = path.Clean(strings.TrimPrefix(target, "/"))
+	} else {
+		rel = path.Clean(path.Join(dir, target))
+	}
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+		return PinnedLink{}, false
+	}
+	out.Href = "/page/" + rel
+	if out.Title == "" {
+		out.Title = strings.TrimSuffix(path.Base(rel), path.Ext(rel))
+	}
+	return out, true
+}
+
+func documents(n *tree.Node) []*tree.Node {
+	if n.Type == tree.NodeTypeFile {
+		return []*tree.Node{n}
+	}
+	var out []*tree.Node
+	for _, child := range n.Children {
+		out = append(out, documents(child)...)
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package dashboard
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+	"github.com/euforicio/wikimd/internal/renderer"
+)
+
+func TestLoadAndBuild(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "eng"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(root, "eng"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist without a dashboard file, got %v", err)
+	}
+	cfg := "title: Engineering\nrecent: 1\npinned:\n  - title: Runbook\n    url: ops/runbook.md\n  - url: https://status.example.com\n  - url: ../../outside.md\n"
+	if err := os.WriteFile(filepath.Join(root, "eng", tree.DashboardFile), []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(root, "eng")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	old := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	dir := &tree.Node{
+		Type:         tree.NodeTypeDirectory,
+		RelativePath: "eng",
+		Title:        "eng",
+		Children: []*tree.Node{
+			{
+				Type:         tree.NodeTypeDirectory,
+				RelativePath: "eng/ops",
+				Title:        "ops",
+				Children: []*tree.Node{
+					{Type: tree.NodeTypeFile, RelativePath: "eng/ops/runbook.md", Title: "Runbook", Modified: old.AddDate(0, 1, 0)},
+				},
+			},
+			{
+				Type:         tree.NodeTypeFile,
+				RelativePath: "eng/onboarding.md",
+				Title:        "Onboarding",
+				Modified:     old,
+				Metadata:     &renderer.Metadata{Description: "Start here"},
+			},
+		},
+	}
+
+	view := Build(loaded, dir)
+	if view.Title != "Engineering" {
+		t.Errorf("title = %q", view.Title)
+	}
+	if len(view.Children) != 2 || view.Children[0].Pages != 1 || view.Children[1].Description != "Start here" {
+		t.Errorf("unexpected child cards: %+v", view.Children)
+	}
+	if len(view.Recent) != 1 || view.Recent[0].Path != "eng/ops/runbook.md" {
+		t.Errorf("expected only the newest page in recent, got %+v", view.Recent)
+	}
+	if len(view.Pinned) != 2 {
+		t.Fatalf("expected the escaping link to be dropped, got %+v", view.Pinned)
+	}
+	if view.Pinned[0].Href != "/page/eng/ops/runbook.md" || view.Pinned[0].External {
+		t.Errorf("unexpected wiki link: %+v", view.Pinned[0])
+	}
+	if !view.Pinned[1].External || view.Pinned[1].Title != "https://status.example.com" {
+		t.Errorf("unexpected external link: %+v", view.Pinned[1])
+	}
+}
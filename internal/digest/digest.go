@@ -0,0 +1,298 @@
+// Package digest compiles periodic summaries of changed pages and mails them
+// to subscribers.
+package digest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/smtp"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+)
+
+// Change kinds reported in a digest.
+const (
+	KindCreated  = "created"
+	KindModified = "modified"
+	KindDeleted  = "deleted"
+)
+
+// Change is one page that changed during the digest window.
+type Change struct {
+	Time  time.Time
+	Path  string
+	Title string
+	Kind  string
+}
+
+// SMTPConfig describes how digests are delivered.
+type SMTPConfig struct {
+	Addr     string // host:port
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// Options configures a Digester.
+type Options struct {
+	SMTP      SMTPConfig
+	SiteTitle string
+	Interval  time.Duration
+}
+
+// TreeFunc returns the current content tree.
+type TreeFunc func(ctx context.Context) (*tree.Node, error)
+
+// Digester periodically mails the pages changed since the previous digest.
+type Digester struct {
+	logger  *slog.Logger
+	send    func(subject, body string) error
+	rootDir string
+	opts    Options
+}
+
+// New returns a Digester for the wiki at rootDir.
+func New(logger *slog.Logger, rootDir string, opts Options) *Digester {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	d := &Digester{
+		logger:  logger.With("component", "digest"),
+		rootDir: rootDir,
+		opts:    opts,
+	}
+	d.send = d.sendMail
+	return d
+}
+
+// Run sends a digest every Interval until ctx is canceled. Each digest covers
+// the time since the previous one; the first covers one interval back.
+func (d *Digester) Run(ctx context.Context, current TreeFunc) {
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+
+	since := time.Now().Add(-d.opts.Interval)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := d.SendDigest(ctx, current, since, now); err != nil {
+				d.logger.WarnContext(ctx, "send digest failed", slog.Any("err", err))
+				continue // retry the same window next time
+			}
+			since = now
+		}
+	}
+}
+
+// SendDigest mails the changes between since and now. Nothing is sent when
+// no page changed.
+func (d *Digester) SendDigest(ctx context.Context, current TreeFunc, since, now time.Time) error {
+	root, err := current(ctx)
+	if err != nil {
+		return err
+	}
+	changes := Collect(ctx, d.rootDir, root, since, now)
+	if len(changes) == 0 {
+		d.logger.InfoContext(ctx, "no changes for digest", slog.Time("since", since))
+		return nil
+	}
+	subject, body := Compose(changes, d.opts.SiteTitle, since, now)
+	if err := d.send(subject, body); err != nil {
+		return err
+	}
+	d.logger.InfoContext(ctx, "digest sent", slog.Int("changes", len(changes)), slog.Int("recipients", len(d.opts.SMTP.To)))
+	return nil
+}
+
+// Collect lists pages changed in (since, now]. When the wiki is a git
+// repository its history distinguishes created and deleted pages; pages known
+// only from their modification time are reported as modified.
+func Collect(ctx context.Context, rootDir string, root *tree.Node, since, now time.Time) []Change {
+	titles := make(map[string]string)
+	var docs []*tree.Node
+	var walk func(*tree.Node)
+	walk = func(n *tree.Node) {
+		if n == nil {
+			return
+		}
+		if n.Type == tree.NodeTypeFile {
+			titles[n.RelativePath] = n.Title
+			docs = append(docs, n)
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	// Git history may be missing (not a repository) or lag behind edits that
+	// were saved but not committed, so modification times fill the gaps.
+	changes, _ := gitChanges(ctx, rootDir, since, now)
+	seen := make(map[string]bool, len(changes))
+	for _, c := range changes {
+		seen[c.Path] = true
+	}
+	for _, doc := range docs {
+		if !seen[doc.RelativePath] && doc.Modified.After(since) && !doc.Modified.After(now) {
+			changes = append(changes, Change{Time: doc.Modified, Path: doc.RelativePath, Kind: KindModified})
+		}
+	}
+	for i := range changes {
+		if title, ok := titles[changes[i].Path]; ok {
+			changes[i].Title = title
+		} else {
+			changes[i].Title = changes[i].Path
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return kindOrder(changes[i].Kind) < kindOrder(changes[j].Kind)
+		}
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+func kindOrder(kind string) int {
+	switch kind {
+	case KindCreated:
+		return 0
+	case KindModified:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// gitChanges summarizes markdown changes from git log, one entry per path.
+func gitChanges(ctx context.Context, rootDir string, since, now time.Time) ([]Change, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, "git", "-C", rootDir, "log", //nolint:gosec // fixed git arguments
+		"--since="+since.Format(time.RFC3339), "--until="+now.Format(time.RFC3339),
+		"--name-status", "--relative", "--format=@%cI", "--", "*.md", "*.markdown")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+
+	byPath := make(map[string]Change)
+	var when time.Time
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "@") {
+			when, _ = time.Parse(time.RFC3339, line[1:])
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			continue
+		}
+		// Log output is newest first, so the first entry seen per path holds
+		// the latest time; kinds are merged across all entries.
+		for _, c := range statusChanges(fields, when) {
+			prev, seen := byPath[c.Path]
+			if !seen {
+				byPath[c.Path] = c
+				continue
+			}
+			if prev.Kind == KindModified && c.Kind == KindCreated {
+				prev.Kind = KindCreated
+				byPath[c.Path] = prev
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	changes := make([]Change, 0, len(byPath))
+	for _, c := range byPath {
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+func statusChanges(fields []string, when time.Time) []Change {
+	status := fields[0]
+	switch {
+	case strings.HasPrefix(status, "A"):
+		return []Change{{Time: when, Path: fields[1], Kind: KindCreated}}
+	case strings.HasPrefix(status, "D"):
+		return []Change{{Time: when, Path: fields[1], Kind: KindDeleted}}
+	case strings.HasPrefix(status, "R") && len(fields) >= 3:
+		return []Change{
+			{Time: when, Path: fields[1], Kind: KindDeleted},
+			{Time: when, Path: fields[2], Kind: KindCreated},
+		}
+	default:
+		return []Change{{Time: when, Path: fields[len(fields)-1], Kind: KindModified}}
+	}
+}
+
+// Compose renders the digest as a plain-text email.
+func Compose(changes []Change, siteTitle string, since, now time.Time) (string, string) {
+	if siteTitle == "" {
+		siteTitle = "wikimd"
+	}
+	subject := fmt.Sprintf("[%s] %d page %s since %s", siteTitle, len(changes), plural(len(changes), "change", "changes"), since.Format("Jan 2"))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Changes to %s between %s and %s.\n", siteTitle, since.Format("Jan 2 15:04"), now.Format("Jan 2 15:04 MST"))
+	kind := ""
+	for _, c := range changes {
+		if c.Kind != kind {
+			kind = c.Kind
+			fmt.Fprintf(&b, "\n%s:\n", strings.ToUpper(kind[:1])+kind[1:])
+		}
+		fmt.Fprintf(&b, "  - %s", c.Title)
+		if c.Title != c.Path {
+			fmt.Fprintf(&b, " (%s)", c.Path)
+		}
+		b.WriteString("\n")
+	}
+	return subject, b.String()
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+func (d *Digester) sendMail(subject, body string) error {
+	cfg := d.opts.SMTP
+	if cfg.Addr == "" || cfg.From == "" || len(cfg.To) == 0 {
+		return errors.New("smtp address, sender, and recipients are required")
+	}
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		host, _, _ := strings.Cut(cfg.Addr, ":")
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return smtp.SendMail(cfg.Addr, auth, cfg.From, cfg.To, msg.Bytes())
+}
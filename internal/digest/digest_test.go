@@ -0,0 +1,66 @@
+package digest
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+)
+
+func TestSendDigestListsChangedPages(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC)
+	since := now.Add(-24 * time.Hour)
+	root := &tree.Node{
+		Type: tree.NodeTypeDirectory,
+		Children: []*tree.Node{
+			{Type: tree.NodeTypeFile, RelativePath: "ops/runbook.md", Title: "Runbook", Modified: now.Add(-time.Hour)},
+			{Type: tree.NodeTypeFile, RelativePath: "stale.md", Title: "Stale", Modified: since.Add(-time.Hour)},
+			{Type: tree.NodeTypeFile, RelativePath: "faq.md", Title: "FAQ", Modified: since.Add(time.Minute)},
+		},
+	}
+
+	// A directory outside any git repository exercises the mtime fallback.
+	d := New(slog.New(slog.NewTextHandler(io.Discard, nil)), t.TempDir(), Options{SiteTitle: "Docs", Interval: 24 * time.Hour})
+	var subject, body string
+	d.send = func(s, b string) error {
+		subject, body = s, b
+		return nil
+	}
+	current := func(context.Context) (*tree.Node, error) { return root, nil }
+
+	if err := d.SendDigest(context.Background(), current, since, now); err != nil {
+		t.Fatalf("SendDigest: %v", err)
+	}
+	if subject != "[Docs] 2 page changes since Jun 9" {
+		t.Errorf("subject = %q", subject)
+	}
+	if !strings.Contains(body, "Modified:\n  - FAQ (faq.md)\n  - Runbook (ops/runbook.md)\n") {
+		t.Errorf("unexpected body:\n%s", body)
+	}
+	if strings.Contains(body, "Stale") {
+		t.Errorf("page changed before the window should be left out:\n%s", body)
+	}
+
+	subject = ""
+	if err := d.SendDigest(context.Background(), current, now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("SendDigest: %v", err)
+	}
+	if subject != "" {
+		t.Errorf("expected no email for an empty window, got %q", subject)
+	}
+}
+
+func TestStatusChangesHandlesRenames(t *testing.T) {
+	t.Parallel()
+
+	got := statusChanges([]string{"R100", "old.md", "new.md"}, time.Time{})
+	if len(got) != 2 || got[0].Kind != KindDeleted || got[0].Path != "old.md" || got[1].Kind != KindCreated || got[1].Path != "new.md" {
+		t.Fatalf("unexpected rename changes: %+v", got)
+	}
+}
@@ -0,0 +1,144 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	wikistatic "github.com/euforicio/wikimd/static"
+)
+
+// Asset sources reported in AssetReport.Source.
+const (
+	AssetSourceEmbedded  = "embedded"
+	AssetSourceDirectory = "directory"
+)
+
+// ErrAssetsMissing reports that Options.AssetsRequired was set and the
+// assets directory, or an asset the pages link to, was missing.
+var ErrAssetsMissing = errors.New("export assets missing")
+
+// AssetReport records where an export's stylesheets and scripts came from.
+type AssetReport struct {
+	Source string `json:"source"`
+	// Files maps each asset the pages link to, by its path inside the asset
+	// bundle, to its hex SHA-256.
+	Files map[string]string `json:"files"`
+	// Missing lists linked assets the assets directory did not have, and
+	// Fallback those a single-file export read from the embedded bundle
+	// instead.
+	Missing  []string `json:"missing,omitempty"`
+	Fallback []string `json:"fallback,omitempty"`
+}
+
+// resolveAssetsDir returns the assets directory to export from, or "" for
+// the embedded bundle. A directory that does not exist falls back to the
+// embedded bundle with a warning, or fails when required is set.
+func (e *Exporter) resolveAssetsDir(dir string, required bool) (string, error) {
+	if dir == "" {
+		if required {
+			return "", fmt.Errorf("%w: no assets directory given", ErrAssetsMissing)
+		}
+		return "", nil
+	}
+	info, err := os.Stat(dir)
+	switch {
+	case err == nil && info.IsDir():
+		return dir, nil
+	case err != nil && !errors.Is(err, os.ErrNotExist):
+		return "", fmt.Errorf("stat assets override: %w", err)
+	case required:
+		return "", fmt.Errorf("%w: %s is not a directory", ErrAssetsMissing, dir)
+	}
+	e.logger.Warn("assets directory missing; using embedded assets", slog.String("dir", dir))
+	return "", nil
+}
+
+// checkAssets fails the export on assets missing from dir when required is
+// set, and warns about them otherwise.
+func (e *Exporter) checkAssets(report *AssetReport, dir string, required bool) error {
+	if len(report.Missing) == 0 {
+		return nil
+	}
+	if required {
+		return fmt.Errorf("%w: %s not in %s", ErrAssetsMissing, strings.Join(report.Missing, ", "), dir)
+	}
+	e.logger.Warn("assets missing from export", slog.String("dir", dir), slog.Any("assets", report.Missing))
+	return nil
+}
+
+// logAssets prints which asset source an export used and each linked
+// asset's checksum, so CI logs show what was published.
+func (e *Exporter) logAssets(report AssetReport, dir string) {
+	names := make([]string, 0, len(report.Files))
+	for name := range report.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sums := make([]any, 0, len(names))
+	for _, name := range names {
+		sums = append(sums, slog.String(name, report.Files[name]))
+	}
+	attrs := []any{slog.String("source", report.Source)}
+	if dir != "" {
+		attrs = append(attrs, slog.String("dir", dir))
+	}
+	if len(report.Fallback) > 0 {
+		attrs = append(attrs, slog.Any("fallback", report.Fallback))
+	}
+	attrs = append(attrs, slog.Group("sha256", sums...))
+	e.logger.Info("export assets", attrs...)
+}
+
+func assetSource(dir string) string {
+	if dir == "" {
+		return AssetSourceEmbedded
+	}
+	return AssetSourceDirectory
+}
+
+// assetReader loads assets for a single-file export from the assets
+// directory, or the embedded bundle when there is none, and reports what it
+// read.
+type assetReader struct {
+	dir      string
+	required bool
+	report   AssetReport
+}
+
+func newAssetReader(dir string, required bool) *assetReader {
+	return &assetReader{
+		dir:      dir,
+		required: required,
+		report:   AssetReport{Source: assetSource(dir), Files: make(map[string]string)},
+	}
+}
+
+// read returns the asset at rel. An asset the directory lacks comes from the
+// embedded bundle, unless the reader is required to use the directory.
+func (r *assetReader) read(rel string) ([]byte, error) {
+	if r.dir != "" {
+		data, err := os.ReadFile(filepath.Join(r.dir, filepath.FromSlash(rel))) //nolint:gosec // path inside the assets directory
+		switch {
+		case err == nil:
+			r.report.Files[rel] = checksum(data)
+			return data, nil
+		case !errors.Is(err, os.ErrNotExist):
+			return nil, err
+		case r.required:
+			return nil, fmt.Errorf("%w: %s not in %s", ErrAssetsMissing, rel, r.dir)
+		}
+		r.report.Fallback = append(r.report.Fallback, rel)
+	}
+	data, err := fs.ReadFile(wikistatic.FS(), rel)
+	if err != nil {
+		return nil, err
+	}
+	r.report.Files[rel] = checksum(data)
+	return data, nil
+}
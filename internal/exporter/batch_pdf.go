@@ -0,0 +1,194 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/euforicio/wikimd/internal/frontmatter"
+)
+
+// MaxBatchPages bounds how many documents one batch PDF may compile.
+const MaxBatchPages = 200
+
+// BatchPDFOptions configures ExportBatchPDF.
+type BatchPDFOptions struct {
+	Writer  io.Writer
+	Date    time.Time // printed on the cover; zero omits it
+	RootDir string
+	Title   string   // cover title; defaults to "Handout"
+	Paths   []string // documents in print order
+}
+
+// handoutSection is one document of a batch PDF.
+type handoutSection struct {
+	title string
+	body  []byte
+}
+
+// ExportBatchPDF compiles the documents in opts.Paths, in order, into a
+// single PDF that opens with a cover page and a table of contents.
+func (e *Exporter) ExportBatchPDF(ctx context.Context, opts BatchPDFOptions) error {
+	if strings.TrimSpace(opts.RootDir) == "" {
+		return errors.New("root directory is required")
+	}
+	if opts.Writer == nil {
+		return errors.New("writer is required")
+	}
+	if len(opts.Paths) == 0 {
+		return errors.New("at least one page is required")
+	}
+	if len(opts.Paths) > MaxBatchPages {
+		return fmt.Errorf("too many pages: %d (max %d)", len(opts.Paths), MaxBatchPages)
+	}
+
+	rootDir, err := filepath.Abs(opts.RootDir)
+	if err != nil {
+		return fmt.Errorf("resolve root: %w", err)
+	}
+	sections := make([]handoutSection, 0, len(opts.Paths))
+	for _, path := range opts.Paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		absPath, err := resolveExportPath(rootDir, path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		_, raw, err := readExportSource(absPath, path)
+		if err != nil {
+			return err
+		}
+		sections = append(sections, splitSection(path, raw))
+	}
+
+	title := firstNonEmpty(strings.TrimSpace(opts.Title), "Handout")
+	return e.exportPDF(ctx, "", time.Time{}, compileHandout(title, opts.Date, sections), opts.Writer)
+}
+
+// compileHandout joins the sections into one markdown document: a cover,
+// a numbered contents list, then each section under a level-one heading.
+func compileHandout(title string, date time.Time, sections []handoutSection) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# %s\n\n", title)
+	if !date.IsZero() {
+		fmt.Fprintf(&buf, "%s\n\n", date.Format("January 2, 2006"))
+	}
+	noun := "pages"
+	if len(sections) == 1 {
+		noun = "page"
+	}
+	fmt.Fprintf(&buf, "%d %s\n\n## Contents\n\n", len(sections), noun)
+	for i, sec := range sections {
+		fmt.Fprintf(&buf, "%d. %s\n", i+1, sec.title)
+	}
+	for _, sec := range sections {
+		fmt.Fprintf(&buf, "\n---\n\n# %s\n\n", sec.title)
+		buf.Write(bytes.TrimSpace(sec.body))
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+// splitSection separates a document into its title and the body printed
+// under it. The title comes from frontmatter, then a leading level-one
+// heading (which is dropped from the body), then the file name. If the body
+// still holds level-one headings, every heading is demoted one level so the
+// section title stays on top.
+func splitSection(path string, raw []byte) handoutSection {
+	raw = bytes.ReplaceAll(raw, []byte("\r\n"), []byte("\n"))
+	title, body := splitFrontmatterTitle(raw)
+
+	lines := strings.Split(string(body), "\n")
+	first := 0
+	for first < len(lines) && strings.TrimSpace(lines[first]) == "" {
+		first++
+	}
+	if first < len(lines) && strings.HasPrefix(lines[first], "# ") {
+		title = firstNonEmpty(title, strings.TrimSpace(strings.TrimPrefix(lines[first], "# ")))
+		lines = lines[first+1:]
+	}
+	if title == "" {
+		title = titleFromPath(path)
+	}
+
+	if headingLevels(lines)[1] {
+		inFence := false
+		for i, line := range lines {
+			if isFence(line) {
+				inFence = !inFence
+				continue
+			}
+			if level := headingLevel(line); !inFence && level > 0 && level < 6 {
+				lines[i] = "#" + line
+			}
+		}
+	}
+	return handoutSection{title: title, body: []byte(strings.Join(lines, "\n"))}
+}
+
+// headingLevels reports which ATX heading levels occur outside code fences.
+func headingLevels(lines []string) map[int]bool {
+	levels := make(map[int]bool)
+	inFence := false
+	for _, line := range lines {
+		if isFence(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		levels[headingLevel(line)] = true
+	}
+	return levels
+}
+
+// headingLevel returns the level of an ATX heading line, or 0.
+func headingLevel(line string) int {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 || (len(line) > level && line[level] != ' ') {
+		return 0
+	}
+	return level
+}
+
+func isFence(line string) bool {
+	t := strings.TrimSpace(line)
+	return strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~")
+}
+
+// splitFrontmatterTitle returns the frontmatter title, if any, and the
+// document without its frontmatter block.
+func splitFrontmatterTitle(raw []byte) (string, []byte) {
+	if values, _, ok, err := frontmatter.Decode(raw); ok && err == nil {
+		body, _ := frontmatter.Strip(raw)
+		title, _ := values["title"].(string)
+		return strings.TrimSpace(title), body
+	}
+	rest, ok := bytes.CutPrefix(raw, []byte("---\n"))
+	if !ok {
+		return "", raw
+	}
+	for offset := 0; ; {
+		line, next, found := bytes.Cut(rest[offset:], []byte("\n"))
+		if t := bytes.TrimSpace(line); bytes.Equal(t, []byte("---")) || bytes.Equal(t, []byte("...")) {
+			var meta struct {
+				Title string `yaml:"title"`
+			}
+			_ = yaml.Unmarshal(rest[:offset], &meta)
+			return strings.TrimSpace(meta.Title), next
+		}
+		if !found {
+			return "", raw
+		}
+		offset = len(rest) - len(next)
+	}
+}
@@ -0,0 +1,71 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompileHandout(t *testing.T) {
+	t.Parallel()
+	sections := []handoutSection{
+		splitSection("policies/leave.md", []byte("---\ntitle: Leave Policy\n---\n\n# Leave\n\nTake time off.\n")),
+		splitSection("guides/setup-steps.md", []byte("Intro.\n\n# Install\n\n## Linux\n\n```sh\n# not a heading\n```\n")),
+	}
+	got := string(compileHandout("Onboarding", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), sections))
+
+	for _, want := range []string{
+		"# Onboarding\n\nMarch 1, 2026\n\n2 pages\n\n## Contents\n\n1. Leave Policy\n2. Setup Steps\n",
+		"\n---\n\n# Leave Policy\n\nTake time off.\n",
+		"\n---\n\n# Setup Steps\n\nIntro.\n\n## Install\n\n### Linux\n\n```sh\n# not a heading\n```\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in handout:\n%s", want, got)
+		}
+	}
+}
+
+func TestSplitSectionUsesLeadingHeading(t *testing.T) {
+	t.Parallel()
+	sec := splitSection("notes.md", []byte("\n# Release Notes\n\n## Fixed\n"))
+	if sec.title != "Release Notes" {
+		t.Fatalf("expected heading as title, got %q", sec.title)
+	}
+	if strings.TrimSpace(string(sec.body)) != "## Fixed" {
+		t.Fatalf("expected body without the title heading, got %q", sec.body)
+	}
+}
+
+func TestExportBatchPDFValidatesPages(t *testing.T) {
+	t.Parallel()
+	exp, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.md"), []byte("# A\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string][]string{
+		"no pages":  nil,
+		"missing":   {"a.md", "missing.md"},
+		"traversal": {"../outside.md"},
+	}
+	for name, paths := range cases {
+		var out bytes.Buffer
+		err := exp.ExportBatchPDF(context.Background(), BatchPDFOptions{RootDir: root, Paths: paths, Writer: &out})
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		if out.Len() != 0 {
+			t.Errorf("%s: expected no output, got %d bytes", name, out.Len())
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// FormatEPUB is an e-book of the whole wiki. It is only written as a book
+// (see Options.Books), not by single-page exports.
+const FormatEPUB Format = "epub"
+
+// Files that Options.Books writes at the root of the export.
+const (
+	BookPDFFile  = "wiki.pdf"
+	BookEPUBFile = "wiki.epub"
+)
+
+// BookFormats lists the formats Options.Books accepts.
+func BookFormats() []Format {
+	return []Format{FormatPDF, FormatEPUB}
+}
+
+// bookPage is what the books keep of a page from its site render, so they
+// need neither read nor render it again.
+type bookPage struct {
+	path    string
+	section handoutSection // title and markdown body, printed into the PDF
+	html    string         // rendered body, reused for the EPUB chapter
+}
+
+// validateBooks checks the Books option of an export.
+func validateBooks(opts Options) error {
+	if len(opts.Books) == 0 {
+		return nil
+	}
+	if opts.SingleFile {
+		return errors.New("books cannot be written with a single-file export")
+	}
+	for _, format := range opts.Books {
+		if !slices.Contains(BookFormats(), format) {
+			return fmt.Errorf("unsupported book format: %s (allowed: pdf, epub)", format)
+		}
+	}
+	return nil
+}
+
+// writeBooks writes each book in st.opts.Books from the pages collected
+// while exporting the site, in export order.
+func (e *Exporter) writeBooks(ctx context.Context, st *exportState) error {
+	for _, format := range st.opts.Books {
+		var err error
+		switch format {
+		case FormatPDF:
+			err = e.writeBookPDF(ctx, st)
+		case FormatEPUB:
+			err = e.writeBookEPUB(st)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bookPages returns the collected pages in export order.
+func (st *exportState) bookPages() []bookPage {
+	pages := make([]bookPage, 0, len(st.order))
+	for _, rel := range st.order {
+		if page, ok := st.book[rel]; ok {
+			pages = append(pages, page)
+		}
+	}
+	return pages
+}
+
+// writeBookPDF prints the wiki the way ExportBatchPDF prints a handout: a
+// cover with the site title and build date, a contents list, then every page.
+func (e *Exporter) writeBookPDF(ctx context.Context, st *exportState) error {
+	pages := st.bookPages()
+	sections := make([]handoutSection, 0, len(pages))
+	for _, page := range pages {
+		sections = append(sections, page.section)
+	}
+	return writeBookFile(st.outputDir, BookPDFFile, func(f *os.File) error {
+		return e.exportPDF(ctx, "", time.Time{}, compileHandout(st.site.Title, st.site.GeneratedAt, sections), f)
+	})
+}
+
+// writeBookFile creates name in the output directory and fills it with write.
+func writeBookFile(outputDir, name string, write func(*os.File) error) error {
+	f, err := os.Create(filepath.Join(outputDir, name)) //nolint:gosec // fixed name inside the export output
+	if err != nil {
+		return fmt.Errorf("create %s: %w", name, err)
+	}
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
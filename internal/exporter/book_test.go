@@ -0,0 +1,156 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportWritesEPUB(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"index.md":           "# Home\n\nRead the [guide](guides/setup.md).\n\n<div x-data=\"{}\" @click=\"go()\">raw</div>\n<script>alert(1)</script>\n",
+		"guides/setup.md":    "---\ntitle: Setup Guide\n---\n\n## Install\n\n![diagram](diagram.png)\n\nA & B < C\n",
+		"guides/diagram.png": "\x89PNG\r\n\x1a\n",
+	}
+	for rel, body := range files {
+		abs := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exp, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "dist")
+	if err := exp.Export(context.Background(), Options{
+		Root:        root,
+		OutputDir:   out,
+		SiteTitle:   "Team Wiki",
+		CleanOutput: true,
+		Books:       []Format{FormatEPUB},
+	}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "index.html")); err != nil {
+		t.Fatalf("expected the site beside the book: %v", err)
+	}
+	manifest, err := ReadManifest(filepath.Join(out, ManifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := manifest.Outputs[BookEPUBFile]; !ok {
+		t.Errorf("expected %s in the manifest outputs", BookEPUBFile)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(out, BookEPUBFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	if err != nil {
+		t.Fatalf("open epub: %v", err)
+	}
+	if first := zr.File[0]; first.Name != "mimetype" || first.Method != zip.Store || len(first.Extra) != 0 {
+		t.Fatalf("expected a stored mimetype entry first, got %s (method %d, %d extra bytes)", first.Name, first.Method, len(first.Extra))
+	}
+
+	entries := make(map[string]string, len(zr.File))
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[f.Name] = string(data)
+		if strings.HasSuffix(f.Name, ".xhtml") || strings.HasSuffix(f.Name, ".opf") || strings.HasSuffix(f.Name, ".xml") {
+			assertWellFormedXML(t, f.Name, data)
+		}
+	}
+
+	if entries["mimetype"] != "application/epub+zip" {
+		t.Errorf("unexpected mimetype %q", entries["mimetype"])
+	}
+	opf := entries["OEBPS/content.opf"]
+	for _, want := range []string{
+		"<dc:title>Team Wiki</dc:title>",
+		`<itemref idref="page-1"/>`,
+		`href="media/1.png" media-type="image/png"`,
+	} {
+		if !strings.Contains(opf, want) {
+			t.Errorf("expected %q in content.opf:\n%s", want, opf)
+		}
+	}
+	nav := entries["OEBPS/nav.xhtml"]
+	if !strings.Contains(nav, `<a href="page-1.xhtml">Setup Guide</a>`) || !strings.Contains(nav, `<a href="page-2.xhtml">Home</a>`) {
+		t.Errorf("expected the pages listed in export order:\n%s", nav)
+	}
+
+	guide, home := entries["OEBPS/page-1.xhtml"], entries["OEBPS/page-2.xhtml"]
+	if !strings.Contains(home, `href="page-1.xhtml"`) {
+		t.Errorf("expected the page link to point at its chapter:\n%s", home)
+	}
+	if strings.Contains(home, "alert(1)") || strings.Contains(home, "@click") || strings.Count(home, "<h1") != 1 {
+		t.Errorf("expected scripts, invalid attributes, and a second title dropped:\n%s", home)
+	}
+	if !strings.Contains(guide, "<h1>Setup Guide</h1>") || !strings.Contains(guide, `src="media/1.png"`) {
+		t.Errorf("expected the title heading and the copied image:\n%s", guide)
+	}
+	if entries["OEBPS/media/1.png"] != files["guides/diagram.png"] {
+		t.Error("expected the image copied into the book")
+	}
+}
+
+func assertWellFormedXML(t *testing.T, name string, data []byte) {
+	t.Helper()
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = true
+	dec.Entity = map[string]string{}
+	for {
+		_, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			t.Fatalf("%s is not well-formed XML: %v\n%s", name, err, data)
+		}
+	}
+}
+
+func TestExportValidatesBooks(t *testing.T) {
+	t.Parallel()
+
+	exp, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for name, opts := range map[string]Options{
+		"unknown format": {Books: []Format{FormatMarkdown}},
+		"single file":    {Books: []Format{FormatEPUB}, SingleFile: true},
+	} {
+		opts.Root = t.TempDir()
+		opts.OutputDir = t.TempDir()
+		if err := exp.Export(context.Background(), opts); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+)
+
+// PageChange is one page in a Diff.
+type PageChange struct {
+	Path  string `json:"path"`
+	Title string `json:"title,omitempty"`
+	// URL is the page's file in the export output.
+	URL string `json:"url"`
+}
+
+// Diff lists how the pages of an export differ from a previous build.
+type Diff struct {
+	Added   []PageChange `json:"added"`
+	Changed []PageChange `json:"changed"`
+	Removed []PageChange `json:"removed"`
+}
+
+// Empty reports whether no page was added, changed, or removed.
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Changed) == 0 && len(d.Removed) == 0
+}
+
+// Diff compares the pages an export with opts would write against old, the
+// manifest of a previous build, without writing anything. Pages are compared
+// by the checksum of their source, so edits that only reach a page through
+// the shared navigation are not reported. Titles of removed pages come from
+// old and are missing when it predates them. Lists are sorted by path.
+func (e *Exporter) Diff(ctx context.Context, old Manifest, opts Options) (Diff, error) {
+	if strings.TrimSpace(opts.Root) == "" {
+		return Diff{}, errors.New("root directory is required")
+	}
+	rootDir, err := filepath.Abs(opts.Root)
+	if err != nil {
+		return Diff{}, fmt.Errorf("resolve root: %w", err)
+	}
+	treeRoot, err := tree.Build(ctx, rootDir, opts.treeOptions(e.renderer))
+	if err != nil {
+		return Diff{}, fmt.Errorf("build content tree: %w", err)
+	}
+
+	var diff Diff
+	current := make(map[string]bool)
+	for _, node := range collectDocuments(treeRoot) {
+		rel := node.RelativePath
+		current[rel] = true
+		sum, err := hashFile(filepath.Join(rootDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return Diff{}, fmt.Errorf("hash input %s: %w", rel, err)
+		}
+		change := PageChange{Path: rel, Title: node.Title, URL: toHTMLRel(rel)}
+		switch prev, ok := old.Inputs[rel]; {
+		case !ok:
+			diff.Added = append(diff.Added, change)
+		case prev != sum:
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+	for rel := range old.Inputs {
+		if !current[rel] {
+			diff.Removed = append(diff.Removed, PageChange{Path: rel, Title: old.Titles[rel], URL: toHTMLRel(rel)})
+		}
+	}
+	for _, list := range [][]PageChange{diff.Added, diff.Changed, diff.Removed} {
+		sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	}
+	return diff, nil
+}
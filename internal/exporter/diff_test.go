@@ -0,0 +1,66 @@
+package exporter
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiffAgainstPreviousManifest(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	write := func(rel, body string) {
+		t.Helper()
+		abs := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("index.md", "# Home\n")
+	write("guides/setup.md", "---\ntitle: Setup\n---\n# Setup\n")
+	write("guides/old.md", "---\ntitle: Old Guide\n---\n")
+
+	exp, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "dist")
+	if err := exp.Export(context.Background(), Options{Root: root, OutputDir: out}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	old, err := ReadManifest(filepath.Join(out, ManifestFile))
+	if err != nil {
+		t.Fatalf("ReadManifest: %v", err)
+	}
+
+	write("guides/setup.md", "---\ntitle: Setup\n---\n# Setup\n\nNow with steps.\n")
+	write("guides/new.md", "---\ntitle: New Guide\n---\n")
+	if err := os.Remove(filepath.Join(root, "guides", "old.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := exp.Diff(context.Background(), old, Options{Root: root})
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	want := Diff{
+		Added:   []PageChange{{Path: "guides/new.md", Title: "New Guide", URL: "guides/new.html"}},
+		Changed: []PageChange{{Path: "guides/setup.md", Title: "Setup", URL: "guides/setup.html"}},
+		Removed: []PageChange{{Path: "guides/old.md", Title: "Old Guide", URL: "guides/old.html"}},
+	}
+	if len(diff.Added) != 1 || diff.Added[0] != want.Added[0] ||
+		len(diff.Changed) != 1 || diff.Changed[0] != want.Changed[0] ||
+		len(diff.Removed) != 1 || diff.Removed[0] != want.Removed[0] {
+		t.Fatalf("expected %+v, got %+v", want, diff)
+	}
+
+	if diff, err := exp.Diff(context.Background(), Manifest{Inputs: map[string]string{}}, Options{Root: root}); err != nil || len(diff.Added) != 3 || diff.Empty() {
+		t.Fatalf("expected every page added against an empty manifest, got %+v, %v", diff, err)
+	}
+}
@@ -0,0 +1,364 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	epubMimetype  = "application/epub+zip"
+	epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+	epubStyles = `body { font-family: serif; line-height: 1.5; }
+h1, h2, h3, h4, h5, h6 { font-family: sans-serif; line-height: 1.2; }
+pre { white-space: pre-wrap; font-size: 0.85em; }
+img, svg { max-width: 100%; height: auto; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #999; padding: 0.2em 0.4em; }
+`
+)
+
+// xmlName matches attribute names that are also valid in XML. Rendered
+// markdown can carry raw HTML whose attributes (Alpine's @click, say) are not.
+var xmlName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// epubChapter is one page of the EPUB, already converted to XHTML.
+type epubChapter struct {
+	file  string // name inside OEBPS
+	title string
+	body  []byte
+	svg   bool // has inline SVG, which the package must declare
+}
+
+// epubMedia is a local image copied into the EPUB.
+type epubMedia struct {
+	file string // name inside OEBPS
+	mime string
+	data []byte
+}
+
+// epubEntry is one file of the EPUB container.
+type epubEntry struct {
+	name string
+	data []byte
+}
+
+// epubBuilder turns rendered pages into EPUB chapters, resolving links
+// between them and collecting the images they show.
+type epubBuilder struct {
+	logger   *slog.Logger
+	rootDir  string
+	baseURL  string
+	chapters map[string]string // wiki path -> chapter file
+	media    []epubMedia
+	mediaFor map[string]string // wiki path -> media file
+}
+
+// writeBookEPUB writes BookEPUBFile, an EPUB 3 book with one chapter per
+// page in export order, a contents page, and the local images the pages
+// show. Entries are stamped with the build time so unchanged content gives
+// an identical file.
+func (e *Exporter) writeBookEPUB(st *exportState) error {
+	pages := st.bookPages()
+	b := &epubBuilder{
+		logger:   e.logger,
+		rootDir:  st.rootDir,
+		baseURL:  st.site.BaseURL,
+		chapters: make(map[string]string, len(pages)),
+		mediaFor: make(map[string]string),
+	}
+	for i, page := range pages {
+		b.chapters[page.path] = fmt.Sprintf("page-%d.xhtml", i+1)
+	}
+	chapters := make([]epubChapter, 0, len(pages))
+	for _, page := range pages {
+		chapter, err := b.chapter(page)
+		if err != nil {
+			return fmt.Errorf("convert %s for %s: %w", page.path, BookEPUBFile, err)
+		}
+		chapters = append(chapters, chapter)
+	}
+
+	id := st.site.BaseURL
+	if id == "" {
+		id = "urn:wikimd:" + checksum([]byte(st.site.Title))[:16]
+	}
+	return writeBookFile(st.outputDir, BookEPUBFile, func(f *os.File) error {
+		return writeEPUB(f, id, st.site.Title, st.site.GeneratedAt, chapters, b.media)
+	})
+}
+
+// writeEPUB packages the chapters and media. The mimetype entry comes first,
+// uncompressed and without extra fields, as the OCF container format requires.
+func writeEPUB(w io.Writer, id, title string, modified time.Time, chapters []epubChapter, media []epubMedia) error {
+	zw := zip.NewWriter(w)
+	mt, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mt, epubMimetype); err != nil {
+		return err
+	}
+
+	entries := []epubEntry{
+		{"META-INF/container.xml", []byte(epubContainer)},
+		{"OEBPS/content.opf", epubPackage(id, title, modified, chapters, media)},
+		{"OEBPS/nav.xhtml", epubNav(chapters)},
+		{"OEBPS/style.css", []byte(epubStyles)},
+	}
+	for _, c := range chapters {
+		entries = append(entries, epubEntry{"OEBPS/" + c.file, c.body})
+	}
+	for _, m := range media {
+		entries = append(entries, epubEntry{"OEBPS/" + m.file, m.data})
+	}
+	for _, entry := range entries {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: entry.name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(entry.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// epubPackage is the content.opf: the book's metadata, every file in it,
+// and the reading order.
+func epubPackage(id, title string, modified time.Time, chapters []epubChapter, media []epubMedia) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+`)
+	fmt.Fprintf(&buf, "    <dc:identifier id=\"book-id\">%s</dc:identifier>\n", xmlText(id))
+	fmt.Fprintf(&buf, "    <dc:title>%s</dc:title>\n", xmlText(title))
+	buf.WriteString("    <dc:language>en</dc:language>\n")
+	fmt.Fprintf(&buf, "    <meta property=\"dcterms:modified\">%s</meta>\n", modified.UTC().Format("2006-01-02T15:04:05Z"))
+	buf.WriteString(`  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="style" href="style.css" media-type="text/css"/>
+`)
+	for i, c := range chapters {
+		properties := ""
+		if c.svg {
+			properties = ` properties="svg"`
+		}
+		fmt.Fprintf(&buf, "    <item id=\"page-%d\" href=\"%s\" media-type=\"application/xhtml+xml\"%s/>\n", i+1, c.file, properties)
+	}
+	for i, m := range media {
+		fmt.Fprintf(&buf, "    <item id=\"media-%d\" href=\"%s\" media-type=\"%s\"/>\n", i+1, m.file, xmlText(m.mime))
+	}
+	buf.WriteString("  </manifest>\n  <spine>\n")
+	for i := range chapters {
+		fmt.Fprintf(&buf, "    <itemref idref=\"page-%d\"/>\n", i+1)
+	}
+	buf.WriteString("  </spine>\n</package>\n")
+	return buf.Bytes()
+}
+
+// epubNav is the contents page, listing the chapters in order.
+func epubNav(chapters []epubChapter) []byte {
+	var body bytes.Buffer
+	body.WriteString("<nav epub:type=\"toc\" id=\"toc\">\n<h1>Contents</h1>\n<ol>\n")
+	for _, c := range chapters {
+		fmt.Fprintf(&body, "<li><a href=\"%s\">%s</a></li>\n", c.file, xmlText(c.title))
+	}
+	body.WriteString("</ol>\n</nav>\n")
+	return xhtmlDocument("Contents", body.Bytes())
+}
+
+// xhtmlDocument wraps body in an XHTML page that uses the book stylesheet.
+func xhtmlDocument(title string, body []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xmlns:xlink="http://www.w3.org/1999/xlink" lang="en" xml:lang="en">
+<head>
+<meta charset="utf-8"/>
+`)
+	fmt.Fprintf(&buf, "<title>%s</title>\n", xmlText(title))
+	buf.WriteString("<link rel=\"stylesheet\" type=\"text/css\" href=\"style.css\"/>\n</head>\n<body>\n")
+	buf.Write(body)
+	buf.WriteString("</body>\n</html>\n")
+	return buf.Bytes()
+}
+
+// chapter converts a page's rendered HTML to an XHTML chapter. The page
+// title opens it unless the page already starts with a level-one heading.
+func (b *epubBuilder) chapter(page bookPage) (epubChapter, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(page.html), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return epubChapter{}, err
+	}
+	c := epubChapter{file: b.chapters[page.path], title: page.section.title}
+
+	var body bytes.Buffer
+	body.WriteString("<section epub:type=\"chapter\">\n")
+	if first := firstElement(nodes); first == nil || first.DataAtom != atom.H1 {
+		fmt.Fprintf(&body, "<h1>%s</h1>\n", xmlText(c.title))
+	}
+	for _, n := range nodes {
+		if !b.clean(n, &c) {
+			continue
+		}
+		if err := html.Render(&body, n); err != nil {
+			return epubChapter{}, err
+		}
+	}
+	body.WriteString("\n</section>\n")
+	c.body = xhtmlDocument(c.title, body.Bytes())
+	return c, nil
+}
+
+func firstElement(nodes []*html.Node) *html.Node {
+	for _, n := range nodes {
+		if n.Type == html.ElementNode {
+			return n
+		}
+	}
+	return nil
+}
+
+// clean prepares n for an XHTML chapter and reports whether to keep it.
+// Scripts, stylesheets, embeds, and comments are dropped, as are attributes
+// XML cannot spell and event handlers. Links to other pages point at their
+// chapters and local images are copied into the book.
+func (b *epubBuilder) clean(n *html.Node, c *epubChapter) bool {
+	switch n.Type {
+	case html.CommentNode:
+		return false
+	case html.ElementNode:
+		if n.Namespace == "" {
+			switch n.DataAtom {
+			case atom.Script, atom.Style, atom.Noscript, atom.Iframe, atom.Object, atom.Embed, atom.Template:
+				return false
+			}
+		}
+		attrs := n.Attr[:0]
+		for _, a := range n.Attr {
+			if !xmlName.MatchString(a.Key) || strings.HasPrefix(strings.ToLower(a.Key), "on") {
+				continue
+			}
+			if n.Namespace == "" && a.Namespace == "" {
+				switch {
+				case n.DataAtom == atom.A && a.Key == "href":
+					a.Val = b.link(a.Val)
+				case n.DataAtom == atom.Img && a.Key == "src":
+					a.Val = b.image(a.Val)
+				}
+			}
+			attrs = append(attrs, a)
+		}
+		n.Attr = attrs
+		if n.Namespace == "svg" && n.Data == "svg" {
+			c.svg = true
+			if !hasAttr(n, "xmlns") {
+				n.Attr = append(n.Attr, html.Attribute{Key: "xmlns", Val: "http://www.w3.org/2000/svg"})
+			}
+		}
+	}
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		if !b.clean(child, c) {
+			n.RemoveChild(child)
+		}
+		child = next
+	}
+	return true
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// link points a /page/ link at the chapter of that page. Pages outside the
+// book link to the published site when there is a base URL.
+func (b *epubBuilder) link(href string) string {
+	escaped, ok := strings.CutPrefix(href, "/page/")
+	if !ok {
+		return href
+	}
+	escaped, fragment, _ := strings.Cut(escaped, "#")
+	rel, err := url.PathUnescape(escaped)
+	if err != nil {
+		return href
+	}
+	if fragment != "" {
+		fragment = "#" + fragment
+	}
+	if file, ok := b.chapters[rel]; ok {
+		return file + fragment
+	}
+	if b.baseURL != "" {
+		return b.baseURL + "/" + toHTMLRel(rel) + fragment
+	}
+	return href
+}
+
+// image copies the local image behind a /media/ source into the book and
+// returns its new source. Images that cannot be read keep their source.
+func (b *epubBuilder) image(src string) string {
+	escaped, ok := strings.CutPrefix(src, "/media/")
+	if !ok {
+		return src
+	}
+	rel, err := url.PathUnescape(escaped)
+	if err == nil {
+		rel = path.Clean("/" + rel)[1:]
+	}
+	if err != nil || rel == "" {
+		return src
+	}
+	if file, ok := b.mediaFor[rel]; ok {
+		return file
+	}
+	data, err := os.ReadFile(filepath.Join(b.rootDir, filepath.FromSlash(rel))) //nolint:gosec // cleaned path under the wiki root
+	if err != nil {
+		b.logger.Warn("copy image into book failed", slog.String("path", rel), slog.Any("err", err))
+		return src
+	}
+	mimeType := mime.TypeByExtension(path.Ext(rel))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	file := fmt.Sprintf("media/%d%s", len(b.media)+1, strings.ToLower(path.Ext(rel)))
+	b.media = append(b.media, epubMedia{file: file, mime: mimeType, data: data})
+	b.mediaFor[rel] = file
+	return file
+}
+
+func xmlText(s string) string {
+	var buf strings.Builder
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -14,9 +14,11 @@ import (
 	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/euforicio/wikimd/internal/banner"
 	"github.com/euforicio/wikimd/internal/content/tree"
 	"github.com/euforicio/wikimd/internal/renderer"
 	wikistatic "github.com/euforicio/wikimd/static"
@@ -36,6 +38,43 @@ type Options struct {
 	DarkModeFirst       bool
 	GenerateSearchIndex bool
 	CleanOutput         bool
+	// Optimize inlines each page's critical CSS, loads the full stylesheets
+	// without blocking render, defers scripts, and omits Mermaid on pages
+	// without diagrams.
+	Optimize bool
+	// SingleFile writes one self-contained index.html with every page,
+	// stylesheet, script, and local image inlined.
+	SingleFile bool
+	// AssetsRequired fails the export with ErrAssetsMissing when AssetsDir
+	// is unset or missing, or lacks an asset the pages link to, instead of
+	// falling back to the embedded assets.
+	AssetsRequired bool
+	// Progress, when set, is called after each document is written with the
+	// number done and the total.
+	Progress func(done, total int)
+	// ExcludeDirs and IgnoreFile leave content out of the export the same way
+	// they do for the live server; see tree.Options.
+	ExcludeDirs []string
+	IgnoreFile  string
+	// Banner, when set, is shown at the top of every exported page.
+	Banner *banner.Banner
+	// LLMsText writes a clean markdown copy beside each page and an
+	// llms.txt index of them for AI tools. Single-file exports skip it.
+	LLMsText bool
+	// Books lists whole-wiki documents to write beside the site, built from
+	// the same walk and renders: FormatPDF writes BookPDFFile and FormatEPUB
+	// BookEPUBFile. Single-file exports cannot carry them.
+	Books []Format
+}
+
+// treeOptions returns the tree build options matching o.
+func (o Options) treeOptions(r *renderer.Service) tree.Options {
+	return tree.Options{
+		Renderer:      r,
+		ExcludeDirs:   o.ExcludeDirs,
+		IgnoreFile:    o.IgnoreFile,
+		IncludeHidden: o.IncludeHidden,
+	}
 }
 
 // Exporter renders markdown content into a static HTML bundle.
@@ -47,9 +86,18 @@ type Exporter struct {
 
 // New constructs an exporter instance ready for use.
 func New(logger *slog.Logger) (*Exporter, error) {
+	return NewWithRenderer(logger, nil)
+}
+
+// NewWithRenderer is New with the markdown renderer to use, for example one
+// configured with diagram defaults. A nil renderer gets the default one.
+func NewWithRenderer(logger *slog.Logger, r *renderer.Service) (*Exporter, error) {
 	if logger == nil {
 		logger = slog.Default()
 	}
+	if r == nil {
+		r = renderer.NewService(logger)
+	}
 
 	tmpl, err := newTemplateRenderer()
 	if err != nil {
@@ -57,21 +105,57 @@ func New(logger *slog.Logger) (*Exporter, error) {
 	}
 
 	return &Exporter{
-		renderer:  renderer.NewService(logger),
+		renderer:  r,
 		templates: tmpl,
 		logger:    logger.With("component", "exporter"),
 	}, nil
 }
 
 // Export walks the markdown tree rooted at opts.Root and writes a static site to opts.OutputDir.
+func (e *Exporter) Export(ctx context.Context, opts Options) error {
+	_, err := e.export(ctx, opts, nil)
+	return err
+}
+
+// ExportTree is Export over an already built tree of opts.Root, such as the
+// live server's snapshot, so the wiki is not walked and every page rendered
+// again just to list it. root should come from tree.Build of opts.Root;
+// opts.ExcludeDirs, IgnoreFile, and IncludeHidden are not applied to it.
+func (e *Exporter) ExportTree(ctx context.Context, root *tree.Node, opts Options) error {
+	if root == nil {
+		return errors.New("content tree is required")
+	}
+	_, err := e.export(ctx, opts, root)
+	return err
+}
+
+// exportState is what a full export computed. Watch mode keeps it between
+// changes so a single edited page can be regenerated on its own.
 //
+//nolint:govet // field order favors readability over padding
+type exportState struct {
+	opts        Options
+	rootDir     string
+	outputDir   string
+	site        siteViewData
+	assets      assetRefs
+	assetReport AssetReport
+	critical    *criticalCSS
+	titles      map[string]string // document path -> navigation title
+	order       []string          // document paths in export order
+	search      map[string]searchEntry
+	llms        map[string]llmsEntry
+	book        map[string]bookPage
+	defaultPath string
+}
+
 //nolint:gocognit,gocyclo // export orchestration requires sequential steps and validation
-func (e *Exporter) Export(ctx context.Context, opts Options) error {
+func (e *Exporter) export(ctx context.Context, opts Options, treeRoot *tree.Node) (*exportState, error) {
 	if strings.TrimSpace(opts.Root) == "" {
-		return errors.New("root directory is required")
+		return nil, errors.New("root directory is required")
 	}
 	if strings.TrimSpace(opts.OutputDir) == "" {
-		return errors.New("output directory is required")
+		return nil, errors.New("output directory is required")
 	}
 	if strings.TrimSpace(opts.AssetPrefix) == "" {
 		opts.AssetPrefix = "assets"
@@ -79,40 +163,45 @@ func (e *Exporter) Export(ctx context.Context, opts Options) error {
 	if strings.TrimSpace(opts.SiteTitle) == "" {
 		opts.SiteTitle = "wikimd"
 	}
+	if err := validateBooks(opts); err != nil {
+		return nil, err
+	}
 
 	rootDir, err := filepath.Abs(opts.Root)
 	if err != nil {
-		return fmt.Errorf("resolve root: %w", err)
+		return nil, fmt.Errorf("resolve root: %w", err)
 	}
 	outputDir, err := filepath.Abs(opts.OutputDir)
 	if err != nil {
-		return fmt.Errorf("resolve output: %w", err)
+		return nil, fmt.Errorf("resolve output: %w", err)
 	}
-	assetsDir := opts.AssetsDir
+	assetsDir := strings.TrimSpace(opts.AssetsDir)
 	if assetsDir != "" {
 		if assetsDir, err = filepath.Abs(assetsDir); err != nil {
-			return fmt.Errorf("resolve assets: %w", err)
+			return nil, fmt.Errorf("resolve assets: %w", err)
 		}
 	}
+	if assetsDir, err = e.resolveAssetsDir(assetsDir, opts.AssetsRequired); err != nil {
+		return nil, err
+	}
 
 	if err := e.prepareOutputDir(outputDir, opts.CleanOutput); err != nil {
-		return err
+		return nil, err
 	}
 
-	generatedAt := time.Now().UTC()
+	started := time.Now()
 
-	treeRoot, err := tree.Build(ctx, rootDir, tree.Options{
-		IncludeHidden: opts.IncludeHidden,
-		Renderer:      e.renderer,
-	})
-	if err != nil {
-		return fmt.Errorf("build content tree: %w", err)
+	if treeRoot == nil {
+		if treeRoot, err = tree.Build(ctx, rootDir, opts.treeOptions(e.renderer)); err != nil {
+			return nil, fmt.Errorf("build content tree: %w", err)
+		}
 	}
 
 	docs := collectDocuments(treeRoot)
 	sort.Slice(docs, func(i, j int) bool {
 		return strings.Compare(strings.ToLower(docs[i].RelativePath), strings.ToLower(docs[j].RelativePath)) < 0
 	})
+	generatedAt := buildTime(docs)
 
 	site := siteViewData{
 		Title:         opts.SiteTitle,
@@ -120,6 +209,8 @@ func (e *Exporter) Export(ctx context.Context, opts Options) error {
 		Tree:          treeRoot,
 		DarkModeFirst: opts.DarkModeFirst,
 		BaseURL:       strings.TrimRight(opts.BaseURL, "/"),
+		Optimize:      opts.Optimize,
+		Banner:        opts.Banner,
 	}
 
 	treePayload := struct {
@@ -135,124 +226,246 @@ func (e *Exporter) Export(ctx context.Context, opts Options) error {
 		e.logger.Warn("encode tree json failed", slog.Any("err", err))
 	}
 
-	assets := buildAssetRefs(opts.AssetPrefix)
+	st := &exportState{
+		opts:      opts,
+		rootDir:   rootDir,
+		outputDir: outputDir,
+		site:      site,
+		titles:    navigationTitles(docs),
+		search:    make(map[string]searchEntry),
+		llms:      make(map[string]llmsEntry),
+		book:      make(map[string]bookPage),
+	}
+
+	// A single-file export stays one file, so it gets no manifest.
+	if opts.SingleFile {
+		return st, e.exportSingleFile(ctx, rootDir, outputDir, newAssetReader(assetsDir, opts.AssetsRequired), site, docs, opts.Progress)
+	}
+
+	st.assets = buildAssetRefs(opts.AssetPrefix)
 
 	assetDest := filepath.Join(outputDir, filepath.FromSlash(opts.AssetPrefix))
 	if err := e.copyAssetBundle(assetDest, assetsDir); err != nil {
-		return err
+		return nil, err
+	}
+	if st.assetReport, err = st.assets.hash(outputDir); err != nil {
+		return nil, err
 	}
+	st.assetReport.Source = assetSource(assetsDir)
+	if err := e.checkAssets(&st.assetReport, assetsDir, opts.AssetsRequired); err != nil {
+		return nil, err
+	}
+	e.logAssets(st.assetReport, assetsDir)
 
-	var (
-		defaultDoc  *tree.Node
-		defaultPage layoutViewData
-		searchIndex []searchEntry
-	)
+	if opts.Optimize {
+		stylesheet, err := os.ReadFile(filepath.Join(assetDest, "css", "app.css")) //nolint:gosec // path inside the export output
+		if err != nil {
+			e.logger.Warn("read stylesheet for critical css failed", slog.Any("err", err))
+		} else {
+			st.critical = newCriticalCSS(stylesheet)
+		}
+	}
 
-	for _, node := range docs {
+	var defaultPage layoutViewData
+
+	for i, node := range docs {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return nil, ctx.Err()
 		default:
 		}
 
-		absPath := filepath.Join(rootDir, filepath.FromSlash(node.RelativePath))
-		info, err := os.Stat(absPath)
-		if err != nil {
-			return fmt.Errorf("stat %s: %w", node.RelativePath, err)
-		}
-		raw, err := os.ReadFile(absPath) //nolint:gosec // absPath constructed from validated root
-		if err != nil {
-			return fmt.Errorf("read %s: %w", node.RelativePath, err)
-		}
-
-		doc, err := e.renderer.Render(ctx, absPath, info.ModTime(), raw)
+		layout, err := e.writeDocument(ctx, st, node)
 		if err != nil {
-			return fmt.Errorf("render %s: %w", node.RelativePath, err)
+			return nil, err
 		}
-
-		page := pageViewData{
-			Path:        node.RelativePath,
-			Output:      toHTMLRel(node.RelativePath),
-			URL:         toHTMLRel(node.RelativePath),
-			Title:       firstNonEmpty(doc.Metadata.Title, node.Title, titleFromPath(node.RelativePath)),
-			HTML:        template.HTML(doc.HTML), //nolint:gosec // HTML from trusted renderer
-			Metadata:    doc.Metadata,
-			Modified:    doc.Modified,
-			Breadcrumbs: breadcrumbsFor(treeRoot, node.RelativePath),
-		}
-
-		if site.BaseURL != "" {
-			if page.URL == indexHTML {
-				page.Canonical = site.BaseURL
-			} else {
-				page.Canonical = fmt.Sprintf("%s/%s", site.BaseURL, page.URL)
-			}
-		}
-
-		layout := layoutViewData{
-			Site:        site,
-			Page:        page,
-			Active:      node.RelativePath,
-			HasDocument: true,
-			Assets:      assets,
-		}
-
-		if err := e.writePage(outputDir, layout); err != nil {
-			return fmt.Errorf("write page %s: %w", node.RelativePath, err)
+		st.order = append(st.order, node.RelativePath)
+		if opts.Progress != nil {
+			opts.Progress(i+1, len(docs))
 		}
 
-		if defaultDoc == nil {
-			defaultDoc = node
+		if st.defaultPath == "" {
+			st.defaultPath = node.RelativePath
 			defaultPage = layout
 		}
-
-		if opts.GenerateSearchIndex {
-			searchIndex = append(searchIndex, searchEntry{
-				Path:     page.URL,
-				Source:   node.RelativePath,
-				Title:    page.Title,
-				Summary:  doc.Metadata.Description,
-				Modified: doc.Modified,
-				Raw:      string(raw),
-			})
-		}
 	}
 
-	if defaultDoc == nil {
+	if st.defaultPath == "" {
 		welcome := layoutViewData{
 			Site:   site,
-			Assets: assets,
+			Assets: st.assets,
 		}
 		welcome.Page.Title = site.Title
 		welcome.Page.Path = ""
 		welcome.Page.URL = indexHTML
 		welcome.Page.HTML = template.HTML(`<div class="rounded-2xl border border-dashed border-slate-700 bg-slate-900/60 p-8 text-sm text-slate-400">No markdown documents were found in the export root. Add <code>.md</code> files under the root directory and rerun <code>wikimd-export</code>.</div>`)
 		welcome.HasDocument = false
+		welcome.SkipMermaid = opts.Optimize
+		if err := e.applyCriticalCSS(st.critical, &welcome); err != nil {
+			return nil, fmt.Errorf("critical css for welcome page: %w", err)
+		}
 		if err := e.writeCustomPage(outputDir, indexHTML, welcome); err != nil {
-			return fmt.Errorf("write welcome page: %w", err)
+			return nil, fmt.Errorf("write welcome page: %w", err)
 		}
-	} else if rel := toHTMLRel(defaultDoc.RelativePath); rel != indexHTML {
+	} else if rel := toHTMLRel(st.defaultPath); rel != indexHTML {
 		if err := e.writeCustomPage(outputDir, indexHTML, defaultPage); err != nil {
-			return fmt.Errorf("write landing page: %w", err)
+			return nil, fmt.Errorf("write landing page: %w", err)
 		}
 	}
 
 	if err := writeTreeJSON(outputDir, treePayload); err != nil {
-		return err
+		return nil, err
 	}
 
 	if opts.GenerateSearchIndex {
-		if err := writeSearchIndex(outputDir, generatedAt, searchIndex); err != nil {
-			return err
+		if err := writeSearchIndex(outputDir, generatedAt, st.searchEntries()); err != nil {
+			return nil, err
+		}
+	}
+	if opts.LLMsText {
+		if err := writeLLMsText(st); err != nil {
+			return nil, err
 		}
 	}
+	if err := e.writeBooks(ctx, st); err != nil {
+		return nil, err
+	}
+
+	if err := e.writeManifest(ctx, st, generatedAt); err != nil {
+		return nil, err
+	}
 
 	e.logger.Info("export complete",
 		slog.Int("documents", len(docs)),
 		slog.String("output", outputDir),
-		slog.Duration("duration", time.Since(generatedAt)))
+		slog.Duration("duration", time.Since(started)))
 
-	return nil
+	return st, nil
+}
+
+// writeDocument renders one document into its output page and records its
+// search entry on st. The returned layout lets callers reuse it for index.html.
+func (e *Exporter) writeDocument(ctx context.Context, st *exportState, node *tree.Node) (layoutViewData, error) {
+	absPath := filepath.Join(st.rootDir, filepath.FromSlash(node.RelativePath))
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return layoutViewData{}, fmt.Errorf("stat %s: %w", node.RelativePath, err)
+	}
+	raw, err := os.ReadFile(absPath) //nolint:gosec // absPath constructed from validated root
+	if err != nil {
+		return layoutViewData{}, fmt.Errorf("read %s: %w", node.RelativePath, err)
+	}
+
+	doc, err := e.renderer.Render(ctx, node.RelativePath, info.ModTime(), raw)
+	if err != nil {
+		return layoutViewData{}, fmt.Errorf("render %s: %w", node.RelativePath, err)
+	}
+
+	page := pageViewData{
+		Path:        node.RelativePath,
+		Output:      toHTMLRel(node.RelativePath),
+		URL:         toHTMLRel(node.RelativePath),
+		Title:       firstNonEmpty(doc.Metadata.Title, node.Title, titleFromPath(node.RelativePath)),
+		HTML:        template.HTML(expandListings(doc.HTML, st.site.Tree, node.RelativePath, toHTMLRel)), //nolint:gosec // HTML from trusted renderer
+		Metadata:    doc.Metadata,
+		Modified:    doc.Modified,
+		Breadcrumbs: breadcrumbsFor(st.site.Tree, node.RelativePath),
+	}
+
+	if st.site.BaseURL != "" {
+		if page.URL == indexHTML {
+			page.Canonical = st.site.BaseURL
+		} else {
+			page.Canonical = fmt.Sprintf("%s/%s", st.site.BaseURL, page.URL)
+		}
+	}
+
+	layout := layoutViewData{
+		Site:        st.site,
+		Page:        page,
+		Active:      node.RelativePath,
+		HasDocument: true,
+		Assets:      st.assets,
+		SkipMermaid: st.opts.Optimize && !strings.Contains(doc.HTML, `class="mermaid"`),
+	}
+	if err := e.applyCriticalCSS(st.critical, &layout); err != nil {
+		return layoutViewData{}, fmt.Errorf("critical css %s: %w", node.RelativePath, err)
+	}
+
+	if err := e.writePage(st.outputDir, layout); err != nil {
+		return layoutViewData{}, fmt.Errorf("write page %s: %w", node.RelativePath, err)
+	}
+
+	// Pages marked noindex are exported but left out of the indexes; a
+	// watch refresh may be dropping one that was listed before.
+	delete(st.search, node.RelativePath)
+	if st.opts.GenerateSearchIndex && !doc.Metadata.NoIndex {
+		st.search[node.RelativePath] = searchEntry{
+			Path:     page.URL,
+			Source:   node.RelativePath,
+			Title:    page.Title,
+			Summary:  doc.Metadata.Description,
+			Modified: doc.Modified,
+			Raw:      string(raw),
+		}
+	}
+	if st.opts.LLMsText {
+		if err := st.recordMirror(node, page.Title, raw, doc); err != nil {
+			return layoutViewData{}, err
+		}
+	}
+	if len(st.opts.Books) > 0 {
+		st.book[node.RelativePath] = bookPage{
+			path:    node.RelativePath,
+			section: splitSection(node.RelativePath, raw),
+			html:    expandListings(doc.HTML, st.site.Tree, node.RelativePath, func(rel string) string { return "/page/" + rel }),
+		}
+	}
+	return layout, nil
+}
+
+func (st *exportState) searchEntries() []searchEntry {
+	entries := make([]searchEntry, 0, len(st.order))
+	for _, rel := range st.order {
+		if entry, ok := st.search[rel]; ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// sourceDateEpochEnv overrides the export timestamp, following the
+// reproducible-builds convention.
+const sourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+// buildTime is the timestamp stamped into an export. It is taken from the
+// inputs rather than the clock so rebuilding unchanged content produces
+// byte-identical output: SOURCE_DATE_EPOCH when set, otherwise the newest
+// document modification time.
+func buildTime(docs []*tree.Node) time.Time {
+	if raw := strings.TrimSpace(os.Getenv(sourceDateEpochEnv)); raw != "" {
+		if secs, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return time.Unix(secs, 0).UTC()
+		}
+	}
+	var newest time.Time
+	for _, node := range docs {
+		if node.Modified.After(newest) {
+			newest = node.Modified
+		}
+	}
+	if newest.IsZero() {
+		return time.Unix(0, 0).UTC()
+	}
+	return newest.UTC().Truncate(time.Second)
+}
+
+func navigationTitles(docs []*tree.Node) map[string]string {
+	titles := make(map[string]string, len(docs))
+	for _, node := range docs {
+		titles[node.RelativePath] = node.Title
+	}
+	return titles
 }
 
 func (e *Exporter) prepareOutputDir(output string, clean bool) error {
@@ -280,21 +493,32 @@ func (e *Exporter) writeCustomPage(root, rel string, data layoutViewData) error
 	return os.WriteFile(dest, buf.Bytes(), 0o644) //nolint:gosec // standard file permissions
 }
 
+// applyCriticalCSS renders the page once to learn which classes it uses and
+// stores the matching stylesheet subset on data. A nil critical is a no-op.
+func (e *Exporter) applyCriticalCSS(critical *criticalCSS, data *layoutViewData) error {
+	if critical == nil {
+		return nil
+	}
+	buf := bytes.Buffer{}
+	if err := e.templates.render(&buf, "layout", *data); err != nil {
+		return err
+	}
+	data.CriticalCSS = critical.forPage(buf.Bytes())
+	return nil
+}
+
+// copyAssetBundle copies the assets directory chosen by resolveAssetsDir,
+// or the embedded bundle when it is empty, to dest.
 func (e *Exporter) copyAssetBundle(dest, override string) error {
 	if err := os.RemoveAll(dest); err != nil {
 		return fmt.Errorf("reset assets dir: %w", err)
 	}
-	override = strings.TrimSpace(override)
 	if override != "" {
-		if info, err := os.Stat(override); err == nil && info.IsDir() {
-			if err := copyAssets(override, dest); err != nil {
-				return fmt.Errorf("copy override assets: %w", err)
-			}
-			e.logger.Debug("exporter using override assets", slog.String("source", override))
-			return nil
-		} else if err != nil && !errors.Is(err, os.ErrNotExist) {
-			return fmt.Errorf("stat assets override: %w", err)
+		if err := copyAssets(override, dest); err != nil {
+			return fmt.Errorf("copy override assets: %w", err)
 		}
+		e.logger.Debug("exporter using override assets", slog.String("source", override))
+		return nil
 	}
 
 	if err := wikistatic.CopyAll(dest); err != nil {
@@ -322,6 +546,14 @@ func collectDocuments(root *tree.Node) []*tree.Node {
 	return docs
 }
 
+// expandListings fills {{children}} directives from the exported tree, linking
+// entries with href.
+func expandListings(html string, root *tree.Node, path string, href func(string) string) string {
+	return renderer.ExpandListings(html, func(depth int) []renderer.ListItem {
+		return tree.Listing(root, path, depth, href)
+	})
+}
+
 func toHTMLRel(rel string) string {
 	clean := strings.TrimSpace(rel)
 	if clean == "" {
@@ -418,6 +650,7 @@ func buildAssetRefs(prefix string) assetRefs {
 		return join("vendor", name)
 	}
 	return assetRefs{
+		prefix:    clean,
 		CSSApp:    join("css", "app.css"),
 		CSSChroma: vendor("chroma-github-dark.min.css"),
 		JSApp:     join("js", "static-site.js"),
@@ -512,7 +745,9 @@ type layoutViewData struct {
 	Site        siteViewData
 	Assets      assetRefs
 	Active      string
+	CriticalCSS template.CSS
 	HasDocument bool
+	SkipMermaid bool
 }
 
 type siteViewData struct {
@@ -522,6 +757,8 @@ type siteViewData struct {
 	TreeJSON      template.JS
 	BaseURL       string
 	DarkModeFirst bool
+	Optimize      bool
+	Banner        *banner.Banner
 }
 
 type pageViewData struct {
@@ -537,8 +774,34 @@ type pageViewData struct {
 }
 
 type assetRefs struct {
+	prefix    string
 	CSSApp    string
 	CSSChroma string
 	JSApp     string
 	JSMermaid string
+	// Integrity holds the SRI value of each asset above, keyed by its ref.
+	Integrity map[string]string
+}
+
+// hash records the SRI value of each referenced asset as copied into the
+// export, so an override --assets directory is hashed as published, and
+// reports each one's SHA-256. Assets missing from the bundle get no hash and
+// are reported missing.
+func (a *assetRefs) hash(outputDir string) (AssetReport, error) {
+	a.Integrity = make(map[string]string, 4)
+	report := AssetReport{Files: make(map[string]string, 4)}
+	for _, ref := range []string{a.CSSApp, a.CSSChroma, a.JSApp, a.JSMermaid} {
+		name := strings.TrimPrefix(ref, a.prefix+"/")
+		data, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(ref))) //nolint:gosec // path inside the export output
+		if errors.Is(err, os.ErrNotExist) {
+			report.Missing = append(report.Missing, name)
+			continue
+		}
+		if err != nil {
+			return AssetReport{}, fmt.Errorf("hash asset %s: %w", ref, err)
+		}
+		a.Integrity[ref] = wikistatic.SRI(data)
+		report.Files[name] = checksum(data)
+	}
+	return report, nil
 }
@@ -0,0 +1,437 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/buildinfo"
+	"github.com/euforicio/wikimd/internal/content/tree"
+	"github.com/euforicio/wikimd/internal/renderer"
+	wikistatic "github.com/euforicio/wikimd/static"
+)
+
+func TestExportHonoursExcludeDirsAndIgnoreFile(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"index.md":             "# Home\n",
+		"drafts/idea.md":       "# Idea\n",
+		"scratch/notes.md":     "# Notes\n",
+		tree.DefaultIgnoreFile: "drafts/\n",
+	}
+	for rel, body := range files {
+		abs := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exp, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "dist")
+	if err := exp.Export(context.Background(), Options{
+		Root:        root,
+		OutputDir:   out,
+		CleanOutput: true,
+		ExcludeDirs: []string{"scratch"},
+		IgnoreFile:  tree.DefaultIgnoreFile,
+	}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(out, "index.html")); err != nil {
+		t.Fatalf("expected index page: %v", err)
+	}
+	for _, dir := range []string{"drafts", "scratch"} {
+		if _, err := os.Stat(filepath.Join(out, dir)); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be left out of the export, got %v", dir, err)
+		}
+	}
+}
+
+func TestExportResolvesLinksFromThePageFolder(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "guides"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "guides", "intro.md"), []byte("# Intro\n\nSee [setup](setup.md).\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "guides", "setup.md"), []byte("# Setup\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	exp, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "dist")
+	if err := exp.Export(context.Background(), Options{Root: root, OutputDir: out}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	page, err := os.ReadFile(filepath.Join(out, "guides", "intro.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(page), `href="/page/guides/setup.md"`) {
+		t.Fatalf("expected the link to resolve within guides/, got %s", page)
+	}
+}
+
+func TestExportTreeUsesTheGivenTree(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, name := range []string{"index.md", "extra.md"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("# Page\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	renderSvc := renderer.NewService(logger)
+	built, err := tree.Build(context.Background(), root, tree.Options{Renderer: renderSvc})
+	if err != nil {
+		t.Fatalf("tree.Build: %v", err)
+	}
+	// A snapshot taken before extra.md appeared.
+	snapshot := *built
+	snapshot.Children = nil
+	for _, child := range built.Children {
+		if child.RelativePath != "extra.md" {
+			snapshot.Children = append(snapshot.Children, child)
+		}
+	}
+
+	exp, err := NewWithRenderer(logger, renderSvc)
+	if err != nil {
+		t.Fatalf("NewWithRenderer: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "dist")
+	if err := exp.ExportTree(context.Background(), &snapshot, Options{Root: root, OutputDir: out}); err != nil {
+		t.Fatalf("ExportTree: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "index.html")); err != nil {
+		t.Fatalf("expected index page: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "extra.html")); !os.IsNotExist(err) {
+		t.Fatalf("expected pages outside the tree to be skipped, got %v", err)
+	}
+}
+
+func TestExportWritesManifest(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "index.md"), []byte("# Home\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	exp, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "dist")
+	if err := exp.Export(context.Background(), Options{
+		Root:        root,
+		OutputDir:   out,
+		SiteTitle:   "Handbook",
+		ExcludeDirs: []string{"scratch"},
+	}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	m, err := ReadManifest(filepath.Join(out, ManifestFile))
+	if err != nil {
+		t.Fatalf("ReadManifest: %v", err)
+	}
+	if m.Wikimd.Version == "" || m.Wikimd.Mermaid != buildinfo.MermaidVersion || m.GeneratedAt.IsZero() {
+		t.Errorf("expected build info and timestamp, got %+v", m)
+	}
+	if m.Options.SiteTitle != "Handbook" || len(m.Options.ExcludeDirs) != 1 {
+		t.Errorf("expected export options to be recorded, got %+v", m.Options)
+	}
+	sum, err := hashFile(filepath.Join(root, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Inputs["index.md"] != sum {
+		t.Errorf("expected input hash %s, got %v", sum, m.Inputs)
+	}
+	if m.Outputs["index.html"] == "" || m.Outputs["tree.json"] == "" {
+		t.Errorf("expected output hashes, got %v", m.Outputs)
+	}
+	if _, ok := m.Outputs[ManifestFile]; ok {
+		t.Errorf("manifest should not hash itself")
+	}
+}
+
+func TestExportPinsAssetsWithSRI(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "index.md"), []byte("# Home\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	exp, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "dist")
+	if err := exp.Export(context.Background(), Options{Root: root, OutputDir: out}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	css, err := os.ReadFile(filepath.Join(out, "assets", "css", "app.css"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := os.ReadFile(filepath.Join(out, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `integrity="` + wikistatic.SRI(css) + `"`
+	if !strings.Contains(string(page), want) {
+		t.Fatalf("expected %s on the stylesheet link, got %s", want, page)
+	}
+}
+
+func TestExportAssetsRequired(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "index.md"), []byte("# Home\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	partial := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(partial, "css"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(partial, "css", "app.css"), []byte("body{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	exp, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	export := func(opts Options) (string, error) {
+		opts.Root = root
+		opts.OutputDir = filepath.Join(t.TempDir(), "dist")
+		return opts.OutputDir, exp.Export(context.Background(), opts)
+	}
+
+	for name, opts := range map[string]Options{
+		"missing dir":            {AssetsDir: missing, AssetsRequired: true},
+		"no dir":                 {AssetsRequired: true},
+		"incomplete dir":         {AssetsDir: partial, AssetsRequired: true},
+		"incomplete single file": {AssetsDir: partial, AssetsRequired: true, SingleFile: true},
+	} {
+		if _, err := export(opts); !errors.Is(err, ErrAssetsMissing) {
+			t.Errorf("%s: Export = %v, want ErrAssetsMissing", name, err)
+		}
+	}
+
+	out, err := export(Options{AssetsDir: missing})
+	if err != nil {
+		t.Fatalf("Export without AssetsRequired: %v", err)
+	}
+	m, err := ReadManifest(filepath.Join(out, ManifestFile))
+	if err != nil {
+		t.Fatalf("ReadManifest: %v", err)
+	}
+	css, err := os.ReadFile(filepath.Join(out, "assets", "css", "app.css"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Assets.Source != AssetSourceEmbedded || m.Assets.Files["css/app.css"] != checksum(css) {
+		t.Errorf("expected embedded assets with checksums in the manifest, got %+v", m.Assets)
+	}
+}
+
+func TestExportIsReproducible(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for rel, body := range map[string]string{
+		"index.md":        "# Home\n\nSee [setup](guides/setup.md).\n",
+		"guides/setup.md": "---\ntags: [ops, onboarding]\n---\n# Setup\n\n```go\nfmt.Println(1)\n```\n",
+		"notes/a.md":      "# A\n",
+	} {
+		abs := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exp, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	export := func() map[string]string {
+		t.Helper()
+		out := filepath.Join(t.TempDir(), "dist")
+		if err := exp.Export(context.Background(), Options{
+			Root:                root,
+			OutputDir:           out,
+			GenerateSearchIndex: true,
+			Optimize:            true,
+		}); err != nil {
+			t.Fatalf("Export: %v", err)
+		}
+		sums, err := hashTree(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		manifest, err := hashFile(filepath.Join(out, ManifestFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sums[ManifestFile] = manifest
+		return sums
+	}
+
+	first := export()
+	time.Sleep(1100 * time.Millisecond) // a clock-based timestamp would now differ
+	second := export()
+	if len(first) != len(second) {
+		t.Fatalf("expected the same files, got %d and %d", len(first), len(second))
+	}
+	for rel, sum := range first {
+		if second[rel] != sum {
+			t.Errorf("%s differs between identical exports", rel)
+		}
+	}
+}
+
+func TestExportLLMsText(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"index.md":         "---\ndescription: Team handbook.\n---\n# Home\n\nWelcome.\n",
+		"guides/setup.md":  "---\ntitle: Setup [draft]\n---\nInstall the tools, then see [home](../index.md).\n",
+		"guides/deploy.md": "# Deploy\n\nShip it.\n",
+		"reference/api.md": "# API\n",
+		"roadmap.md":       "# Roadmap\n\nWhat comes next.\n",
+	}
+	for rel, body := range files {
+		abs := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exp, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "dist")
+	if err := exp.Export(context.Background(), Options{
+		Root:        root,
+		OutputDir:   out,
+		CleanOutput: true,
+		BaseURL:     "https://docs.example.com",
+		LLMsText:    true,
+	}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	index, err := os.ReadFile(filepath.Join(out, LLMsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# wikimd\n\n> Team handbook.\n\n" +
+		"## Pages\n\n- [Home](https://docs.example.com/index.md): Team handbook.\n- [Roadmap](https://docs.example.com/roadmap.md): What comes next.\n\n" +
+		"## Guides\n\n- [Deploy](https://docs.example.com/guides/deploy.md): Ship it.\n- [Setup \\[draft\\]](https://docs.example.com/guides/setup.md): Install the tools, then see home.\n\n" +
+		"## Reference\n\n- [API](https://docs.example.com/reference/api.md)\n"
+	if string(index) != want {
+		t.Errorf("llms.txt:\n got %q\nwant %q", index, want)
+	}
+
+	mirror, err := os.ReadFile(filepath.Join(out, "guides", "setup.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# Setup [draft]\n\nInstall the tools, then see [home](../index.md).\n"; string(mirror) != want {
+		t.Errorf("markdown copy:\n got %q\nwant %q", mirror, want)
+	}
+	if mirror, _ := os.ReadFile(filepath.Join(out, "index.md")); string(mirror) != "# Home\n\nWelcome.\n" {
+		t.Errorf("expected the frontmatter stripped from index.md, got %q", mirror)
+	}
+}
+
+func TestExportNoIndex(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"index.md":  "# Home\n\nWelcome.\n",
+		"secret.md": "---\nnoindex: true\n---\n# Secret\n\nUnlisted.\n",
+	}
+	for rel, body := range files {
+		if err := os.WriteFile(filepath.Join(root, rel), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exp, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "dist")
+	if err := exp.Export(context.Background(), Options{
+		Root:                root,
+		OutputDir:           out,
+		CleanOutput:         true,
+		GenerateSearchIndex: true,
+		LLMsText:            true,
+	}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	secret, err := os.ReadFile(filepath.Join(out, "secret.html"))
+	if err != nil {
+		t.Fatalf("expected the noindex page to be exported: %v", err)
+	}
+	if !strings.Contains(string(secret), `<meta name="robots" content="noindex">`) {
+		t.Error("expected a robots meta tag on the noindex page")
+	}
+	if home, _ := os.ReadFile(filepath.Join(out, "index.html")); strings.Contains(string(home), `name="robots"`) {
+		t.Error("expected no robots meta tag on an indexed page")
+	}
+
+	for _, name := range []string{"search.json", LLMsFile} {
+		data, err := os.ReadFile(filepath.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), "secret") {
+			t.Errorf("expected %s to leave out the noindex page, got %s", name, data)
+		}
+		if !strings.Contains(string(data), "index") {
+			t.Errorf("expected %s to list the home page, got %s", name, data)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/euforicio/wikimd/internal/clipboard"
+	"github.com/euforicio/wikimd/internal/content/tree"
+	"github.com/euforicio/wikimd/internal/renderer"
+)
+
+// LLMsFile is the index of markdown mirrors that Options.LLMsText writes,
+// following the llms.txt convention (https://llmstxt.org).
+const LLMsFile = "llms.txt"
+
+// llmsEntry is one page listed in llms.txt.
+type llmsEntry struct {
+	Mirror  string // output-relative path of the markdown copy
+	Title   string
+	Summary string
+}
+
+// writeMirror writes a clean markdown copy of a page beside its HTML: the
+// source without frontmatter, opened by the page title when the source does
+// not start with a heading. Links between pages keep working, since the
+// copies mirror the source layout.
+func writeMirror(outputDir, rel, title string, body []byte) (string, error) {
+	var out bytes.Buffer
+	if !bytes.HasPrefix(body, []byte("# ")) {
+		fmt.Fprintf(&out, "# %s\n\n", title)
+	}
+	out.Write(body)
+	if !bytes.HasSuffix(body, []byte("\n")) {
+		out.WriteByte('\n')
+	}
+
+	mirror := toMarkdownRel(rel)
+	dest := filepath.Join(outputDir, filepath.FromSlash(mirror))
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil { //nolint:gosec // standard directory permissions
+		return "", err
+	}
+	if err := os.WriteFile(dest, out.Bytes(), 0o644); err != nil { //nolint:gosec // standard file permissions
+		return "", err
+	}
+	return mirror, nil
+}
+
+// recordMirror writes the markdown copy of a rendered page and notes it for
+// llms.txt unless the page is marked noindex. The listed title is the frontmatter title, else the page's
+// leading heading, else fallback.
+func (st *exportState) recordMirror(node *tree.Node, fallback string, raw []byte, doc renderer.Document) error {
+	body := []byte(strings.TrimLeft(clipboard.Markdown(string(raw)), " \t\r\n"))
+	title := doc.Metadata.Title
+	if heading, ok := bytes.CutPrefix(body, []byte("# ")); title == "" && ok {
+		line, _, _ := bytes.Cut(heading, []byte("\n"))
+		title = strings.TrimSpace(string(line))
+	}
+	title = firstNonEmpty(title, fallback)
+
+	mirror, err := writeMirror(st.outputDir, node.RelativePath, title, body)
+	if err != nil {
+		return fmt.Errorf("write markdown copy of %s: %w", node.RelativePath, err)
+	}
+	if doc.Metadata.NoIndex {
+		delete(st.llms, node.RelativePath)
+		return nil
+	}
+	st.llms[node.RelativePath] = llmsEntry{
+		Mirror:  mirror,
+		Title:   title,
+		Summary: firstNonEmpty(doc.Metadata.Description, renderer.Summarize(doc.HTML).Excerpt),
+	}
+	return nil
+}
+
+// writeLLMsText writes llms.txt: the site title, the home page summary as a
+// quote, then a list of links to the markdown copies. Pages at the root
+// come first under "Pages", then one section per top-level directory, titled from its name.
+func writeLLMsText(st *exportState) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# %s\n", st.site.Title)
+	if home, ok := st.homeEntry(); ok && home.Summary != "" {
+		fmt.Fprintf(&buf, "\n> %s\n", strings.Join(strings.Fields(home.Summary), " "))
+	}
+
+	var pages []llmsEntry
+	sections := map[string][]llmsEntry{}
+	var dirs []string
+	for _, rel := range st.order {
+		entry, ok := st.llms[rel]
+		if !ok {
+			continue
+		}
+		dir, _, nested := strings.Cut(rel, "/")
+		if !nested {
+			pages = append(pages, entry)
+			continue
+		}
+		if _, seen := sections[dir]; !seen {
+			dirs = append(dirs, dir)
+		}
+		sections[dir] = append(sections[dir], entry)
+	}
+
+	if len(pages) > 0 {
+		st.writeLLMsSection(&buf, "Pages", pages)
+	}
+	for _, dir := range dirs {
+		st.writeLLMsSection(&buf, titleFromPath(dir), sections[dir])
+	}
+
+	if err := os.WriteFile(filepath.Join(st.outputDir, LLMsFile), buf.Bytes(), 0o644); err != nil { //nolint:gosec // standard file permissions
+		return fmt.Errorf("write %s: %w", LLMsFile, err)
+	}
+	return nil
+}
+
+// homeEntry is the root index.md or README.md, else the landing page.
+func (st *exportState) homeEntry() (llmsEntry, bool) {
+	for _, rel := range []string{"index.md", "README.md", "readme.md", st.defaultPath} {
+		if entry, ok := st.llms[rel]; ok {
+			return entry, true
+		}
+	}
+	return llmsEntry{}, false
+}
+
+func (st *exportState) writeLLMsSection(buf *bytes.Buffer, heading string, entries []llmsEntry) {
+	fmt.Fprintf(buf, "\n## %s\n\n", heading)
+	for _, entry := range entries {
+		fmt.Fprintf(buf, "- [%s](%s)", escapeLinkText(entry.Title), st.mirrorURL(entry.Mirror))
+		if summary := strings.Join(strings.Fields(entry.Summary), " "); summary != "" {
+			fmt.Fprintf(buf, ": %s", summary)
+		}
+		buf.WriteByte('\n')
+	}
+}
+
+// mirrorURL links a markdown copy absolutely when the export has a base URL.
+func (st *exportState) mirrorURL(mirror string) string {
+	if st.site.BaseURL != "" {
+		return st.site.BaseURL + "/" + mirror
+	}
+	return mirror
+}
+
+// toMarkdownRel is the output path of a page's markdown copy.
+func toMarkdownRel(rel string) string {
+	return strings.TrimSuffix(rel, path.Ext(rel)) + ".md"
+}
+
+func escapeLinkText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`).Replace(s)
+}
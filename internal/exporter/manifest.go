@@ -0,0 +1,211 @@
+package exporter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/buildinfo"
+)
+
+// ManifestFile is written at the root of every export. It records what
+// produced the site so a published build can be audited and a rebuild from
+// the same inputs verified against it.
+const ManifestFile = "manifest.json"
+
+// Manifest is the content of ManifestFile. Paths are slash-separated and
+// relative to the wiki root (Inputs) or the output directory (Outputs).
+type Manifest struct {
+	GeneratedAt time.Time       `json:"generatedAt"`
+	Wikimd      ManifestBuild   `json:"wikimd"`
+	Source      ManifestSource  `json:"source"`
+	Options     ManifestOptions `json:"options"`
+	Assets      AssetReport     `json:"assets"`
+	// Inputs and Outputs map each file to its hex SHA-256.
+	Inputs  map[string]string `json:"inputs"`
+	Outputs map[string]string `json:"outputs"`
+	// Titles maps each document in Inputs to its navigation title, so a
+	// later Diff can name pages that have since been removed.
+	Titles map[string]string `json:"titles,omitempty"`
+}
+
+// ManifestBuild identifies the wikimd binary that ran the export and the
+// Mermaid release bundled with it.
+type ManifestBuild struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+	Date    string `json:"date,omitempty"`
+	Mermaid string `json:"mermaid,omitempty"`
+}
+
+// ManifestSource describes the wiki checkout that was exported. Commit is
+// empty when the root is not a git repository; Dirty reports uncommitted
+// changes under the root.
+type ManifestSource struct {
+	Commit string `json:"commit,omitempty"`
+	Dirty  bool   `json:"dirty,omitempty"`
+}
+
+// ManifestOptions records the export options that affect the output.
+// Machine-specific paths are left out so identical builds match.
+type ManifestOptions struct {
+	SiteTitle           string   `json:"siteTitle"`
+	BaseURL             string   `json:"baseUrl,omitempty"`
+	AssetPrefix         string   `json:"assetPrefix"`
+	ExcludeDirs         []string `json:"excludeDirs,omitempty"`
+	IgnoreFile          string   `json:"ignoreFile,omitempty"`
+	IncludeHidden       bool     `json:"includeHidden"`
+	DarkModeFirst       bool     `json:"darkModeFirst"`
+	GenerateSearchIndex bool     `json:"searchIndex"`
+	Optimize            bool     `json:"optimize"`
+	SingleFile          bool     `json:"singleFile"`
+	LLMsText            bool     `json:"llmsText,omitempty"`
+	Books               []Format `json:"books,omitempty"`
+}
+
+// ReadManifest loads a manifest written by a previous export.
+func ReadManifest(path string) (Manifest, error) {
+	raw, err := os.ReadFile(path) //nolint:gosec // caller-chosen manifest path
+	if err != nil {
+		return Manifest{}, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return Manifest{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// writeManifest hashes the exported documents and every file in the output
+// directory and writes ManifestFile.
+func (e *Exporter) writeManifest(ctx context.Context, st *exportState, generatedAt time.Time) error {
+	m := Manifest{
+		GeneratedAt: generatedAt,
+		Wikimd: ManifestBuild{
+			Version: buildinfo.Version,
+			Commit:  buildinfo.Commit,
+			Date:    buildinfo.Date,
+			Mermaid: buildinfo.MermaidVersion,
+		},
+		Source:  gitSource(ctx, st.rootDir),
+		Options: manifestOptions(st.opts),
+		Assets:  st.assetReport,
+		Inputs:  make(map[string]string, len(st.titles)),
+		Titles:  st.titles,
+	}
+
+	for rel := range st.titles {
+		sum, err := hashFile(filepath.Join(st.rootDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return fmt.Errorf("hash input %s: %w", rel, err)
+		}
+		m.Inputs[rel] = sum
+	}
+
+	outputs, err := hashTree(st.outputDir)
+	if err != nil {
+		return fmt.Errorf("hash output: %w", err)
+	}
+	m.Outputs = outputs
+
+	raw, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(st.outputDir, ManifestFile), append(raw, '\n'), 0o644); err != nil { //nolint:gosec // standard file permissions
+		return fmt.Errorf("write %s: %w", ManifestFile, err)
+	}
+	return nil
+}
+
+func manifestOptions(opts Options) ManifestOptions {
+	exclude := append([]string(nil), opts.ExcludeDirs...)
+	sort.Strings(exclude)
+	return ManifestOptions{
+		SiteTitle:           opts.SiteTitle,
+		BaseURL:             opts.BaseURL,
+		AssetPrefix:         opts.AssetPrefix,
+		ExcludeDirs:         exclude,
+		IgnoreFile:          opts.IgnoreFile,
+		IncludeHidden:       opts.IncludeHidden,
+		DarkModeFirst:       opts.DarkModeFirst,
+		GenerateSearchIndex: opts.GenerateSearchIndex,
+		Optimize:            opts.Optimize,
+		SingleFile:          opts.SingleFile,
+		LLMsText:            opts.LLMsText,
+		Books:               opts.Books,
+	}
+}
+
+// gitSource reports the commit checked out at root, if root is in a git
+// repository.
+func gitSource(ctx context.Context, root string) ManifestSource {
+	if _, err := exec.LookPath("git"); err != nil {
+		return ManifestSource{}
+	}
+	out, err := exec.CommandContext(ctx, "git", "-C", root, "rev-parse", "HEAD").Output() //nolint:gosec // fixed git arguments
+	if err != nil {
+		return ManifestSource{}
+	}
+	src := ManifestSource{Commit: strings.TrimSpace(string(out))}
+	status, err := exec.CommandContext(ctx, "git", "-C", root, "status", "--porcelain", "--", ".").Output() //nolint:gosec // fixed git arguments
+	src.Dirty = err == nil && len(strings.TrimSpace(string(status))) > 0
+	return src
+}
+
+// hashTree returns the SHA-256 of every regular file under dir except the
+// manifest itself, keyed by slash-separated relative path.
+func hashTree(dir string) (map[string]string, error) {
+	sums := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == ManifestFile {
+			return nil
+		}
+		sum, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		sums[rel] = sum
+		return nil
+	})
+	return sums, err
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path) //nolint:gosec // paths from the export root or output
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
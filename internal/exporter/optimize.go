@@ -0,0 +1,294 @@
+package exporter
+
+import (
+	"bytes"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+// criticalCSS extracts, per page, the subset of the site stylesheet whose
+// selectors only reference classes and ids present in that page's markup.
+// The result is inlined in <head> so first paint does not wait on app.css.
+type criticalCSS struct {
+	nodes []cssNode
+}
+
+// cssNode is a top-level or nested CSS construct. Style rules keep their
+// declaration body verbatim; grouping at-rules (@media, @supports, @layer,
+// @container) are parsed into children so they can be filtered too.
+type cssNode struct {
+	prelude  string
+	body     string
+	children []cssNode
+	grouping bool
+	stmt     bool // at-rule terminated by ';' rather than a block
+}
+
+var (
+	classAttr = regexp.MustCompile(`\bclass\s*=\s*"([^"]*)"`)
+	idAttr    = regexp.MustCompile(`\bid\s*=\s*"([^"]*)"`)
+)
+
+func newCriticalCSS(stylesheet []byte) *criticalCSS {
+	return &criticalCSS{nodes: parseCSS(string(stylesheet))}
+}
+
+// forPage returns the critical rules for the rendered page HTML.
+func (c *criticalCSS) forPage(page []byte) template.CSS {
+	if c == nil || len(c.nodes) == 0 {
+		return ""
+	}
+	used := make(map[string]bool)
+	for _, m := range classAttr.FindAllSubmatch(page, -1) {
+		for _, class := range strings.Fields(string(m[1])) {
+			used["."+class] = true
+		}
+	}
+	for _, m := range idAttr.FindAllSubmatch(page, -1) {
+		used["#"+strings.TrimSpace(string(m[1]))] = true
+	}
+
+	var buf strings.Builder
+	writeCSS(&buf, c.nodes, used)
+	return template.CSS(buf.String()) //nolint:gosec // subset of the bundled stylesheet
+}
+
+func writeCSS(buf *strings.Builder, nodes []cssNode, used map[string]bool) {
+	for _, n := range nodes {
+		switch {
+		case n.stmt:
+			// Keep layer ordering statements so cascade layers resolve the same way.
+			if strings.HasPrefix(n.prelude, "@layer") {
+				buf.WriteString(n.prelude)
+				buf.WriteByte(';')
+			}
+		case n.grouping:
+			var inner strings.Builder
+			writeCSS(&inner, n.children, used)
+			if inner.Len() > 0 {
+				buf.WriteString(n.prelude)
+				buf.WriteByte('{')
+				buf.WriteString(inner.String())
+				buf.WriteByte('}')
+			}
+		case strings.HasPrefix(n.prelude, "@property"):
+			// Registered custom properties carry initial values utilities rely on.
+			buf.WriteString(n.prelude)
+			buf.WriteByte('{')
+			buf.WriteString(n.body)
+			buf.WriteByte('}')
+		case strings.HasPrefix(n.prelude, "@"):
+			// @font-face, @keyframes and friends arrive with the full stylesheet.
+		case selectorUsed(n.prelude, used):
+			buf.WriteString(n.prelude)
+			buf.WriteByte('{')
+			buf.WriteString(n.body)
+			buf.WriteByte('}')
+		}
+	}
+}
+
+// selectorUsed reports whether any selector in a comma-separated list can
+// match the page: every class and id it requires must appear in used.
+func selectorUsed(list string, used map[string]bool) bool {
+	for _, sel := range splitTopLevel(list, ',') {
+		if requirementsMet(sel, used) {
+			return true
+		}
+	}
+	return false
+}
+
+func requirementsMet(sel string, used map[string]bool) bool {
+	depth := 0
+	for i := 0; i < len(sel); i++ {
+		switch ch := sel[i]; ch {
+		case '\\':
+			i++
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case '.', '#':
+			if depth > 0 {
+				continue // :not(.x), [href="#x"] and friends are not requirements
+			}
+			name, n := readIdent(sel[i+1:])
+			if name == "" {
+				continue
+			}
+			if !used[string(ch)+name] {
+				return false
+			}
+			i += n
+		}
+	}
+	return true
+}
+
+// readIdent reads a CSS identifier, resolving backslash escapes such as the
+// "\:" in Tailwind's "md\:flex". It returns the identifier and bytes consumed.
+func readIdent(s string) (string, int) {
+	var b strings.Builder
+	i := 0
+	for i < len(s) {
+		ch := s[i]
+		if ch == '\\' && i+1 < len(s) {
+			b.WriteByte(s[i+1])
+			i += 2
+			continue
+		}
+		if ch == '-' || ch == '_' || ch >= 0x80 ||
+			(ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') {
+			b.WriteByte(ch)
+			i++
+			continue
+		}
+		break
+	}
+	return b.String(), i
+}
+
+func parseCSS(src string) []cssNode {
+	src = stripComments(src)
+	var nodes []cssNode
+	for i := 0; i < len(src); {
+		end := scanTo(src, i, "{;")
+		prelude := strings.TrimSpace(src[i:end])
+		if end >= len(src) {
+			break
+		}
+		if src[end] == ';' {
+			if prelude != "" {
+				nodes = append(nodes, cssNode{prelude: prelude, stmt: true})
+			}
+			i = end + 1
+			continue
+		}
+		closeIdx := matchBrace(src, end)
+		if closeIdx < 0 {
+			break // unterminated block
+		}
+		inner := src[end+1 : closeIdx]
+		node := cssNode{prelude: prelude}
+		if isGroupingRule(prelude) {
+			node.grouping = true
+			node.children = parseCSS(inner)
+		} else {
+			node.body = strings.TrimSpace(inner)
+		}
+		nodes = append(nodes, node)
+		i = closeIdx + 1
+	}
+	return nodes
+}
+
+func isGroupingRule(prelude string) bool {
+	for _, prefix := range []string{"@media", "@supports", "@layer", "@container"} {
+		if strings.HasPrefix(prelude, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanTo returns the index of the first byte in stops outside strings and
+// parentheses, or len(src).
+func scanTo(src string, from int, stops string) int {
+	depth := 0
+	var quote byte
+	for i := from; i < len(src); i++ {
+		ch := src[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '\\':
+			i++
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case depth == 0 && strings.IndexByte(stops, ch) >= 0:
+			return i
+		}
+	}
+	return len(src)
+}
+
+// matchBrace returns the index of the '}' closing the '{' at open, or -1.
+func matchBrace(src string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(src); i++ {
+		ch := src[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '\\':
+			i++
+		case ch == '{':
+			depth++
+		case ch == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func stripComments(src string) string {
+	if !strings.Contains(src, "/*") {
+		return src
+	}
+	var buf bytes.Buffer
+	for {
+		start := strings.Index(src, "/*")
+		if start < 0 {
+			buf.WriteString(src)
+			break
+		}
+		buf.WriteString(src[:start])
+		end := strings.Index(src[start+2:], "*/")
+		if end < 0 {
+			break
+		}
+		src = src[start+2+end+2:]
+	}
+	return buf.String()
+}
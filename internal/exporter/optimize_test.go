@@ -0,0 +1,112 @@
+package exporter
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCriticalCSSForPage(t *testing.T) {
+	t.Parallel()
+
+	stylesheet := `@layer theme, base, utilities;
+/* comment { not a rule } */
+:root { --accent: #0ea5e9; }
+@font-face { font-family: Inter; src: url("inter.woff2"); }
+@layer utilities {
+  .flex { display: flex; }
+  .grid { display: grid; }
+  .md\:flex { display: flex; }
+  .unused-a, .text-sky-300 { color: var(--accent); }
+  @media (width >= 48rem) { .md\:w-80 { width: 20rem; } .hidden-everywhere { display: none; } }
+}
+a:not(.btn) { text-decoration: underline; }
+#page-region .prose { max-width: 65ch; }
+@keyframes spin { to { transform: rotate(360deg); } }`
+
+	page := []byte(`<html class="dark"><body><div class="flex md:flex md:w-80 text-sky-300" id="page-region"><a href="#x">x</a></div></body></html>`)
+	got := string(newCriticalCSS([]byte(stylesheet)).forPage(page))
+
+	for _, want := range []string{
+		"@layer theme, base, utilities;",
+		":root{--accent: #0ea5e9;}",
+		".flex{display: flex;}",
+		`.md\:flex{display: flex;}`,
+		".unused-a, .text-sky-300{",
+		`@media (width >= 48rem){.md\:w-80{width: 20rem;}}`,
+		"a:not(.btn){",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("critical css missing %q\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{".grid", "hidden-everywhere", "@font-face", "@keyframes", ".prose", "comment"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("critical css should not contain %q\n%s", unwanted, got)
+		}
+	}
+}
+
+func TestExportOptimize(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "index.md"), []byte("# Home\n\nPlain page.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "overview.md"), []byte("# Diagram\n\n```mermaid\ngraph TD; A-->B\n```\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	assets := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(assets, "css"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(assets, "css", "app.css"), []byte(".min-h-screen{min-height:100vh}.never-used{color:red}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	exp, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "dist")
+	if err := exp.Export(context.Background(), Options{
+		Root:        root,
+		OutputDir:   out,
+		AssetsDir:   assets,
+		CleanOutput: true,
+		Optimize:    true,
+	}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	index, err := os.ReadFile(filepath.Join(out, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(index)
+	if !strings.Contains(html, "<style>.min-h-screen{min-height:100vh}</style>") {
+		t.Errorf("expected inlined critical css, got head:\n%s", html[:strings.Index(html, "</head>")])
+	}
+	if strings.Contains(html, "never-used") {
+		t.Errorf("unused rule leaked into critical css")
+	}
+	if !strings.Contains(html, `rel="preload" as="style"`) {
+		t.Errorf("expected full stylesheet to be preloaded")
+	}
+	if strings.Contains(html, "mermaid.min.js") {
+		t.Errorf("page without diagrams should not load mermaid")
+	}
+
+	diagram, err := os.ReadFile(filepath.Join(out, "overview.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(diagram), `mermaid.min.js" defer>`) {
+		t.Errorf("expected deferred mermaid script on diagram page")
+	}
+}
@@ -0,0 +1,168 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+)
+
+// singleFileName is the only file written by a single-file export.
+const singleFileName = "index.html"
+
+var (
+	pageHrefPattern  = regexp.MustCompile(`href="/page/([^"#]*)(#[^"]*)?"`)
+	mediaSrcPattern  = regexp.MustCompile(`src="/media/([^"]*)"`)
+	closeScriptBytes = regexp.MustCompile(`(?i)</script`)
+)
+
+//nolint:govet // field order optimized for readability, not memory
+type singleFileViewData struct {
+	Site    siteViewData
+	Pages   []pageViewData
+	Styles  template.CSS
+	Mermaid template.JS
+}
+
+// exportSingleFile writes every document into one self-contained HTML file.
+// Pages are switched client-side via "#/<path>" fragments, and stylesheets,
+// scripts, and local images are inlined so the file works from an email
+// attachment or a file:// URL.
+func (e *Exporter) exportSingleFile(ctx context.Context, rootDir, outputDir string, assets *assetReader, site siteViewData, docs []*tree.Node, progress func(done, total int)) error {
+	data := singleFileViewData{Site: site}
+	media := make(map[string]string)
+	needsMermaid := false
+
+	for i, node := range docs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		absPath := filepath.Join(rootDir, filepath.FromSlash(node.RelativePath))
+		info, err := os.Stat(absPath)
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", node.RelativePath, err)
+		}
+		raw, err := os.ReadFile(absPath) //nolint:gosec // absPath constructed from validated root
+		if err != nil {
+			return fmt.Errorf("read %s: %w", node.RelativePath, err)
+		}
+		// Render with the wiki-relative path so relative links resolve to /page/ routes.
+		doc, err := e.renderer.Render(ctx, node.RelativePath, info.ModTime(), raw)
+		if err != nil {
+			return fmt.Errorf("render %s: %w", node.RelativePath, err)
+		}
+		if strings.Contains(doc.HTML, `class="mermaid"`) {
+			needsMermaid = true
+		}
+
+		html := expandListings(doc.HTML, site.Tree, node.RelativePath, func(rel string) string { return "/page/" + rel })
+		// A heading fragment stays on the route: #/page.md#heading.
+		html = pageHrefPattern.ReplaceAllString(html, `href="#/$1$2"`)
+		html = mediaSrcPattern.ReplaceAllStringFunc(html, func(match string) string {
+			rel := mediaSrcPattern.FindStringSubmatch(match)[1]
+			if uri, ok := media[rel]; ok {
+				return `src="` + uri + `"`
+			}
+			uri, err := dataURI(rootDir, rel)
+			if err != nil {
+				e.logger.Warn("inline image failed", slog.String("path", rel), slog.Any("err", err))
+				return match
+			}
+			media[rel] = uri
+			return `src="` + uri + `"`
+		})
+
+		data.Pages = append(data.Pages, pageViewData{
+			Path:        node.RelativePath,
+			Output:      singleFileName,
+			URL:         toHTMLRel(node.RelativePath),
+			Title:       firstNonEmpty(doc.Metadata.Title, node.Title, titleFromPath(node.RelativePath)),
+			HTML:        template.HTML(html), //nolint:gosec // HTML from trusted renderer
+			Metadata:    doc.Metadata,
+			Modified:    doc.Modified,
+			Breadcrumbs: breadcrumbsFor(site.Tree, node.RelativePath),
+		})
+		if progress != nil {
+			progress(i+1, len(docs))
+		}
+	}
+
+	var styles strings.Builder
+	for _, rel := range []string{"css/app.css", "vendor/chroma-github-dark.min.css"} {
+		css, err := assets.read(rel)
+		if errors.Is(err, ErrAssetsMissing) {
+			return err
+		}
+		if err != nil {
+			e.logger.Warn("inline stylesheet failed", slog.String("asset", rel), slog.Any("err", err))
+			continue
+		}
+		styles.Write(css)
+		styles.WriteByte('\n')
+	}
+	data.Styles = template.CSS(styles.String()) //nolint:gosec // bundled stylesheet
+
+	if needsMermaid {
+		js, err := assets.read("vendor/mermaid.min.js")
+		if errors.Is(err, ErrAssetsMissing) {
+			return err
+		}
+		if err != nil {
+			e.logger.Warn("inline mermaid failed; diagrams will show as source", slog.Any("err", err))
+		} else {
+			js = closeScriptBytes.ReplaceAll(js, []byte(`<\/script`))
+			data.Mermaid = template.JS(js) //nolint:gosec // bundled vendor script
+		}
+	}
+
+	buf := bytes.Buffer{}
+	if err := e.templates.render(&buf, "single-file", data); err != nil {
+		return fmt.Errorf("render single file: %w", err)
+	}
+	dest := filepath.Join(outputDir, singleFileName)
+	if err := os.WriteFile(dest, buf.Bytes(), 0o644); err != nil { //nolint:gosec // standard file permissions
+		return fmt.Errorf("write %s: %w", singleFileName, err)
+	}
+
+	e.logAssets(assets.report, assets.dir)
+	e.logger.Info("single-file export complete",
+		slog.Int("documents", len(docs)),
+		slog.Int("images", len(media)),
+		slog.String("output", dest))
+	return nil
+}
+
+// dataURI encodes a wiki-relative media file as a base64 data URI.
+func dataURI(rootDir, escaped string) (string, error) {
+	rel, err := url.PathUnescape(escaped)
+	if err != nil {
+		return "", err
+	}
+	rel = path.Clean("/" + rel)[1:]
+	if rel == "" {
+		return "", errors.New("empty media path")
+	}
+	data, err := os.ReadFile(filepath.Join(rootDir, filepath.FromSlash(rel))) //nolint:gosec // cleaned path under the wiki root
+	if err != nil {
+		return "", err
+	}
+	mimeType := mime.TypeByExtension(path.Ext(rel))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,93 @@
+package exporter
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/euforicio/wikimd/internal/banner"
+)
+
+func TestExportSingleFile(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "guides", "img"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"index.md":         "# Home\n\nRead the [guide](guides/start.md), then [its logo](/page/guides/start.md#logo).\n",
+		"guides/start.md":  "# Start\n\n![Logo](img/logo.png)\n",
+		"guides/img/x.txt": "not markdown",
+	}
+	for rel, body := range files {
+		if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(rel)), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	png := []byte("\x89PNG\r\n\x1a\n")
+	if err := os.WriteFile(filepath.Join(root, "guides", "img", "logo.png"), png, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	assets := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(assets, "css"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(assets, "css", "app.css"), []byte(".inline-me{color:red}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	exp, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	notice, err := banner.New("Archived copy", banner.SeverityInfo, true)
+	if err != nil {
+		t.Fatalf("banner: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "dist")
+	if err := exp.Export(context.Background(), Options{
+		Root:        root,
+		OutputDir:   out,
+		AssetsDir:   assets,
+		CleanOutput: true,
+		SingleFile:  true,
+		Banner:      notice,
+	}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	entries, err := os.ReadDir(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "index.html" {
+		t.Fatalf("expected only index.html in output, got %v", entries)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(out, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(raw)
+	for _, want := range []string{
+		`data-route="index.md"`,
+		`data-route="guides/start.md"`,
+		`href="#/guides/start.md"`,
+		`href="#/guides/start.md#logo"`,
+		`src="data:image/png;base64,`,
+		".inline-me{color:red}",
+		`<p>Archived copy</p>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("single file missing %q", want)
+		}
+	}
+	if strings.Contains(html, `src="/media/`) || strings.Contains(html, `<link rel="stylesheet"`) {
+		t.Errorf("single file still references external resources")
+	}
+}
@@ -17,6 +17,8 @@ import (
 	meta "github.com/yuin/goldmark-meta"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
+
+	"github.com/euforicio/wikimd/internal/renderer/embed"
 )
 
 // Format represents an export format.
@@ -77,16 +79,19 @@ func (e *Exporter) ExportPage(ctx context.Context, opts ExportPageOptions) error
 	if err != nil {
 		return err
 	}
+	// Render under the wiki-relative path, as the content service does, so
+	// relative links resolve and a shared renderer's cache is reused.
+	rel := filepath.ToSlash(filepath.Clean(opts.Path))
 
 	switch opts.Format {
 	case FormatHTML:
-		return e.exportHTML(ctx, absPath, info.ModTime(), raw, opts.Writer)
+		return e.exportHTML(ctx, rel, info.ModTime(), raw, opts.Writer)
 	case FormatMarkdown:
 		return e.exportMarkdown(raw, opts.Writer)
 	case FormatPlainText:
-		return e.exportPlainText(ctx, absPath, info.ModTime(), raw, opts.Writer)
+		return e.exportPlainText(ctx, rel, info.ModTime(), raw, opts.Writer)
 	case FormatPDF:
-		return e.exportPDF(ctx, absPath, info.ModTime(), raw, opts.Writer)
+		return e.exportPDF(ctx, rel, info.ModTime(), raw, opts.Writer)
 	default:
 		return fmt.Errorf("unsupported format: %s", opts.Format)
 	}
@@ -284,8 +289,9 @@ func (e *Exporter) exportPDF(_ context.Context, _ string, _ time.Time, raw []byt
 		goldmark.WithRenderer(pdfRenderer),
 	)
 
-	// Parse and render markdown to PDF
-	if err := md.Convert(raw, w); err != nil {
+	// Parse and render markdown to PDF; embedded videos and gists cannot
+	// play there, so they become thumbnails and links.
+	if err := md.Convert(embed.Fallback(raw), w); err != nil {
 		return fmt.Errorf("convert markdown to PDF: %w", err)
 	}
 
@@ -369,6 +375,8 @@ func ContentType(format Format) string {
 		return "text/plain; charset=utf-8"
 	case FormatPDF:
 		return "application/pdf"
+	case FormatEPUB:
+		return epubMimetype
 	default:
 		return "application/octet-stream"
 	}
@@ -385,6 +393,8 @@ func FileExtension(format Format) string {
 		return ".txt"
 	case FormatPDF:
 		return ".pdf"
+	case FormatEPUB:
+		return ".epub"
 	default:
 		return ""
 	}
@@ -341,6 +341,7 @@ func TestContentType(t *testing.T) {
 	f(FormatMarkdown, "text/markdown; charset=utf-8")
 	f(FormatPlainText, "text/plain; charset=utf-8")
 	f(FormatPDF, "application/pdf")
+	f(FormatEPUB, "application/epub+zip")
 	f("invalid", "application/octet-stream")
 }
 
@@ -358,6 +359,7 @@ func TestFileExtension(t *testing.T) {
 	f(FormatMarkdown, ".md")
 	f(FormatPlainText, ".txt")
 	f(FormatPDF, ".pdf")
+	f(FormatEPUB, ".epub")
 	f("invalid", "")
 }
 
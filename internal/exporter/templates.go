@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/euforicio/wikimd/internal/buildinfo"
 	"github.com/euforicio/wikimd/internal/renderer"
 )
 
@@ -46,7 +47,15 @@ func newTemplateRenderer() (*templateRenderer, error) {
 		"hasMetadata": func(meta renderer.Metadata) bool {
 			return !meta.IsZero()
 		},
-		"pageURL": toHTMLRel,
+		"pageURL":        toHTMLRel,
+		"mermaidVersion": func() string { return buildinfo.MermaidVersion },
+		"integrity": func(assets assetRefs, ref string) template.HTMLAttr {
+			sri := assets.Integrity[ref]
+			if sri == "" {
+				return ""
+			}
+			return template.HTMLAttr(` integrity="` + sri + `"`) //nolint:gosec // base64 digest from static.SRI
+		},
 	}
 
 	base, err := template.New("layout").Funcs(funcs).ParseFS(templateFS, "templates/*.gohtml")
@@ -0,0 +1,201 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/content"
+	"github.com/euforicio/wikimd/internal/content/tree"
+)
+
+// watchSettle is how long Watch waits for a burst of change events (editors
+// often write a file several times per save) before regenerating.
+const watchSettle = 150 * time.Millisecond
+
+// Watch runs a full export and then keeps the output current as events
+// arrive, until ctx is canceled or events is closed. Edits that leave the
+// navigation unchanged rewrite only the affected pages; anything else
+// (new, deleted, renamed, or retitled documents) triggers a full export.
+// Failed regenerations are logged and the previous output is left in place.
+func (e *Exporter) Watch(ctx context.Context, opts Options, events <-chan content.Event) error {
+	st, err := e.export(ctx, opts, nil)
+	if err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(watchSettle)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	defer timer.Stop()
+
+	pending := make(map[string]bool)
+	full := false
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case evt, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if evt.Type == content.EventPageUpdated && evt.Path != "" {
+				pending[evt.Path] = true
+			} else {
+				full = true
+			}
+			timer.Reset(watchSettle)
+		case <-timer.C:
+			started := time.Now()
+			next, written, err := e.applyChanges(ctx, st, pending, full)
+			if err != nil {
+				e.logger.Error("regenerate export failed", slog.Any("err", err))
+			} else {
+				e.logger.Info("export updated",
+					slog.Int("pages", written),
+					slog.Bool("full", next != st),
+					slog.Duration("duration", time.Since(started)))
+				st = next
+			}
+			pending = make(map[string]bool)
+			full = false
+		}
+	}
+}
+
+// applyChanges regenerates the output for the changed pages, falling back to
+// a full export when the navigation changed. It returns the state to use for
+// the next round and the number of documents written.
+func (e *Exporter) applyChanges(ctx context.Context, st *exportState, pages map[string]bool, full bool) (*exportState, int, error) {
+	if !full && !st.opts.SingleFile {
+		written, patched, err := e.refreshPages(ctx, st, pages)
+		if err != nil {
+			return nil, 0, err
+		}
+		if patched {
+			return st, written, nil
+		}
+	}
+	// Later rounds must not wipe the output directory out from under a
+	// preview, so pages of deleted or renamed documents are removed one by one.
+	opts := st.opts
+	opts.CleanOutput = false
+	next, err := e.export(ctx, opts, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := removeStaleOutputs(st, next); err != nil {
+		return nil, 0, err
+	}
+	// The manifest was written before the stale pages went away.
+	if !next.opts.SingleFile {
+		if err := e.writeManifest(ctx, next, next.site.GeneratedAt); err != nil {
+			return nil, 0, err
+		}
+	}
+	return next, len(next.titles), nil
+}
+
+// removeStaleOutputs deletes the pages prev wrote for documents that next no
+// longer exports, along with any directories that leaves empty.
+func removeStaleOutputs(prev, next *exportState) error {
+	current := map[string]bool{indexHTML: true}
+	for _, rel := range next.order {
+		current[toHTMLRel(rel)] = true
+	}
+	for _, rel := range prev.order {
+		out := toHTMLRel(rel)
+		if current[out] {
+			continue
+		}
+		if err := removeOutput(prev.outputDir, out); err != nil {
+			return err
+		}
+		if prev.opts.LLMsText {
+			if err := removeOutput(prev.outputDir, toMarkdownRel(rel)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// removeOutput deletes one output file and the directories that leaves empty.
+func removeOutput(outputDir, rel string) error {
+	abs := filepath.Join(outputDir, filepath.FromSlash(rel))
+	if err := os.Remove(abs); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove stale page %s: %w", rel, err)
+	}
+	// Remove fails on the first non-empty directory, which ends the walk.
+	for dir := filepath.Dir(abs); dir != outputDir && strings.HasPrefix(dir, outputDir); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// refreshPages rewrites only the given pages and returns how many it wrote.
+// It reports false, writing nothing, when the document set or any navigation
+// title differs from the last full export, since every page's sidebar would
+// then be stale.
+func (e *Exporter) refreshPages(ctx context.Context, st *exportState, pages map[string]bool) (int, bool, error) {
+	treeRoot, err := tree.Build(ctx, st.rootDir, st.opts.treeOptions(e.renderer))
+	if err != nil {
+		return 0, false, fmt.Errorf("build content tree: %w", err)
+	}
+	docs := collectDocuments(treeRoot)
+	titles := navigationTitles(docs)
+	if len(titles) != len(st.titles) {
+		return 0, false, nil
+	}
+	for rel, title := range titles {
+		if prev, ok := st.titles[rel]; !ok || prev != title {
+			return 0, false, nil
+		}
+	}
+
+	// GeneratedAt stays as the last full export set it: the pages left
+	// alone show that time, so the rewritten ones must too.
+	st.site.Tree = treeRoot
+	written := 0
+	for _, node := range docs {
+		if !pages[node.RelativePath] {
+			continue
+		}
+		layout, err := e.writeDocument(ctx, st, node)
+		if err != nil {
+			return 0, false, err
+		}
+		written++
+		if node.RelativePath == st.defaultPath && toHTMLRel(node.RelativePath) != indexHTML {
+			if err := e.writeCustomPage(st.outputDir, indexHTML, layout); err != nil {
+				return 0, false, fmt.Errorf("write landing page: %w", err)
+			}
+		}
+	}
+
+	if st.opts.GenerateSearchIndex {
+		if err := writeSearchIndex(st.outputDir, st.site.GeneratedAt, st.searchEntries()); err != nil {
+			return 0, false, err
+		}
+	}
+	if st.opts.LLMsText {
+		if err := writeLLMsText(st); err != nil {
+			return 0, false, err
+		}
+	}
+	if err := e.writeBooks(ctx, st); err != nil {
+		return 0, false, err
+	}
+	if err := e.writeManifest(ctx, st, st.site.GeneratedAt); err != nil {
+		return 0, false, err
+	}
+	return written, true, nil
+}
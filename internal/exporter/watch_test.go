@@ -0,0 +1,173 @@
+package exporter
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/content"
+)
+
+func TestWatchRegeneratesChangedPages(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	write := func(rel, body string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(root, rel), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.md", "# Alpha\n\nfirst draft\n")
+	write("b.md", "# Beta\n\nuntouched\n")
+
+	exp, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "dist")
+	events := make(chan content.Event)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- exp.Watch(ctx, Options{Root: root, OutputDir: out, CleanOutput: true}, events)
+	}()
+
+	waitFor := func(rel, want string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if raw, err := os.ReadFile(filepath.Join(out, rel)); err == nil && strings.Contains(string(raw), want) {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("timed out waiting for %q in %s", want, rel)
+	}
+	waitFor("b.html", "untouched")
+
+	before, err := os.Stat(filepath.Join(out, "b.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Same title, new body: only a.html should be rewritten.
+	write("a.md", "# Alpha\n\nsecond draft\n")
+	future := time.Now().Add(2 * time.Second)
+	if err := os.Chtimes(filepath.Join(root, "a.md"), future, future); err != nil {
+		t.Fatal(err)
+	}
+	events <- content.Event{Type: content.EventPageUpdated, Path: "a.md"}
+	waitFor("a.html", "second draft")
+
+	after, err := os.Stat(filepath.Join(out, "b.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !after.ModTime().Equal(before.ModTime()) {
+		t.Errorf("expected b.html to be left alone on a single-page edit")
+	}
+	if a, b := generatedAt(t, filepath.Join(out, "a.html")), generatedAt(t, filepath.Join(out, "b.html")); a != b {
+		t.Errorf("expected pages to agree on when the site was generated, got %q and %q", a, b)
+	}
+
+	// A new document changes the navigation, so the whole site is rebuilt.
+	write("c.md", "# Gamma\n\nbrand new\n")
+	events <- content.Event{Type: content.EventTreeUpdated, Path: "c.md"}
+	waitFor("c.html", "brand new")
+	waitFor("b.html", `data-tree-path="c.md"`)
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Watch returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch did not stop after cancel")
+	}
+}
+
+var generatedAtMeta = regexp.MustCompile(`<meta name="generated-at" content="([^"]*)">`)
+
+func generatedAt(t *testing.T, path string) string {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := generatedAtMeta.FindSubmatch(raw)
+	if m == nil {
+		t.Fatalf("no generated-at meta in %s", path)
+	}
+	return string(m[1])
+}
+
+func TestWatchRemovesDeletedPages(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for rel, body := range map[string]string{
+		"a.md":        "# Alpha\n",
+		"guides/b.md": "# Beta\n",
+	} {
+		abs := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(abs), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exp, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "dist")
+	events := make(chan content.Event)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = exp.Watch(ctx, Options{Root: root, OutputDir: out, CleanOutput: true}, events)
+	}()
+
+	stale := filepath.Join(out, "guides", "b.html")
+	waitUntil := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if cond() {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("timed out waiting for %s", what)
+	}
+	waitUntil("initial export", func() bool {
+		_, err := os.Stat(stale)
+		return err == nil
+	})
+
+	if err := os.Remove(filepath.Join(root, "guides", "b.md")); err != nil {
+		t.Fatal(err)
+	}
+	events <- content.Event{Type: content.EventTreeUpdated, Path: "guides/b.md"}
+	waitUntil("deleted page to be removed", func() bool {
+		_, err := os.Stat(stale)
+		return os.IsNotExist(err)
+	})
+
+	if _, err := os.Stat(filepath.Join(out, "guides")); !os.IsNotExist(err) {
+		t.Errorf("expected the emptied guides directory to be removed, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, "a.html")); err != nil {
+		t.Errorf("expected a.html to survive: %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package frontmatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Frontmatter formats reported by Format.
+const (
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+	FormatJSON = "json"
+)
+
+// ErrNotYAML reports an edit to TOML or JSON frontmatter. Set, Delete, and
+// Patch only rewrite YAML, so those documents are left alone.
+var ErrNotYAML = errors.New("only YAML frontmatter can be edited")
+
+// Format reports the format of doc's frontmatter, or "" when it has none.
+// YAML sits between "---" lines, TOML between "+++" lines, and JSON between
+// ";;;" lines or, as in Hugo, as an object opening the document.
+func Format(doc []byte) string {
+	switch {
+	case hasDelimiter(doc, "---"):
+		return FormatYAML
+	case hasDelimiter(doc, "+++"):
+		return FormatTOML
+	case hasDelimiter(doc, ";;;"):
+		return FormatJSON
+	}
+	if _, ok := jsonObject(doc); ok {
+		return FormatJSON
+	}
+	return ""
+}
+
+// Decode reads TOML or JSON frontmatter. body is doc with the block, its
+// delimiters included, blanked out with spaces, so offsets and lines in body
+// match doc. ok is false when doc has no TOML or JSON frontmatter; YAML is
+// left to the markdown parser.
+func Decode(doc []byte) (values map[string]any, body []byte, ok bool, err error) {
+	format, start, end, next, err := locate(doc)
+	if err != nil || format == "" {
+		return nil, doc, false, err
+	}
+	if format == FormatTOML {
+		values, err = decodeTOML(string(doc[start:end]))
+	} else if err = json.Unmarshal(doc[start:end], &values); err != nil {
+		err = fmt.Errorf("json frontmatter: %w", err)
+	}
+	if err != nil {
+		return nil, doc, false, err
+	}
+	return values, blankPrefix(doc, next), true, nil
+}
+
+// Strip returns doc without its TOML or JSON frontmatter block, and reports
+// whether there was one. YAML frontmatter is left in place.
+func Strip(doc []byte) ([]byte, bool) {
+	format, _, _, next, err := locate(doc)
+	if err != nil || format == "" {
+		return doc, false
+	}
+	return doc[next:], true
+}
+
+// locate finds TOML or JSON frontmatter. start and end bound the encoded
+// values; next is where the rest of the document begins. format is "" when
+// doc has neither.
+func locate(doc []byte) (format string, start, end, next int, err error) {
+	switch format = Format(doc); {
+	case format == FormatTOML:
+		start, end, next, err = delimited(doc, "+++")
+	case format == FormatJSON && hasDelimiter(doc, ";;;"):
+		start, end, next, err = delimited(doc, ";;;")
+	case format == FormatJSON:
+		end, _ = jsonObject(doc)
+		next = end
+		if i := bytes.IndexByte(doc[end:], '\n'); i >= 0 {
+			next += i + 1
+		} else {
+			next = len(doc)
+		}
+	default:
+		return "", 0, 0, 0, nil
+	}
+	return format, start, end, next, err
+}
+
+func hasDelimiter(doc []byte, delim string) bool {
+	return bytes.HasPrefix(doc, []byte(delim+"\n")) || bytes.HasPrefix(doc, []byte(delim+"\r\n"))
+}
+
+// delimited locates a block opened and closed by delim lines. start and end
+// bound its body; next is where the document continues.
+func delimited(doc []byte, delim string) (start, end, next int, err error) {
+	start = bytes.IndexByte(doc, '\n') + 1
+	for offset := start; offset < len(doc); {
+		line, _, found := bytes.Cut(doc[offset:], []byte("\n"))
+		if bytes.Equal(bytes.TrimSpace(line), []byte(delim)) {
+			next = offset + len(line)
+			if found {
+				next++
+			}
+			return start, offset, next, nil
+		}
+		if !found {
+			break
+		}
+		offset += len(line) + 1
+	}
+	return 0, 0, 0, ErrUnclosed
+}
+
+// jsonObject reports whether doc opens with a JSON object on lines of its
+// own, and where the object ends. "{{", which starts a directive such as
+// {{children}}, never does.
+func jsonObject(doc []byte) (end int, ok bool) {
+	if len(doc) < 2 || doc[0] != '{' || doc[1] == '{' {
+		return 0, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	var raw json.RawMessage
+	if err := dec.Decode(&raw); err != nil {
+		return 0, false
+	}
+	end = int(dec.InputOffset())
+	rest := bytes.TrimLeft(doc[end:], " \t\r")
+	if len(rest) > 0 && rest[0] != '\n' {
+		return 0, false
+	}
+	return end, true
+}
+
+// blankPrefix returns a copy of doc with everything but newlines in doc[:n]
+// replaced by spaces.
+func blankPrefix(doc []byte, n int) []byte {
+	out := bytes.Clone(doc)
+	for i := range n {
+		if out[i] != '\n' {
+			out[i] = ' '
+		}
+	}
+	return out
+}
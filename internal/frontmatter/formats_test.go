@@ -0,0 +1,133 @@
+package frontmatter
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeTOML(t *testing.T) {
+	t.Parallel()
+	doc := strings.Join([]string{
+		"+++",
+		`title = "Hugo \"page\""`,
+		"draft = false",
+		"weight = 1_000",
+		"ratio = 0.5",
+		"date = 2024-03-01T09:30:00Z",
+		"reviewBy = 2025-01-15",
+		"tags = [",
+		"  'go', # trailing comment",
+		`  "wiki",`,
+		"]",
+		`path = 'C:\docs'`,
+		`note = """`,
+		`two \`,
+		`   lines"""`,
+		"params.icon = '📘'",
+		"author = { name = 'Ada', id = 0x2A }",
+		"",
+		"[menu.main]",
+		"parent = 'Guides'",
+		"",
+		"[[resources]]",
+		"src = 'a.png'",
+		"[[resources]]",
+		"src = 'b.png'",
+		"+++",
+		"# Body",
+		"",
+	}, "\n")
+
+	values, body, ok, err := Decode([]byte(doc))
+	if err != nil || !ok {
+		t.Fatalf("Decode = %v, %v", ok, err)
+	}
+	want := map[string]any{
+		"title":    `Hugo "page"`,
+		"draft":    false,
+		"weight":   1000,
+		"ratio":    0.5,
+		"date":     time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		"reviewBy": time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC),
+		"tags":     []any{"go", "wiki"},
+		"path":     `C:\docs`,
+		"note":     "two lines",
+		"params":   map[string]any{"icon": "📘"},
+		"author":   map[string]any{"name": "Ada", "id": 42},
+		"menu":     map[string]any{"main": map[string]any{"parent": "Guides"}},
+		"resources": []any{
+			map[string]any{"src": "a.png"},
+			map[string]any{"src": "b.png"},
+		},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("values:\n got %#v\nwant %#v", values, want)
+	}
+	if len(body) != len(doc) || strings.Count(string(body), "\n") != strings.Count(doc, "\n") {
+		t.Errorf("body should keep the document's offsets and lines, got %q", body)
+	}
+	if !strings.HasSuffix(string(body), "\n# Body\n") || strings.TrimSpace(string(body)) != "# Body" {
+		t.Errorf("expected the block blanked out, got %q", body)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"delimited": ";;;\n{\"title\": \"Doc\", \"tags\": [\"a\"]}\n;;;\nbody\n",
+		"hugo":      "{\n  \"title\": \"Doc\",\n  \"tags\": [\"a\"]\n}\nbody\n",
+	}
+	for name, doc := range cases {
+		values, body, ok, err := Decode([]byte(doc))
+		if err != nil || !ok {
+			t.Fatalf("%s: Decode = %v, %v", name, ok, err)
+		}
+		if values["title"] != "Doc" || !reflect.DeepEqual(values["tags"], []any{"a"}) {
+			t.Errorf("%s: unexpected values %v", name, values)
+		}
+		if strings.TrimSpace(string(body)) != "body" {
+			t.Errorf("%s: expected the block blanked out, got %q", name, body)
+		}
+	}
+}
+
+func TestDecodeLeavesOtherDocumentsAlone(t *testing.T) {
+	t.Parallel()
+	for _, doc := range []string{
+		"---\ntitle: yaml\n---\n",
+		"{{children}}\n",
+		"{not json}\n",
+		"{\"a\": 1} trailing text\n",
+		"# Plain\n",
+	} {
+		if _, body, ok, err := Decode([]byte(doc)); ok || err != nil || string(body) != doc {
+			t.Errorf("Decode(%q) = %q, %v, %v; want the document unchanged", doc, body, ok, err)
+		}
+	}
+}
+
+func TestDecodeReportsBadFrontmatter(t *testing.T) {
+	t.Parallel()
+	if _, _, _, err := Decode([]byte("+++\ntitle = 'x'\n")); !errors.Is(err, ErrUnclosed) {
+		t.Errorf("expected ErrUnclosed, got %v", err)
+	}
+	for _, doc := range []string{"+++\ntitle = \n+++\n", "+++\na = 1\na = 2\n+++\n", "+++\nn = 007\n+++\n", ";;;\n{bad\n;;;\n"} {
+		if _, _, _, err := Decode([]byte(doc)); err == nil {
+			t.Errorf("Decode(%q) accepted invalid frontmatter", doc)
+		}
+	}
+}
+
+func TestEditsLeaveTOMLAlone(t *testing.T) {
+	t.Parallel()
+	doc := []byte("+++\ntitle = 'x'\n+++\nbody\n")
+	if _, err := Set(doc, "status", "done"); !errors.Is(err, ErrNotYAML) {
+		t.Errorf("Set: expected ErrNotYAML, got %v", err)
+	}
+	if _, err := Delete(doc, "title"); !errors.Is(err, ErrNotYAML) {
+		t.Errorf("Delete: expected ErrNotYAML, got %v", err)
+	}
+}
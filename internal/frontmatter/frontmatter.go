@@ -0,0 +1,136 @@
+// Package frontmatter edits the YAML frontmatter of markdown documents in
+// place, leaving the rest of the block, comments and key order included, as
+// the author wrote it.
+package frontmatter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ErrUnclosed reports a frontmatter block without its closing delimiter.
+var ErrUnclosed = errors.New("frontmatter block is not closed")
+
+// block locates the YAML frontmatter of doc. start and end bound its body,
+// between the delimiter lines; ok is false when doc has no frontmatter.
+func block(doc []byte) (start, end int, ok bool, err error) {
+	if f := Format(doc); f == FormatTOML || f == FormatJSON {
+		return 0, 0, false, fmt.Errorf("%w: the page uses %s", ErrNotYAML, strings.ToUpper(f))
+	}
+	var open int
+	switch {
+	case bytes.HasPrefix(doc, []byte("---\n")):
+		open = len("---\n")
+	case bytes.HasPrefix(doc, []byte("---\r\n")):
+		open = len("---\r\n")
+	default:
+		return 0, 0, false, nil
+	}
+	for offset := open; offset <= len(doc); {
+		line, _, found := bytes.Cut(doc[offset:], []byte("\n"))
+		if t := bytes.TrimSpace(line); bytes.Equal(t, []byte("---")) || bytes.Equal(t, []byte("...")) {
+			return open, offset, true, nil
+		}
+		if !found {
+			break
+		}
+		offset += len(line) + 1
+	}
+	return 0, 0, false, ErrUnclosed
+}
+
+// Set gives key the scalar value in doc's frontmatter. An existing entry is
+// replaced where it stands, including any nested or multi-line value; a new
+// one is appended to the block, which is created when doc has none.
+func Set(doc []byte, key string, value any) ([]byte, error) {
+	entry, err := yaml.Marshal(map[string]any{key: value})
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %w", key, err)
+	}
+	start, end, ok, err := block(doc)
+	if err != nil {
+		return nil, err
+	}
+	newline := "\n"
+	if bytes.HasPrefix(doc, []byte("---\r\n")) {
+		newline = "\r\n"
+		entry = bytes.ReplaceAll(entry, []byte("\n"), []byte("\r\n"))
+	}
+	if !ok {
+		out := append([]byte("---"+newline), entry...)
+		out = append(out, "---"+newline...)
+		if len(doc) > 0 {
+			out = append(out, newline...)
+		}
+		return append(out, doc...), nil
+	}
+
+	from, to, found := findEntry(doc[start:end], key)
+	var out []byte
+	out = append(out, doc[:start]...)
+	if found {
+		out = append(out, doc[start:start+from]...)
+		out = append(out, entry...)
+		out = append(out, doc[start+to:]...)
+	} else {
+		out = append(out, doc[start:end]...)
+		out = append(out, entry...)
+		out = append(out, doc[end:]...)
+	}
+	return out, nil
+}
+
+// Delete removes key from doc's frontmatter. doc is returned unchanged when
+// the key is absent.
+func Delete(doc []byte, key string) ([]byte, error) {
+	start, end, ok, err := block(doc)
+	if err != nil || !ok {
+		return doc, err
+	}
+	from, to, found := findEntry(doc[start:end], key)
+	if !found {
+		return doc, nil
+	}
+	out := append([]byte{}, doc[:start+from]...)
+	return append(out, doc[start+to:]...), nil
+}
+
+// findEntry returns the byte range of key's top-level entry in body: its own
+// line and the lines that continue its value. Those are indented lines, and
+// "- item" lines, since YAML allows a block sequence at the key's own
+// indentation.
+func findEntry(body []byte, key string) (from, to int, found bool) {
+	offset := 0
+	for offset < len(body) {
+		line, _, _ := bytes.Cut(body[offset:], []byte("\n"))
+		next := min(offset+len(line)+1, len(body))
+		if !found && topLevelKey(line) == key {
+			from, to, found = offset, next, true
+		} else if found {
+			if len(line) > 0 && line[0] != ' ' && line[0] != '\t' && line[0] != '\r' && line[0] != '-' {
+				return from, to, true
+			}
+			if len(bytes.TrimSpace(line)) > 0 {
+				to = next
+			}
+		}
+		offset = next
+	}
+	return from, to, found
+}
+
+// topLevelKey returns the key a "key: value" line at column zero defines.
+func topLevelKey(line []byte) string {
+	if len(line) == 0 || line[0] == ' ' || line[0] == '\t' || line[0] == '#' || line[0] == '-' {
+		return ""
+	}
+	key, _, ok := strings.Cut(string(line), ":")
+	if !ok {
+		return ""
+	}
+	return strings.Trim(strings.TrimSpace(key), `"'`)
+}
@@ -0,0 +1,82 @@
+package frontmatter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name, doc, want string
+		value           any
+	}{
+		{
+			name:  "replaces in place",
+			doc:   "---\ntitle: Plan # keep\nstatus: todo\nowner: ada\n---\n# Plan\n",
+			value: "done",
+			want:  "---\ntitle: Plan # keep\nstatus: done\nowner: ada\n---\n# Plan\n",
+		},
+		{
+			name:  "replaces a multi-line value",
+			doc:   "---\nstatus:\n- a\n- b\n\ntitle: x\n---\nbody\n",
+			value: "review",
+			want:  "---\nstatus: review\n\ntitle: x\n---\nbody\n",
+		},
+		{
+			name:  "appends a missing key",
+			doc:   "---\ntitle: Plan\n---\nbody\n",
+			value: "in progress",
+			want:  "---\ntitle: Plan\nstatus: in progress\n---\nbody\n",
+		},
+		{
+			name:  "creates the block",
+			doc:   "# Plan\n",
+			value: "todo",
+			want:  "---\nstatus: todo\n---\n\n# Plan\n",
+		},
+		{
+			name:  "quotes when YAML needs it",
+			doc:   "---\nstatus: todo\n---\n",
+			value: "yes: really",
+			want:  "---\nstatus: 'yes: really'\n---\n",
+		},
+		{
+			name:  "keeps CRLF endings",
+			doc:   "---\r\nstatus: todo\r\n---\r\nbody\r\n",
+			value: "done",
+			want:  "---\r\nstatus: done\r\n---\r\nbody\r\n",
+		},
+	}
+	for _, tc := range cases {
+		got, err := Set([]byte(tc.doc), "status", tc.value)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestSetRejectsUnclosedBlock(t *testing.T) {
+	t.Parallel()
+	if _, err := Set([]byte("---\ntitle: x\n"), "status", "done"); !errors.Is(err, ErrUnclosed) {
+		t.Fatalf("expected ErrUnclosed, got %v", err)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	t.Parallel()
+	got, err := Delete([]byte("---\ntitle: x\ntags:\n  - a\n  - b\nowner: ada\n---\n"), "tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "---\ntitle: x\nowner: ada\n---\n"; string(got) != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	doc := []byte("---\ntitle: x\n---\n")
+	if got, _ := Delete(doc, "missing"); string(got) != string(doc) {
+		t.Fatalf("expected an absent key to leave the document alone, got %q", got)
+	}
+}
@@ -0,0 +1,41 @@
+package frontmatter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/fuzztest"
+)
+
+func FuzzFrontmatter(f *testing.F) {
+	for _, doc := range fuzztest.Markdown(f, "../../testdata/wiki") {
+		f.Add(doc, "title", "Fuzzed")
+	}
+	f.Add([]byte("---\r\ntags: [a, b\r\n"), TagsKey, "x")
+	f.Add([]byte("---\n---\n"), "", "---")
+	f.Add([]byte("---\nkey: |\n  ---\n...\n"), "key", "line\n---\nline")
+	f.Add([]byte("+++\na.b = [1, {c = '''x'''}]\n[[d]]\ne = \"\\u00e9\"\n+++\n"), "title", "x")
+	f.Add([]byte(";;;\n{\"a\": [1]}\n;;;\n"), "title", "x")
+	f.Add([]byte("+++\na = []\na.b = 1\n+++\n"), "title", "x")
+	f.Add([]byte("+++\na = []\n[a.b]\n+++\n"), "title", "x")
+
+	f.Fuzz(func(t *testing.T, doc []byte, key, value string) {
+		fuzztest.Within(t, 5*time.Second, func() {
+			_, _ = Block(doc)
+			_, _, _, _ = Decode(doc)
+			if p := (Patch{Set: map[string]any{key: value}, AddTags: []string{value}}); p.Validate() == nil {
+				_, _, _ = p.Apply(doc)
+			}
+			_, _ = Delete(doc, key)
+
+			out, err := Set(doc, key, value)
+			if err != nil {
+				return
+			}
+			// A document Set accepted has a closed block.
+			if _, err := Block(out); err != nil {
+				t.Fatalf("Set produced a document without a readable block: %v\n%q", err, out)
+			}
+		})
+	})
+}
@@ -0,0 +1,161 @@
+package frontmatter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// TagsKey is the frontmatter key AddTags and RemoveTags edit.
+const TagsKey = "tags"
+
+// Patch is a set of frontmatter edits applied to one document.
+type Patch struct {
+	// Set gives keys new values.
+	Set map[string]any `json:"set,omitempty"`
+	// Unset removes keys.
+	Unset []string `json:"unset,omitempty"`
+	// AddTags appends tags the document does not have yet.
+	AddTags []string `json:"addTags,omitempty"`
+	// RemoveTags drops tags.
+	RemoveTags []string `json:"removeTags,omitempty"`
+}
+
+var validKey = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// Validate reports a patch that is empty, names an unusable key, or edits
+// tags both directly and through AddTags or RemoveTags.
+func (p Patch) Validate() error {
+	if len(p.Set) == 0 && len(p.Unset) == 0 && len(p.AddTags) == 0 && len(p.RemoveTags) == 0 {
+		return errors.New("patch is empty")
+	}
+	keys := slices.Clone(p.Unset)
+	for key := range p.Set {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		if !validKey.MatchString(key) {
+			return fmt.Errorf("invalid key %q", key)
+		}
+	}
+	if len(p.AddTags) > 0 || len(p.RemoveTags) > 0 {
+		if _, ok := p.Set[TagsKey]; ok || slices.Contains(p.Unset, TagsKey) {
+			return errors.New("tags cannot be set or unset alongside addTags or removeTags")
+		}
+	}
+	for _, tag := range append(slices.Clone(p.AddTags), p.RemoveTags...) {
+		if strings.TrimSpace(tag) == "" {
+			return errors.New("tags must not be empty")
+		}
+	}
+	return nil
+}
+
+// Apply returns doc with the patch applied and whether anything changed.
+// Keys are edited in place; see Set.
+func (p Patch) Apply(doc []byte) ([]byte, bool, error) {
+	out := doc
+	var err error
+	for _, key := range p.Unset {
+		if out, err = Delete(out, key); err != nil {
+			return nil, false, err
+		}
+	}
+	keys := make([]string, 0, len(p.Set))
+	for key := range p.Set {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys) // new keys are appended in a stable order
+	for _, key := range keys {
+		if current, ok, err := value(out, key); err != nil {
+			return nil, false, err
+		} else if ok && equalYAML(current, p.Set[key]) {
+			continue
+		}
+		if out, err = Set(out, key, p.Set[key]); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if len(p.AddTags) > 0 || len(p.RemoveTags) > 0 {
+		current, _, err := value(out, TagsKey)
+		if err != nil {
+			return nil, false, err
+		}
+		tags := tagList(current)
+		next := slices.DeleteFunc(slices.Clone(tags), func(t string) bool {
+			return slices.Contains(p.RemoveTags, t)
+		})
+		for _, tag := range p.AddTags {
+			if tag = strings.TrimSpace(tag); !slices.Contains(next, tag) {
+				next = append(next, tag)
+			}
+		}
+		if !slices.Equal(tags, next) {
+			if len(next) == 0 {
+				out, err = Delete(out, TagsKey)
+			} else {
+				out, err = Set(out, TagsKey, next)
+			}
+			if err != nil {
+				return nil, false, err
+			}
+		}
+	}
+	return out, string(out) != string(doc), nil
+}
+
+// Block returns the frontmatter of doc without its delimiters, or "".
+func Block(doc []byte) (string, error) {
+	start, end, ok, err := block(doc)
+	if err != nil || !ok {
+		return "", err
+	}
+	return string(doc[start:end]), nil
+}
+
+// value decodes key from doc's frontmatter.
+func value(doc []byte, key string) (any, bool, error) {
+	body, err := Block(doc)
+	if err != nil {
+		return nil, false, err
+	}
+	var meta map[string]any
+	if err := yaml.Unmarshal([]byte(body), &meta); err != nil {
+		return nil, false, fmt.Errorf("invalid YAML: %w", err)
+	}
+	v, ok := meta[key]
+	return v, ok, nil
+}
+
+// equalYAML compares a decoded frontmatter value with a patch value by their
+// YAML encodings, so 3 and "3" differ but equal lists match.
+func equalYAML(a, b any) bool {
+	ea, errA := yaml.Marshal(a)
+	eb, errB := yaml.Marshal(b)
+	return errA == nil && errB == nil && string(ea) == string(eb)
+}
+
+// tagList reads a tags value written as a list or a comma-separated string.
+func tagList(v any) []string {
+	var tags []string
+	switch val := v.(type) {
+	case []any:
+		for _, item := range val {
+			if s := strings.TrimSpace(fmt.Sprint(item)); s != "" {
+				tags = append(tags, s)
+			}
+		}
+	case string:
+		for _, part := range strings.Split(val, ",") {
+			if s := strings.TrimSpace(part); s != "" {
+				tags = append(tags, s)
+			}
+		}
+	}
+	return tags
+}
@@ -0,0 +1,59 @@
+package frontmatter
+
+import "testing"
+
+func TestPatchApply(t *testing.T) {
+	t.Parallel()
+	doc := "---\ntitle: Runbook\ntags: [ops, wip]\ndraft: true\n---\n# Runbook\n"
+	p := Patch{
+		Set:        map[string]any{"owner": "ada", "title": "Runbook"},
+		Unset:      []string{"draft"},
+		AddTags:    []string{"reviewed", "ops"},
+		RemoveTags: []string{"wip"},
+	}
+	got, changed, err := p.Apply([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "---\ntitle: Runbook\ntags:\n- ops\n- reviewed\nowner: ada\n---\n# Runbook\n"
+	if !changed || string(got) != want {
+		t.Fatalf("expected %q, got %q (changed=%v)", want, got, changed)
+	}
+
+	again, changed, err := p.Apply(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed || string(again) != string(got) {
+		t.Fatalf("expected a second apply to change nothing, got %q", again)
+	}
+}
+
+func TestPatchApplyCommaTags(t *testing.T) {
+	t.Parallel()
+	got, _, err := Patch{RemoveTags: []string{"a", "b"}}.Apply([]byte("---\ntags: a, b\n---\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "---\n---\n" {
+		t.Fatalf("expected the emptied tags key to be removed, got %q", got)
+	}
+}
+
+func TestPatchValidate(t *testing.T) {
+	t.Parallel()
+	cases := map[string]Patch{
+		"empty":        {},
+		"bad key":      {Set: map[string]any{"owner: x": "y"}},
+		"tag conflict": {Set: map[string]any{"tags": []string{"a"}}, AddTags: []string{"b"}},
+		"blank tag":    {AddTags: []string{" "}},
+	}
+	for name, p := range cases {
+		if err := p.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if err := (Patch{Set: map[string]any{"owner": "ada"}, AddTags: []string{"x"}}).Validate(); err != nil {
+		t.Fatalf("expected a valid patch, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package frontmatter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// decodeTOML parses TOML frontmatter. Tables decode to map[string]any,
+// arrays to []any, integers to int, and dates and times to time.Time, the
+// same shapes YAML and JSON frontmatter produce.
+func decodeTOML(src string) (map[string]any, error) {
+	var values map[string]any
+	if err := toml.Unmarshal([]byte(src), &values); err != nil {
+		return nil, fmt.Errorf("toml frontmatter: %w", err)
+	}
+	if values == nil {
+		values = map[string]any{}
+	}
+	return normalizeTOML(values).(map[string]any), nil
+}
+
+// normalizeTOML converts the decoder's int64s and local dates and times into
+// the shapes the rest of the frontmatter code expects. Values without an
+// offset are read as UTC rather than in the server's zone, so a page means
+// the same day everywhere.
+func normalizeTOML(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			v[k] = normalizeTOML(child)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = normalizeTOML(child)
+		}
+		return v
+	case int64:
+		return int(v)
+	case toml.LocalDateTime:
+		return v.AsTime(time.UTC)
+	case toml.LocalDate:
+		return v.AsTime(time.UTC)
+	case toml.LocalTime:
+		return time.Date(0, time.January, 1, v.Hour, v.Minute, v.Second, v.Nanosecond, time.UTC)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,17 @@
+package frontmatter
+
+import "testing"
+
+// An empty array has no last table to continue in; these inputs used to
+// panic instead of failing to parse.
+func TestDecodeTOMLRejectsKeysUnderEmptyArray(t *testing.T) {
+	t.Parallel()
+	for _, doc := range []string{
+		"+++\na = []\na.b = 1\n+++\n",
+		"+++\na = []\n[a.b]\n+++\n",
+	} {
+		if _, _, _, err := Decode([]byte(doc)); err == nil {
+			t.Errorf("Decode(%q) accepted invalid frontmatter", doc)
+		}
+	}
+}
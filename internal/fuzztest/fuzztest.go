@@ -0,0 +1,77 @@
+// Package fuzztest seeds fuzz targets from the sample wiki and guards them
+// against inputs that hang instead of failing.
+package fuzztest
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Markdown returns every markdown file under dir, for seeding a corpus with
+// f.Add. Tests pass the sample wiki as a path relative to their package,
+// such as ../../testdata/wiki.
+func Markdown(tb testing.TB, dir string) [][]byte {
+	tb.Helper()
+	var docs [][]byte
+	walk(tb, dir, func(path, _ string) {
+		if !strings.EqualFold(filepath.Ext(path), ".md") {
+			return
+		}
+		data, err := os.ReadFile(path) //nolint:gosec // test fixtures
+		if err != nil {
+			tb.Fatalf("read seed %s: %v", path, err)
+		}
+		docs = append(docs, data)
+	})
+	return docs
+}
+
+// Paths returns the slash-separated path of every file and directory under
+// dir, relative to it.
+func Paths(tb testing.TB, dir string) []string {
+	tb.Helper()
+	var paths []string
+	walk(tb, dir, func(_, rel string) {
+		paths = append(paths, rel)
+	})
+	return paths
+}
+
+func walk(tb testing.TB, dir string, visit func(path, rel string)) {
+	tb.Helper()
+	err := filepath.WalkDir(dir, func(path string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		visit(path, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		tb.Fatalf("walk seeds %s: %v", dir, err)
+	}
+}
+
+// Within fails tb when fn has not returned after limit, so an input that
+// makes the code under test loop forever is reported rather than stalling
+// the fuzzer.
+func Within(tb testing.TB, limit time.Duration, fn func()) {
+	tb.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+	case <-time.After(limit):
+		tb.Fatalf("still running after %s", limit)
+	}
+}
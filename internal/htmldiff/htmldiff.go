@@ -0,0 +1,308 @@
+// Package htmldiff compares two rendered HTML fragments and marks the
+// differences inline with <ins> and <del>, so a reader sees the changes in
+// the formatted page rather than in its markdown source.
+package htmldiff
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/euforicio/wikimd/internal/textdiff"
+)
+
+// Result is a marked-up comparison.
+type Result struct {
+	// HTML is the new fragment with deleted text in <del> and added text in
+	// <ins>. It keeps the markup of the new fragment, so it stays well formed.
+	HTML string
+	// Inserted and Deleted count the words added and removed.
+	Inserted int
+	Deleted  int
+}
+
+// Diff compares the fragments old and new. Top-level blocks are matched
+// first; blocks that changed are then compared word by word.
+func Diff(old, new string) Result {
+	var d differ
+	a, b := blocks(old), blocks(new)
+	ops := textdiff.Compare(a, b)
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == textdiff.Equal {
+			d.out.WriteString(a[ops[i].A])
+			i++
+			continue
+		}
+		// Gather the run of changed blocks and compare its words.
+		var delBlocks, insBlocks []string
+		for ; i < len(ops) && ops[i].Kind != textdiff.Equal; i++ {
+			if ops[i].Kind == textdiff.Delete {
+				delBlocks = append(delBlocks, a[ops[i].A])
+			} else {
+				insBlocks = append(insBlocks, b[ops[i].B])
+			}
+		}
+		d.blocks(delBlocks, insBlocks)
+	}
+	return Result{HTML: d.out.String(), Inserted: d.inserted, Deleted: d.deleted}
+}
+
+type differ struct {
+	out               strings.Builder
+	inserted, deleted int
+}
+
+// blocks writes a run of changed blocks. Blocks edited in place, where both
+// sides hold the same kinds of element in the same order, are compared word
+// by word; anything else is shown as removed blocks followed by added ones.
+func (d *differ) blocks(old, new []string) {
+	if len(old) == len(new) {
+		a := make([][]string, len(old))
+		b := make([][]string, len(new))
+		similar := true
+		for i := range old {
+			a[i], b[i] = tokens(old[i]), tokens(new[i])
+			similar = similar && leadingTag(a[i]) == leadingTag(b[i])
+		}
+		if similar {
+			for i := range a {
+				d.region(a[i], b[i])
+			}
+			return
+		}
+	}
+	d.wrap("del", tokens(strings.Join(old, "")), &d.deleted)
+	d.wrap("ins", tokens(strings.Join(new, "")), &d.inserted)
+}
+
+// region writes the word-level comparison of one edited block.
+func (d *differ) region(a, b []string) {
+	if len(a)*len(b) > textdiff.MaxCells {
+		d.wrap("del", a, &d.deleted)
+		d.wrap("ins", b, &d.inserted)
+		return
+	}
+	ops := textdiff.Compare(a, b)
+	for i := 0; i < len(ops); {
+		switch ops[i].Kind {
+		case textdiff.Equal:
+			d.out.WriteString(a[ops[i].A])
+			i++
+		case textdiff.Delete:
+			var run []string
+			for ; i < len(ops) && ops[i].Kind == textdiff.Delete; i++ {
+				run = append(run, a[ops[i].A])
+			}
+			// Deleted markup is dropped: the new fragment's tags alone
+			// keep the result balanced.
+			d.mark("del", run, false, &d.deleted)
+		default:
+			var run []string
+			for ; i < len(ops) && ops[i].Kind == textdiff.Insert; i++ {
+				run = append(run, b[ops[i].B])
+			}
+			d.mark("ins", run, true, &d.inserted)
+		}
+	}
+}
+
+// wrap marks a whole run of blocks, markup included.
+func (d *differ) wrap(tag string, toks []string, count *int) {
+	if len(toks) == 0 {
+		return
+	}
+	d.out.WriteString("<" + tag + ">")
+	for _, tok := range toks {
+		d.out.WriteString(tok)
+		if isWord(tok) {
+			*count++
+		}
+	}
+	d.out.WriteString("</" + tag + ">")
+}
+
+// mark wraps each stretch of text in run with tag. Markup between stretches
+// is written as is when keepTags is set and dropped otherwise.
+func (d *differ) mark(tag string, run []string, keepTags bool, count *int) {
+	open := false
+	for _, tok := range run {
+		if isTag(tok) {
+			if open {
+				d.out.WriteString("</" + tag + ">")
+				open = false
+			}
+			if keepTags {
+				d.out.WriteString(tok)
+			}
+			continue
+		}
+		if !open {
+			d.out.WriteString("<" + tag + ">")
+			open = true
+		}
+		d.out.WriteString(tok)
+		if isWord(tok) {
+			*count++
+		}
+	}
+	if open {
+		d.out.WriteString("</" + tag + ">")
+	}
+}
+
+// blocks splits a fragment into its top-level elements and text.
+func blocks(fragment string) []string {
+	var out []string
+	var cur strings.Builder
+	depth := 0
+	z := html.NewTokenizer(strings.NewReader(fragment))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		raw := string(z.Raw())
+		switch tt {
+		case html.StartTagToken:
+			if !isVoid(z) {
+				depth++
+			}
+		case html.EndTagToken:
+			depth--
+		case html.TextToken:
+			if depth == 0 && strings.TrimSpace(raw) == "" {
+				if cur.Len() == 0 && len(out) > 0 {
+					out[len(out)-1] += raw
+				} else {
+					cur.WriteString(raw)
+				}
+				continue
+			}
+		}
+		cur.WriteString(raw)
+		if depth <= 0 {
+			depth = 0
+			out = append(out, cur.String())
+			cur.Reset()
+		}
+	}
+	if cur.Len() > 0 {
+		out = append(out, cur.String())
+	}
+	return out
+}
+
+// tokens splits a fragment into tags, words, whitespace, and punctuation.
+// An inline <svg> diagram is kept whole so it is compared as one unit.
+func tokens(fragment string) []string {
+	var out []string
+	z := html.NewTokenizer(strings.NewReader(fragment))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return out
+		}
+		raw := string(z.Raw())
+		if tt == html.StartTagToken {
+			if name, _ := z.TagName(); atom.Lookup(name) == atom.Svg {
+				out = append(out, svgToken(z, raw))
+				continue
+			}
+		}
+		if tt != html.TextToken {
+			out = append(out, raw)
+			continue
+		}
+		out = append(out, words(raw)...)
+	}
+}
+
+func svgToken(z *html.Tokenizer, start string) string {
+	var b strings.Builder
+	b.WriteString(start)
+	for depth := 1; depth > 0; {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		b.Write(z.Raw())
+		name, _ := z.TagName()
+		if atom.Lookup(name) != atom.Svg {
+			continue
+		}
+		switch tt {
+		case html.StartTagToken:
+			depth++
+		case html.EndTagToken:
+			depth--
+		}
+	}
+	return b.String()
+}
+
+// words splits escaped text into runs of letters and digits, runs of
+// whitespace, and single other characters. Character references such as
+// &amp; stay whole.
+func words(text string) []string {
+	var out []string
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		n := size
+		switch {
+		case r == '&':
+			n = strings.IndexByte(text, ';') + 1
+			if n <= 0 || n > 12 {
+				n = 1
+			}
+		case unicode.IsSpace(r):
+			n = len(text) - len(strings.TrimLeftFunc(text, unicode.IsSpace))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			n = len(text) - len(strings.TrimLeftFunc(text, func(r rune) bool {
+				return unicode.IsLetter(r) || unicode.IsDigit(r)
+			}))
+		}
+		out = append(out, text[:n])
+		text = text[n:]
+	}
+	return out
+}
+
+// leadingTag returns the name of the first element in toks, or "" for text.
+func leadingTag(toks []string) string {
+	for _, tok := range toks {
+		if strings.TrimSpace(tok) == "" {
+			continue
+		}
+		if !isTag(tok) {
+			return ""
+		}
+		name, _, _ := strings.Cut(strings.TrimPrefix(tok, "<"), " ")
+		return strings.TrimRight(name, ">/")
+	}
+	return ""
+}
+
+func isTag(tok string) bool {
+	return strings.HasPrefix(tok, "<") && !strings.HasPrefix(tok, "<svg")
+}
+
+// isWord reports whether tok counts as a changed word: text with a letter or
+// digit, or a diagram.
+func isWord(tok string) bool {
+	return !isTag(tok) && strings.IndexFunc(tok, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}) >= 0
+}
+
+func isVoid(z *html.Tokenizer) bool {
+	name, _ := z.TagName()
+	switch atom.Lookup(name) {
+	case atom.Area, atom.Br, atom.Col, atom.Embed, atom.Hr, atom.Img, atom.Input,
+		atom.Link, atom.Meta, atom.Source, atom.Track, atom.Wbr:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package htmldiff
+
+import "testing"
+
+func TestDiffMarksChangedWords(t *testing.T) {
+	t.Parallel()
+	old := "<h1>Leave</h1>\n<p>Staff get 20 days of leave.</p>\n<p>Ask your manager.</p>\n"
+	new := "<h1>Leave</h1>\n<p>Staff get 25 days of paid leave.</p>\n<p>Ask your manager.</p>\n"
+
+	got := Diff(old, new)
+	want := "<h1>Leave</h1>\n<p>Staff get <del>20</del><ins>25</ins> days of<ins> paid</ins> leave.</p>\n<p>Ask your manager.</p>\n"
+	if got.HTML != want {
+		t.Fatalf("expected\n%q\ngot\n%q", want, got.HTML)
+	}
+	if got.Inserted != 2 || got.Deleted != 1 {
+		t.Fatalf("expected 2 inserted and 1 deleted word, got %d and %d", got.Inserted, got.Deleted)
+	}
+}
+
+func TestDiffKeepsNewMarkup(t *testing.T) {
+	t.Parallel()
+	got := Diff("<p>Run <em>setup</em> first.</p>", "<p>Run <strong>setup</strong> now.</p>")
+	want := "<p>Run <strong>setup</strong> <del>first</del><ins>now</ins>.</p>"
+	if got.HTML != want {
+		t.Fatalf("expected %q, got %q", want, got.HTML)
+	}
+}
+
+func TestDiffWholeBlocks(t *testing.T) {
+	t.Parallel()
+	got := Diff("<p>Keep.</p><p>Drop <b>me</b>.</p>", "<p>Keep.</p><ul><li>New item</li></ul>")
+	if got.HTML != "<p>Keep.</p><del><p>Drop <b>me</b>.</p></del><ins><ul><li>New item</li></ul></ins>" || got.Deleted != 2 || got.Inserted != 2 {
+		t.Fatalf("unexpected diff %q", got.HTML)
+	}
+
+	got = Diff("<p>Only.</p>", "<p>Only.</p><p>Added.</p>")
+	if got.HTML != "<p>Only.</p><ins><p>Added.</p></ins>" || got.Inserted != 1 {
+		t.Fatalf("expected an inserted block, got %q (%d words)", got.HTML, got.Inserted)
+	}
+}
+
+func TestDiffIdentical(t *testing.T) {
+	t.Parallel()
+	doc := "<p>Same &amp; unchanged.</p>\n<svg><g><text>x</text></g></svg>"
+	if got := Diff(doc, doc); got.HTML != doc || got.Inserted != 0 || got.Deleted != 0 {
+		t.Fatalf("expected no changes, got %+v", got)
+	}
+}
@@ -0,0 +1,166 @@
+// Package inventory lists every page with the metadata documentation leads
+// audit: ownership, freshness, size, and link health.
+package inventory
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+	"github.com/euforicio/wikimd/internal/frontmatter"
+	"github.com/euforicio/wikimd/internal/lint"
+)
+
+// Row describes one page.
+type Row struct {
+	Modified    time.Time `json:"modified"`
+	Path        string    `json:"path"`
+	Title       string    `json:"title"`
+	Owner       string    `json:"owner,omitempty"`
+	Tags        []string  `json:"tags"`
+	Words       int       `json:"words"`
+	BrokenLinks int       `json:"brokenLinks"`
+}
+
+// Build lists the pages of root in tree order. Word counts come from the
+// documents in site, and broken links are counted from the links-pass
+// findings in report.
+func Build(root *tree.Node, site *lint.Site, report lint.Report) []Row {
+	broken := make(map[string]int)
+	for _, f := range report.Findings {
+		broken[f.Path]++
+	}
+
+	rows := []Row{}
+	var walk func(*tree.Node)
+	walk = func(n *tree.Node) {
+		if n == nil {
+			return
+		}
+		if n.Type == tree.NodeTypeFile {
+			row := Row{
+				Modified:    n.Modified,
+				Path:        n.RelativePath,
+				Title:       n.Title,
+				Tags:        []string{},
+				BrokenLinks: broken[n.RelativePath],
+			}
+			if n.Metadata != nil {
+				row.Owner = owner(n.Metadata.Raw)
+				if n.Metadata.Tags != nil {
+					row.Tags = n.Metadata.Tags
+				}
+			}
+			if doc := site.Document(n.RelativePath); doc != nil {
+				row.Words = CountWords(doc.Source)
+			}
+			rows = append(rows, row)
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+	return rows
+}
+
+// owner reads the owner frontmatter key, falling back to author. Lists are
+// joined with commas.
+func owner(raw map[string]any) string {
+	for _, key := range []string{"owner", "owners", "author"} {
+		switch v := raw[key].(type) {
+		case string:
+			if s := strings.TrimSpace(v); s != "" {
+				return s
+			}
+		case []any:
+			var names []string
+			for _, item := range v {
+				if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+					names = append(names, strings.TrimSpace(s))
+				}
+			}
+			if len(names) > 0 {
+				return strings.Join(names, ", ")
+			}
+		}
+	}
+	return ""
+}
+
+// CountWords counts the words of a markdown document, skipping frontmatter,
+// fenced code, and tokens such as list markers that hold no letters or
+// digits.
+func CountWords(source []byte) int {
+	body, _ := frontmatter.Strip(source)
+	if rest, ok := bytes.CutPrefix(body, []byte("---\n")); ok {
+		if _, after, found := bytes.Cut(rest, []byte("\n---\n")); found {
+			body = after
+		}
+	}
+
+	count := 0
+	inFence := false
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		trimmed := bytes.TrimSpace(line)
+		if bytes.HasPrefix(trimmed, []byte("```")) || bytes.HasPrefix(trimmed, []byte("~~~")) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		for _, field := range bytes.Fields(line) {
+			if bytes.IndexFunc(field, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// csvHeader names the columns WriteCSV emits.
+var csvHeader = []string{"path", "title", "tags", "owner", "modified", "words", "broken_links"}
+
+// WriteCSV writes rows as CSV with a header line. Tags are joined with
+// semicolons so each page stays on one spreadsheet row.
+func WriteCSV(w io.Writer, rows []Row) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		modified := ""
+		if !row.Modified.IsZero() {
+			modified = row.Modified.UTC().Format(time.RFC3339)
+		}
+		record := []string{
+			sanitize(row.Path),
+			sanitize(row.Title),
+			sanitize(strings.Join(row.Tags, "; ")),
+			sanitize(row.Owner),
+			modified,
+			strconv.Itoa(row.Words),
+			strconv.Itoa(row.BrokenLinks),
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("write %s: %w", row.Path, err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// sanitize keeps spreadsheet apps from evaluating a cell as a formula.
+func sanitize(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,77 @@
+package inventory
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+	"github.com/euforicio/wikimd/internal/lint"
+	"github.com/euforicio/wikimd/internal/renderer"
+)
+
+func TestCountWords(t *testing.T) {
+	t.Parallel()
+	src := "---\ntitle: Skip me\n---\n# Install guide\n\n- Run the tool, twice.\n\n```sh\nnot counted here\n```\n| a | b |\n"
+	if got := CountWords([]byte(src)); got != 8 {
+		t.Fatalf("expected 8 words, got %d", got)
+	}
+}
+
+func TestBuildAndWriteCSV(t *testing.T) {
+	t.Parallel()
+	guide, err := lint.Parse("guide.md", []byte("# Guide\n\nSee [setup](setup.md) and [gone](missing.md).\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setup, err := lint.Parse("setup.md", []byte("# Setup\n\nTwo words.\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	site := lint.NewSite(guide, setup)
+	report, err := lint.NewEngine(lint.Config{}, lint.LinkRules()...).Lint(context.Background(), site)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modified := time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)
+	root := &tree.Node{
+		Type: tree.NodeTypeDirectory,
+		Children: []*tree.Node{
+			{
+				Type:         tree.NodeTypeFile,
+				RelativePath: "guide.md",
+				Title:        "=Guide",
+				Modified:     modified,
+				Metadata: &renderer.Metadata{
+					Tags: []string{"ops", "howto"},
+					Raw:  map[string]any{"owner": []any{"ada", "lin"}},
+				},
+			},
+			{Type: tree.NodeTypeFile, RelativePath: "setup.md", Title: "Setup"},
+		},
+	}
+
+	rows := Build(root, site, report)
+	if len(rows) != 2 {
+		t.Fatalf("expected two rows, got %+v", rows)
+	}
+	if rows[0].BrokenLinks != 1 || rows[0].Owner != "ada, lin" || rows[0].Words != 5 {
+		t.Fatalf("unexpected guide row %+v", rows[0])
+	}
+	if rows[1].BrokenLinks != 0 || rows[1].Words != 3 || rows[1].Tags == nil {
+		t.Fatalf("unexpected setup row %+v", rows[1])
+	}
+
+	var b strings.Builder
+	if err := WriteCSV(&b, rows); err != nil {
+		t.Fatal(err)
+	}
+	want := "path,title,tags,owner,modified,words,broken_links\n" +
+		"guide.md,'=Guide,ops; howto,\"ada, lin\",2025-03-04T05:06:07Z,5,1\n" +
+		"setup.md,Setup,,,,3,0\n"
+	if b.String() != want {
+		t.Fatalf("expected %q, got %q", want, b.String())
+	}
+}
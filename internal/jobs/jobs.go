@@ -0,0 +1,281 @@
+// Package jobs tracks long-running operations so clients can follow their
+// progress and cancel them.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Job states.
+const (
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+	StateCanceled  = "canceled"
+)
+
+// historyLimit is how many finished jobs are kept for listing.
+const historyLimit = 50
+
+// progressInterval throttles progress notifications; state changes are
+// always delivered.
+const progressInterval = 200 * time.Millisecond
+
+// ErrNotFound is returned for unknown job IDs.
+var ErrNotFound = errors.New("job not found")
+
+// Status is a point-in-time view of a job.
+type Status struct {
+	Started  time.Time  `json:"started"`
+	Finished *time.Time `json:"finished,omitempty"`
+	ID       string     `json:"id"`
+	Kind     string     `json:"kind"`
+	State    string     `json:"state"`
+	Message  string     `json:"message,omitempty"`
+	Error    string     `json:"error,omitempty"`
+	// Result is where the job's output can be fetched, once it succeeded.
+	Result string `json:"result,omitempty"`
+	// Done and Total count work units; Total is zero when unknown.
+	Done  int `json:"done"`
+	Total int `json:"total,omitempty"`
+}
+
+// Func performs a job's work, reporting through p. It should return promptly
+// once ctx is canceled.
+type Func func(ctx context.Context, p *Progress) error
+
+// Progress lets a running job report how far along it is.
+type Progress struct {
+	job *job
+}
+
+// Set records done of total units with an optional message.
+func (p *Progress) Set(done, total int, message string) {
+	p.job.update(func(s *Status) {
+		s.Done, s.Total = done, total
+		if message != "" {
+			s.Message = message
+		}
+	}, false)
+}
+
+// ID returns the ID of the job being reported on.
+func (p *Progress) ID() string {
+	return p.job.snapshot().ID
+}
+
+// SetResult records where the job's output can be fetched.
+func (p *Progress) SetResult(result string) {
+	p.job.update(func(s *Status) {
+		s.Result = result
+	}, false)
+}
+
+type job struct {
+	notified time.Time
+	manager  *Manager
+	cancel   context.CancelFunc
+	status   Status
+	mu       sync.Mutex
+}
+
+func (j *job) snapshot() Status {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status
+}
+
+// update applies fn and notifies subscribers, throttled unless force is set.
+func (j *job) update(fn func(*Status), force bool) {
+	j.mu.Lock()
+	fn(&j.status)
+	now := time.Now()
+	if !force && now.Sub(j.notified) < progressInterval {
+		j.mu.Unlock()
+		return
+	}
+	j.notified = now
+	status := j.status
+	j.mu.Unlock()
+	j.manager.publish(status)
+}
+
+// Manager runs jobs and remembers recent ones.
+type Manager struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	jobs        map[string]*job
+	subscribers map[uint64]chan Status
+	nextID      uint64
+	nextSub     uint64
+	mu          sync.Mutex
+}
+
+// New returns an empty Manager. Close cancels every job it started.
+func New() *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		ctx:         ctx,
+		cancel:      cancel,
+		jobs:        make(map[string]*job),
+		subscribers: make(map[uint64]chan Status),
+	}
+}
+
+// Close cancels all running jobs.
+func (m *Manager) Close() {
+	m.cancel()
+}
+
+// Start runs fn in the background as a job of the given kind. Jobs outlive
+// the request that started them; use Cancel to stop one.
+func (m *Manager) Start(kind string, fn Func) Status {
+	j, ctx := m.newJob(m.ctx, kind)
+	started := j.snapshot()
+	go func() {
+		j.finish(fn(ctx, &Progress{job: j}))
+	}()
+	return started
+}
+
+// Run is like Start but runs fn in the caller's goroutine and returns once it
+// finishes. Request-scoped work such as a streamed download uses it so its
+// outcome is recorded alongside background jobs. The job stops when ctx is
+// done, when the Manager is closed, or through Cancel.
+func (m *Manager) Run(ctx context.Context, kind string, fn Func) (Status, error) {
+	j, jobCtx := m.newJob(ctx, kind)
+	stop := context.AfterFunc(m.ctx, j.cancel)
+	defer stop()
+	err := fn(jobCtx, &Progress{job: j})
+	j.finish(err)
+	return j.snapshot(), err
+}
+
+func (m *Manager) newJob(parent context.Context, kind string) (*job, context.Context) {
+	ctx, cancel := context.WithCancel(parent)
+	j := &job{manager: m, cancel: cancel}
+
+	m.mu.Lock()
+	m.nextID++
+	j.status = Status{
+		ID:      fmt.Sprintf("%s-%d", kind, m.nextID),
+		Kind:    kind,
+		State:   StateRunning,
+		Started: time.Now().UTC(),
+	}
+	m.jobs[j.status.ID] = j
+	m.pruneLocked()
+	m.mu.Unlock()
+	m.publish(j.snapshot())
+	return j, ctx
+}
+
+// finish records the job's outcome from the error its Func returned.
+func (j *job) finish(err error) {
+	j.cancel()
+	j.update(func(s *Status) {
+		finished := time.Now().UTC()
+		s.Finished = &finished
+		switch {
+		case err == nil:
+			s.State = StateSucceeded
+		case errors.Is(err, context.Canceled):
+			s.State = StateCanceled
+		default:
+			s.State = StateFailed
+			s.Error = err.Error()
+		}
+	}, true)
+}
+
+// Get returns the status of one job.
+func (m *Manager) Get(id string) (Status, error) {
+	m.mu.Lock()
+	j, ok := m.jobs[id]
+	m.mu.Unlock()
+	if !ok {
+		return Status{}, fmt.Errorf("%s: %w", id, ErrNotFound)
+	}
+	return j.snapshot(), nil
+}
+
+// List returns running and recently finished jobs, newest first.
+func (m *Manager) List() []Status {
+	m.mu.Lock()
+	out := make([]Status, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		out = append(out, j.snapshot())
+	}
+	m.mu.Unlock()
+	sort.Slice(out, func(i, k int) bool {
+		return out[i].Started.After(out[k].Started)
+	})
+	return out
+}
+
+// Cancel asks a running job to stop. Canceling a finished job is a no-op.
+func (m *Manager) Cancel(id string) (Status, error) {
+	m.mu.Lock()
+	j, ok := m.jobs[id]
+	m.mu.Unlock()
+	if !ok {
+		return Status{}, fmt.Errorf("%s: %w", id, ErrNotFound)
+	}
+	j.cancel()
+	return j.snapshot(), nil
+}
+
+// Subscribe delivers job status changes until ctx is done. Slow subscribers
+// miss intermediate progress updates rather than stalling jobs.
+func (m *Manager) Subscribe(ctx context.Context) <-chan Status {
+	ch := make(chan Status, 16)
+	m.mu.Lock()
+	id := m.nextSub
+	m.nextSub++
+	m.subscribers[id] = ch
+	m.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		m.mu.Lock()
+		delete(m.subscribers, id)
+		m.mu.Unlock()
+		close(ch)
+	}()
+	return ch
+}
+
+func (m *Manager) publish(status Status) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, ch := range m.subscribers {
+		select {
+		case ch <- status:
+		default:
+		}
+	}
+}
+
+// pruneLocked drops the oldest finished jobs beyond historyLimit.
+func (m *Manager) pruneLocked() {
+	var finished []*job
+	for _, j := range m.jobs {
+		if s := j.snapshot(); s.State != StateRunning {
+			finished = append(finished, j)
+		}
+	}
+	if len(finished) <= historyLimit {
+		return
+	}
+	sort.Slice(finished, func(i, k int) bool {
+		return finished[i].snapshot().Started.Before(finished[k].snapshot().Started)
+	})
+	for _, j := range finished[:len(finished)-historyLimit] {
+		delete(m.jobs, j.snapshot().ID)
+	}
+}
@@ -0,0 +1,122 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/jobs"
+)
+
+func waitForState(t *testing.T, m *jobs.Manager, id, state string) jobs.Status {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		status, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("Get(%s): %v", id, err)
+		}
+		if status.State == state {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s is %s, want %s", id, status.State, state)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobLifecycle(t *testing.T) {
+	t.Parallel()
+	m := jobs.New()
+	t.Cleanup(m.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	updates := m.Subscribe(ctx)
+
+	done := m.Start("count", func(_ context.Context, p *jobs.Progress) error {
+		for i := 1; i <= 3; i++ {
+			p.Set(i, 3, "counting")
+		}
+		return nil
+	})
+	if done.State != jobs.StateRunning || done.Kind != "count" {
+		t.Fatalf("unexpected start status %+v", done)
+	}
+	status := waitForState(t, m, done.ID, jobs.StateSucceeded)
+	if status.Done != 3 || status.Total != 3 || status.Finished == nil {
+		t.Fatalf("expected final progress 3/3, got %+v", status)
+	}
+
+	failed := m.Start("broken", func(context.Context, *jobs.Progress) error {
+		return errors.New("disk on fire")
+	})
+	if status := waitForState(t, m, failed.ID, jobs.StateFailed); status.Error != "disk on fire" {
+		t.Fatalf("expected error to be recorded, got %+v", status)
+	}
+
+	blocked := m.Start("wait", func(ctx context.Context, _ *jobs.Progress) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if _, err := m.Cancel(blocked.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	waitForState(t, m, blocked.ID, jobs.StateCanceled)
+
+	if _, err := m.Cancel("missing-1"); !errors.Is(err, jobs.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if list := m.List(); len(list) != 3 || list[0].ID != blocked.ID {
+		t.Fatalf("expected three jobs newest first, got %+v", list)
+	}
+
+	finals := make(map[string]string)
+	timeout := time.After(time.Second)
+	for len(finals) < 3 {
+		select {
+		case status := <-updates:
+			if status.State != jobs.StateRunning {
+				finals[status.ID] = status.State
+			}
+		case <-timeout:
+			t.Fatalf("missing final events, got %v", finals)
+		}
+	}
+}
+
+func TestRunRecordsOutcome(t *testing.T) {
+	t.Parallel()
+	m := jobs.New()
+	t.Cleanup(m.Close)
+
+	status, err := m.Run(context.Background(), "inline", func(_ context.Context, p *jobs.Progress) error {
+		p.Set(1, 1, "")
+		return nil
+	})
+	if err != nil || status.State != jobs.StateSucceeded {
+		t.Fatalf("expected succeeded run, got %+v (%v)", status, err)
+	}
+
+	status, err = m.Run(context.Background(), "inline", func(context.Context, *jobs.Progress) error {
+		return errors.New("truncated")
+	})
+	if err == nil || status.State != jobs.StateFailed {
+		t.Fatalf("expected failed run, got %+v (%v)", status, err)
+	}
+	if got, err := m.Get(status.ID); err != nil || got.Error != "truncated" {
+		t.Fatalf("expected failure to be retrievable, got %+v (%v)", got, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	status, _ = m.Run(ctx, "inline", func(ctx context.Context, _ *jobs.Progress) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if status.State != jobs.StateCanceled {
+		t.Fatalf("expected canceled run, got %+v", status)
+	}
+}
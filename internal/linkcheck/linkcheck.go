@@ -0,0 +1,298 @@
+// Package linkcheck periodically verifies external links found in wiki documents.
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/buildinfo"
+)
+
+// Options controls how the checker crawls.
+type Options struct {
+	// Client performs requests. Defaults to a client with Timeout.
+	Client *http.Client
+	// UserAgent identifies the checker to remote hosts and selects robots.txt groups.
+	UserAgent string
+	// Interval between full crawls when using Run.
+	Interval time.Duration
+	// HostDelay is the minimum spacing between requests to the same host.
+	HostDelay time.Duration
+	// Timeout bounds each request when Client is nil.
+	Timeout time.Duration
+}
+
+// Status is the last known state of one external URL.
+type Status struct {
+	CheckedAt  time.Time `json:"checkedAt"`
+	URL        string    `json:"url"`
+	Error      string    `json:"error,omitempty"`
+	Documents  []string  `json:"documents"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Dead       bool      `json:"dead"`
+	Skipped    bool      `json:"skipped,omitempty"`
+}
+
+// Snapshot is a point-in-time view of the checker's cache.
+type Snapshot struct {
+	LastRun time.Time `json:"lastRun"`
+	Links   []Status  `json:"links"`
+	Running bool      `json:"running"`
+}
+
+// CollectFunc returns the external URLs to check, each mapped to the
+// documents that reference it.
+type CollectFunc func(ctx context.Context) (map[string][]string, error)
+
+// Checker HEADs external links and caches their statuses.
+type Checker struct {
+	logger *slog.Logger
+	opts   Options
+
+	mu       sync.RWMutex
+	results  map[string]Status
+	lastRun  time.Time
+	running  bool
+	lastHit  map[string]time.Time
+	robots   map[string]*robotsRules
+	robotsMu sync.Mutex
+}
+
+// New constructs a Checker, filling in defaults for unset options.
+func New(logger *slog.Logger, opts Options) *Checker {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.HostDelay <= 0 {
+		opts.HostDelay = time.Second
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 24 * time.Hour
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = "wikimd-linkcheck/" + buildinfo.Version
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: opts.Timeout}
+	}
+	return &Checker{
+		logger:  logger.With("component", "linkcheck"),
+		opts:    opts,
+		results: make(map[string]Status),
+		lastHit: make(map[string]time.Time),
+		robots:  make(map[string]*robotsRules),
+	}
+}
+
+// Run crawls immediately and then once per interval until ctx is canceled.
+func (c *Checker) Run(ctx context.Context, collect CollectFunc) {
+	ticker := time.NewTicker(c.opts.Interval)
+	defer ticker.Stop()
+	for {
+		links, err := collect(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger.Warn("collect external links failed", slog.Any("err", err))
+		} else if err := c.Check(ctx, links); err != nil && ctx.Err() == nil {
+			c.logger.Warn("external link check failed", slog.Any("err", err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check crawls every URL in links once and replaces the cache with the
+// results. URLs no longer referenced by any document are dropped.
+func (c *Checker) Check(ctx context.Context, links map[string][]string) error {
+	c.mu.Lock()
+	if c.running {
+		c.mu.Unlock()
+		return errors.New("check already running")
+	}
+	c.running = true
+	c.mu.Unlock()
+
+	c.robotsMu.Lock()
+	c.robots = make(map[string]*robotsRules) // refresh robots.txt once per crawl
+	c.robotsMu.Unlock()
+
+	urls := make([]string, 0, len(links))
+	for raw := range links {
+		urls = append(urls, raw)
+	}
+	sort.Strings(urls)
+
+	results := make(map[string]Status, len(urls))
+	var err error
+	for _, raw := range urls {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		status := c.checkOne(ctx, raw)
+		status.Documents = links[raw]
+		results[raw] = status
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.running = false
+	if err != nil {
+		return err
+	}
+	c.results = results
+	c.lastRun = time.Now().UTC()
+	c.logger.Info("external link check complete", slog.Int("links", len(results)))
+	return nil
+}
+
+// Snapshot returns the cached statuses sorted by URL. When deadOnly is set,
+// only links considered dead are included.
+func (c *Checker) Snapshot(deadOnly bool) Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	snap := Snapshot{LastRun: c.lastRun, Running: c.running, Links: []Status{}}
+	for _, st := range c.results {
+		if deadOnly && !st.Dead {
+			continue
+		}
+		snap.Links = append(snap.Links, st)
+	}
+	sort.Slice(snap.Links, func(i, j int) bool { return snap.Links[i].URL < snap.Links[j].URL })
+	return snap
+}
+
+func (c *Checker) checkOne(ctx context.Context, raw string) Status {
+	st := Status{URL: raw, CheckedAt: time.Now().UTC()}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		st.Skipped = true
+		st.Error = "not an http(s) URL"
+		return st
+	}
+
+	if !c.allowed(ctx, u) {
+		st.Skipped = true
+		st.Error = "disallowed by robots.txt"
+		return st
+	}
+
+	code, err := c.request(ctx, http.MethodHead, u)
+	if err == nil && (code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented) {
+		code, err = c.request(ctx, http.MethodGet, u)
+	}
+	st.CheckedAt = time.Now().UTC()
+	if err != nil {
+		st.Error = err.Error()
+		st.Dead = true
+		return st
+	}
+	st.StatusCode = code
+	// 429 means the host is throttling us, not that the page is gone.
+	st.Dead = code >= 400 && code != http.StatusTooManyRequests
+	return st
+}
+
+func (c *Checker) request(ctx context.Context, method string, u *url.URL) (int, error) {
+	if err := c.wait(ctx, u.Host); err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", c.opts.UserAgent)
+	resp, err := c.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// wait blocks until HostDelay has elapsed since the previous request to host.
+func (c *Checker) wait(ctx context.Context, host string) error {
+	c.mu.Lock()
+	next := c.lastHit[host].Add(c.opts.HostDelay)
+	now := time.Now()
+	if next.Before(now) {
+		next = now
+	}
+	c.lastHit[host] = next
+	c.mu.Unlock()
+
+	delay := time.Until(next)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// allowed consults the host's robots.txt, fetching it on first use per crawl.
+// Hosts whose robots.txt cannot be fetched are treated as allowing everything.
+func (c *Checker) allowed(ctx context.Context, u *url.URL) bool {
+	origin := u.Scheme + "://" + u.Host
+	c.robotsMu.Lock()
+	rules, ok := c.robots[origin]
+	c.robotsMu.Unlock()
+	if !ok {
+		rules = c.fetchRobots(ctx, origin)
+		c.robotsMu.Lock()
+		c.robots[origin] = rules
+		c.robotsMu.Unlock()
+	}
+	return rules.allows(u.EscapedPath())
+}
+
+func (c *Checker) fetchRobots(ctx context.Context, origin string) *robotsRules {
+	u, err := url.Parse(origin + "/robots.txt")
+	if err != nil {
+		return nil
+	}
+	if err := c.wait(ctx, u.Host); err != nil {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("User-Agent", c.opts.UserAgent)
+	resp, err := c.opts.Client.Do(req)
+	if err != nil {
+		c.logger.Debug("fetch robots.txt failed", slog.String("origin", origin), slog.Any("err", err))
+		return nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	rules, err := parseRobots(io.LimitReader(resp.Body, 512<<10), c.opts.UserAgent)
+	if err != nil {
+		c.logger.Debug("parse robots.txt failed", slog.String("origin", origin), slog.Any("err", fmt.Errorf("%s: %w", origin, err)))
+		return nil
+	}
+	return rules
+}
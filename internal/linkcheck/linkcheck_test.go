@@ -0,0 +1,93 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckClassifiesLinks(t *testing.T) {
+	t.Parallel()
+
+	var privateHits int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /private\nAllow: /private/public\n"))
+		case "/ok", "/private/public":
+			w.WriteHeader(http.StatusOK)
+		case "/head-unsupported":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/private/secret":
+			privateHits++
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	checker := New(nil, Options{Client: ts.Client(), HostDelay: time.Millisecond})
+	links := map[string][]string{
+		ts.URL + "/ok":               {"index.md"},
+		ts.URL + "/gone":             {"index.md", "guides/start.md"},
+		ts.URL + "/head-unsupported": {"index.md"},
+		ts.URL + "/private/secret":   {"index.md"},
+		ts.URL + "/private/public":   {"index.md"},
+	}
+	if err := checker.Check(context.Background(), links); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	snap := checker.Snapshot(false)
+	if len(snap.Links) != len(links) || snap.LastRun.IsZero() {
+		t.Fatalf("unexpected snapshot: %+v", snap)
+	}
+	byURL := make(map[string]Status)
+	for _, st := range snap.Links {
+		byURL[strings.TrimPrefix(st.URL, ts.URL)] = st
+	}
+	if st := byURL["/ok"]; st.Dead || st.StatusCode != http.StatusOK {
+		t.Fatalf("expected /ok alive, got %+v", st)
+	}
+	if st := byURL["/head-unsupported"]; st.Dead || st.StatusCode != http.StatusOK {
+		t.Fatalf("expected GET fallback for /head-unsupported, got %+v", st)
+	}
+	if st := byURL["/private/secret"]; !st.Skipped || st.Dead {
+		t.Fatalf("expected robots.txt to skip /private/secret, got %+v", st)
+	}
+	if privateHits != 0 {
+		t.Fatalf("disallowed URL was requested %d times", privateHits)
+	}
+	if st := byURL["/private/public"]; st.Skipped || st.Dead {
+		t.Fatalf("expected Allow to override Disallow, got %+v", st)
+	}
+
+	dead := checker.Snapshot(true).Links
+	if len(dead) != 1 || dead[0].StatusCode != http.StatusNotFound || len(dead[0].Documents) != 2 {
+		t.Fatalf("expected only /gone reported dead, got %+v", dead)
+	}
+}
+
+func TestParseRobotsPrefersSpecificAgent(t *testing.T) {
+	t.Parallel()
+
+	body := "User-agent: *\nDisallow: /\n\nUser-agent: wikimd-linkcheck\nDisallow: /drafts\n"
+	rules, err := parseRobots(strings.NewReader(body), "wikimd-linkcheck/1.0")
+	if err != nil {
+		t.Fatalf("parseRobots: %v", err)
+	}
+	if !rules.allows("/docs") {
+		t.Fatalf("expected specific group to allow /docs")
+	}
+	if rules.allows("/drafts/x") {
+		t.Fatalf("expected specific group to disallow /drafts/x")
+	}
+}
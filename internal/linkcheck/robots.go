@@ -0,0 +1,99 @@
+package linkcheck
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// robotsRules holds the Allow/Disallow prefixes that apply to our user agent.
+// A nil *robotsRules allows everything.
+type robotsRules struct {
+	allow    []string
+	disallow []string
+}
+
+// parseRobots extracts the rules for userAgent from a robots.txt body. A group
+// naming our agent wins over the "*" group, as in the robots exclusion standard.
+func parseRobots(r io.Reader, userAgent string) (*robotsRules, error) {
+	agent := strings.ToLower(userAgent)
+	if i := strings.IndexByte(agent, '/'); i >= 0 {
+		agent = agent[:i]
+	}
+
+	var specific, wildcard robotsRules
+	var haveSpecific bool
+	var current []*robotsRules
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = current[:0]
+			}
+			inAgents = true
+			name := strings.ToLower(value)
+			switch {
+			case name == "*":
+				current = append(current, &wildcard)
+			case name != "" && strings.Contains(agent, name):
+				haveSpecific = true
+				current = append(current, &specific)
+			}
+		case "allow", "disallow":
+			inAgents = false
+			if value == "" {
+				continue
+			}
+			for _, group := range current {
+				if key == "allow" {
+					group.allow = append(group.allow, value)
+				} else {
+					group.disallow = append(group.disallow, value)
+				}
+			}
+		default:
+			inAgents = false
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if haveSpecific {
+		return &specific, nil
+	}
+	return &wildcard, nil
+}
+
+// allows applies longest-match precedence, with Allow winning ties.
+func (r *robotsRules) allows(path string) bool {
+	if r == nil {
+		return true
+	}
+	if path == "" {
+		path = "/"
+	}
+	longest := func(prefixes []string) int {
+		best := -1
+		for _, p := range prefixes {
+			if strings.HasPrefix(path, p) && len(p) > best {
+				best = len(p)
+			}
+		}
+		return best
+	}
+	return longest(r.allow) >= longest(r.disallow)
+}
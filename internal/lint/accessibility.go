@@ -0,0 +1,185 @@
+package lint
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// minContrastRatio is the WCAG 2.x AA threshold for normal body text.
+const minContrastRatio = 4.5
+
+// AccessibilityRules returns the rules that make up the accessibility pass:
+// heading structure, image alternative text, and inline colour contrast.
+func AccessibilityRules() []Rule {
+	return []Rule{
+		headingIncrementRule{},
+		imageAltTextRule{},
+		lowContrastRule{},
+	}
+}
+
+// imageAltTextRule flags images whose alt text is empty.
+type imageAltTextRule struct{}
+
+func (imageAltTextRule) ID() string                { return "image-alt-text" }
+func (imageAltTextRule) DefaultSeverity() Severity { return SeverityWarning }
+func (imageAltTextRule) Description() string {
+	return "Images need alternative text for screen readers."
+}
+
+func (imageAltTextRule) Check(doc *Document, _ *Site) []Finding {
+	var out []Finding
+	for _, img := range doc.Images {
+		if strings.TrimSpace(img.Alt) != "" {
+			continue
+		}
+		out = append(out, Finding{
+			Line:    img.Line,
+			Message: fmt.Sprintf("image %q has no alt text", path.Base(img.Destination)),
+		})
+	}
+	return out
+}
+
+// lowContrastRule flags inline HTML styles that set both a foreground and a
+// background colour whose contrast ratio falls below WCAG AA.
+type lowContrastRule struct{}
+
+func (lowContrastRule) ID() string                { return "low-contrast" }
+func (lowContrastRule) DefaultSeverity() Severity { return SeverityWarning }
+func (lowContrastRule) Description() string {
+	return "Inline text and background colours must meet a 4.5:1 contrast ratio."
+}
+
+func (lowContrastRule) Check(doc *Document, _ *Site) []Finding {
+	var out []Finding
+	for _, style := range doc.Styles {
+		fg, bg, ok := styleColors(style.Declarations)
+		if !ok {
+			continue
+		}
+		ratio := contrastRatio(fg, bg)
+		if ratio >= minContrastRatio {
+			continue
+		}
+		out = append(out, Finding{
+			Line:    style.Line,
+			Message: fmt.Sprintf("inline style contrast ratio %.2f:1 is below %.1f:1", ratio, minContrastRatio),
+		})
+	}
+	return out
+}
+
+type rgb struct {
+	r, g, b float64
+}
+
+// styleColors extracts the text and background colours from a CSS
+// declaration list. Both must be present and parseable.
+func styleColors(decls string) (fg, bg rgb, ok bool) {
+	var haveFG, haveBG bool
+	for _, decl := range strings.Split(decls, ";") {
+		name, value, found := strings.Cut(decl, ":")
+		if !found {
+			continue
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important"))
+		switch name {
+		case "color":
+			fg, haveFG = parseColor(value)
+		case "background-color", "background":
+			bg, haveBG = parseColor(value)
+		}
+	}
+	return fg, bg, haveFG && haveBG
+}
+
+var namedColors = map[string]rgb{
+	"black":   {0, 0, 0},
+	"white":   {255, 255, 255},
+	"gray":    {128, 128, 128},
+	"grey":    {128, 128, 128},
+	"silver":  {192, 192, 192},
+	"red":     {255, 0, 0},
+	"maroon":  {128, 0, 0},
+	"yellow":  {255, 255, 0},
+	"olive":   {128, 128, 0},
+	"lime":    {0, 255, 0},
+	"green":   {0, 128, 0},
+	"aqua":    {0, 255, 255},
+	"cyan":    {0, 255, 255},
+	"teal":    {0, 128, 128},
+	"blue":    {0, 0, 255},
+	"navy":    {0, 0, 128},
+	"fuchsia": {255, 0, 255},
+	"magenta": {255, 0, 255},
+	"purple":  {128, 0, 128},
+	"orange":  {255, 165, 0},
+}
+
+// parseColor understands #rgb, #rrggbb, rgb()/rgba() and the CSS basic
+// named colours. Anything else (variables, gradients, hsl) is skipped.
+func parseColor(value string) (rgb, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if c, ok := namedColors[value]; ok {
+		return c, true
+	}
+	if hex, ok := strings.CutPrefix(value, "#"); ok {
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if len(hex) != 6 {
+			return rgb{}, false
+		}
+		n, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return rgb{}, false
+		}
+		return rgb{float64(n >> 16 & 0xff), float64(n >> 8 & 0xff), float64(n & 0xff)}, true
+	}
+	for _, fn := range []string{"rgb(", "rgba("} {
+		args, ok := strings.CutPrefix(value, fn)
+		if !ok {
+			continue
+		}
+		args = strings.TrimSuffix(args, ")")
+		parts := strings.FieldsFunc(args, func(r rune) bool { return r == ',' || r == ' ' || r == '/' })
+		if len(parts) < 3 {
+			return rgb{}, false
+		}
+		var ch [3]float64
+		for i := range ch {
+			v, err := strconv.ParseFloat(parts[i], 64)
+			if err != nil {
+				return rgb{}, false
+			}
+			ch[i] = v
+		}
+		return rgb{ch[0], ch[1], ch[2]}, true
+	}
+	return rgb{}, false
+}
+
+// contrastRatio implements the WCAG relative luminance contrast formula.
+func contrastRatio(a, b rgb) float64 {
+	la, lb := luminance(a), luminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+func luminance(c rgb) float64 {
+	channel := func(v float64) float64 {
+		v /= 255
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.r) + 0.7152*channel(c.g) + 0.0722*channel(c.b)
+}
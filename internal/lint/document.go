@@ -0,0 +1,254 @@
+package lint
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+
+	"github.com/yuin/goldmark"
+	goldmarkmeta "github.com/yuin/goldmark-meta"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+
+	"github.com/euforicio/wikimd/internal/frontmatter"
+	"github.com/euforicio/wikimd/internal/renderer/headingid"
+)
+
+// Document is a parsed markdown file with the structural details rules need.
+//
+//nolint:govet // field order favors readability over padding
+type Document struct {
+	Path     string
+	Source   []byte
+	Metadata map[string]any
+	Headings []Heading
+	Links    []Link
+	Images   []Image
+	Styles   []InlineStyle
+	lines    []int           // byte offset of each line start
+	htmlIDs  map[string]bool // id and name attributes in raw HTML
+}
+
+// Heading is an ATX or setext heading.
+type Heading struct {
+	Text  string
+	ID    string
+	Level int
+	Line  int
+}
+
+// Link is an inline or reference link.
+type Link struct {
+	Destination string
+	Line        int
+}
+
+// Image is an inline image.
+type Image struct {
+	Destination string
+	Alt         string
+	Line        int
+}
+
+// InlineStyle is a style attribute found in raw HTML.
+type InlineStyle struct {
+	Declarations string
+	Line         int
+}
+
+var (
+	styleAttr = regexp.MustCompile(`(?i)\bstyle\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	idAttr    = regexp.MustCompile(`(?i)\b(?:id|name)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM, goldmarkmeta.Meta),
+	goldmark.WithParserOptions(
+		parser.WithAutoHeadingID(),
+		parser.WithAttribute(),
+	),
+)
+
+// Parse builds a Document from raw markdown.
+func Parse(rel string, source []byte) (*Document, error) {
+	doc := &Document{Path: rel, Source: source, lines: lineOffsets(source)}
+
+	// TOML and JSON frontmatter is blanked out in place, so offsets and
+	// lines in the parsed body still match source.
+	body := source
+	values, blanked, ok, fmErr := frontmatter.Decode(source)
+	if ok && fmErr == nil {
+		body = blanked
+	}
+
+	pc := parser.NewContext(parser.WithIDs(headingid.New(body)))
+	root := markdown.Parser().Parse(text.NewReader(body), parser.WithContext(pc))
+	doc.Metadata = goldmarkmeta.Get(pc)
+	if ok && fmErr == nil {
+		doc.Metadata = values
+	}
+
+	err := ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Heading:
+			h := Heading{
+				Level: node.Level,
+				Text:  string(nodeText(node, source)),
+				Line:  doc.lineOf(node),
+			}
+			if id, ok := node.AttributeString("id"); ok {
+				if b, ok := id.([]byte); ok {
+					h.ID = string(b)
+				}
+			}
+			doc.Headings = append(doc.Headings, h)
+		case *ast.Link:
+			doc.Links = append(doc.Links, Link{
+				Destination: string(node.Destination),
+				Line:        doc.lineOf(node),
+			})
+		case *ast.Image:
+			doc.Images = append(doc.Images, Image{
+				Destination: string(node.Destination),
+				Alt:         string(nodeText(node, source)),
+				Line:        doc.lineOf(node),
+			})
+		case *ast.RawHTML:
+			for i := 0; i < node.Segments.Len(); i++ {
+				seg := node.Segments.At(i)
+				doc.collectStyles(seg.Value(source), seg.Start)
+			}
+		case *ast.HTMLBlock:
+			lines := node.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				seg := lines.At(i)
+				doc.collectStyles(seg.Value(source), seg.Start)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// collectStyles records style attributes in a raw HTML fragment that starts
+// at offset, along with any id or name attributes that can serve as anchors.
+func (d *Document) collectStyles(fragment []byte, offset int) {
+	for _, m := range idAttr.FindAllSubmatch(fragment, -1) {
+		if d.htmlIDs == nil {
+			d.htmlIDs = make(map[string]bool)
+		}
+		d.htmlIDs[string(m[1])+string(m[2])] = true
+	}
+	for _, m := range styleAttr.FindAllSubmatchIndex(fragment, -1) {
+		start, end := m[2], m[3]
+		if start < 0 {
+			start, end = m[4], m[5]
+		}
+		d.Styles = append(d.Styles, InlineStyle{
+			Declarations: string(fragment[start:end]),
+			Line:         d.lineAt(offset + m[0]),
+		})
+	}
+}
+
+// HasAnchor reports whether fragment names a heading ID or an id or name
+// attribute in the document's raw HTML.
+func (d *Document) HasAnchor(fragment string) bool {
+	for _, h := range d.Headings {
+		if h.ID == fragment {
+			return true
+		}
+	}
+	return d.htmlIDs[fragment]
+}
+
+// Title returns the frontmatter title or, failing that, the first level-1 heading.
+func (d *Document) Title() string {
+	if title, ok := d.Metadata["title"].(string); ok && title != "" {
+		return title
+	}
+	for _, h := range d.Headings {
+		if h.Level == 1 {
+			return h.Text
+		}
+	}
+	return ""
+}
+
+// Lines splits the source into lines without trailing newlines.
+func (d *Document) Lines() []string {
+	lines := bytes.Split(d.Source, []byte("\n"))
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = string(bytes.TrimSuffix(line, []byte("\r")))
+	}
+	return out
+}
+
+// lineOf returns the 1-based source line where n starts. Inline nodes carry no
+// position, so the first text segment beneath them (or the enclosing block) is used.
+func (d *Document) lineOf(n ast.Node) int {
+	if offset, ok := nodeOffset(n); ok {
+		return d.lineAt(offset)
+	}
+	return 0
+}
+
+func (d *Document) lineAt(offset int) int {
+	return sort.Search(len(d.lines), func(i int) bool { return d.lines[i] > offset })
+}
+
+func nodeOffset(n ast.Node) (int, bool) {
+	for cur := n; cur != nil; cur = cur.Parent() {
+		if cur.Type() == ast.TypeBlock {
+			if lines := cur.Lines(); lines != nil && lines.Len() > 0 {
+				return lines.At(0).Start, true
+			}
+			continue
+		}
+		if t, ok := cur.(*ast.Text); ok {
+			return t.Segment.Start, true
+		}
+		for child := cur.FirstChild(); child != nil; child = child.NextSibling() {
+			if t, ok := child.(*ast.Text); ok {
+				return t.Segment.Start, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func nodeText(n ast.Node, source []byte) []byte {
+	var buf bytes.Buffer
+	_ = ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := child.(type) {
+		case *ast.Text:
+			buf.Write(t.Segment.Value(source))
+		case *ast.String:
+			buf.Write(t.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return buf.Bytes()
+}
+
+func lineOffsets(source []byte) []int {
+	offsets := []int{0}
+	for i, b := range source {
+		if b == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
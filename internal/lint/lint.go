@@ -0,0 +1,344 @@
+// Package lint checks markdown documents against a configurable set of content rules.
+package lint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+)
+
+// ConfigFile is the wiki-relative location of the lint configuration.
+const ConfigFile = ".wikimd/lint.yaml"
+
+// Severity ranks how serious a finding is.
+type Severity string
+
+// Severity levels. SeverityOff disables a rule entirely.
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+	SeverityOff     Severity = "off"
+)
+
+// Finding is a single rule violation within a document.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Path     string   `json:"path"`
+	Message  string   `json:"message"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+}
+
+// Rule inspects one document at a time. Rules leave Finding.Rule and
+// Finding.Severity empty; the engine fills them in from configuration.
+type Rule interface {
+	ID() string
+	Description() string
+	DefaultSeverity() Severity
+	Check(doc *Document, site *Site) []Finding
+}
+
+// Config is the decoded form of .wikimd/lint.yaml.
+//
+//	rules:
+//	  trailing-whitespace: off
+//	  heading-increment: error
+//	ignore:
+//	  - archive/**
+type Config struct {
+	Rules  map[string]Severity `yaml:"rules"`
+	Ignore []string            `yaml:"ignore"`
+}
+
+// LoadConfig reads the lint configuration from the wiki root. A missing file
+// yields the zero Config, which runs every built-in rule at its default severity.
+func LoadConfig(root string) (Config, error) {
+	var cfg Config
+	raw, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(ConfigFile))) //nolint:gosec // fixed path under the wiki root
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("read lint config: %w", err)
+	}
+	if err := yaml.Unmarshal(raw, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse lint config: %w", err)
+	}
+	for id, sev := range cfg.Rules {
+		switch Severity(strings.ToLower(string(sev))) {
+		case SeverityError, SeverityWarning, SeverityInfo, SeverityOff:
+			cfg.Rules[id] = Severity(strings.ToLower(string(sev)))
+		default:
+			return cfg, fmt.Errorf("parse lint config: rule %s: unknown severity %q", id, sev)
+		}
+	}
+	return cfg, nil
+}
+
+// Report is the outcome of a lint run.
+type Report struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	Findings    []Finding `json:"findings"`
+	Documents   int       `json:"documents"`
+	Errors      int       `json:"errors"`
+	Warnings    int       `json:"warnings"`
+}
+
+// Engine applies a rule set to documents.
+type Engine struct {
+	rules []Rule
+	cfg   Config
+}
+
+// NewEngine constructs an engine using cfg. When no rules are supplied the
+// built-in rule set is used.
+func NewEngine(cfg Config, rules ...Rule) *Engine {
+	if len(rules) == 0 {
+		rules = DefaultRules()
+	}
+	return &Engine{rules: rules, cfg: cfg}
+}
+
+// Rules returns the rules the engine runs, including disabled ones.
+func (e *Engine) Rules() []Rule {
+	return e.rules
+}
+
+// severity resolves the configured severity for a rule.
+func (e *Engine) severity(r Rule) Severity {
+	if sev, ok := e.cfg.Rules[r.ID()]; ok {
+		return sev
+	}
+	return r.DefaultSeverity()
+}
+
+// Lint runs every enabled rule against the selected documents. When paths is
+// empty every document in the site is checked.
+func (e *Engine) Lint(ctx context.Context, site *Site, paths ...string) (Report, error) {
+	report := Report{GeneratedAt: time.Now().UTC(), Findings: []Finding{}}
+
+	targets := paths
+	if len(targets) == 0 {
+		targets = site.Paths()
+	}
+
+	for _, rel := range targets {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if e.ignored(rel) {
+			continue
+		}
+		doc := site.Document(rel)
+		if doc == nil {
+			return report, fmt.Errorf("document not found: %s: %w", rel, os.ErrNotExist)
+		}
+		report.Documents++
+		for _, rule := range e.rules {
+			sev := e.severity(rule)
+			if sev == SeverityOff {
+				continue
+			}
+			for _, f := range rule.Check(doc, site) {
+				f.Rule = rule.ID()
+				f.Severity = sev
+				f.Path = doc.Path
+				report.Findings = append(report.Findings, f)
+				switch sev {
+				case SeverityError:
+					report.Errors++
+				case SeverityWarning:
+					report.Warnings++
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Rule < b.Rule
+	})
+	return report, nil
+}
+
+func (e *Engine) ignored(rel string) bool {
+	for _, pattern := range e.cfg.Ignore {
+		if matchGlob(strings.TrimSpace(pattern), rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches wiki-relative paths against path.Match patterns, with a
+// trailing "/**" matching everything below a directory.
+func matchGlob(pattern, rel string) bool {
+	if pattern == "" {
+		return false
+	}
+	if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return rel == dir || strings.HasPrefix(rel, dir+"/")
+	}
+	ok, err := path.Match(pattern, rel)
+	return err == nil && ok
+}
+
+// Site is the set of documents a lint run can see, used to resolve links.
+type Site struct {
+	docs map[string]*Document
+
+	mu      sync.Mutex
+	inbound map[string][]InboundLink // built on first use, keyed by target
+}
+
+// InboundLink is a link from one document to a fragment of another.
+type InboundLink struct {
+	From     string
+	Fragment string
+	Line     int
+}
+
+// NewSite indexes already-parsed documents.
+func NewSite(docs ...*Document) *Site {
+	s := &Site{docs: make(map[string]*Document, len(docs))}
+	for _, doc := range docs {
+		s.docs[doc.Path] = doc
+	}
+	return s
+}
+
+// LoadSite reads and parses every document in the tree rooted at root.
+func LoadSite(ctx context.Context, root string, treeRoot *tree.Node) (*Site, error) {
+	site := NewSite()
+	var walk func(*tree.Node) error
+	walk = func(n *tree.Node) error {
+		if n == nil {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if n.Type == tree.NodeTypeFile {
+			raw, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(n.RelativePath))) //nolint:gosec // path comes from the content tree
+			if err != nil {
+				return fmt.Errorf("read %s: %w", n.RelativePath, err)
+			}
+			doc, err := Parse(n.RelativePath, raw)
+			if err != nil {
+				return fmt.Errorf("parse %s: %w", n.RelativePath, err)
+			}
+			site.docs[doc.Path] = doc
+			return nil
+		}
+		for _, child := range n.Children {
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(treeRoot); err != nil {
+		return nil, err
+	}
+	return site, nil
+}
+
+// Put adds doc to the site, replacing any document at the same path. It is
+// used to lint unsaved editor drafts against the rest of the wiki.
+func (s *Site) Put(doc *Document) {
+	s.docs[doc.Path] = doc
+	s.mu.Lock()
+	s.inbound = nil
+	s.mu.Unlock()
+}
+
+// Inbound returns the links from other documents to a fragment of the
+// document at rel, ordered by source path and line.
+func (s *Site) Inbound(rel string) []InboundLink {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inbound == nil {
+		s.inbound = make(map[string][]InboundLink)
+		for _, from := range s.Paths() {
+			for _, link := range s.docs[from].Links {
+				target, ok := ResolveLink(from, link.Destination)
+				if !ok || target == from {
+					continue
+				}
+				if fragment := Fragment(link.Destination); fragment != "" {
+					s.inbound[target] = append(s.inbound[target], InboundLink{From: from, Fragment: fragment, Line: link.Line})
+				}
+			}
+		}
+	}
+	return s.inbound[rel]
+}
+
+// Document returns the parsed document at rel, or nil.
+func (s *Site) Document(rel string) *Document {
+	return s.docs[rel]
+}
+
+// Has reports whether rel names a document in the site.
+func (s *Site) Has(rel string) bool {
+	_, ok := s.docs[rel]
+	return ok
+}
+
+// ExternalLinks maps every http(s) link and image URL in the site to the
+// sorted paths of the documents that reference it.
+func (s *Site) ExternalLinks() map[string][]string {
+	out := make(map[string][]string)
+	add := func(dest, rel string) {
+		u, err := url.Parse(strings.TrimSpace(dest))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return
+		}
+		u.Fragment = ""
+		key := u.String()
+		if docs := out[key]; len(docs) > 0 && docs[len(docs)-1] == rel {
+			return
+		}
+		out[key] = append(out[key], rel)
+	}
+	for _, rel := range s.Paths() {
+		doc := s.docs[rel]
+		for _, link := range doc.Links {
+			add(link.Destination, rel)
+		}
+		for _, img := range doc.Images {
+			add(img.Destination, rel)
+		}
+	}
+	return out
+}
+
+// Paths lists document paths in sorted order.
+func (s *Site) Paths() []string {
+	out := make([]string, 0, len(s.docs))
+	for rel := range s.docs {
+		out = append(out, rel)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,253 @@
+package lint_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/euforicio/wikimd/internal/lint"
+)
+
+func mustParse(t *testing.T, rel, src string) *lint.Document {
+	t.Helper()
+	doc, err := lint.Parse(rel, []byte(src))
+	if err != nil {
+		t.Fatalf("Parse(%s): %v", rel, err)
+	}
+	return doc
+}
+
+func findingsFor(report lint.Report, rule string) []lint.Finding {
+	var out []lint.Finding
+	for _, f := range report.Findings {
+		if f.Rule == rule {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func TestBuiltInRules(t *testing.T) {
+	t.Parallel()
+
+	site := lint.NewSite(
+		mustParse(t, "index.md", "---\ntitle: Home\n---\n\nSee [guide](guides/start.md) and [gone](missing.md).\n\n## Setup\n\n#### Too deep\n\ntrailing tab\t\nhard break  \n[root](/guides/start.md)\n"),
+		mustParse(t, "guides/start.md", "Intro without a heading.\n\n[back](../index.md)\n"),
+	)
+
+	report, err := lint.NewEngine(lint.Config{}).Lint(context.Background(), site)
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+	if report.Documents != 2 {
+		t.Fatalf("expected 2 documents, got %d", report.Documents)
+	}
+
+	broken := findingsFor(report, "broken-links")
+	if len(broken) != 1 || broken[0].Path != "index.md" || broken[0].Line != 5 {
+		t.Fatalf("expected one broken link on index.md:5, got %+v", broken)
+	}
+	if broken[0].Severity != lint.SeverityError {
+		t.Fatalf("expected broken links to default to error, got %s", broken[0].Severity)
+	}
+
+	titles := findingsFor(report, "missing-title")
+	if len(titles) != 1 || titles[0].Path != "guides/start.md" {
+		t.Fatalf("expected missing title on guides/start.md, got %+v", titles)
+	}
+
+	jumps := findingsFor(report, "heading-increment")
+	if len(jumps) != 1 || jumps[0].Line != 9 {
+		t.Fatalf("expected heading jump on line 9, got %+v", jumps)
+	}
+
+	ws := findingsFor(report, "trailing-whitespace")
+	if len(ws) != 1 || ws[0].Line != 11 {
+		t.Fatalf("expected trailing whitespace only on line 11, got %+v", ws)
+	}
+
+	abs := findingsFor(report, "absolute-internal-url")
+	if len(abs) != 1 || abs[0].Line != 13 {
+		t.Fatalf("expected root-relative link on line 13, got %+v", abs)
+	}
+	if report.Errors != 1 {
+		t.Fatalf("expected 1 error, got %d", report.Errors)
+	}
+}
+
+func TestConfigControlsSeverityAndIgnores(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".wikimd"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfgYAML := "rules:\n  broken-links: warning\n  missing-title: off\nignore:\n  - archive/**\n"
+	if err := os.WriteFile(filepath.Join(root, ".wikimd", "lint.yaml"), []byte(cfgYAML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := lint.LoadConfig(root)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	site := lint.NewSite(
+		mustParse(t, "a.md", "[x](nope.md)\n"),
+		mustParse(t, "archive/old.md", "[x](nope.md)\n"),
+	)
+	report, err := lint.NewEngine(cfg).Lint(context.Background(), site)
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+	if report.Documents != 1 {
+		t.Fatalf("expected archive to be ignored, checked %d documents", report.Documents)
+	}
+	if len(findingsFor(report, "missing-title")) != 0 {
+		t.Fatalf("expected missing-title to be disabled")
+	}
+	broken := findingsFor(report, "broken-links")
+	if len(broken) != 1 || broken[0].Severity != lint.SeverityWarning {
+		t.Fatalf("expected broken link downgraded to warning, got %+v", broken)
+	}
+	if report.Errors != 0 || report.Warnings != 1 {
+		t.Fatalf("unexpected counts: %d errors, %d warnings", report.Errors, report.Warnings)
+	}
+}
+
+func TestLoadConfigRejectsUnknownSeverity(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".wikimd"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".wikimd", "lint.yaml"), []byte("rules:\n  broken-links: fatal\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lint.LoadConfig(root); err == nil {
+		t.Fatalf("expected error for unknown severity")
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	t.Parallel()
+
+	site := lint.NewSite(mustParse(t, "index.md", "# Home\n\n[x](missing.md)\n"))
+	engine := lint.NewEngine(lint.Config{})
+	report, err := engine.Lint(context.Background(), site)
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := lint.WriteSARIF(&buf, report, engine.Rules()); err != nil {
+		t.Fatalf("WriteSARIF: %v", err)
+	}
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID string `json:"ruleId"`
+				Level  string `json:"level"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("decode sarif: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected sarif envelope: %+v", log)
+	}
+	results := log.Runs[0].Results
+	if len(results) != 1 || results[0].RuleID != "broken-links" || results[0].Level != "error" {
+		t.Fatalf("unexpected sarif results: %+v", results)
+	}
+}
+
+func TestAccessibilityPass(t *testing.T) {
+	t.Parallel()
+
+	src := "# Colours\n\n![](chart.png)\n![Revenue chart](chart.png)\n\n" +
+		"<span style=\"color: #777; background-color: #888\">faint</span>\n\n" +
+		"<div style='color:black;background:white'>fine</div>\n\n" +
+		"### Skipped\n"
+	site := lint.NewSite(mustParse(t, "a11y.md", src))
+
+	report, err := lint.NewEngine(lint.Config{}, lint.AccessibilityRules()...).Lint(context.Background(), site)
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+
+	alt := findingsFor(report, "image-alt-text")
+	if len(alt) != 1 || alt[0].Line != 3 {
+		t.Fatalf("expected missing alt text on line 3, got %+v", alt)
+	}
+	contrast := findingsFor(report, "low-contrast")
+	if len(contrast) != 1 || contrast[0].Line != 6 {
+		t.Fatalf("expected low contrast on line 6, got %+v", contrast)
+	}
+	jumps := findingsFor(report, "heading-increment")
+	if len(jumps) != 1 || jumps[0].Line != 10 {
+		t.Fatalf("expected heading jump on line 10, got %+v", jumps)
+	}
+	if len(report.Findings) != 3 {
+		t.Fatalf("accessibility pass should only run its own rules, got %+v", report.Findings)
+	}
+}
+
+func TestAnchorRules(t *testing.T) {
+	t.Parallel()
+
+	site := lint.NewSite(
+		mustParse(t, "runbook.md", "# Runbook\n\n## Setup\n\n## Setup\n\n## Restart {#restart}\n\n## Again {#restart}\n\n<a id=\"legacy\"></a>\n"),
+		mustParse(t, "index.md", "[a](runbook.md#setup-1) [b](runbook.md#install) [c](runbook.md#legacy) [d](runbook.md#restart) [e](#nowhere)\n"),
+	)
+	report, err := lint.NewEngine(lint.Config{}).Lint(context.Background(), site)
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+
+	dups := findingsFor(report, "duplicate-anchors")
+	if len(dups) != 1 || dups[0].Line != 9 {
+		t.Fatalf("expected the repeated {#restart} on line 9, got %+v", dups)
+	}
+	inbound := findingsFor(report, "inbound-anchors")
+	if len(inbound) != 1 || inbound[0].Path != "runbook.md" || inbound[0].Message != "index.md:1 links to #install, which no longer exists here" {
+		t.Fatalf("expected one dangling inbound anchor, got %+v", inbound)
+	}
+
+	// Renaming a heading in a draft breaks the link to it.
+	site.Put(mustParse(t, "runbook.md", "# Runbook\n\n## Setting up\n\n## Setup\n"))
+	report, err = lint.NewEngine(lint.Config{}).Lint(context.Background(), site, "runbook.md")
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+	if got := findingsFor(report, "inbound-anchors"); len(got) != 4 {
+		t.Fatalf("expected #setup-1, #install, #legacy, and #restart to dangle, got %+v", got)
+	}
+}
+
+func TestBrokenLinksChecksFragments(t *testing.T) {
+	t.Parallel()
+
+	site := lint.NewSite(
+		mustParse(t, "guide.md", "# Guide\n\n## Install {#setup}\n\n## Usage\n"),
+		mustParse(t, "index.md", "# Home\n\n[ok](guide.md#setup)\n[gone](guide.md#install)\n[here](#home)\n[missing](#nowhere)\n[top](#top)\n"),
+	)
+	report, err := lint.NewEngine(lint.Config{}).Lint(context.Background(), site, "index.md")
+	if err != nil {
+		t.Fatalf("Lint: %v", err)
+	}
+	broken := findingsFor(report, "broken-links")
+	if len(broken) != 2 || broken[0].Line != 4 || broken[1].Line != 6 {
+		t.Fatalf("expected broken anchors on lines 4 and 6, got %+v", broken)
+	}
+	if broken[0].Message != `link "guide.md#install" points to missing anchor #install in guide.md` {
+		t.Fatalf("unexpected message %q", broken[0].Message)
+	}
+}
@@ -0,0 +1,278 @@
+package lint
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// DefaultRules returns the built-in rule set.
+func DefaultRules() []Rule {
+	return []Rule{
+		brokenLinksRule{},
+		missingTitleRule{},
+		headingIncrementRule{},
+		trailingWhitespaceRule{},
+		absoluteInternalURLRule{},
+		duplicateAnchorsRule{},
+		inboundAnchorsRule{},
+		imageAltTextRule{},
+		lowContrastRule{},
+	}
+}
+
+// LinkRules returns the rules that make up the links pass: missing pages and
+// anchors.
+func LinkRules() []Rule {
+	return []Rule{brokenLinksRule{}}
+}
+
+// Passes names the rule subsets that can be run on their own.
+var Passes = map[string]func() []Rule{
+	"all":           DefaultRules,
+	"accessibility": AccessibilityRules,
+	"links":         LinkRules,
+}
+
+// brokenLinksRule flags relative links to markdown documents that do not
+// exist, and fragment links (page.md#section or #section) to anchors the
+// target page does not define.
+type brokenLinksRule struct{}
+
+func (brokenLinksRule) ID() string                { return "broken-links" }
+func (brokenLinksRule) DefaultSeverity() Severity { return SeverityError }
+func (brokenLinksRule) Description() string {
+	return "Links to markdown documents must resolve to an existing page and heading."
+}
+
+func (brokenLinksRule) Check(doc *Document, site *Site) []Finding {
+	var out []Finding
+	for _, link := range doc.Links {
+		fragment := Fragment(link.Destination)
+		if strings.HasPrefix(strings.TrimSpace(link.Destination), "#") {
+			if fragment != "" && fragment != "top" && !doc.HasAnchor(fragment) {
+				out = append(out, Finding{
+					Line:    link.Line,
+					Message: fmt.Sprintf("link %q points to missing anchor on this page", link.Destination),
+				})
+			}
+			continue
+		}
+		target, ok := ResolveLink(doc.Path, link.Destination)
+		if !ok {
+			continue
+		}
+		targetDoc := site.Document(target)
+		switch {
+		case targetDoc == nil:
+			out = append(out, Finding{
+				Line:    link.Line,
+				Message: fmt.Sprintf("link %q points to missing document %s", link.Destination, target),
+			})
+		case fragment != "" && !targetDoc.HasAnchor(fragment):
+			out = append(out, Finding{
+				Line:    link.Line,
+				Message: fmt.Sprintf("link %q points to missing anchor #%s in %s", link.Destination, fragment, target),
+			})
+		}
+	}
+	return out
+}
+
+// missingTitleRule requires a frontmatter title or a level-1 heading.
+type missingTitleRule struct{}
+
+func (missingTitleRule) ID() string                { return "missing-title" }
+func (missingTitleRule) DefaultSeverity() Severity { return SeverityWarning }
+func (missingTitleRule) Description() string {
+	return "Documents need a frontmatter title or a level-1 heading."
+}
+
+func (missingTitleRule) Check(doc *Document, _ *Site) []Finding {
+	if doc.Title() != "" {
+		return nil
+	}
+	return []Finding{{Line: 1, Message: "document has no frontmatter title or level-1 heading"}}
+}
+
+// headingIncrementRule flags headings that skip levels (e.g. h2 followed by h4).
+type headingIncrementRule struct{}
+
+func (headingIncrementRule) ID() string                { return "heading-increment" }
+func (headingIncrementRule) DefaultSeverity() Severity { return SeverityWarning }
+func (headingIncrementRule) Description() string {
+	return "Heading levels should only increase by one at a time."
+}
+
+func (headingIncrementRule) Check(doc *Document, _ *Site) []Finding {
+	var out []Finding
+	prev := 0
+	for _, h := range doc.Headings {
+		if prev > 0 && h.Level > prev+1 {
+			out = append(out, Finding{
+				Line:    h.Line,
+				Message: fmt.Sprintf("heading level jumps from h%d to h%d", prev, h.Level),
+			})
+		}
+		prev = h.Level
+	}
+	return out
+}
+
+// trailingWhitespaceRule flags lines ending in whitespace. Exactly two trailing
+// spaces are a markdown hard line break and are allowed; fenced code is skipped.
+type trailingWhitespaceRule struct{}
+
+func (trailingWhitespaceRule) ID() string                { return "trailing-whitespace" }
+func (trailingWhitespaceRule) DefaultSeverity() Severity { return SeverityInfo }
+func (trailingWhitespaceRule) Description() string {
+	return "Lines should not end with stray whitespace."
+}
+
+func (trailingWhitespaceRule) Check(doc *Document, _ *Site) []Finding {
+	var out []Finding
+	inFence := false
+	for i, line := range doc.Lines() {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || trimmed == "" {
+			continue
+		}
+		stripped := strings.TrimRight(line, " \t")
+		if stripped == line {
+			continue
+		}
+		if line[len(stripped):] == "  " {
+			continue
+		}
+		out = append(out, Finding{
+			Line:    i + 1,
+			Column:  len(stripped) + 1,
+			Message: "line has trailing whitespace",
+		})
+	}
+	return out
+}
+
+// absoluteInternalURLRule flags root-relative links into the wiki, which break
+// once the site is exported or served under a path prefix.
+type absoluteInternalURLRule struct{}
+
+func (absoluteInternalURLRule) ID() string                { return "absolute-internal-url" }
+func (absoluteInternalURLRule) DefaultSeverity() Severity { return SeverityWarning }
+func (absoluteInternalURLRule) Description() string {
+	return "Internal links should be relative so they survive static export."
+}
+
+func (absoluteInternalURLRule) Check(doc *Document, _ *Site) []Finding {
+	var out []Finding
+	for _, link := range doc.Links {
+		dest := link.Destination
+		if !strings.HasPrefix(dest, "/") || strings.HasPrefix(dest, "//") {
+			continue
+		}
+		out = append(out, Finding{
+			Line:    link.Line,
+			Message: fmt.Sprintf("root-relative link %q; use a path relative to this document", dest),
+		})
+	}
+	return out
+}
+
+// duplicateAnchorsRule flags headings that declare the same {#id}. Generated
+// IDs are numbered apart, so only explicit ones can collide.
+type duplicateAnchorsRule struct{}
+
+func (duplicateAnchorsRule) ID() string                { return "duplicate-anchors" }
+func (duplicateAnchorsRule) DefaultSeverity() Severity { return SeverityWarning }
+func (duplicateAnchorsRule) Description() string {
+	return "Heading IDs must be unique within a document."
+}
+
+func (duplicateAnchorsRule) Check(doc *Document, _ *Site) []Finding {
+	var out []Finding
+	seen := make(map[string]int)
+	for _, h := range doc.Headings {
+		if h.ID == "" {
+			continue
+		}
+		if first, ok := seen[h.ID]; ok {
+			out = append(out, Finding{
+				Line:    h.Line,
+				Message: fmt.Sprintf("heading ID #%s is already used on line %d", h.ID, first),
+			})
+			continue
+		}
+		seen[h.ID] = h.Line
+	}
+	return out
+}
+
+// inboundAnchorsRule flags fragments other pages link to that this document
+// no longer defines, typically because a heading was reworded. Linting an
+// editor draft reports them before the change is saved.
+type inboundAnchorsRule struct{}
+
+func (inboundAnchorsRule) ID() string                { return "inbound-anchors" }
+func (inboundAnchorsRule) DefaultSeverity() Severity { return SeverityWarning }
+func (inboundAnchorsRule) Description() string {
+	return "Anchors that other pages link to must keep existing; add {#old-id} to a renamed heading to preserve them."
+}
+
+func (inboundAnchorsRule) Check(doc *Document, site *Site) []Finding {
+	var out []Finding
+	for _, in := range site.Inbound(doc.Path) {
+		if doc.HasAnchor(in.Fragment) {
+			continue
+		}
+		out = append(out, Finding{
+			Line:    1,
+			Message: fmt.Sprintf("%s:%d links to #%s, which no longer exists here", in.From, in.Line, in.Fragment),
+		})
+	}
+	return out
+}
+
+// Fragment returns the decoded fragment of a link destination, or "".
+func Fragment(dest string) string {
+	u, err := url.Parse(strings.TrimSpace(dest))
+	if err != nil {
+		return ""
+	}
+	return u.Fragment
+}
+
+// ResolveLink maps a link destination found in the document at from to the
+// wiki-relative markdown path it targets. It reports false for external links,
+// pure fragments, and links that are not markdown documents.
+func ResolveLink(from, dest string) (string, bool) {
+	dest = strings.TrimSpace(dest)
+	if dest == "" || strings.HasPrefix(dest, "#") || strings.HasPrefix(dest, "//") {
+		return "", false
+	}
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return "", false
+	}
+	target := u.Path
+	if !strings.HasSuffix(strings.ToLower(target), ".md") && !strings.HasSuffix(strings.ToLower(target), ".markdown") {
+		return "", false
+	}
+	switch {
+	case strings.HasPrefix(target, "/page/"):
+		target = strings.TrimPrefix(target, "/page/")
+	case strings.HasPrefix(target, "/"):
+		target = strings.TrimPrefix(target, "/")
+	default:
+		target = path.Join(path.Dir(from), target)
+	}
+	target = path.Clean(target)
+	if target == "." {
+		return "", false
+	}
+	return target, true
+}
@@ -0,0 +1,131 @@
+// Package merge performs line-based three-way merges of documents.
+package merge
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/euforicio/wikimd/internal/textdiff"
+)
+
+// Conflict markers written around unresolved hunks in Result.Text, in the
+// style of git.
+const (
+	markerOurs   = "<<<<<<< "
+	markerSep    = "======="
+	markerTheirs = ">>>>>>> "
+)
+
+// Conflict is a hunk both sides changed differently. Line is the 1-based
+// line of its opening marker in Result.Text.
+type Conflict struct {
+	Base   string `json:"base"`
+	Ours   string `json:"ours"`
+	Theirs string `json:"theirs"`
+	Line   int    `json:"line"`
+}
+
+// Result is the outcome of a merge. Text holds the merged document, with
+// conflict markers around each entry of Conflicts.
+type Result struct {
+	Text      string
+	Conflicts []Conflict
+}
+
+// Clean reports whether the merge needed no manual resolution.
+func (r Result) Clean() bool {
+	return len(r.Conflicts) == 0
+}
+
+// Labels name the two sides in conflict markers.
+type Labels struct {
+	Ours, Theirs string
+}
+
+// ThreeWay merges the changes made from base to ours with those made from
+// base to theirs. Hunks changed on one side only, or identically on both,
+// merge cleanly.
+func ThreeWay(base, ours, theirs string, labels Labels) Result {
+	b, o, t := lines(base), lines(ours), lines(theirs)
+	toOurs, toTheirs := matches(b, o), matches(b, t)
+
+	var (
+		out       []string
+		conflicts []Conflict
+	)
+	i, j, k := 0, 0, 0
+	for i < len(b) || j < len(o) || k < len(t) {
+		if i < len(b) && toOurs[i] == j && toTheirs[i] == k {
+			out = append(out, b[i])
+			i, j, k = i+1, j+1, k+1
+			continue
+		}
+		// Find the next base line both sides kept; everything before it is
+		// one hunk.
+		ni, nj, nk := len(b), len(o), len(t)
+		for n := i; n < len(b); n++ {
+			if toOurs[n] >= j && toTheirs[n] >= k {
+				ni, nj, nk = n, toOurs[n], toTheirs[n]
+				break
+			}
+		}
+		hb, ho, ht := b[i:ni], o[j:nj], t[k:nk]
+		switch {
+		case slices.Equal(ho, hb):
+			out = append(out, ht...)
+		case slices.Equal(ht, hb), slices.Equal(ho, ht):
+			out = append(out, ho...)
+		default:
+			conflicts = append(conflicts, Conflict{
+				Base:   strings.Join(hb, ""),
+				Ours:   strings.Join(ho, ""),
+				Theirs: strings.Join(ht, ""),
+				Line:   len(out) + 1,
+			})
+			out = append(out, markerOurs+labels.Ours+"\n")
+			out = appendHunk(out, ho)
+			out = append(out, markerSep+"\n")
+			out = appendHunk(out, ht)
+			out = append(out, markerTheirs+labels.Theirs+"\n")
+		}
+		i, j, k = ni, nj, nk
+	}
+	return Result{Text: strings.Join(out, ""), Conflicts: conflicts}
+}
+
+// matches maps each line of base to the line of other it is kept as, or -1.
+func matches(base, other []string) []int {
+	m := make([]int, len(base))
+	for i := range m {
+		m[i] = -1
+	}
+	for _, op := range textdiff.Compare(base, other) {
+		if op.Kind == textdiff.Equal {
+			m[op.A] = op.B
+		}
+	}
+	return m
+}
+
+// lines splits s after each newline, keeping the newlines so the merge
+// preserves line endings and a missing final newline.
+func lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	out := strings.SplitAfter(s, "\n")
+	if out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+// appendHunk adds one side of a conflict, ending it with a newline so the
+// marker that follows starts on its own line.
+func appendHunk(out, hunk []string) []string {
+	out = append(out, hunk...)
+	if n := len(out); n > 0 && !strings.HasSuffix(out[n-1], "\n") {
+		out[n-1] += "\n"
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package merge
+
+import "testing"
+
+var labels = Labels{Ours: "server", Theirs: "yours"}
+
+func TestThreeWayMergesSeparateEdits(t *testing.T) {
+	t.Parallel()
+	base := "# Title\n\nIntro.\n\n## Setup\n\nInstall it.\n"
+	ours := "# Better Title\n\nIntro.\n\n## Setup\n\nInstall it.\n"
+	theirs := "# Title\n\nIntro.\n\n## Setup\n\nInstall it.\nThen run it.\n"
+
+	got := ThreeWay(base, ours, theirs, labels)
+	if !got.Clean() {
+		t.Fatalf("expected a clean merge, got conflicts %+v", got.Conflicts)
+	}
+	want := "# Better Title\n\nIntro.\n\n## Setup\n\nInstall it.\nThen run it.\n"
+	if got.Text != want {
+		t.Fatalf("expected %q, got %q", want, got.Text)
+	}
+}
+
+func TestThreeWayIdenticalEdits(t *testing.T) {
+	t.Parallel()
+	got := ThreeWay("a\nb\n", "a\nB\n", "a\nB\n", labels)
+	if !got.Clean() || got.Text != "a\nB\n" {
+		t.Fatalf("expected identical edits to merge, got %+v", got)
+	}
+}
+
+func TestThreeWayReportsConflicts(t *testing.T) {
+	t.Parallel()
+	base := "one\ntwo\nthree"
+	got := ThreeWay(base, "one\n2\nthree", "one\nTWO\nthree", labels)
+	if got.Clean() || len(got.Conflicts) != 1 {
+		t.Fatalf("expected one conflict, got %+v", got)
+	}
+	c := got.Conflicts[0]
+	if c.Base != "two\n" || c.Ours != "2\n" || c.Theirs != "TWO\n" || c.Line != 2 {
+		t.Fatalf("unexpected conflict %+v", c)
+	}
+	want := "one\n<<<<<<< server\n2\n=======\nTWO\n>>>>>>> yours\nthree"
+	if got.Text != want {
+		t.Fatalf("expected %q, got %q", want, got.Text)
+	}
+}
+
+func TestThreeWayConflictAtEndOfFile(t *testing.T) {
+	t.Parallel()
+	got := ThreeWay("a\nb", "a\nx", "a\ny", labels)
+	want := "a\n<<<<<<< server\nx\n=======\ny\n>>>>>>> yours\n"
+	if got.Text != want {
+		t.Fatalf("expected %q, got %q", want, got.Text)
+	}
+}
@@ -0,0 +1,168 @@
+// Package migrate upgrades the state wikimd keeps under a wiki's .wikimd
+// directory, so a new release reads what an older one wrote.
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// StateDir is the directory of server-side state, relative to the wiki root.
+const StateDir = ".wikimd"
+
+// StateFile records which migrations the state directory has been through.
+const StateFile = ".wikimd/state.json"
+
+// ErrNewerState is returned when the state was written by a newer release,
+// which this one cannot read without risking it.
+var ErrNewerState = errors.New("wiki state was written by a newer wikimd")
+
+// Migration upgrades the state directory by one version. State written
+// before versions were recorded starts at 0 and runs every migration, so
+// Apply must leave state that is already current unchanged.
+type Migration struct {
+	Apply       func(root string) error
+	Description string
+	Version     int
+}
+
+// migrations are applied in order; the last one's Version is the current
+// state version. Never renumber or remove one that has shipped.
+var migrations = []Migration{
+	{Version: 1, Description: "point redirect chains in redirects.json straight at each page's current location", Apply: collapseRedirects},
+}
+
+// Latest is the state version this release writes.
+func Latest() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// Status describes the state directory of one wiki.
+type Status struct {
+	Pending []Migration
+	Version int
+	// Exists is false for a wiki with no state directory, which needs no
+	// migrations.
+	Exists bool
+}
+
+// Check reports the state version of the wiki at root and the migrations an
+// upgrade would apply. It returns ErrNewerState when the state is newer than
+// this release.
+func Check(root string) (Status, error) {
+	var st Status
+	info, err := os.Stat(filepath.Join(root, StateDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		st.Version = Latest()
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	if !info.IsDir() {
+		return st, fmt.Errorf("%s is not a directory", StateDir)
+	}
+	st.Exists = true
+	st.Version, err = readVersion(root)
+	if err != nil {
+		return st, err
+	}
+	if st.Version > Latest() {
+		return st, fmt.Errorf("%w: %s is at version %d, this release reads up to %d; upgrade wikimd", ErrNewerState, StateFile, st.Version, Latest())
+	}
+	for _, m := range migrations {
+		if m.Version > st.Version {
+			st.Pending = append(st.Pending, m)
+		}
+	}
+	return st, nil
+}
+
+// Upgrade applies the pending migrations to the wiki at root, recording the
+// new version after each one so an interrupted upgrade resumes where it
+// stopped. It returns the migrations applied.
+func Upgrade(root string, logger *slog.Logger) ([]Migration, error) {
+	st, err := Check(root)
+	if err != nil {
+		return nil, err
+	}
+	var applied []Migration
+	for _, m := range st.Pending {
+		if err := m.Apply(root); err != nil {
+			return applied, fmt.Errorf("migrate %s to version %d: %w", StateDir, m.Version, err)
+		}
+		if err := writeVersion(root, m.Version); err != nil {
+			return applied, err
+		}
+		applied = append(applied, m)
+		logger.Info("migrated wiki state", slog.Int("version", m.Version), slog.String("migration", m.Description))
+	}
+	return applied, nil
+}
+
+type stateFile struct {
+	Version int `json:"version"`
+}
+
+// readVersion reads StateFile, where a missing file is version 0.
+func readVersion(root string) (int, error) {
+	raw, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(StateFile)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read %s: %w", StateFile, err)
+	}
+	var state stateFile
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return 0, fmt.Errorf("parse %s: %w", StateFile, err)
+	}
+	if state.Version < 0 {
+		return 0, fmt.Errorf("%s: invalid version %d", StateFile, state.Version)
+	}
+	return state.Version, nil
+}
+
+func writeVersion(root string, version int) error {
+	data, err := json.MarshalIndent(stateFile{Version: version}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(root, filepath.FromSlash(StateFile)), append(data, '\n')); err != nil {
+		return fmt.Errorf("write %s: %w", StateFile, err)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces path with data through a temporary file in the
+// same directory, so a crash never leaves half a file behind.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".migrate-*")
+	if err != nil {
+		return err
+	}
+	name := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(name)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(name)
+		return err
+	}
+	if err := os.Chmod(name, 0o644); err != nil { //nolint:gosec // state files are shared with the wiki
+		_ = os.Remove(name)
+		return err
+	}
+	if err := os.Rename(name, path); err != nil {
+		_ = os.Remove(name)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/euforicio/wikimd/internal/content"
+)
+
+func TestUpgradeCollapsesRedirectsAndRecordsVersion(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	redirects := filepath.Join(root, filepath.FromSlash(content.RedirectsFile))
+	if err := os.MkdirAll(filepath.Dir(redirects), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	old := `{"a.md": "b.md", "b.md": "archive/b.md", "x.md": "y.md", "y.md": "x.md"}`
+	if err := os.WriteFile(redirects, []byte(old), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := Check(root)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if !st.Exists || st.Version != 0 || len(st.Pending) != len(migrations) {
+		t.Fatalf("expected unversioned state with every migration pending, got %+v", st)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	applied, err := Upgrade(root, logger)
+	if err != nil {
+		t.Fatalf("Upgrade: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("expected %d migrations applied, got %d", len(migrations), len(applied))
+	}
+
+	raw, err := os.ReadFile(redirects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"a.md": "archive/b.md", "b.md": "archive/b.md"}
+	if len(got) != len(want) || got["a.md"] != want["a.md"] || got["b.md"] != want["b.md"] {
+		t.Errorf("expected chains collapsed and loops dropped, got %v", got)
+	}
+
+	if st, err := Check(root); err != nil || st.Version != Latest() || len(st.Pending) != 0 {
+		t.Fatalf("expected current state after the upgrade, got %+v (%v)", st, err)
+	}
+	if applied, err := Upgrade(root, logger); err != nil || len(applied) != 0 {
+		t.Fatalf("expected a second upgrade to do nothing, got %d applied (%v)", len(applied), err)
+	}
+}
+
+func TestUpgradeLeavesWikiWithoutStateAlone(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	applied, err := Upgrade(root, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil || len(applied) != 0 {
+		t.Fatalf("expected nothing to migrate, got %d applied (%v)", len(applied), err)
+	}
+	if _, err := os.Stat(filepath.Join(root, StateDir)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected no state directory to be created, got %v", err)
+	}
+}
+
+func TestCheckRejectsNewerState(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, StateDir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeVersion(root, Latest()+1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Upgrade(root, slog.New(slog.NewTextHandler(io.Discard, nil))); !errors.Is(err, ErrNewerState) {
+		t.Fatalf("expected ErrNewerState, got %v", err)
+	}
+}
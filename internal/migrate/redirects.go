@@ -0,0 +1,58 @@
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+
+	"github.com/euforicio/wikimd/internal/content"
+)
+
+// collapseRedirects rewrites redirects.json so every old path points at the
+// page's final location. The server keeps chains collapsed as it records
+// moves, but a file edited by hand or merged from another branch can hold
+// chains, which are followed one hop per request, and loops, which never
+// resolve. Redirects that lead back to their source are dropped.
+func collapseRedirects(root string) error {
+	path := filepath.Join(root, filepath.FromSlash(content.RedirectsFile))
+	raw, err := os.ReadFile(path) //nolint:gosec // fixed file under the wiki root
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	redirects := make(map[string]string)
+	if err := json.Unmarshal(raw, &redirects); err != nil {
+		return fmt.Errorf("parse %s: %w", content.RedirectsFile, err)
+	}
+
+	collapsed := make(map[string]string, len(redirects))
+	for src, dst := range redirects {
+		seen := map[string]bool{src: true}
+		for {
+			next, ok := redirects[dst]
+			if !ok || seen[dst] {
+				break
+			}
+			seen[dst] = true
+			dst = next
+		}
+		if dst != src {
+			collapsed[src] = dst
+		}
+	}
+	if maps.Equal(collapsed, redirects) {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(collapsed, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
@@ -0,0 +1,104 @@
+package d2
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// limiter bounds how many diagrams compile at once across every Renderer in
+// the process, so the server's pages, exports, and imports share one budget
+// and a burst of heavy diagrams cannot take every CPU.
+type limiter struct {
+	slots     chan struct{}
+	active    atomic.Int64
+	queued    atomic.Int64
+	rendered  atomic.Uint64
+	failed    atomic.Uint64
+	timedOut  atomic.Uint64
+	waits     atomic.Uint64
+	waitTotal atomic.Int64 // nanoseconds
+	waitMax   atomic.Int64 // nanoseconds
+}
+
+var slots atomic.Pointer[limiter]
+
+func init() {
+	SetConcurrency(0)
+}
+
+// DefaultConcurrency is the number of diagrams compiled at once unless
+// SetConcurrency says otherwise: half the CPUs, and at least one.
+func DefaultConcurrency() int {
+	return max(1, runtime.NumCPU()/2)
+}
+
+// SetConcurrency sets how many diagrams compile at once; n <= 0 restores
+// DefaultConcurrency. Call it at startup: renders already waiting finish
+// against the old limit, and the counters in Stats start over.
+func SetConcurrency(n int) {
+	if n <= 0 {
+		n = DefaultConcurrency()
+	}
+	slots.Store(&limiter{slots: make(chan struct{}, n)})
+}
+
+// acquire waits for a render slot until ctx is done. The returned release
+// must be called once the render finishes.
+func (l *limiter) acquire(ctx context.Context) (release func(), err error) {
+	start := time.Now()
+	l.queued.Add(1)
+	defer l.queued.Add(-1)
+
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	wait := int64(time.Since(start))
+	l.waits.Add(1)
+	l.waitTotal.Add(wait)
+	for {
+		current := l.waitMax.Load()
+		if wait <= current || l.waitMax.CompareAndSwap(current, wait) {
+			break
+		}
+	}
+	l.active.Add(1)
+	return func() {
+		l.active.Add(-1)
+		<-l.slots
+	}, nil
+}
+
+// Stats describes the diagram render queue.
+type Stats struct {
+	Concurrency int     `json:"concurrency"`
+	Active      int64   `json:"active"`
+	Queued      int64   `json:"queued"`
+	Rendered    uint64  `json:"rendered"`
+	Failed      uint64  `json:"failed"`
+	TimedOut    uint64  `json:"timedOut"`
+	AvgWaitMs   float64 `json:"avgWaitMs"`
+	MaxWaitMs   float64 `json:"maxWaitMs"`
+}
+
+// QueueStats reports the render queue shared by every Renderer.
+func QueueStats() Stats {
+	l := slots.Load()
+	st := Stats{
+		Concurrency: cap(l.slots),
+		Active:      l.active.Load(),
+		Queued:      l.queued.Load(),
+		Rendered:    l.rendered.Load(),
+		Failed:      l.failed.Load(),
+		TimedOut:    l.timedOut.Load(),
+		MaxWaitMs:   float64(l.waitMax.Load()) / float64(time.Millisecond),
+	}
+	if n := l.waits.Load(); n > 0 {
+		st.AvgWaitMs = float64(l.waitTotal.Load()) / float64(n) / float64(time.Millisecond)
+	}
+	return st
+}
@@ -0,0 +1,77 @@
+package d2
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiterQueuesAndTimesOut(t *testing.T) {
+	t.Parallel()
+	l := &limiter{slots: make(chan struct{}, 1)}
+
+	release, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	if got := l.active.Load(); got != 1 {
+		t.Fatalf("active = %d, want 1", got)
+	}
+
+	waiting := make(chan error, 1)
+	go func() {
+		r, err := l.acquire(context.Background())
+		if err == nil {
+			r()
+		}
+		waiting <- err
+	}()
+	deadline := time.Now().Add(time.Second)
+	for l.queued.Load() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("second render never queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire past deadline = %v, want deadline exceeded", err)
+	}
+	if err := l.interrupted(ctx.Err(), "waiting", 10*time.Millisecond); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("interrupted = %v, want ErrTimeout", err)
+	}
+	if got := l.timedOut.Load(); got != 1 {
+		t.Fatalf("timedOut = %d, want 1", got)
+	}
+
+	release()
+	if err := <-waiting; err != nil {
+		t.Fatalf("queued acquire: %v", err)
+	}
+	if got := l.active.Load(); got != 0 {
+		t.Fatalf("active = %d after release, want 0", got)
+	}
+	if got := l.waits.Load(); got != 2 {
+		t.Fatalf("waits = %d, want 2", got)
+	}
+	if l.waitMax.Load() <= 0 {
+		t.Fatal("queued render recorded no wait")
+	}
+}
+
+func TestSetConcurrency(t *testing.T) {
+	prev := cap(slots.Load().slots)
+	t.Cleanup(func() { SetConcurrency(prev) })
+
+	SetConcurrency(3)
+	if got := QueueStats().Concurrency; got != 3 {
+		t.Fatalf("Concurrency = %d, want 3", got)
+	}
+	SetConcurrency(0)
+	if got := QueueStats().Concurrency; got != DefaultConcurrency() {
+		t.Fatalf("Concurrency = %d, want default %d", got, DefaultConcurrency())
+	}
+}
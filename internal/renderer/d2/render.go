@@ -6,8 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"oss.terrastruct.com/d2/d2graph"
 	"oss.terrastruct.com/d2/d2layouts/d2dagrelayout"
@@ -19,6 +23,13 @@ import (
 	"oss.terrastruct.com/d2/lib/textmeasure"
 )
 
+const (
+	// DefaultPad is D2's own padding around a diagram, in pixels.
+	DefaultPad = d2svg.DEFAULT_PADDING
+	// DefaultTimeout bounds a diagram when Options leave Timeout unset.
+	DefaultTimeout = 12 * time.Second
+)
+
 // Result captures the outcome of a render attempt.
 type Result struct {
 	SVG      string
@@ -28,34 +39,102 @@ type Result struct {
 var (
 	// ErrEmptyDiagram is returned when the supplied diagram body is empty.
 	ErrEmptyDiagram = errors.New("empty d2 diagram")
+	// ErrTimeout is returned when a diagram waits or renders past the
+	// renderer's timeout.
+	ErrTimeout = errors.New("d2 render timed out")
+	// ErrInvalidOptions wraps New's errors for an unknown theme or layout.
+	ErrInvalidOptions = errors.New("invalid d2 options")
 )
 
 // Renderer performs server-side D2 compilation using the embedded D2 compiler.
-// Layout choices are left entirely to the source diagram (via D2 config blocks)
-// or environment variables such as D2_LAYOUT.
+// Each diagram's look comes, in order of precedence, from the Settings it is
+// rendered with, its own d2-config block, and the renderer's Options.
 type Renderer struct {
-	ruler   *textmeasure.Ruler
-	logger  *slog.Logger
-	timeout time.Duration
+	ruler     *textmeasure.Ruler
+	logger    *slog.Logger
+	pad       *int64
+	timeout   time.Duration
+	themeID   int64
+	darkTheme *int64
+	layout    string
+	sketch    bool
 }
 
 // Options configure the renderer.
 type Options struct {
+	// Timeout bounds each diagram, including time queued for a render slot
+	// (default DefaultTimeout).
 	Timeout time.Duration
+	// Theme and DarkTheme name catalog themes (see ThemeID). Theme defaults to
+	// Dark Flagship Terrastruct; DarkTheme, used when the reader's system
+	// prefers dark mode, is unset by default.
+	Theme     string
+	DarkTheme string
+	// Layout is the engine for diagrams that do not choose one: dagre (the
+	// default) or elk.
+	Layout string
+	Sketch bool
+	// Pad is the space around each diagram in pixels; nil keeps D2's default.
+	Pad *int64
+}
+
+// Settings override Options for one diagram; zero fields are left to the
+// diagram's d2-config and then to Options.
+type Settings struct {
+	Theme     string
+	DarkTheme string
+	Layout    string
+	Sketch    *bool
+	Pad       *int64
 }
 
 // New creates a renderer instance. The provided context is unused for now but
-// kept for API parity with future enhancements.
+// kept for API parity with future enhancements. An unknown theme or layout in
+// opts fails with ErrInvalidOptions.
 func New(_ context.Context, logger *slog.Logger, opts *Options) (*Renderer, error) {
 	if logger == nil {
 		logger = slog.Default()
 	}
 
 	cfg := Options{
-		Timeout: 12 * time.Second,
+		Timeout: DefaultTimeout,
+	}
+	if opts != nil {
+		cfg = *opts
+		if cfg.Timeout <= 0 {
+			cfg.Timeout = DefaultTimeout
+		}
+	}
+
+	r := &Renderer{
+		logger:  logger,
+		timeout: cfg.Timeout,
+		themeID: d2themescatalog.DarkFlagshipTerrastruct.ID,
+		sketch:  cfg.Sketch,
+		pad:     cfg.Pad,
+	}
+	if cfg.Theme != "" {
+		id, err := ThemeID(cfg.Theme)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidOptions, err)
+		}
+		r.themeID = id
+	}
+	if cfg.DarkTheme != "" {
+		id, err := ThemeID(cfg.DarkTheme)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidOptions, err)
+		}
+		r.darkTheme = &id
 	}
-	if opts != nil && opts.Timeout > 0 {
-		cfg.Timeout = opts.Timeout
+	if cfg.Layout != "" {
+		if _, err := r.layoutResolver(cfg.Layout); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidOptions, err)
+		}
+		r.layout = strings.ToLower(cfg.Layout)
+	}
+	if cfg.Pad != nil && *cfg.Pad < 0 {
+		return nil, fmt.Errorf("%w: padding %d", ErrInvalidOptions, *cfg.Pad)
 	}
 
 	// Initialize ruler once - it loads font metrics which is expensive
@@ -63,17 +142,15 @@ func New(_ context.Context, logger *slog.Logger, opts *Options) (*Renderer, erro
 	if err != nil {
 		return nil, fmt.Errorf("init ruler: %w", err)
 	}
-
-	return &Renderer{
-		logger:  logger,
-		timeout: cfg.Timeout,
-		ruler:   ruler,
-	}, nil
+	r.ruler = ruler
+	return r, nil
 }
 
-// Render compiles the given D2 script into SVG, respecting any layout directives
-// defined inside the document itself.
-func (r *Renderer) Render(ctx context.Context, source string) (Result, error) {
+// Render compiles the given D2 script into SVG, applying settings on top of
+// the diagram's own d2-config and the renderer's defaults. It waits for a
+// render slot (see SetConcurrency); the renderer's timeout covers both the
+// wait and the render, and exceeding it fails with ErrTimeout.
+func (r *Renderer) Render(ctx context.Context, source string, settings Settings) (Result, error) {
 	if strings.TrimSpace(source) == "" {
 		return Result{}, ErrEmptyDiagram
 	}
@@ -81,25 +158,61 @@ func (r *Renderer) Render(ctx context.Context, source string) (Result, error) {
 		ctx = context.Background()
 	}
 
+	renderOpts, compileOpts, err := r.options(source, settings)
+	if err != nil {
+		return Result{}, err
+	}
+
 	ctx = d2log.With(ctx, r.logger)
 	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	themeID := d2themescatalog.DarkFlagshipTerrastruct.ID
-	darkThemeID := d2themescatalog.DarkFlagshipTerrastruct.ID
-	pad := int64(d2svg.DEFAULT_PADDING)
-	renderOpts := &d2svg.RenderOpts{
-		ThemeID:     &themeID,
-		DarkThemeID: &darkThemeID,
-		Pad:         &pad,
+	l := slots.Load()
+	release, err := l.acquire(ctx)
+	if err != nil {
+		return Result{}, l.interrupted(err, "waiting for a render slot", r.timeout)
+	}
+
+	// D2 layouts do not all stop when ctx ends, so the compile runs apart and
+	// keeps its slot until it really finishes; the caller gets the timeout.
+	type outcome struct {
+		result Result
+		err    error
 	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer release()
+		result, err := r.render(ctx, source, compileOpts, renderOpts)
+		done <- outcome{result, err}
+	}()
 
-	start := time.Now()
-	compileOpts := &d2lib.CompileOptions{
-		Ruler:          r.ruler,
-		LayoutResolver: r.layoutResolver,
+	select {
+	case o := <-done:
+		if o.err != nil && ctx.Err() != nil {
+			return Result{}, l.interrupted(ctx.Err(), "rendering", r.timeout)
+		}
+		if o.err != nil {
+			l.failed.Add(1)
+		} else {
+			l.rendered.Add(1)
+		}
+		return o.result, o.err
+	case <-ctx.Done():
+		return Result{}, l.interrupted(ctx.Err(), "rendering", r.timeout)
 	}
+}
 
+// interrupted counts a render cut short by err and describes it.
+func (l *limiter) interrupted(err error, stage string, timeout time.Duration) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		l.timedOut.Add(1)
+		return fmt.Errorf("%w: exceeded %s while %s", ErrTimeout, timeout, stage)
+	}
+	return err
+}
+
+func (r *Renderer) render(ctx context.Context, source string, compileOpts *d2lib.CompileOptions, renderOpts *d2svg.RenderOpts) (Result, error) {
+	start := time.Now()
 	diagram, _, err := d2lib.Compile(ctx, source, compileOpts, renderOpts)
 	if err != nil {
 		return Result{}, err
@@ -119,6 +232,124 @@ func (r *Renderer) Render(ctx context.Context, source string) (Result, error) {
 	}, nil
 }
 
+// configKey matches a key set inside a diagram's vars.d2-config block.
+var configKey = regexp.MustCompile(`(?m)^\s*(theme-id|dark-theme-id|layout-engine|sketch|pad)\s*:`)
+
+// options resolves the compile and render options for one diagram. D2 only
+// consults a diagram's d2-config for options left nil, so the renderer's
+// defaults are withheld for keys the diagram sets itself.
+func (r *Renderer) options(source string, settings Settings) (*d2svg.RenderOpts, *d2lib.CompileOptions, error) {
+	own := make(map[string]bool)
+	if strings.Contains(source, "d2-config") {
+		for _, m := range configKey.FindAllStringSubmatch(source, -1) {
+			own[m[1]] = true
+		}
+	}
+
+	renderOpts := &d2svg.RenderOpts{}
+	compileOpts := &d2lib.CompileOptions{
+		Ruler:          r.ruler,
+		LayoutResolver: r.layoutResolver,
+	}
+
+	switch {
+	case settings.Theme != "":
+		id, err := ThemeID(settings.Theme)
+		if err != nil {
+			return nil, nil, err
+		}
+		renderOpts.ThemeID = &id
+	case !own["theme-id"]:
+		id := r.themeID
+		renderOpts.ThemeID = &id
+	}
+	switch {
+	case settings.DarkTheme != "":
+		id, err := ThemeID(settings.DarkTheme)
+		if err != nil {
+			return nil, nil, err
+		}
+		renderOpts.DarkThemeID = &id
+	case !own["dark-theme-id"] && r.darkTheme != nil:
+		id := *r.darkTheme
+		renderOpts.DarkThemeID = &id
+	}
+	switch {
+	case settings.Layout != "":
+		if _, err := r.layoutResolver(settings.Layout); err != nil {
+			return nil, nil, err
+		}
+		layout := strings.ToLower(settings.Layout)
+		compileOpts.Layout = &layout
+	case !own["layout-engine"] && r.layout != "":
+		layout := r.layout
+		compileOpts.Layout = &layout
+	}
+	switch {
+	case settings.Sketch != nil:
+		sketch := *settings.Sketch
+		renderOpts.Sketch = &sketch
+	case !own["sketch"] && r.sketch:
+		sketch := true
+		renderOpts.Sketch = &sketch
+	}
+	switch {
+	case settings.Pad != nil:
+		if *settings.Pad < 0 {
+			return nil, nil, fmt.Errorf("invalid D2 padding %d", *settings.Pad)
+		}
+		pad := *settings.Pad
+		renderOpts.Pad = &pad
+	case !own["pad"]:
+		pad := int64(DefaultPad)
+		if r.pad != nil {
+			pad = *r.pad
+		}
+		renderOpts.Pad = &pad
+	}
+	return renderOpts, compileOpts, nil
+}
+
+// ThemeID resolves a theme from the D2 catalog by numeric ID or by name.
+// Names ignore case, spaces, hyphens, and underscores ("dark-mauve"); a
+// prefix picks the first matching catalog theme, so "neutral" is Neutral
+// Default.
+func ThemeID(name string) (int64, error) {
+	name = strings.TrimSpace(name)
+	if id, err := strconv.ParseInt(name, 10, 64); err == nil {
+		if d2themescatalog.Find(id).Name == "" {
+			return 0, fmt.Errorf("unknown D2 theme %d", id)
+		}
+		return id, nil
+	}
+	want := themeKey(name)
+	if want == "" {
+		return 0, errors.New("empty D2 theme name")
+	}
+	themes := append(slices.Clone(d2themescatalog.LightCatalog), d2themescatalog.DarkCatalog...)
+	for _, t := range themes {
+		if themeKey(t.Name) == want {
+			return t.ID, nil
+		}
+	}
+	for _, t := range themes {
+		if strings.HasPrefix(themeKey(t.Name), want) {
+			return t.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown D2 theme %q", name)
+}
+
+func themeKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_':
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, name)
+}
+
 func (r *Renderer) layoutResolver(engine string) (d2graph.LayoutGraph, error) {
 	switch strings.ToLower(engine) {
 	case "", "dagre":
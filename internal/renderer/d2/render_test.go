@@ -0,0 +1,76 @@
+package d2
+
+import (
+	"testing"
+
+	"oss.terrastruct.com/d2/d2themes/d2themescatalog"
+)
+
+func TestThemeID(t *testing.T) {
+	t.Parallel()
+	tests := map[string]int64{
+		"neutral":                   d2themescatalog.NeutralDefault.ID,
+		"Dark Mauve":                d2themescatalog.DarkMauve.ID,
+		"dark-flagship-terrastruct": d2themescatalog.DarkFlagshipTerrastruct.ID,
+		"terminal":                  d2themescatalog.Terminal.ID,
+		"200":                       200,
+	}
+	for name, want := range tests {
+		got, err := ThemeID(name)
+		if err != nil || got != want {
+			t.Errorf("ThemeID(%q) = %d, %v; want %d", name, got, err, want)
+		}
+	}
+	for _, name := range []string{"", "nope", "9999"} {
+		if _, err := ThemeID(name); err == nil {
+			t.Errorf("ThemeID(%q) succeeded, want error", name)
+		}
+	}
+}
+
+func TestOptionsPrecedence(t *testing.T) {
+	t.Parallel()
+	pad := int64(10)
+	r := &Renderer{
+		themeID: d2themescatalog.Terminal.ID,
+		layout:  "elk",
+		sketch:  true,
+		pad:     &pad,
+	}
+
+	// Renderer defaults apply to a plain diagram.
+	render, compile, err := r.options("a -> b", Settings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *render.ThemeID != d2themescatalog.Terminal.ID || *compile.Layout != "elk" || !*render.Sketch || *render.Pad != 10 {
+		t.Fatalf("defaults not applied: theme=%d layout=%s sketch=%v pad=%d", *render.ThemeID, *compile.Layout, *render.Sketch, *render.Pad)
+	}
+
+	// Keys the diagram sets in d2-config are left to D2.
+	source := "vars: {\n  d2-config: {\n    theme-id: 300\n    layout-engine: dagre\n  }\n}\na -> b\n"
+	render, compile, err = r.options(source, Settings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if render.ThemeID != nil || compile.Layout != nil {
+		t.Fatalf("expected d2-config to choose theme and layout, got theme=%v layout=%v", render.ThemeID, compile.Layout)
+	}
+	if *render.Pad != 10 {
+		t.Fatalf("expected default padding for keys d2-config leaves out")
+	}
+
+	// Fence settings beat both.
+	off := false
+	render, compile, err = r.options(source, Settings{Theme: "neutral", Layout: "dagre", Sketch: &off})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *render.ThemeID != d2themescatalog.NeutralDefault.ID || *compile.Layout != "dagre" || *render.Sketch {
+		t.Fatalf("fence settings not applied")
+	}
+
+	if _, _, err := r.options("a", Settings{Layout: "tala"}); err == nil {
+		t.Fatalf("expected error for unsupported layout")
+	}
+}
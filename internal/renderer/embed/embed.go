@@ -0,0 +1,308 @@
+// Package embed turns video and gist references that stand on their own line
+// into embedded players: {{youtube ID}}, {{vimeo ID}}, {{gist user/ID}}, or a
+// bare YouTube, Vimeo, or gist URL.
+package embed
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Providers an embed can come from.
+const (
+	YouTube = "youtube"
+	Vimeo   = "vimeo"
+	Gist    = "gist"
+)
+
+// Embed is one embedded video or gist.
+type Embed struct {
+	Provider string
+	ID       string // video ID, or "user/ID" (or just "ID") for a gist
+	Start    int    // YouTube start offset in seconds
+}
+
+var (
+	youtubeID = regexp.MustCompile(`^[A-Za-z0-9_-]{6,20}$`)
+	vimeoID   = regexp.MustCompile(`^[0-9]{1,12}$`)
+	gistID    = regexp.MustCompile(`^(?:[A-Za-z0-9-]{1,39}/)?[0-9a-fA-F]{5,40}$`)
+)
+
+// Directive reads the provider and argument of a {{provider arg}} directive.
+// The argument may be an ID or a URL from the same provider.
+func Directive(provider, arg string) (Embed, bool) {
+	provider = strings.ToLower(provider)
+	if strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://") {
+		e, ok := FromURL(arg)
+		return e, ok && e.Provider == provider
+	}
+	e := Embed{Provider: provider, ID: arg}
+	return e, e.valid()
+}
+
+// FromURL recognizes YouTube, Vimeo, and gist page URLs.
+func FromURL(raw string) (Embed, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return Embed{}, false
+	}
+	host := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(u.Hostname()), "www."), "m.")
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+	var e Embed
+	switch host {
+	case "youtube.com", "youtube-nocookie.com":
+		e.Provider = YouTube
+		switch {
+		case len(segments) == 1 && segments[0] == "watch":
+			e.ID = u.Query().Get("v")
+		case len(segments) == 2 && (segments[0] == "embed" || segments[0] == "shorts" || segments[0] == "live"):
+			e.ID = segments[1]
+		}
+	case "youtu.be":
+		e.Provider = YouTube
+		if len(segments) == 1 {
+			e.ID = segments[0]
+		}
+	case "vimeo.com":
+		e.Provider = Vimeo
+		if len(segments) == 1 {
+			e.ID = segments[0]
+		}
+	case "player.vimeo.com":
+		e.Provider = Vimeo
+		if len(segments) == 2 && segments[0] == "video" {
+			e.ID = segments[1]
+		}
+	case "gist.github.com":
+		e.Provider = Gist
+		if len(segments) == 2 {
+			e.ID = segments[0] + "/" + segments[1]
+		}
+	}
+	if e.Provider == YouTube {
+		e.Start = parseStart(firstNonEmpty(u.Query().Get("t"), u.Query().Get("start")))
+	}
+	return e, e.valid()
+}
+
+func (e Embed) valid() bool {
+	switch e.Provider {
+	case YouTube:
+		return youtubeID.MatchString(e.ID)
+	case Vimeo:
+		return vimeoID.MatchString(e.ID)
+	case Gist:
+		return gistID.MatchString(e.ID)
+	default:
+		return false
+	}
+}
+
+// parseStart reads a YouTube start time: seconds, optionally with an "s"
+// suffix, or a duration such as 1h2m3s.
+func parseStart(t string) int {
+	if t == "" {
+		return 0
+	}
+	if n, err := strconv.Atoi(strings.TrimSuffix(t, "s")); err == nil && n > 0 {
+		return n
+	}
+	total := 0
+	for _, unit := range []struct {
+		suffix  string
+		seconds int
+	}{{"h", 3600}, {"m", 60}, {"s", 1}} {
+		i := strings.Index(t, unit.suffix)
+		if i < 0 {
+			continue
+		}
+		n, err := strconv.Atoi(t[:i])
+		if err != nil || n < 0 {
+			return 0
+		}
+		total += n * unit.seconds
+		t = t[i+1:]
+	}
+	if t != "" {
+		return 0
+	}
+	return total
+}
+
+// PageURL is the address of the video or gist on its own site.
+func (e Embed) PageURL() string {
+	switch e.Provider {
+	case YouTube:
+		u := "https://www.youtube.com/watch?v=" + e.ID
+		if e.Start > 0 {
+			u += "&t=" + strconv.Itoa(e.Start) + "s"
+		}
+		return u
+	case Vimeo:
+		return "https://vimeo.com/" + e.ID
+	default:
+		return "https://gist.github.com/" + e.ID
+	}
+}
+
+// frameURL is the player address. YouTube videos play from the
+// youtube-nocookie.com domain and Vimeo videos with do-not-track set, so
+// neither sets tracking cookies before the video is played.
+func (e Embed) frameURL() string {
+	switch e.Provider {
+	case YouTube:
+		u := "https://www.youtube-nocookie.com/embed/" + e.ID
+		if e.Start > 0 {
+			u += "?start=" + strconv.Itoa(e.Start)
+		}
+		return u
+	case Vimeo:
+		return "https://player.vimeo.com/video/" + e.ID + "?dnt=1"
+	default:
+		return "https://gist.github.com/" + e.ID + ".pibb"
+	}
+}
+
+func (e Embed) label() string {
+	switch e.Provider {
+	case YouTube:
+		return "YouTube video"
+	case Vimeo:
+		return "Vimeo video"
+	default:
+		return "GitHub gist"
+	}
+}
+
+// HTML is the embedded player: an iframe that loads only when it scrolls
+// into view.
+func (e Embed) HTML() string {
+	return fmt.Sprintf(`<div class="embed embed-%s"><iframe src="%s" title="%s" loading="lazy" referrerpolicy="strict-origin-when-cross-origin" allow="encrypted-media; picture-in-picture; fullscreen" allowfullscreen="allowfullscreen"></iframe></div>`,
+		e.Provider, html.EscapeString(e.frameURL()), e.label())
+}
+
+// Markdown is the embed for output that cannot play it, such as PDF: a
+// YouTube video's thumbnail linked to the video, and a link for the rest.
+func (e Embed) Markdown() string {
+	link := e.label() + ": <" + e.PageURL() + ">"
+	if e.Provider != YouTube {
+		return link
+	}
+	thumbnail := "https://img.youtube.com/vi/" + e.ID + "/hqdefault.jpg"
+	return "[![" + e.label() + "](" + thumbnail + ")](" + e.PageURL() + ")\n\n" + link
+}
+
+// paragraph matches a rendered paragraph holding only a directive or only
+// an autolinked URL. References inside code spans or blocks never render as
+// a bare paragraph, so they are left alone.
+var paragraph = regexp.MustCompile(`<p>(?:\{\{\s*(youtube|vimeo|gist)\s+([^\s{}<>]+)\s*\}\}|<a href="(https?://[^"]+)"[^>]*>([^<]+)</a>)</p>`)
+
+// Expand replaces embed paragraphs in rendered HTML with players.
+func Expand(rendered string) string {
+	if !strings.Contains(rendered, "{{") && !strings.Contains(rendered, "<p><a href=\"http") {
+		return rendered
+	}
+	return paragraph.ReplaceAllStringFunc(rendered, func(match string) string {
+		parts := paragraph.FindStringSubmatch(match)
+		var e Embed
+		var ok bool
+		if parts[1] != "" {
+			e, ok = Directive(parts[1], html.UnescapeString(parts[2]))
+		} else if href := html.UnescapeString(parts[3]); href == html.UnescapeString(parts[4]) {
+			e, ok = FromURL(href)
+		}
+		if !ok {
+			return match
+		}
+		return e.HTML()
+	})
+}
+
+var directiveLine = regexp.MustCompile(`^\{\{\s*(youtube|vimeo|gist)\s+([^\s{}<>]+)\s*\}\}$`)
+
+// Fallback rewrites embeds in markdown source with their Markdown form. As
+// with Expand, only a reference that is a paragraph of its own, outside
+// code, is rewritten.
+func Fallback(source []byte) []byte {
+	if !bytes.Contains(source, []byte("{{")) && !bytes.Contains(source, []byte("http")) {
+		return source
+	}
+	lines := strings.SplitAfter(string(source), "\n")
+	var fence string
+	changed := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if marker := fenceMarker(trimmed); marker != "" {
+			switch {
+			case fence == "":
+				fence = marker
+			case strings.HasPrefix(marker, fence) && marker == trimmed:
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" || strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		if !blank(lines, i-1) || !blank(lines, i+1) {
+			continue
+		}
+		e, ok := lineEmbed(trimmed)
+		if !ok {
+			continue
+		}
+		lines[i] = e.Markdown() + strings.TrimPrefix(line, strings.TrimRight(line, "\r\n"))
+		changed = true
+	}
+	if !changed {
+		return source
+	}
+	return []byte(strings.Join(lines, ""))
+}
+
+// blank reports whether lines[i] is empty or out of range.
+func blank(lines []string, i int) bool {
+	return i < 0 || i >= len(lines) || strings.TrimSpace(lines[i]) == ""
+}
+
+func lineEmbed(line string) (Embed, bool) {
+	if m := directiveLine.FindStringSubmatch(line); m != nil {
+		return Directive(m[1], m[2])
+	}
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "<"), ">")
+	if strings.ContainsAny(line, " \t") {
+		return Embed{}, false
+	}
+	return FromURL(line)
+}
+
+// fenceMarker returns the run of backticks or tildes opening line, if it
+// is at least three long.
+func fenceMarker(line string) string {
+	if line == "" || (line[0] != '`' && line[0] != '~') {
+		return ""
+	}
+	n := 1
+	for n < len(line) && line[n] == line[0] {
+		n++
+	}
+	if n < 3 {
+		return ""
+	}
+	return line[:n]
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
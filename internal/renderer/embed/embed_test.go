@@ -0,0 +1,94 @@
+package embed
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFromURL(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		url  string
+		want Embed
+		ok   bool
+	}{
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ", Embed{Provider: YouTube, ID: "dQw4w9WgXcQ"}, true},
+		{"https://youtu.be/dQw4w9WgXcQ?t=1m30s", Embed{Provider: YouTube, ID: "dQw4w9WgXcQ", Start: 90}, true},
+		{"https://m.youtube.com/shorts/dQw4w9WgXcQ", Embed{Provider: YouTube, ID: "dQw4w9WgXcQ"}, true},
+		{"https://vimeo.com/76979871", Embed{Provider: Vimeo, ID: "76979871"}, true},
+		{"https://player.vimeo.com/video/76979871", Embed{Provider: Vimeo, ID: "76979871"}, true},
+		{"https://gist.github.com/octocat/6cad326836d38bd3a7ae", Embed{Provider: Gist, ID: "octocat/6cad326836d38bd3a7ae"}, true},
+		{"https://www.youtube.com/channel/UC123456", Embed{}, false},
+		{"https://vimeo.com/channels/staffpicks", Embed{}, false},
+		{"https://example.com/watch?v=dQw4w9WgXcQ", Embed{}, false},
+		{"ftp://youtu.be/dQw4w9WgXcQ", Embed{}, false},
+	}
+	for _, tc := range cases {
+		got, ok := FromURL(tc.url)
+		if ok != tc.ok || (ok && got != tc.want) {
+			t.Errorf("FromURL(%q) = %+v, %v; want %+v, %v", tc.url, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name, html string
+		contains   string // empty means unchanged
+	}{
+		{
+			name:     "youtube directive",
+			html:     "<p>{{youtube dQw4w9WgXcQ}}</p>",
+			contains: `<iframe src="https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ" title="YouTube video" loading="lazy"`,
+		},
+		{
+			name:     "vimeo directive with a URL",
+			html:     "<p>{{ vimeo https://vimeo.com/76979871 }}</p>",
+			contains: `src="https://player.vimeo.com/video/76979871?dnt=1"`,
+		},
+		{
+			name:     "gist directive",
+			html:     "<p>{{gist octocat/6cad326836d38bd3a7ae}}</p>",
+			contains: `<div class="embed embed-gist"><iframe src="https://gist.github.com/octocat/6cad326836d38bd3a7ae.pibb"`,
+		},
+		{
+			name:     "bare URL with a start time",
+			html:     `<p><a href="https://www.youtube.com/watch?v=dQw4w9WgXcQ&amp;t=42">https://www.youtube.com/watch?v=dQw4w9WgXcQ&amp;t=42</a></p>`,
+			contains: `src="https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ?start=42"`,
+		},
+		{
+			name:     "decorated bare URL",
+			html:     `<p><a href="https://vimeo.com/76979871" target="_blank" rel="noopener" class="external-link">https://vimeo.com/76979871</a></p>`,
+			contains: `src="https://player.vimeo.com/video/76979871?dnt=1"`,
+		},
+		{name: "labelled link", html: `<p><a href="https://youtu.be/dQw4w9WgXcQ">Watch this</a></p>`},
+		{name: "URL in a sentence", html: `<p>See <a href="https://youtu.be/dQw4w9WgXcQ">https://youtu.be/dQw4w9WgXcQ</a></p>`},
+		{name: "provider mismatch", html: "<p>{{vimeo https://youtu.be/dQw4w9WgXcQ}}</p>"},
+		{name: "invalid ID", html: `<p>{{youtube "><script>}}</p>`},
+	}
+	for _, tc := range cases {
+		got := Expand(tc.html)
+		if tc.contains == "" {
+			if got != tc.html {
+				t.Errorf("%s: expected no change, got %s", tc.name, got)
+			}
+			continue
+		}
+		if !strings.Contains(got, tc.contains) || strings.Contains(got, "<p>") {
+			t.Errorf("%s: got %s, want it to contain %s", tc.name, got, tc.contains)
+		}
+	}
+}
+
+func TestFallback(t *testing.T) {
+	t.Parallel()
+	src := "Intro\n\n{{youtube dQw4w9WgXcQ}}\n\nhttps://vimeo.com/76979871\n\n```\n{{gist octocat/6cad326836d38bd3a7ae}}\n```\n\nSee\nhttps://vimeo.com/1\n"
+	want := "Intro\n\n" +
+		"[![YouTube video](https://img.youtube.com/vi/dQw4w9WgXcQ/hqdefault.jpg)](https://www.youtube.com/watch?v=dQw4w9WgXcQ)\n\nYouTube video: <https://www.youtube.com/watch?v=dQw4w9WgXcQ>\n\n" +
+		"Vimeo video: <https://vimeo.com/76979871>\n\n" +
+		"```\n{{gist octocat/6cad326836d38bd3a7ae}}\n```\n\nSee\nhttps://vimeo.com/1\n"
+	if got := string(Fallback([]byte(src))); got != want {
+		t.Errorf("Fallback:\n got %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+// Package extlink decides how links to other sites render: whether they open
+// in a new tab, carry an icon, or are allowed at all.
+package extlink
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// IconClass marks decorated links; the stylesheet draws the icon after them.
+const IconClass = "external-link"
+
+// Options configure external links. The zero value renders them as written.
+type Options struct {
+	// NewTab opens external links in a new tab with rel="noopener".
+	NewTab bool
+	// Icon adds IconClass to external links.
+	Icon bool
+	// Allow, when set, keeps only links to these domains and their
+	// subdomains; the others render as plain text.
+	Allow []string
+	// Deny lists domains, with their subdomains, whose links render as plain
+	// text. It wins over Allow.
+	Deny []string
+}
+
+// ParseDomains normalizes a domain list: lower case, without a leading
+// "*." or ".", and empty entries dropped. Entries must be bare host names.
+func ParseDomains(entries []string) ([]string, error) {
+	out := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		domain := strings.ToLower(strings.TrimSpace(entry))
+		domain = strings.TrimPrefix(strings.TrimPrefix(domain, "*"), ".")
+		if domain == "" {
+			continue
+		}
+		if strings.ContainsAny(domain, "/:@ ?#*") {
+			return nil, fmt.Errorf("invalid link domain %q (want a host name such as example.com)", entry)
+		}
+		out = append(out, domain)
+	}
+	return out, nil
+}
+
+// IsZero reports whether o leaves external links unchanged.
+func (o Options) IsZero() bool {
+	return !o.NewTab && !o.Icon && len(o.Allow) == 0 && len(o.Deny) == 0
+}
+
+// Host returns the host of an external http(s) link destination.
+func Host(dest string) (string, bool) {
+	if !strings.HasPrefix(dest, "http://") && !strings.HasPrefix(dest, "https://") {
+		return "", false
+	}
+	u, err := url.Parse(dest)
+	if err != nil || u.Hostname() == "" {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.ToLower(u.Hostname()), "."), true
+}
+
+// Blocked reports whether links to host render as plain text.
+func (o Options) Blocked(host string) bool {
+	if matches(o.Deny, host) {
+		return true
+	}
+	return len(o.Allow) > 0 && !matches(o.Allow, host)
+}
+
+// Attributes are the attributes a permitted external link gets, in order.
+func (o Options) Attributes() [][2]string {
+	var attrs [][2]string
+	if o.NewTab {
+		attrs = append(attrs, [2]string{"target", "_blank"}, [2]string{"rel", "noopener"})
+	}
+	if o.Icon {
+		attrs = append(attrs, [2]string{"class", IconClass})
+	}
+	return attrs
+}
+
+func matches(domains []string, host string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
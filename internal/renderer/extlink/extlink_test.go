@@ -0,0 +1,43 @@
+package extlink
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDomains(t *testing.T) {
+	t.Parallel()
+	got, err := ParseDomains([]string{" Example.COM ", "*.corp.example", ".docs.example", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"example.com", "corp.example", "docs.example"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseDomains = %v, want %v", got, want)
+	}
+	for _, bad := range []string{"https://example.com", "example.com/path", "a b"} {
+		if _, err := ParseDomains([]string{bad}); err == nil {
+			t.Errorf("ParseDomains(%q) should fail", bad)
+		}
+	}
+}
+
+func TestBlocked(t *testing.T) {
+	t.Parallel()
+	opts := Options{Allow: []string{"example.com"}, Deny: []string{"ads.example.com"}}
+	cases := map[string]bool{
+		"example.com":        false,
+		"docs.example.com":   false,
+		"ads.example.com":    true,
+		"x.ads.example.com":  true,
+		"badexample.com":     true,
+		"example.com.evil.a": true,
+	}
+	for host, want := range cases {
+		if got := opts.Blocked(host); got != want {
+			t.Errorf("Blocked(%q) = %v, want %v", host, got, want)
+		}
+	}
+	if (Options{}).Blocked("anything.example") {
+		t.Error("no lists should block nothing")
+	}
+}
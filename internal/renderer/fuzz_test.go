@@ -0,0 +1,51 @@
+package renderer_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/fuzztest"
+	"github.com/euforicio/wikimd/internal/renderer"
+)
+
+// renderLimit bounds one fuzz input, leaving room for a D2 diagram to hit
+// its own render timeout first.
+const renderLimit = 30 * time.Second
+
+func FuzzRender(f *testing.F) {
+	for _, doc := range fuzztest.Markdown(f, "../../testdata/wiki") {
+		f.Add(doc)
+	}
+	f.Add([]byte("---\ntitle: [unclosed\n---\n# Heading {#\n"))
+	f.Add([]byte("```mermaid\ngraph TD;\n"))
+	f.Add([]byte("{{children depth=-1}}\n[[nested [link](a.md)]](../../x.md)"))
+
+	svc := renderer.NewService(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	f.Fuzz(func(t *testing.T, content []byte) {
+		fuzztest.Within(t, renderLimit, func() {
+			_, _ = svc.Render(context.Background(), "fuzz.md", time.Time{}, content)
+		})
+	})
+}
+
+func FuzzRenderLinks(f *testing.F) {
+	for _, rel := range fuzztest.Paths(f, "../../testdata/wiki") {
+		f.Add("guides/page.md", rel)
+		f.Add("index.md", "../"+rel)
+	}
+	f.Add("a/b/c.md", "https://example.com/x.md")
+	f.Add("page.md", "/abs/../../escape.md#top")
+	f.Add("page.md", "%zz.md?q=1")
+
+	svc := renderer.NewService(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	f.Fuzz(func(t *testing.T, docPath, dest string) {
+		content := []byte("[link](<" + dest + ">) ![image](<" + dest + ">)\n\n[ref]: " + dest + "\n")
+		fuzztest.Within(t, renderLimit, func() {
+			_, _ = svc.Render(context.Background(), path.Clean("/" + docPath)[1:], time.Time{}, content)
+		})
+	})
+}
@@ -0,0 +1,106 @@
+// Package headingid assigns heading anchors. Generated IDs follow goldmark's
+// slugs, so existing links keep working, but explicit {#custom-id}
+// attributes are reserved before any ID is generated: a heading never takes
+// an ID that a later heading declares, and duplicates are numbered in
+// document order (#setup, #setup-1, #setup-2).
+package headingid
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/util"
+)
+
+// explicitAttr matches a trailing attribute block with an id, as in
+// "## Setup {#install .wide}".
+var explicitAttr = regexp.MustCompile(`\{[^{}]*?#([^\s{}]+)[^{}]*\}\s*$`)
+
+// Explicit returns the IDs declared with {#id} on heading lines of source,
+// in document order. Fenced code is skipped.
+func Explicit(source []byte) []string {
+	var out []string
+	inFence := false
+	for _, line := range bytes.Split(source, []byte("\n")) {
+		trimmed := bytes.TrimSpace(line)
+		if bytes.HasPrefix(trimmed, []byte("```")) || bytes.HasPrefix(trimmed, []byte("~~~")) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if m := explicitAttr.FindSubmatch(trimmed); m != nil {
+			out = append(out, string(m[1]))
+		}
+	}
+	return out
+}
+
+// ids implements parser.IDs.
+type ids struct {
+	values map[string]bool
+}
+
+// New returns the parser.IDs for rendering source, with its explicit IDs
+// reserved. Pass it to parser.NewContext with parser.WithIDs.
+func New(source []byte) parser.IDs {
+	s := &ids{values: make(map[string]bool)}
+	for _, id := range Explicit(source) {
+		s.values[id] = true
+	}
+	return s
+}
+
+// Generate slugs value the way goldmark does: ASCII letters and digits are
+// kept and lowercased, spaces, hyphens, and underscores become hyphens, and
+// everything else is dropped. A taken slug gets the first free numeric
+// suffix.
+func (s *ids) Generate(value []byte, kind ast.NodeKind) []byte {
+	slug := Slug(value)
+	if len(slug) == 0 {
+		if kind == ast.KindHeading {
+			slug = "heading"
+		} else {
+			slug = "id"
+		}
+	}
+	id := slug
+	for i := 1; s.values[id]; i++ {
+		id = slug + "-" + strconv.Itoa(i)
+	}
+	s.values[id] = true
+	return []byte(id)
+}
+
+// Put records an explicit ID.
+func (s *ids) Put(value []byte) {
+	s.values[string(value)] = true
+}
+
+// Slug returns the un-numbered anchor goldmark derives from heading text.
+func Slug(text []byte) string {
+	text = util.TrimRightSpace(util.TrimLeftSpace(text))
+	out := make([]byte, 0, len(text))
+	for i := 0; i < len(text); {
+		c := text[i]
+		n := int(util.UTF8Len(c))
+		i += max(n, 1)
+		if n > 1 {
+			continue
+		}
+		switch {
+		case util.IsAlphaNumeric(c):
+			if 'A' <= c && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			out = append(out, c)
+		case util.IsSpace(c) || c == '-' || c == '_':
+			out = append(out, '-')
+		}
+	}
+	return string(out)
+}
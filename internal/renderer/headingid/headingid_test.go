@@ -0,0 +1,31 @@
+package headingid
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+func TestExplicit(t *testing.T) {
+	t.Parallel()
+	src := "# Title {#top}\n\n```md\n## Fenced {#nope}\n```\n\n## Setup {.wide #install}\n\nText {#not-heading-but-reserved}\n"
+	got := Explicit([]byte(src))
+	want := []string{"top", "install", "not-heading-but-reserved"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestGenerateAvoidsExplicitIDs(t *testing.T) {
+	t.Parallel()
+	ids := New([]byte("## Setup\n\n## Setup\n\n## Later {#setup-1}\n"))
+	var got []string
+	for _, text := range []string{"Setup", "Setup", "Setup 1", "Ünïcode!", ""} {
+		got = append(got, string(ids.Generate([]byte(text), ast.KindHeading)))
+	}
+	want := []string{"setup", "setup-2", "setup-1-1", "ncode", "heading"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
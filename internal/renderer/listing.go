@@ -0,0 +1,91 @@
+package renderer
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ListItem is one entry in a generated page listing.
+type ListItem struct {
+	Title       string
+	Description string
+	Href        string // empty for directories without a page of their own
+	Children    []ListItem
+}
+
+// ListFunc returns the pages below the current document's directory, nested
+// depth levels deep.
+type ListFunc func(depth int) []ListItem
+
+// maxListingDepth bounds toc-tree recursion.
+const maxListingDepth = 6
+
+// listingDirective matches a paragraph holding only {{children}} or
+// {{toc-tree}}, optionally with depth=N. Directives inside code spans or
+// blocks never render as a bare paragraph, so they are left alone.
+var listingDirective = regexp.MustCompile(`<p>\{\{\s*(children|toc-tree)((?:\s+[a-z]+=\d+)*)\s*\}\}</p>`)
+
+var directiveArg = regexp.MustCompile(`([a-z]+)=(\d+)`)
+
+// ExpandListings replaces {{children}} and {{toc-tree depth=N}} directives in
+// rendered HTML with a nested list of child pages. {{children}} defaults to
+// one level and {{toc-tree}} to two. Expansion is kept separate from Render
+// so cached documents pick up added or retitled pages without re-rendering.
+func ExpandListings(rendered string, list ListFunc) string {
+	if list == nil || !strings.Contains(rendered, "{{") {
+		return rendered
+	}
+	return listingDirective.ReplaceAllStringFunc(rendered, func(match string) string {
+		parts := listingDirective.FindStringSubmatch(match)
+		depth := 1
+		if parts[1] == "toc-tree" {
+			depth = 2
+		}
+		for _, arg := range directiveArg.FindAllStringSubmatch(parts[2], -1) {
+			if arg[1] == "depth" {
+				if n, err := strconv.Atoi(arg[2]); err == nil {
+					depth = n
+				}
+			}
+		}
+		depth = min(max(depth, 1), maxListingDepth)
+
+		var b strings.Builder
+		b.WriteString(`<nav class="page-listing" data-listing="`)
+		b.WriteString(parts[1])
+		b.WriteString(`">`)
+		writeListItems(&b, list(depth))
+		b.WriteString(`</nav>`)
+		return b.String()
+	})
+}
+
+func writeListItems(b *strings.Builder, items []ListItem) {
+	if len(items) == 0 {
+		return
+	}
+	b.WriteString("<ul>")
+	for _, item := range items {
+		b.WriteString("<li>")
+		if item.Href != "" {
+			b.WriteString(`<a href="`)
+			b.WriteString(html.EscapeString(item.Href))
+			b.WriteString(`">`)
+			b.WriteString(html.EscapeString(item.Title))
+			b.WriteString("</a>")
+		} else {
+			b.WriteString("<strong>")
+			b.WriteString(html.EscapeString(item.Title))
+			b.WriteString("</strong>")
+		}
+		if item.Description != "" {
+			b.WriteString(" — ")
+			b.WriteString(html.EscapeString(item.Description))
+		}
+		writeListItems(b, item.Children)
+		b.WriteString("</li>")
+	}
+	b.WriteString("</ul>")
+}
@@ -4,9 +4,11 @@ package renderer
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"path"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -24,21 +26,37 @@ import (
 	"github.com/yuin/goldmark/util"
 	"go.abhg.dev/goldmark/anchor"
 
+	"github.com/euforicio/wikimd/internal/frontmatter"
 	d2renderer "github.com/euforicio/wikimd/internal/renderer/d2"
+	"github.com/euforicio/wikimd/internal/renderer/embed"
+	"github.com/euforicio/wikimd/internal/renderer/extlink"
+	"github.com/euforicio/wikimd/internal/renderer/headingid"
+	"github.com/euforicio/wikimd/internal/renderer/shortcode"
 	"github.com/euforicio/wikimd/internal/renderer/transform"
 )
 
 // Metadata captures optional frontmatter data rendered alongside a document.
 type Metadata struct {
+	// ReviewBy is the date from the reviewBy frontmatter key after which the
+	// page is considered overdue for review. Zero when unset or unparseable.
+	ReviewBy    time.Time
 	Raw         map[string]any
 	Title       string
 	Description string
-	Tags        []string
+	// Icon and Color come from the icon and color frontmatter keys and
+	// decorate the page in navigation. Icon is an emoji or an image path.
+	Icon  string
+	Color string
+	Tags  []string
+	// NoIndex comes from the noindex frontmatter key. Such pages are served
+	// and exported as usual but ask crawlers not to index them and are left
+	// out of search.
+	NoIndex bool
 }
 
 // IsZero reports whether the metadata carries any meaningful values.
 func (m Metadata) IsZero() bool {
-	if m.Title != "" || m.Description != "" || len(m.Tags) > 0 {
+	if m.Title != "" || m.Description != "" || len(m.Tags) > 0 || !m.ReviewBy.IsZero() || m.Icon != "" || m.Color != "" || m.NoIndex {
 		return false
 	}
 	return len(m.Raw) == 0
@@ -52,6 +70,43 @@ type Document struct {
 	Metadata Metadata
 	Modified time.Time
 	Raw      string
+	// Cost is what producing HTML took. A cached document keeps the cost
+	// of the render that produced it.
+	Cost Cost
+}
+
+// Cost breaks down the time a render took.
+type Cost struct {
+	Total time.Duration
+	// D2 is the time spent on diagrams, including waiting for a slot.
+	D2 time.Duration
+	// Highlight is the time spent writing HTML when the page has code
+	// blocks, which syntax highlighting dominates; zero without any.
+	Highlight  time.Duration
+	Bytes      int
+	Diagrams   int
+	CodeBlocks int
+}
+
+// Cost causes reported by Dominant.
+const (
+	CostD2        = "d2"
+	CostHighlight = "highlighting"
+	CostSize      = "size"
+)
+
+// Dominant names what took most of the render: CostD2, CostHighlight, or
+// CostSize for parsing and everything else, which grows with the page.
+func (c Cost) Dominant() string {
+	rest := c.Total - c.D2 - c.Highlight
+	switch {
+	case c.D2 >= c.Highlight && c.D2 >= rest:
+		return CostD2
+	case c.Highlight >= rest:
+		return CostHighlight
+	default:
+		return CostSize
+	}
 }
 
 type cacheEntry struct {
@@ -66,9 +121,10 @@ type cacheKey string
 // syntax highlighting, and automatic link transformation for wiki-style navigation.
 // Rendered documents are cached by path and modification time for improved performance.
 type Service struct {
-	md     goldmark.Markdown
-	logger *slog.Logger
-	cache  sync.Map // map[cacheKey]cacheEntry
+	md         goldmark.Markdown
+	logger     *slog.Logger
+	shortcodes shortcode.Options
+	cache      sync.Map // map[cacheKey]cacheEntry
 }
 
 // contextKey for storing document path
@@ -81,10 +137,14 @@ var bufferPool = sync.Pool{
 	},
 }
 
-// linkTransformer rewrites .md links to /page/ routes and image paths to /media/ routes
-type linkTransformer struct{}
+// linkTransformer rewrites .md links to /page/ routes and image paths to
+// /media/ routes, and applies the external link options to links to other
+// sites.
+type linkTransformer struct {
+	external extlink.Options
+}
 
-func (t *linkTransformer) Transform(node *ast.Document, _ text.Reader, pc parser.Context) {
+func (t *linkTransformer) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
 	// Get current document path from context (wiki-relative path)
 	currentPath := ""
 	if v := pc.Get(docPathKey); v != nil {
@@ -96,6 +156,7 @@ func (t *linkTransformer) Transform(node *ast.Document, _ text.Reader, pc parser
 	// Get directory of current document (wiki-relative)
 	currentDir := path.Dir(currentPath)
 
+	var external []ast.Node
 	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
 		if !entering {
 			return ast.WalkContinue, nil
@@ -103,13 +164,27 @@ func (t *linkTransformer) Transform(node *ast.Document, _ text.Reader, pc parser
 
 		switch typed := n.(type) {
 		case *ast.Link:
+			if t.isExternalLink(string(typed.Destination)) {
+				external = append(external, typed)
+			}
 			t.transformLink(typed, currentDir)
+		case *ast.AutoLink:
+			if typed.AutoLinkType == ast.AutoLinkURL {
+				external = append(external, typed)
+			}
 		case *ast.Image:
 			t.transformImage(typed, currentDir)
 		}
 
 		return ast.WalkContinue, nil
 	})
+
+	// Blocked links are replaced, so the tree is changed after the walk.
+	if !t.external.IsZero() {
+		for _, n := range external {
+			t.transformExternal(n, reader.Source())
+		}
+	}
 }
 
 func (t *linkTransformer) transformLink(link *ast.Link, currentDir string) {
@@ -134,6 +209,42 @@ func (t *linkTransformer) transformImage(img *ast.Image, currentDir string) {
 	img.Destination = []byte("/media/" + normalizeWikiPath(dest, currentDir))
 }
 
+// transformExternal decorates a link to another site, or replaces it with
+// its text when the domain is not allowed.
+func (t *linkTransformer) transformExternal(n ast.Node, source []byte) {
+	var dest string
+	switch typed := n.(type) {
+	case *ast.Link:
+		dest = string(typed.Destination)
+	case *ast.AutoLink:
+		dest = string(typed.URL(source))
+	}
+	host, ok := extlink.Host(dest)
+	if !ok {
+		return
+	}
+	if !t.external.Blocked(host) {
+		for _, attr := range t.external.Attributes() {
+			n.SetAttributeString(attr[0], []byte(attr[1]))
+		}
+		return
+	}
+
+	parent := n.Parent()
+	if autolink, ok := n.(*ast.AutoLink); ok {
+		label := ast.NewString(autolink.Label(source))
+		label.SetRaw(true)
+		parent.ReplaceChild(parent, n, label)
+		return
+	}
+	for child := n.FirstChild(); child != nil; {
+		next := child.NextSibling()
+		parent.InsertBefore(parent, n, child)
+		child = next
+	}
+	parent.RemoveChild(parent, n)
+}
+
 func (t *linkTransformer) isExternalLink(dest string) bool {
 	return strings.HasPrefix(dest, "http://") || strings.HasPrefix(dest, "https://")
 }
@@ -161,12 +272,36 @@ func normalizeWikiPath(dest, currentDir string) string {
 //
 // If logger is nil, the default slog logger is used.
 func NewService(logger *slog.Logger) *Service {
+	svc, _ := NewServiceWithOptions(logger, Options{})
+	return svc
+}
+
+// Options configure a Service.
+type Options struct {
+	// D2 sets the default theme, layout, sketch mode, and padding of D2
+	// diagrams; fences can override them per diagram.
+	D2 d2renderer.Options
+	// Shortcodes sets how Hugo shortcodes and Liquid tags in migrated
+	// content are handled; the zero value leaves them as text.
+	Shortcodes shortcode.Options
+	// ExternalLinks sets how links to other sites render; the zero value
+	// leaves them as written.
+	ExternalLinks extlink.Options
+}
+
+// NewServiceWithOptions is NewService with diagram defaults. It fails only
+// when opts names an unknown D2 theme or layout; D2 being unavailable just
+// disables diagrams, as with NewService.
+func NewServiceWithOptions(logger *slog.Logger, opts Options) (*Service, error) {
 	if logger == nil {
 		logger = slog.Default()
 	}
 
-	d2Service, err := d2renderer.New(context.Background(), logger.With("component", "d2"), nil)
+	d2Service, err := d2renderer.New(context.Background(), logger.With("component", "d2"), &opts.D2)
 	if err != nil {
+		if errors.Is(err, d2renderer.ErrInvalidOptions) {
+			return nil, err
+		}
 		logger.Warn("d2: diagrams disabled", "err", err)
 		d2Service = nil
 	}
@@ -181,7 +316,7 @@ func NewService(logger *slog.Logger) *Service {
 	)
 
 	transformers := []util.PrioritizedValue{
-		util.Prioritized(&linkTransformer{}, 100),
+		util.Prioritized(&linkTransformer{external: opts.ExternalLinks}, 100),
 	}
 	if d2Service != nil {
 		transformers = append(transformers, util.Prioritized(transform.NewD2Transformer(d2Service, logger), 90))
@@ -221,16 +356,17 @@ func NewService(logger *slog.Logger) *Service {
 	)
 
 	return &Service{
-		md:     md,
-		logger: logger.With("component", "renderer"),
-	}
+		md:         md,
+		logger:     logger.With("component", "renderer"),
+		shortcodes: opts.Shortcodes,
+	}, nil
 }
 
 // Render converts markdown content to HTML, caching results by path and modification time.
 // If a cached entry exists with a matching modification time, it is returned immediately.
 // Otherwise, the markdown is parsed and rendered, then cached for future requests.
 // The path parameter is used for cache key generation and relative link resolution.
-func (s *Service) Render(_ context.Context, path string, modTime time.Time, content []byte) (Document, error) {
+func (s *Service) Render(ctx context.Context, path string, modTime time.Time, content []byte) (Document, error) {
 	key := cacheKey(path)
 
 	if entry, ok := s.cache.Load(key); ok {
@@ -241,29 +377,80 @@ func (s *Service) Render(_ context.Context, path string, modTime time.Time, cont
 		}
 	}
 
-	parserCtx := parser.NewContext()
+	// goldmark-meta reads YAML frontmatter; TOML and JSON are decoded here
+	// and blanked out before parsing.
+	source := content
+	frontmatterValues, body, decoded, err := frontmatter.Decode(content)
+	switch {
+	case err != nil:
+		s.logger.Warn("frontmatter not parsed", slog.String("path", path), slog.Any("err", err))
+	case decoded:
+		source = body
+	}
+	source, removed := shortcode.Rewrite(source, s.shortcodes)
+	if len(removed) > 0 && s.shortcodes.Mode != shortcode.ModeStrip {
+		s.logger.Warn("unsupported shortcodes removed", slog.String("path", path), slog.Any("shortcodes", removed))
+	}
+
+	parserCtx := parser.NewContext(parser.WithIDs(headingid.New(source)))
 	parserCtx.Set(docPathKey, path)
+	transform.WithContext(parserCtx, ctx)
 
 	buf := bufferPool.Get().(*bytes.Buffer) //nolint:errcheck // pool always returns *bytes.Buffer
 	buf.Reset()
 	defer bufferPool.Put(buf)
 
-	if err := s.md.Convert(content, buf, parser.WithContext(parserCtx)); err != nil {
+	// Parsing runs the D2 transformer; writing HTML runs the highlighter.
+	// They are timed apart to tell which one a slow page is paying for.
+	started := time.Now()
+	root := s.md.Parser().Parse(text.NewReader(source), parser.WithContext(parserCtx))
+	cost := Cost{Bytes: len(content), D2: transform.D2Time(parserCtx)}
+	countBlocks(root, &cost)
+	written := time.Now()
+	if err := s.md.Renderer().Render(buf, source, root); err != nil {
 		return Document{}, fmt.Errorf("render markdown: %w", err)
 	}
+	if cost.CodeBlocks > 0 {
+		cost.Highlight = time.Since(written)
+	}
+	cost.Total = time.Since(started)
 
-	metadata := extractMetadata(parserCtx)
+	if !decoded {
+		frontmatterValues = goldmarkmeta.Get(parserCtx)
+	}
+	metadata := extractMetadata(frontmatterValues)
 	doc := Document{
-		HTML:     buf.String(),
+		HTML:     embed.Expand(buf.String()),
 		Metadata: metadata,
 		Modified: modTime,
 		Raw:      string(content),
+		Cost:     cost,
 	}
 
+	if transform.Incomplete(parserCtx) {
+		// A diagram timed out or was cancelled; render it again next time.
+		return doc, nil
+	}
 	s.cache.Store(key, cacheEntry{modTime: modTime, doc: doc})
 	return doc, nil
 }
 
+// countBlocks counts the diagrams and highlighted code blocks under root.
+func countBlocks(root ast.Node, cost *Cost) {
+	_ = ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n.(type) {
+		case *transform.D2Block:
+			cost.Diagrams++
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			cost.CodeBlocks++
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
 // Invalidate removes the cached entry for the given path.
 // This should be called when a document is updated or deleted to ensure
 // the next Render call processes the latest content.
@@ -271,8 +458,7 @@ func (s *Service) Invalidate(path string) {
 	s.cache.Delete(cacheKey(path))
 }
 
-func extractMetadata(ctx parser.Context) Metadata {
-	raw := goldmarkmeta.Get(ctx)
+func extractMetadata(raw map[string]any) Metadata {
 	var meta Metadata
 	if raw == nil {
 		return meta
@@ -292,6 +478,20 @@ func extractMetadata(ctx parser.Context) Metadata {
 			}
 		case "tags", "keywords":
 			meta.Tags = toStringSlice(v)
+		case "icon":
+			if str, ok := toString(v); ok {
+				meta.Icon = strings.TrimSpace(str)
+			}
+		case "color", "colour":
+			if str, ok := toString(v); ok {
+				meta.Color = strings.TrimSpace(str)
+			}
+		case "reviewBy", "review_by", "reviewby":
+			if t, ok := toDate(v); ok {
+				meta.ReviewBy = t
+			}
+		case "noindex", "noIndex", "no_index":
+			meta.NoIndex = toBool(v)
 		}
 	}
 
@@ -313,6 +513,39 @@ func toString(v any) (string, bool) {
 	}
 }
 
+// toBool accepts YAML booleans and their common string spellings.
+func toBool(v any) bool {
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	str, ok := toString(v)
+	if !ok {
+		return false
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(str))
+	return err == nil && b
+}
+
+// reviewDateLayouts are the accepted spellings of frontmatter dates.
+var reviewDateLayouts = []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05"}
+
+func toDate(v any) (time.Time, bool) {
+	if t, ok := v.(time.Time); ok {
+		return t, true
+	}
+	str, ok := toString(v)
+	if !ok {
+		return time.Time{}, false
+	}
+	str = strings.TrimSpace(str)
+	for _, layout := range reviewDateLayouts {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
 func toStringSlice(v any) []string {
 	switch vv := v.(type) {
 	case []any:
@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/euforicio/wikimd/internal/renderer"
+	"github.com/euforicio/wikimd/internal/renderer/extlink"
+	"github.com/euforicio/wikimd/internal/renderer/shortcode"
 )
 
 func TestRenderWithMetadataAndMermaid(t *testing.T) {
@@ -18,6 +20,8 @@ func TestRenderWithMetadataAndMermaid(t *testing.T) {
 	content := []byte("---\n" +
 		"title: Example Doc\n" +
 		"description: Sample description\n" +
+		"reviewBy: 2025-03-01\n" +
+		"noindex: true\n" +
 		"tags:\n" +
 		"  - go\n" +
 		"  - wiki\n" +
@@ -51,6 +55,12 @@ func TestRenderWithMetadataAndMermaid(t *testing.T) {
 	if len(doc.Metadata.Tags) != 2 || doc.Metadata.Tags[0] != "go" || doc.Metadata.Tags[1] != "wiki" {
 		t.Fatalf("unexpected tags: %#v", doc.Metadata.Tags)
 	}
+	if want := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC); !doc.Metadata.ReviewBy.Equal(want) {
+		t.Fatalf("expected reviewBy %v, got %v", want, doc.Metadata.ReviewBy)
+	}
+	if !doc.Metadata.NoIndex {
+		t.Fatalf("expected noindex to be set")
+	}
 
 	html := doc.HTML
 	if !strings.Contains(html, `<div class="mermaid">`) {
@@ -73,6 +83,105 @@ func TestRenderWithMetadataAndMermaid(t *testing.T) {
 	}
 }
 
+func TestRenderTOMLAndJSONFrontmatter(t *testing.T) {
+	t.Parallel()
+	svc := renderer.NewService(slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError})))
+
+	docs := map[string]string{
+		"toml.md": "+++\ntitle = \"Hugo Page\"\ntags = [\"go\", \"wiki\"]\nreviewBy = 2025-03-01\n+++\n\n# Body\n",
+		"json.md": ";;;\n{\"title\": \"Hugo Page\", \"tags\": [\"go\", \"wiki\"], \"reviewBy\": \"2025-03-01\"}\n;;;\n\n# Body\n",
+	}
+	for name, content := range docs {
+		doc, err := svc.Render(context.Background(), name, time.Time{}, []byte(content))
+		if err != nil {
+			t.Fatalf("%s: Render returned error: %v", name, err)
+		}
+		if doc.Metadata.Title != "Hugo Page" || len(doc.Metadata.Tags) != 2 {
+			t.Errorf("%s: unexpected metadata %+v", name, doc.Metadata)
+		}
+		if want := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC); !doc.Metadata.ReviewBy.Equal(want) {
+			t.Errorf("%s: expected reviewBy %v, got %v", name, want, doc.Metadata.ReviewBy)
+		}
+		if strings.Contains(doc.HTML, "Hugo Page") || !strings.Contains(doc.HTML, `<h1 id="body">`) {
+			t.Errorf("%s: expected the frontmatter hidden and the body rendered, got %s", name, doc.HTML)
+		}
+		if doc.Raw != content {
+			t.Errorf("%s: Raw should keep the frontmatter", name)
+		}
+	}
+}
+
+func TestRenderMapsShortcodes(t *testing.T) {
+	t.Parallel()
+	svc, err := renderer.NewServiceWithOptions(slog.New(slog.NewTextHandler(io.Discard, nil)), renderer.Options{
+		Shortcodes: shortcode.Options{Mode: shortcode.ModeMap, Map: map[string]string{"hint": shortcode.KindAdmonition}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("{{< hint warning >}}\nMind the **gap**.\n{{< /hint >}}\n\nSee {{< ref \"other.md\" >}}.\n")
+	doc, err := svc.Render(context.Background(), "migrated.md", time.Time{}, content)
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	if !strings.Contains(doc.HTML, "<blockquote>") || !strings.Contains(doc.HTML, "<strong>Warning</strong>") || !strings.Contains(doc.HTML, "<strong>gap</strong>") {
+		t.Errorf("expected the hint as a titled blockquote, got %s", doc.HTML)
+	}
+	if strings.Contains(doc.HTML, "{{") {
+		t.Errorf("expected shortcodes removed, got %s", doc.HTML)
+	}
+}
+
+func TestRenderEmbeds(t *testing.T) {
+	t.Parallel()
+	svc := renderer.NewService(slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError})))
+	content := []byte("{{youtube dQw4w9WgXcQ}}\n\nhttps://vimeo.com/76979871\n\n<https://gist.github.com/octocat/6cad326836d38bd3a7ae>\n\n`{{youtube dQw4w9WgXcQ}}`\n")
+	doc, err := svc.Render(context.Background(), "embeds.md", time.Time{}, content)
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	for _, want := range []string{
+		`src="https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ"`,
+		`src="https://player.vimeo.com/video/76979871?dnt=1"`,
+		`src="https://gist.github.com/octocat/6cad326836d38bd3a7ae.pibb"`,
+		"<code>{{youtube dQw4w9WgXcQ}}</code>",
+	} {
+		if !strings.Contains(doc.HTML, want) {
+			t.Errorf("expected %s in %s", want, doc.HTML)
+		}
+	}
+	if n := strings.Count(doc.HTML, "<iframe"); n != 3 {
+		t.Errorf("expected 3 embeds, got %d", n)
+	}
+}
+
+func TestRenderExternalLinks(t *testing.T) {
+	t.Parallel()
+	svc, err := renderer.NewServiceWithOptions(slog.New(slog.NewTextHandler(io.Discard, nil)), renderer.Options{
+		ExternalLinks: extlink.Options{NewTab: true, Icon: true, Deny: []string{"tracker.example"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("[Go](https://go.dev/doc) and <https://pkg.go.dev> and [*ads*](https://cdn.tracker.example/x) " +
+		"and https://tracker.example/y and [local](other.md) and [top](#top)\n")
+	doc, err := svc.Render(context.Background(), "links.md", time.Time{}, content)
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+	for _, want := range []string{
+		`<a href="https://go.dev/doc" target="_blank" rel="noopener" class="external-link">Go</a>`,
+		`<a href="https://pkg.go.dev" target="_blank" rel="noopener" class="external-link">https://pkg.go.dev</a>`,
+		"<em>ads</em> and https://tracker.example/y and",
+		`<a href="/page/other.md">local</a>`,
+		`<a href="#top">top</a>`,
+	} {
+		if !strings.Contains(doc.HTML, want) {
+			t.Errorf("expected %s in %s", want, doc.HTML)
+		}
+	}
+}
+
 func TestRenderD2Diagram(t *testing.T) {
 	t.Parallel()
 	svc := renderer.NewService(slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError})))
@@ -91,6 +200,47 @@ func TestRenderD2Diagram(t *testing.T) {
 	if !strings.Contains(doc.HTML, "<svg") {
 		t.Fatalf("expected rendered svg output, got %s", doc.HTML)
 	}
+	if c := doc.Cost; c.Diagrams != 1 || c.CodeBlocks != 0 || c.D2 <= 0 || c.Total < c.D2 || c.Bytes != len(content) {
+		t.Fatalf("unexpected render cost %+v", c)
+	}
+}
+
+func TestCostDominant(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		cost renderer.Cost
+		want string
+	}{
+		{renderer.Cost{Total: 900 * time.Millisecond, D2: 800 * time.Millisecond}, renderer.CostD2},
+		{renderer.Cost{Total: 300 * time.Millisecond, D2: 20 * time.Millisecond, Highlight: 250 * time.Millisecond}, renderer.CostHighlight},
+		{renderer.Cost{Total: 300 * time.Millisecond, Highlight: 100 * time.Millisecond}, renderer.CostSize},
+	}
+	for _, tc := range cases {
+		if got := tc.cost.Dominant(); got != tc.want {
+			t.Errorf("%+v: Dominant() = %s, want %s", tc.cost, got, tc.want)
+		}
+	}
+}
+
+func TestRenderD2FenceAttributes(t *testing.T) {
+	t.Parallel()
+	svc := renderer.NewService(slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError})))
+
+	content := []byte("```d2 {theme=neutral sketch=true pad=0}\na -> b\n```\n\n```d2 {colour=red}\na -> b\n```\n")
+	doc, err := svc.Render(context.Background(), "docs/d2-attrs.md", time.Unix(2_600, 0), content)
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+
+	if strings.Count(doc.HTML, `class="d2-block"`) != 2 {
+		t.Fatalf("expected two d2 blocks, got %s", doc.HTML)
+	}
+	if !strings.Contains(doc.HTML, "<svg") {
+		t.Fatalf("expected the first diagram to render, got %s", doc.HTML)
+	}
+	if !strings.Contains(doc.HTML, `unknown d2 fence attribute &#34;colour&#34;`) {
+		t.Fatalf("expected an error for the unknown attribute, got %s", doc.HTML)
+	}
 }
 
 func TestRenderCaching(t *testing.T) {
@@ -125,3 +275,53 @@ func TestRenderCaching(t *testing.T) {
 		t.Fatalf("expected new HTML to include updated content, got %s", doc3.HTML)
 	}
 }
+
+func TestExpandListings(t *testing.T) {
+	t.Parallel()
+	svc := renderer.NewService(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	content := []byte("# Index\n\n{{children}}\n\n{{toc-tree depth=3}}\n\n`{{children}}`\n")
+	doc, err := svc.Render(context.Background(), "docs/index.md", time.Unix(1_000, 0), content)
+	if err != nil {
+		t.Fatalf("Render returned error: %v", err)
+	}
+
+	var depths []int
+	html := renderer.ExpandListings(doc.HTML, func(depth int) []renderer.ListItem {
+		depths = append(depths, depth)
+		return []renderer.ListItem{
+			{Title: "Setup", Href: "/page/docs/setup.md", Description: "Install <steps>"},
+			{Title: "api", Children: []renderer.ListItem{{Title: "Auth", Href: "/page/docs/api/auth.md"}}},
+		}
+	})
+
+	if len(depths) != 2 || depths[0] != 1 || depths[1] != 3 {
+		t.Fatalf("expected directives expanded with depths [1 3], got %v", depths)
+	}
+	for _, want := range []string{
+		`<nav class="page-listing" data-listing="children">`,
+		`<a href="/page/docs/setup.md">Setup</a> — Install &lt;steps&gt;`,
+		`<strong>api</strong><ul><li><a href="/page/docs/api/auth.md">Auth</a>`,
+		`<code>{{children}}</code>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in expanded HTML, got %s", want, html)
+		}
+	}
+}
+
+func TestRenderHeadingIDs(t *testing.T) {
+	t.Parallel()
+	svc := renderer.NewService(slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError})))
+
+	content := []byte("## Setup\n\n## Setup\n\n## Later {#setup-1}\n\n## Custom {#keep-me}\n")
+	doc, err := svc.Render(context.Background(), "ids.md", time.Unix(1_000, 0), content)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	for _, want := range []string{`<h2 id="setup">`, `<h2 id="setup-2">`, `<h2 id="setup-1">`, `<h2 id="keep-me">`} {
+		if !strings.Contains(doc.HTML, want) {
+			t.Errorf("expected %s in %s", want, doc.HTML)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package shortcode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/fuzztest"
+)
+
+func FuzzRewrite(f *testing.F) {
+	f.Add([]byte("{{< hint info >}}\n{{% figure src=\"a.png\" %}}\n{{< /hint >}}"))
+	f.Add([]byte("{% raw %}{{</* x */>}}{% endraw %} `{{< y >}}`\n```\n{{< z >}}\n"))
+	f.Add([]byte("{{< youtube id=\"a\\\"b\" title='t' >}}{{< admonition >}}"))
+	opts := Options{Mode: ModeMap, Map: map[string]string{"figure": KindFigure, "youtube": KindYouTube, "hint": KindAdmonition, "admonition": KindAdmonition}}
+	f.Fuzz(func(t *testing.T, src []byte) {
+		fuzztest.Within(t, 5*time.Second, func() {
+			_, _ = Rewrite(src, opts)
+		})
+	})
+}
@@ -0,0 +1,267 @@
+package shortcode
+
+import (
+	"bytes"
+	"strings"
+)
+
+// args are a shortcode's parameters: key="value" pairs and positional
+// values, either of which may be quoted.
+type args struct {
+	named      map[string]string
+	positional []string
+}
+
+// get returns the named parameter, or the positional one at pos when it is
+// not set; pos < 0 skips the fallback.
+func (a args) get(name string, pos int) string {
+	if v, ok := a.named[name]; ok {
+		return v
+	}
+	if pos >= 0 && pos < len(a.positional) {
+		return a.positional[pos]
+	}
+	return ""
+}
+
+func parseArgs(s string) args {
+	a := args{named: map[string]string{}}
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		var key string
+		if i := strings.IndexAny(s, "= \t\n\"'`"); i > 0 && s[i] == '=' {
+			key, s = s[:i], s[i+1:]
+		}
+		var value string
+		value, s = nextValue(s)
+		if key != "" {
+			a.named[key] = value
+		} else {
+			a.positional = append(a.positional, value)
+		}
+	}
+	return a
+}
+
+// nextValue reads one possibly quoted value from the front of s.
+func nextValue(s string) (value, rest string) {
+	if s == "" {
+		return "", ""
+	}
+	switch q := s[0]; q {
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			switch {
+			case s[i] == '\\' && i+1 < len(s):
+				i++
+				b.WriteByte(s[i])
+			case s[i] == '"':
+				return b.String(), s[i+1:]
+			default:
+				b.WriteByte(s[i])
+			}
+		}
+		return b.String(), ""
+	case '\'', '`':
+		if end := strings.IndexByte(s[1:], q); end >= 0 {
+			return s[1 : end+1], s[end+2:]
+		}
+		return s[1:], ""
+	}
+	if end := strings.IndexAny(s, " \t\n"); end >= 0 {
+		return s[:end], s[end:]
+	}
+	return s, ""
+}
+
+type span struct{ start, end int }
+
+// scan finds the shortcodes and Liquid tags in src outside code.
+func scan(src []byte) []tag {
+	code := codeSpans(src)
+	var tags []tag
+	for i := 0; i < len(src); {
+		next := bytes.IndexByte(src[i:], '{')
+		if next < 0 {
+			break
+		}
+		i += next
+		if c, ok := within(code, i); ok {
+			i = c.end
+			continue
+		}
+		t, ok := parseTag(src, i)
+		if !ok {
+			i++
+			continue
+		}
+		tags = append(tags, t)
+		i = t.end
+	}
+	return tags
+}
+
+func within(spans []span, i int) (span, bool) {
+	for _, s := range spans {
+		if i >= s.start && i < s.end {
+			return s, true
+		}
+	}
+	return span{}, false
+}
+
+// parseTag reads a {{< … >}}, {{% … %}}, or {% … %} tag at src[at:].
+func parseTag(src []byte, at int) (tag, bool) {
+	rest := src[at:]
+	var open, closer string
+	switch {
+	case bytes.HasPrefix(rest, []byte("{{<")):
+		open, closer = "{{<", ">}}"
+	case bytes.HasPrefix(rest, []byte("{{%")):
+		open, closer = "{{%", "%}}"
+	case bytes.HasPrefix(rest, []byte("{%")):
+		open, closer = "{%", "%}"
+	default:
+		return tag{}, false
+	}
+	end := closingDelimiter(rest, len(open), closer)
+	if end < 0 {
+		return tag{}, false
+	}
+	t := tag{start: at, end: at + end + len(closer), liquid: open == "{%"}
+	inner := strings.TrimSpace(string(rest[len(open):end]))
+
+	if t.liquid {
+		inner = strings.TrimSpace(strings.Trim(inner, "-"))
+	} else if strings.HasPrefix(inner, "/*") && strings.HasSuffix(inner, "*/") && len(inner) >= 4 {
+		t.literal = open + " " + strings.TrimSpace(inner[2:len(inner)-2]) + " " + closer
+		return t, true
+	}
+	if !t.liquid && strings.HasPrefix(inner, "/") {
+		t.closing = true
+		inner = strings.TrimSpace(inner[1:])
+	}
+	inner = strings.TrimSpace(strings.TrimSuffix(inner, "/"))
+
+	name, params := inner, ""
+	if i := strings.IndexAny(inner, " \t\r\n"); i >= 0 {
+		name, params = inner[:i], inner[i:]
+	}
+	if !validName(name) {
+		return tag{}, false
+	}
+	if t.liquid && strings.HasPrefix(name, "end") && len(name) > len("end") {
+		t.closing = true
+		name = name[len("end"):]
+	}
+	t.name = name
+	t.args = parseArgs(params)
+	return t, true
+}
+
+// closingDelimiter returns the offset of closer in s after from, skipping
+// quoted strings, or -1. A tag does not span a blank line.
+func closingDelimiter(s []byte, from int, closer string) int {
+	for i := from; i < len(s); i++ {
+		switch c := s[i]; {
+		case bytes.HasPrefix(s[i:], []byte(closer)):
+			return i
+		case c == '"' || c == '\'' || c == '`':
+			end := bytes.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return -1
+			}
+			i += end + 1
+		case bytes.HasPrefix(s[i:], []byte("\n\n")):
+			return -1
+		}
+	}
+	return -1
+}
+
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("_./-", c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// codeSpans returns the fenced code blocks and inline code spans of src, in
+// order.
+func codeSpans(src []byte) []span {
+	var spans []span
+	var fence []byte
+	fenceStart, textStart := 0, 0
+	for offset := 0; offset < len(src); {
+		line := src[offset:]
+		if i := bytes.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i+1]
+		}
+		trimmed := bytes.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+		switch {
+		case fence == nil && indent < 4 && (bytes.HasPrefix(trimmed, []byte("```")) || bytes.HasPrefix(trimmed, []byte("~~~"))):
+			spans = append(spans, inlineCode(src, textStart, offset)...)
+			fence = leadingRun(trimmed)
+			fenceStart = offset
+		case fence != nil && bytes.HasPrefix(bytes.TrimSpace(trimmed), fence) && len(bytes.TrimSpace(trimmed)) == len(leadingRun(trimmed)):
+			spans = append(spans, span{fenceStart, offset + len(line)})
+			fence = nil
+			textStart = offset + len(line)
+		}
+		offset += len(line)
+	}
+	if fence != nil {
+		return append(spans, span{fenceStart, len(src)})
+	}
+	return append(spans, inlineCode(src, textStart, len(src))...)
+}
+
+// inlineCode returns the backtick code spans in src[from:to].
+func inlineCode(src []byte, from, to int) []span {
+	var spans []span
+	for i := from; i < to; {
+		if src[i] != '`' {
+			i++
+			continue
+		}
+		run := len(leadingRun(src[i:to]))
+		closeAt := -1
+		for j := i + run; j < to; {
+			if src[j] != '`' {
+				j++
+				continue
+			}
+			n := len(leadingRun(src[j:to]))
+			if n == run {
+				closeAt = j
+				break
+			}
+			j += n
+		}
+		if closeAt < 0 {
+			i += run
+			continue
+		}
+		spans = append(spans, span{i, closeAt + run})
+		i = closeAt + run
+	}
+	return spans
+}
+
+// leadingRun returns the run of s[0] that s starts with.
+func leadingRun(s []byte) []byte {
+	if len(s) == 0 {
+		return nil
+	}
+	n := 1
+	for n < len(s) && s[n] == s[0] {
+		n++
+	}
+	return s[:n]
+}
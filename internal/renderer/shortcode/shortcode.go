@@ -0,0 +1,271 @@
+// Package shortcode handles Hugo shortcodes and Jekyll Liquid tags left in
+// migrated content, which would otherwise render as literal text.
+package shortcode
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Mode selects what Rewrite does with shortcodes.
+type Mode string
+
+// Modes accepted by Rewrite.
+const (
+	// ModeOff leaves shortcodes in the page as text.
+	ModeOff Mode = "off"
+	// ModeStrip removes shortcode tags. Paired shortcodes keep their inner
+	// content.
+	ModeStrip Mode = "strip"
+	// ModeWarn strips like ModeStrip and reports what it removed.
+	ModeWarn Mode = "warn"
+	// ModeMap turns mapped shortcodes into markdown and strips the rest,
+	// reporting them.
+	ModeMap Mode = "map"
+)
+
+// Kinds of markdown a shortcode can be mapped to.
+const (
+	KindFigure     = "figure"
+	KindYouTube    = "youtube"
+	KindAdmonition = "admonition"
+)
+
+// DefaultMap maps each shortcode of the same name.
+var DefaultMap = []string{KindFigure, KindYouTube, KindAdmonition}
+
+// Options configure Rewrite.
+type Options struct {
+	Mode Mode
+	// Map names the shortcodes ModeMap converts, each to one of the kinds.
+	Map map[string]string
+}
+
+// ParseMode validates a mode name; "" is ModeOff.
+func ParseMode(name string) (Mode, error) {
+	switch m := Mode(strings.ToLower(strings.TrimSpace(name))); m {
+	case "":
+		return ModeOff, nil
+	case ModeOff, ModeStrip, ModeWarn, ModeMap:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid shortcode mode %q (want off, strip, warn, or map)", name)
+	}
+}
+
+// ParseMap reads entries of the form "name" or "name=kind", such as
+// "figure" or "hint=admonition". A bare name must itself be a kind.
+func ParseMap(entries []string) (map[string]string, error) {
+	out := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		name, kind, found := strings.Cut(strings.TrimSpace(entry), "=")
+		name = strings.TrimSpace(name)
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		if !found {
+			kind = strings.ToLower(name)
+		}
+		if name == "" {
+			continue
+		}
+		switch kind {
+		case KindFigure, KindYouTube, KindAdmonition:
+			out[name] = kind
+		default:
+			return nil, fmt.Errorf("invalid shortcode mapping %q (map to figure, youtube, or admonition)", entry)
+		}
+	}
+	return out, nil
+}
+
+// Rewrite applies opts to the shortcodes in source, leaving fenced and
+// inline code alone. It returns the rewritten source and the sorted names of
+// shortcodes it removed without mapping. Hugo's escaped form, {{</* name */>}},
+// becomes the literal shortcode in every mode but ModeOff.
+func Rewrite(source []byte, opts Options) ([]byte, []string) {
+	if opts.Mode == ModeOff || opts.Mode == "" {
+		return source, nil
+	}
+	tags := scan(source)
+	if len(tags) == 0 {
+		return source, nil
+	}
+	r := &rewriter{src: source, opts: opts, removed: map[string]bool{}}
+	var out bytes.Buffer
+	r.write(&out, 0, len(source), tags)
+
+	removed := make([]string, 0, len(r.removed))
+	for name := range r.removed {
+		removed = append(removed, name)
+	}
+	sort.Strings(removed)
+	return out.Bytes(), removed
+}
+
+// tag is one shortcode or Liquid tag in the source.
+type tag struct {
+	start, end int
+	liquid     bool
+	literal    string // set for escaped shortcodes
+	name       string
+	closing    bool
+	args       args
+}
+
+type rewriter struct {
+	src     []byte
+	opts    Options
+	removed map[string]bool
+}
+
+// write copies src[from:to] to out, rewriting tags, which lie in that range.
+func (r *rewriter) write(out *bytes.Buffer, from, to int, tags []tag) {
+	pos := from
+	for k := 0; k < len(tags); k++ {
+		t := tags[k]
+		out.Write(r.src[pos:t.start])
+		pos = t.end
+
+		switch {
+		case t.literal != "":
+			out.WriteString(t.literal)
+		case t.closing:
+			// Closing tags are consumed with their opening tag; a stray one
+			// is dropped.
+		case t.liquid && t.name == "raw":
+			if m := matching(tags, k); m > 0 {
+				out.Write(r.src[t.end:tags[m].start])
+				k, pos = m, tags[m].end
+			}
+		case r.kind(t) == KindFigure:
+			out.WriteString(figure(t.args))
+		case r.kind(t) == KindYouTube:
+			out.WriteString(youtube(t.args))
+		case r.kind(t) == KindAdmonition:
+			m := matching(tags, k)
+			if m < 0 {
+				r.removed[t.name] = true
+				continue
+			}
+			var inner bytes.Buffer
+			r.write(&inner, t.end, tags[m].start, tags[k+1:m])
+			admonition(out, t.args, inner.Bytes())
+			k, pos = m, tags[m].end
+		default:
+			r.removed[t.name] = true
+		}
+	}
+	out.Write(r.src[pos:to])
+}
+
+func (r *rewriter) kind(t tag) string {
+	if r.opts.Mode != ModeMap || t.liquid {
+		return ""
+	}
+	return r.opts.Map[t.name]
+}
+
+// matching returns the index of the tag closing tags[k], or -1.
+func matching(tags []tag, k int) int {
+	open := tags[k]
+	depth := 0
+	for i := k + 1; i < len(tags); i++ {
+		t := tags[i]
+		if t.liquid != open.liquid || t.name != open.name || t.literal != "" {
+			continue
+		}
+		if !t.closing {
+			depth++
+			continue
+		}
+		if depth == 0 {
+			return i
+		}
+		depth--
+	}
+	return -1
+}
+
+// figure renders a figure shortcode as a markdown image, linked when the
+// shortcode has a link, with its caption on the line below.
+func figure(a args) string {
+	caption := a.get("caption", -1)
+	title := a.get("title", -1)
+	alt := firstNonEmpty(a.get("alt", -1), caption, title)
+	image := "![" + escapeText(alt) + "](" + destination(a.get("src", 0))
+	if title != "" {
+		image += ` "` + strings.ReplaceAll(title, `"`, `\"`) + `"`
+	}
+	image += ")"
+	if link := a.get("link", -1); link != "" {
+		image = "[" + image + "](" + destination(link) + ")"
+	}
+	if caption != "" && caption != alt {
+		image += "\n*" + caption + "*"
+	}
+	return image
+}
+
+// youtube renders a youtube shortcode as a link to the video.
+func youtube(a args) string {
+	id := a.get("id", 0)
+	if id == "" {
+		return ""
+	}
+	label := firstNonEmpty(a.get("title", -1), "YouTube video")
+	return "[▶ " + escapeText(label) + "](https://www.youtube.com/watch?v=" + url.QueryEscape(id) + ")"
+}
+
+// admonition writes a paired admonition shortcode as a blockquote opened by
+// its title in bold. The type comes from type=, the first argument, or, as
+// in Docsy's alert, color=.
+func admonition(out *bytes.Buffer, a args, inner []byte) {
+	kind := firstNonEmpty(a.get("type", 0), a.get("color", -1), "note")
+	title := firstNonEmpty(a.get("title", 1), strings.ToUpper(kind[:1])+kind[1:])
+
+	if out.Len() > 0 && !bytes.HasSuffix(out.Bytes(), []byte("\n\n")) {
+		if !bytes.HasSuffix(out.Bytes(), []byte("\n")) {
+			out.WriteByte('\n')
+		}
+		out.WriteByte('\n')
+	}
+	out.WriteString("> **" + escapeText(title) + "**\n")
+	body := strings.Trim(string(inner), "\n")
+	if strings.TrimSpace(body) != "" {
+		out.WriteString(">\n")
+		for _, line := range strings.Split(body, "\n") {
+			if strings.TrimSpace(line) == "" {
+				out.WriteString(">\n")
+				continue
+			}
+			out.WriteString("> " + line + "\n")
+		}
+	}
+	out.WriteByte('\n')
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`, `*`, `\*`, `_`, `\_`)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// destination wraps a link destination in angle brackets when markdown
+// would otherwise end it early.
+func destination(dest string) string {
+	if strings.ContainsAny(dest, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(dest) + ">"
+	}
+	return dest
+}
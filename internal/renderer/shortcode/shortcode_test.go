@@ -0,0 +1,111 @@
+package shortcode
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRewrite(t *testing.T) {
+	t.Parallel()
+	mapped := map[string]string{"figure": KindFigure, "youtube": KindYouTube, "hint": KindAdmonition, "admonition": KindAdmonition}
+	cases := []struct {
+		name, src, want string
+		mode            Mode
+		removed         []string
+	}{
+		{
+			name: "off leaves text alone",
+			mode: ModeOff,
+			src:  "{{< youtube abc >}}\n",
+			want: "{{< youtube abc >}}\n",
+		},
+		{
+			name:    "strip keeps paired content",
+			mode:    ModeStrip,
+			src:     "Before {{< ref \"a.md\" >}} after.\n\n{{% hint info %}}\nInner **text**.\n{{% /hint %}}\n",
+			want:    "Before  after.\n\n\nInner **text**.\n\n",
+			removed: []string{"hint", "ref"},
+		},
+		{
+			name:    "strip removes Liquid tags but keeps raw blocks",
+			mode:    ModeWarn,
+			src:     "{% include note.html %}\n{% raw %}{{< youtube x >}}{% endraw %}\n",
+			want:    "\n{{< youtube x >}}\n",
+			removed: []string{"include"},
+		},
+		{
+			name: "code is left alone",
+			mode: ModeStrip,
+			src:  "`{{< ref x >}}` and\n\n```\n{{< ref y >}}\n```\n",
+			want: "`{{< ref x >}}` and\n\n```\n{{< ref y >}}\n```\n",
+		},
+		{
+			name: "escaped shortcodes become literal",
+			mode: ModeStrip,
+			src:  "Write {{</* figure src=\"x.png\" */>}} to embed.\n",
+			want: "Write {{< figure src=\"x.png\" >}} to embed.\n",
+		},
+		{
+			name: "figure maps to an image",
+			mode: ModeMap,
+			src:  "{{< figure src=\"/img/a b.png\" title=\"Diagram\" caption=\"The flow\" link=\"https://example.com\" >}}\n",
+			want: "[![The flow](</img/a b.png> \"Diagram\")](https://example.com)\n",
+		},
+		{
+			name: "figure caption goes below when alt differs",
+			mode: ModeMap,
+			src:  "{{< figure src=\"a.png\" alt=\"A\" caption=\"Seen from above\" />}}\n",
+			want: "![A](a.png)\n*Seen from above*\n",
+		},
+		{
+			name: "youtube maps to a link",
+			mode: ModeMap,
+			src:  "{{< youtube id=\"dQw4w9WgXcQ\" title=\"Demo [v2]\" >}}\n",
+			want: "[▶ Demo \\[v2\\]](https://www.youtube.com/watch?v=dQw4w9WgXcQ)\n",
+		},
+		{
+			name:    "admonitions map to blockquotes and unmapped ones are stripped",
+			mode:    ModeMap,
+			src:     "Intro\n{{< hint warning >}}\nCareful.\n\nVery {{< ref x >}}careful.\n{{< /hint >}}\nAfter\n",
+			want:    "Intro\n\n> **Warning**\n>\n> Careful.\n>\n> Very careful.\n\n\nAfter\n",
+			removed: []string{"ref"},
+		},
+		{
+			name: "admonition titles",
+			mode: ModeMap,
+			src:  "{{< admonition tip \"Pro tip\" >}}Use it.{{< /admonition >}}",
+			want: "> **Pro tip**\n>\n> Use it.\n\n",
+		},
+	}
+	for _, tc := range cases {
+		got, removed := Rewrite([]byte(tc.src), Options{Mode: tc.mode, Map: mapped})
+		if string(got) != tc.want {
+			t.Errorf("%s:\n got %q\nwant %q", tc.name, got, tc.want)
+		}
+		if len(removed) != 0 || len(tc.removed) != 0 {
+			if !reflect.DeepEqual(removed, tc.removed) {
+				t.Errorf("%s: removed %v, want %v", tc.name, removed, tc.removed)
+			}
+		}
+	}
+}
+
+func TestParseMap(t *testing.T) {
+	t.Parallel()
+	got, err := ParseMap([]string{"figure", "hint=admonition", " notice = Admonition "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"figure": KindFigure, "hint": KindAdmonition, "notice": KindAdmonition}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseMap = %v, want %v", got, want)
+	}
+	for _, bad := range []string{"tabs", "gist=embed"} {
+		if _, err := ParseMap([]string{bad}); err == nil {
+			t.Errorf("ParseMap(%q) should fail", bad)
+		}
+	}
+	if _, err := ParseMode("loud"); err == nil {
+		t.Error("ParseMode should reject unknown modes")
+	}
+}
@@ -0,0 +1,101 @@
+package renderer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxExcerpt bounds the length of a summary excerpt, in runes.
+const maxExcerpt = 300
+
+// Summary is a short preview of a rendered document.
+type Summary struct {
+	// Excerpt is the text of the first paragraph with any text, shortened
+	// at a word boundary.
+	Excerpt string
+	// Image is the src of the first image, usually a /media/ path.
+	Image string
+}
+
+// Summarize returns the excerpt and lead image of rendered HTML. Listings
+// and embeds are skipped.
+func Summarize(rendered string) Summary {
+	var sum Summary
+	var text strings.Builder
+	inParagraph, skipDepth := false, 0
+	z := html.NewTokenizer(strings.NewReader(rendered))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return sum
+		}
+		tok := z.Token()
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch {
+			case tok.DataAtom == atom.Nav || tok.DataAtom == atom.Iframe || tok.DataAtom == atom.Svg:
+				if tt == html.StartTagToken {
+					skipDepth++
+				}
+			case tok.DataAtom == atom.Img && sum.Image == "" && skipDepth == 0:
+				sum.Image = attrValue(tok, "src")
+			case tok.DataAtom == atom.P && sum.Excerpt == "" && skipDepth == 0:
+				inParagraph = true
+				text.Reset()
+			}
+		case html.EndTagToken:
+			switch tok.DataAtom {
+			case atom.Nav, atom.Iframe, atom.Svg:
+				skipDepth = max(skipDepth-1, 0)
+			case atom.P:
+				if inParagraph {
+					inParagraph = false
+					// A listing directive is expanded only when served.
+					if !listingDirective.MatchString("<p>" + strings.TrimSpace(text.String()) + "</p>") {
+						sum.Excerpt = excerpt(text.String())
+					}
+				}
+			}
+		case html.TextToken:
+			if inParagraph {
+				text.WriteString(tok.Data)
+			}
+		}
+		if sum.Excerpt != "" && sum.Image != "" {
+			return sum
+		}
+	}
+}
+
+// excerpt collapses whitespace in s and cuts it to maxExcerpt runes at a
+// word boundary, marking the cut with an ellipsis.
+func excerpt(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= maxExcerpt {
+		return s
+	}
+	runes := []rune(s)[:maxExcerpt]
+	cut := len(runes)
+	for i := len(runes) - 1; i > maxExcerpt/2; i-- {
+		if unicode.IsSpace(runes[i]) {
+			cut = i
+			break
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + "…"
+}
+
+func attrValue(tok html.Token, key string) string {
+	for _, a := range tok.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
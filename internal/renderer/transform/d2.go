@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"html"
 	"log/slog"
+	"strconv"
 	"strings"
 	"time"
 
@@ -36,20 +38,46 @@ func NewD2Transformer(renderer *d2renderer.Renderer, logger *slog.Logger) parser
 	}
 }
 
+var (
+	renderContextKey = parser.NewContextKey()
+	incompleteKey    = parser.NewContextKey()
+	d2TimeKey        = parser.NewContextKey()
+)
+
+// WithContext makes ctx the context diagrams in the document render under,
+// so they stop when the request that wanted them goes away.
+func WithContext(pc parser.Context, ctx context.Context) {
+	pc.Set(renderContextKey, ctx)
+}
+
+// Incomplete reports whether a diagram in the document gave up because its
+// render timed out or was cancelled; such output should not be cached.
+func Incomplete(pc parser.Context) bool {
+	incomplete, _ := pc.Get(incompleteKey).(bool)
+	return incomplete
+}
+
+// D2Time reports how long the diagrams in the document took, including time
+// spent waiting for a render slot.
+func D2Time(pc parser.Context) time.Duration {
+	elapsed, _ := pc.Get(d2TimeKey).(time.Duration)
+	return elapsed
+}
+
 // Transform implements parser.ASTTransformer.
-func (t *D2Transformer) Transform(node *ast.Document, reader text.Reader, _ parser.Context) {
+func (t *D2Transformer) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
 	if t.renderer == nil || node == nil {
 		return
 	}
-	t.walk(node, reader)
+	t.walk(node, reader, pc)
 }
 
-func (t *D2Transformer) walk(parent ast.Node, reader text.Reader) {
+func (t *D2Transformer) walk(parent ast.Node, reader text.Reader, pc parser.Context) {
 	for child := parent.FirstChild(); child != nil; {
 		next := child.NextSibling()
 
 		if block, ok := child.(*ast.FencedCodeBlock); ok && isD2Block(block, reader.Source()) {
-			replacement := t.renderBlock(block, reader)
+			replacement := t.renderBlock(block, reader, pc)
 			replacement.SetBlankPreviousLines(block.HasBlankPreviousLines())
 			copyAttributes(block, replacement)
 			parent.ReplaceChild(parent, block, replacement)
@@ -58,15 +86,28 @@ func (t *D2Transformer) walk(parent ast.Node, reader text.Reader) {
 		}
 
 		if child.HasChildren() {
-			t.walk(child, reader)
+			t.walk(child, reader, pc)
 		}
 		child = next
 	}
 }
 
-func (t *D2Transformer) renderBlock(block *ast.FencedCodeBlock, reader text.Reader) *D2Block {
+func (t *D2Transformer) renderBlock(block *ast.FencedCodeBlock, reader text.Reader, pc parser.Context) *D2Block {
+	ctx, _ := pc.Get(renderContextKey).(context.Context)
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	source := blockSource(block, reader)
-	result, err := t.renderer.Render(context.Background(), source)
+	settings, err := fenceSettings(block, reader.Source())
+	var result d2renderer.Result
+	if err == nil {
+		started := time.Now()
+		result, err = t.renderer.Render(ctx, source, settings)
+		pc.Set(d2TimeKey, D2Time(pc)+time.Since(started))
+	}
+	if errors.Is(err, d2renderer.ErrTimeout) || errors.Is(err, context.Canceled) {
+		pc.Set(incompleteKey, true)
+	}
 	if err != nil {
 		if t.logger != nil {
 			t.logger.Warn("d2: render failed", "err", err)
@@ -88,6 +129,85 @@ func isD2Block(block *ast.FencedCodeBlock, source []byte) bool {
 	return strings.EqualFold(lang, d2Language)
 }
 
+// fenceSettings reads per-diagram options from the fence info string after
+// the language, as in ```d2 {theme=neutral layout=elk sketch=true pad=20}.
+// The braces are optional and values may be quoted.
+func fenceSettings(block *ast.FencedCodeBlock, source []byte) (d2renderer.Settings, error) {
+	var settings d2renderer.Settings
+	if block.Info == nil {
+		return settings, nil
+	}
+	info := strings.TrimSpace(string(block.Info.Segment.Value(source)))
+	if i := strings.IndexAny(info, " \t{"); i >= 0 {
+		info = info[i:]
+	} else {
+		return settings, nil
+	}
+	info = strings.TrimSpace(info)
+	info = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(info, "{"), "}"))
+
+	for _, field := range splitAttributes(info) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return settings, fmt.Errorf("d2 fence attribute %q needs a value", field)
+		}
+		value = strings.Trim(value, `"'`)
+		switch strings.ToLower(key) {
+		case "theme":
+			settings.Theme = value
+		case "dark-theme", "dark_theme", "darktheme":
+			settings.DarkTheme = value
+		case "layout":
+			settings.Layout = value
+		case "sketch":
+			sketch, err := strconv.ParseBool(value)
+			if err != nil {
+				return settings, fmt.Errorf("d2 fence attribute sketch=%q is not a boolean", value)
+			}
+			settings.Sketch = &sketch
+		case "pad", "padding":
+			pad, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || pad < 0 {
+				return settings, fmt.Errorf("d2 fence attribute %s=%q is not a pixel count", key, value)
+			}
+			settings.Pad = &pad
+		default:
+			return settings, fmt.Errorf("unknown d2 fence attribute %q", key)
+		}
+	}
+	return settings, nil
+}
+
+// splitAttributes splits on whitespace outside quotes.
+func splitAttributes(s string) []string {
+	var fields []string
+	var b strings.Builder
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+			b.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			b.WriteRune(r)
+		case r == ' ' || r == '\t' || r == ',':
+			if b.Len() > 0 {
+				fields = append(fields, b.String())
+				b.Reset()
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() > 0 {
+		fields = append(fields, b.String())
+	}
+	return fields
+}
+
 func blockSource(block *ast.FencedCodeBlock, reader text.Reader) string {
 	var buf bytes.Buffer
 	for i := 0; i < block.Lines().Len(); i++ {
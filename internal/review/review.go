@@ -0,0 +1,98 @@
+// Package review tracks pages whose reviewBy frontmatter date has passed.
+package review
+
+import (
+	"sort"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+	"github.com/euforicio/wikimd/internal/renderer"
+)
+
+// EventOverdue is the webhook event type fired when a page becomes overdue.
+const EventOverdue = "review.overdue"
+
+// Item describes a page that is past its review date.
+type Item struct {
+	ReviewBy    time.Time `json:"reviewBy"`
+	Path        string    `json:"path"`
+	Title       string    `json:"title"`
+	DaysOverdue int       `json:"daysOverdue"`
+}
+
+// IsOverdue reports whether meta carries a review date that is before now.
+// A date-only value stays current for the whole of that day.
+func IsOverdue(meta *renderer.Metadata, now time.Time) bool {
+	if meta == nil || meta.ReviewBy.IsZero() {
+		return false
+	}
+	return now.After(deadline(meta.ReviewBy))
+}
+
+// Overdue walks root and returns the overdue pages, oldest review date first.
+func Overdue(root *tree.Node, now time.Time) []Item {
+	var items []Item
+	var walk func(*tree.Node)
+	walk = func(n *tree.Node) {
+		if n == nil {
+			return
+		}
+		if n.Type == tree.NodeTypeFile && IsOverdue(n.Metadata, now) {
+			items = append(items, Item{
+				ReviewBy:    n.Metadata.ReviewBy,
+				Path:        n.RelativePath,
+				Title:       n.Title,
+				DaysOverdue: int(now.Sub(deadline(n.Metadata.ReviewBy)).Hours()/24) + 1,
+			})
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].ReviewBy.Equal(items[j].ReviewBy) {
+			return items[i].ReviewBy.Before(items[j].ReviewBy)
+		}
+		return items[i].Path < items[j].Path
+	})
+	return items
+}
+
+// deadline is the instant a review date lapses: the end of the day for
+// date-only values, the exact time otherwise.
+func deadline(t time.Time) time.Time {
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+		return t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// Tracker remembers which overdue pages have already been announced so each
+// lapse is reported once. A page whose review date moves forward and later
+// lapses again is reported again.
+type Tracker struct {
+	seen map[string]time.Time
+}
+
+// NewTracker returns an empty Tracker.
+func NewTracker() *Tracker {
+	return &Tracker{seen: make(map[string]time.Time)}
+}
+
+// Newly returns the items not reported by a previous call and forgets pages
+// that are no longer overdue.
+func (t *Tracker) Newly(items []Item) []Item {
+	current := make(map[string]time.Time, len(items))
+	var fresh []Item
+	for _, item := range items {
+		current[item.Path] = item.ReviewBy
+		if prev, ok := t.seen[item.Path]; ok && prev.Equal(item.ReviewBy) {
+			continue
+		}
+		fresh = append(fresh, item)
+	}
+	t.seen = current
+	return fresh
+}
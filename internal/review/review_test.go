@@ -0,0 +1,70 @@
+package review
+
+import (
+	"testing"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+	"github.com/euforicio/wikimd/internal/renderer"
+)
+
+func page(path string, reviewBy time.Time) *tree.Node {
+	return &tree.Node{
+		Type:         tree.NodeTypeFile,
+		RelativePath: path,
+		Title:        path,
+		Metadata:     &renderer.Metadata{ReviewBy: reviewBy},
+	}
+}
+
+func TestOverdue(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	root := &tree.Node{
+		Type: tree.NodeTypeDirectory,
+		Children: []*tree.Node{
+			page("today.md", time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)),
+			page("future.md", time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)),
+			{Type: tree.NodeTypeFile, RelativePath: "plain.md"},
+			{
+				Type: tree.NodeTypeDirectory,
+				Children: []*tree.Node{
+					page("ops/old.md", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)),
+					page("ops/yesterday.md", time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC)),
+				},
+			},
+		},
+	}
+
+	items := Overdue(root, now)
+	if len(items) != 2 {
+		t.Fatalf("expected 2 overdue pages, got %+v", items)
+	}
+	if items[0].Path != "ops/old.md" || items[1].Path != "ops/yesterday.md" {
+		t.Fatalf("expected oldest first, got %s, %s", items[0].Path, items[1].Path)
+	}
+	if items[1].DaysOverdue != 1 {
+		t.Errorf("expected yesterday's review to be 1 day overdue, got %d", items[1].DaysOverdue)
+	}
+}
+
+func TestTrackerReportsEachLapseOnce(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewTracker()
+	first := []Item{{Path: "a.md", ReviewBy: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}}
+	if got := tracker.Newly(first); len(got) != 1 {
+		t.Fatalf("expected first lapse to be reported, got %+v", got)
+	}
+	if got := tracker.Newly(first); len(got) != 0 {
+		t.Fatalf("expected repeat check to be quiet, got %+v", got)
+	}
+
+	// Reviewed (no longer overdue), then lapsed again with a new date.
+	tracker.Newly(nil)
+	again := []Item{{Path: "a.md", ReviewBy: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)}}
+	if got := tracker.Newly(again); len(got) != 1 {
+		t.Fatalf("expected new lapse to be reported, got %+v", got)
+	}
+}
@@ -0,0 +1,70 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// Backend runs full-text queries over the wiki. Backends that keep an index
+// build it in Index and refresh it from Invalidate; stateless backends treat
+// both as no-ops.
+type Backend interface {
+	// Search returns matches for query. Globs in opts are relative to the
+	// wiki root and use doublestar syntax ("archive/**").
+	Search(ctx context.Context, query string, opts Options) ([]Result, error)
+	// Index prepares the backend for searching. It runs once at startup.
+	Index(ctx context.Context) error
+	// Invalidate reports wiki-relative paths whose contents changed or were
+	// removed since the last Index.
+	Invalidate(paths ...string)
+}
+
+// BackendRipgrep searches by running ripgrep over the files on disk.
+const BackendRipgrep = "ripgrep"
+
+// DefaultBackend is used when no backend is configured.
+const DefaultBackend = BackendRipgrep
+
+// BackendOptions configures the backends New constructs; each reads the
+// fields that concern it.
+type BackendOptions struct {
+	// RipgrepPath is the rg executable, looked up on PATH when empty.
+	RipgrepPath string
+	// RipgrepArgs are extra flags for every rg run, such as --threads=2 or
+	// --max-filesize=1M. Values must be joined to their flags with "=".
+	RipgrepArgs []string
+}
+
+// backends maps configurable backend names to constructors.
+var backends = map[string]func(root string, logger *slog.Logger, opts BackendOptions) (Backend, error){
+	BackendRipgrep: func(root string, logger *slog.Logger, opts BackendOptions) (Backend, error) {
+		return NewService(root, logger, opts)
+	},
+}
+
+// Backends lists the names accepted by New.
+func Backends() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New constructs the named backend for root. An empty name selects
+// DefaultBackend.
+func New(name, root string, logger *slog.Logger, opts BackendOptions) (Backend, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = DefaultBackend
+	}
+	newBackend, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown search backend %q (want one of %s)", name, strings.Join(Backends(), ", "))
+	}
+	return newBackend(root, logger, opts)
+}
@@ -0,0 +1,125 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// cacheTTL is how long results are reused. Invalidate drops them
+	// sooner when pages change; the TTL covers edits the watcher misses.
+	cacheTTL = 5 * time.Second
+	// maxCachedSearches bounds the cache; the entry closest to expiry
+	// makes room for a new one.
+	maxCachedSearches = 256
+)
+
+// resultCache shares one ripgrep run among identical concurrent searches
+// and keeps its results for cacheTTL.
+type resultCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedResults
+	// gen counts clears. A search started before the latest clear may have
+	// read old files, so it neither stores its results nor is joined.
+	gen    uint64
+	flight singleflight.Group
+	now    func() time.Time
+}
+
+type cachedResults struct {
+	expires time.Time
+	results []Result
+}
+
+func newResultCache() *resultCache {
+	return &resultCache{entries: make(map[string]cachedResults), now: time.Now}
+}
+
+// cacheKey identifies a search by its query and options.
+func cacheKey(query string, opts Options) string {
+	raw, _ := json.Marshal(struct {
+		Query   string
+		Options Options
+	}{query, opts})
+	return string(raw)
+}
+
+// do returns the cached results for key, or runs search, joining a run of
+// the same search already in flight. The shared run keeps going when the
+// caller that started it gives up, so the others still get their results;
+// it stops at that caller's deadline. Callers get their own copy of the
+// results.
+func (c *resultCache) do(ctx context.Context, key string, search func(context.Context) ([]Result, error)) ([]Result, error) {
+	c.mu.Lock()
+	gen := c.gen
+	hit, ok := c.entries[key]
+	if ok && c.now().After(hit.expires) {
+		delete(c.entries, key)
+		ok = false
+	}
+	c.mu.Unlock()
+	if ok {
+		return slices.Clone(hit.results), nil
+	}
+
+	ch := c.flight.DoChan(strconv.FormatUint(gen, 10)+"\x00"+key, func() (any, error) {
+		runCtx := context.WithoutCancel(ctx)
+		if deadline, ok := ctx.Deadline(); ok {
+			var cancel context.CancelFunc
+			runCtx, cancel = context.WithDeadline(runCtx, deadline)
+			defer cancel()
+		}
+		results, err := search(runCtx)
+		if err == nil {
+			c.put(gen, key, results)
+		}
+		return results, err
+	})
+	select {
+	case <-ctx.Done():
+		return nil, contextError(ctx)
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		results, _ := res.Val.([]Result)
+		return slices.Clone(results), nil
+	}
+}
+
+func (c *resultCache) put(gen uint64, key string, results []Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	now := c.now()
+	if len(c.entries) >= maxCachedSearches {
+		oldest := ""
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			} else if oldest == "" || e.expires.Before(c.entries[oldest].expires) {
+				oldest = k
+			}
+		}
+		if len(c.entries) >= maxCachedSearches {
+			delete(c.entries, oldest)
+		}
+	}
+	c.entries[key] = cachedResults{expires: now.Add(cacheTTL), results: results}
+}
+
+// clear drops every cached result and detaches searches in flight.
+func (c *resultCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	clear(c.entries)
+}
@@ -0,0 +1,56 @@
+package search
+
+import (
+	"sort"
+	"strings"
+)
+
+// PageResult aggregates every match in one document.
+type PageResult struct {
+	Path  string `json:"path"`
+	Title string `json:"title,omitempty"`
+	// Best is the most representative match, shown as the page's snippet.
+	Best  Result `json:"best"`
+	Count int    `json:"count"`
+}
+
+// GroupByPage folds line matches into one entry per document, ordered by
+// match count and then path. Result order within a document is preserved, so
+// ties for the best snippet go to the earliest line.
+func GroupByPage(results []Result) []PageResult {
+	index := make(map[string]int)
+	var pages []PageResult
+	for _, res := range results {
+		i, ok := index[res.Path]
+		if !ok {
+			index[res.Path] = len(pages)
+			pages = append(pages, PageResult{Path: res.Path, Best: res, Count: 1})
+			continue
+		}
+		pages[i].Count++
+		if snippetScore(res) > snippetScore(pages[i].Best) {
+			pages[i].Best = res
+		}
+	}
+	sort.SliceStable(pages, func(i, j int) bool {
+		if pages[i].Count != pages[j].Count {
+			return pages[i].Count > pages[j].Count
+		}
+		return pages[i].Path < pages[j].Path
+	})
+	return pages
+}
+
+// snippetScore ranks a match as a page snippet: headings describe the page
+// best, and frontmatter titles come next. Everything else ties.
+func snippetScore(res Result) int {
+	line := strings.TrimSpace(res.LineText)
+	switch {
+	case strings.HasPrefix(line, "#"):
+		return 2
+	case strings.HasPrefix(line, "title:"):
+		return 1
+	default:
+		return 0
+	}
+}
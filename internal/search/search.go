@@ -12,10 +12,18 @@ import (
 	"log/slog"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// DefaultTimeout bounds a search when the caller sets no deadline of its own.
+const DefaultTimeout = 10 * time.Second
+
+// ErrTimeout is returned when a search runs past its context's deadline.
+var ErrTimeout = errors.New("search timed out")
+
 // Options controls the behavior of the ripgrep search.
 type Options struct {
 	IncludeGlobs  []string
@@ -42,14 +50,19 @@ type LineSnippet struct {
 	Line int    `json:"line"`
 }
 
-// Service executes ripgrep searches rooted at the repository.
+// Service executes ripgrep searches rooted at the repository. It is the
+// BackendRipgrep implementation of Backend.
 type Service struct {
 	logger *slog.Logger
 	root   string
+	rg     string
+	args   []string
+	cache  *resultCache
 }
 
-// NewService constructs a ripgrep-backed search service.
-func NewService(root string, logger *slog.Logger) (*Service, error) {
+// NewService constructs a ripgrep-backed search service. opts.RipgrepPath
+// and opts.RipgrepArgs choose the rg executable and flags every run gets.
+func NewService(root string, logger *slog.Logger, opts BackendOptions) (*Service, error) {
 	if root == "" {
 		return nil, errors.New("root is required")
 	}
@@ -61,22 +74,79 @@ func NewService(root string, logger *slog.Logger) (*Service, error) {
 		logger = slog.Default()
 	}
 
-	if _, err := exec.LookPath("rg"); err != nil {
-		return nil, fmt.Errorf("ripgrep executable not found in PATH: %w", err)
+	rg, err := ripgrepPath(opts.RipgrepPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, arg := range opts.RipgrepArgs {
+		if !strings.HasPrefix(arg, "-") {
+			return nil, fmt.Errorf("invalid ripgrep argument %q: give flags with their values, as --max-filesize=1M", arg)
+		}
 	}
 
-	return &Service{root: abs, logger: logger.With("component", "search")}, nil
+	return &Service{
+		root:   abs,
+		rg:     rg,
+		args:   slices.Clone(opts.RipgrepArgs),
+		cache:  newResultCache(),
+		logger: logger.With("component", "search"),
+	}, nil
 }
 
-// Search executes ripgrep with the provided query and options.
-//
-//nolint:gocognit,gocyclo // ripgrep argument building requires option handling
+// ripgrepPath resolves the rg executable: path when set, made absolute
+// because searches run with the wiki root as working directory, or else rg
+// on PATH.
+func ripgrepPath(path string) (string, error) {
+	if path == "" {
+		rg, err := exec.LookPath("rg")
+		if err != nil {
+			return "", fmt.Errorf("ripgrep executable not found in PATH: %w", err)
+		}
+		return rg, nil
+	}
+	if strings.ContainsRune(path, filepath.Separator) || strings.ContainsRune(path, '/') {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return "", fmt.Errorf("resolve ripgrep path: %w", err)
+		}
+		path = abs
+	}
+	rg, err := exec.LookPath(path)
+	if err != nil {
+		return "", fmt.Errorf("ripgrep executable %q not usable: %w", path, err)
+	}
+	return rg, nil
+}
+
+var _ Backend = (*Service)(nil)
+
+// Index is a no-op: ripgrep reads the files on every search.
+func (s *Service) Index(context.Context) error { return nil }
+
+// Invalidate drops cached results, which may include the changed paths.
+func (s *Service) Invalidate(...string) { s.cache.clear() }
+
+// Search executes ripgrep with the provided query and options. Identical
+// searches running at once share one ripgrep run, and its results are
+// reused for a few seconds or until Invalidate.
 func (s *Service) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
 	if strings.TrimSpace(query) == "" {
 		return nil, errors.New("query cannot be empty")
 	}
+	return s.cache.do(ctx, cacheKey(query, opts), func(ctx context.Context) ([]Result, error) {
+		return s.run(ctx, query, opts)
+	})
+}
 
-	args := []string{"--json", "--line-number", "--color=never", "--no-heading"}
+// run executes one ripgrep search.
+//
+//nolint:gocognit,gocyclo // ripgrep argument building requires option handling
+func (s *Service) run(ctx context.Context, query string, opts Options) ([]Result, error) {
+	// Configured flags go first so wikimd's own, which the output parser
+	// depends on, win where they overlap. .gitignore and other ignore files
+	// are not honored: callers decide what is searched through opts, as the
+	// wiki's own visibility rules do not follow them.
+	args := append(slices.Clone(s.args), "--json", "--line-number", "--color=never", "--no-heading", "--no-ignore")
 	if opts.CaseSensitive {
 		args = append(args, "--case-sensitive")
 	} else {
@@ -104,7 +174,7 @@ func (s *Service) Search(ctx context.Context, query string, opts Options) ([]Res
 
 	args = append(args, "--", query, "./")
 
-	cmd := exec.CommandContext(ctx, "rg", args...)
+	cmd := exec.CommandContext(ctx, s.rg, args...)
 	cmd.Dir = s.root
 
 	stdout, err := cmd.StdoutPipe()
@@ -116,16 +186,26 @@ func (s *Service) Search(ctx context.Context, query string, opts Options) ([]Res
 	cmd.Stderr = stderrBuf
 
 	if err := cmd.Start(); err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, fmt.Errorf("start rg: %w", err)
 	}
 
 	results, err := parseRipgrepJSON(stdout, opts)
 	if err != nil {
 		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, err
 	}
 
 	if err := cmd.Wait(); err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
 		var exitErr *exec.ExitError
 		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
 			return results, nil
@@ -139,6 +219,17 @@ func (s *Service) Search(ctx context.Context, query string, opts Options) ([]Res
 	return results, nil
 }
 
+// contextError reports why ctx ended, if it has: ErrTimeout for a passed
+// deadline, or the cancellation itself. Killing rg for either shows up as a
+// read or exit error that would otherwise hide the cause.
+func contextError(ctx context.Context) error {
+	err := ctx.Err()
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	return err
+}
+
 type rgMessage struct {
 	Type string          `json:"type"`
 	Data json.RawMessage `json:"data"`
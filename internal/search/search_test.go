@@ -2,10 +2,15 @@ package search_test
 
 import (
 	"context"
+	"errors"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/euforicio/wikimd/internal/search"
 )
@@ -17,7 +22,7 @@ func TestSearchFindsMatches(t *testing.T) {
 	}
 
 	root := filepath.Join("..", "..", "testdata", "wiki")
-	svc, err := search.NewService(root, nil)
+	svc, err := search.NewService(root, nil, search.BackendOptions{})
 	if err != nil {
 		t.Fatalf("NewService: %v", err)
 	}
@@ -54,7 +59,7 @@ func TestSearchHonorsGlobExclusion(t *testing.T) {
 	}
 
 	root := filepath.Join("..", "..", "testdata", "wiki")
-	svc, err := search.NewService(root, nil)
+	svc, err := search.NewService(root, nil, search.BackendOptions{})
 	if err != nil {
 		t.Fatalf("NewService: %v", err)
 	}
@@ -74,7 +79,7 @@ func TestSearchHonorsGlobExclusion(t *testing.T) {
 func TestSearchEmptyQuery(t *testing.T) {
 	t.Parallel()
 	root := filepath.Join("..", "..", "testdata", "wiki")
-	svc, err := search.NewService(root, nil)
+	svc, err := search.NewService(root, nil, search.BackendOptions{})
 	if err != nil {
 		t.Fatalf("NewService: %v", err)
 	}
@@ -83,3 +88,169 @@ func TestSearchEmptyQuery(t *testing.T) {
 		t.Fatalf("expected error for empty query")
 	}
 }
+
+func TestGroupByPage(t *testing.T) {
+	t.Parallel()
+	results := []search.Result{
+		{Path: "a.md", Line: 3, LineText: "some wiki text"},
+		{Path: "b.md", Line: 1, LineText: "wiki"},
+		{Path: "a.md", Line: 7, LineText: "## Wiki setup"},
+		{Path: "a.md", Line: 9, LineText: "## Wiki again"},
+	}
+
+	pages := search.GroupByPage(results)
+	if len(pages) != 2 {
+		t.Fatalf("expected 2 pages, got %+v", pages)
+	}
+	if pages[0].Path != "a.md" || pages[0].Count != 3 {
+		t.Fatalf("expected a.md first with 3 matches, got %+v", pages[0])
+	}
+	if pages[0].Best.Line != 7 {
+		t.Fatalf("expected the first heading as best snippet, got line %d", pages[0].Best.Line)
+	}
+	if pages[1].Path != "b.md" || pages[1].Count != 1 {
+		t.Fatalf("unexpected second page %+v", pages[1])
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	t.Parallel()
+	vocab := search.Vocabulary{}
+	vocab.Add("Getting Started")
+	vocab.Add("Deployment guide")
+	vocab.Add("Deploy checklist")
+	vocab.Add("Deploy notes")
+
+	got := search.Suggest("deplyoment gide", vocab)
+	if len(got) == 0 || got[0] != "deployment guide" {
+		t.Fatalf("expected deployment guide first, got %v", got)
+	}
+
+	got = search.Suggest("delpoy", vocab)
+	if len(got) == 0 || got[0] != "deploy" {
+		t.Fatalf("expected transposition to correct to deploy, got %v", got)
+	}
+
+	if got := search.Suggest("getting started", vocab); got != nil {
+		t.Fatalf("expected no suggestions for known words, got %v", got)
+	}
+	if got := search.Suggest("kubernetes", vocab); got != nil {
+		t.Fatalf("expected no suggestions for distant words, got %v", got)
+	}
+}
+
+func TestSearchReportsTimeout(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("rg"); err != nil {
+		t.Skip("ripgrep (rg) not installed")
+	}
+
+	svc, err := search.NewService(filepath.Join("..", "..", "testdata", "wiki"), nil, search.BackendOptions{})
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+
+	if _, err := svc.Search(ctx, "Welcome", search.Options{}); !errors.Is(err, search.ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+}
+
+func TestSearchUsesConfiguredRipgrep(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the rg executable")
+	}
+
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > " + argsFile + "\nexit 1\n"
+	rg := filepath.Join(dir, "custom-rg")
+	if err := os.WriteFile(rg, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	svc, err := search.NewService(filepath.Join("..", "..", "testdata", "wiki"), nil, search.BackendOptions{
+		RipgrepPath: rg,
+		RipgrepArgs: []string{"--threads=2", "--max-filesize=1M"},
+	})
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	if _, err := svc.Search(context.Background(), "Welcome", search.Options{}); err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+
+	raw, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("expected the configured executable to run: %v", err)
+	}
+	args := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	if len(args) < 3 || args[0] != "--threads=2" || args[1] != "--max-filesize=1M" || args[2] != "--json" {
+		t.Fatalf("expected the extra flags ahead of wikimd's own, got %q", args)
+	}
+}
+
+func TestNewServiceRejectsBadRipgrepSettings(t *testing.T) {
+	t.Parallel()
+	root := filepath.Join("..", "..", "testdata", "wiki")
+	for name, opts := range map[string]search.BackendOptions{
+		"missing executable": {RipgrepPath: filepath.Join(t.TempDir(), "rg")},
+		"positional arg":     {RipgrepArgs: []string{"--threads", "2"}},
+	} {
+		if _, err := search.NewService(root, nil, opts); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSearchSharesAndCachesRuns(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the rg executable")
+	}
+
+	dir := t.TempDir()
+	runs := filepath.Join(dir, "runs")
+	script := "#!/bin/sh\necho run >> " + runs + "\nsleep 0.3\nexit 1\n"
+	rg := filepath.Join(dir, "slow-rg")
+	if err := os.WriteFile(rg, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	svc, err := search.NewService(filepath.Join("..", "..", "testdata", "wiki"), nil, search.BackendOptions{RipgrepPath: rg})
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	count := func() int {
+		raw, _ := os.ReadFile(runs)
+		return strings.Count(string(raw), "run")
+	}
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Go(func() {
+			if _, err := svc.Search(context.Background(), "Welcome", search.Options{}); err != nil {
+				t.Errorf("Search returned error: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+	if _, err := svc.Search(context.Background(), "Welcome", search.Options{}); err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if n := count(); n != 1 {
+		t.Fatalf("expected identical searches to share one rg run, got %d", n)
+	}
+
+	if _, err := svc.Search(context.Background(), "Welcome", search.Options{CaseSensitive: true}); err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	svc.Invalidate("index.md")
+	if _, err := svc.Search(context.Background(), "Welcome", search.Options{}); err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if n := count(); n != 3 {
+		t.Fatalf("expected other options and invalidation to run rg again, got %d runs", n)
+	}
+}
@@ -0,0 +1,130 @@
+// Package searchtest provides an in-memory search.Backend for tests.
+package searchtest
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/euforicio/wikimd/internal/search"
+)
+
+// Backend matches queries as case-insensitive substrings of the documents it
+// holds and records how it was called.
+type Backend struct {
+	mu          sync.Mutex
+	docs        map[string]string
+	queries     []Query
+	invalidated []string
+	indexed     int
+}
+
+// Query is one recorded Search call.
+type Query struct {
+	Text    string
+	Options search.Options
+}
+
+var _ search.Backend = (*Backend)(nil)
+
+// New returns a Backend holding docs, keyed by wiki-relative path.
+func New(docs map[string]string) *Backend {
+	b := &Backend{docs: make(map[string]string, len(docs))}
+	for p, body := range docs {
+		b.docs[p] = body
+	}
+	return b
+}
+
+// Search implements search.Backend. Exclude globs ending in "/**" drop every
+// path below that directory; other globs are matched with path.Match.
+func (b *Backend) Search(_ context.Context, query string, opts search.Options) ([]search.Result, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.queries = append(b.queries, Query{Text: query, Options: opts})
+
+	needle := strings.ToLower(query)
+	var results []search.Result
+	for _, p := range sortedKeys(b.docs) {
+		if excluded(p, opts.ExcludeGlobs) {
+			continue
+		}
+		for i, line := range strings.Split(b.docs[p], "\n") {
+			col := strings.Index(strings.ToLower(line), needle)
+			if col < 0 {
+				continue
+			}
+			results = append(results, search.Result{
+				Path:     p,
+				Line:     i + 1,
+				Column:   col + 1,
+				LineText: line,
+				Match:    line[col:min(col+len(needle), len(line))],
+			})
+		}
+	}
+	return results, nil
+}
+
+// Index implements search.Backend.
+func (b *Backend) Index(context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.indexed++
+	return nil
+}
+
+// Invalidate implements search.Backend.
+func (b *Backend) Invalidate(paths ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.invalidated = append(b.invalidated, paths...)
+}
+
+// Queries returns the Search calls made so far.
+func (b *Backend) Queries() []Query {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Query(nil), b.queries...)
+}
+
+// Invalidated returns every path passed to Invalidate so far.
+func (b *Backend) Invalidated() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.invalidated...)
+}
+
+// Indexed reports how many times Index ran.
+func (b *Backend) Indexed() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.indexed
+}
+
+func excluded(p string, globs []string) bool {
+	for _, glob := range globs {
+		glob = strings.TrimPrefix(glob, "!")
+		if dir, ok := strings.CutSuffix(glob, "/**"); ok {
+			if p == dir || strings.HasPrefix(p, dir+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(glob, p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
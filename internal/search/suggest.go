@@ -0,0 +1,151 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxSuggestions bounds the corrected queries returned for one search.
+const maxSuggestions = 3
+
+// Vocabulary counts how often each lowercased word appears in the text the
+// wiki is navigated by (titles, tags, descriptions). Suggest draws
+// corrections from it.
+type Vocabulary map[string]int
+
+// Add counts every word in text.
+func (v Vocabulary) Add(text string) {
+	for _, word := range words(text) {
+		v[word]++
+	}
+}
+
+// Suggest returns up to three corrected versions of query, best first. Each
+// word missing from the vocabulary is replaced by the closest known word
+// within a small edit distance; nil means nothing could be corrected.
+func Suggest(query string, vocab Vocabulary) []string {
+	tokens := words(query)
+	if len(tokens) == 0 || len(vocab) == 0 {
+		return nil
+	}
+
+	corrected := make([]string, len(tokens))
+	copy(corrected, tokens)
+	// Alternatives for the first misspelled word become extra suggestions.
+	var alternatives []string
+	altIndex := -1
+	for i, tok := range tokens {
+		if _, known := vocab[tok]; known {
+			continue
+		}
+		candidates := closestWords(tok, vocab)
+		if len(candidates) == 0 {
+			continue
+		}
+		corrected[i] = candidates[0]
+		if altIndex == -1 {
+			altIndex = i
+			alternatives = candidates[1:]
+		}
+	}
+	if altIndex == -1 {
+		return nil
+	}
+
+	suggestions := []string{strings.Join(corrected, " ")}
+	for _, alt := range alternatives {
+		if len(suggestions) == maxSuggestions {
+			break
+		}
+		variant := make([]string, len(corrected))
+		copy(variant, corrected)
+		variant[altIndex] = alt
+		suggestions = append(suggestions, strings.Join(variant, " "))
+	}
+	return suggestions
+}
+
+// closestWords lists vocabulary words within the allowed edit distance of
+// word, nearest first and then most frequent.
+func closestWords(word string, vocab Vocabulary) []string {
+	limit := 1
+	if len([]rune(word)) > 4 {
+		limit = 2
+	}
+	type candidate struct {
+		word  string
+		dist  int
+		count int
+	}
+	var found []candidate
+	for w, count := range vocab {
+		if d := editDistance(word, w, limit); d <= limit {
+			found = append(found, candidate{w, d, count})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].dist != found[j].dist {
+			return found[i].dist < found[j].dist
+		}
+		if found[i].count != found[j].count {
+			return found[i].count > found[j].count
+		}
+		return found[i].word < found[j].word
+	})
+	out := make([]string, 0, min(len(found), maxSuggestions))
+	for _, c := range found[:min(len(found), maxSuggestions)] {
+		out = append(out, c.word)
+	}
+	return out
+}
+
+// editDistance is the optimal string alignment distance between a and b
+// (Levenshtein plus adjacent transpositions). It stops early and returns
+// limit+1 once the distance is known to exceed limit.
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if diff := len(ra) - len(rb); diff > limit || -diff > limit {
+		return limit + 1
+	}
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+// words splits text into lowercased letter/digit runs of two or more runes.
+func words(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := fields[:0]
+	for _, f := range fields {
+		if len([]rune(f)) > 1 {
+			out = append(out, f)
+		}
+	}
+	return out
+}
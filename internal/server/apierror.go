@@ -0,0 +1,79 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/euforicio/wikimd/internal/content"
+	"github.com/euforicio/wikimd/internal/validation"
+)
+
+// Error codes carried in the "code" field of API error responses. Clients
+// should branch on these rather than on messages, which may change.
+const (
+	codeInvalidRequest     = "invalid_request"
+	codeInvalidJSON        = "invalid_json"
+	codeValidation         = validation.CodeInvalid
+	codePathTraversal      = validation.CodeTraversal
+	codeNotFound           = "not_found"
+	codeConflict           = "conflict"
+	codeLocked             = "locked"
+	codeUnsupportedVersion = "unsupported_version"
+	codeUnavailable        = "unavailable"
+	codeTimeout            = "timeout"
+	codeInternal           = "internal"
+)
+
+// apiError is the JSON body of every API error response.
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Field names the offending request field or query parameter.
+	Field string `json:"field,omitempty"`
+	// Path is the document the error concerns.
+	Path string `json:"path,omitempty"`
+	// Details lists every failed field of a rejected request.
+	Details []validation.FieldError `json:"details,omitempty"`
+	// Error repeats Message for clients written against the original
+	// {"error": "..."} body.
+	Error string `json:"error"`
+}
+
+func newAPIError(code, message string) apiError {
+	return apiError{Code: code, Message: message}
+}
+
+func (e apiError) withField(field string) apiError {
+	e.Field = field
+	return e
+}
+
+func (e apiError) withPath(path string) apiError {
+	e.Path = path
+	return e
+}
+
+// respondError writes e with the given status.
+func respondError(w http.ResponseWriter, status int, e apiError) {
+	e.Error = e.Message
+	respondJSON(w, status, e)
+}
+
+// contentError maps an error from the content service to a status and code.
+func contentError(err error) (int, apiError) {
+	switch {
+	case errors.Is(err, content.ErrFrozen):
+		return http.StatusLocked, newAPIError(codeLocked, err.Error())
+	case errors.Is(err, content.ErrVersionsDisabled):
+		return http.StatusNotFound, newAPIError(codeNotFound, err.Error())
+	case errors.Is(err, content.ErrInvalidPath):
+		return http.StatusBadRequest, newAPIError(codePathTraversal, err.Error())
+	case errors.Is(err, os.ErrExist):
+		return http.StatusConflict, newAPIError(codeConflict, err.Error())
+	case errors.Is(err, os.ErrNotExist):
+		return http.StatusNotFound, newAPIError(codeNotFound, err.Error())
+	default:
+		return http.StatusInternalServerError, newAPIError(codeInternal, err.Error())
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/euforicio/wikimd/internal/board"
+	"github.com/euforicio/wikimd/internal/frontmatter"
+)
+
+// handleBoard groups pages into kanban columns by their status frontmatter.
+// ?columns=todo,doing,done fixes the leading columns and their order; ?dir=
+// limits the board to one folder.
+func (s *Server) handleBoard(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	root, err := s.content.CurrentTree(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "load content tree failed", slog.Any("err", err))
+		respondError(w, http.StatusInternalServerError, newAPIError(codeInternal, "failed to load content tree"))
+		return
+	}
+
+	q := r.URL.Query()
+	var order []string
+	if columns := strings.TrimSpace(q.Get("columns")); columns != "" {
+		order = strings.Split(columns, ",")
+	}
+	respondJSON(w, http.StatusOK, board.Build(root, q.Get("dir"), order))
+}
+
+// handlePageStatus serves POST /api/page/{path}/status, which moves a card
+// on the board by rewriting the page's status frontmatter. An empty status
+// removes the key, taking the page off the board.
+func (s *Server) handlePageStatus(w http.ResponseWriter, r *http.Request, path string) {
+	ctx := r.Context()
+	var payload struct {
+		Status string `json:"status"`
+	}
+	if err := decodeJSON(r, &payload); err != nil {
+		respondError(w, http.StatusBadRequest, newAPIError(codeInvalidJSON, "invalid JSON payload"))
+		return
+	}
+	status := strings.TrimSpace(payload.Status)
+	if !validateRequest(w, pageStatusSchema, map[string]string{"path": path, "status": status}) {
+		return
+	}
+
+	doc, err := s.content.Document(ctx, path)
+	if err != nil {
+		status, apiErr := contentError(err)
+		s.logger.WarnContext(ctx, "load page for status failed", slog.Any("err", err), slog.String("path", path))
+		respondError(w, status, apiErr.withPath(path))
+		return
+	}
+	var updated []byte
+	if status == "" {
+		updated, err = frontmatter.Delete([]byte(doc.Raw), board.Field)
+	} else {
+		updated, err = frontmatter.Set([]byte(doc.Raw), board.Field, status)
+	}
+	if err != nil {
+		if errors.Is(err, frontmatter.ErrUnclosed) {
+			respondError(w, http.StatusUnprocessableEntity, newAPIError(codeValidation, "page frontmatter is not closed").withPath(path))
+			return
+		}
+		if errors.Is(err, frontmatter.ErrNotYAML) {
+			respondError(w, http.StatusUnprocessableEntity, newAPIError(codeValidation, err.Error()).withPath(path))
+			return
+		}
+		respondError(w, http.StatusInternalServerError, newAPIError(codeInternal, "update frontmatter failed").withPath(path))
+		return
+	}
+
+	if err := s.content.SaveDocument(ctx, path, updated); err != nil {
+		status, apiErr := contentError(err)
+		s.logger.WarnContext(ctx, "save page status failed", slog.Any("err", err), slog.String("path", path))
+		respondError(w, status, apiErr.withPath(path))
+		return
+	}
+
+	resp := struct {
+		Path    string `json:"path"`
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}{
+		Path:    path,
+		Status:  status,
+		Message: "moved",
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBoard(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServer(t)
+	t.Cleanup(cleanup)
+
+	target := filepath.Join(srv.cfg.RootDir, "tasks", "launch.md")
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("---\ntitle: Launch\nstatus: todo # set by planning\n---\n\n# Launch\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	type boardResponse struct {
+		Columns []struct {
+			Name  string `json:"name"`
+			Cards []struct {
+				Path  string `json:"path"`
+				Title string `json:"title"`
+			} `json:"cards"`
+		} `json:"columns"`
+		Count int `json:"count"`
+	}
+	getBoard := func(want string) boardResponse {
+		var resp boardResponse
+		deadline := time.Now().Add(3 * time.Second)
+		for {
+			req := httptest.NewRequest(http.MethodGet, "/api/board?dir=tasks&columns=todo,done", nil)
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+			resp = boardResponse{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode board: %v", err)
+			}
+			for _, col := range resp.Columns {
+				if col.Name == want && len(col.Cards) == 1 {
+					return resp
+				}
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected launch.md in %q, got %+v", want, resp)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	resp := getBoard("todo")
+	if resp.Count != 1 || len(resp.Columns) != 2 || resp.Columns[0].Cards[0].Title != "Launch" {
+		t.Fatalf("unexpected board %+v", resp)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/page/tasks/launch.md/status", strings.NewReader(`{"status": "done"}`))
+	req.Host = "localhost:8080"
+	req.Header.Set("Origin", "http://localhost:8080")
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	raw, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "---\ntitle: Launch\nstatus: done\n---\n\n# Launch\n"; string(raw) != want {
+		t.Fatalf("expected %q, got %q", want, raw)
+	}
+	getBoard("done")
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/euforicio/wikimd/internal/calendar"
+)
+
+// handleCalendar serves the dated pages as an iCalendar feed that calendar
+// apps can subscribe to. ?dir= limits the feed to one folder.
+func (s *Server) handleCalendar(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	dir := strings.Trim(strings.TrimSpace(r.URL.Query().Get("dir")), "/")
+
+	root, err := s.content.CurrentTree(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "load content tree failed", slog.Any("err", err))
+		respondError(w, http.StatusInternalServerError, newAPIError(codeInternal, "failed to load content tree"))
+		return
+	}
+
+	events := calendar.Collect(root)
+	if dir != "" {
+		kept := events[:0]
+		for _, ev := range events {
+			if strings.HasPrefix(ev.Path, dir+"/") {
+				kept = append(kept, ev)
+			}
+		}
+		events = kept
+	}
+
+	name := filepath.Base(s.cfg.RootDir)
+	if dir != "" {
+		name += " / " + dir
+	}
+	baseURL := s.requestBaseURL(r)
+	feed := calendar.Feed{
+		Name:    name,
+		PageURL: func(path string) string { return pageURL(baseURL, path) },
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="calendar.ics"`)
+	if err := calendar.Write(w, feed, events); err != nil {
+		s.logger.WarnContext(ctx, "write calendar failed", slog.Any("err", err))
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/chunk"
+)
+
+// Bounds of the maxTokens parameter. The floor keeps chunks from
+// degenerating into single lines.
+const (
+	minChunkTokens = 50
+	maxChunkTokens = 8192
+)
+
+// pageChunks is a page split for embedding.
+//
+//nolint:govet // field order matches the JSON documented in the README
+type pageChunks struct {
+	Path      string        `json:"path"`
+	Title     string        `json:"title"`
+	Modified  time.Time     `json:"modified"`
+	NoIndex   bool          `json:"noindex,omitempty"`
+	MaxTokens int           `json:"maxTokens"`
+	Chunks    []chunk.Chunk `json:"chunks"`
+}
+
+// handlePageChunks serves GET /api/page/{path}/chunks?maxTokens=500, the
+// page source split at headings into chunks with stable IDs and line
+// ranges, for external search and embedding pipelines.
+func (s *Server) handlePageChunks(w http.ResponseWriter, r *http.Request, path string) {
+	ctx := r.Context()
+	if !validateRequest(w, pathSchema, map[string]string{"path": path}) {
+		return
+	}
+	maxTokens := chunk.DefaultMaxTokens
+	if v := r.URL.Query().Get("maxTokens"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < minChunkTokens || n > maxChunkTokens {
+			msg := fmt.Sprintf("maxTokens must be a number from %d to %d", minChunkTokens, maxChunkTokens)
+			respondError(w, http.StatusBadRequest, newAPIError(codeValidation, msg).withField("maxTokens"))
+			return
+		}
+		maxTokens = n
+	}
+
+	doc, err := s.content.Document(ctx, path)
+	if err != nil {
+		s.logger.DebugContext(ctx, "load page chunks failed", slog.Any("err", err), slog.String("path", path))
+		status, apiErr := contentError(err)
+		respondError(w, status, apiErr.withPath(path))
+		return
+	}
+
+	title := doc.Metadata.Title
+	if title == "" {
+		title = titleFromPath(path)
+	}
+	chunks := chunk.Split(path, []byte(doc.Raw), maxTokens)
+	if chunks == nil {
+		chunks = []chunk.Chunk{}
+	}
+	respondJSON(w, http.StatusOK, pageChunks{
+		Path:      path,
+		Title:     title,
+		Modified:  doc.Modified,
+		NoIndex:   doc.Metadata.NoIndex,
+		MaxTokens: maxTokens,
+		Chunks:    chunks,
+	})
+}
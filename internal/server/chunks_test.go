@@ -0,0 +1,47 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPageChunks(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServer(t)
+	t.Cleanup(cleanup)
+
+	page := "---\ntitle: Runbook\n---\n# Restart\n\nRestart the worker.\n\n## Verify\n\nCheck the queue.\n"
+	if err := os.WriteFile(filepath.Join(srv.cfg.RootDir, "runbook.md"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/page/runbook.md/chunks?maxTokens=100", nil)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got pageChunks
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Runbook" || got.MaxTokens != 100 || len(got.Chunks) != 2 {
+		t.Fatalf("unexpected response: %+v", got)
+	}
+	if c := got.Chunks[1]; c.ID != "runbook.md#verify" || c.StartLine != 8 || c.EndLine != 10 || len(c.Headings) != 2 {
+		t.Errorf("unexpected second chunk: %+v", c)
+	}
+
+	for _, query := range []string{"maxTokens=10", "maxTokens=lots"} {
+		req = httptest.NewRequest(http.MethodGet, "/api/page/runbook.md/chunks?"+query, nil)
+		rec = httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"encoding/base64"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/euforicio/wikimd/internal/clipboard"
+)
+
+// maxInlineMedia caps the size of an image embedded in a copy payload;
+// larger images are replaced by their alt text.
+const maxInlineMedia = 2 << 20
+
+// handlePageCopy serves GET /api/page/{path}/copy, the payloads behind the
+// UI's copy buttons. format=markdown returns the source without frontmatter,
+// format=html a sanitized, self-contained fragment, and format=rich (the
+// default) JSON with inline-styled HTML plus a plain-text fallback for the
+// two clipboard flavours.
+func (s *Server) handlePageCopy(w http.ResponseWriter, r *http.Request, path string) {
+	ctx := r.Context()
+	if !validateRequest(w, pathSchema, map[string]string{"path": path}) {
+		return
+	}
+
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	switch format {
+	case "", "rich", "markdown", "html":
+	default:
+		respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "format must be rich, markdown, or html").withField("format"))
+		return
+	}
+
+	doc, err := s.content.Document(ctx, path)
+	if err != nil {
+		s.logger.WarnContext(ctx, "load page for copy failed", slog.Any("err", err), slog.String("path", path))
+		status, apiErr := contentError(err)
+		respondError(w, status, apiErr.withPath(path))
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+
+	if format == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		_, _ = w.Write([]byte(clipboard.Markdown(doc.Raw)))
+		return
+	}
+
+	fragment := doc.HTML
+	if root, err := s.content.CurrentTree(ctx); err == nil {
+		fragment = expandListings(fragment, root, path)
+	}
+	opts := clipboard.Options{
+		BaseURL: s.requestBaseURL(r),
+		Inline:  s.inlineMedia,
+		Styled:  format != "html",
+	}
+
+	if format == "html" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(clipboard.HTML(fragment, opts)))
+		return
+	}
+	resp := struct {
+		Path string `json:"path"`
+		HTML string `json:"html"`
+		Text string `json:"text"`
+	}{
+		Path: path,
+		HTML: clipboard.HTML(fragment, opts),
+		Text: clipboard.Text(fragment),
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// requestBaseURL is the origin outbound links point at: the configured public
+// URL when set, otherwise the host the request came in on.
+func (s *Server) requestBaseURL(r *http.Request) string {
+	if s.cfg.PublicURL != "" {
+		return strings.TrimSuffix(s.cfg.PublicURL, "/") + "/"
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + "/"
+}
+
+// inlineMedia reads a /media/ image from the wiki root and returns it as a
+// data URI.
+func (s *Server) inlineMedia(src string) (string, bool) {
+	u, err := url.Parse(src)
+	if err != nil {
+		return "", false
+	}
+	rel, ok := strings.CutPrefix(u.Path, "/media/")
+	if !ok {
+		return "", false
+	}
+	clean := filepath.Clean(filepath.FromSlash(rel))
+	if clean == "." || filepath.IsAbs(clean) || strings.HasPrefix(clean, "..") {
+		return "", false
+	}
+	ctype := mime.TypeByExtension(filepath.Ext(clean))
+	if !strings.HasPrefix(ctype, "image/") {
+		return "", false
+	}
+	full := filepath.Join(s.cfg.RootDir, clean)
+	info, err := os.Stat(full)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxInlineMedia {
+		return "", false
+	}
+	raw, err := os.ReadFile(full) //nolint:gosec // path confined to the wiki root above
+	if err != nil {
+		return "", false
+	}
+	ctype, _, _ = strings.Cut(ctype, ";")
+	return "data:" + ctype + ";base64," + base64.StdEncoding.EncodeToString(raw), true
+}
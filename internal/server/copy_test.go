@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPageCopyFormats(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServer(t)
+	t.Cleanup(cleanup)
+
+	root := srv.cfg.RootDir
+	png := []byte("\x89PNG\r\n\x1a\n")
+	if err := os.WriteFile(filepath.Join(root, "logo.png"), png, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	page := "---\ntitle: Copy me\n---\n\n# Copy me\n\n![Logo](logo.png) See [home](index.md).\n\n<script>alert(1)</script>\n"
+	if err := os.WriteFile(filepath.Join(root, "copy.md"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/page/copy.md/copy"+query, nil)
+		req.Host = "wiki.test"
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("?format=markdown")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/markdown") {
+		t.Fatalf("expected markdown, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if body := rec.Body.String(); strings.Contains(body, "title:") || !strings.HasPrefix(body, "# Copy me") {
+		t.Fatalf("expected source without frontmatter, got %q", body)
+	}
+
+	rec = get("?format=html")
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("expected html, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(body, `href="http://wiki.test/page/index.md"`) || !strings.Contains(body, `src="data:image/png;base64,`) {
+		t.Fatalf("expected absolute links and inlined images, got %q", body)
+	}
+	if strings.Contains(body, "<script") || strings.Contains(body, "style=") {
+		t.Fatalf("expected plain sanitized html, got %q", body)
+	}
+
+	rec = get("")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for rich copy, got %d", rec.Code)
+	}
+	var rich struct {
+		HTML string `json:"html"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &rich); err != nil {
+		t.Fatalf("decode rich copy: %v", err)
+	}
+	if !strings.Contains(rich.HTML, `style="`) || !strings.HasPrefix(rich.Text, "Copy me\n") {
+		t.Fatalf("expected styled html and plain text, got %+v", rich)
+	}
+
+	if rec := get("?format=pdf"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown format, got %d", rec.Code)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/page/missing.md/copy", nil)
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for missing page, got %d", rec.Code)
+	}
+}
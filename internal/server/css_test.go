@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/euforicio/wikimd/internal/config"
@@ -248,6 +249,60 @@ func TestHandleCustomCSS_Caching(t *testing.T) {
 	}
 }
 
+func TestScopedCSS(t *testing.T) {
+	t.Parallel()
+	tmpDir := t.TempDir()
+	for _, dir := range []string{"eng/.wikimd", "eng/api/.wikimd", "sales/.wikimd"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"eng/.wikimd/custom.css", "eng/api/.wikimd/custom.css"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, file), []byte(":root {}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A non-CSS file is not picked up.
+	if err := os.WriteFile(filepath.Join(tmpDir, "sales/.wikimd/custom.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{
+		logger: testLogger(),
+		cfg:    config.Config{RootDir: tmpDir},
+	}
+
+	tests := []struct {
+		page string
+		want []string
+	}{
+		{page: "index.md", want: nil},
+		{page: "eng/setup.md", want: []string{"/custom-theme/dir/eng"}},
+		{page: "eng/api/v1/auth.md", want: []string{"/custom-theme/dir/eng", "/custom-theme/dir/eng/api"}},
+		{page: "sales/q3.md", want: nil},
+	}
+	for _, tt := range tests {
+		if got := s.scopedCSSURLs(tt.page); !slices.Equal(got, tt.want) {
+			t.Errorf("scopedCSSURLs(%q) = %v, want %v", tt.page, got, tt.want)
+		}
+	}
+
+	for dir, want := range map[string]int{
+		"eng/api": http.StatusOK,
+		"sales":   http.StatusNotFound,
+		"..":      http.StatusNotFound,
+		"eng/..":  http.StatusNotFound,
+	} {
+		req := httptest.NewRequest("GET", "/custom-theme/dir/x", nil)
+		req.SetPathValue("dir", dir)
+		w := httptest.NewRecorder()
+		s.handleScopedCSS(w, req)
+		if w.Code != want {
+			t.Errorf("handleScopedCSS(%q) status = %v, want %v", dir, w.Code, want)
+		}
+	}
+}
+
 // testLogger creates a no-op logger for testing
 func testLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
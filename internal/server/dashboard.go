@@ -0,0 +1,92 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+	"github.com/euforicio/wikimd/internal/dashboard"
+	"github.com/euforicio/wikimd/internal/renderer"
+)
+
+// dashboardPage renders the generated landing page for the directory at path.
+// It reports false when path is not a directory with a dashboard file, so
+// callers fall back to normal document handling.
+func (s *Server) dashboardPage(ctx context.Context, root *tree.Node, path string) (pageViewData, bool) {
+	dir := findNode(root, strings.Trim(path, "/"))
+	if dir == nil || dir.Type != tree.NodeTypeDirectory || !dir.HasDashboard {
+		return pageViewData{}, false
+	}
+
+	cfg, err := dashboard.Load(s.cfg.RootDir, dir.RelativePath)
+	if err != nil {
+		// A broken dashboard file should not hide the section; show the
+		// generated listing with defaults and log the problem.
+		s.logger.WarnContext(ctx, "load dashboard failed", slog.Any("err", err), slog.String("path", dir.RelativePath))
+		cfg = dashboard.Config{}
+	}
+	view := dashboard.Build(cfg, dir)
+
+	var buf bytes.Buffer
+	if err := s.templates.render(&buf, "dashboard", view); err != nil {
+		s.logger.ErrorContext(ctx, "render dashboard failed", slog.Any("err", err), slog.String("path", dir.RelativePath))
+		return pageViewData{}, false
+	}
+
+	return pageViewData{
+		Path:        dir.RelativePath,
+		Title:       view.Title,
+		HTML:        template.HTML(buf.String()), //nolint:gosec // produced by html/template
+		Metadata:    renderer.Metadata{Description: view.Description},
+		Modified:    view.Modified,
+		Breadcrumbs: breadcrumbsFor(root, dir.RelativePath),
+		Generated:   true,
+	}, true
+}
+
+// respondDashboard answers an /api/page request for a dashboard directory,
+// reporting whether it did.
+func (s *Server) respondDashboard(w http.ResponseWriter, r *http.Request, path string) bool {
+	ctx := r.Context()
+	root, err := s.content.CurrentTree(ctx)
+	if err != nil {
+		return false
+	}
+	page, ok := s.dashboardPage(ctx, root, path)
+	if !ok {
+		return false
+	}
+
+	if isHTMXRequest(r) {
+		setHXTrigger(w, map[string]any{
+			"pageLoaded": map[string]any{
+				"path":  page.Path,
+				"title": page.Title,
+			},
+		})
+		w.Header().Set("X-Wikimd-Path", page.Path)
+		s.renderTemplate(w, r, "page", page)
+		return true
+	}
+
+	resp := struct {
+		Modified time.Time `json:"modified"`
+		Path     string    `json:"path"`
+		Title    string    `json:"title"`
+		HTML     string    `json:"html"`
+		Type     string    `json:"type"`
+	}{
+		Modified: page.Modified,
+		Path:     page.Path,
+		Title:    page.Title,
+		HTML:     string(page.HTML),
+		Type:     "dashboard",
+	}
+	respondJSON(w, http.StatusOK, resp)
+	return true
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page sizes of GET /api/dump. A page also ends once it carries
+// maxDumpBytes of content, so a few large documents cannot make one
+// response unbounded; it always holds at least one document.
+const (
+	defaultDumpLimit = 100
+	maxDumpLimit     = 1000
+	maxDumpBytes     = 8 << 20
+)
+
+// dumpDocument is one raw document in a dump page.
+type dumpDocument struct {
+	Path     string    `json:"path"`
+	Modified time.Time `json:"modified"`
+	Content  string    `json:"content"`
+}
+
+// handleDump serves GET /api/dump, the raw source of every document in path
+// order, a page at a time, for backup and indexing agents. since keeps only
+// documents modified at or after a time, given as RFC 3339 or Unix seconds.
+// cursor continues from the nextCursor of the previous page, which is empty
+// on the last one. The body is streamed, so a page is never held in memory.
+func (s *Server) handleDump(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := r.URL.Query()
+
+	var since time.Time
+	if v := params.Get("since"); v != "" {
+		t, ok := parseDumpTime(v)
+		if !ok {
+			respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "since must be an RFC 3339 time or Unix seconds").withField("since"))
+			return
+		}
+		since = t
+	}
+	var after string
+	if v := params.Get("cursor"); v != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(v)
+		if err != nil || len(decoded) == 0 {
+			respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "invalid cursor").withField("cursor"))
+			return
+		}
+		after = string(decoded)
+	}
+	limit := defaultDumpLimit
+	if v := params.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "invalid limit value").withField("limit"))
+			return
+		}
+		limit = min(n, maxDumpLimit)
+	}
+
+	root, err := s.content.CurrentTree(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "load content tree failed", slog.Any("err", err))
+		respondError(w, http.StatusInternalServerError, newAPIError(codeInternal, "failed to load content tree"))
+		return
+	}
+	var paths []string
+	for _, n := range filterPages(root, "", "") {
+		if n.RelativePath > after {
+			paths = append(paths, n.RelativePath)
+		}
+	}
+	slices.Sort(paths)
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(w)
+	_, _ = w.Write([]byte(`{"documents":[`))
+
+	count, size, next := 0, 0, ""
+	for i, rel := range paths {
+		if ctx.Err() != nil {
+			return
+		}
+		if count == limit || size >= maxDumpBytes {
+			// paths[i-1] was looked at, whether or not it was sent.
+			next = base64.RawURLEncoding.EncodeToString([]byte(paths[i-1]))
+			break
+		}
+		doc, ok, err := s.readDumpDocument(rel, since)
+		if err != nil {
+			s.logger.WarnContext(ctx, "read document for dump failed", slog.Any("err", err), slog.String("path", rel))
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if count > 0 {
+			_, _ = w.Write([]byte(","))
+		}
+		if err := enc.Encode(doc); err != nil {
+			s.logger.WarnContext(ctx, "write dump failed", slog.Any("err", err))
+			return
+		}
+		count++
+		size += len(doc.Content)
+	}
+
+	cursor, _ := json.Marshal(next)
+	_, _ = fmt.Fprintf(w, "],\"count\":%d,\"nextCursor\":%s}\n", count, cursor)
+}
+
+// readDumpDocument reads the document at rel, a path from the content tree.
+// ok is false when it was modified before since or has been removed.
+func (s *Server) readDumpDocument(rel string, since time.Time) (dumpDocument, bool, error) {
+	abs := filepath.Join(s.cfg.RootDir, filepath.FromSlash(rel))
+	info, err := os.Stat(abs)
+	if errors.Is(err, os.ErrNotExist) {
+		return dumpDocument{}, false, nil
+	}
+	if err != nil {
+		return dumpDocument{}, false, err
+	}
+	if info.ModTime().Before(since) {
+		return dumpDocument{}, false, nil
+	}
+	raw, err := os.ReadFile(abs) //nolint:gosec // rel comes from the content tree
+	if errors.Is(err, os.ErrNotExist) {
+		return dumpDocument{}, false, nil
+	}
+	if err != nil {
+		return dumpDocument{}, false, err
+	}
+	return dumpDocument{Path: rel, Modified: info.ModTime().UTC(), Content: string(raw)}, true, nil
+}
+
+// parseDumpTime reads an RFC 3339 time or a count of Unix seconds.
+func parseDumpTime(v string) (time.Time, bool) {
+	v = strings.TrimSpace(v)
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(secs, 0), true
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	return t, err == nil
+}
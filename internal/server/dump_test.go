@@ -0,0 +1,95 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDumpPagesThroughEveryDocument(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServer(t)
+	t.Cleanup(cleanup)
+
+	type dumpPage struct {
+		Documents  []dumpDocument `json:"documents"`
+		Count      int            `json:"count"`
+		NextCursor string         `json:"nextCursor"`
+	}
+	fetch := func(query url.Values) dumpPage {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/dump?"+query.Encode(), nil)
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var page dumpPage
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatalf("decode dump: %v\n%s", err, rec.Body.String())
+		}
+		if page.Count != len(page.Documents) {
+			t.Fatalf("count %d does not match %d documents", page.Count, len(page.Documents))
+		}
+		return page
+	}
+
+	var paths []string
+	query := url.Values{"limit": {"2"}}
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatal("dump never ended")
+		}
+		page := fetch(query)
+		for _, doc := range page.Documents {
+			want, err := os.ReadFile(filepath.Join(srv.cfg.RootDir, filepath.FromSlash(doc.Path)))
+			if err != nil || doc.Content != string(want) {
+				t.Fatalf("content of %s does not match the file on disk", doc.Path)
+			}
+			paths = append(paths, doc.Path)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		if len(page.Documents) != 2 {
+			t.Fatalf("expected full pages before the last, got %d documents", len(page.Documents))
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+
+	all := fetch(url.Values{"limit": {"1000"}})
+	var want []string
+	for _, doc := range all.Documents {
+		want = append(want, doc.Path)
+	}
+	if len(want) < 3 || !slices.IsSorted(want) || !slices.Equal(paths, want) {
+		t.Fatalf("paged dump %v does not match the full dump %v", paths, want)
+	}
+
+	if err := os.WriteFile(filepath.Join(srv.cfg.RootDir, want[0]), []byte("# Changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(srv.cfg.RootDir, want[0]), future, future); err != nil {
+		t.Fatal(err)
+	}
+	recent := fetch(url.Values{"since": {time.Now().Add(time.Minute).Format(time.RFC3339)}})
+	if len(recent.Documents) != 1 || recent.Documents[0].Path != want[0] || recent.Documents[0].Content != "# Changed\n" {
+		t.Fatalf("expected only the changed document since a minute from now, got %+v", recent.Documents)
+	}
+
+	for _, query := range []string{"since=yesterday", "cursor=!!", "limit=0"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/dump?"+query, nil)
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rec.Code)
+		}
+	}
+}
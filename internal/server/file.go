@@ -0,0 +1,26 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// handleFileInfo describes any file under the root, such as an attachment
+// a page links to, so the frontend can pick a viewer from its sniffed MIME
+// type rather than its extension.
+func (s *Server) handleFileInfo(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rel, err := parseWildcardPath(r.PathValue("path"))
+	if err != nil {
+		s.respondPathError(w, err)
+		return
+	}
+	info, err := s.content.File(ctx, rel)
+	if err != nil {
+		s.logger.DebugContext(ctx, "file info failed", slog.Any("err", err), slog.String("path", rel))
+		status, apiErr := contentError(err)
+		respondError(w, status, apiErr.withPath(rel))
+		return
+	}
+	respondJSON(w, http.StatusOK, info)
+}
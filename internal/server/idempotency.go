@@ -0,0 +1,195 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IdempotencyKeyHeader lets clients retry a mutating request safely: repeats
+// with the same key replay the first response instead of re-running it.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyReplayedHeader marks a response served from the cache.
+const idempotencyReplayedHeader = "Idempotent-Replayed"
+
+const (
+	idempotencyTTL    = 10 * time.Minute
+	maxIdempotencyKey = 255
+	// maxIdempotentBody matches the limit decodeJSON applies, so a keyed
+	// request is never cut short where an unkeyed one would not be.
+	maxIdempotentBody = 4 << 20
+)
+
+// idempotencyCache remembers recent results of keyed mutating requests.
+type idempotencyCache struct {
+	mu      sync.Mutex
+	entries map[string]*idempotencyEntry
+	now     func() time.Time
+}
+
+type idempotencyEntry struct {
+	expires     time.Time
+	header      http.Header
+	body        []byte
+	fingerprint [sha256.Size]byte
+	status      int
+	done        bool // false while the first request is still running
+}
+
+func newIdempotencyCache() *idempotencyCache {
+	return &idempotencyCache{
+		entries: make(map[string]*idempotencyEntry),
+		now:     time.Now,
+	}
+}
+
+// idempotent wraps a mutating handler. Requests without the header pass
+// straight through. A repeated key with the same request body replays the
+// stored response; a different body, or a repeat while the first request is
+// still in flight, is rejected. Server errors are not stored so the client
+// can retry them.
+func (c *idempotencyCache) idempotent(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimSpace(r.Header.Get(IdempotencyKeyHeader))
+		if key == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "Idempotency-Key is too long").withField(IdempotencyKeyHeader))
+			return
+		}
+
+		var body []byte
+		if r.Body != nil {
+			var err error
+			body, err = io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+			if err != nil {
+				respondError(w, http.StatusBadRequest, newAPIError(codeInvalidRequest, "failed to read request body"))
+				return
+			}
+			if len(body) > maxIdempotentBody {
+				respondError(w, http.StatusRequestEntityTooLarge, newAPIError(codeInvalidRequest, "request body exceeds 4 MB"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		fingerprint := sha256.Sum256(body)
+		// /api/ and /api/v1/ are the same endpoint, so they share keys.
+		scope := r.Method + " " + strings.Replace(r.URL.Path, "/api/v1/", "/api/", 1) + " " + key
+
+		entry, fresh := c.reserve(scope, fingerprint)
+		switch {
+		case entry.fingerprint != fingerprint:
+			respondError(w, http.StatusUnprocessableEntity, newAPIError(codeConflict, "Idempotency-Key was already used with a different request").withField(IdempotencyKeyHeader))
+			return
+		case !fresh && !entry.done:
+			respondError(w, http.StatusConflict, newAPIError(codeConflict, "a request with this Idempotency-Key is still in progress").withField(IdempotencyKeyHeader))
+			return
+		case !fresh:
+			for name, values := range entry.header {
+				w.Header()[name] = values
+			}
+			w.Header().Set(idempotencyReplayedHeader, "true")
+			w.WriteHeader(entry.status)
+			_, _ = w.Write(entry.body)
+			return
+		}
+
+		// Headers set by outer middleware (compression, versioning) are
+		// reapplied on replay, so only the handler's own are stored.
+		before := w.Header().Clone()
+		rec := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+		finished := false
+		defer func() {
+			// A panicking handler is answered by the recovery middleware;
+			// drop the reservation so retries are not refused forever.
+			if !finished {
+				c.release(scope)
+			}
+		}()
+		h.ServeHTTP(rec, r)
+		c.finish(scope, rec, handlerHeaders(before, w.Header()))
+		finished = true
+	})
+}
+
+// reserve returns the live entry for scope, creating an in-flight one when
+// none exists. fresh reports whether the caller should run the request.
+func (c *idempotencyCache) reserve(scope string, fingerprint [sha256.Size]byte) (*idempotencyEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for k, e := range c.entries {
+		if e.done && now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	if e, ok := c.entries[scope]; ok {
+		return e, false
+	}
+	e := &idempotencyEntry{fingerprint: fingerprint}
+	c.entries[scope] = e
+	return e, true
+}
+
+// release forgets scope, letting the next request with its key run.
+func (c *idempotencyCache) release(scope string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, scope)
+}
+
+func (c *idempotencyCache) finish(scope string, rec *recordingWriter, header http.Header) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if rec.status >= http.StatusInternalServerError {
+		delete(c.entries, scope)
+		return
+	}
+	e := c.entries[scope]
+	e.status = rec.status
+	e.header = header
+	e.body = rec.body.Bytes()
+	e.expires = c.now().Add(idempotencyTTL)
+	e.done = true
+}
+
+func handlerHeaders(before, after http.Header) http.Header {
+	out := make(http.Header)
+	for name, values := range after {
+		if strings.Join(before[name], "\x00") != strings.Join(values, "\x00") {
+			out[name] = append([]string(nil), values...)
+		}
+	}
+	return out
+}
+
+// recordingWriter passes a response through while keeping a copy of it.
+type recordingWriter struct {
+	http.ResponseWriter
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
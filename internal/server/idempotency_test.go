@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIdempotentRejectsOversizeBody(t *testing.T) {
+	t.Parallel()
+	called := false
+	h := newIdempotencyCache().idempotent(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/page", strings.NewReader(strings.Repeat("x", maxIdempotentBody+1)))
+	req.Header.Set(IdempotencyKeyHeader, "big")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge || called {
+		t.Fatalf("expected 413 without running the handler, got %d (called=%v)", rec.Code, called)
+	}
+}
+
+func TestIdempotentReleasesKeyWhenHandlerPanics(t *testing.T) {
+	t.Parallel()
+	calls := 0
+	h := recoveryMiddleware(newIdempotencyCache().idempotent(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusCreated)
+	})))
+
+	send := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/api/page", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "retry")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := send(); code != http.StatusInternalServerError {
+		t.Fatalf("expected the panic answered with 500, got %d", code)
+	}
+	if code := send(); code != http.StatusCreated || calls != 2 {
+		t.Fatalf("expected the retry to run the handler, got %d after %d calls", code, calls)
+	}
+}
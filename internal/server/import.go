@@ -0,0 +1,170 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/euforicio/wikimd/internal/renderer/headingid"
+	"github.com/euforicio/wikimd/internal/webimport"
+)
+
+// importedFrontmatter records where a clipped page came from.
+type importedFrontmatter struct {
+	Title    string `yaml:"title,omitempty"`
+	Source   string `yaml:"source"`
+	Imported string `yaml:"imported"`
+}
+
+// handleImportURL clips a web page into a new document. Images are saved in
+// media/<page name>/ beside the document and linked relatively.
+func (s *Server) handleImportURL(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var payload struct {
+		URL  string `json:"url"`
+		Path string `json:"path"`
+	}
+	if err := decodeJSON(r, &payload); err != nil {
+		s.logger.WarnContext(ctx, "decode import payload failed", slog.Any("err", err))
+		respondError(w, http.StatusBadRequest, newAPIError(codeInvalidJSON, "invalid JSON payload"))
+		return
+	}
+	rawURL := strings.TrimSpace(payload.URL)
+	docPath := strings.TrimSpace(payload.Path)
+	if !validateRequest(w, importURLSchema, map[string]string{"url": rawURL, "path": docPath}) {
+		return
+	}
+	if docPath != "" {
+		docPath = withMarkdownExt(docPath)
+		if s.rootEntryExists(docPath) {
+			respondError(w, http.StatusConflict, newAPIError(codeConflict, "document already exists").withPath(docPath))
+			return
+		}
+	}
+
+	page, err := webimport.New(webimport.Options{}).Import(ctx, rawURL)
+	if err != nil {
+		s.logger.WarnContext(ctx, "import url failed", slog.Any("err", err), slog.String("url", rawURL))
+		if errors.Is(err, webimport.ErrNotHTML) {
+			respondError(w, http.StatusUnprocessableEntity, newAPIError(codeValidation, err.Error()).withField("url"))
+			return
+		}
+		respondError(w, http.StatusBadGateway, newAPIError(codeUnavailable, "fetch failed: "+err.Error()))
+		return
+	}
+
+	if docPath == "" {
+		docPath = s.importPath(page.Title)
+	}
+	mediaDir := s.importMediaDir(docPath)
+	relMedia := strings.TrimPrefix(mediaDir, path.Dir(docPath)+"/")
+
+	meta, err := yaml.Marshal(importedFrontmatter{
+		Title:    page.Title,
+		Source:   page.Source,
+		Imported: time.Now().Format(time.DateOnly),
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, newAPIError(codeInternal, "encode frontmatter failed"))
+		return
+	}
+	content := "---\n" + string(meta) + "---\n\n" + page.Markdown(relMedia)
+	if !validateRequest(w, writePageSchema, map[string]string{"path": docPath, "content": content}) {
+		return
+	}
+
+	var images []string
+	for _, img := range page.Images {
+		rel := mediaDir + "/" + img.Name
+		if err := s.content.CreateAsset(ctx, rel, img.Data); err != nil {
+			s.removeImportMedia(mediaDir)
+			status, apiErr := contentError(err)
+			s.logger.WarnContext(ctx, "save imported image failed", slog.Any("err", err), slog.String("path", rel))
+			respondError(w, status, apiErr.withPath(rel))
+			return
+		}
+		images = append(images, rel)
+	}
+
+	if err := s.content.CreateDocument(ctx, docPath, []byte(content)); err != nil {
+		s.removeImportMedia(mediaDir)
+		status, apiErr := contentError(err)
+		if errors.Is(err, os.ErrNotExist) {
+			status = http.StatusBadRequest
+		}
+		s.logger.WarnContext(ctx, "create imported document failed", slog.Any("err", err), slog.String("path", docPath))
+		respondError(w, status, apiErr.withPath(docPath))
+		return
+	}
+
+	resp := struct {
+		Path   string   `json:"path"`
+		Title  string   `json:"title"`
+		Source string   `json:"source"`
+		Images []string `json:"images"`
+	}{
+		Path:   docPath,
+		Title:  page.Title,
+		Source: page.Source,
+		Images: images,
+	}
+	if resp.Images == nil {
+		resp.Images = []string{}
+	}
+	respondJSON(w, http.StatusCreated, resp)
+}
+
+// importPath names a document after the page title, adding a numeric suffix
+// when the name is taken.
+func (s *Server) importPath(title string) string {
+	slug := headingid.Slug([]byte(title))
+	if slug == "" {
+		slug = "imported-page"
+	}
+	name := slug + ".md"
+	for i := 1; s.rootEntryExists(name); i++ {
+		name = slug + "-" + strconv.Itoa(i) + ".md"
+	}
+	return name
+}
+
+// importMediaDir returns a folder for the images of docPath that does not
+// exist yet, so an import never mixes its files with another's.
+func (s *Server) importMediaDir(docPath string) string {
+	stem := strings.TrimSuffix(path.Base(docPath), path.Ext(docPath))
+	dir := path.Join(path.Dir(docPath), "media", stem)
+	candidate := dir
+	for i := 1; s.rootEntryExists(candidate); i++ {
+		candidate = dir + "-" + strconv.Itoa(i)
+	}
+	return candidate
+}
+
+func (s *Server) rootEntryExists(rel string) bool {
+	_, err := os.Stat(filepath.Join(s.cfg.RootDir, filepath.FromSlash(rel)))
+	return err == nil
+}
+
+// removeImportMedia deletes the image folder of a failed import. The folder
+// was chosen because it did not exist, so it only holds this import's files.
+func (s *Server) removeImportMedia(mediaDir string) {
+	if err := os.RemoveAll(filepath.Join(s.cfg.RootDir, filepath.FromSlash(mediaDir))); err != nil {
+		s.logger.Warn("remove imported media failed", slog.Any("err", err), slog.String("dir", mediaDir))
+	}
+}
+
+func withMarkdownExt(p string) string {
+	if isMarkdownFile(p) {
+		return p
+	}
+	return p + ".md"
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportURL(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServer(t)
+	t.Cleanup(cleanup)
+
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/guide":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><head><title>Field Guide</title></head><body>
+<article><h1>Field Guide</h1><p>Pack light.</p><img src="map.png" alt="Map"></article></body></html>`))
+		case "/map.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("\x89PNG\r\n\x1a\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(site.Close)
+
+	post := func(body map[string]string) *httptest.ResponseRecorder {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/api/import/url", strings.NewReader(string(raw)))
+		req.Host = "localhost:8080"
+		req.Header.Set("Origin", "http://localhost:8080")
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+	type importResponse struct {
+		Path   string   `json:"path"`
+		Title  string   `json:"title"`
+		Images []string `json:"images"`
+	}
+
+	rec := post(map[string]string{"url": site.URL + "/guide"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp importResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode import: %v", err)
+	}
+	if resp.Path != "field-guide.md" || resp.Title != "Field Guide" {
+		t.Fatalf("expected the page named after its title, got %+v", resp)
+	}
+	if len(resp.Images) != 1 || resp.Images[0] != "media/field-guide/map.png" {
+		t.Fatalf("expected the image in the media folder, got %v", resp.Images)
+	}
+	if _, err := os.Stat(filepath.Join(srv.cfg.RootDir, "media", "field-guide", "map.png")); err != nil {
+		t.Fatalf("expected the image on disk: %v", err)
+	}
+	raw, err := os.ReadFile(filepath.Join(srv.cfg.RootDir, "field-guide.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := string(raw)
+	for _, want := range []string{"title: Field Guide\n", "source: " + site.URL + "/guide\n", "Pack light.", "![Map](media/field-guide/map.png)"} {
+		if !strings.Contains(doc, want) {
+			t.Fatalf("expected document to contain %q, got:\n%s", want, doc)
+		}
+	}
+
+	// A second import of the same page gets fresh names.
+	rec = post(map[string]string{"url": site.URL + "/guide"})
+	resp = importResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode import: %v", err)
+	}
+	if resp.Path != "field-guide-1.md" || len(resp.Images) != 1 || resp.Images[0] != "media/field-guide-1/map.png" {
+		t.Fatalf("expected suffixed names, got %+v", resp)
+	}
+
+	if rec := post(map[string]string{"url": site.URL + "/guide", "path": "field-guide.md"}); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for an existing path, got %d", rec.Code)
+	}
+	if rec := post(map[string]string{"url": "file:///etc/passwd"}); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for a non-web URL, got %d", rec.Code)
+	}
+	if rec := post(map[string]string{"url": site.URL + "/missing"}); rec.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502 when the fetch fails, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/inventory"
+)
+
+// handleInventory lists every page with its title, tags, owner, modification
+// time, word count, and broken-link count, as JSON or, with format=csv, as a
+// spreadsheet download.
+func (s *Server) handleInventory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	if format != "" && format != "json" && format != "csv" {
+		respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "invalid format. Supported formats: json, csv").withField("format"))
+		return
+	}
+
+	engine, site, ok := s.prepareLint(ctx, w, "links")
+	if !ok {
+		return
+	}
+	root, err := s.content.CurrentTree(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "load content tree failed", slog.Any("err", err))
+		respondError(w, http.StatusInternalServerError, newAPIError(codeInternal, "failed to load content tree"))
+		return
+	}
+	report, err := engine.Lint(ctx, site)
+	if err != nil {
+		status, apiErr := contentError(err)
+		respondError(w, status, apiErr)
+		return
+	}
+	rows := inventory.Build(root, site, report)
+
+	if format == "csv" {
+		name := filepath.Base(s.cfg.RootDir) + "-inventory-" + time.Now().Format("2006-01-02") + ".csv"
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+		w.WriteHeader(http.StatusOK)
+		if err := inventory.WriteCSV(w, rows); err != nil {
+			s.logger.ErrorContext(ctx, "write inventory csv failed", slog.Any("err", err))
+		}
+		return
+	}
+
+	resp := struct {
+		Pages []inventory.Row `json:"pages"`
+		Count int             `json:"count"`
+	}{
+		Pages: rows,
+		Count: len(rows),
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,409 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/euforicio/wikimd/internal/exporter"
+	"github.com/euforicio/wikimd/internal/jobs"
+)
+
+// Job kinds started through the API.
+const (
+	jobReindex = "reindex"
+	jobExport  = "export"
+)
+
+// maxExportArtifacts bounds how many finished exports are kept on disk for
+// download; the oldest is removed first.
+const maxExportArtifacts = 10
+
+// jobHeader names the job recording a synchronous export's outcome.
+const jobHeader = "X-Wikimd-Job"
+
+// exportBufferLimit is how much export output is held back so a failure can
+// still be reported with an error status. Larger exports are streamed and
+// report their outcome in the export status trailers instead.
+const exportBufferLimit = 8 << 20
+
+// Trailers sent after a streamed export: exportStatusTrailer is "ok" or
+// "failed", and exportErrorTrailer carries the failure message.
+const (
+	exportStatusTrailer = "X-Export-Status"
+	exportErrorTrailer  = "X-Export-Error"
+)
+
+// jobEvent is the SSE payload announcing a job status change.
+type jobEvent struct {
+	Job  jobs.Status `json:"job"`
+	Type string      `json:"type"`
+}
+
+func (s *Server) handleListJobs(w http.ResponseWriter, _ *http.Request) {
+	list := s.jobs.List()
+	respondJSON(w, http.StatusOK, map[string]any{
+		"jobs":  list,
+		"count": len(list),
+	})
+}
+
+func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	status, err := s.jobs.Get(r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusNotFound, newAPIError(codeNotFound, "job not found"))
+		return
+	}
+	respondJSON(w, http.StatusOK, status)
+}
+
+// handleCancelJob stops a running job. The job reports StateCanceled once its
+// work has unwound, so the response may still show it running.
+func (s *Server) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	status, err := s.jobs.Cancel(r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusNotFound, newAPIError(codeNotFound, "job not found"))
+		return
+	}
+	respondJSON(w, http.StatusAccepted, status)
+}
+
+// handleReindex rebuilds the content tree and search index as a job.
+func (s *Server) handleReindex(w http.ResponseWriter, _ *http.Request) {
+	status := s.jobs.Start(jobReindex, s.reindex)
+	w.Header().Set("Location", "/api/jobs/"+status.ID)
+	respondJSON(w, http.StatusAccepted, status)
+}
+
+func (s *Server) reindex(ctx context.Context, p *jobs.Progress) error {
+	var scanned int
+	err := s.content.Reindex(ctx, func(done, total int) {
+		scanned = done
+		p.Set(done, total, "scanning documents")
+	})
+	if err != nil {
+		return fmt.Errorf("rebuild tree: %w", err)
+	}
+	if s.search == nil {
+		return nil
+	}
+	p.Set(scanned, scanned, "building search index")
+	if err := s.search.Index(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		return fmt.Errorf("build search index: %w", err)
+	}
+	return ctx.Err()
+}
+
+// exportArtifact is the output of a finished export job.
+type exportArtifact struct {
+	path        string
+	filename    string
+	contentType string
+}
+
+// artifactStore keeps export job outputs in a temporary directory until
+// they are downloaded or displaced by newer ones.
+type artifactStore struct {
+	files map[string]exportArtifact
+	dir   string
+	order []string
+	mu    sync.Mutex
+}
+
+func newArtifactStore() *artifactStore {
+	return &artifactStore{files: make(map[string]exportArtifact)}
+}
+
+// create opens a new file for a job's output.
+func (a *artifactStore) create(jobID string) (*os.File, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.dir == "" {
+		dir, err := os.MkdirTemp("", "wikimd-exports-")
+		if err != nil {
+			return nil, fmt.Errorf("create export directory: %w", err)
+		}
+		a.dir = dir
+	}
+	return os.CreateTemp(a.dir, jobID+"-*")
+}
+
+func (a *artifactStore) add(jobID string, artifact exportArtifact) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.files[jobID] = artifact
+	a.order = append(a.order, jobID)
+	for len(a.order) > maxExportArtifacts {
+		oldest := a.order[0]
+		a.order = a.order[1:]
+		_ = os.Remove(a.files[oldest].path)
+		delete(a.files, oldest)
+	}
+}
+
+func (a *artifactStore) get(jobID string) (exportArtifact, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	artifact, ok := a.files[jobID]
+	return artifact, ok
+}
+
+// close removes every stored artifact.
+func (a *artifactStore) close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.dir != "" {
+		_ = os.RemoveAll(a.dir)
+	}
+	a.files = make(map[string]exportArtifact)
+	a.order = nil
+}
+
+// handleStartExport runs an export as a cancellable job instead of streaming
+// it into the response. scope=site renders the whole wiki into one
+// self-contained HTML file; otherwise the page at path is exported in
+// format, as with GET /api/export. The output is downloaded from the job's
+// result URL.
+func (s *Server) handleStartExport(w http.ResponseWriter, r *http.Request) {
+	var run jobs.Func
+	switch scope := r.URL.Query().Get("scope"); scope {
+	case "site":
+		run = s.exportSite
+	case "", "page":
+		cleanPath, format, ok := s.parseExportRequest(w, r)
+		if !ok {
+			return
+		}
+		run = func(ctx context.Context, p *jobs.Progress) error {
+			return s.exportPageJob(ctx, p, cleanPath, exporter.Format(format))
+		}
+	default:
+		respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "scope must be page or site").withField("scope"))
+		return
+	}
+	status := s.jobs.Start(jobExport, run)
+	w.Header().Set("Location", "/api/jobs/"+status.ID)
+	respondJSON(w, http.StatusAccepted, status)
+}
+
+// handleBatchPDF starts a job compiling the posted pages, in order, into one
+// PDF with a cover and contents page.
+func (s *Server) handleBatchPDF(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Title string   `json:"title"`
+		Paths []string `json:"paths"`
+	}
+	if err := decodeJSON(r, &payload); err != nil {
+		respondError(w, http.StatusBadRequest, newAPIError(codeInvalidJSON, "invalid JSON payload"))
+		return
+	}
+	if len(payload.Paths) == 0 || len(payload.Paths) > exporter.MaxBatchPages {
+		msg := fmt.Sprintf("paths must list between 1 and %d documents", exporter.MaxBatchPages)
+		respondError(w, http.StatusUnprocessableEntity, newAPIError(codeValidation, msg).withField("paths"))
+		return
+	}
+	for _, path := range payload.Paths {
+		if !validateRequest(w, pathSchema, map[string]string{"path": path}) {
+			return
+		}
+		if info, err := os.Stat(filepath.Join(s.cfg.RootDir, filepath.FromSlash(path))); err != nil || info.IsDir() {
+			respondError(w, http.StatusNotFound, newAPIError(codeNotFound, "document not found").withPath(path))
+			return
+		}
+	}
+
+	title := strings.TrimSpace(payload.Title)
+	paths := payload.Paths
+	status := s.jobs.Start(jobExport, func(ctx context.Context, p *jobs.Progress) error {
+		return s.batchPDFJob(ctx, p, title, paths)
+	})
+	w.Header().Set("Location", "/api/jobs/"+status.ID)
+	respondJSON(w, http.StatusAccepted, status)
+}
+
+func (s *Server) batchPDFJob(ctx context.Context, p *jobs.Progress, title string, paths []string) error {
+	p.Set(0, 1, fmt.Sprintf("compiling %d pages", len(paths)))
+	f, err := s.artifacts.create(p.ID())
+	if err != nil {
+		return err
+	}
+	err = s.exporter.ExportBatchPDF(ctx, exporter.BatchPDFOptions{
+		RootDir: s.cfg.RootDir,
+		Title:   title,
+		Paths:   paths,
+		Date:    time.Now(),
+		Writer:  f,
+	})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	name := "handout"
+	if title != "" {
+		name = sanitizeFilename(title)
+	}
+	s.finishExport(p, exportArtifact{
+		path:        f.Name(),
+		filename:    name + exporter.FileExtension(exporter.FormatPDF),
+		contentType: exporter.ContentType(exporter.FormatPDF),
+	})
+	p.Set(1, 1, "")
+	return nil
+}
+
+func (s *Server) exportPageJob(ctx context.Context, p *jobs.Progress, path string, format exporter.Format) error {
+	p.Set(0, 1, "exporting "+path)
+	f, err := s.artifacts.create(p.ID())
+	if err != nil {
+		return err
+	}
+	err = s.exporter.ExportPage(ctx, exporter.ExportPageOptions{
+		RootDir: s.cfg.RootDir,
+		Path:    path,
+		Format:  format,
+		Writer:  f,
+	})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	s.finishExport(p, exportArtifact{
+		path:        f.Name(),
+		filename:    sanitizeFilename(path) + exporter.FileExtension(format),
+		contentType: exporter.ContentType(format),
+	})
+	p.Set(1, 1, "")
+	return nil
+}
+
+func (s *Server) exportSite(ctx context.Context, p *jobs.Progress) error {
+	// The live tree already reflects the exclusion settings, and its pages
+	// are in the shared renderer's cache.
+	root, err := s.content.CurrentTree(ctx)
+	if err != nil {
+		return fmt.Errorf("load content tree: %w", err)
+	}
+	format, _ := findWizardFormat(wizardSingleFile)
+	return s.exportTreeJob(ctx, p, root, format, wizardRequest{Title: filepath.Base(s.cfg.RootDir)})
+}
+
+func (s *Server) finishExport(p *jobs.Progress, artifact exportArtifact) {
+	s.artifacts.add(p.ID(), artifact)
+	p.SetResult("/api/jobs/" + p.ID() + "/result")
+}
+
+// handleJobResult downloads the output of a finished export job.
+func (s *Server) handleJobResult(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	artifact, ok := s.artifacts.get(id)
+	if !ok {
+		if status, err := s.jobs.Get(id); err == nil && status.State == jobs.StateRunning {
+			respondError(w, http.StatusConflict, newAPIError(codeConflict, "job is still running"))
+			return
+		}
+		respondError(w, http.StatusNotFound, newAPIError(codeNotFound, "no result for this job"))
+		return
+	}
+	f, err := os.Open(artifact.path)
+	if err != nil {
+		s.logger.ErrorContext(r.Context(), "open export result failed", slog.Any("err", err), slog.String("job", id))
+		respondError(w, http.StatusNotFound, newAPIError(codeNotFound, "no result for this job"))
+		return
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, newAPIError(codeInternal, "failed to read result"))
+		return
+	}
+	w.Header().Set("Content-Type", artifact.contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", artifact.filename))
+	http.ServeContent(w, r, artifact.filename, info.ModTime(), f)
+}
+
+// exportWriter buffers an export response up to exportBufferLimit, then
+// switches to streaming with the outcome announced in trailers.
+type exportWriter struct {
+	w         http.ResponseWriter
+	buf       bytes.Buffer
+	streaming bool
+}
+
+func (e *exportWriter) Write(p []byte) (int, error) {
+	if e.streaming {
+		return e.w.Write(p)
+	}
+	if e.buf.Len()+len(p) <= exportBufferLimit {
+		return e.buf.Write(p)
+	}
+	e.streaming = true
+	e.w.Header().Set("Trailer", exportStatusTrailer+", "+exportErrorTrailer)
+	e.w.WriteHeader(http.StatusOK)
+	if _, err := e.w.Write(e.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	e.buf.Reset()
+	return e.w.Write(p)
+}
+
+// maxTrailerValue caps the error trailer; the full error is on the job
+// named in the X-Wikimd-Job header.
+const maxTrailerValue = 256
+
+// trailerValue makes msg safe to send as a single trailer line, replacing
+// line breaks and other control characters and truncating long messages.
+func trailerValue(msg string) string {
+	msg = strings.Join(strings.FieldsFunc(msg, unicode.IsControl), " ")
+	if len(msg) <= maxTrailerValue {
+		return msg
+	}
+	cut := maxTrailerValue
+	for cut > 0 && !utf8.RuneStart(msg[cut]) {
+		cut--
+	}
+	return msg[:cut] + "..."
+}
+
+// finish completes the response for an export that ended with err.
+func (e *exportWriter) finish(err error) {
+	if e.streaming {
+		if err != nil {
+			e.w.Header().Set(exportStatusTrailer, "failed")
+			e.w.Header().Set(exportErrorTrailer, trailerValue(err.Error()))
+			return
+		}
+		e.w.Header().Set(exportStatusTrailer, "ok")
+		return
+	}
+	if err != nil {
+		e.w.Header().Del("Content-Disposition")
+		respondError(e.w, http.StatusInternalServerError, newAPIError(codeInternal, "export failed"))
+		return
+	}
+	e.w.Header().Set("Content-Length", strconv.Itoa(e.buf.Len()))
+	e.w.WriteHeader(http.StatusOK)
+	_, _ = e.w.Write(e.buf.Bytes())
+}
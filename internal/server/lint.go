@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/euforicio/wikimd/internal/lint"
+)
+
+// handleLint runs the lint engine over the wiki (or the documents named by
+// repeated path parameters) and returns the report as JSON or SARIF.
+func (s *Server) handleLint(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	if format != "" && format != "json" && format != "sarif" {
+		respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "invalid format. Supported formats: json, sarif").withField("format"))
+		return
+	}
+
+	engine, site, ok := s.prepareLint(ctx, w, r.URL.Query().Get("pass"))
+	if !ok {
+		return
+	}
+
+	var paths []string
+	for _, p := range r.URL.Query()["path"] {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+
+	report, err := engine.Lint(ctx, site, paths...)
+	if err != nil {
+		status, apiErr := contentError(err)
+		respondError(w, status, apiErr)
+		return
+	}
+
+	if format == "sarif" {
+		w.Header().Set("Content-Type", "application/sarif+json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		if err := lint.WriteSARIF(w, report, engine.Rules()); err != nil {
+			s.logger.ErrorContext(ctx, "encode sarif failed", slog.Any("err", err))
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+// handleLintDraft lints unsaved editor content so the preview can show inline
+// warnings before the document is written. Links are resolved against the
+// saved wiki, with the draft standing in for its own path.
+func (s *Server) handleLintDraft(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var payload struct {
+		Path    string `json:"path"`
+		Content string `json:"content"`
+		Pass    string `json:"pass"`
+	}
+	if err := decodeJSON(r, &payload); err != nil {
+		s.logger.WarnContext(ctx, "decode lint payload failed", slog.Any("err", err))
+		respondError(w, http.StatusBadRequest, newAPIError(codeInvalidJSON, "invalid JSON payload"))
+		return
+	}
+	path := strings.TrimSpace(payload.Path)
+	if !validateRequest(w, lintDraftSchema, map[string]string{"path": path, "content": payload.Content}) {
+		return
+	}
+
+	engine, site, ok := s.prepareLint(ctx, w, payload.Pass)
+	if !ok {
+		return
+	}
+
+	doc, err := lint.Parse(path, []byte(payload.Content))
+	if err != nil {
+		respondError(w, http.StatusUnprocessableEntity, newAPIError(codeValidation, err.Error()).withField("content").withPath(path))
+		return
+	}
+	site.Put(doc)
+
+	report, err := engine.Lint(ctx, site, path)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "lint draft failed", slog.Any("err", err), slog.String("path", path))
+		respondError(w, http.StatusInternalServerError, newAPIError(codeInternal, "failed to lint draft").withPath(path))
+		return
+	}
+	respondJSON(w, http.StatusOK, report)
+}
+
+// prepareLint loads the lint configuration and the current site for a request.
+// It writes an error response and reports false when either cannot be loaded.
+func (s *Server) prepareLint(ctx context.Context, w http.ResponseWriter, pass string) (*lint.Engine, *lint.Site, bool) {
+	pass = strings.ToLower(strings.TrimSpace(pass))
+	if pass == "" {
+		pass = "all"
+	}
+	rules, ok := lint.Passes[pass]
+	if !ok {
+		respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "invalid pass. Supported passes: all, accessibility, links").withField("pass"))
+		return nil, nil, false
+	}
+
+	cfg, err := lint.LoadConfig(s.cfg.RootDir)
+	if err != nil {
+		s.logger.WarnContext(ctx, "load lint config failed", slog.Any("err", err))
+		respondError(w, http.StatusUnprocessableEntity, newAPIError(codeValidation, err.Error()).withPath(lint.ConfigFile))
+		return nil, nil, false
+	}
+
+	root, err := s.content.CurrentTree(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "load content tree failed", slog.Any("err", err))
+		respondError(w, http.StatusInternalServerError, newAPIError(codeInternal, "failed to load content tree"))
+		return nil, nil, false
+	}
+
+	site, err := lint.LoadSite(ctx, s.cfg.RootDir, root)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "load lint site failed", slog.Any("err", err))
+		respondError(w, http.StatusInternalServerError, newAPIError(codeInternal, "failed to read documents"))
+		return nil, nil, false
+	}
+
+	return lint.NewEngine(cfg, rules()...), site, true
+}
+
+// handleExternalLinks reports the cached results of the background external
+// link checker. Only dead links are listed unless all=true.
+func (s *Server) handleExternalLinks(w http.ResponseWriter, r *http.Request) {
+	if s.links == nil {
+		respondError(w, http.StatusServiceUnavailable, newAPIError(codeUnavailable, "external link checking is disabled (start with --check-links)"))
+		return
+	}
+	all, _ := strconv.ParseBool(r.URL.Query().Get("all"))
+	respondJSON(w, http.StatusOK, s.links.Snapshot(!all))
+}
+
+// collectExternalLinks feeds the link checker from the current content tree.
+func (s *Server) collectExternalLinks(ctx context.Context) (map[string][]string, error) {
+	root, err := s.content.CurrentTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	site, err := lint.LoadSite(ctx, s.cfg.RootDir, root)
+	if err != nil {
+		return nil, err
+	}
+	return site.ExternalLinks(), nil
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/euforicio/wikimd/internal/merge"
+)
+
+// handlePageMerge serves POST /api/page/{path}/merge. When a save would
+// overwrite edits made since the editor loaded the page, the client posts
+// the text it started from (base, or baseRev naming a git revision) and its
+// edited content. The server merges that edit with the page as it is now
+// and returns the merged markdown, with git-style conflict markers and a
+// list of conflicting hunks when both sides changed the same lines. Nothing
+// is saved.
+func (s *Server) handlePageMerge(w http.ResponseWriter, r *http.Request, path string) {
+	ctx := r.Context()
+	var payload struct {
+		Base    *string `json:"base"`
+		BaseRev string  `json:"baseRev"`
+		Content string  `json:"content"`
+	}
+	if err := decodeJSON(r, &payload); err != nil {
+		respondError(w, http.StatusBadRequest, newAPIError(codeInvalidJSON, "invalid JSON payload"))
+		return
+	}
+	values := map[string]string{"path": path, "content": payload.Content}
+	if payload.Base != nil {
+		values["base"] = *payload.Base
+	}
+	if !validateRequest(w, mergeSchema, values) {
+		return
+	}
+
+	var base string
+	switch {
+	case payload.BaseRev != "":
+		if !validRev(payload.BaseRev) {
+			respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "invalid revision").withField("baseRev"))
+			return
+		}
+		raw, err := runGit(ctx, s.cfg.RootDir, "show", payload.BaseRev+":./"+path)
+		if err != nil {
+			s.logger.WarnContext(ctx, "load merge base failed", slog.Any("err", err), slog.String("path", path))
+			respondError(w, http.StatusNotFound, newAPIError(codeNotFound, "revision not found").withPath(path).withField("baseRev"))
+			return
+		}
+		base = string(raw)
+	case payload.Base != nil:
+		base = *payload.Base
+	default:
+		respondError(w, http.StatusUnprocessableEntity, newAPIError(codeValidation, "base or baseRev is required").withField("base"))
+		return
+	}
+
+	current, err := s.content.Document(ctx, path)
+	if err != nil {
+		status, apiErr := contentError(err)
+		s.logger.WarnContext(ctx, "load page for merge failed", slog.Any("err", err), slog.String("path", path))
+		respondError(w, status, apiErr.withPath(path))
+		return
+	}
+
+	result := merge.ThreeWay(base, current.Raw, payload.Content, merge.Labels{Ours: "server", Theirs: "yours"})
+	conflicts := result.Conflicts
+	if conflicts == nil {
+		conflicts = []merge.Conflict{}
+	}
+	respondJSON(w, http.StatusOK, struct {
+		Path      string           `json:"path"`
+		Merged    string           `json:"merged"`
+		Conflicts []merge.Conflict `json:"conflicts"`
+		Clean     bool             `json:"clean"`
+	}{Path: path, Merged: result.Text, Clean: result.Clean(), Conflicts: conflicts})
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPageMerge(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServer(t)
+	t.Cleanup(cleanup)
+
+	base := "# Runbook\n\nStep one.\n\nStep two.\n"
+	server := "# Runbook\n\nStep one, carefully.\n\nStep two.\n"
+	if err := os.WriteFile(filepath.Join(srv.cfg.RootDir, "runbook.md"), []byte(server), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(body any) *httptest.ResponseRecorder {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/api/page/runbook.md/merge", strings.NewReader(string(raw)))
+		req.Host = "localhost:8080"
+		req.Header.Set("Origin", "http://localhost:8080")
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+	type mergeResponse struct {
+		Merged    string `json:"merged"`
+		Conflicts []struct {
+			Ours   string `json:"ours"`
+			Theirs string `json:"theirs"`
+		} `json:"conflicts"`
+		Clean bool `json:"clean"`
+	}
+
+	rec := post(map[string]string{"base": base, "content": "# Runbook\n\nStep one.\n\nStep two.\n\nStep three.\n"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp mergeResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode merge: %v", err)
+	}
+	if !resp.Clean || resp.Merged != "# Runbook\n\nStep one, carefully.\n\nStep two.\n\nStep three.\n" {
+		t.Fatalf("expected both edits merged, got %+v", resp)
+	}
+
+	rec = post(map[string]string{"base": base, "content": "# Runbook\n\nStep one, quickly.\n\nStep two.\n"})
+	resp = mergeResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode merge: %v", err)
+	}
+	if resp.Clean || len(resp.Conflicts) != 1 || resp.Conflicts[0].Ours != "Step one, carefully.\n" || resp.Conflicts[0].Theirs != "Step one, quickly.\n" {
+		t.Fatalf("expected one conflict, got %+v", resp)
+	}
+	if !strings.Contains(resp.Merged, "<<<<<<< server\n") || !strings.Contains(resp.Merged, ">>>>>>> yours\n") {
+		t.Fatalf("expected conflict markers, got %q", resp.Merged)
+	}
+
+	if rec := post(map[string]string{"content": "x"}); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 without a base, got %d", rec.Code)
+	}
+	if rec := post(map[string]string{"baseRev": "-p", "content": "x"}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an option-like revision, got %d", rec.Code)
+	}
+	if current, _ := os.ReadFile(filepath.Join(srv.cfg.RootDir, "runbook.md")); string(current) != server {
+		t.Fatalf("merge must not save, page is now %q", current)
+	}
+}
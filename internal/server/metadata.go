@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/euforicio/wikimd/internal/content"
+	"github.com/euforicio/wikimd/internal/content/tree"
+	"github.com/euforicio/wikimd/internal/frontmatter"
+)
+
+// metadataChange is one page a batch patch edits.
+type metadataChange struct {
+	Path   string `json:"path"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+
+	original []byte
+	updated  []byte
+}
+
+// handleMetadataBatch applies a frontmatter patch to every page matching a
+// glob or tag filter. With dryRun it only reports the frontmatter each page
+// would end up with. Every page is patched in memory before anything is
+// written, and pages already saved are restored if a later save fails, so
+// a batch lands whole or not at all.
+func (s *Server) handleMetadataBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var payload struct {
+		Filter struct {
+			Glob string `json:"glob"`
+			Tag  string `json:"tag"`
+		} `json:"filter"`
+		Patch  frontmatter.Patch `json:"patch"`
+		DryRun bool              `json:"dryRun"`
+	}
+	if err := decodeJSON(r, &payload); err != nil {
+		s.logger.WarnContext(ctx, "decode metadata batch failed", slog.Any("err", err))
+		respondError(w, http.StatusBadRequest, newAPIError(codeInvalidJSON, "invalid JSON payload"))
+		return
+	}
+	glob := strings.TrimSpace(payload.Filter.Glob)
+	tag := strings.TrimSpace(payload.Filter.Tag)
+	if glob == "" && tag == "" {
+		respondError(w, http.StatusUnprocessableEntity, newAPIError(codeValidation, "filter.glob or filter.tag is required").withField("filter"))
+		return
+	}
+	if _, err := path.Match(strings.TrimSuffix(glob, "/**"), ""); err != nil {
+		respondError(w, http.StatusUnprocessableEntity, newAPIError(codeValidation, "invalid glob: "+err.Error()).withField("filter.glob"))
+		return
+	}
+	if err := payload.Patch.Validate(); err != nil {
+		respondError(w, http.StatusUnprocessableEntity, newAPIError(codeValidation, err.Error()).withField("patch"))
+		return
+	}
+
+	root, err := s.content.CurrentTree(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "load content tree failed", slog.Any("err", err))
+		respondError(w, http.StatusInternalServerError, newAPIError(codeInternal, "failed to load content tree"))
+		return
+	}
+
+	matched := 0
+	changes := []metadataChange{}
+	for _, n := range filterPages(root, glob, tag) {
+		matched++
+		doc, err := s.content.Document(ctx, n.RelativePath)
+		if err != nil {
+			status, apiErr := contentError(err)
+			respondError(w, status, apiErr.withPath(n.RelativePath))
+			return
+		}
+		original := []byte(doc.Raw)
+		updated, changed, err := payload.Patch.Apply(original)
+		if err != nil {
+			respondError(w, http.StatusUnprocessableEntity, newAPIError(codeValidation, "frontmatter: "+err.Error()).withPath(n.RelativePath))
+			return
+		}
+		if !changed {
+			continue
+		}
+		if n.ReadOnly {
+			status, apiErr := contentError(fmt.Errorf("%s is read-only: %w", n.RelativePath, content.ErrFrozen))
+			respondError(w, status, apiErr.withPath(n.RelativePath))
+			return
+		}
+		if !validateRequest(w, writePageSchema, map[string]string{"path": n.RelativePath, "content": string(updated)}) {
+			return
+		}
+		before, _ := frontmatter.Block(original)
+		after, _ := frontmatter.Block(updated)
+		changes = append(changes, metadataChange{
+			Path:     n.RelativePath,
+			Before:   before,
+			After:    after,
+			original: original,
+			updated:  updated,
+		})
+	}
+
+	if !payload.DryRun {
+		for i, c := range changes {
+			if err := s.content.SaveDocument(ctx, c.Path, c.updated); err != nil {
+				s.logger.WarnContext(ctx, "metadata batch save failed", slog.Any("err", err), slog.String("path", c.Path))
+				// The request may have been cancelled; the rollback must still run.
+				s.restorePages(context.WithoutCancel(ctx), changes[:i])
+				status, apiErr := contentError(err)
+				apiErr.Message = "batch rolled back: " + apiErr.Message
+				respondError(w, status, apiErr.withPath(c.Path))
+				return
+			}
+		}
+	}
+
+	resp := struct {
+		Changes []metadataChange `json:"changes"`
+		Matched int              `json:"matched"`
+		Changed int              `json:"changed"`
+		DryRun  bool             `json:"dryRun"`
+	}{
+		Changes: changes,
+		Matched: matched,
+		Changed: len(changes),
+		DryRun:  payload.DryRun,
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// restorePages writes back the original content of pages a failed batch
+// already saved.
+func (s *Server) restorePages(ctx context.Context, changes []metadataChange) {
+	for _, c := range changes {
+		if err := s.content.SaveDocument(ctx, c.Path, c.original); err != nil {
+			s.logger.ErrorContext(ctx, "metadata batch rollback failed", slog.Any("err", err), slog.String("path", c.Path))
+		}
+	}
+}
+
+// filterPages returns the pages under root matching glob (path.Match syntax,
+// with a trailing "/**" for everything below a folder) and carrying tag.
+// An empty glob or tag matches every page.
+func filterPages(root *tree.Node, glob, tag string) []*tree.Node {
+	var out []*tree.Node
+	var walk func(*tree.Node)
+	walk = func(n *tree.Node) {
+		if n == nil {
+			return
+		}
+		if n.Type == tree.NodeTypeFile && matchPageGlob(glob, n.RelativePath) {
+			if tag == "" || (n.Metadata != nil && slices.Contains(n.Metadata.Tags, tag)) {
+				out = append(out, n)
+			}
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+	return out
+}
+
+func matchPageGlob(glob, rel string) bool {
+	if glob == "" {
+		return true
+	}
+	if dir, ok := strings.CutSuffix(glob, "/**"); ok {
+		return strings.HasPrefix(rel, dir+"/")
+	}
+	ok, err := path.Match(glob, rel)
+	return err == nil && ok
+}
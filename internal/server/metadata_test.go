@@ -0,0 +1,109 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetadataBatch(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServer(t)
+	t.Cleanup(cleanup)
+
+	dir := filepath.Join(srv.cfg.RootDir, "runbooks")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	pages := map[string]string{
+		"db.md":    "---\ntitle: Database\ntags: [ops]\n---\n# Database\n",
+		"cache.md": "---\ntitle: Cache\ntags: [ops, wip]\n---\n# Cache\n",
+		"notes.md": "# Notes\n",
+	}
+	for name, body := range pages {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/metadata/batch", strings.NewReader(body))
+		req.Host = "localhost:8080"
+		req.Header.Set("Origin", "http://localhost:8080")
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+	type batchResponse struct {
+		Changes []struct {
+			Path  string `json:"path"`
+			After string `json:"after"`
+		} `json:"changes"`
+		Matched int  `json:"matched"`
+		Changed int  `json:"changed"`
+		DryRun  bool `json:"dryRun"`
+	}
+	const body = `{"filter": {"glob": "runbooks/**", "tag": "ops"}, "patch": {"set": {"owner": "ada"}, "removeTags": ["wip"]}%s}`
+
+	var resp batchResponse
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		rec := post(strings.Replace(body, "%s", `, "dryRun": true`, 1))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		resp = batchResponse{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode batch: %v", err)
+		}
+		if resp.Matched == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if !resp.DryRun || resp.Matched != 2 || resp.Changed != 2 {
+		t.Fatalf("expected a preview of both ops pages, got %+v", resp)
+	}
+	if resp.Changes[0].Path != "runbooks/cache.md" || resp.Changes[0].After != "title: Cache\ntags:\n- ops\nowner: ada\n" {
+		t.Fatalf("unexpected preview %+v", resp.Changes[0])
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, "db.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != pages["db.md"] {
+		t.Fatalf("expected dry run to leave files alone, got %q", raw)
+	}
+
+	rec := post(strings.Replace(body, "%s", "", 1))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	raw, err = os.ReadFile(filepath.Join(dir, "db.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "---\ntitle: Database\ntags: [ops]\nowner: ada\n---\n# Database\n"; string(raw) != want {
+		t.Fatalf("expected %q, got %q", want, raw)
+	}
+	raw, err = os.ReadFile(filepath.Join(dir, "notes.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != pages["notes.md"] {
+		t.Fatalf("expected untagged page untouched, got %q", raw)
+	}
+
+	if rec := post(`{"patch": {"set": {"owner": "ada"}}}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 without a filter, got %d", rec.Code)
+	}
+	if rec := post(`{"filter": {"tag": "ops"}, "patch": {}}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for an empty patch, got %d", rec.Code)
+	}
+}
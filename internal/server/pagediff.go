@@ -0,0 +1,229 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/htmldiff"
+)
+
+// pageChange summarises the latest edit to a document for change
+// notifications.
+type pageChange struct {
+	Author  string
+	Added   int
+	Removed int
+}
+
+// String describes the change as "+added -removed lines".
+func (c pageChange) String() string {
+	return fmt.Sprintf("+%d -%d lines", c.Added, c.Removed)
+}
+
+// gitPageChange describes the latest change to the wiki-relative path under
+// root. An uncommitted edit is credited to the local git user, since that is
+// who is editing this checkout; otherwise the last commit touching the path
+// is used. It reports false outside a git repository, when git is missing,
+// or when git has no record of the path.
+func gitPageChange(ctx context.Context, root, path string) (pageChange, bool) {
+	out, err := runGit(ctx, root, "diff", "--numstat", "HEAD", "--", path)
+	if err != nil {
+		return pageChange{}, false
+	}
+	if added, removed, ok := parseNumstat(out); ok {
+		name, _ := runGit(ctx, root, "config", "user.name")
+		return pageChange{Author: strings.TrimSpace(string(name)), Added: added, Removed: removed}, true
+	}
+
+	out, err = runGit(ctx, root, "log", "-1", "--numstat", "--format=%an", "--", path)
+	if err != nil {
+		return pageChange{}, false
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		// Never committed: an untracked document is new in its entirety.
+		raw, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(path))) //nolint:gosec // path validated by callers
+		if err != nil {
+			return pageChange{}, false
+		}
+		name, _ := runGit(ctx, root, "config", "user.name")
+		return pageChange{Author: strings.TrimSpace(string(name)), Added: bytes.Count(raw, []byte("\n"))}, true
+	}
+	author, stats, _ := bytes.Cut(bytes.TrimLeft(out, "\n"), []byte("\n"))
+	change := pageChange{Author: strings.TrimSpace(string(author))}
+	change.Added, change.Removed, _ = parseNumstat(stats)
+	return change, true
+}
+
+// gitPageDiff returns the unified diff of the change gitPageChange describes,
+// or nil when git has none to show.
+func gitPageDiff(ctx context.Context, root, path string) ([]byte, error) {
+	out, err := runGit(ctx, root, "diff", "HEAD", "--", path)
+	if err != nil || len(out) > 0 {
+		return out, err
+	}
+	return runGit(ctx, root, "log", "-1", "-p", "--format=medium", "--", path)
+}
+
+// parseNumstat sums the added and removed counts of git --numstat lines. It
+// reports false when there are none.
+func parseNumstat(out []byte) (int, int, bool) {
+	var added, removed int
+	found := false
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		// Binary files report "-" for both counts.
+		a, _ := strconv.Atoi(fields[0])
+		r, _ := strconv.Atoi(fields[1])
+		added += a
+		removed += r
+		found = true
+	}
+	return added, removed, found
+}
+
+func runGit(ctx context.Context, root string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", root}, args...)...) //nolint:gosec // fixed git subcommands
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// handlePageDiff serves the latest change to a document as a plain-text git
+// diff. Change notifications link here.
+func (s *Server) handlePageDiff(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	path, err := parseWildcardPath(r.PathValue("path"))
+	if err != nil {
+		s.respondPathError(w, err)
+		return
+	}
+	if !validateRequest(w, pathSchema, map[string]string{"path": path}) {
+		return
+	}
+
+	diff, err := gitPageDiff(ctx, s.cfg.RootDir, path)
+	if err != nil {
+		s.logger.WarnContext(ctx, "page diff failed", slog.Any("err", err), slog.String("path", path))
+		respondError(w, http.StatusNotFound, newAPIError(codeNotFound, "no git history for this document").withPath(path))
+		return
+	}
+	if len(diff) == 0 {
+		respondError(w, http.StatusNotFound, newAPIError(codeNotFound, "no changes recorded for this document").withPath(path))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(diff)
+}
+
+// revision names one side of a rendered diff: a document path and a git
+// revision, where an empty revision is the working copy.
+type revision struct {
+	Path string `json:"path"`
+	Rev  string `json:"rev,omitempty"`
+}
+
+// parseRevision splits "path@rev". The text after the last "@" is taken as
+// the revision unless it looks like a markdown file name.
+func parseRevision(spec string) revision {
+	spec = strings.TrimSpace(spec)
+	i := strings.LastIndex(spec, "@")
+	if i < 0 || isMarkdownFile(spec[i+1:]) {
+		return revision{Path: spec}
+	}
+	return revision{Path: spec[:i], Rev: spec[i+1:]}
+}
+
+// validRev rejects revisions git could read as options or as a path.
+func validRev(rev string) bool {
+	return !strings.HasPrefix(rev, "-") && !strings.ContainsAny(rev, ": \t\n\\")
+}
+
+// handleRenderedDiff serves GET /api/diff?a=path@rev&b=path@rev: the two
+// versions rendered and compared word by word, with insertions and
+// deletions marked. b defaults to the working copy of a's document.
+func (s *Server) handleRenderedDiff(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+	if query.Get("a") == "" {
+		respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "a is required").withField("a"))
+		return
+	}
+	a := parseRevision(query.Get("a"))
+	b := revision{Path: a.Path}
+	if query.Get("b") != "" {
+		b = parseRevision(query.Get("b"))
+	}
+
+	var rendered [2]string
+	for i, side := range []revision{a, b} {
+		field := [2]string{"a", "b"}[i]
+		if !validateRequest(w, pathSchema, map[string]string{"path": side.Path}) {
+			return
+		}
+		if side.Rev != "" && !validRev(side.Rev) {
+			respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "invalid revision").withField(field))
+			return
+		}
+		html, err := s.renderRevision(ctx, side)
+		if err != nil {
+			s.logger.WarnContext(ctx, "load revision for diff failed", slog.Any("err", err), slog.String("path", side.Path), slog.String("rev", side.Rev))
+			if side.Rev != "" {
+				respondError(w, http.StatusNotFound, newAPIError(codeNotFound, "revision not found").withPath(side.Path).withField(field))
+				return
+			}
+			status, apiErr := contentError(err)
+			respondError(w, status, apiErr.withPath(side.Path).withField(field))
+			return
+		}
+		rendered[i] = html
+	}
+
+	result := htmldiff.Diff(rendered[0], rendered[1])
+	if strings.EqualFold(query.Get("format"), "html") {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(result.HTML))
+		return
+	}
+	respondJSON(w, http.StatusOK, struct {
+		A        revision `json:"a"`
+		B        revision `json:"b"`
+		HTML     string   `json:"html"`
+		Inserted int      `json:"inserted"`
+		Deleted  int      `json:"deleted"`
+	}{A: a, B: b, HTML: result.HTML, Inserted: result.Inserted, Deleted: result.Deleted})
+}
+
+// renderRevision renders one side of a diff: the working copy, or the
+// document as committed at rev.
+func (s *Server) renderRevision(ctx context.Context, side revision) (string, error) {
+	if side.Rev == "" {
+		doc, err := s.content.Document(ctx, side.Path)
+		return doc.HTML, err
+	}
+	raw, err := runGit(ctx, s.cfg.RootDir, "show", side.Rev+":./"+side.Path)
+	if err != nil {
+		return "", err
+	}
+	doc, err := s.content.RenderSource(ctx, side.Path, time.Time{}, raw)
+	return doc.HTML, err
+}
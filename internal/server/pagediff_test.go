@@ -0,0 +1,69 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderedDiffComparesRevisions(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServer(t)
+	t.Cleanup(cleanup)
+	root := srv.cfg.RootDir
+	if err := os.WriteFile(filepath.Join(root, "policy.md"), []byte("# Policy\n\nStaff get 20 days of leave.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	initGitWiki(t, root)
+	if err := os.WriteFile(filepath.Join(root, "policy.md"), []byte("# Policy\n\nStaff get 25 days of leave.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/diff?"+query, nil))
+		return rec
+	}
+
+	rec := get("a=policy.md@HEAD")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		A        revision `json:"a"`
+		B        revision `json:"b"`
+		HTML     string   `json:"html"`
+		Inserted int      `json:"inserted"`
+		Deleted  int      `json:"deleted"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode diff: %v", err)
+	}
+	if resp.A != (revision{Path: "policy.md", Rev: "HEAD"}) || resp.B != (revision{Path: "policy.md"}) {
+		t.Fatalf("unexpected sides %+v and %+v", resp.A, resp.B)
+	}
+	if !strings.Contains(resp.HTML, "<del>20</del><ins>25</ins>") || resp.Inserted != 1 || resp.Deleted != 1 {
+		t.Fatalf("expected a word-level change, got %+v", resp)
+	}
+
+	rec = get("a=policy.md@HEAD&b=index.md@HEAD&format=html")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("expected an html fragment, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	for query, status := range map[string]int{
+		"":                       http.StatusBadRequest,
+		"a=policy.md@--output=x": http.StatusBadRequest,
+		"a=policy.md@nosuchrev":  http.StatusNotFound,
+		"a=missing.md":           http.StatusNotFound,
+		"a=../secret.md@HEAD":    http.StatusUnprocessableEntity,
+	} {
+		if rec := get(query); rec.Code != status {
+			t.Errorf("%q: expected %d, got %d: %s", query, status, rec.Code, rec.Body.String())
+		}
+	}
+}
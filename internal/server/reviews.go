@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/review"
+	"github.com/euforicio/wikimd/internal/webhook"
+)
+
+// reviewCheckInterval is how often the reminder loop looks for pages that
+// have become overdue since the last check.
+const reviewCheckInterval = time.Hour
+
+// handleOverdueReviews lists pages whose reviewBy date has passed, oldest
+// first.
+func (s *Server) handleOverdueReviews(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	root, err := s.content.CurrentTree(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "load content tree failed", slog.Any("err", err))
+		respondError(w, http.StatusInternalServerError, newAPIError(codeInternal, "failed to load content tree"))
+		return
+	}
+
+	items := review.Overdue(root, time.Now())
+	if items == nil {
+		items = []review.Item{}
+	}
+	resp := struct {
+		Pages []review.Item `json:"pages"`
+		Count int           `json:"count"`
+	}{
+		Pages: items,
+		Count: len(items),
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// runReviewReminders fires a webhook for each page as it becomes overdue,
+// checking once at startup and then every reviewCheckInterval until ctx is
+// done. Pages already overdue at startup are announced once per process.
+func (s *Server) runReviewReminders(ctx context.Context, baseURL string) {
+	tracker := review.NewTracker()
+	ticker := time.NewTicker(reviewCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		root, err := s.content.CurrentTree(ctx)
+		if err != nil {
+			s.logger.WarnContext(ctx, "review reminder check failed", slog.Any("err", err))
+		} else {
+			for _, item := range tracker.Newly(review.Overdue(root, time.Now())) {
+				// Delivery failures are logged by the dispatcher.
+				_ = s.webhooks.Send(ctx, webhook.Event{
+					Type: review.EventOverdue,
+					Data: item,
+					Page: &webhook.Page{
+						Path:    item.Path,
+						Title:   item.Title,
+						URL:     pageURL(baseURL, item.Path),
+						Summary: fmt.Sprintf("Review was due %s.", item.ReviewBy.Format("2006-01-02")),
+					},
+				})
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIVersionHeader negotiates the API version. Clients may send it to pin a
+// version; every /api/ response carries the version that served it.
+const APIVersionHeader = "X-Wikimd-Api-Version"
+
+// apiVersion is the current (and only) API version.
+const apiVersion = 1
+
+// unversionedDeprecatedAt is when the unversioned /api/ paths were superseded
+// by /api/v1/, reported in the Deprecation header (RFC 9745).
+var unversionedDeprecatedAt = time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)
+
+// routeInfo describes a registered HTTP endpoint for the route listing API.
+type routeInfo struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Description string `json:"description"`
+	Deprecated  bool   `json:"deprecated,omitempty"`
+}
+
+// handle registers h on the mux and records the route for GET /api/routes.
+// Patterns use the net/http "METHOD /path" form. API routes are registered
+// twice: under /api/v1/, and under the unversioned /api/ prefix that the
+// bundled UI uses, which signals deprecation to other clients.
+func (s *Server) handle(pattern, description string, h http.Handler) {
+	method, path := "", pattern
+	if idx := strings.IndexByte(pattern, ' '); idx != -1 {
+		method, path = pattern[:idx], strings.TrimSpace(pattern[idx+1:])
+	}
+
+	if rest, ok := strings.CutPrefix(path, "/api/"); ok {
+		switch method {
+		case http.MethodPost, http.MethodPut, http.MethodDelete:
+			h = s.idempotency.idempotent(h)
+		}
+		s.register(method, "/api/v1/"+rest, description, false, versioned(h, false))
+		s.register(method, path, description, true, versioned(h, true))
+		return
+	}
+	s.register(method, path, description, false, h)
+}
+
+func (s *Server) register(method, path, description string, deprecated bool, h http.Handler) {
+	pattern := path
+	if method != "" {
+		pattern = method + " " + path
+	}
+	s.mux.Handle(pattern, h)
+	s.routes = append(s.routes, routeInfo{
+		Method:      method,
+		Path:        path,
+		Description: description,
+		Deprecated:  deprecated,
+	})
+}
+
+// handleFunc is the http.HandlerFunc counterpart of handle.
+func (s *Server) handleFunc(pattern, description string, h http.HandlerFunc) {
+	s.handle(pattern, description, h)
+}
+
+// versioned wraps an API handler with version negotiation. Requests naming a
+// version other than apiVersion are rejected before reaching h. Deprecated
+// (unversioned) routes also point clients at their /api/v1/ successor.
+func versioned(h http.Handler, deprecated bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(APIVersionHeader, strconv.Itoa(apiVersion))
+		if requested := strings.TrimSpace(r.Header.Get(APIVersionHeader)); requested != "" {
+			if n, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(requested), "v")); err != nil || n != apiVersion {
+				respondError(w, http.StatusBadRequest, newAPIError(codeUnsupportedVersion, fmt.Sprintf("unsupported API version %q (supported: %d)", requested, apiVersion)))
+				return
+			}
+		}
+		if deprecated {
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(unversionedDeprecatedAt.Unix(), 10))
+			successor := "/api/v1/" + strings.TrimPrefix(r.URL.Path, "/api/")
+			w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// handleRoutes lists every registered route with its method and description,
+// sorted by path so the output is stable across releases.
+func (s *Server) handleRoutes(w http.ResponseWriter, _ *http.Request) {
+	routes := make([]routeInfo, len(s.routes))
+	copy(routes, s.routes)
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path == routes[j].Path {
+			return routes[i].Method < routes[j].Method
+		}
+		return routes[i].Path < routes[j].Path
+	})
+
+	resp := struct {
+		Routes []routeInfo `json:"routes"`
+		Count  int         `json:"count"`
+	}{
+		Routes: routes,
+		Count:  len(routes),
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
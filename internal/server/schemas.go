@@ -0,0 +1,80 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/euforicio/wikimd/internal/validation"
+)
+
+// maxStatusBytes bounds a kanban status, which is a column name.
+const maxStatusBytes = 100
+
+// Request schemas for the write endpoints. Handlers decode the payload, then
+// validate the fields named here before touching the content service.
+var (
+	documentPathRules = []validation.Rule{validation.Required, validation.DocumentPath}
+
+	pathSchema = validation.Schema{
+		{Name: "path", Rules: documentPathRules},
+	}
+
+	writePageSchema = validation.Schema{
+		{Name: "path", Rules: documentPathRules},
+		{Name: "content", Rules: []validation.Rule{validation.MaxBytes(validation.MaxDocumentBytes), validation.Frontmatter}},
+	}
+
+	renamePageSchema = validation.Schema{
+		{Name: "from", Rules: documentPathRules},
+		{Name: "to", Rules: []validation.Rule{validation.Required, validation.DocumentPath, validation.DiffersFrom("from")}},
+	}
+
+	// Drafts are linted precisely because they may be malformed, so their
+	// frontmatter is not checked here.
+	lintDraftSchema = validation.Schema{
+		{Name: "path", Rules: documentPathRules},
+		{Name: "content", Rules: []validation.Rule{validation.MaxBytes(validation.MaxDocumentBytes)}},
+	}
+
+	// Merge inputs are capped like a save. Frontmatter is not checked: the
+	// client's copy may be mid-edit, and the merge reports conflicts anyway.
+	mergeSchema = validation.Schema{
+		{Name: "path", Rules: documentPathRules},
+		{Name: "content", Rules: []validation.Rule{validation.MaxBytes(validation.MaxDocumentBytes)}},
+		{Name: "base", Rules: []validation.Rule{validation.MaxBytes(validation.MaxDocumentBytes)}},
+	}
+
+	pageStatusSchema = validation.Schema{
+		{Name: "path", Rules: documentPathRules},
+		{Name: "status", Rules: []validation.Rule{validation.MaxBytes(maxStatusBytes)}},
+	}
+
+	// The import path is optional; without one the page is named after its
+	// title.
+	importURLSchema = validation.Schema{
+		{Name: "url", Rules: []validation.Rule{validation.Required, validation.WebURL}},
+		{Name: "path", Rules: []validation.Rule{validation.DocumentPath}},
+	}
+)
+
+// validateRequest applies schema to values and writes a 422 describing every
+// failed field. It reports whether the request may proceed.
+func validateRequest(w http.ResponseWriter, schema validation.Schema, values map[string]string) bool {
+	err := schema.Validate(values)
+	if err == nil {
+		return true
+	}
+	var fieldErrs validation.Errors
+	if !errors.As(err, &fieldErrs) || len(fieldErrs) == 0 {
+		respondError(w, http.StatusUnprocessableEntity, newAPIError(codeValidation, err.Error()))
+		return false
+	}
+	first := fieldErrs[0]
+	apiErr := newAPIError(first.Code, fieldErrs.Error()).withField(first.Field)
+	apiErr.Details = fieldErrs
+	if path := values["path"]; path != "" && first.Code != validation.CodeTraversal {
+		apiErr = apiErr.withPath(path)
+	}
+	respondError(w, http.StatusUnprocessableEntity, apiErr)
+	return false
+}
@@ -0,0 +1,259 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/content"
+	"github.com/euforicio/wikimd/internal/search"
+	"github.com/euforicio/wikimd/internal/search/searchtest"
+)
+
+func TestSearchUsesConfiguredBackend(t *testing.T) {
+	t.Parallel()
+	backend := searchtest.New(map[string]string{
+		"guides/intro.md":   "# Intro\nWelcome to the wiki.",
+		"archive/legacy.md": "# Legacy\nWelcome to the old wiki.",
+	})
+	srv, cleanup := newTestServerWithSearch(t, backend)
+	t.Cleanup(cleanup)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search?q=welcome", nil)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d with body %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Results []struct {
+			Path string `json:"path"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].Path != "guides/intro.md" {
+		t.Fatalf("expected only the unarchived match, got %+v", resp.Results)
+	}
+	queries := backend.Queries()
+	if len(queries) != 1 || !slices.Contains(queries[0].Options.ExcludeGlobs, content.ArchiveDir+"/**") {
+		t.Fatalf("expected archive exclusion to reach the backend, got %+v", queries)
+	}
+}
+
+func TestSearchSkipsNoIndexPages(t *testing.T) {
+	t.Parallel()
+	backend := searchtest.New(map[string]string{
+		"guides/intro.md": "# Intro\nWelcome to the wiki.",
+		"secret.md":       "# Secret\nWelcome, insiders.",
+	})
+	srv, cleanup := newTestServerWithSearch(t, backend)
+	t.Cleanup(cleanup)
+
+	if err := os.WriteFile(filepath.Join(srv.cfg.RootDir, "secret.md"), []byte("---\nnoindex: true\n---\n# Secret\n\nWelcome, insiders.\n"), 0o644); err != nil {
+		t.Fatalf("write document: %v", err)
+	}
+
+	// The tree picks up the new page asynchronously.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		req := httptest.NewRequest(http.MethodGet, "/api/search?q=welcome", nil)
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		var resp struct {
+			Results []search.Result `json:"results"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if len(resp.Results) == 1 && resp.Results[0].Path == "guides/intro.md" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the noindex page to be left out, got %+v", resp.Results)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSyncSearchInvalidatesChangedPaths(t *testing.T) {
+	t.Parallel()
+	backend := searchtest.New(nil)
+	srv, cleanup := newTestServerWithSearch(t, backend)
+	t.Cleanup(cleanup)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go srv.syncSearch(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for backend.Indexed() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("backend was never indexed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := os.WriteFile(filepath.Join(srv.cfg.RootDir, "synced.md"), []byte("# Synced\n"), 0o644); err != nil {
+		t.Fatalf("write document: %v", err)
+	}
+	for !slices.Contains(backend.Invalidated(), "synced.md") {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected synced.md to be invalidated, got %v", backend.Invalidated())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSearchGroupsMatchesByPage(t *testing.T) {
+	t.Parallel()
+	backend := searchtest.New(map[string]string{
+		"guides/intro.md": "# Welcome\nwelcome again\nand welcome once more",
+		"notes/todo.md":   "say welcome",
+	})
+	srv, cleanup := newTestServerWithSearch(t, backend)
+	t.Cleanup(cleanup)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search?q=welcome&group=page", nil)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d with body %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Pages   []search.PageResult `json:"pages"`
+		Count   int                 `json:"count"`
+		Matches int                 `json:"matches"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Count != 2 || resp.Matches != 4 {
+		t.Fatalf("expected 2 pages with 4 matches, got %d pages and %d matches", resp.Count, resp.Matches)
+	}
+	if first := resp.Pages[0]; first.Path != "guides/intro.md" || first.Count != 3 || first.Best.Line != 1 {
+		t.Fatalf("unexpected first page %+v", first)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/search?q=welcome&group=page", nil)
+	req.Header.Set("HX-Request", "true")
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if body := rec.Body.String(); !strings.Contains(body, "3 matches") || !strings.Contains(body, "guides/intro.md:1") {
+		t.Fatalf("expected grouped fragment, got %s", body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/search?q=welcome&group=word", nil)
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown group, got %d", rec.Code)
+	}
+}
+
+func TestSearchSuggestsCorrectionsWhenNothingMatches(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServerWithSearch(t, searchtest.New(nil))
+	t.Cleanup(cleanup)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search?q=getting+startde", nil)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	var resp struct {
+		Suggestions []string `json:"suggestions"`
+		Count       int      `json:"count"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Count != 0 || len(resp.Suggestions) == 0 || resp.Suggestions[0] != "getting started" {
+		t.Fatalf("expected a getting started suggestion, got %+v", resp)
+	}
+}
+
+// stalledBackend is a search.Backend whose searches run until their context
+// ends, as a ripgrep stuck on a huge tree would.
+type stalledBackend struct {
+	*searchtest.Backend
+}
+
+func (stalledBackend) Search(ctx context.Context, _ string, _ search.Options) ([]search.Result, error) {
+	<-ctx.Done()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, search.ErrTimeout
+	}
+	return nil, ctx.Err()
+}
+
+func TestSearchTimesOut(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServerWithSearch(t, stalledBackend{searchtest.New(nil)})
+	t.Cleanup(cleanup)
+	srv.cfg.SearchTimeout = 20 * time.Millisecond
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search?q=anything", nil)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var body apiError
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode error body: %v", err)
+	}
+	if body.Code != codeTimeout || !strings.Contains(body.Message, "20ms") {
+		t.Fatalf("expected a timeout error naming the limit, got %+v", body)
+	}
+}
+
+func TestSearchFollowsTreeVisibility(t *testing.T) {
+	t.Parallel()
+	backend := searchtest.New(map[string]string{
+		"guides/parity.md":       "parity",
+		"scratch/notes.md":       "parity",
+		"docs/node_modules/x.md": "parity",
+		".drafts/secret.md":      "parity",
+	})
+	srv, cleanup := newTestServerWithOptions(t, backend, content.Options{ExcludeDirs: []string{"scratch"}})
+	t.Cleanup(cleanup)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search?q=parity&hidden=true", nil)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected hidden=true to be refused when the tree hides dotfiles, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/search?q=parity", nil)
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d with body %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Results []search.Result `json:"results"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].Path != "guides/parity.md" {
+		t.Fatalf("expected only the page the tree shows, got %+v", resp.Results)
+	}
+	queries := backend.Queries()
+	if len(queries) != 1 || !slices.Contains(queries[0].Options.ExcludeGlobs, "**/scratch/**") {
+		t.Fatalf("expected excluded directories to reach the backend, got %+v", queries)
+	}
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+)
+
+// syncSearch prepares the search backend and then reports every changed path
+// to it until ctx is done, so indexing backends never serve stale results.
+func (s *Server) syncSearch(ctx context.Context) {
+	// Subscribe first so changes made while the index builds are not lost.
+	events := s.content.Subscribe(ctx)
+	if err := s.search.Index(ctx); err != nil {
+		s.logger.WarnContext(ctx, "build search index failed", slog.Any("err", err))
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case evt, ok := <-events:
+			if !ok {
+				return
+			}
+			if evt.Path != "" {
+				s.search.Invalidate(evt.Path)
+			}
+		}
+	}
+}
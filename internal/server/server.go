@@ -12,18 +12,25 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/euforicio/wikimd/internal/banner"
 	"github.com/euforicio/wikimd/internal/config"
 	"github.com/euforicio/wikimd/internal/content"
 	"github.com/euforicio/wikimd/internal/content/tree"
+	"github.com/euforicio/wikimd/internal/digest"
 	"github.com/euforicio/wikimd/internal/exporter"
+	"github.com/euforicio/wikimd/internal/jobs"
+	"github.com/euforicio/wikimd/internal/linkcheck"
 	"github.com/euforicio/wikimd/internal/renderer"
+	d2renderer "github.com/euforicio/wikimd/internal/renderer/d2"
 	"github.com/euforicio/wikimd/internal/search"
+	"github.com/euforicio/wikimd/internal/webhook"
 	"github.com/euforicio/wikimd/static"
 )
 
@@ -35,13 +42,29 @@ type Server struct { //nolint:govet // field order favors logical grouping over
 	httpServer     *http.Server
 	logger         *slog.Logger
 	content        *content.Service
-	search         *search.Service
+	search         search.Backend
 	exporter       *exporter.Exporter
+	links          *linkcheck.Checker  // nil unless external link checking is enabled
+	webhooks       *webhook.Dispatcher // nil unless webhook URLs are configured
+	digest         *digest.Digester    // nil unless a digest schedule is configured
 	templates      *templateRenderer
 	cfg            config.Config
-	customCSSPaths []string // Resolved custom CSS file paths (global + per-repo)
+	customCSSPaths []string       // Resolved custom CSS file paths (global + per-repo)
+	banner         *banner.Banner // nil unless an announcement is configured
+	routes         []routeInfo    // Registered routes, in registration order
+	idempotency    *idempotencyCache
+	jobs           *jobs.Manager
+	artifacts      *artifactStore // outputs of finished export jobs
+	pageStats      *pageStats     // render costs and response times behind /api/slow-pages
+	// streamThreshold is the document size (bytes) at or above which page
+	// routes flush the layout shell before rendering the document body.
+	streamThreshold int64
 }
 
+// defaultStreamThreshold is the source size at which full page loads switch to
+// streamed rendering so the sidebar paints before a huge document is rendered.
+const defaultStreamThreshold = 256 << 10
+
 var (
 	errPathRequired        = errors.New("path is required")
 	errInvalidPathEncoding = errors.New("invalid path encoding")
@@ -51,27 +74,69 @@ var (
 // It initializes the HTTP server, registers all routes and middleware,
 // and prepares the server for starting via the Start method.
 // Returns an error if template loading or exporter initialization fails.
-func New(cfg config.Config, logger *slog.Logger, contentSvc *content.Service, searchSvc *search.Service) (*Server, error) {
-	tmpl, err := newTemplateRenderer()
+func New(cfg config.Config, logger *slog.Logger, contentSvc *content.Service, searchSvc search.Backend) (*Server, error) {
+	// Embedded assets are fixed at build time, so pages can pin them with
+	// SRI hashes. Assets served from --assets are rebuilt while the server
+	// runs and get none.
+	integrity := static.Integrity
+	if assetsOverride(cfg.AssetsDir) {
+		integrity = nil
+	}
+	tmpl, err := newTemplateRenderer(integrity)
 	if err != nil {
 		return nil, fmt.Errorf("load templates: %w", err)
 	}
 
-	exp, err := exporter.New(logger)
+	// Exports reuse the content service's renderer, so a page the server
+	// has already rendered comes out of its cache.
+	exp, err := exporter.NewWithRenderer(logger, contentSvc.Renderer())
 	if err != nil {
 		return nil, fmt.Errorf("init exporter: %w", err)
 	}
 
+	announcement, err := banner.New(cfg.Banner, cfg.BannerSeverity, cfg.BannerDismissible)
+	if err != nil {
+		return nil, err
+	}
+
 	mux := http.NewServeMux()
 
 	s := &Server{
-		cfg:       cfg,
-		mux:       mux,
-		logger:    logger.With("component", "http"),
-		content:   contentSvc,
-		search:    searchSvc,
-		exporter:  exp,
-		templates: tmpl,
+		cfg:             cfg,
+		mux:             mux,
+		logger:          logger.With("component", "http"),
+		content:         contentSvc,
+		search:          searchSvc,
+		exporter:        exp,
+		templates:       tmpl,
+		banner:          announcement,
+		idempotency:     newIdempotencyCache(),
+		jobs:            jobs.New(),
+		artifacts:       newArtifactStore(),
+		pageStats:       newPageStats(),
+		streamThreshold: defaultStreamThreshold,
+	}
+
+	if cfg.LinkCheckInterval > 0 {
+		s.links = linkcheck.New(logger, linkcheck.Options{Interval: cfg.LinkCheckInterval})
+	}
+	targets, err := webhook.LoadTargets(cfg.RootDir)
+	if err != nil {
+		return nil, fmt.Errorf("load webhooks: %w", err)
+	}
+	s.webhooks = webhook.New(logger, webhook.Options{URLs: cfg.Webhooks, Targets: targets, Secret: cfg.WebhookSecret})
+	if cfg.DigestInterval > 0 {
+		s.digest = digest.New(logger, cfg.RootDir, digest.Options{
+			Interval:  cfg.DigestInterval,
+			SiteTitle: filepath.Base(cfg.RootDir),
+			SMTP: digest.SMTPConfig{
+				Addr:     cfg.SMTPAddr,
+				Username: cfg.SMTPUsername,
+				Password: cfg.SMTPPassword,
+				From:     cfg.DigestFrom,
+				To:       cfg.DigestTo,
+			},
+		})
 	}
 
 	s.registerRoutes()
@@ -82,28 +147,57 @@ func New(cfg config.Config, logger *slog.Logger, contentSvc *content.Service, se
 
 func (s *Server) registerRoutes() {
 	staticHandler := http.StripPrefix("/static/", http.FileServer(s.resolveStaticFS()))
-	s.mux.Handle("GET /static/{path...}", staticHandler)
-	s.mux.Handle("HEAD /static/{path...}", staticHandler)
+	s.handle("GET /static/{path...}", "Frontend assets (CSS, JS, vendor bundles)", staticHandler)
+	s.handle("HEAD /static/{path...}", "Frontend asset metadata", staticHandler)
 
 	// Custom theme CSS endpoints
-	s.mux.HandleFunc("GET /custom-theme/{index}", s.handleCustomCSS)
+	s.handleFunc("GET /custom-theme/{index}", "Custom theme stylesheet by discovery index", s.handleCustomCSS)
+	s.handleFunc("GET /custom-theme/dir/{dir...}", "Custom stylesheet scoped to the pages under a directory", s.handleScopedCSS)
 
 	// Media files (images, etc.) from wiki root
-	s.mux.HandleFunc("GET /media/{path...}", s.handleMedia)
-
-	s.mux.HandleFunc("GET /healthz", s.handleHealth)
-	s.mux.HandleFunc("GET /page/{path...}", s.handlePageRoute)
-	s.mux.HandleFunc("GET /", s.handleRoot)
-
-	s.mux.HandleFunc("GET /api/tree", s.handleTree)
-	s.mux.HandleFunc("POST /api/page", s.handleCreatePage)
-	s.mux.HandleFunc("PUT /api/page/{path...}", s.handleSavePage)
-	s.mux.HandleFunc("POST /api/page/rename", s.handleRenamePage)
-	s.mux.HandleFunc("DELETE /api/page/{path...}", s.handleDeletePage)
-	s.mux.HandleFunc("GET /api/page/{path...}", s.handlePage)
-	s.mux.HandleFunc("GET /api/search", s.handleSearch)
-	s.mux.HandleFunc("GET /api/export", s.handleExport)
-	s.mux.HandleFunc("GET /events", s.handleEvents)
+	s.handleFunc("GET /media/{path...}", "Media files (images, attachments) from the wiki root", s.handleMedia)
+
+	s.handleFunc("GET /healthz", "Liveness probe", s.handleHealth)
+	s.handleFunc("GET /page/{path...}", "Full HTML page for a document", s.handlePageRoute)
+	s.handleFunc("GET /", "Redirect to the first document or render the empty state", s.handleRoot)
+
+	s.handleFunc("GET /api/routes", "List registered routes", s.handleRoutes)
+	s.handleFunc("GET /api/tree", "Navigation tree as JSON (HTML fragment for HTMX)", s.handleTree)
+	s.handleFunc("GET /api/tree/delta", "Tree nodes changed since generation ?since=", s.handleTreeDelta)
+	s.handleFunc("POST /api/page", "Create a document", s.handleCreatePage)
+	s.handleFunc("PUT /api/page/{path...}", "Save a document", s.handleSavePage)
+	s.handleFunc("POST /api/import/url", "Clip a web page into a new document, downloading its images beside it", s.handleImportURL)
+	s.handleFunc("POST /api/page/rename", "Rename a document", s.handleRenamePage)
+	s.handleFunc("DELETE /api/page/{path...}", "Delete a document", s.handleDeletePage)
+	s.handleFunc("POST /api/page/{path...}", "Document actions: {path}/archive moves it under archive/, {path}/merge three-way merges an edit with the current page, {path}/status moves it on the board, {path}/restore brings back a kept version", s.handlePageAction)
+	s.handleFunc("GET /api/page/{path...}", "Rendered document as JSON (raw with format=raw, HTML fragment for HTMX, clipboard payloads under /copy, hover previews under /summary, embedding chunks under /chunks, kept versions under /versions)", s.handlePage)
+	s.handleFunc("GET /api/file/{path...}", "Type, size, mtime, and sniffed MIME type of any file under the root", s.handleFileInfo)
+	s.handleFunc("GET /api/diff", "Rendered word-level diff between ?a=path@rev and ?b=path@rev (format=html for the fragment)", s.handleRenderedDiff)
+	s.handleFunc("GET /api/diff/{path...}", "Latest change to a document as a git diff (uncommitted edits, else its last commit)", s.handlePageDiff)
+	s.handleFunc("POST /api/metadata/batch", "Apply a frontmatter patch (set, unset, addTags, removeTags) to pages matching a glob or tag, with dryRun preview", s.handleMetadataBatch)
+	s.handleFunc("GET /api/dump", "Raw source of every document in path order, paged with ?cursor= and filtered by ?since=", s.handleDump)
+	s.handleFunc("GET /api/search", "Full-text search", s.handleSearch)
+	s.handleFunc("GET /api/lint", "Lint documents against .wikimd/lint.yaml rules (format=json|sarif)", s.handleLint)
+	s.handleFunc("POST /api/lint", "Lint unsaved editor content for inline preview warnings", s.handleLintDraft)
+	s.handleFunc("GET /api/lint/external-links", "Dead external links from the background checker (all=true for every link)", s.handleExternalLinks)
+	s.handleFunc("GET /api/tags/suggest", "Existing frontmatter tags matching q, ranked by usage (for editor autocomplete)", s.handleTagSuggest)
+	s.handleFunc("GET /api/inventory", "Every page with title, tags, owner, mtime, word count, and broken links (format=csv for a spreadsheet)", s.handleInventory)
+	s.handleFunc("GET /api/board", "Kanban board of pages grouped by status frontmatter (?columns=todo,doing,done, ?dir=)", s.handleBoard)
+	s.handleFunc("GET /api/calendar.ics", "iCalendar feed of pages with date or event frontmatter (?dir= for one folder)", s.handleCalendar)
+	s.handleFunc("GET /api/reviews/overdue", "Pages past their reviewBy frontmatter date", s.handleOverdueReviews)
+	s.handleFunc("GET /api/export", "Export a single document (html, pdf, markdown, txt)", s.handleExport)
+	s.handleFunc("POST /api/export/batch-pdf", "Compile several documents, in order, into one PDF with a cover and contents, as a job", s.handleBatchPDF)
+	s.handleFunc("GET /api/export/wizard", "List the export wizard's formats, subtrees, and defaults", s.handleExportWizardOptions)
+	s.handleFunc("POST /api/export/wizard", "Export the wiki or a subtree as a site, single file, PDF, or EPUB, as a job", s.handleExportWizard)
+	s.handleFunc("POST /api/export", "Export a document, or the whole site with scope=site, as a cancellable job", s.handleStartExport)
+	s.handleFunc("GET /api/jobs", "Running and recently finished background jobs", s.handleListJobs)
+	s.handleFunc("GET /api/jobs/{id}", "Status of one background job", s.handleGetJob)
+	s.handleFunc("DELETE /api/jobs/{id}", "Cancel a background job", s.handleCancelJob)
+	s.handleFunc("GET /api/slow-pages", "Viewed pages whose render exceeds ?threshold= (default 200ms), with the dominant cost and recent response times", s.handleSlowPages)
+	s.handleFunc("GET /api/diagrams/stats", "D2 render queue: concurrency, active and queued diagrams, timeouts, and wait times", s.handleDiagramStats)
+	s.handleFunc("GET /api/jobs/{id}/result", "Download the output of a finished export job", s.handleJobResult)
+	s.handleFunc("POST /api/jobs/reindex", "Rebuild the content tree and search index as a job", s.handleReindex)
+	s.handleFunc("GET /events", "Server-sent change events", s.handleEvents)
 }
 
 func (s *Server) resolveStaticFS() http.FileSystem {
@@ -122,6 +216,17 @@ func (s *Server) resolveStaticFS() http.FileSystem {
 	return static.HTTP()
 }
 
+// assetsOverride reports whether resolveStaticFS serves assets from dir
+// instead of the embedded copies.
+func assetsOverride(dir string) bool {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return false
+	}
+	info, err := os.Stat(dir)
+	return err == nil && info.IsDir()
+}
+
 // Start runs the HTTP server and optionally opens the browser.
 // The server will listen on the configured port (or allocate a dynamic port if cfg.Port is 0).
 // It supports graceful shutdown when the provided context is canceled.
@@ -135,6 +240,16 @@ func (s *Server) Start(ctx context.Context) error {
 		loggingMiddleware(s.logger, s.cfg.Verbose),
 	)
 
+	if s.links != nil {
+		go s.links.Run(ctx, s.collectExternalLinks)
+	}
+	if s.digest != nil {
+		go s.digest.Run(ctx, s.content.CurrentTree)
+	}
+	if s.search != nil {
+		go s.syncSearch(ctx)
+	}
+
 	var errCh chan error
 
 	var serverURL string
@@ -197,6 +312,15 @@ func (s *Server) Start(ctx context.Context) error {
 		}
 	}
 
+	if s.webhooks != nil {
+		baseURL := s.cfg.PublicURL
+		if baseURL == "" {
+			baseURL = serverURL
+		}
+		go s.runReviewReminders(ctx, baseURL)
+		go s.forwardChangeEvents(ctx, baseURL)
+	}
+
 	select {
 	case <-ctx.Done():
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -218,6 +342,8 @@ func (s *Server) Start(ctx context.Context) error {
 // It waits for all active connections to close or the context to be canceled.
 // Returns an error if the shutdown process fails or times out.
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.jobs.Close()
+	s.artifacts.close()
 	if s.httpServer == nil {
 		return nil
 	}
@@ -228,6 +354,10 @@ func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func (s *Server) handleDiagramStats(w http.ResponseWriter, _ *http.Request) {
+	respondJSON(w, http.StatusOK, d2renderer.QueueStats())
+}
+
 func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -258,7 +388,9 @@ func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
 		Page:            pageViewData{},
 		HasDocument:     false,
 		CustomCSSURLs:   s.customCSSURLs(),
+		Banner:          s.banner,
 		SearchAvailable: s.search != nil,
+		Indexing:        s.indexingState(),
 	}
 
 	s.renderTemplate(w, r, "layout", data)
@@ -266,6 +398,7 @@ func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handlePageRoute(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	started := time.Now()
 	path, err := parseWildcardPath(r.PathValue("path"))
 	if err != nil {
 		s.respondPathError(w, err)
@@ -279,6 +412,25 @@ func (s *Server) handlePageRoute(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if page, ok := s.dashboardPage(ctx, root, path); ok {
+		s.renderTemplate(w, r, "layout", homeViewData{
+			Tree:            root,
+			ActivePath:      page.Path,
+			Page:            page,
+			HasDocument:     true,
+			CustomCSSURLs:   s.customCSSURLs(),
+			Banner:          s.banner,
+			SearchAvailable: s.search != nil,
+			Indexing:        s.indexingState(),
+		})
+		return
+	}
+
+	if node := findNode(root, path); node != nil && s.streamThreshold > 0 && node.Size >= s.streamThreshold {
+		s.streamPage(w, r, root, node, started)
+		return
+	}
+
 	var (
 		page        pageViewData
 		hasDocument bool
@@ -291,6 +443,10 @@ func (s *Server) handlePageRoute(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "failed to load page", http.StatusInternalServerError)
 			return
 		}
+		if dest, ok := s.content.Redirect(path); ok {
+			http.Redirect(w, r, "/page/"+dest, http.StatusMovedPermanently)
+			return
+		}
 		// Document not found, but still render the layout with a not found message
 		page = pageViewData{
 			Path:    path,
@@ -301,6 +457,7 @@ func (s *Server) handlePageRoute(w http.ResponseWriter, r *http.Request) {
 	if err == nil {
 		page = s.pageViewFromDocument(ctx, root, path, doc)
 		hasDocument = true
+		defer s.pageStats.observe(path, doc.Cost, started)
 	}
 
 	data := homeViewData{
@@ -309,20 +466,98 @@ func (s *Server) handlePageRoute(w http.ResponseWriter, r *http.Request) {
 		Page:            page,
 		HasDocument:     hasDocument,
 		CustomCSSURLs:   s.customCSSURLs(),
+		Banner:          s.banner,
 		SearchAvailable: s.search != nil,
+		Indexing:        s.indexingState(),
 	}
 
 	s.renderTemplate(w, r, "layout", data)
 }
 
+// streamPage renders a full page load in three flushed fragments: the layout
+// shell (head, sidebar, header), the document body, and the closing markup.
+// Large documents can take a while to render, so the browser gets something to
+// paint before the markdown conversion finishes.
+func (s *Server) streamPage(w http.ResponseWriter, r *http.Request, root *tree.Node, node *tree.Node, started time.Time) {
+	ctx := r.Context()
+	path := node.RelativePath
+
+	shell := pageViewData{
+		Path:  path,
+		Title: node.Title,
+	}
+	if node.Metadata != nil {
+		shell.Metadata = *node.Metadata
+	}
+
+	data := homeViewData{
+		Tree:            root,
+		ActivePath:      path,
+		Page:            shell,
+		HasDocument:     true,
+		CustomCSSURLs:   s.customCSSURLs(),
+		Banner:          s.banner,
+		SearchAvailable: s.search != nil,
+		Indexing:        s.indexingState(),
+	}
+
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if err := s.templates.render(w, "layout-head", data); err != nil {
+		s.logger.ErrorContext(ctx, "render template failed", slog.Any("err", err), slog.String("template", "layout-head"))
+		return
+	}
+	flush()
+
+	doc, err := s.content.Document(ctx, path)
+	if err != nil {
+		// Headers are already on the wire, so report the failure inside the page region.
+		s.logger.WarnContext(ctx, "page load failed", slog.Any("err", err), slog.String("path", path))
+		missing := fmt.Sprintf("<div class=\"rounded-2xl border border-dashed border-red-500/50 bg-red-500/10 p-6 text-sm text-red-200\">Document <code>%s</code> could not be loaded.</div>", template.HTMLEscapeString(path))
+		data.Page = pageViewData{
+			Path:    path,
+			Title:   shell.Title,
+			HTML:    template.HTML(missing), //nolint:gosec // HTML is safely escaped
+			Missing: true,
+		}
+	} else {
+		data.Page = s.pageViewFromDocument(ctx, root, path, doc)
+		defer s.pageStats.observe(path, doc.Cost, started)
+	}
+
+	if err := s.templates.render(w, "layout-body", data); err != nil {
+		s.logger.ErrorContext(ctx, "render template failed", slog.Any("err", err), slog.String("template", "layout-body"))
+		return
+	}
+	flush()
+
+	if err := s.templates.render(w, "layout-foot", data); err != nil {
+		s.logger.ErrorContext(ctx, "render template failed", slog.Any("err", err), slog.String("template", "layout-foot"))
+	}
+}
+
 func (s *Server) handleTree(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	node, err := s.content.CurrentTree(ctx)
+	order, custom, ok := s.treeSortParams(w, r)
+	if !ok {
+		return
+	}
+	node, generation, err := s.content.TreeWithGeneration(ctx)
 	if err != nil {
 		s.logger.ErrorContext(ctx, "fetch tree failed", slog.Any("err", err))
-		respondJSON(w, http.StatusInternalServerError, errorResponse("failed to load tree"))
+		respondError(w, http.StatusInternalServerError, newAPIError(codeInternal, "failed to load tree"))
 		return
 	}
+	if custom {
+		node = tree.Sorted(node, order)
+	}
 
 	if isHTMXRequest(r) {
 		active := r.URL.Query().Get("current")
@@ -335,37 +570,132 @@ func (s *Server) handleTree(w http.ResponseWriter, r *http.Request) {
 			},
 		})
 		s.renderTemplate(w, r, "tree", treeViewData{
-			Root:   node,
-			Active: active,
+			Root:     node,
+			Active:   active,
+			Indexing: s.indexingState(),
 		})
 		return
 	}
 
+	indexing := s.indexingState()
 	resp := struct {
 		GeneratedAt time.Time  `json:"generatedAt"`
 		Root        *tree.Node `json:"root"`
+		Generation  uint64     `json:"generation"`
+		// Indexing is set while the initial build runs and Root is empty.
+		Indexing bool `json:"indexing,omitempty"`
+		Scanned  int  `json:"scanned,omitempty"`
 	}{
 		GeneratedAt: time.Now(),
 		Root:        node,
+		Generation:  generation,
+		Indexing:    indexing.Active,
+		Scanned:     indexing.Scanned,
 	}
 	respondJSON(w, http.StatusOK, resp)
 }
 
+// indexingState reports the progress of a background initial build.
+func (s *Server) indexingState() indexingState {
+	scanned, active := s.content.Indexing()
+	return indexingState{Active: active, Scanned: scanned}
+}
+
+// treeSortParams reads the sort and dirsFirst query parameters, filling in
+// whichever is missing from the configured default. custom is false when
+// neither is given, so the already-sorted tree can be served as is. Invalid
+// values are answered with a 400 and ok is false.
+func (s *Server) treeSortParams(w http.ResponseWriter, r *http.Request) (order tree.Sort, custom, ok bool) {
+	order = tree.Sort{By: s.cfg.TreeSort, DirsFirst: s.cfg.TreeDirsFirst}
+	query := r.URL.Query()
+	if v := query.Get("sort"); v != "" {
+		by, err := tree.ParseSortBy(v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "sort must be title, modified, or size").withField("sort"))
+			return order, false, false
+		}
+		order.By = by
+		custom = true
+	}
+	if v := query.Get("dirsFirst"); v != "" {
+		dirsFirst, err := strconv.ParseBool(v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "dirsFirst must be true or false").withField("dirsFirst"))
+			return order, false, false
+		}
+		order.DirsFirst = dirsFirst
+		custom = true
+	}
+	return order, custom, true
+}
+
+// handleTreeDelta returns only the nodes that changed since the generation a
+// client last saw, falling back to the whole tree when that generation is too
+// old to diff against.
+func (s *Server) handleTreeDelta(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	since, err := strconv.ParseUint(r.URL.Query().Get("since"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "since must be a tree generation").withField("since"))
+		return
+	}
+	delta, err := s.content.TreeDelta(ctx, since)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "tree delta failed", slog.Any("err", err))
+		respondError(w, http.StatusInternalServerError, newAPIError(codeInternal, "failed to load tree"))
+		return
+	}
+	respondJSON(w, http.StatusOK, delta)
+}
+
 func (s *Server) handlePage(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	started := time.Now()
 	path, err := parseWildcardPath(r.PathValue("path"))
 	if err != nil {
 		s.respondPathError(w, err)
 		return
 	}
+	if doc, ok := strings.CutSuffix(path, "/copy"); ok && isMarkdownFile(doc) {
+		s.handlePageCopy(w, r, doc)
+		return
+	}
+	if doc, ok := strings.CutSuffix(path, "/summary"); ok && isMarkdownFile(doc) {
+		s.handlePageSummary(w, r, doc)
+		return
+	}
+	if doc, ok := strings.CutSuffix(path, "/chunks"); ok && isMarkdownFile(doc) {
+		s.handlePageChunks(w, r, doc)
+		return
+	}
+	if doc, ok := strings.CutSuffix(path, "/versions"); ok && isMarkdownFile(doc) {
+		s.handlePageVersions(w, r, doc)
+		return
+	}
+	if doc, id, ok := strings.Cut(path, "/versions/"); ok && isMarkdownFile(doc) {
+		s.handlePageVersion(w, r, doc, id)
+		return
+	}
+
+	if s.respondDashboard(w, r, path) {
+		return
+	}
 
 	doc, err := s.content.Document(ctx, path)
 	if err != nil {
-		status := http.StatusInternalServerError
+		s.logger.WarnContext(ctx, "load page failed", slog.Any("err", err), slog.String("path", path))
+
 		if errors.Is(err, os.ErrNotExist) {
-			status = http.StatusNotFound
+			if dest, ok := s.content.Redirect(path); ok {
+				if isHTMXRequest(r) {
+					w.Header().Set("HX-Location", "/page/"+dest)
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				http.Redirect(w, r, "/api/page/"+dest+redirectQuery(r), http.StatusMovedPermanently)
+				return
+			}
 		}
-		s.logger.WarnContext(ctx, "load page failed", slog.Any("err", err), slog.String("path", path))
 
 		if isHTMXRequest(r) && errors.Is(err, os.ErrNotExist) {
 			setHXTrigger(w, map[string]any{
@@ -385,7 +715,8 @@ func (s *Server) handlePage(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		}
-		respondJSON(w, status, errorResponse(err.Error()))
+		status, apiErr := contentError(err)
+		respondError(w, status, apiErr.withPath(path))
 		return
 	}
 
@@ -399,6 +730,7 @@ func (s *Server) handlePage(w http.ResponseWriter, r *http.Request) {
 			root = treeRoot
 		}
 		page := s.pageViewFromDocument(ctx, root, path, doc)
+		defer s.pageStats.observe(path, doc.Cost, started)
 		setHXTrigger(w, map[string]any{
 			"pageLoaded": map[string]any{
 				"path":  path,
@@ -440,6 +772,9 @@ func (s *Server) handlePage(w http.ResponseWriter, r *http.Request) {
 		Metadata: doc.Metadata,
 		Modified: doc.Modified,
 	}
+	if root, err := s.content.CurrentTree(ctx); err == nil {
+		resp.HTML = expandListings(doc.HTML, root, path)
+	}
 
 	respondJSON(w, http.StatusOK, resp)
 }
@@ -457,17 +792,17 @@ func (s *Server) handleSavePage(w http.ResponseWriter, r *http.Request) {
 	}
 	if err := decodeJSON(r, &payload); err != nil {
 		s.logger.WarnContext(ctx, "decode save payload failed", slog.Any("err", err), slog.String("path", path))
-		respondJSON(w, http.StatusBadRequest, errorResponse("invalid JSON payload"))
+		respondError(w, http.StatusBadRequest, newAPIError(codeInvalidJSON, "invalid JSON payload"))
+		return
+	}
+	if !validateRequest(w, writePageSchema, map[string]string{"path": path, "content": payload.Content}) {
 		return
 	}
 
 	if err := s.content.SaveDocument(ctx, path, []byte(payload.Content)); err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, os.ErrNotExist) {
-			status = http.StatusNotFound
-		}
+		status, apiErr := contentError(err)
 		s.logger.WarnContext(ctx, "save document failed", slog.Any("err", err), slog.String("path", path))
-		respondJSON(w, status, errorResponse(err.Error()))
+		respondError(w, status, apiErr.withPath(path))
 		return
 	}
 
@@ -490,26 +825,23 @@ func (s *Server) handleCreatePage(w http.ResponseWriter, r *http.Request) {
 	}
 	if err := decodeJSON(r, &payload); err != nil {
 		s.logger.WarnContext(ctx, "decode create payload failed", slog.Any("err", err))
-		respondJSON(w, http.StatusBadRequest, errorResponse("invalid JSON payload"))
+		respondError(w, http.StatusBadRequest, newAPIError(codeInvalidJSON, "invalid JSON payload"))
 		return
 	}
 
 	path := strings.TrimSpace(payload.Path)
-	if path == "" {
-		respondJSON(w, http.StatusBadRequest, errorResponse("path is required"))
+	if !validateRequest(w, writePageSchema, map[string]string{"path": path, "content": payload.Content}) {
 		return
 	}
 
 	if err := s.content.CreateDocument(ctx, path, []byte(payload.Content)); err != nil {
-		status := http.StatusInternalServerError
-		switch {
-		case errors.Is(err, os.ErrExist):
-			status = http.StatusConflict
-		case errors.Is(err, os.ErrNotExist):
+		status, apiErr := contentError(err)
+		if errors.Is(err, os.ErrNotExist) {
+			// The parent directory is missing, which is a client error.
 			status = http.StatusBadRequest
 		}
 		s.logger.WarnContext(ctx, "create document failed", slog.Any("err", err), slog.String("path", path))
-		respondJSON(w, status, errorResponse(err.Error()))
+		respondError(w, status, apiErr.withPath(path))
 		return
 	}
 
@@ -532,31 +864,20 @@ func (s *Server) handleRenamePage(w http.ResponseWriter, r *http.Request) {
 	}
 	if err := decodeJSON(r, &payload); err != nil {
 		s.logger.WarnContext(ctx, "decode rename payload failed", slog.Any("err", err))
-		respondJSON(w, http.StatusBadRequest, errorResponse("invalid JSON payload"))
+		respondError(w, http.StatusBadRequest, newAPIError(codeInvalidJSON, "invalid JSON payload"))
 		return
 	}
 
 	from := strings.TrimSpace(payload.From)
 	to := strings.TrimSpace(payload.To)
-	if from == "" || to == "" {
-		respondJSON(w, http.StatusBadRequest, errorResponse("from and to paths are required"))
-		return
-	}
-	if from == to {
-		respondJSON(w, http.StatusBadRequest, errorResponse("destination path must differ from source"))
+	if !validateRequest(w, renamePageSchema, map[string]string{"from": from, "to": to}) {
 		return
 	}
 
 	if err := s.content.RenameDocument(ctx, from, to); err != nil {
-		status := http.StatusInternalServerError
-		switch {
-		case errors.Is(err, os.ErrNotExist):
-			status = http.StatusNotFound
-		case errors.Is(err, os.ErrExist):
-			status = http.StatusConflict
-		}
+		status, apiErr := contentError(err)
 		s.logger.WarnContext(ctx, "rename document failed", slog.Any("err", err), slog.String("from", from), slog.String("to", to))
-		respondJSON(w, status, errorResponse(err.Error()))
+		respondError(w, status, apiErr.withPath(from))
 		return
 	}
 
@@ -579,14 +900,14 @@ func (s *Server) handleDeletePage(w http.ResponseWriter, r *http.Request) {
 		s.respondPathError(w, err)
 		return
 	}
+	if !validateRequest(w, pathSchema, map[string]string{"path": path}) {
+		return
+	}
 
 	if err := s.content.DeleteDocument(ctx, path); err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, os.ErrNotExist) {
-			status = http.StatusNotFound
-		}
+		status, apiErr := contentError(err)
 		s.logger.WarnContext(ctx, "delete document failed", slog.Any("err", err), slog.String("path", path))
-		respondJSON(w, status, errorResponse(err.Error()))
+		respondError(w, status, apiErr.withPath(path))
 		return
 	}
 
@@ -600,6 +921,68 @@ func (s *Server) handleDeletePage(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, resp)
 }
 
+// redirectQuery preserves the request's query string across a redirect.
+func redirectQuery(r *http.Request) string {
+	if r.URL.RawQuery == "" {
+		return ""
+	}
+	return "?" + r.URL.RawQuery
+}
+
+// handlePageAction dispatches POST /api/page/{path}/{action}. The action is
+// the last path segment because ServeMux wildcards must end the pattern.
+func (s *Server) handlePageAction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	raw, err := parseWildcardPath(r.PathValue("path"))
+	if err != nil {
+		s.respondPathError(w, err)
+		return
+	}
+	idx := strings.LastIndex(raw, "/")
+	if idx <= 0 {
+		respondError(w, http.StatusNotFound, newAPIError(codeNotFound, "unknown page action"))
+		return
+	}
+	path, action := raw[:idx], raw[idx+1:]
+
+	switch action {
+	case "archive":
+		if !validateRequest(w, pathSchema, map[string]string{"path": path}) {
+			return
+		}
+		dest, err := s.content.ArchiveDocument(ctx, path)
+		if err != nil && dest == "" {
+			status, apiErr := contentError(err)
+			s.logger.WarnContext(ctx, "archive document failed", slog.Any("err", err), slog.String("path", path))
+			respondError(w, status, apiErr.withPath(path))
+			return
+		}
+		if err != nil {
+			// The page moved but the redirect was not saved; report success
+			// since the move itself cannot be rolled back safely.
+			s.logger.WarnContext(ctx, "archive redirect not recorded", slog.Any("err", err), slog.String("path", path))
+		}
+		resp := struct {
+			From    string `json:"from"`
+			To      string `json:"to"`
+			Message string `json:"message"`
+		}{
+			From:    path,
+			To:      dest,
+			Message: "archived",
+		}
+		respondJSON(w, http.StatusOK, resp)
+	case "merge":
+		s.handlePageMerge(w, r, path)
+	case "status":
+		s.handlePageStatus(w, r, path)
+	case "restore":
+		s.handlePageRestore(w, r, path)
+	default:
+		respondError(w, http.StatusNotFound, newAPIError(codeNotFound, "unknown page action: "+action).withPath(path))
+	}
+}
+
 func parseWildcardPath(raw string) (string, error) {
 	trimmed := strings.TrimSpace(raw)
 	if trimmed == "" {
@@ -616,21 +999,134 @@ func parseWildcardPath(raw string) (string, error) {
 	return path, nil
 }
 
+// respondGroupedSearch answers group=page searches with one entry per
+// document, titled from the content tree.
+func (s *Server) respondGroupedSearch(w http.ResponseWriter, r *http.Request, query string, opts search.Options, results []search.Result, suggestions []string) {
+	pages := search.GroupByPage(results)
+	if root, err := s.content.CurrentTree(r.Context()); err == nil {
+		for i := range pages {
+			if node := findNode(root, pages[i].Path); node != nil {
+				pages[i].Title = node.Title
+			}
+		}
+	}
+	for i := range pages {
+		if pages[i].Title == "" {
+			pages[i].Title = titleFromPath(pages[i].Path)
+		}
+	}
+
+	if isHTMXRequest(r) {
+		setHXTrigger(w, map[string]any{
+			"searchResults": map[string]any{
+				"query": query,
+				"count": len(results),
+			},
+		})
+		s.renderTemplate(w, r, "search", searchViewData{
+			Query:       query,
+			Count:       len(results),
+			Pages:       pages,
+			Grouped:     true,
+			Suggestions: suggestions,
+			Options:     opts,
+		})
+		return
+	}
+
+	resp := struct {
+		Query       string              `json:"query"`
+		Pages       []search.PageResult `json:"pages"`
+		Suggestions []string            `json:"suggestions,omitempty"`
+		Context     search.Options      `json:"options"`
+		Count       int                 `json:"count"`
+		Matches     int                 `json:"matches"`
+	}{
+		Query:       query,
+		Pages:       pages,
+		Suggestions: suggestions,
+		Context:     opts,
+		Count:       len(pages),
+		Matches:     len(results),
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// searchSuggestions offers corrected queries drawn from page titles, tags,
+// descriptions, and file names.
+func (s *Server) searchSuggestions(ctx context.Context, query string) []string {
+	root, err := s.content.CurrentTree(ctx)
+	if err != nil {
+		s.logger.WarnContext(ctx, "load tree for search suggestions failed", slog.Any("err", err))
+		return nil
+	}
+	vocab := search.Vocabulary{}
+	var walk func(*tree.Node)
+	walk = func(n *tree.Node) {
+		if n.Metadata != nil && n.Metadata.NoIndex {
+			return
+		}
+		vocab.Add(n.Title)
+		vocab.Add(strings.TrimSuffix(n.Name, filepath.Ext(n.Name)))
+		if n.Metadata != nil {
+			vocab.Add(n.Metadata.Description)
+			for _, tag := range n.Metadata.Tags {
+				vocab.Add(tag)
+			}
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+	return search.Suggest(query, vocab)
+}
+
+// searchable drops matches in files the visibility policy leaves out of
+// the wiki, so search agrees with the tree, and in pages whose frontmatter
+// sets noindex.
+func (s *Server) searchable(ctx context.Context, results []search.Result) []search.Result {
+	policy := s.content.Visibility()
+	noindex := map[string]bool{}
+	if root, err := s.content.CurrentTree(ctx); err != nil {
+		s.logger.WarnContext(ctx, "load tree for search filtering failed", slog.Any("err", err))
+	} else {
+		var walk func(*tree.Node)
+		walk = func(n *tree.Node) {
+			if n.Type == tree.NodeTypeFile && n.Metadata != nil && n.Metadata.NoIndex {
+				noindex[n.RelativePath] = true
+			}
+			for _, child := range n.Children {
+				walk(child)
+			}
+		}
+		walk(root)
+	}
+	kept := results[:0]
+	for _, result := range results {
+		rel := filepath.ToSlash(result.Path)
+		if policy.Visible(rel) && !noindex[rel] {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
+
 func (s *Server) respondPathError(w http.ResponseWriter, err error) {
 	switch {
 	case errors.Is(err, errPathRequired):
-		respondJSON(w, http.StatusBadRequest, errorResponse("path is required"))
+		respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "path is required").withField("path"))
 	case errors.Is(err, errInvalidPathEncoding):
-		respondJSON(w, http.StatusBadRequest, errorResponse("invalid path encoding"))
+		respondError(w, http.StatusBadRequest, newAPIError(codeInvalidRequest, "invalid path encoding").withField("path"))
 	default:
-		respondJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		respondError(w, http.StatusBadRequest, newAPIError(codeInvalidRequest, err.Error()))
 	}
 }
 
 func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	if s.search == nil {
-		respondJSON(w, http.StatusServiceUnavailable, errorResponse("search not configured"))
+		respondError(w, http.StatusServiceUnavailable, newAPIError(codeUnavailable, "search not configured"))
 		return
 	}
 
@@ -643,15 +1139,22 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 			s.renderTemplate(w, r, "search", searchViewData{})
 			return
 		}
-		respondJSON(w, http.StatusBadRequest, errorResponse("query parameter 'q' is required"))
+		respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "query parameter 'q' is required").withField("q"))
 		return
 	}
 
-	var opts search.Options
+	// Search the files the tree shows: dotfiles only when it includes them,
+	// and never the directories it leaves out at any depth. Results are
+	// filtered again by the full policy.
+	policy := s.content.Visibility()
+	opts := search.Options{SearchHidden: policy.IncludeHidden()}
+	for _, name := range policy.ExcludedDirs() {
+		opts.ExcludeGlobs = append(opts.ExcludeGlobs, "**/"+name+"/**")
+	}
 	if v := r.URL.Query().Get("caseSensitive"); v != "" {
 		b, err := strconv.ParseBool(v)
 		if err != nil {
-			respondJSON(w, http.StatusBadRequest, errorResponse("invalid caseSensitive value"))
+			respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "invalid caseSensitive value").withField("caseSensitive"))
 			return
 		}
 		opts.CaseSensitive = b
@@ -659,7 +1162,7 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	if v := r.URL.Query().Get("context"); v != "" {
 		n, err := strconv.Atoi(v)
 		if err != nil || n < 0 {
-			respondJSON(w, http.StatusBadRequest, errorResponse("invalid context value"))
+			respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "invalid context value").withField("context"))
 			return
 		}
 		opts.Context = n
@@ -667,13 +1170,24 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	if v := r.URL.Query().Get("hidden"); v != "" {
 		b, err := strconv.ParseBool(v)
 		if err != nil {
-			respondJSON(w, http.StatusBadRequest, errorResponse("invalid hidden value"))
+			respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "invalid hidden value").withField("hidden"))
+			return
+		}
+		// Results are limited to what the tree shows, so hidden can narrow a
+		// search but not widen it.
+		if b && !policy.IncludeHidden() {
+			respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "hidden files are not shown in this wiki, so they cannot be searched").withField("hidden"))
 			return
 		}
 		opts.SearchHidden = b
 	}
 
 	params := r.URL.Query()
+	group := strings.ToLower(strings.TrimSpace(params.Get("group")))
+	if group != "" && group != "line" && group != "page" {
+		respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "invalid group value. Supported values: line, page").withField("group"))
+		return
+	}
 	if globs, ok := params["glob"]; ok {
 		opts.IncludeGlobs = append(opts.IncludeGlobs, globs...)
 	}
@@ -684,19 +1198,57 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	// Always search only markdown files
 	opts.IncludeGlobs = append(opts.IncludeGlobs, "*.md", "*.markdown")
 
-	results, err := s.search.Search(ctx, query, opts)
+	// Archived pages are retired; leave them out unless asked for.
+	if v := params.Get("archived"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "invalid archived value").withField("archived"))
+			return
+		}
+		if !b {
+			opts.ExcludeGlobs = append(opts.ExcludeGlobs, content.ArchiveDir+"/**")
+		}
+	} else {
+		opts.ExcludeGlobs = append(opts.ExcludeGlobs, content.ArchiveDir+"/**")
+	}
+
+	searchCtx := ctx
+	if s.cfg.SearchTimeout > 0 {
+		var cancel context.CancelFunc
+		searchCtx, cancel = context.WithTimeout(ctx, s.cfg.SearchTimeout)
+		defer cancel()
+	}
+	results, err := s.search.Search(searchCtx, query, opts)
+	if errors.Is(err, search.ErrTimeout) {
+		s.logger.WarnContext(ctx, "search timed out", slog.String("query", query), slog.Duration("timeout", s.cfg.SearchTimeout))
+		msg := fmt.Sprintf("search timed out after %s; narrow the query or raise --search-timeout", s.cfg.SearchTimeout)
+		respondError(w, http.StatusGatewayTimeout, newAPIError(codeTimeout, msg))
+		return
+	}
 	if err != nil {
 		s.logger.WarnContext(ctx, "search failed", slog.Any("err", err))
-		respondJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		respondError(w, http.StatusBadRequest, newAPIError(codeInvalidRequest, err.Error()))
+		return
+	}
+	results = s.searchable(ctx, results)
+
+	var suggestions []string
+	if len(results) == 0 {
+		suggestions = s.searchSuggestions(ctx, query)
+	}
+
+	if group == "page" {
+		s.respondGroupedSearch(w, r, query, opts, results, suggestions)
 		return
 	}
 
 	if isHTMXRequest(r) {
 		data := searchViewData{
-			Query:   query,
-			Count:   len(results),
-			Results: results,
-			Options: opts,
+			Query:       query,
+			Count:       len(results),
+			Results:     results,
+			Suggestions: suggestions,
+			Options:     opts,
 		}
 		setHXTrigger(w, map[string]any{
 			"searchResults": map[string]any{
@@ -709,15 +1261,17 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := struct {
-		Query   string          `json:"query"`
-		Results []search.Result `json:"results"`
-		Context search.Options  `json:"options"`
-		Count   int             `json:"count"`
+		Query       string          `json:"query"`
+		Results     []search.Result `json:"results"`
+		Suggestions []string        `json:"suggestions,omitempty"`
+		Context     search.Options  `json:"options"`
+		Count       int             `json:"count"`
 	}{
-		Query:   query,
-		Count:   len(results),
-		Results: results,
-		Context: opts,
+		Query:       query,
+		Count:       len(results),
+		Results:     results,
+		Suggestions: suggestions,
+		Context:     opts,
 	}
 
 	respondJSON(w, http.StatusOK, resp)
@@ -738,31 +1292,45 @@ func (s *Server) pageViewFromDocument(ctx context.Context, root *tree.Node, path
 		title = titleFromPath(path)
 	}
 
-	var crumbs []breadcrumb
-	if root != nil {
-		crumbs = breadcrumbsFor(root, path)
-	}
 	if root == nil {
 		treeRoot, err := s.content.CurrentTree(ctx)
 		if err != nil {
 			s.logger.WarnContext(ctx, "load tree for breadcrumbs failed", slog.Any("err", err))
 		}
 		if err == nil {
-			crumbs = breadcrumbsFor(treeRoot, path)
+			root = treeRoot
 		}
 	}
 
+	var crumbs []breadcrumb
+	if root != nil {
+		crumbs = breadcrumbsFor(root, path)
+	}
+
 	return pageViewData{
 		Path:        path,
 		Title:       title,
-		HTML:        template.HTML(doc.HTML), //nolint:gosec // HTML from trusted renderer
+		HTML:        template.HTML(expandListings(doc.HTML, root, path)), //nolint:gosec // HTML from trusted renderer
 		Metadata:    doc.Metadata,
 		Modified:    doc.Modified,
 		Breadcrumbs: crumbs,
 		Missing:     false,
+		Archived:    content.IsArchived(path),
+		ScopedCSS:   s.scopedCSSURLs(path),
 	}
 }
 
+// expandListings fills {{children}} directives in rendered HTML from the
+// current tree.
+func expandListings(html string, root *tree.Node, path string) string {
+	if root == nil {
+		return html
+	}
+	return renderer.ExpandListings(html, func(depth int) []renderer.ListItem {
+		return tree.Listing(root, path, depth, func(rel string) string { return "/page/" + rel })
+	})
+}
+
 func titleFromPath(p string) string {
 	name := strings.TrimSuffix(filepath.Base(p), filepath.Ext(p))
 	name = strings.ReplaceAll(name, "-", " ")
@@ -810,8 +1378,11 @@ func breadcrumbsFor(root *tree.Node, target string) []breadcrumb {
 			title = titleFromPath(node.RelativePath)
 		}
 		crumb := breadcrumb{
-			Title: title,
-			Path:  "",
+			Title:     title,
+			Path:      "",
+			Icon:      node.Icon,
+			IconImage: node.IconImage,
+			Color:     node.Color,
 		}
 		if node.Type == tree.NodeTypeFile && i != len(nodes)-1 {
 			crumb.Path = node.RelativePath
@@ -824,6 +1395,15 @@ func breadcrumbsFor(root *tree.Node, target string) []breadcrumb {
 	return out
 }
 
+// findNode returns the tree node matching target, or nil when absent.
+func findNode(root *tree.Node, target string) *tree.Node {
+	nodes := findNodePath(root, target)
+	if len(nodes) == 0 {
+		return nil
+	}
+	return nodes[len(nodes)-1]
+}
+
 func findNodePath(root *tree.Node, target string) []*tree.Node {
 	if root == nil {
 		return nil
@@ -855,6 +1435,7 @@ func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 
 	ch := s.content.Subscribe(ctx)
+	jobUpdates := s.jobs.Subscribe(ctx)
 
 	if _, err := w.Write([]byte(": ready\n\n")); err != nil {
 		return
@@ -865,6 +1446,19 @@ func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
 		select {
 		case <-ctx.Done():
 			return
+		case status, ok := <-jobUpdates:
+			if !ok {
+				return
+			}
+			payload, err := encodeJSON(jobEvent{Type: "job", Job: status})
+			if err != nil {
+				s.logger.WarnContext(ctx, "encode sse job event failed", slog.Any("err", err))
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", payload); err != nil {
+				return
+			}
+			flusher.Flush()
 		case evt, ok := <-ch:
 			if !ok {
 				return
@@ -884,12 +1478,47 @@ func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	cleanPath, format, ok := s.parseExportRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// Generate filename using the cleaned path
+	filename := sanitizeFilename(cleanPath) + exporter.FileExtension(exporter.Format(format))
+
+	// Set response headers
+	w.Header().Set("Content-Type", exporter.ContentType(exporter.Format(format)))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// Each export is recorded as a job so its outcome can be looked up
+	// afterwards through /api/jobs/{id}.
+	out := &exportWriter{w: w}
+	status, err := s.jobs.Run(ctx, jobExport, func(ctx context.Context, p *jobs.Progress) error {
+		w.Header().Set(jobHeader, p.ID())
+		return s.exporter.ExportPage(ctx, exporter.ExportPageOptions{
+			RootDir: s.cfg.RootDir,
+			Path:    cleanPath,
+			Format:  exporter.Format(format),
+			Writer:  out,
+		})
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "export failed", slog.Any("err", err), slog.String("path", cleanPath), slog.String("format", format), slog.String("job", status.ID))
+	}
+	out.finish(err)
+}
+
+// parseExportRequest validates the path and format query parameters of an
+// export request and checks that the document exists. It responds itself and
+// returns false when the request is invalid.
+func (s *Server) parseExportRequest(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	ctx := r.Context()
 
 	// Parse and validate path parameter
 	path := strings.TrimSpace(r.URL.Query().Get("path"))
 	if path == "" {
-		respondJSON(w, http.StatusBadRequest, errorResponse("path parameter is required"))
-		return
+		respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "path parameter is required").withField("path"))
+		return "", "", false
 	}
 
 	// Validate path to prevent directory traversal attacks
@@ -898,8 +1527,8 @@ func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
 	// Check for directory traversal attempts
 	if strings.Contains(cleanPath, "..") || filepath.IsAbs(cleanPath) {
 		s.logger.WarnContext(ctx, "invalid export path attempted", slog.String("path", path))
-		respondJSON(w, http.StatusBadRequest, errorResponse("invalid path"))
-		return
+		respondError(w, http.StatusBadRequest, newAPIError(codePathTraversal, "invalid path").withField("path"))
+		return "", "", false
 	}
 
 	// Verify the resolved path is within the wiki root directory
@@ -907,22 +1536,22 @@ func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
 	absRoot, err := filepath.Abs(s.cfg.RootDir)
 	if err != nil {
 		s.logger.ErrorContext(ctx, "failed to resolve root directory", slog.Any("err", err))
-		respondJSON(w, http.StatusInternalServerError, errorResponse("internal server error"))
-		return
+		respondError(w, http.StatusInternalServerError, newAPIError(codeInternal, "internal server error"))
+		return "", "", false
 	}
 
 	absPath, err = filepath.Abs(absPath)
 	if err != nil {
 		s.logger.ErrorContext(ctx, "failed to resolve absolute path", slog.Any("err", err), slog.String("path", path))
-		respondJSON(w, http.StatusBadRequest, errorResponse("invalid path"))
-		return
+		respondError(w, http.StatusBadRequest, newAPIError(codePathTraversal, "invalid path").withField("path"))
+		return "", "", false
 	}
 
 	// Ensure the resolved path is within the root directory
 	if !strings.HasPrefix(absPath, absRoot+string(filepath.Separator)) && absPath != absRoot {
 		s.logger.WarnContext(ctx, "path outside root directory attempted", slog.String("path", path), slog.String("resolved", absPath))
-		respondJSON(w, http.StatusBadRequest, errorResponse("invalid path"))
-		return
+		respondError(w, http.StatusBadRequest, newAPIError(codePathTraversal, "invalid path").withField("path"))
+		return "", "", false
 	}
 
 	// Validate and normalize format parameter
@@ -932,43 +1561,21 @@ func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if !exporter.IsValidFormat(format) {
-		respondJSON(w, http.StatusBadRequest, errorResponse("invalid format. Supported formats: html, pdf, markdown, txt"))
-		return
+		respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "invalid format. Supported formats: html, pdf, markdown, txt").withField("format"))
+		return "", "", false
 	}
 
 	// Check if the document exists (using the cleaned path)
 	_, err = s.content.Document(ctx, cleanPath)
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, os.ErrNotExist) {
-			status = http.StatusNotFound
-		}
+		status, apiErr := contentError(err)
 		s.logger.WarnContext(ctx, "export document not found", slog.Any("err", err), slog.String("path", cleanPath))
-		respondJSON(w, status, errorResponse("document not found"))
-		return
+		apiErr.Message = "document not found"
+		respondError(w, status, apiErr.withPath(cleanPath))
+		return "", "", false
 	}
 
-	// Generate filename using the cleaned path
-	filename := sanitizeFilename(cleanPath) + exporter.FileExtension(exporter.Format(format))
-
-	// Set response headers
-	w.Header().Set("Content-Type", exporter.ContentType(exporter.Format(format)))
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
-	w.WriteHeader(http.StatusOK)
-
-	// Export the page using the cleaned path
-	opts := exporter.ExportPageOptions{
-		RootDir: s.cfg.RootDir,
-		Path:    cleanPath,
-		Format:  exporter.Format(format),
-		Writer:  w,
-	}
-
-	if err := s.exporter.ExportPage(ctx, opts); err != nil {
-		s.logger.ErrorContext(ctx, "export failed", slog.Any("err", err), slog.String("path", cleanPath), slog.String("format", format))
-		// At this point headers are already sent, so we can't return a proper error response
-		// The error will be logged and the response will be incomplete
-	}
+	return cleanPath, format, true
 }
 
 func sanitizeFilename(path string) string {
@@ -993,10 +1600,6 @@ func sanitizeFilename(path string) string {
 	return name
 }
 
-func errorResponse(message string) map[string]string {
-	return map[string]string{"error": message}
-}
-
 // discoverCustomCSS searches for custom theme CSS files in global and per-repo locations
 // and validates paths for security (symlink resolution, directory traversal prevention)
 func (s *Server) discoverCustomCSS() {
@@ -1103,8 +1706,59 @@ func (s *Server) handleCustomCSS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cssPath := s.customCSSPaths[index]
+	s.serveCustomCSS(w, r, s.customCSSPaths[index])
+}
+
+// handleScopedCSS serves <dir>/.wikimd/custom.css for a directory inside the
+// wiki root.
+func (s *Server) handleScopedCSS(w http.ResponseWriter, r *http.Request) {
+	dir, err := parseWildcardPath(r.PathValue("dir"))
+	if err != nil {
+		http.Error(w, "CSS file not found", http.StatusNotFound)
+		return
+	}
+	cssPath := s.scopedCSSPath(dir)
+	if cssPath == "" {
+		http.Error(w, "CSS file not found", http.StatusNotFound)
+		return
+	}
+	s.serveCustomCSS(w, r, cssPath)
+}
+
+// scopedCSSPath returns the validated custom.css of a wiki subdirectory, or
+// "" when it has none or dir does not name a directory inside the root.
+func (s *Server) scopedCSSPath(dir string) string {
+	if s.cfg.RootDir == "" {
+		return ""
+	}
+	clean := path.Clean(strings.Trim(dir, "/"))
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") || path.IsAbs(clean) {
+		return ""
+	}
+	cssDir := filepath.Join(s.cfg.RootDir, filepath.FromSlash(clean), ".wikimd")
+	return s.validateCSSPath(filepath.Join(cssDir, "custom.css"), cssDir)
+}
+
+// scopedCSSURLs lists the custom stylesheets of the directories enclosing a
+// page, outermost first, so a nested section's theme overrides its parent's.
+// They are looked up on each render, so a new custom.css applies on refresh.
+func (s *Server) scopedCSSURLs(pagePath string) []string {
+	var urls []string
+	dir := ""
+	for _, segment := range strings.Split(path.Dir(pagePath), "/") {
+		if segment == "." || segment == "" {
+			break
+		}
+		dir = path.Join(dir, segment)
+		if s.scopedCSSPath(dir) != "" {
+			urls = append(urls, "/custom-theme/dir/"+dir)
+		}
+	}
+	return urls
+}
 
+// serveCustomCSS writes a validated stylesheet with caching headers.
+func (s *Server) serveCustomCSS(w http.ResponseWriter, r *http.Request, cssPath string) {
 	// Security: Re-validate file extension
 	if filepath.Ext(cssPath) != ".css" {
 		s.logger.Warn("invalid CSS file extension", slog.String("path", cssPath))
@@ -1,9 +1,11 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
@@ -11,15 +13,21 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
+	"github.com/euforicio/wikimd/internal/banner"
 	"github.com/euforicio/wikimd/internal/config"
 	"github.com/euforicio/wikimd/internal/content"
 	"github.com/euforicio/wikimd/internal/content/tree"
+	"github.com/euforicio/wikimd/internal/jobs"
 	"github.com/euforicio/wikimd/internal/renderer"
+	d2renderer "github.com/euforicio/wikimd/internal/renderer/d2"
 	"github.com/euforicio/wikimd/internal/search"
+	"github.com/euforicio/wikimd/static"
 )
 
 func TestAPIHandlers(t *testing.T) {
@@ -65,6 +73,71 @@ func TestAPIHandlers(t *testing.T) {
 		}
 	})
 
+	t.Run("tree honours sort parameters", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/tree?sort=title&dirsFirst=false", nil)
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Root *tree.Node `json:"root"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if first := resp.Root.Children[0]; first.Type != tree.NodeTypeFile {
+			t.Fatalf("expected a document first with dirsFirst=false, got %s", first.RelativePath)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/api/tree?sort=popularity", nil)
+		rec = httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"field":"sort"`) {
+			t.Fatalf("expected 400 for unknown sort, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("tree delta is empty at the current generation", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/tree", nil)
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		var snapshot struct {
+			Root       *tree.Node `json:"root"`
+			Generation uint64     `json:"generation"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &snapshot); err != nil {
+			t.Fatalf("failed to decode tree: %v", err)
+		}
+		if snapshot.Generation == 0 || snapshot.Root.Hash == "" {
+			t.Fatalf("expected generation and root hash, got %d %q", snapshot.Generation, snapshot.Root.Hash)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/tree/delta?since=%d", snapshot.Generation), nil)
+		rec = httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var delta struct {
+			Changed []json.RawMessage `json:"changed"`
+			Full    bool              `json:"full"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &delta); err != nil {
+			t.Fatalf("failed to decode delta: %v", err)
+		}
+		if delta.Full || len(delta.Changed) != 0 {
+			t.Fatalf("expected empty delta, got %s", rec.Body.String())
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/api/tree/delta?since=latest", nil)
+		rec = httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"field":"since"`) {
+			t.Fatalf("expected 400 for invalid since, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
 	t.Run("tree returns HTML for HTMX requests", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/api/tree?current=index.md", nil)
 		req.Header.Set("HX-Request", "true")
@@ -244,6 +317,76 @@ func TestAPIHandlers(t *testing.T) {
 		}
 	})
 
+	t.Run("create endpoint replays requests with the same idempotency key", func(t *testing.T) {
+		send := func(path, payload string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(payload))
+			req.Host = "localhost:8080"
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Origin", "http://localhost:8080")
+			req.Header.Set(IdempotencyKeyHeader, "create-retry-1")
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			return rec
+		}
+		target := filepath.Join(srv.cfg.RootDir, "notes", "retried.md")
+		t.Cleanup(func() { _ = os.Remove(target) })
+
+		payload := `{"path":"notes/retried.md","content":"# Retried\n"}`
+		first := send("/api/page", payload)
+		if first.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d with body %s", first.Code, first.Body.String())
+		}
+		// A retry must not hit the "already exists" conflict, even via the v1 alias.
+		second := send("/api/v1/page", payload)
+		if second.Code != http.StatusCreated {
+			t.Fatalf("expected replayed 201, got %d with body %s", second.Code, second.Body.String())
+		}
+		if second.Header().Get(idempotencyReplayedHeader) != "true" {
+			t.Fatalf("expected replayed response to be marked")
+		}
+		if second.Body.String() != first.Body.String() {
+			t.Fatalf("replayed body differs: %q vs %q", second.Body.String(), first.Body.String())
+		}
+
+		other := send("/api/page", `{"path":"notes/other.md","content":"# Other\n"}`)
+		if other.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("expected 422 for reused key, got %d", other.Code)
+		}
+	})
+
+	t.Run("create endpoint reports traversal and missing fields with codes", func(t *testing.T) {
+		for _, tc := range []struct {
+			payload, code, field string
+			status               int
+		}{
+			{`{"path":"../outside.md","content":"x"}`, codePathTraversal, "path", http.StatusUnprocessableEntity},
+			{`{"content":"x"}`, codeValidation, "path", http.StatusUnprocessableEntity},
+			{`{"path":"notes/bad.md","content":"---\ntitle: [unclosed\n---\n"}`, codeValidation, "content", http.StatusUnprocessableEntity},
+			{`{"path":`, codeInvalidJSON, "", http.StatusBadRequest},
+		} {
+			req := httptest.NewRequest(http.MethodPost, "/api/page", strings.NewReader(tc.payload))
+			req.Host = "localhost:8080"
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Origin", "http://localhost:8080")
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+
+			var apiErr apiError
+			if err := json.Unmarshal(rec.Body.Bytes(), &apiErr); err != nil {
+				t.Fatalf("decode error body for %s: %v", tc.payload, err)
+			}
+			if rec.Code != tc.status {
+				t.Fatalf("payload %s: expected status %d, got %d", tc.payload, tc.status, rec.Code)
+			}
+			if apiErr.Code != tc.code || apiErr.Field != tc.field {
+				t.Fatalf("payload %s: expected code %q field %q, got %+v", tc.payload, tc.code, tc.field, apiErr)
+			}
+			if apiErr.Error != apiErr.Message {
+				t.Fatalf("expected legacy error field to mirror message, got %+v", apiErr)
+			}
+		}
+	})
+
 	t.Run("rename endpoint moves document", func(t *testing.T) {
 		payload := `{"from":"guides/getting_started.md","to":"guides/getting_started_v2.md"}`
 		req := httptest.NewRequest(http.MethodPost, "/api/page/rename", strings.NewReader(payload))
@@ -303,6 +446,41 @@ func TestAPIHandlers(t *testing.T) {
 		}
 	})
 
+	t.Run("archive endpoint retires document behind a redirect", func(t *testing.T) {
+		target := filepath.Join(srv.cfg.RootDir, "notes", "retired.md")
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			t.Fatalf("create directory failed: %v", err)
+		}
+		if err := os.WriteFile(target, []byte("# Retired\n"), 0o644); err != nil {
+			t.Fatalf("prepare document failed: %v", err)
+		}
+		archived := filepath.Join(srv.cfg.RootDir, "archive", "notes", "retired.md")
+		t.Cleanup(func() {
+			_ = os.Remove(archived)
+			_ = os.Remove(filepath.Join(srv.cfg.RootDir, ".wikimd", "redirects.json"))
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/page/notes/retired.md/archive", nil)
+		req.Host = "localhost:8080"
+		req.Header.Set("Origin", "http://localhost:8080")
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d with body %s", rec.Code, rec.Body.String())
+		}
+		if _, err := os.Stat(archived); err != nil {
+			t.Fatalf("expected archived document on disk: %v", err)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/page/notes/retired.md", nil)
+		rec = httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/page/archive/notes/retired.md" {
+			t.Fatalf("expected redirect to archived page, got %d %q", rec.Code, rec.Header().Get("Location"))
+		}
+	})
+
 	t.Run("search endpoint returns ripgrep results", func(t *testing.T) {
 		if _, err := exec.LookPath("rg"); err != nil {
 			t.Skip("rg not installed")
@@ -348,6 +526,503 @@ func TestAPIHandlers(t *testing.T) {
 			t.Fatalf("expected rendered search fragment")
 		}
 	})
+
+	t.Run("lint endpoint reports findings", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/lint?path=index.md", nil)
+		rec := httptest.NewRecorder()
+
+		srv.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d with body %s", rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Findings  []map[string]any `json:"findings"`
+			Documents int              `json:"documents"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp.Documents != 1 {
+			t.Fatalf("expected one linted document, got %d", resp.Documents)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/api/lint?format=sarif", nil)
+		rec = httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200 for sarif, got %d", rec.Code)
+		}
+		if !strings.Contains(rec.Header().Get("Content-Type"), "sarif") {
+			t.Fatalf("expected sarif content type, got %q", rec.Header().Get("Content-Type"))
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/api/lint?path=missing.md", nil)
+		rec = httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("expected 404 for unknown document, got %d", rec.Code)
+		}
+	})
+
+	t.Run("lint endpoint checks unsaved drafts", func(t *testing.T) {
+		payload := `{"path":"draft.md","pass":"accessibility","content":"# Draft\n\n![](diagram.png)\n"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/lint", strings.NewReader(payload))
+		req.Host = "localhost:8080" // Set Host for CSRF validation
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", "http://localhost:8080") // CSRF protection requires Origin
+		rec := httptest.NewRecorder()
+
+		srv.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d with body %s", rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Findings []struct {
+				Rule string `json:"rule"`
+				Line int    `json:"line"`
+			} `json:"findings"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(resp.Findings) != 1 || resp.Findings[0].Rule != "image-alt-text" || resp.Findings[0].Line != 3 {
+			t.Fatalf("expected one image-alt-text finding on line 3, got %+v", resp.Findings)
+		}
+		if _, err := os.Stat(filepath.Join(srv.cfg.RootDir, "draft.md")); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("expected draft not to be written to disk, stat err: %v", err)
+		}
+	})
+
+	t.Run("directory dashboard renders generated landing page", func(t *testing.T) {
+		target := filepath.Join(srv.cfg.RootDir, "guides", "_dashboard.yaml")
+		if err := os.WriteFile(target, []byte("title: Guides Hub\npinned:\n  - title: Status\n    url: https://status.example.com\n"), 0o644); err != nil {
+			t.Fatalf("write dashboard failed: %v", err)
+		}
+		t.Cleanup(func() { _ = os.Remove(target) })
+
+		var body string
+		deadline := time.Now().Add(3 * time.Second)
+		for {
+			req := httptest.NewRequest(http.MethodGet, "/page/guides", nil)
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			body = rec.Body.String()
+			if strings.Contains(body, "data-dashboard=") || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		for _, want := range []string{"Guides Hub", "https://status.example.com", "In this section", "/page/guides/advanced_topics.md"} {
+			if !strings.Contains(body, want) {
+				t.Fatalf("expected dashboard to contain %q, got %s", want, body)
+			}
+		}
+		if strings.Contains(body, `id="export-button"`) {
+			t.Fatalf("expected generated dashboard to omit export controls")
+		}
+	})
+
+	t.Run("inventory lists every page as csv", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/inventory?format=csv", nil)
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d with body %s", rec.Code, rec.Body.String())
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+			t.Fatalf("expected text/csv, got %q", ct)
+		}
+		if cd := rec.Header().Get("Content-Disposition"); !strings.Contains(cd, "-inventory-") {
+			t.Fatalf("expected an attachment filename, got %q", cd)
+		}
+		lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+		if lines[0] != "path,title,tags,owner,modified,words,broken_links" {
+			t.Fatalf("unexpected header %q", lines[0])
+		}
+		if !strings.Contains(rec.Body.String(), "guides/advanced_topics.md,") {
+			t.Fatalf("expected a row per page, got:\n%s", rec.Body.String())
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/api/inventory?format=xml", nil)
+		rec = httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for an unknown format, got %d", rec.Code)
+		}
+	})
+
+	t.Run("overdue reviews endpoint lists lapsed pages", func(t *testing.T) {
+		target := filepath.Join(srv.cfg.RootDir, "policy.md")
+		if err := os.WriteFile(target, []byte("---\ntitle: Retention Policy\nreviewBy: 2020-01-01\n---\n\n# Policy\n"), 0o644); err != nil {
+			t.Fatalf("write document failed: %v", err)
+		}
+		t.Cleanup(func() { _ = os.Remove(target) })
+
+		type overdueResponse struct {
+			Pages []struct {
+				Path        string `json:"path"`
+				Title       string `json:"title"`
+				DaysOverdue int    `json:"daysOverdue"`
+			} `json:"pages"`
+			Count int `json:"count"`
+		}
+		var resp overdueResponse
+		deadline := time.Now().Add(3 * time.Second)
+		for {
+			req := httptest.NewRequest(http.MethodGet, "/api/reviews/overdue", nil)
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d with body %s", rec.Code, rec.Body.String())
+			}
+			resp = overdueResponse{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Count > 0 || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		if resp.Count != 1 || resp.Pages[0].Path != "policy.md" || resp.Pages[0].Title != "Retention Policy" {
+			t.Fatalf("expected policy.md to be overdue, got %+v", resp)
+		}
+		if resp.Pages[0].DaysOverdue < 1 {
+			t.Fatalf("expected positive daysOverdue, got %d", resp.Pages[0].DaysOverdue)
+		}
+	})
+
+	t.Run("calendar feed lists dated pages", func(t *testing.T) {
+		if err := os.MkdirAll(filepath.Join(srv.cfg.RootDir, "meetings"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		target := filepath.Join(srv.cfg.RootDir, "meetings", "kickoff.md")
+		doc := "---\ntitle: Kickoff\nevent:\n  start: 2025-08-01T09:00:00Z\n  end: 2025-08-01T10:30:00Z\n  location: Room 4\n---\n\n# Kickoff\n"
+		if err := os.WriteFile(target, []byte(doc), 0o644); err != nil {
+			t.Fatalf("write document failed: %v", err)
+		}
+		t.Cleanup(func() { _ = os.RemoveAll(filepath.Dir(target)) })
+
+		var body string
+		deadline := time.Now().Add(3 * time.Second)
+		for {
+			req := httptest.NewRequest(http.MethodGet, "/api/calendar.ics?dir=meetings", nil)
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d with body %s", rec.Code, rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+				t.Fatalf("expected text/calendar, got %q", ct)
+			}
+			body = rec.Body.String()
+			if strings.Contains(body, "BEGIN:VEVENT") || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		for _, want := range []string{
+			"SUMMARY:Kickoff\r\n",
+			"DTSTART:20250801T090000Z\r\nDTEND:20250801T103000Z\r\n",
+			"LOCATION:Room 4\r\n",
+			"URL:http://example.com/page/meetings/kickoff.md\r\n",
+		} {
+			if !strings.Contains(body, want) {
+				t.Fatalf("expected feed to contain %q, got:\n%s", want, body)
+			}
+		}
+		if strings.Count(body, "BEGIN:VEVENT") != 1 {
+			t.Fatalf("expected only the meetings folder, got:\n%s", body)
+		}
+	})
+
+	t.Run("external links endpoint requires opt-in", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/lint/external-links", nil)
+		rec := httptest.NewRecorder()
+
+		srv.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected 503 when link checking is disabled, got %d", rec.Code)
+		}
+	})
+
+	t.Run("routes endpoint lists registered routes", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/routes", nil)
+		rec := httptest.NewRecorder()
+
+		srv.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+		var resp struct {
+			Routes []routeInfo `json:"routes"`
+			Count  int         `json:"count"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp.Count != len(resp.Routes) || resp.Count == 0 {
+			t.Fatalf("expected non-empty route list, got %d routes (count %d)", len(resp.Routes), resp.Count)
+		}
+		found := false
+		for _, rt := range resp.Routes {
+			if rt.Description == "" {
+				t.Fatalf("route %s %s has no description", rt.Method, rt.Path)
+			}
+			if rt.Method == http.MethodPut && rt.Path == "/api/page/{path...}" {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("expected PUT /api/page/{path...} in routes, got %+v", resp.Routes)
+		}
+	})
+
+	t.Run("file endpoint describes attachments", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/file/index.md", nil)
+		rec := httptest.NewRecorder()
+
+		srv.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var info content.FileInfo
+		if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if info.Type != content.FileMarkdown || info.Size == 0 || info.MIME == "" {
+			t.Fatalf("unexpected file info: %+v", info)
+		}
+
+		for path, want := range map[string]int{
+			"missing.bin":   http.StatusNotFound,
+			"..%2Fetc.conf": http.StatusBadRequest,
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/api/file/"+path, nil)
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			if rec.Code != want {
+				t.Fatalf("%s: expected %d, got %d", path, want, rec.Code)
+			}
+		}
+	})
+
+	t.Run("diagram stats report the render queue", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/diagrams/stats", nil)
+		rec := httptest.NewRecorder()
+
+		srv.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+		var stats d2renderer.Stats
+		if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if stats.Concurrency < 1 {
+			t.Fatalf("expected a concurrency limit, got %+v", stats)
+		}
+	})
+
+	t.Run("reindex runs as a job", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/jobs/reindex", nil)
+		req.Host = "localhost:8080"
+		req.Header.Set("Origin", "http://localhost:8080")
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var started jobs.Status
+		if err := json.Unmarshal(rec.Body.Bytes(), &started); err != nil {
+			t.Fatalf("decode job: %v", err)
+		}
+		if started.Kind != "reindex" || rec.Header().Get("Location") != "/api/jobs/"+started.ID {
+			t.Fatalf("unexpected job %+v (Location %q)", started, rec.Header().Get("Location"))
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			req = httptest.NewRequest(http.MethodGet, "/api/jobs/"+started.ID, nil)
+			rec = httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			var status jobs.Status
+			if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+				t.Fatalf("decode job: %v", err)
+			}
+			if status.State == jobs.StateSucceeded {
+				if status.Done == 0 {
+					t.Fatalf("expected scanned documents in progress, got %+v", status)
+				}
+				break
+			}
+			if status.State != jobs.StateRunning || time.Now().After(deadline) {
+				t.Fatalf("reindex job ended as %+v", status)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/api/jobs", nil)
+		rec = httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if !strings.Contains(rec.Body.String(), started.ID) {
+			t.Fatalf("expected job list to include %s: %s", started.ID, rec.Body.String())
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/api/jobs/reindex-999", nil)
+		rec = httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("expected 404 for unknown job, got %d", rec.Code)
+		}
+	})
+
+	t.Run("export job produces a downloadable result", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/export?path=index.md&format=markdown", nil)
+		req.Host = "localhost:8080"
+		req.Header.Set("Origin", "http://localhost:8080")
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var status jobs.Status
+		if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+			t.Fatalf("decode job: %v", err)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for status.State == jobs.StateRunning {
+			if time.Now().After(deadline) {
+				t.Fatal("export job did not finish")
+			}
+			time.Sleep(20 * time.Millisecond)
+			req = httptest.NewRequest(http.MethodGet, "/api/jobs/"+status.ID, nil)
+			rec = httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+				t.Fatalf("decode job: %v", err)
+			}
+		}
+		if status.State != jobs.StateSucceeded || status.Result == "" {
+			t.Fatalf("expected a succeeded job with a result, got %+v", status)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, status.Result, nil)
+		rec = httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "title: Welcome Home") {
+			t.Fatalf("expected markdown export, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if disposition := rec.Header().Get("Content-Disposition"); !strings.Contains(disposition, "index.md") {
+			t.Fatalf("expected attachment filename, got %q", disposition)
+		}
+
+		req = httptest.NewRequest(http.MethodPost, "/api/export?scope=everything", nil)
+		req.Host = "localhost:8080"
+		req.Header.Set("Origin", "http://localhost:8080")
+		rec = httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for unknown scope, got %d", rec.Code)
+		}
+	})
+
+	t.Run("batch pdf checks pages before starting a job", func(t *testing.T) {
+		cases := []struct {
+			body   string
+			status int
+		}{
+			{`{"paths":[]}`, http.StatusUnprocessableEntity},
+			{`{"paths":["index.md","../etc/passwd"]}`, http.StatusUnprocessableEntity},
+			{`{"paths":["index.md","missing.md"]}`, http.StatusNotFound},
+			{`{"paths":`, http.StatusBadRequest},
+			{`{"title":"Handout","paths":["index.md","guides/getting_started.md"]}`, http.StatusAccepted},
+		}
+		for _, tc := range cases {
+			req := httptest.NewRequest(http.MethodPost, "/api/export/batch-pdf", strings.NewReader(tc.body))
+			req.Host = "localhost:8080"
+			req.Header.Set("Origin", "http://localhost:8080")
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Fatalf("%s: expected %d, got %d: %s", tc.body, tc.status, rec.Code, rec.Body.String())
+			}
+			if tc.status == http.StatusAccepted && !strings.HasPrefix(rec.Header().Get("Location"), "/api/jobs/") {
+				t.Fatalf("expected a job location, got %q", rec.Header().Get("Location"))
+			}
+		}
+	})
+
+	t.Run("tag suggestions rank prefix matches first", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/tags/suggest?q=O", nil)
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+		var resp struct {
+			Tags []tagSuggestion `json:"tags"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if len(resp.Tags) != 2 || resp.Tags[0].Tag != "onboarding" || resp.Tags[1].Tag != "intro" {
+			t.Fatalf("expected onboarding then intro, got %+v", resp.Tags)
+		}
+		if resp.Tags[0].Count != 1 {
+			t.Fatalf("expected usage count 1, got %d", resp.Tags[0].Count)
+		}
+	})
+
+	t.Run("v1 aliases negotiate version and unversioned paths signal deprecation", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tree", nil)
+		req.Header.Set(APIVersionHeader, "1")
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200 from /api/v1/tree, got %d", rec.Code)
+		}
+		if got := rec.Header().Get(APIVersionHeader); got != "1" {
+			t.Fatalf("expected version header 1, got %q", got)
+		}
+		if rec.Header().Get("Deprecation") != "" {
+			t.Fatalf("versioned route should not be deprecated")
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/api/tree", nil)
+		rec = httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200 from /api/tree, got %d", rec.Code)
+		}
+		if !strings.HasPrefix(rec.Header().Get("Deprecation"), "@") {
+			t.Fatalf("expected Deprecation header, got %q", rec.Header().Get("Deprecation"))
+		}
+		if link := rec.Header().Get("Link"); link != `</api/v1/tree>; rel="successor-version"` {
+			t.Fatalf("unexpected Link header %q", link)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/api/v1/tree", nil)
+		req.Header.Set(APIVersionHeader, "2")
+		rec = httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for unsupported version, got %d", rec.Code)
+		}
+	})
 }
 
 func TestRootHandlerRendersLayout(t *testing.T) {
@@ -376,6 +1051,92 @@ func TestRootHandlerRendersLayout(t *testing.T) {
 	}
 }
 
+func TestPageRouteStreamsLargeDocuments(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServer(t)
+	t.Cleanup(cleanup)
+
+	// Force every document onto the streaming path.
+	srv.streamThreshold = 1
+
+	req := httptest.NewRequest(http.MethodGet, "/page/index.md", nil)
+	rec := httptest.NewRecorder()
+
+	srv.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if !rec.Flushed {
+		t.Fatalf("expected streamed response to be flushed")
+	}
+	body := rec.Body.String()
+	head := strings.Index(body, "id=\"nav-tree\"")
+	article := strings.Index(body, "id=\"page-view\"")
+	foot := strings.Index(body, "</html>")
+	if head == -1 || article == -1 || foot == -1 {
+		t.Fatalf("expected complete layout, got %q", body)
+	}
+	if head > article || article > foot {
+		t.Fatalf("expected shell, article, and footer in order")
+	}
+	if !strings.Contains(body, "<h1 id=\"welcome\">Welcome") {
+		t.Fatalf("expected rendered document in streamed body")
+	}
+}
+
+func TestPageRouteShowsBanner(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServer(t)
+	t.Cleanup(cleanup)
+
+	b, err := banner.New("This wiki is moving to **docs.example.com**.", banner.SeverityWarning, true)
+	if err != nil {
+		t.Fatalf("banner: %v", err)
+	}
+	srv.banner = b
+
+	req := httptest.NewRequest(http.MethodGet, "/page/index.md", nil)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"wikimd-banner-warning", "<strong>docs.example.com</strong>", "Dismiss announcement"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %q in page, got %q", want, body)
+		}
+	}
+}
+
+func TestLayoutPinsEmbeddedAssets(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServer(t)
+	t.Cleanup(cleanup)
+
+	// The test server serves assets from disk, which get no hashes; pin the
+	// embedded copies as a production server would.
+	tmpl, err := newTemplateRenderer(static.Integrity)
+	if err != nil {
+		t.Fatalf("templates: %v", err)
+	}
+	srv.templates = tmpl
+
+	req := httptest.NewRequest(http.MethodGet, "/page/index.md", nil)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	want := `href="/static/css/app.css" integrity="` + static.Integrity("css/app.css") + `"`
+	if !strings.Contains(rec.Body.String(), want) {
+		t.Fatalf("expected %q in page, got %q", want, rec.Body.String())
+	}
+}
+
 func TestEventsHandlerSendsReadyComment(t *testing.T) {
 	t.Parallel()
 	srv, cleanup := newTestServer(t)
@@ -560,6 +1321,23 @@ func TestExportHandlerSecurity(t *testing.T) {
 		if !strings.Contains(contentType, "text/markdown") {
 			t.Errorf("expected content-type text/markdown, got %s", contentType)
 		}
+		if rec.Header().Get("Content-Length") != strconv.Itoa(rec.Body.Len()) {
+			t.Errorf("expected buffered export with Content-Length %d, got %q", rec.Body.Len(), rec.Header().Get("Content-Length"))
+		}
+
+		id := rec.Header().Get("X-Wikimd-Job")
+		if id == "" {
+			t.Fatal("expected export outcome job header")
+		}
+		req = httptest.NewRequest(http.MethodGet, "/api/jobs/"+id, nil)
+		rec = httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		var status struct {
+			State string `json:"state"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil || status.State != "succeeded" {
+			t.Fatalf("expected succeeded export record, got %d: %s", rec.Code, rec.Body.String())
+		}
 	})
 
 	t.Run("requires path parameter", func(t *testing.T) {
@@ -601,8 +1379,51 @@ func TestExportHandlerSecurity(t *testing.T) {
 	})
 }
 
+func TestCreateRejectsFrozenDirectories(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServerWithOptions(t, nil, content.Options{FrozenDirs: []string{"archive"}})
+	t.Cleanup(cleanup)
+
+	payload := `{"path":"archive/v1.md","content":"# Release 1\n"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/page", strings.NewReader(payload))
+	req.Host = "localhost:8080"
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "http://localhost:8080")
+	rec := httptest.NewRecorder()
+
+	srv.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusLocked {
+		t.Fatalf("expected status 423, got %d with body %s", rec.Code, rec.Body.String())
+	}
+	var apiErr apiError
+	if err := json.Unmarshal(rec.Body.Bytes(), &apiErr); err != nil {
+		t.Fatalf("decode error body: %v", err)
+	}
+	if apiErr.Code != codeLocked || apiErr.Path != "archive/v1.md" || apiErr.Message == "" {
+		t.Fatalf("unexpected error body %+v", apiErr)
+	}
+	if _, err := os.Stat(filepath.Join(srv.cfg.RootDir, "archive", "v1.md")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected frozen directory to stay untouched, got err=%v", err)
+	}
+}
+
 func newTestServer(t *testing.T) (*testServer, func()) {
 	t.Helper()
+	return newTestServerWithSearch(t, nil)
+}
+
+// newTestServerWithSearch builds a test server around backend, or around the
+// ripgrep backend when backend is nil.
+func newTestServerWithSearch(t *testing.T, backend search.Backend) (*testServer, func()) {
+	t.Helper()
+	return newTestServerWithOptions(t, backend, content.Options{})
+}
+
+// newTestServerWithOptions is newTestServerWithSearch with custom content
+// service options.
+func newTestServerWithOptions(t *testing.T, backend search.Backend, opts content.Options) (*testServer, func()) {
+	t.Helper()
 
 	tempRoot := t.TempDir()
 	copyDir(t, filepath.Join("..", "..", "testdata", "wiki"), tempRoot)
@@ -610,15 +1431,18 @@ func newTestServer(t *testing.T) (*testServer, func()) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError}))
 	renderSvc := renderer.NewService(logger)
 
-	contentSvc, err := content.NewService(context.Background(), tempRoot, renderSvc, logger, content.Options{})
+	contentSvc, err := content.NewService(context.Background(), tempRoot, renderSvc, logger, opts)
 	if err != nil {
 		t.Fatalf("content service init failed: %v", err)
 	}
 
-	searchSvc, err := search.NewService(tempRoot, logger)
-	if err != nil {
-		contentSvc.Close()
-		t.Fatalf("search service init failed: %v", err)
+	if backend == nil {
+		searchSvc, err := search.NewService(tempRoot, logger, search.BackendOptions{})
+		if err != nil {
+			contentSvc.Close()
+			t.Fatalf("search service init failed: %v", err)
+		}
+		backend = searchSvc
 	}
 
 	cfg := config.Default()
@@ -626,7 +1450,7 @@ func newTestServer(t *testing.T) (*testServer, func()) {
 	cfg.AutoOpen = false
 	cfg.AssetsDir = filepath.Join("..", "..", "static")
 
-	srv, err := New(cfg, logger, contentSvc, searchSvc)
+	srv, err := New(cfg, logger, contentSvc, backend)
 	if err != nil {
 		contentSvc.Close()
 		t.Fatalf("server init failed: %v", err)
@@ -680,3 +1504,25 @@ func copyDir(t *testing.T, src, dst string) {
 		t.Fatalf("copyDir failed: %v", err)
 	}
 }
+
+func TestExportWriterSanitizesErrorTrailer(t *testing.T) {
+	t.Parallel()
+	rec := httptest.NewRecorder()
+	out := &exportWriter{w: rec}
+	if _, err := out.Write(bytes.Repeat([]byte("x"), exportBufferLimit+1)); err != nil {
+		t.Fatal(err)
+	}
+	out.finish(fmt.Errorf("render pdf: %w", errors.New("font missing\r\nX-Injected: yes\n"+strings.Repeat("é", 400))))
+
+	trailer := rec.Result().Trailer
+	if trailer.Get(exportStatusTrailer) != "failed" {
+		t.Fatalf("expected failed status trailer, got %q", trailer.Get(exportStatusTrailer))
+	}
+	msg := trailer.Get(exportErrorTrailer)
+	if strings.ContainsAny(msg, "\r\n") || len(msg) > maxTrailerValue+len("...") || !utf8.ValidString(msg) {
+		t.Fatalf("expected a single capped line, got %q", msg)
+	}
+	if !strings.HasPrefix(msg, "render pdf: font missing X-Injected: yes é") {
+		t.Fatalf("unexpected trailer %q", msg)
+	}
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"cmp"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/renderer"
+)
+
+const (
+	// pageStatsWindow is how many recent views of a page its response
+	// times are averaged over.
+	pageStatsWindow = 50
+	// maxTrackedPages bounds the pages with statistics; views of further
+	// pages are not tracked.
+	maxTrackedPages = 10000
+	// defaultSlowPageThreshold is the render time above which
+	// GET /api/slow-pages lists a page.
+	defaultSlowPageThreshold = 200 * time.Millisecond
+)
+
+// pageStats keeps, for every viewed page, the cost of its latest render and
+// its response times over the last pageStatsWindow views: full page loads
+// and the HTMX swaps of soft navigation.
+type pageStats struct {
+	mu    sync.Mutex
+	pages map[string]*pageViews
+}
+
+type pageViews struct {
+	render    renderer.Cost
+	responses [pageStatsWindow]time.Duration
+	next      int // ring index of the next response
+	count     int // responses in the ring
+	total     int // views since the server started
+}
+
+func newPageStats() *pageStats {
+	return &pageStats{pages: make(map[string]*pageViews)}
+}
+
+// observe records a view of path whose document cost render and whose
+// response started at started.
+func (p *pageStats) observe(path string, render renderer.Cost, started time.Time) {
+	elapsed := time.Since(started)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	views, ok := p.pages[path]
+	if !ok {
+		if len(p.pages) >= maxTrackedPages {
+			return
+		}
+		views = &pageViews{}
+		p.pages[path] = views
+	}
+	views.render = render
+	views.responses[views.next] = elapsed
+	views.next = (views.next + 1) % pageStatsWindow
+	views.count = min(views.count+1, pageStatsWindow)
+	views.total++
+}
+
+// slowPage is one entry of GET /api/slow-pages. Times are in milliseconds.
+//
+//nolint:govet // field order matches the JSON documented in the README
+type slowPage struct {
+	Path          string  `json:"path"`
+	Dominant      string  `json:"dominant"`
+	RenderMs      float64 `json:"renderMs"`
+	D2Ms          float64 `json:"d2Ms"`
+	HighlightMs   float64 `json:"highlightMs"`
+	Bytes         int     `json:"bytes"`
+	Diagrams      int     `json:"diagrams"`
+	CodeBlocks    int     `json:"codeBlocks"`
+	Views         int     `json:"views"`
+	AvgResponseMs float64 `json:"avgResponseMs"`
+	MaxResponseMs float64 `json:"maxResponseMs"`
+}
+
+// slow lists the pages whose latest render took at least threshold, slowest
+// first. keep filters out pages that no longer exist.
+func (p *pageStats) slow(threshold time.Duration, keep func(string) bool) []slowPage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := []slowPage{}
+	for path, views := range p.pages {
+		if views.render.Total < threshold || !keep(path) {
+			continue
+		}
+		var sum, worst time.Duration
+		for _, d := range views.responses[:views.count] {
+			sum += d
+			worst = max(worst, d)
+		}
+		out = append(out, slowPage{
+			Path:          path,
+			Dominant:      views.render.Dominant(),
+			RenderMs:      millis(views.render.Total),
+			D2Ms:          millis(views.render.D2),
+			HighlightMs:   millis(views.render.Highlight),
+			Bytes:         views.render.Bytes,
+			Diagrams:      views.render.Diagrams,
+			CodeBlocks:    views.render.CodeBlocks,
+			Views:         views.total,
+			AvgResponseMs: millis(sum / time.Duration(max(views.count, 1))),
+			MaxResponseMs: millis(worst),
+		})
+	}
+	slices.SortFunc(out, func(a, b slowPage) int {
+		return cmp.Or(cmp.Compare(b.RenderMs, a.RenderMs), strings.Compare(a.Path, b.Path))
+	})
+	return out
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// handleSlowPages serves GET /api/slow-pages, the viewed pages whose latest
+// render took at least ?threshold= (a Go duration, default 200ms), with
+// what dominated the render and their recent response times.
+func (s *Server) handleSlowPages(w http.ResponseWriter, r *http.Request) {
+	threshold := defaultSlowPageThreshold
+	if v := r.URL.Query().Get("threshold"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "threshold must be a duration such as 250ms").withField("threshold"))
+			return
+		}
+		threshold = d
+	}
+
+	// Paths were recorded after their documents loaded, so they are safe
+	// to join under the root.
+	pages := s.pageStats.slow(threshold, func(path string) bool {
+		_, err := os.Stat(filepath.Join(s.cfg.RootDir, filepath.FromSlash(path)))
+		return err == nil
+	})
+	respondJSON(w, http.StatusOK, struct {
+		ThresholdMs float64    `json:"thresholdMs"`
+		Window      int        `json:"window"`
+		Pages       []slowPage `json:"pages"`
+		Count       int        `json:"count"`
+	}{
+		ThresholdMs: millis(threshold),
+		Window:      pageStatsWindow,
+		Pages:       pages,
+		Count:       len(pages),
+	})
+}
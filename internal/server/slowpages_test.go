@@ -0,0 +1,72 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSlowPages(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServer(t)
+	t.Cleanup(cleanup)
+
+	page := "# Snippets\n\n" + strings.Repeat("```go\nfunc main() { println(\"hi\") }\n```\n\n", 20)
+	if err := os.WriteFile(filepath.Join(srv.cfg.RootDir, "snippets.md"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []string{"/page/snippets.md", "/api/page/snippets.md"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("HX-Request", "true")
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", target, rec.Code)
+		}
+	}
+
+	type report struct {
+		Pages []slowPage `json:"pages"`
+		Count int        `json:"count"`
+	}
+	fetch := func(query string) report {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/slow-pages?"+query, nil)
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var got report
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	all := fetch("threshold=0s")
+	if all.Count != 1 {
+		t.Fatalf("expected the viewed page, got %+v", all.Pages)
+	}
+	got := all.Pages[0]
+	if got.Path != "snippets.md" || got.Views != 2 || got.CodeBlocks != 20 || got.Bytes != len(page) || got.RenderMs <= 0 || got.MaxResponseMs < got.AvgResponseMs {
+		t.Errorf("unexpected entry %+v", got)
+	}
+	if got.Dominant == "" {
+		t.Error("expected a dominant cost")
+	}
+	if slow := fetch("threshold=1h"); slow.Count != 0 {
+		t.Errorf("expected no page over an hour, got %+v", slow.Pages)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/slow-pages?threshold=soon", nil)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad threshold, got %d", rec.Code)
+	}
+}
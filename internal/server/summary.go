@@ -0,0 +1,52 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/renderer"
+)
+
+// pageSummary is the hover preview of a page.
+//
+//nolint:govet // field order matches the JSON documented in the README
+type pageSummary struct {
+	Path        string    `json:"path"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Excerpt     string    `json:"excerpt"`
+	Thumbnail   string    `json:"thumbnail,omitempty"`
+	Modified    time.Time `json:"modified"`
+}
+
+// handlePageSummary serves GET /api/page/{path}/summary, the title, first
+// paragraph, and first image of a page, for link previews. It reads the
+// cached render, so hovering links does not re-render pages.
+func (s *Server) handlePageSummary(w http.ResponseWriter, r *http.Request, path string) {
+	ctx := r.Context()
+	if !validateRequest(w, pathSchema, map[string]string{"path": path}) {
+		return
+	}
+	doc, err := s.content.Document(ctx, path)
+	if err != nil {
+		s.logger.DebugContext(ctx, "load page summary failed", slog.Any("err", err), slog.String("path", path))
+		status, apiErr := contentError(err)
+		respondError(w, status, apiErr.withPath(path))
+		return
+	}
+
+	title := doc.Metadata.Title
+	if title == "" {
+		title = titleFromPath(path)
+	}
+	sum := renderer.Summarize(doc.HTML)
+	respondJSON(w, http.StatusOK, pageSummary{
+		Path:        path,
+		Title:       title,
+		Description: doc.Metadata.Description,
+		Excerpt:     sum.Excerpt,
+		Thumbnail:   sum.Image,
+		Modified:    doc.Modified,
+	})
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPageSummary(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServer(t)
+	t.Cleanup(cleanup)
+
+	page := "# Runbook\n\n{{children}}\n\n![Diagram](diagram.png)\n\nRestart the **worker** when the queue\nbacks up. " + strings.Repeat("More detail follows. ", 30) + "\n\nSecond paragraph.\n"
+	if err := os.WriteFile(filepath.Join(srv.cfg.RootDir, "runbook.md"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/page/runbook.md/summary", nil)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got pageSummary
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Path != "runbook.md" || got.Title != "Runbook" {
+		t.Errorf("unexpected path or title: %+v", got)
+	}
+	if got.Thumbnail != "/media/diagram.png" {
+		t.Errorf("expected the first image as thumbnail, got %q", got.Thumbnail)
+	}
+	if !strings.HasPrefix(got.Excerpt, "Restart the worker when the queue backs up. More detail") || !strings.HasSuffix(got.Excerpt, "…") || len([]rune(got.Excerpt)) > 301 {
+		t.Errorf("expected a shortened first paragraph, got %q", got.Excerpt)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/page/missing.md/summary", nil)
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a missing page, got %d", rec.Code)
+	}
+}
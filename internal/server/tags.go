@@ -0,0 +1,132 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+)
+
+const (
+	defaultTagSuggestions = 10
+	maxTagSuggestions     = 50
+)
+
+// tagSuggestion is one existing tag with the number of pages using it.
+type tagSuggestion struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// handleTagSuggest lists existing frontmatter tags matching q, so editors
+// reuse tags instead of inventing near-duplicates. Prefix matches rank ahead
+// of substring matches, then by usage; an empty q returns the most used tags.
+func (s *Server) handleTagSuggest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+
+	limit := defaultTagSuggestions
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			respondError(w, http.StatusBadRequest, newAPIError(codeValidation, "invalid limit value").withField("limit"))
+			return
+		}
+		limit = min(n, maxTagSuggestions)
+	}
+
+	root, err := s.content.CurrentTree(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "load content tree failed", slog.Any("err", err))
+		respondError(w, http.StatusInternalServerError, newAPIError(codeInternal, "failed to load content tree"))
+		return
+	}
+
+	var matches []tagSuggestion
+	prefix := make(map[string]bool)
+	for _, tag := range tagUsage(root) {
+		key := strings.ToLower(tag.Tag)
+		switch {
+		case strings.HasPrefix(key, query):
+			prefix[tag.Tag] = true
+		case !strings.Contains(key, query):
+			continue
+		}
+		matches = append(matches, tag)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if pi, pj := prefix[matches[i].Tag], prefix[matches[j].Tag]; pi != pj {
+			return pi
+		}
+		if matches[i].Count != matches[j].Count {
+			return matches[i].Count > matches[j].Count
+		}
+		return matches[i].Tag < matches[j].Tag
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	if matches == nil {
+		matches = []tagSuggestion{}
+	}
+
+	resp := struct {
+		Query string          `json:"query"`
+		Tags  []tagSuggestion `json:"tags"`
+		Count int             `json:"count"`
+	}{
+		Query: query,
+		Tags:  matches,
+		Count: len(matches),
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// tagUsage counts pages per tag, ignoring case. Each tag is reported with
+// its most common spelling.
+func tagUsage(root *tree.Node) []tagSuggestion {
+	pages := make(map[string]int)
+	spellings := make(map[string]map[string]int)
+	var walk func(*tree.Node)
+	walk = func(n *tree.Node) {
+		if n == nil {
+			return
+		}
+		if n.Type == tree.NodeTypeFile && n.Metadata != nil {
+			seen := make(map[string]bool)
+			for _, tag := range n.Metadata.Tags {
+				tag = strings.TrimSpace(tag)
+				key := strings.ToLower(tag)
+				if tag == "" || seen[key] {
+					continue
+				}
+				seen[key] = true
+				pages[key]++
+				if spellings[key] == nil {
+					spellings[key] = make(map[string]int)
+				}
+				spellings[key][tag]++
+			}
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	out := make([]tagSuggestion, 0, len(pages))
+	for key, count := range pages {
+		best, bestCount := "", 0
+		for spelling, n := range spellings[key] {
+			if n > bestCount || (n == bestCount && spelling < best) {
+				best, bestCount = spelling, n
+			}
+		}
+		out = append(out, tagSuggestion{Tag: best, Count: count})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Tag < out[j].Tag })
+	return out
+}
@@ -8,8 +8,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/euforicio/wikimd/internal/banner"
+	"github.com/euforicio/wikimd/internal/content"
 	"github.com/euforicio/wikimd/internal/content/tree"
 	"github.com/euforicio/wikimd/internal/renderer"
+	"github.com/euforicio/wikimd/internal/review"
 	"github.com/euforicio/wikimd/internal/search"
 )
 
@@ -20,7 +23,9 @@ type templateRenderer struct {
 	tmpl *template.Template
 }
 
-func newTemplateRenderer() (*templateRenderer, error) {
+// newTemplateRenderer parses the page templates. integrity returns the SRI
+// value for a path under /static; nil leaves asset tags without one.
+func newTemplateRenderer(integrity func(string) string) (*templateRenderer, error) {
 	funcs := template.FuncMap{
 		"dict": func(values ...any) (map[string]any, error) {
 			if len(values)%2 != 0 {
@@ -47,6 +52,20 @@ func newTemplateRenderer() (*templateRenderer, error) {
 		"hasMetadata": func(meta renderer.Metadata) bool {
 			return !meta.IsZero()
 		},
+		"trimPrefix": func(s, prefix string) string {
+			return strings.TrimPrefix(s, prefix)
+		},
+		"isArchived":  content.IsArchived,
+		"formatBytes": formatBytes,
+		"isOverdue": func(meta *renderer.Metadata) bool {
+			return review.IsOverdue(meta, time.Now())
+		},
+		"integrity": func(name string) template.HTMLAttr {
+			if integrity == nil {
+				return ""
+			}
+			return integrityAttr(integrity(name))
+		},
 	}
 
 	base, err := template.New("layout").Funcs(funcs).ParseFS(templateFS, "templates/*.gohtml")
@@ -57,6 +76,29 @@ func newTemplateRenderer() (*templateRenderer, error) {
 	return &templateRenderer{tmpl: base}, nil
 }
 
+// integrityAttr renders an SRI value as an integrity attribute, or nothing
+// when there is no value.
+func integrityAttr(sri string) template.HTMLAttr {
+	if sri == "" {
+		return ""
+	}
+	return template.HTMLAttr(` integrity="` + sri + `"`) //nolint:gosec // base64 digest from static.SRI
+}
+
+// formatBytes renders a size with a binary unit, e.g. "1.5 KB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 func (r *templateRenderer) render(w io.Writer, name string, data any) error {
 	return r.tmpl.ExecuteTemplate(w, name, data)
 }
@@ -67,7 +109,15 @@ type homeViewData struct { //nolint:govet // struct fields grouped for template
 	Page            pageViewData
 	HasDocument     bool
 	CustomCSSURLs   []string // URLs for custom theme CSS files
-	SearchAvailable bool     // Whether ripgrep is available for search
+	Banner          *banner.Banner
+	SearchAvailable bool // Whether ripgrep is available for search
+	Indexing        indexingState
+}
+
+// indexingState describes a background initial tree build for templates.
+type indexingState struct {
+	Active  bool
+	Scanned int
 }
 
 type pageViewData struct {
@@ -78,21 +128,31 @@ type pageViewData struct {
 	Modified    time.Time
 	Breadcrumbs []breadcrumb
 	Missing     bool
+	Generated   bool // Dashboard pages have no markdown source to copy or export
+	Archived    bool
+	ScopedCSS   []string // custom.css URLs of the enclosing directories, outermost first
 }
 
 type treeViewData struct {
-	Root   *tree.Node
-	Active string
+	Root     *tree.Node
+	Active   string
+	Indexing indexingState
 }
 
 type searchViewData struct {
-	Query   string
-	Results []search.Result
-	Options search.Options
-	Count   int
+	Query       string
+	Results     []search.Result
+	Pages       []search.PageResult // set instead of Results when Grouped
+	Suggestions []string            // corrected queries, offered when nothing matched
+	Options     search.Options
+	Count       int
+	Grouped     bool
 }
 
 type breadcrumb struct {
-	Title string
-	Path  string
+	Title     string
+	Path      string
+	Icon      string
+	IconImage string
+	Color     string
 }
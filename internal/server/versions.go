@@ -0,0 +1,111 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/content"
+)
+
+// handlePageVersions serves GET /api/page/{path}/versions: the kept earlier
+// versions of a page, newest first. A wiki in git keeps none and reports
+// enabled: false, since its history lives in git.
+func (s *Server) handlePageVersions(w http.ResponseWriter, r *http.Request, path string) {
+	ctx := r.Context()
+	if !validateRequest(w, pathSchema, map[string]string{"path": path}) {
+		return
+	}
+	versions, err := s.content.PageVersions(path)
+	if err != nil && !errors.Is(err, content.ErrVersionsDisabled) {
+		status, apiErr := contentError(err)
+		s.logger.WarnContext(ctx, "list page versions failed", slog.Any("err", err), slog.String("path", path))
+		respondError(w, status, apiErr.withPath(path))
+		return
+	}
+	if versions == nil {
+		versions = []content.PageVersion{}
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	respondJSON(w, http.StatusOK, struct {
+		Path     string                `json:"path"`
+		Enabled  bool                  `json:"enabled"`
+		Versions []content.PageVersion `json:"versions"`
+		Count    int                   `json:"count"`
+	}{
+		Path:     path,
+		Enabled:  s.content.VersionsEnabled(),
+		Versions: versions,
+		Count:    len(versions),
+	})
+}
+
+// handlePageVersion serves GET /api/page/{path}/versions/{id}: the raw
+// markdown of one kept version.
+func (s *Server) handlePageVersion(w http.ResponseWriter, r *http.Request, path, id string) {
+	ctx := r.Context()
+	if !validateRequest(w, pathSchema, map[string]string{"path": path}) {
+		return
+	}
+	version, data, err := s.content.PageVersionContent(path, id)
+	if err != nil {
+		status, apiErr := contentError(err)
+		s.logger.DebugContext(ctx, "load page version failed", slog.Any("err", err), slog.String("path", path), slog.String("version", id))
+		respondError(w, status, apiErr.withPath(path))
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	respondJSON(w, http.StatusOK, struct {
+		Path    string    `json:"path"`
+		ID      string    `json:"id"`
+		Saved   time.Time `json:"saved"`
+		Content string    `json:"content"`
+	}{
+		Path:    path,
+		ID:      version.ID,
+		Saved:   version.Saved,
+		Content: string(data),
+	})
+}
+
+// handlePageRestore serves POST /api/page/{path}/restore, writing a kept
+// version back as the page. The replaced content is kept as a version, so
+// the restore can itself be undone.
+func (s *Server) handlePageRestore(w http.ResponseWriter, r *http.Request, path string) {
+	ctx := r.Context()
+	var payload struct {
+		Version string `json:"version"`
+	}
+	if err := decodeJSON(r, &payload); err != nil {
+		respondError(w, http.StatusBadRequest, newAPIError(codeInvalidJSON, "invalid JSON payload"))
+		return
+	}
+	if !validateRequest(w, pathSchema, map[string]string{"path": path}) {
+		return
+	}
+	id := strings.TrimSpace(payload.Version)
+	if id == "" {
+		respondError(w, http.StatusUnprocessableEntity, newAPIError(codeValidation, "version is required").withField("version"))
+		return
+	}
+
+	if err := s.content.RestorePageVersion(ctx, path, id); err != nil {
+		status, apiErr := contentError(err)
+		s.logger.WarnContext(ctx, "restore page version failed", slog.Any("err", err), slog.String("path", path), slog.String("version", id))
+		respondError(w, status, apiErr.withPath(path))
+		return
+	}
+
+	resp := struct {
+		Path    string `json:"path"`
+		Version string `json:"version"`
+		Message string `json:"message"`
+	}{
+		Path:    path,
+		Version: id,
+		Message: "restored",
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/euforicio/wikimd/internal/content"
+)
+
+func TestPageVersionsAndRestore(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServerWithOptions(t, nil, content.Options{PageVersions: 5})
+	t.Cleanup(cleanup)
+
+	const page = "guides/getting_started.md"
+	ctx := context.Background()
+	for _, body := range []string{"# First\n", "# Second\n"} {
+		if err := srv.content.SaveDocument(ctx, page, []byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/page/"+page+"/versions", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var list struct {
+		Enabled  bool                  `json:"enabled"`
+		Versions []content.PageVersion `json:"versions"`
+		Count    int                   `json:"count"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode versions: %v", err)
+	}
+	if !list.Enabled || list.Count != 2 || len(list.Versions) != 2 {
+		t.Fatalf("expected two kept versions, got %+v", list)
+	}
+	first := list.Versions[0].ID
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/page/"+page+"/versions/"+first, nil))
+	var version struct {
+		ID      string `json:"id"`
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &version); err != nil {
+		t.Fatalf("decode version: %v", err)
+	}
+	if rec.Code != http.StatusOK || version.ID != first || version.Content != "# First\n" {
+		t.Fatalf("unexpected version %d %+v", rec.Code, version)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/page/"+page+"/versions/20000101T000000.000000000Z", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown version, got %d", rec.Code)
+	}
+
+	restore := func(body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/page/"+page+"/restore", strings.NewReader(body))
+		req.Host = "localhost:8080"
+		req.Header.Set("Origin", "http://localhost:8080")
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := restore(`{}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 without a version, got %d", rec.Code)
+	}
+	if rec := restore(`{"version":"` + first + `"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	data, err := os.ReadFile(filepath.Join(srv.cfg.RootDir, "guides", "getting_started.md"))
+	if err != nil || string(data) != "# First\n" {
+		t.Fatalf("expected the version restored, got %q (%v)", data, err)
+	}
+}
+
+func TestPageVersionsDisabled(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServer(t)
+	t.Cleanup(cleanup)
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/page/index.md/versions", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"enabled":false`) {
+		t.Fatalf("expected versions reported as disabled, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/page/index.md/restore", strings.NewReader(`{"version":"20000101T000000.000000000Z"}`))
+	req.Host = "localhost:8080"
+	req.Header.Set("Origin", "http://localhost:8080")
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 when versions are off, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/content"
+	"github.com/euforicio/wikimd/internal/webhook"
+)
+
+// Webhook event types for page changes.
+const (
+	eventPageUpdated = "page.updated"
+	eventPageDeleted = "page.deleted"
+)
+
+// changeSettle coalesces the bursts of filesystem events a single save
+// produces so each edit is announced once.
+const changeSettle = 2 * time.Second
+
+// forwardChangeEvents relays content change events to the webhook
+// dispatcher until ctx is done.
+func (s *Server) forwardChangeEvents(ctx context.Context, baseURL string) {
+	events := s.content.Subscribe(ctx)
+
+	timer := time.NewTimer(changeSettle)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	defer timer.Stop()
+
+	pending := make(map[string]string) // path -> webhook event type
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case evt, ok := <-events:
+			if !ok {
+				return
+			}
+			if evt.Path == "" || !isMarkdownFile(evt.Path) {
+				continue
+			}
+			switch evt.Type {
+			case content.EventPageUpdated:
+				pending[evt.Path] = eventPageUpdated
+			case content.EventDeleted:
+				pending[evt.Path] = eventPageDeleted
+			default:
+				continue
+			}
+			timer.Reset(changeSettle)
+		case <-timer.C:
+			s.sendChangeEvents(ctx, baseURL, pending)
+			pending = make(map[string]string)
+		}
+	}
+}
+
+// sendChangeEvents announces each pending change with its author and line
+// counts from git, when the wiki is a git checkout, and links to the diff.
+func (s *Server) sendChangeEvents(ctx context.Context, baseURL string, pending map[string]string) {
+	root, err := s.content.CurrentTree(ctx)
+	if err != nil {
+		s.logger.WarnContext(ctx, "load tree for webhooks failed", slog.Any("err", err))
+	}
+	for path, eventType := range pending {
+		page := &webhook.Page{Path: path, Title: titleFromPath(path)}
+		if node := findNode(root, path); node != nil {
+			page.Title = node.Title
+		}
+		verb := "Updated"
+		if eventType == eventPageDeleted {
+			verb = "Deleted"
+		} else {
+			page.URL = pageURL(baseURL, path)
+		}
+		page.Summary = verb + " " + path + "."
+		if change, ok := gitPageChange(ctx, s.cfg.RootDir, path); ok {
+			page.Author = change.Author
+			page.Summary = fmt.Sprintf("%s %s (%s).", verb, path, change)
+			page.DiffURL = siteURL(baseURL, "/api/diff/", path)
+		}
+		// Delivery failures are logged by the dispatcher.
+		_ = s.webhooks.Send(ctx, webhook.Event{Type: eventType, Page: page})
+	}
+}
+
+// pageURL links to a document under baseURL.
+func pageURL(baseURL, path string) string {
+	if baseURL == "" {
+		return ""
+	}
+	return siteURL(baseURL, "/page/", path)
+}
+
+// siteURL joins baseURL, prefix, and the escaped document path.
+func siteURL(baseURL, prefix, path string) string {
+	if baseURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(baseURL, "/") + prefix + (&url.URL{Path: path}).EscapedPath()
+}
+
+func isMarkdownFile(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasSuffix(lower, ".md") || strings.HasSuffix(lower, ".markdown")
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/webhook"
+)
+
+// initGitWiki turns root into a git checkout with one commit by "Ada Author".
+func initGitWiki(t *testing.T, root string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.name", "Ada Author"},
+		{"config", "user.email", "ada@example.com"},
+		{"add", "-A"},
+		{"commit", "-q", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", append([]string{"-C", root}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", args[0], err, out)
+		}
+	}
+}
+
+// webhookReceiver collects the JSON events posted to it.
+func webhookReceiver(t *testing.T) (*httptest.Server, <-chan webhook.Event) {
+	t.Helper()
+	events := make(chan webhook.Event, 16)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var evt webhook.Event
+		if err := json.NewDecoder(r.Body).Decode(&evt); err != nil {
+			t.Errorf("decode webhook body: %v", err)
+		}
+		events <- evt
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver, events
+}
+
+func TestSendChangeEventsNamesAuthorAndLinksDiff(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServer(t)
+	t.Cleanup(cleanup)
+	initGitWiki(t, srv.cfg.RootDir)
+
+	receiver, events := webhookReceiver(t)
+	srv.webhooks = webhook.New(nil, webhook.Options{URLs: []string{receiver.URL}})
+
+	target := filepath.Join(srv.cfg.RootDir, "index.md")
+	raw, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, append(raw, "\nOne more line.\nAnd another.\n"...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.sendChangeEvents(context.Background(), "http://wiki.test", map[string]string{"index.md": eventPageUpdated})
+
+	var evt webhook.Event
+	select {
+	case evt = <-events:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no webhook delivered")
+	}
+	if evt.Type != eventPageUpdated || evt.Page == nil {
+		t.Fatalf("unexpected event %+v", evt)
+	}
+	if evt.Page.Author != "Ada Author" {
+		t.Errorf("expected the git user as author, got %q", evt.Page.Author)
+	}
+	if !strings.Contains(evt.Page.Summary, "+3 -0 lines") {
+		t.Errorf("expected line counts in summary, got %q", evt.Page.Summary)
+	}
+	if evt.Page.URL != "http://wiki.test/page/index.md" || evt.Page.DiffURL != "http://wiki.test/api/diff/index.md" {
+		t.Errorf("unexpected links %q and %q", evt.Page.URL, evt.Page.DiffURL)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, strings.TrimPrefix(evt.Page.DiffURL, "http://wiki.test"), nil)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "+One more line.") {
+		t.Fatalf("expected the diff behind the link, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestForwardChangeEventsAnnouncesSaves(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServer(t)
+	t.Cleanup(cleanup)
+	initGitWiki(t, srv.cfg.RootDir)
+
+	receiver, events := webhookReceiver(t)
+	srv.webhooks = webhook.New(nil, webhook.Options{URLs: []string{receiver.URL}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go srv.forwardChangeEvents(ctx, "http://wiki.test")
+
+	// The forwarder subscribes asynchronously, so keep saving until one of
+	// the edits is announced.
+	deadline := time.After(20 * time.Second)
+	for i := 1; ; i++ {
+		body := "# Home\n\n" + strings.Repeat("edit\n", i)
+		if err := srv.content.SaveDocument(ctx, "index.md", []byte(body)); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+		select {
+		case evt := <-events:
+			if evt.Type != eventPageUpdated || evt.Page == nil || evt.Page.Path != "index.md" {
+				t.Fatalf("unexpected event %+v", evt)
+			}
+			if evt.Page.Author != "Ada Author" || evt.Page.DiffURL == "" {
+				t.Fatalf("expected author and diff link, got %+v", evt.Page)
+			}
+			return
+		case <-time.After(changeSettle + time.Second):
+		case <-deadline:
+			t.Fatal("no webhook delivered for saved page")
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package server
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/euforicio/wikimd/internal/content/tree"
+	"github.com/euforicio/wikimd/internal/exporter"
+	"github.com/euforicio/wikimd/internal/jobs"
+)
+
+// Artifacts the export wizard can produce.
+const (
+	wizardSite       = "html"
+	wizardSingleFile = "single-file"
+	wizardPDF        = "pdf"
+	wizardEPUB       = "epub"
+)
+
+// wizardFormat describes one choice of artifact for GET /api/export/wizard.
+type wizardFormat struct {
+	ID          string `json:"id"`
+	Label       string `json:"label"`
+	Description string `json:"description"`
+	Extension   string `json:"extension"`
+}
+
+var wizardFormats = []wizardFormat{
+	{ID: wizardSite, Label: "Static site", Description: "Every page as HTML with its assets, ready for any static host", Extension: ".zip"},
+	{ID: wizardSingleFile, Label: "Single HTML file", Description: "One self-contained page with everything inlined, to share as an attachment", Extension: ".html"},
+	{ID: wizardPDF, Label: "PDF book", Description: "Every page in one PDF with a cover and contents", Extension: ".pdf"},
+	{ID: wizardEPUB, Label: "EPUB book", Description: "An e-book with one chapter per page", Extension: ".epub"},
+}
+
+// wizardRequest is the body of POST /api/export/wizard. Subtree is a
+// directory to limit the export to, empty for the whole wiki.
+type wizardRequest struct {
+	Format        string `json:"format"`
+	Subtree       string `json:"subtree"`
+	BaseURL       string `json:"baseUrl"`
+	Title         string `json:"title"`
+	IncludeDrafts bool   `json:"includeDrafts"`
+}
+
+// handleExportWizardOptions serves GET /api/export/wizard: what the export
+// wizard offers, with the directories a subtree can be picked from, how many
+// draft pages there are, and the default choices.
+func (s *Server) handleExportWizardOptions(w http.ResponseWriter, r *http.Request) {
+	root, err := s.content.CurrentTree(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, newAPIError(codeInternal, "failed to load content tree"))
+		return
+	}
+	subtrees := []string{}
+	drafts := 0
+	var walk func(*tree.Node)
+	walk = func(n *tree.Node) {
+		if n.Type == tree.NodeTypeFile {
+			if isDraft(n) {
+				drafts++
+			}
+			return
+		}
+		if n.RelativePath != "" && n.Documents > 0 {
+			subtrees = append(subtrees, n.RelativePath)
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	respondJSON(w, http.StatusOK, struct {
+		Formats  []wizardFormat `json:"formats"`
+		Subtrees []string       `json:"subtrees"`
+		Drafts   int            `json:"drafts"`
+		Defaults wizardRequest  `json:"defaults"`
+	}{
+		Formats:  wizardFormats,
+		Subtrees: subtrees,
+		Drafts:   drafts,
+		Defaults: wizardRequest{Format: wizardSite, Title: filepath.Base(s.cfg.RootDir)},
+	})
+}
+
+// handleExportWizard serves POST /api/export/wizard, starting an export job
+// with the chosen options. Its result URL downloads the artifact.
+func (s *Server) handleExportWizard(w http.ResponseWriter, r *http.Request) {
+	var req wizardRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, newAPIError(codeInvalidJSON, "invalid JSON payload"))
+		return
+	}
+	format, ok := findWizardFormat(req.Format)
+	if !ok {
+		respondError(w, http.StatusUnprocessableEntity, newAPIError(codeValidation, "format must be one of html, single-file, pdf, or epub").withField("format"))
+		return
+	}
+	req.BaseURL = strings.TrimRight(strings.TrimSpace(req.BaseURL), "/")
+	if req.BaseURL != "" {
+		if u, err := url.Parse(req.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			respondError(w, http.StatusUnprocessableEntity, newAPIError(codeValidation, "baseUrl must be an absolute http or https URL").withField("baseUrl"))
+			return
+		}
+	}
+	req.Subtree = strings.Trim(path.Clean("/"+strings.TrimSpace(req.Subtree)), "/")
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		req.Title = filepath.Base(s.cfg.RootDir)
+	}
+
+	root, err := s.content.CurrentTree(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, newAPIError(codeInternal, "failed to load content tree"))
+		return
+	}
+	if req.Subtree != "" && !hasDirectory(root, req.Subtree) {
+		respondError(w, http.StatusNotFound, newAPIError(codeNotFound, "directory not found").withField("subtree").withPath(req.Subtree))
+		return
+	}
+	pruned := wizardTree(root, req.Subtree, req.IncludeDrafts)
+	if pruned.Documents == 0 {
+		respondError(w, http.StatusUnprocessableEntity, newAPIError(codeValidation, "the selection holds no pages to export").withField("subtree"))
+		return
+	}
+
+	status := s.jobs.Start(jobExport, func(ctx context.Context, p *jobs.Progress) error {
+		return s.exportTreeJob(ctx, p, pruned, format, req)
+	})
+	w.Header().Set("Location", "/api/jobs/"+status.ID)
+	respondJSON(w, http.StatusAccepted, status)
+}
+
+func findWizardFormat(id string) (wizardFormat, bool) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	for _, f := range wizardFormats {
+		if f.ID == id {
+			return f, true
+		}
+	}
+	return wizardFormat{}, false
+}
+
+// isDraft reports whether a page is marked draft: true in its frontmatter.
+func isDraft(n *tree.Node) bool {
+	if n.Metadata == nil {
+		return false
+	}
+	draft, _ := n.Metadata.Raw["draft"].(bool)
+	return draft
+}
+
+// hasDirectory reports whether the tree below n holds the directory rel.
+func hasDirectory(n *tree.Node, rel string) bool {
+	for _, child := range n.Children {
+		switch {
+		case child.Type == tree.NodeTypeFile:
+		case child.RelativePath == rel:
+			return true
+		case strings.HasPrefix(rel, child.RelativePath+"/"):
+			return hasDirectory(child, rel)
+		}
+	}
+	return false
+}
+
+// wizardTree copies the part of root under the directory subtree (all of it
+// when empty), leaving out draft pages unless drafts is set and directories
+// left without pages. Pages keep their paths, so links between them still
+// resolve. root is not modified.
+func wizardTree(root *tree.Node, subtree string, drafts bool) *tree.Node {
+	var prune func(n *tree.Node) *tree.Node
+	prune = func(n *tree.Node) *tree.Node {
+		if n.Type == tree.NodeTypeFile {
+			inside := subtree == "" || strings.HasPrefix(n.RelativePath, subtree+"/")
+			if !inside || (!drafts && isDraft(n)) {
+				return nil
+			}
+			return n
+		}
+		dir := *n
+		dir.Children = nil
+		dir.Documents, dir.Size = 0, 0
+		for _, child := range n.Children {
+			kept := prune(child)
+			if kept == nil {
+				continue
+			}
+			dir.Children = append(dir.Children, kept)
+			if kept.Type == tree.NodeTypeFile {
+				dir.Documents++
+			} else {
+				dir.Documents += kept.Documents
+			}
+			dir.Size += kept.Size
+		}
+		if len(dir.Children) == 0 && n != root {
+			return nil
+		}
+		return &dir
+	}
+	return prune(root)
+}
+
+// exportTreeJob exports root, the server's tree or part of it, as the
+// artifact format describes and stores it for download.
+func (s *Server) exportTreeJob(ctx context.Context, p *jobs.Progress, root *tree.Node, format wizardFormat, req wizardRequest) error {
+	p.Set(0, 0, "exporting site")
+	out, err := os.MkdirTemp("", "wikimd-site-")
+	if err != nil {
+		return fmt.Errorf("create export directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(out) }()
+
+	opts := exporter.Options{
+		Root:      s.cfg.RootDir,
+		OutputDir: out,
+		SiteTitle: req.Title,
+		BaseURL:   req.BaseURL,
+		Banner:    s.banner,
+		Progress: func(done, total int) {
+			p.Set(done, total, "")
+		},
+	}
+	// The artifact is the file to download, or empty for the zipped site.
+	artifact := ""
+	contentType := "application/zip"
+	switch format.ID {
+	case wizardSingleFile:
+		opts.SingleFile = true
+		artifact, contentType = "index.html", exporter.ContentType(exporter.FormatHTML)
+	case wizardPDF:
+		opts.Books = []exporter.Format{exporter.FormatPDF}
+		artifact, contentType = exporter.BookPDFFile, exporter.ContentType(exporter.FormatPDF)
+	case wizardEPUB:
+		opts.Books = []exporter.Format{exporter.FormatEPUB}
+		artifact, contentType = exporter.BookEPUBFile, exporter.ContentType(exporter.FormatEPUB)
+	}
+	if err := s.exporter.ExportTree(ctx, root, opts); err != nil {
+		return err
+	}
+
+	f, err := s.artifacts.create(p.ID())
+	if err != nil {
+		return err
+	}
+	if artifact == "" {
+		err = zipDirectory(f, out)
+	} else {
+		var src *os.File
+		src, err = os.Open(filepath.Join(out, artifact)) //nolint:gosec // path inside the export directory
+		if err == nil {
+			_, err = f.ReadFrom(src)
+			_ = src.Close()
+		}
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("store site export: %w", err)
+	}
+	s.finishExport(p, exportArtifact{
+		path:        f.Name(),
+		filename:    sanitizeFilename(req.Title) + format.Extension,
+		contentType: contentType,
+	})
+	return nil
+}
+
+// zipDirectory writes every file under dir into a zip archive on w.
+func zipDirectory(w io.Writer, dir string) error {
+	zw := zip.NewWriter(w)
+	err := filepath.WalkDir(dir, func(abs string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, abs)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		header.Method = zip.Deflate
+		dst, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		src, err := os.Open(abs) //nolint:gosec // path inside the export directory
+		if err != nil {
+			return err
+		}
+		defer func() { _ = src.Close() }()
+		_, err = io.Copy(dst, src)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
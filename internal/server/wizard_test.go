@@ -0,0 +1,113 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/euforicio/wikimd/internal/jobs"
+)
+
+func TestExportWizard(t *testing.T) {
+	t.Parallel()
+	srv, cleanup := newTestServer(t)
+	t.Cleanup(cleanup)
+
+	draft := filepath.Join(srv.cfg.RootDir, "guides", "upcoming.md")
+	if err := os.WriteFile(draft, []byte("---\ndraft: true\n---\n# Upcoming\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.content.Reindex(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/export/wizard", nil)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	var options struct {
+		Formats  []wizardFormat `json:"formats"`
+		Subtrees []string       `json:"subtrees"`
+		Drafts   int            `json:"drafts"`
+		Defaults wizardRequest  `json:"defaults"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &options); err != nil {
+		t.Fatalf("decode options: %v\n%s", err, rec.Body.String())
+	}
+	if len(options.Formats) != 4 || !slices.Contains(options.Subtrees, "guides") || options.Drafts != 1 || options.Defaults.Format != wizardSite {
+		t.Fatalf("unexpected wizard options: %+v", options)
+	}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/export/wizard", strings.NewReader(body))
+		req.Host = "localhost:8080"
+		req.Header.Set("Origin", "http://localhost:8080")
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+	for body, want := range map[string]int{
+		`{"format":"docx"}`:                        http.StatusUnprocessableEntity,
+		`{"format":"html","baseUrl":"ftp://host"}`: http.StatusUnprocessableEntity,
+		`{"format":"html","subtree":"missing"}`:    http.StatusNotFound,
+		`{"format":`:                               http.StatusBadRequest,
+	} {
+		if rec := post(body); rec.Code != want {
+			t.Errorf("%s: expected %d, got %d: %s", body, want, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec = post(`{"format":"html","subtree":"guides","title":"Guides"}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var status jobs.Status
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("decode job: %v", err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for status.State == jobs.StateRunning {
+		if time.Now().After(deadline) {
+			t.Fatal("export job did not finish")
+		}
+		time.Sleep(20 * time.Millisecond)
+		rec = httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/jobs/"+status.ID, nil))
+		if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+			t.Fatalf("decode job: %v", err)
+		}
+	}
+	if status.State != jobs.StateSucceeded {
+		t.Fatalf("expected the export to succeed, got %+v", status)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, status.Result, nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Header().Get("Content-Disposition"), "Guides.zip") {
+		t.Fatalf("expected the zipped site, got %d %q", rec.Code, rec.Header().Get("Content-Disposition"))
+	}
+	zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	files := make(map[string]bool)
+	for _, f := range zr.File {
+		files[f.Name] = true
+	}
+	if !files["guides/getting_started.html"] || !files["index.html"] {
+		t.Errorf("expected the subtree's pages and a landing page, got %v", files)
+	}
+	if files["mermaid-test.html"] || files["guides/upcoming.html"] {
+		t.Errorf("expected pages outside the subtree and drafts to be left out, got %v", files)
+	}
+}
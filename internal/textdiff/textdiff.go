@@ -0,0 +1,101 @@
+// Package textdiff computes edit scripts between token sequences, such as
+// the lines of two documents or the words of two paragraphs.
+package textdiff
+
+// MaxCells bounds the comparison table Compare builds for the region left
+// after trimming the common prefix and suffix. Larger regions are reported
+// as a whole deletion followed by a whole insertion.
+const MaxCells = 1 << 22
+
+// Kind says what an Op does.
+type Kind int
+
+// Edit kinds.
+const (
+	Equal Kind = iota
+	Delete
+	Insert
+)
+
+// Op is one step of an edit script. Equal keeps a[A], which matches b[B];
+// Delete removes a[A]; Insert adds b[B].
+type Op struct {
+	Kind Kind
+	A, B int
+}
+
+// Compare returns an edit script turning a into b along their longest
+// common subsequence. Within each changed run, deletions come before
+// insertions.
+func Compare(a, b []string) []Op {
+	// Trim the common prefix and suffix, which is most of a typical edit.
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	var ops []Op
+	for i := range pre {
+		ops = append(ops, Op{Kind: Equal, A: i, B: i})
+	}
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	if len(ma)*len(mb) > MaxCells {
+		for i := range ma {
+			ops = append(ops, Op{Kind: Delete, A: pre + i})
+		}
+		for j := range mb {
+			ops = append(ops, Op{Kind: Insert, B: pre + j})
+		}
+	} else {
+		ops = append(ops, lcs(ma, mb, pre)...)
+	}
+	for k := range suf {
+		ops = append(ops, Op{Kind: Equal, A: len(a) - suf + k, B: len(b) - suf + k})
+	}
+	return ops
+}
+
+// lcs diffs a and b with a dynamic-programming table; indexes in the result
+// are offset by base.
+func lcs(a, b []string, base int) []Op {
+	n, m := len(a), len(b)
+	// table[i*(m+1)+j] is the LCS length of a[i:] and b[j:].
+	table := make([]int32, (n+1)*(m+1))
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i*(m+1)+j] = table[(i+1)*(m+1)+j+1] + 1
+			} else {
+				table[i*(m+1)+j] = max(table[(i+1)*(m+1)+j], table[i*(m+1)+j+1])
+			}
+		}
+	}
+
+	var ops, ins []Op
+	flush := func() {
+		ops = append(ops, ins...)
+		ins = ins[:0]
+	}
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			flush()
+			ops = append(ops, Op{Kind: Equal, A: base + i, B: base + j})
+			i++
+			j++
+		case j < m && (i == n || table[i*(m+1)+j+1] >= table[(i+1)*(m+1)+j]):
+			ins = append(ins, Op{Kind: Insert, B: base + j})
+			j++
+		default:
+			ops = append(ops, Op{Kind: Delete, A: base + i})
+			i++
+		}
+	}
+	flush()
+	return ops
+}
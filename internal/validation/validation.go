@@ -0,0 +1,192 @@
+// Package validation checks API request payloads against declarative,
+// per-endpoint schemas.
+package validation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/euforicio/wikimd/internal/frontmatter"
+)
+
+// Codes identify why a field failed. They match the server's API error codes.
+const (
+	CodeInvalid   = "validation_failed"
+	CodeTraversal = "path_traversal"
+)
+
+// MaxDocumentBytes bounds document content accepted by the API.
+const MaxDocumentBytes = 4 << 20
+
+// FieldError describes one failed rule.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors collects every failed field of a request.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Rule checks one field. It returns nil when the value is acceptable. values
+// holds every field of the request so rules can compare fields.
+type Rule func(field, value string, values map[string]string) *FieldError
+
+// Field lists the rules for one named field, applied in order until one fails.
+type Field struct {
+	Name  string
+	Rules []Rule
+}
+
+// Schema describes a request body or parameter set.
+type Schema []Field
+
+// Validate applies the schema to values and returns Errors, or nil when every
+// field passes.
+func (s Schema) Validate(values map[string]string) error {
+	var errs Errors
+	for _, f := range s {
+		for _, rule := range f.Rules {
+			if fe := rule(f.Name, values[f.Name], values); fe != nil {
+				errs = append(errs, *fe)
+				break
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func invalid(field, format string, args ...any) *FieldError {
+	return &FieldError{Field: field, Code: CodeInvalid, Message: fmt.Sprintf(format, args...)}
+}
+
+// Required rejects empty or whitespace-only values.
+func Required(field, value string, _ map[string]string) *FieldError {
+	if strings.TrimSpace(value) == "" {
+		return invalid(field, "%s is required", field)
+	}
+	return nil
+}
+
+// DocumentPath accepts wiki-relative document paths. Empty values pass so the
+// rule can follow Required or guard optional fields.
+func DocumentPath(field, value string, _ map[string]string) *FieldError {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	if strings.ContainsRune(value, 0) || strings.Contains(value, `\`) {
+		return invalid(field, "%s contains invalid characters", field)
+	}
+	clean := path.Clean(value)
+	if strings.HasPrefix(value, "/") || clean == ".." || strings.HasPrefix(clean, "../") {
+		return &FieldError{Field: field, Code: CodeTraversal, Message: fmt.Sprintf("%s must stay inside the wiki root", field)}
+	}
+	if clean == "." {
+		return invalid(field, "%s must name a document", field)
+	}
+	// Names without a markdown extension get ".md" appended, so a dot alone
+	// (release-1.2, v2.0-plan) is fine; only other file types are refused.
+	if nonMarkdownExts[strings.ToLower(path.Ext(clean))] {
+		return invalid(field, "%s must be a markdown document", field)
+	}
+	return nil
+}
+
+// nonMarkdownExts lists file types that are never documents, even though the
+// content service would accept the name with ".md" appended.
+var nonMarkdownExts = map[string]bool{
+	".avif": true, ".bmp": true, ".css": true, ".csv": true, ".doc": true,
+	".docx": true, ".gif": true, ".gz": true, ".htm": true, ".html": true,
+	".ico": true, ".jpeg": true, ".jpg": true, ".js": true, ".json": true,
+	".mov": true, ".mp3": true, ".mp4": true, ".pdf": true, ".png": true,
+	".svg": true, ".tar": true, ".tgz": true, ".txt": true, ".wav": true,
+	".webm": true, ".webp": true, ".xls": true, ".xlsx": true, ".xml": true,
+	".yaml": true, ".yml": true, ".zip": true,
+}
+
+// WebURL accepts absolute http and https URLs. Empty values pass.
+func WebURL(field, value string, _ map[string]string) *FieldError {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return invalid(field, "%s must be an http or https URL", field)
+	}
+	return nil
+}
+
+// MaxBytes rejects values longer than n bytes.
+func MaxBytes(n int) Rule {
+	return func(field, value string, _ map[string]string) *FieldError {
+		if len(value) > n {
+			return invalid(field, "%s exceeds %d bytes", field, n)
+		}
+		return nil
+	}
+}
+
+// DiffersFrom rejects a value equal to the other field's.
+func DiffersFrom(other string) Rule {
+	return func(field, value string, values map[string]string) *FieldError {
+		if strings.TrimSpace(value) == strings.TrimSpace(values[other]) {
+			return invalid(field, "%s must differ from %s", field, other)
+		}
+		return nil
+	}
+}
+
+// Frontmatter checks that a leading "---" block is closed and holds a YAML
+// mapping, and that TOML ("+++") and JSON frontmatter is closed and parses.
+// Content without frontmatter passes.
+func Frontmatter(field, value string, _ map[string]string) *FieldError {
+	if err := checkFrontmatter(value); err != nil {
+		return invalid(field, "%s frontmatter: %v", field, err)
+	}
+	return nil
+}
+
+func checkFrontmatter(doc string) error {
+	raw := []byte(strings.ReplaceAll(doc, "\r\n", "\n"))
+	if !bytes.HasPrefix(raw, []byte("---\n")) {
+		_, _, _, err := frontmatter.Decode(raw)
+		return err
+	}
+	rest := raw[len("---\n"):]
+	var block []byte
+	for offset := 0; ; {
+		line, next, found := bytes.Cut(rest[offset:], []byte("\n"))
+		if t := bytes.TrimSpace(line); bytes.Equal(t, []byte("---")) || bytes.Equal(t, []byte("...")) {
+			block = rest[:offset]
+			break
+		}
+		if !found {
+			return errors.New("block is not closed")
+		}
+		offset = len(rest) - len(next)
+	}
+	var meta map[string]any
+	if err := yaml.Unmarshal(block, &meta); err != nil {
+		return fmt.Errorf("invalid YAML: %w", err)
+	}
+	return nil
+}
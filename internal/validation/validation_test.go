@@ -0,0 +1,105 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	schema := Schema{
+		{Name: "from", Rules: []Rule{Required, DocumentPath}},
+		{Name: "to", Rules: []Rule{Required, DocumentPath, DiffersFrom("from")}},
+	}
+
+	if err := schema.Validate(map[string]string{"from": "a.md", "to": "b/c.md"}); err != nil {
+		t.Fatalf("expected valid request, got %v", err)
+	}
+
+	err := schema.Validate(map[string]string{"from": "../a.md", "to": ""})
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected Errors, got %T", err)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("expected one error per field, got %+v", errs)
+	}
+	if errs[0].Field != "from" || errs[0].Code != CodeTraversal {
+		t.Fatalf("unexpected first error %+v", errs[0])
+	}
+	if errs[1].Field != "to" || errs[1].Code != CodeInvalid || !strings.Contains(errs[1].Message, "required") {
+		t.Fatalf("unexpected second error %+v", errs[1])
+	}
+
+	err = schema.Validate(map[string]string{"from": "a.md", "to": "a.md"})
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Field != "to" {
+		t.Fatalf("expected DiffersFrom failure on to, got %v", err)
+	}
+}
+
+func TestDocumentPath(t *testing.T) {
+	cases := []struct {
+		path string
+		code string
+	}{
+		{path: "guides/intro.md"},
+		{path: "guides/intro"},
+		{path: "notes.markdown"},
+		{path: "notes/release-1.2"},
+		{path: "v2.0-plan"},
+		{path: ""},
+		{path: "/etc/passwd", code: CodeTraversal},
+		{path: "a/../../b.md", code: CodeTraversal},
+		{path: ".", code: CodeInvalid},
+		{path: `a\b.md`, code: CodeInvalid},
+		{path: "image.png", code: CodeInvalid},
+		{path: "docs/Report.PDF", code: CodeInvalid},
+	}
+	for _, tc := range cases {
+		fe := DocumentPath("path", tc.path, nil)
+		switch {
+		case tc.code == "" && fe != nil:
+			t.Errorf("%q: unexpected error %+v", tc.path, fe)
+		case tc.code != "" && (fe == nil || fe.Code != tc.code):
+			t.Errorf("%q: expected code %q, got %+v", tc.path, tc.code, fe)
+		}
+	}
+}
+
+func TestFrontmatter(t *testing.T) {
+	cases := map[string]bool{
+		"# No frontmatter\n":                    true,
+		"---\ntitle: Intro\n---\n# Body\n":      true,
+		"---\r\ntitle: Intro\r\n---\r\nBody\n":  true,
+		"---\ntitle: Intro\n...\nBody\n":        true,
+		"---\ntitle: Intro\n# never closed\n":   false,
+		"---\ntitle: [unclosed\n---\n":          false,
+		"---\n- a list\n- not a mapping\n---\n": false,
+		"+++\ntitle = 'Intro'\n+++\n":           true,
+		"+++\ntitle = \n+++\n":                  false,
+		";;;\n{\"title\": 1}\n;;;\n":            true,
+		";;;\n{\"title\": \n;;;\n":              false,
+	}
+	for doc, ok := range cases {
+		if fe := Frontmatter("content", doc, nil); (fe == nil) != ok {
+			t.Errorf("%q: expected ok=%v, got %+v", doc, ok, fe)
+		}
+	}
+}
+
+func TestWebURL(t *testing.T) {
+	cases := map[string]bool{
+		"":                           true,
+		"https://example.com/a?b=c":  true,
+		"http://localhost:8080/page": true,
+		"ftp://example.com/file":     false,
+		"file:///etc/passwd":         false,
+		"/relative/path":             false,
+		"https://":                   false,
+	}
+	for raw, ok := range cases {
+		if fe := WebURL("url", raw, nil); (fe == nil) != ok {
+			t.Errorf("%q: expected ok=%v, got %+v", raw, ok, fe)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Package visibility decides which files under the wiki root belong to the
+// wiki. The content tree, the file watcher, file lookups, search, and static
+// exports all ask the same Policy, so a page hidden from one is hidden from
+// all of them.
+package visibility
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// DefaultIgnoreFile is the conventional ignore file at the wiki root.
+const DefaultIgnoreFile = ".wikimdignore"
+
+// DefaultExcludedDirs are the vendor and tooling directories left out at any
+// depth.
+var DefaultExcludedDirs = []string{
+	"node_modules",
+	"vendor",
+	"venv",
+	".venv",
+	"deps",
+	"third_party",
+	".git",
+	".hg",
+	".svn",
+	".idea",
+	".vscode",
+	"__pycache__",
+}
+
+// Options are the visibility settings.
+type Options struct {
+	// ExcludeDirs names directories skipped at any depth, in addition to
+	// DefaultExcludedDirs.
+	ExcludeDirs []string
+	// IgnoreFile is a root-relative file listing further patterns to skip,
+	// usually DefaultIgnoreFile; empty disables it.
+	IgnoreFile string
+	// IncludeHidden keeps files and directories whose names start with ".".
+	IncludeHidden bool
+}
+
+// Policy applies Options to paths. Build one with Load.
+type Policy struct {
+	exclude       map[string]struct{}
+	ignore        []ignorePattern
+	includeHidden bool
+}
+
+// ignorePattern is one line of an ignore file.
+type ignorePattern struct {
+	glob     string
+	anchored bool // matched against the wiki-relative path, not the name
+	dirOnly  bool
+}
+
+// Load builds the policy for the wiki at root, reading its ignore file. Each
+// non-blank line of that file not starting with "#" is a path.Match pattern.
+// A pattern containing "/" is matched against the wiki-relative path, any
+// other against entry names at every depth, and a trailing "/" matches
+// directories only. A missing ignore file ignores nothing.
+func Load(root string, opts Options) (*Policy, error) {
+	p := &Policy{exclude: make(map[string]struct{}), includeHidden: opts.IncludeHidden}
+	for _, name := range slices.Concat(DefaultExcludedDirs, opts.ExcludeDirs) {
+		if name = strings.TrimSpace(name); name != "" {
+			p.exclude[strings.ToLower(name)] = struct{}{}
+		}
+	}
+	if opts.IgnoreFile == "" {
+		return p, nil
+	}
+	raw, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(opts.IgnoreFile)))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return p, nil
+		}
+		return nil, fmt.Errorf("read ignore file: %w", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var ip ignorePattern
+		text, ip.dirOnly = strings.CutSuffix(text, "/")
+		ip.anchored = strings.Contains(text, "/")
+		ip.glob = strings.TrimPrefix(text, "/")
+		if _, err := path.Match(ip.glob, ""); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid pattern %q", opts.IgnoreFile, line, text)
+		}
+		p.ignore = append(p.ignore, ip)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// IncludeHidden reports whether dotfiles are part of the wiki.
+func (p *Policy) IncludeHidden() bool {
+	return p.includeHidden
+}
+
+// SkipDir reports whether the directory at the wiki-relative path rel is
+// left out, judging only its own name and path; see Visible.
+func (p *Policy) SkipDir(rel string) bool {
+	rel = normalize(rel)
+	if rel == "" {
+		return false
+	}
+	name := path.Base(rel)
+	if p.hidden(name) {
+		return true
+	}
+	if _, ok := p.exclude[strings.ToLower(name)]; ok {
+		return true
+	}
+	return p.ignored(rel, true)
+}
+
+// SkipFile reports whether the file at the wiki-relative path rel is left
+// out, judging only its own name and path; see Visible.
+func (p *Policy) SkipFile(rel string) bool {
+	rel = normalize(rel)
+	return p.hidden(path.Base(rel)) || p.ignored(rel, false)
+}
+
+// Visible reports whether the file at the wiki-relative path rel is part of
+// the wiki: neither it nor any directory above it is left out.
+func (p *Policy) Visible(rel string) bool {
+	rel = normalize(rel)
+	if rel == "" {
+		return false
+	}
+	for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+		if p.SkipDir(dir) {
+			return false
+		}
+	}
+	return !p.SkipFile(rel)
+}
+
+// ExcludedDirs lists, lower-cased and sorted, the directory names left out
+// at any depth, for tools such as ripgrep that can prune them while walking.
+func (p *Policy) ExcludedDirs() []string {
+	return slices.Sorted(maps.Keys(p.exclude))
+}
+
+func (p *Policy) hidden(name string) bool {
+	return !p.IncludeHidden() && strings.HasPrefix(name, ".")
+}
+
+func (p *Policy) ignored(rel string, isDir bool) bool {
+	name := path.Base(rel)
+	for _, ip := range p.ignore {
+		if ip.dirOnly && !isDir {
+			continue
+		}
+		target := name
+		if ip.anchored {
+			target = rel
+		}
+		if ok, _ := path.Match(ip.glob, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize turns rel into a clean slash-separated path without leading
+// "./" or "/".
+func normalize(rel string) string {
+	rel = path.Clean(filepath.ToSlash(rel))
+	rel = strings.TrimPrefix(rel, "/")
+	if rel == "." {
+		return ""
+	}
+	return rel
+}
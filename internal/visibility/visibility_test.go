@@ -0,0 +1,66 @@
+package visibility
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	ignore := "# drafts stay local\n/drafts/\n*.draft.md\narchive/2020\n"
+	if err := os.WriteFile(filepath.Join(root, DefaultIgnoreFile), []byte(ignore), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := Load(root, Options{ExcludeDirs: []string{"Build"}, IgnoreFile: DefaultIgnoreFile})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	for rel, want := range map[string]bool{
+		"index.md":               true,
+		"guides/setup.md":        true,
+		"guides/drafts/wip.md":   true,
+		"drafts/idea.md":         false,
+		"guides/setup.draft.md":  false,
+		"archive/2020/old.md":    false,
+		"archive/2021/new.md":    true,
+		"notes/build/gen.md":     false,
+		"docs/node_modules/x.md": false,
+		".github/readme.md":      false,
+		"guides/.hidden.md":      false,
+		"./guides/setup.md":      true,
+		"":                       false,
+	} {
+		if got := policy.Visible(rel); got != want {
+			t.Errorf("Visible(%q) = %t, want %t", rel, got, want)
+		}
+	}
+	if !slices.Contains(policy.ExcludedDirs(), "build") || !slices.Contains(policy.ExcludedDirs(), "node_modules") {
+		t.Errorf("expected configured and default excluded dirs, got %v", policy.ExcludedDirs())
+	}
+
+	hidden, err := Load(root, Options{IncludeHidden: true})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !hidden.IncludeHidden() || !hidden.Visible(".github/readme.md") || hidden.Visible(".git/notes.md") {
+		t.Error("expected dotfiles visible except in excluded directories")
+	}
+}
+
+func TestLoadRejectsInvalidPatterns(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, DefaultIgnoreFile), []byte("[\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(root, Options{IgnoreFile: DefaultIgnoreFile}); err == nil {
+		t.Fatal("expected an invalid pattern to be reported")
+	}
+	if _, err := Load(t.TempDir(), Options{IgnoreFile: DefaultIgnoreFile}); err != nil {
+		t.Fatalf("expected a missing ignore file to ignore nothing, got %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigFile lists per-channel webhook targets, relative to the wiki root.
+const ConfigFile = ".wikimd/webhooks.yaml"
+
+// Payload formats a target can receive.
+const (
+	FormatJSON  = "json"
+	FormatSlack = "slack"
+	FormatTeams = "teams"
+)
+
+// Target is one endpoint with its payload format and filters.
+type Target struct {
+	URL    string `yaml:"url"`
+	Format string `yaml:"format"`
+	// Paths limits page events to matching documents. Patterns use path.Match
+	// syntax, and a trailing "/**" matches everything below a directory.
+	Paths []string `yaml:"paths"`
+	// Events limits delivery to these event types; empty means all.
+	Events []string `yaml:"events"`
+}
+
+// LoadTargets reads ConfigFile under root. A missing file yields no targets.
+func LoadTargets(root string) ([]Target, error) {
+	raw, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(ConfigFile)))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read %s: %w", ConfigFile, err)
+	}
+	var file struct {
+		Webhooks []Target `yaml:"webhooks"`
+	}
+	if err := yaml.UnmarshalStrict(raw, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", ConfigFile, err)
+	}
+	for i := range file.Webhooks {
+		t := &file.Webhooks[i]
+		t.Format = strings.ToLower(strings.TrimSpace(t.Format))
+		if t.Format == "" {
+			t.Format = FormatJSON
+		}
+		if t.URL == "" {
+			return nil, fmt.Errorf("%s: webhook %d has no url", ConfigFile, i+1)
+		}
+		switch t.Format {
+		case FormatJSON, FormatSlack, FormatTeams:
+		default:
+			return nil, fmt.Errorf("%s: unknown format %q (want json, slack, or teams)", ConfigFile, t.Format)
+		}
+	}
+	return file.Webhooks, nil
+}
+
+// Matches reports whether evt passes the target's event and path filters.
+func (t Target) Matches(evt Event) bool {
+	if len(t.Events) > 0 && !containsFold(t.Events, evt.Type) {
+		return false
+	}
+	if len(t.Paths) == 0 || evt.Page == nil {
+		return true
+	}
+	for _, pattern := range t.Paths {
+		if matchPath(pattern, evt.Page.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Body encodes evt in the target's format.
+func (t Target) Body(evt Event) ([]byte, error) {
+	switch t.Format {
+	case FormatSlack:
+		return json.Marshal(slackPayload(evt))
+	case FormatTeams:
+		return json.Marshal(teamsPayload(evt))
+	default:
+		return json.Marshal(evt)
+	}
+}
+
+func matchPath(pattern, rel string) bool {
+	pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "/")
+	if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return rel == dir || strings.HasPrefix(rel, dir+"/")
+	}
+	ok, err := path.Match(pattern, rel)
+	return err == nil && ok
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// headline is the one-line description shared by the chat formats.
+func headline(evt Event) string {
+	action := strings.ReplaceAll(evt.Type, ".", " ")
+	if evt.Page == nil {
+		return action
+	}
+	title := evt.Page.Title
+	if title == "" {
+		title = evt.Page.Path
+	}
+	var b strings.Builder
+	b.WriteString(title)
+	b.WriteString(" — ")
+	b.WriteString(action)
+	if evt.Page.Author != "" {
+		b.WriteString(" by ")
+		b.WriteString(evt.Page.Author)
+	}
+	return b.String()
+}
+
+// slackPayload builds an incoming-webhook message with a text fallback and a
+// section block linking to the page.
+func slackPayload(evt Event) map[string]any {
+	text := headline(evt)
+	section := text
+	if evt.Page != nil {
+		if evt.Page.URL != "" {
+			section = fmt.Sprintf("<%s|%s>", evt.Page.URL, slackEscape(text))
+		}
+		if evt.Page.Summary != "" {
+			section += "\n" + slackEscape(evt.Page.Summary)
+		}
+		if evt.Page.DiffURL != "" {
+			section += fmt.Sprintf(" <%s|View changes>", evt.Page.DiffURL)
+		}
+	}
+	return map[string]any{
+		"text": text,
+		"blocks": []map[string]any{{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": section},
+		}},
+	}
+}
+
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// teamsPayload builds a legacy MessageCard, which Teams incoming webhooks and
+// workflow connectors both accept.
+func teamsPayload(evt Event) map[string]any {
+	card := map[string]any{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    headline(evt),
+		"title":      headline(evt),
+		"themeColor": "0EA5E9",
+	}
+	if evt.Page == nil {
+		return card
+	}
+	if evt.Page.Summary != "" {
+		card["text"] = evt.Page.Summary
+	}
+	var actions []map[string]any
+	if evt.Page.URL != "" {
+		actions = append(actions, openURI("View page", evt.Page.URL))
+	}
+	if evt.Page.DiffURL != "" {
+		actions = append(actions, openURI("View changes", evt.Page.DiffURL))
+	}
+	if len(actions) > 0 {
+		card["potentialAction"] = actions
+	}
+	return card
+}
+
+func openURI(name, uri string) map[string]any {
+	return map[string]any{
+		"@type":   "OpenUri",
+		"name":    name,
+		"targets": []map[string]string{{"os": "default", "uri": uri}},
+	}
+}
@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadTargetsAndFilters(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if targets, err := LoadTargets(root); err != nil || targets != nil {
+		t.Fatalf("expected no targets without a config file, got %v, %v", targets, err)
+	}
+
+	cfg := "webhooks:\n" +
+		"  - url: https://hooks.slack.com/services/x\n" +
+		"    format: Slack\n" +
+		"    paths: [\"ops/**\", \"*.md\"]\n" +
+		"    events: [page.updated]\n" +
+		"  - url: https://example.com/hook\n"
+	if err := os.MkdirAll(filepath.Join(root, ".wikimd"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".wikimd", "webhooks.yaml"), []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	targets, err := LoadTargets(root)
+	if err != nil {
+		t.Fatalf("LoadTargets: %v", err)
+	}
+	if len(targets) != 2 || targets[0].Format != FormatSlack || targets[1].Format != FormatJSON {
+		t.Fatalf("unexpected targets: %+v", targets)
+	}
+
+	slack := targets[0]
+	cases := []struct {
+		evt  Event
+		want bool
+	}{
+		{Event{Type: "page.updated", Page: &Page{Path: "ops/deploy/runbook.md"}}, true},
+		{Event{Type: "page.updated", Page: &Page{Path: "index.md"}}, true},
+		{Event{Type: "page.updated", Page: &Page{Path: "guides/intro.md"}}, false},
+		{Event{Type: "page.deleted", Page: &Page{Path: "ops/runbook.md"}}, false},
+	}
+	for _, tc := range cases {
+		if got := slack.Matches(tc.evt); got != tc.want {
+			t.Errorf("Matches(%s %s) = %v, want %v", tc.evt.Type, tc.evt.Page.Path, got, tc.want)
+		}
+	}
+}
+
+func TestChatFormats(t *testing.T) {
+	t.Parallel()
+
+	evt := Event{Type: "page.updated", Page: &Page{
+		Path:    "ops/runbook.md",
+		Title:   "Runbook <v2>",
+		URL:     "https://wiki.example.com/page/ops/runbook.md",
+		Author:  "sam",
+		Summary: "Updated ops/runbook.md.",
+		DiffURL: "https://wiki.example.com/api/diff/ops/runbook.md",
+	}}
+
+	raw, err := Target{Format: FormatSlack}.Body(evt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var slack struct {
+		Text   string `json:"text"`
+		Blocks []struct {
+			Text struct {
+				Text string `json:"text"`
+			} `json:"text"`
+		} `json:"blocks"`
+	}
+	if err := json.Unmarshal(raw, &slack); err != nil {
+		t.Fatal(err)
+	}
+	if slack.Text != "Runbook <v2> — page updated by sam" {
+		t.Errorf("slack text = %q", slack.Text)
+	}
+	if len(slack.Blocks) != 1 || !strings.HasPrefix(slack.Blocks[0].Text.Text, "<https://wiki.example.com/page/ops/runbook.md|Runbook &lt;v2&gt;") {
+		t.Errorf("unexpected slack blocks: %s", raw)
+	}
+	if !strings.Contains(slack.Blocks[0].Text.Text, "<https://wiki.example.com/api/diff/ops/runbook.md|View changes>") {
+		t.Errorf("expected a diff link in slack blocks: %s", raw)
+	}
+
+	raw, err = Target{Format: FormatTeams}.Body(evt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var teams map[string]any
+	if err := json.Unmarshal(raw, &teams); err != nil {
+		t.Fatal(err)
+	}
+	if teams["@type"] != "MessageCard" || teams["text"] != "Updated ops/runbook.md." {
+		t.Errorf("unexpected teams card: %s", raw)
+	}
+	if actions, _ := teams["potentialAction"].([]any); len(actions) != 2 {
+		t.Errorf("expected page and diff actions: %s", raw)
+	}
+}
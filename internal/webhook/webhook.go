@@ -0,0 +1,152 @@
+// Package webhook delivers wiki events to external HTTP endpoints.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the request body when a
+// secret is configured, prefixed with "sha256=".
+const SignatureHeader = "X-Wikimd-Signature"
+
+// EventHeader carries the event type so receivers can route without parsing
+// the body.
+const EventHeader = "X-Wikimd-Event"
+
+// Event is the JSON body posted to endpoints using FormatJSON.
+type Event struct {
+	Time time.Time `json:"time"`
+	Data any       `json:"data,omitempty"`
+	// Page identifies the document the event is about. Path filters and the
+	// chat formatters use it; events without a page reach every target.
+	Page *Page  `json:"page,omitempty"`
+	Type string `json:"type"`
+}
+
+// Page describes the document an event concerns.
+type Page struct {
+	Path    string `json:"path"`
+	Title   string `json:"title,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Author  string `json:"author,omitempty"`
+	Summary string `json:"summary,omitempty"`
+	// DiffURL links to the change the event describes.
+	DiffURL string `json:"diffUrl,omitempty"`
+}
+
+// Options configures a Dispatcher.
+type Options struct {
+	// Client sends the requests; a client with Timeout is used when nil.
+	Client *http.Client
+	// Secret, when set, signs each body (see SignatureHeader).
+	Secret string
+	// URLs receive every event as FormatJSON.
+	URLs []string
+	// Targets add endpoints with their own format and path filters.
+	Targets []Target
+	Timeout time.Duration
+}
+
+// Dispatcher posts events to a fixed set of endpoints.
+type Dispatcher struct {
+	client  *http.Client
+	logger  *slog.Logger
+	secret  []byte
+	targets []Target
+}
+
+// New returns a Dispatcher for the configured endpoints, or nil when there
+// are none so callers can treat "no webhooks configured" as a nil check.
+func New(logger *slog.Logger, opts Options) *Dispatcher {
+	targets := make([]Target, 0, len(opts.URLs)+len(opts.Targets))
+	for _, url := range opts.URLs {
+		targets = append(targets, Target{URL: url, Format: FormatJSON})
+	}
+	targets = append(targets, opts.Targets...)
+	if len(targets) == 0 {
+		return nil
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: opts.Timeout}
+	}
+	return &Dispatcher{
+		client:  client,
+		logger:  logger.With("component", "webhook"),
+		secret:  []byte(opts.Secret),
+		targets: targets,
+	}
+}
+
+// Send posts evt to every matching endpoint and returns the joined delivery
+// errors. A non-2xx response counts as a failed delivery.
+func (d *Dispatcher) Send(ctx context.Context, evt Event) error {
+	if evt.Time.IsZero() {
+		evt.Time = time.Now().UTC()
+	}
+
+	var errs []error
+	for _, target := range d.targets {
+		if !target.Matches(evt) {
+			continue
+		}
+		body, err := target.Body(evt)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("format %s event for %s: %w", target.Format, target.URL, err))
+			continue
+		}
+		if err := d.post(ctx, target.URL, evt.Type, body); err != nil {
+			d.logger.WarnContext(ctx, "webhook delivery failed", slog.String("url", target.URL), slog.String("event", evt.Type), slog.Any("err", err))
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (d *Dispatcher) post(ctx context.Context, url, eventType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request for %s: %w", url, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "wikimd-webhook")
+	req.Header.Set(EventHeader, eventType)
+	if len(d.secret) > 0 {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(d.secret, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post %s: %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("post %s: unexpected status %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body under secret.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
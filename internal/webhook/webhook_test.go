@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSendSignsAndPostsEvents(t *testing.T) {
+	t.Parallel()
+
+	var gotSig, gotEvent string
+	var gotBody Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		gotSig = r.Header.Get(SignatureHeader)
+		gotEvent = r.Header.Get(EventHeader)
+		if err := json.Unmarshal(raw, &gotBody); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if want := "sha256=" + Sign([]byte("s3cret"), raw); gotSig != want {
+			t.Errorf("signature = %q, want %q", gotSig, want)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	d := New(slog.New(slog.NewTextHandler(io.Discard, nil)), Options{URLs: []string{srv.URL}, Secret: "s3cret"})
+	if err := d.Send(context.Background(), Event{Type: "review.overdue", Data: map[string]string{"path": "a.md"}}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if gotEvent != "review.overdue" || gotBody.Type != "review.overdue" || gotBody.Time.IsZero() {
+		t.Fatalf("unexpected delivery: event=%q body=%+v", gotEvent, gotBody)
+	}
+}
+
+func TestSendReportsFailedDeliveries(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(srv.Close)
+
+	d := New(slog.New(slog.NewTextHandler(io.Discard, nil)), Options{URLs: []string{srv.URL}})
+	if err := d.Send(context.Background(), Event{Type: "test"}); err == nil {
+		t.Fatal("expected an error for a 502 response")
+	}
+	if New(nil, Options{}) != nil {
+		t.Fatal("expected nil dispatcher without URLs")
+	}
+}
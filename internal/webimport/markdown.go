@@ -0,0 +1,334 @@
+package webimport
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// converter renders an HTML tree as GitHub-flavoured markdown.
+type converter struct {
+	base *url.URL
+	// image maps an absolute image URL to the destination written in the
+	// markdown.
+	image func(src string) string
+}
+
+var (
+	spaceRun   = regexp.MustCompile(`\s+`)
+	blankLines = regexp.MustCompile(`\n{3,}`)
+	textEscape = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`)
+)
+
+// markdown converts the element n and its descendants.
+func (c *converter) markdown(n *html.Node) string {
+	out := blankLines.ReplaceAllString(c.blocks(n), "\n\n")
+	return strings.TrimSpace(out) + "\n"
+}
+
+// blocks renders the children of n as a sequence of blocks. Runs of inline
+// content between block elements become paragraphs.
+func (c *converter) blocks(n *html.Node) string {
+	var parts []string
+	var para strings.Builder
+	flush := func() {
+		if p := strings.TrimSpace(para.String()); p != "" {
+			parts = append(parts, p)
+		}
+		para.Reset()
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && isBlock(child.DataAtom) {
+			flush()
+			if b := strings.TrimSpace(c.block(child)); b != "" {
+				parts = append(parts, b)
+			}
+			continue
+		}
+		para.WriteString(c.inline(child))
+	}
+	flush()
+	return strings.Join(parts, "\n\n")
+}
+
+func (c *converter) block(n *html.Node) string {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		text := strings.TrimSpace(c.inlineChildren(n))
+		if text == "" {
+			return ""
+		}
+		return strings.Repeat("#", level) + " " + text
+	case atom.P:
+		return c.inlineChildren(n)
+	case atom.Ul, atom.Ol:
+		return c.list(n)
+	case atom.Pre:
+		return c.code(n)
+	case atom.Blockquote:
+		inner := strings.TrimSpace(c.blocks(n))
+		if inner == "" {
+			return ""
+		}
+		lines := strings.Split(inner, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return strings.Join(lines, "\n")
+	case atom.Table:
+		return c.table(n)
+	case atom.Hr:
+		return "---"
+	case atom.Dt:
+		if text := strings.TrimSpace(c.inlineChildren(n)); text != "" {
+			return "**" + text + "**"
+		}
+		return ""
+	case atom.Figcaption:
+		if text := strings.TrimSpace(c.inlineChildren(n)); text != "" {
+			return "*" + text + "*"
+		}
+		return ""
+	default:
+		return c.blocks(n)
+	}
+}
+
+func (c *converter) list(n *html.Node) string {
+	var items []string
+	index := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+		index = start
+	}
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(index) + ". "
+			index++
+		}
+		body := strings.TrimSpace(c.blocks(li))
+		indent := strings.Repeat(" ", len(marker))
+		lines := strings.Split(body, "\n")
+		for i := 1; i < len(lines); i++ {
+			if lines[i] != "" {
+				lines[i] = indent + lines[i]
+			}
+		}
+		items = append(items, marker+strings.Join(lines, "\n"))
+	}
+	return strings.Join(items, "\n")
+}
+
+func (c *converter) code(n *html.Node) string {
+	lang := ""
+	for _, el := range []*html.Node{n, n.FirstChild} {
+		if el == nil || el.Type != html.ElementNode {
+			continue
+		}
+		for _, class := range strings.Fields(attr(el, "class")) {
+			if l, ok := strings.CutPrefix(class, "language-"); ok {
+				lang = l
+			} else if l, ok := strings.CutPrefix(class, "lang-"); ok {
+				lang = l
+			}
+		}
+	}
+	body := strings.Trim(textContent(n), "\n")
+	fence := "```"
+	if strings.Contains(body, "```") {
+		fence = "~~~"
+	}
+	return fence + lang + "\n" + body + "\n" + fence
+}
+
+func (c *converter) table(n *html.Node) string {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(el *html.Node) {
+		for child := el.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			if child.DataAtom != atom.Tr {
+				walk(child)
+				continue
+			}
+			var row []string
+			for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+					text := strings.TrimSpace(c.inlineChildren(cell))
+					row = append(row, strings.ReplaceAll(text, "|", `\|`))
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return ""
+	}
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	var b strings.Builder
+	for i, row := range rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (c *converter) inlineChildren(n *html.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(c.inline(child))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func (c *converter) inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return textEscape.Replace(spaceRun.ReplaceAllString(n.Data, " "))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.Strong, atom.B:
+		return wrap("**", c.inlineChildren(n), n)
+	case atom.Em, atom.I:
+		return wrap("*", c.inlineChildren(n), n)
+	case atom.Del, atom.S, atom.Strike:
+		return wrap("~~", c.inlineChildren(n), n)
+	case atom.Code, atom.Kbd, atom.Samp:
+		text := spaceRun.ReplaceAllString(textContent(n), " ")
+		if text == "" {
+			return ""
+		}
+		if strings.Contains(text, "`") {
+			return "`` " + text + " ``"
+		}
+		return "`" + text + "`"
+	case atom.Br:
+		return "\\\n"
+	case atom.A:
+		text := c.inlineChildren(n)
+		href := c.resolve(attr(n, "href"))
+		if text == "" || href == "" {
+			return text
+		}
+		return "[" + text + "](" + href + ")"
+	case atom.Img:
+		src := imageSource(n)
+		if src == "" {
+			return ""
+		}
+		abs := c.resolve(src)
+		if abs == "" {
+			return ""
+		}
+		return "![" + textEscape.Replace(attr(n, "alt")) + "](" + c.image(abs) + ")"
+	default:
+		return c.inlineChildren(n) + trailingSpace(n)
+	}
+}
+
+// resolve makes href absolute against the page URL. Script and other
+// non-web links are dropped.
+func (c *converter) resolve(href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" {
+		return ""
+	}
+	if strings.HasPrefix(href, "#") {
+		return ""
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if c.base != nil {
+		u = c.base.ResolveReference(u)
+	}
+	switch u.Scheme {
+	case "http", "https", "mailto":
+		return strings.NewReplacer("(", "%28", ")", "%29", " ", "%20").Replace(u.String())
+	}
+	return ""
+}
+
+// wrap surrounds inner with an emphasis marker, keeping the marker against
+// the text so markdown recognises it.
+func wrap(marker, inner string, n *html.Node) string {
+	if inner == "" {
+		return ""
+	}
+	return marker + inner + marker + trailingSpace(n)
+}
+
+// trailingSpace keeps the word break after an inline element whose text
+// ended in whitespace, which inlineChildren trims.
+func trailingSpace(n *html.Node) string {
+	if last := n.LastChild; last != nil && last.Type == html.TextNode && strings.TrimRight(last.Data, " \t\n") != last.Data {
+		return " "
+	}
+	return ""
+}
+
+// imageSource prefers lazy-loading attributes, which hold the real image
+// when src is a placeholder.
+func imageSource(n *html.Node) string {
+	for _, key := range []string{"data-src", "data-original", "src"} {
+		if v := strings.TrimSpace(attr(n, key)); v != "" && !strings.HasPrefix(v, "data:") {
+			return v
+		}
+	}
+	return ""
+}
+
+func isBlock(a atom.Atom) bool {
+	switch a {
+	case atom.Address, atom.Article, atom.Aside, atom.Blockquote, atom.Dd, atom.Details,
+		atom.Div, atom.Dl, atom.Dt, atom.Figcaption, atom.Figure, atom.Footer, atom.H1,
+		atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Header, atom.Hr, atom.Li,
+		atom.Main, atom.Ol, atom.P, atom.Pre, atom.Section, atom.Summary, atom.Table, atom.Ul:
+		return true
+	}
+	return false
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(textContent(child))
+	}
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,378 @@
+// Package webimport clips web pages into the wiki. It fetches a page, picks
+// out the main content the way reader modes do, converts it to markdown, and
+// downloads the images it references.
+package webimport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/euforicio/wikimd/internal/buildinfo"
+)
+
+// Limits applied to every import.
+const (
+	MaxPageBytes  = 5 << 20
+	MaxImageBytes = 10 << 20
+	MaxImages     = 50
+)
+
+// ErrNotHTML reports that the URL did not serve an HTML page.
+var ErrNotHTML = errors.New("not an HTML page")
+
+// Options controls how pages are fetched.
+type Options struct {
+	// Client performs requests. Defaults to a client with Timeout.
+	Client *http.Client
+	// UserAgent identifies the importer to remote hosts.
+	UserAgent string
+	// Timeout bounds each request when Client is nil.
+	Timeout time.Duration
+	// SkipImages leaves images at their remote URLs instead of downloading
+	// them.
+	SkipImages bool
+}
+
+// Image is a downloaded image. Name is a file name unique within the page.
+type Image struct {
+	Name   string
+	Source string
+	Data   []byte
+}
+
+// Page is a clipped web page.
+type Page struct {
+	Title  string
+	Source string // final URL after redirects
+	Images []Image
+
+	content *html.Node
+	base    *url.URL
+	local   map[string]string // image URL to Image.Name
+}
+
+// Markdown converts the page's main content. Downloaded images are linked as
+// imageDir/name, so imageDir is where the caller saves Images relative to
+// the new document.
+func (p *Page) Markdown(imageDir string) string {
+	conv := &converter{base: p.base, image: func(src string) string {
+		if name, ok := p.local[src]; ok {
+			return imageDir + "/" + name
+		}
+		return src
+	}}
+	return conv.markdown(p.content)
+}
+
+// Importer fetches and converts pages.
+type Importer struct {
+	opts Options
+}
+
+// New returns an Importer with defaults filled in.
+func New(opts Options) *Importer {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: opts.Timeout}
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = "wikimd-import/" + buildinfo.Version
+	}
+	return &Importer{opts: opts}
+}
+
+// Import fetches rawURL and converts its main content to markdown. Images
+// that fail to download keep their remote URLs.
+func (im *Importer) Import(ctx context.Context, rawURL string) (*Page, error) {
+	body, final, err := im.fetch(ctx, rawURL, MaxPageBytes, "text/html", "application/xhtml+xml")
+	if err != nil {
+		return nil, err
+	}
+	root, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("parse page: %w", err)
+	}
+
+	page := &Page{
+		Title:   title(root),
+		Source:  final.String(),
+		content: mainContent(root),
+		base:    final,
+		local:   make(map[string]string),
+	}
+	if im.opts.SkipImages {
+		return page, nil
+	}
+	names := make(map[string]bool)
+	for _, src := range imageSources(page.content, &converter{base: final}) {
+		if len(page.Images) == MaxImages {
+			break
+		}
+		img, err := im.image(ctx, src, names)
+		if err != nil {
+			continue
+		}
+		page.local[src] = img.Name
+		page.Images = append(page.Images, img)
+	}
+	return page, nil
+}
+
+// fetch GETs rawURL and returns at most limit bytes of a response whose media
+// type is one of accept.
+func (im *Importer) fetch(ctx context.Context, rawURL string, limit int64, accept ...string) ([]byte, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", im.opts.UserAgent)
+	resp, err := im.opts.Client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, nil, fmt.Errorf("fetch %s: %s", rawURL, resp.Status)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !acceptable(mediaType, accept) {
+		if accept[0] == "text/html" {
+			return nil, nil, fmt.Errorf("%w: %s serves %q", ErrNotHTML, rawURL, mediaType)
+		}
+		return nil, nil, fmt.Errorf("fetch %s: unexpected content type %q", rawURL, mediaType)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("read %s: %w", rawURL, err)
+	}
+	if int64(len(body)) > limit {
+		return nil, nil, fmt.Errorf("fetch %s: response exceeds %d bytes", rawURL, limit)
+	}
+	return body, resp.Request.URL, nil
+}
+
+func acceptable(mediaType string, accept []string) bool {
+	for _, a := range accept {
+		if a == mediaType || (strings.HasSuffix(a, "/") && strings.HasPrefix(mediaType, a)) {
+			return true
+		}
+	}
+	return false
+}
+
+// image downloads src and names it uniquely among names.
+func (im *Importer) image(ctx context.Context, src string, names map[string]bool) (Image, error) {
+	data, final, err := im.fetch(ctx, src, MaxImageBytes, "image/")
+	if err != nil {
+		return Image{}, err
+	}
+	name := imageName(final, http.DetectContentType(data))
+	base, ext := strings.TrimSuffix(name, path.Ext(name)), path.Ext(name)
+	for i := 1; names[name]; i++ {
+		name = base + "-" + strconv.Itoa(i) + ext
+	}
+	names[name] = true
+	return Image{Name: name, Source: src, Data: data}, nil
+}
+
+var unsafeName = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// imageName derives a file name from the image URL, adding an extension from
+// the sniffed content type when the URL lacks one.
+func imageName(u *url.URL, contentType string) string {
+	name := unsafeName.ReplaceAllString(path.Base(u.Path), "-")
+	name = strings.Trim(name, ".-")
+	if name == "" || name == "/" {
+		name = "image"
+	}
+	if path.Ext(name) == "" {
+		if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+			name += exts[len(exts)-1]
+		}
+	}
+	return name
+}
+
+// imageSources lists the absolute image URLs in n, in document order and
+// without duplicates.
+func imageSources(n *html.Node, conv *converter) []string {
+	var out []string
+	seen := make(map[string]bool)
+	var walk func(*html.Node)
+	walk = func(el *html.Node) {
+		if el.Type == html.ElementNode && el.DataAtom == atom.Img {
+			if src := conv.resolve(imageSource(el)); strings.HasPrefix(src, "http") && !seen[src] {
+				seen[src] = true
+				out = append(out, src)
+			}
+		}
+		for child := el.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return out
+}
+
+// title prefers the Open Graph title, which sites set without the " | Site
+// Name" suffix, then <title>, then the first <h1>.
+func title(root *html.Node) string {
+	var ogTitle, docTitle, h1 string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Meta:
+				if attr(n, "property") == "og:title" && ogTitle == "" {
+					ogTitle = attr(n, "content")
+				}
+			case atom.Title:
+				if docTitle == "" {
+					docTitle = textContent(n)
+				}
+			case atom.H1:
+				if h1 == "" {
+					h1 = textContent(n)
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+	for _, t := range []string{ogTitle, docTitle, h1} {
+		if t = strings.TrimSpace(spaceRun.ReplaceAllString(t, " ")); t != "" {
+			return t
+		}
+	}
+	return ""
+}
+
+// Elements that never hold article content. They are removed before the
+// content is chosen.
+var boilerplate = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true,
+	atom.Form: true, atom.Button: true, atom.Iframe: true, atom.Svg: true,
+	atom.Input: true, atom.Select: true, atom.Textarea: true, atom.Head: true,
+}
+
+// Class and id fragments that mark page chrome rather than content.
+var chrome = regexp.MustCompile(`(?i)\b(?:comment|sidebar|share|social|related|promo|advert|cookie|newsletter|breadcrumb|menu)s?\b`)
+
+// mainContent returns the element holding the page's article: the largest
+// <article>, else <main>, else the container with the most paragraph text.
+func mainContent(root *html.Node) *html.Node {
+	strip(root)
+
+	var articles []*html.Node
+	var main *html.Node
+	var body *html.Node
+	scores := make(map[*html.Node]int)
+	var scored []*html.Node // in document order, so ties go to the first
+	credit := func(n *html.Node, length int) {
+		if _, ok := scores[n]; !ok {
+			scored = append(scored, n)
+		}
+		scores[n] += length
+	}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Article:
+				articles = append(articles, n)
+			case atom.Main:
+				if main == nil {
+					main = n
+				}
+			case atom.Body:
+				body = n
+			case atom.P, atom.Pre, atom.Blockquote:
+				// Credit the parent fully and the grandparent partly, so a
+				// wrapper around several text blocks wins over each block.
+				length := len(strings.TrimSpace(textContent(n)))
+				if p := n.Parent; p != nil {
+					credit(p, length)
+					if gp := p.Parent; gp != nil {
+						credit(gp, length/2)
+					}
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	if len(articles) > 0 {
+		best := articles[0]
+		for _, a := range articles[1:] {
+			if len(textContent(a)) > len(textContent(best)) {
+				best = a
+			}
+		}
+		return best
+	}
+	if main != nil {
+		return main
+	}
+	var best *html.Node
+	for _, n := range scored {
+		if best == nil || scores[n] > scores[best] {
+			best = n
+		}
+	}
+	if best != nil {
+		return best
+	}
+	if body != nil {
+		return body
+	}
+	return root
+}
+
+// strip removes boilerplate elements and page chrome from n's subtree.
+func strip(n *html.Node) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		switch {
+		case child.Type == html.CommentNode:
+			n.RemoveChild(child)
+		case child.Type == html.ElementNode && (boilerplate[child.DataAtom] || isChrome(child)):
+			n.RemoveChild(child)
+		default:
+			strip(child)
+		}
+		child = next
+	}
+}
+
+func isChrome(n *html.Node) bool {
+	if n.DataAtom == atom.Body || n.DataAtom == atom.Html || n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+		return false
+	}
+	if attr(n, "aria-hidden") == "true" || attr(n, "role") == "navigation" {
+		return true
+	}
+	return chrome.MatchString(attr(n, "class") + " " + attr(n, "id"))
+}
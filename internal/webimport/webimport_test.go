@@ -0,0 +1,143 @@
+package webimport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// png is the smallest valid PNG header, enough for content sniffing.
+var png = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+const articlePage = `<!doctype html>
+<html><head>
+<title>Deploying Services | Example Blog</title>
+<meta property="og:title" content="Deploying Services">
+<script>track()</script>
+</head><body>
+<nav><a href="/">Home</a> <a href="/about">About</a></nav>
+<div class="sidebar"><p>Subscribe to our newsletter for more posts like this one.</p></div>
+<article>
+  <h1>Deploying Services</h1>
+  <p>Start with a <strong>clean</strong> build and read the <a href="/docs/setup">setup guide</a>.</p>
+  <img src="/img/diagram.png" alt="Pipeline">
+  <h2>Steps</h2>
+  <ol><li>Build the <code>release</code> binary</li><li>Ship it<ul><li>to staging</li></ul></li></ol>
+  <pre><code class="language-sh">make release
+./deploy --env=prod</code></pre>
+  <blockquote><p>Never deploy on Fridays.</p></blockquote>
+  <table><tr><th>Env</th><th>Region</th></tr><tr><td>prod</td><td>eu-west</td></tr></table>
+  <div class="share-buttons"><a href="#">Tweet</a></div>
+</article>
+<footer>© Example</footer>
+</body></html>`
+
+func newSite(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/post", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(articlePage))
+	})
+	mux.HandleFunc("/img/diagram.png", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(png)
+	})
+	mux.HandleFunc("/data.json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestImportExtractsArticle(t *testing.T) {
+	t.Parallel()
+	site := newSite(t)
+
+	page, err := New(Options{}).Import(context.Background(), site.URL+"/post")
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if page.Title != "Deploying Services" {
+		t.Fatalf("expected the og:title, got %q", page.Title)
+	}
+	if len(page.Images) != 1 || page.Images[0].Name != "diagram.png" || string(page.Images[0].Data) != string(png) {
+		t.Fatalf("expected the diagram to be downloaded, got %+v", page.Images)
+	}
+
+	md := page.Markdown("media/deploying")
+	for _, want := range []string{
+		"# Deploying Services\n\nStart with a **clean** build and read the [setup guide](" + site.URL + "/docs/setup).",
+		"![Pipeline](media/deploying/diagram.png)",
+		"## Steps\n\n1. Build the `release` binary\n2. Ship it\n\n   - to staging",
+		"```sh\nmake release\n./deploy --env=prod\n```",
+		"> Never deploy on Fridays.",
+		"| Env | Region |\n| --- | --- |\n| prod | eu-west |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("expected markdown to contain %q, got:\n%s", want, md)
+		}
+	}
+	for _, unwanted := range []string{"track()", "Home", "newsletter", "Tweet", "© Example"} {
+		if strings.Contains(md, unwanted) {
+			t.Errorf("expected %q to be stripped, got:\n%s", unwanted, md)
+		}
+	}
+}
+
+func TestImportSkipImagesKeepsRemoteLinks(t *testing.T) {
+	t.Parallel()
+	site := newSite(t)
+
+	page, err := New(Options{SkipImages: true}).Import(context.Background(), site.URL+"/post")
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if len(page.Images) != 0 {
+		t.Fatalf("expected no downloads, got %d", len(page.Images))
+	}
+	if md := page.Markdown("media"); !strings.Contains(md, "![Pipeline]("+site.URL+"/img/diagram.png)") {
+		t.Fatalf("expected the remote image URL, got:\n%s", md)
+	}
+}
+
+func TestImportRejectsNonHTML(t *testing.T) {
+	t.Parallel()
+	site := newSite(t)
+
+	_, err := New(Options{}).Import(context.Background(), site.URL+"/data.json")
+	if !errors.Is(err, ErrNotHTML) {
+		t.Fatalf("expected ErrNotHTML, got %v", err)
+	}
+	if _, err := New(Options{}).Import(context.Background(), site.URL+"/missing"); err == nil {
+		t.Fatal("expected an error for a 404")
+	}
+}
+
+func TestMainContentFallsBackToDensestContainer(t *testing.T) {
+	t.Parallel()
+	page := `<html><body>
+<div id="menu"><p>Home</p></div>
+<div class="post"><p>First paragraph with plenty of words in it.</p><p>Second paragraph, also long enough.</p></div>
+<div class="teaser"><p>Short.</p></div>
+</body></html>`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(page))
+	}))
+	t.Cleanup(srv.Close)
+
+	got, err := New(Options{}).Import(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	want := "First paragraph with plenty of words in it.\n\nSecond paragraph, also long enough.\n"
+	if md := got.Markdown("media"); md != want {
+		t.Fatalf("expected %q, got %q", want, md)
+	}
+}
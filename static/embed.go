@@ -2,12 +2,15 @@
 package static
 
 import (
+	"crypto/sha512"
 	"embed"
+	"encoding/base64"
 	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 //go:embed css/*.css js/*.js js/chunks/*.js vendor/*
@@ -63,3 +66,33 @@ func ensureDir(target string) error {
 func writeFile(path string, data []byte) error {
 	return os.WriteFile(path, data, 0o644) //nolint:gosec // standard file permissions
 }
+
+// SRI formats data's SHA-384 digest as a Subresource Integrity value.
+func SRI(data []byte) string {
+	sum := sha512.Sum384(data)
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// integrity maps each embedded asset to its SRI value. The assets never
+// change at runtime, so the hashes are computed once.
+var integrity = sync.OnceValue(func() map[string]string {
+	sums := make(map[string]string)
+	_ = fs.WalkDir(assets, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(assets, path)
+		if err != nil {
+			return err
+		}
+		sums[path] = SRI(data)
+		return nil
+	})
+	return sums
+})
+
+// Integrity returns the SRI value of an embedded asset, or "" when there is
+// no such asset.
+func Integrity(name string) string {
+	return integrity()[strings.TrimPrefix(name, "/")]
+}
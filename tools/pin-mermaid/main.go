@@ -0,0 +1,202 @@
+// Package main pins the vendored Mermaid bundle to a release.
+//
+// It downloads mermaid.min.js for the requested version into static/vendor,
+// checks it against the expected SHA-256, and records the version and
+// checksum in vendor.json and internal/buildinfo so exports can say which
+// Mermaid drew their diagrams. Run it from the repository root:
+//
+//	go run ./tools/pin-mermaid -version 11.4.1 [-sha256 <hex>]
+//	go run ./tools/pin-mermaid -verify
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	vendorFile    = "vendor.json"
+	buildinfoFile = "internal/buildinfo/mermaid.go"
+	urlPattern    = "https://cdn.jsdelivr.net/npm/mermaid@%s/dist/mermaid.min.js"
+	maxBundleSize = 16 << 20
+)
+
+// vendorEntry is one package in vendor.json, which web/build.js also reads.
+type vendorEntry struct {
+	Version string `json:"version"`
+	URL     string `json:"url"`
+	Output  string `json:"output"`
+	SHA256  string `json:"sha256,omitempty"`
+}
+
+func main() {
+	version := flag.String("version", "", "Mermaid release to pin, e.g. 11.4.1")
+	sum := flag.String("sha256", "", "expected hex SHA-256 of mermaid.min.js (default: trust the download, unless re-pinning the same version)")
+	verify := flag.Bool("verify", false, "check the vendored bundle against the pinned checksum without downloading")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	var err error
+	switch {
+	case *verify:
+		err = verifyPinned()
+	case *version == "":
+		err = errors.New("-version or -verify is required")
+	default:
+		err = pin(ctx, strings.TrimPrefix(*version, "v"), strings.ToLower(strings.TrimSpace(*sum)))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pin-mermaid: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func pin(ctx context.Context, version, want string) error {
+	vendors, err := readVendors()
+	if err != nil {
+		return err
+	}
+	entry := vendors["mermaid"]
+	if entry.Output == "" {
+		entry.Output = "static/vendor/mermaid.min.js"
+	}
+	// Re-pinning the current version must produce the bytes already pinned;
+	// anything else means the CDN served something new under an old tag.
+	if want == "" && entry.Version == version {
+		want = entry.SHA256
+	}
+
+	entry.Version = version
+	entry.URL = fmt.Sprintf(urlPattern, version)
+	body, err := download(ctx, entry.URL)
+	if err != nil {
+		return err
+	}
+	got := checksum(body)
+	if want != "" && got != want {
+		return fmt.Errorf("checksum mismatch for mermaid %s: got %s, want %s", version, got, want)
+	}
+	entry.SHA256 = got
+
+	if err := os.MkdirAll(filepath.Dir(entry.Output), 0o755); err != nil { //nolint:gosec // standard directory permissions
+		return err
+	}
+	if err := os.WriteFile(entry.Output, body, 0o644); err != nil { //nolint:gosec // standard file permissions
+		return err
+	}
+	vendors["mermaid"] = entry
+	if err := writeVendors(vendors); err != nil {
+		return err
+	}
+	if err := writeBuildinfo(entry); err != nil {
+		return err
+	}
+	fmt.Printf("pinned mermaid %s (sha256 %s)\n", version, got)
+	return nil
+}
+
+func verifyPinned() error {
+	vendors, err := readVendors()
+	if err != nil {
+		return err
+	}
+	entry, ok := vendors["mermaid"]
+	if !ok || entry.SHA256 == "" {
+		return fmt.Errorf("%s pins no mermaid checksum; run with -version first", vendorFile)
+	}
+	body, err := os.ReadFile(entry.Output)
+	if err != nil {
+		return err
+	}
+	if got := checksum(body); got != entry.SHA256 {
+		return fmt.Errorf("%s does not match mermaid %s: got %s, want %s", entry.Output, entry.Version, got, entry.SHA256)
+	}
+	fmt.Printf("%s matches mermaid %s\n", entry.Output, entry.Version)
+	return nil
+}
+
+func download(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download %s: %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBundleSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", url, err)
+	}
+	if len(body) > maxBundleSize {
+		return nil, fmt.Errorf("download %s: larger than %d bytes", url, maxBundleSize)
+	}
+	return body, nil
+}
+
+func checksum(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func readVendors() (map[string]vendorEntry, error) {
+	raw, err := os.ReadFile(vendorFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w (run from the repository root)", err)
+	}
+	vendors := map[string]vendorEntry{}
+	if err := json.Unmarshal(raw, &vendors); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", vendorFile, err)
+	}
+	return vendors, nil
+}
+
+func writeVendors(vendors map[string]vendorEntry) error {
+	raw, err := json.MarshalIndent(vendors, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(vendorFile, append(raw, '\n'), 0o644) //nolint:gosec // standard file permissions
+}
+
+func writeBuildinfo(entry vendorEntry) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `// Code generated by go run ./tools/pin-mermaid; DO NOT EDIT.
+
+package buildinfo
+
+// MermaidVersion is the Mermaid release vendored into static/vendor, and
+// MermaidSHA256 the checksum of its mermaid.min.js.
+const (
+	MermaidVersion = %q
+	MermaidSHA256  = %q
+)
+`, entry.Version, entry.SHA256)
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(buildinfoFile, src, 0o644) //nolint:gosec // standard file permissions
+}
@@ -1,5 +1,6 @@
 import { watch, existsSync, mkdirSync, readFileSync, writeFileSync, rmSync } from "fs";
 import { resolve, dirname } from "path";
+import { createHash } from "crypto";
 import { fileURLToPath } from "url";
 
 const __dirname = dirname(fileURLToPath(import.meta.url));
@@ -47,10 +48,10 @@ async function downloadVendors() {
   for (const [name, config] of Object.entries(vendorConfig)) {
     const outputPath = resolve(__dirname, "..", config.output);
 
-    // Skip if file already exists and is not empty
+    // Skip if file already exists, is not empty, and matches any pinned checksum
     if (existsSync(outputPath)) {
       const stats = await Bun.file(outputPath).size;
-      if (stats > 0) {
+      if (stats > 0 && (!config.sha256 || sha256(readFileSync(outputPath)) === config.sha256)) {
         skipCount++;
         continue;
       }
@@ -64,7 +65,10 @@ async function downloadVendors() {
         throw new Error(`HTTP ${response.status}: ${response.statusText}`);
       }
 
-      const content = await response.text();
+      const content = Buffer.from(await response.arrayBuffer());
+      if (config.sha256 && sha256(content) !== config.sha256) {
+        throw new Error(`checksum mismatch (want ${config.sha256}); re-pin with tools/pin-mermaid`);
+      }
       writeFileSync(outputPath, content);
       downloadCount++;
       console.log(`✓ ${name} downloaded successfully`);
@@ -82,6 +86,10 @@ async function downloadVendors() {
   }
 }
 
+function sha256(data) {
+  return createHash("sha256").update(data).digest("hex");
+}
+
 function cleanChunks() {
   const chunksDir = resolve(__dirname, "../static/js/chunks");
   if (existsSync(chunksDir)) {
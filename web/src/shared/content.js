@@ -1,3 +1,5 @@
+import { addLinkPreviews } from "./link-preview";
+
 const MERMAID_OVERLAY_VISIBLE_CLASS = "mermaid-overlay-open";
 const OVERLAY_MIN_SCALE = 0.25;
 const OVERLAY_MAX_SCALE = 5;
@@ -27,6 +29,7 @@ export function enhanceContent(element) {
   }
 
   normaliseInternalLinks(element);
+  addLinkPreviews(element);
   addCopyButtonsToCodeBlocks(element);
   renderMermaid(element);
   enhanceD2(element);
@@ -54,9 +57,65 @@ function enhanceD2(element) {
       const button = createDiagramExpandButton(() => openD2Overlay(block));
       block.appendChild(button);
     }
+
+    const source = decodeBase64Source(block.dataset.sourceB64);
+    if (source && !block.querySelector(".diagram-source-button")) {
+      addDiagramSourceToggle(block, block, source, "d2");
+    }
   });
 }
 
+function decodeBase64Source(encoded) {
+  if (!encoded) {
+    return "";
+  }
+  try {
+    const bytes = Uint8Array.from(atob(encoded), (c) => c.charCodeAt(0));
+    return new TextDecoder().decode(bytes);
+  } catch (err) {
+    console.error("decode diagram source failed:", err);
+    return "";
+  }
+}
+
+let diagramSourceCounter = 0;
+
+/**
+ * Add a "view source" button to a diagram that reveals its original fenced
+ * code below it, with the usual copy button, so readers can adapt it.
+ */
+function addDiagramSourceToggle(container, diagram, source, language) {
+  const panel = document.createElement("div");
+  panel.className = "diagram-source";
+  panel.id = `diagram-source-${++diagramSourceCounter}`;
+  panel.hidden = true;
+
+  const pre = document.createElement("pre");
+  const code = document.createElement("code");
+  code.className = `language-${language}`;
+  code.textContent = source;
+  pre.appendChild(code);
+  panel.appendChild(pre);
+  diagram.insertAdjacentElement("afterend", panel);
+  addCopyButtonsToCodeBlocks(panel);
+
+  const button = document.createElement("button");
+  button.type = "button";
+  button.className = "diagram-source-button";
+  button.setAttribute("aria-controls", panel.id);
+  button.setAttribute("aria-expanded", "false");
+  button.setAttribute("title", "View diagram source");
+  button.textContent = "</>";
+  button.addEventListener("click", (event) => {
+    event.preventDefault();
+    event.stopPropagation();
+    panel.hidden = !panel.hidden;
+    button.setAttribute("aria-expanded", String(!panel.hidden));
+    button.setAttribute("title", panel.hidden ? "View diagram source" : "Hide diagram source");
+  });
+  container.appendChild(button);
+}
+
 // Theme variables for mermaid
 const lightThemeVars = {
   primaryColor: "#ffffff",
@@ -257,6 +316,15 @@ function ensureMermaidContainer(element) {
     wrapper.appendChild(button);
   }
 
+  if (wrapper && !wrapper.querySelector(".diagram-source-button")) {
+    // Capture the source before mermaid replaces it with the SVG.
+    const source = element.dataset.mermaidSource || element.textContent.trim();
+    if (source) {
+      element.dataset.mermaidSource = source;
+      addDiagramSourceToggle(wrapper, element, source, "mermaid");
+    }
+  }
+
   return wrapper;
 }
 
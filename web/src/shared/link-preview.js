@@ -0,0 +1,142 @@
+// Hover cards for links between pages, filled from /api/page/{path}/summary.
+
+const PREVIEW_DELAY = 350; // ms of hovering before the card is fetched
+const PREVIEW_HIDE_DELAY = 150; // ms to move the pointer onto the card
+
+const summaries = new Map();
+let card = null;
+let showTimer = null;
+let hideTimer = null;
+let activeAnchor = null;
+
+export function addLinkPreviews(root) {
+  if (!root || !window.htmx) {
+    // Static exports have no API to ask.
+    return;
+  }
+  root.querySelectorAll("a[data-page-path]").forEach((anchor) => {
+    if (anchor.dataset.linkPreview === "true") {
+      return;
+    }
+    anchor.dataset.linkPreview = "true";
+    anchor.addEventListener("mouseenter", () => schedulePreview(anchor));
+    anchor.addEventListener("focus", () => schedulePreview(anchor));
+    anchor.addEventListener("mouseleave", scheduleHide);
+    anchor.addEventListener("blur", scheduleHide);
+    anchor.addEventListener("click", hidePreview);
+  });
+}
+
+function schedulePreview(anchor) {
+  clearTimeout(hideTimer);
+  clearTimeout(showTimer);
+  showTimer = setTimeout(() => showPreview(anchor), PREVIEW_DELAY);
+}
+
+function scheduleHide() {
+  clearTimeout(showTimer);
+  clearTimeout(hideTimer);
+  hideTimer = setTimeout(hidePreview, PREVIEW_HIDE_DELAY);
+}
+
+async function showPreview(anchor) {
+  const path = anchor.dataset.pagePath;
+  if (!path || !document.body.contains(anchor)) {
+    return;
+  }
+  activeAnchor = anchor;
+  let summary = summaries.get(path);
+  if (summary === undefined) {
+    summary = await fetchSummary(path);
+    summaries.set(path, summary);
+  }
+  if (!summary || activeAnchor !== anchor) {
+    return;
+  }
+  renderCard(summary);
+  positionCard(anchor);
+}
+
+async function fetchSummary(path) {
+  const encoded = path.split("/").map(encodeURIComponent).join("/");
+  try {
+    const response = await fetch(`/api/page/${encoded}/summary`, {
+      headers: { Accept: "application/json" },
+    });
+    if (!response.ok) {
+      return null;
+    }
+    return await response.json();
+  } catch {
+    return null;
+  }
+}
+
+function ensureCard() {
+  if (card) {
+    return card;
+  }
+  card = document.createElement("div");
+  card.className = "link-preview";
+  card.setAttribute("role", "tooltip");
+  card.hidden = true;
+  card.addEventListener("mouseenter", () => clearTimeout(hideTimer));
+  card.addEventListener("mouseleave", scheduleHide);
+  document.body.appendChild(card);
+  return card;
+}
+
+function renderCard(summary) {
+  const el = ensureCard();
+  el.replaceChildren();
+  if (summary.thumbnail) {
+    const img = document.createElement("img");
+    img.className = "link-preview-image";
+    img.src = summary.thumbnail;
+    img.alt = "";
+    img.loading = "lazy";
+    el.appendChild(img);
+  }
+  const title = document.createElement("div");
+  title.className = "link-preview-title";
+  title.textContent = summary.title;
+  el.appendChild(title);
+
+  const text = summary.description || summary.excerpt;
+  if (text) {
+    const excerpt = document.createElement("p");
+    excerpt.className = "link-preview-excerpt";
+    excerpt.textContent = text;
+    el.appendChild(excerpt);
+  }
+  el.hidden = false;
+}
+
+function positionCard(anchor) {
+  const rect = anchor.getBoundingClientRect();
+  const width = card.offsetWidth;
+  const height = card.offsetHeight;
+  const margin = 8;
+  let left = Math.min(rect.left, window.innerWidth - width - margin);
+  left = Math.max(left, margin);
+  let top = rect.bottom + margin;
+  if (top + height > window.innerHeight - margin) {
+    top = rect.top - height - margin;
+  }
+  card.style.left = `${left + window.scrollX}px`;
+  card.style.top = `${Math.max(top, margin) + window.scrollY}px`;
+}
+
+function hidePreview() {
+  clearTimeout(showTimer);
+  activeAnchor = null;
+  if (card) {
+    card.hidden = true;
+  }
+}
+
+// Summaries are fetched again after navigation so cards pick up edits.
+document.addEventListener("htmx:afterSwap", () => {
+  summaries.clear();
+  hidePreview();
+});
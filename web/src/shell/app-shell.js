@@ -37,7 +37,21 @@ function connectEvents(pendingScrollRef) {
     try {
       const payload = JSON.parse(event.data);
       switch (payload.type) {
+        case "job":
+          document.body.dispatchEvent(new CustomEvent("jobUpdated", { detail: payload.job }));
+          break;
+        case "indexing":
+          document.querySelectorAll("[data-indexing-count]").forEach((el) => {
+            el.textContent = String(payload.scanned || 0);
+          });
+          break;
         case "treeUpdated":
+          // The first tree after a background build replaces the empty
+          // state, so reload to land on the first document.
+          if (!currentPath() && document.querySelector("[data-indexing]")) {
+            window.location.reload();
+            break;
+          }
           fetchTree();
           break;
         case "pageUpdated":